@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -55,9 +56,10 @@ func (d *Daemon) Start() error {
 	defer ctx.Cancel()
 	logger := logging.LogWithContext(ctx)
 
-	logger.Info("Starting read-only MCP LNC Server daemon",
+	logger.Info("Starting MCP LNC Server daemon",
 		zap.String("version", d.cfg.ServerVersion),
 		zap.Bool("development", d.cfg.Development),
+		zap.Bool("write_mode", d.cfg.WriteMode),
 	)
 
 	// Start the server in a goroutine.
@@ -153,16 +155,51 @@ func (d *Daemon) shutdownHandler() {
 
 // main is the entry point for the MCP LNC server daemon.
 func main() {
+	// Subcommands take their own flags.
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		err := runLoadTest(os.Args[2:])
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "loadtest: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "scenario" {
+		err := runScenarios(os.Args[2:])
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errScenariosFailed):
+			os.Exit(1)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "scenario: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Parse command line flags
 	var version = flag.Bool("version", false, "Show version information")
+	var writeMode = flag.Bool("write", false,
+		"Enable state-changing tools (overrides LNC_WRITE_MODE)")
 	flag.Parse()
 
 	// Load configuration
 	cfg := config.LoadConfig()
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "write" {
+			cfg.WriteMode = *writeMode
+		}
+	})
 
 	// Handle version flag
 	if *version {
-		fmt.Printf("MCP LNC Server %s (Read-Only)\n", cfg.ServerVersion)
+		mode := "Read-Only"
+		if cfg.WriteMode {
+			mode = "Write Mode"
+		}
+		fmt.Printf("MCP LNC Server %s (%s)\n", cfg.ServerVersion, mode)
 		fmt.Println("Lightning Network integration for AI assistants")
 		fmt.Println("https://github.com/jbrill/mcp-lnc-server")
 		os.Exit(0)
@@ -0,0 +1,331 @@
+// Package anomaly watches tool calls for patterns that suggest a
+// compromised or misbehaving client, such as a burst of graph dumps,
+// repeated refused writes, writes outside the operator's active hours, and
+// the use of planted honeytokens, and reports them as alerts.
+package anomaly
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule names, as alerts report them.
+const (
+	// RuleGraphDumpSpike fires when the network graph is dumped more often
+	// than the configured limit allows.
+	RuleGraphDumpSpike = "graph_dump_spike"
+
+	// RuleDeniedWrites fires when a session's write calls are refused
+	// repeatedly.
+	RuleDeniedWrites = "repeated_denied_writes"
+
+	// RuleUnusualHours fires when a write tool is called outside the
+	// operator's active hours.
+	RuleUnusualHours = "unusual_hours"
+
+	// RuleHoneytoken fires when a call's arguments contain a honeytoken.
+	RuleHoneytoken = "honeytoken"
+)
+
+// graphDumpTool is the tool that describes the network graph, and
+// graphDumpTools every tool whose calls count as graph dumps.
+const graphDumpTool = "lnc_describe_graph"
+
+var graphDumpTools = map[string]bool{
+	graphDumpTool:                 true,
+	"lnc_export_anonymized_graph": true,
+}
+
+// Default windows for the counting rules.
+const (
+	DefaultGraphDumpWindow   = time.Hour
+	DefaultDeniedWriteWindow = 10 * time.Minute
+)
+
+// unusualHoursCooldown is how long a session's writes outside active hours
+// go unreported after one alert, so a batch of writes raises one alert.
+const unusualHoursCooldown = time.Hour
+
+// Config selects the rules a Detector applies. The zero Config applies
+// none.
+type Config struct {
+	// GraphDumps is how many graph dumps within GraphDumpWindow raise an
+	// alert. Zero disables the rule.
+	GraphDumps      int
+	GraphDumpWindow time.Duration
+
+	// DeniedWrites is how many refused write calls from one session
+	// within DeniedWriteWindow raise an alert. Zero disables the rule.
+	DeniedWrites      int
+	DeniedWriteWindow time.Duration
+
+	// ActiveHours is the operator's working day as "HH-HH" in Location,
+	// such as "08-20", or "22-06" across midnight. Write calls outside it
+	// raise an alert. Empty disables the rule.
+	ActiveHours string
+	Location    *time.Location
+
+	// Honeytokens are strings planted where only an intruder would find
+	// them, such as a decoy invoice or node pubkey. Any call whose
+	// arguments contain one raises an alert.
+	Honeytokens []string
+}
+
+// Call is one completed tool call, as the detector sees it.
+type Call struct {
+	Tool      string
+	SessionID string
+	Arguments map[string]any
+
+	// Write is set for tools that change state, and Denied for calls
+	// refused with a permission error.
+	Write  bool
+	Denied bool
+
+	At time.Time
+}
+
+// Alert reports a rule a call tripped.
+type Alert struct {
+	Rule      string
+	Tool      string
+	SessionID string
+	Message   string
+	Details   map[string]any
+}
+
+// Detector applies the configured rules to the calls it observes. A nil
+// Detector observes nothing.
+type Detector struct {
+	cfg Config
+
+	// startHour and endHour bound the active hours when the rule is on.
+	activeHours        bool
+	startHour, endHour int
+
+	mu sync.Mutex
+
+	// Recent graph dumps, and each session's recent refused writes.
+	graphDumps   []time.Time
+	deniedWrites map[string][]time.Time
+
+	// When each session was last reported for writing outside active
+	// hours.
+	offHours map[string]time.Time
+}
+
+// NewDetector validates cfg and returns a detector applying its rules.
+func NewDetector(cfg Config) (*Detector, error) {
+	if cfg.GraphDumps < 0 || cfg.DeniedWrites < 0 {
+		return nil, fmt.Errorf("anomaly limits must not be negative")
+	}
+	if cfg.GraphDumpWindow <= 0 {
+		cfg.GraphDumpWindow = DefaultGraphDumpWindow
+	}
+	if cfg.DeniedWriteWindow <= 0 {
+		cfg.DeniedWriteWindow = DefaultDeniedWriteWindow
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	d := &Detector{
+		cfg:          cfg,
+		deniedWrites: make(map[string][]time.Time),
+		offHours:     make(map[string]time.Time),
+	}
+	if cfg.ActiveHours != "" {
+		start, end, err := parseHours(cfg.ActiveHours)
+		if err != nil {
+			return nil, err
+		}
+		d.activeHours, d.startHour, d.endHour = true, start, end
+	}
+
+	return d, nil
+}
+
+// parseHours parses active hours of the form "HH-HH".
+func parseHours(hours string) (int, int, error) {
+	startText, endText, ok := strings.Cut(hours, "-")
+	start, startErr := strconv.Atoi(strings.TrimSpace(startText))
+	end, endErr := strconv.Atoi(strings.TrimSpace(endText))
+	if !ok || startErr != nil || endErr != nil || start < 0 ||
+		start > 23 || end < 0 || end > 24 || start == end {
+		return 0, 0, fmt.Errorf("invalid active hours %q: want HH-HH, "+
+			"such as 08-20", hours)
+	}
+	return start, end, nil
+}
+
+// Observe applies the rules to a call and returns the alerts it raises.
+func (d *Detector) Observe(call Call) []Alert {
+	if d == nil {
+		return nil
+	}
+	if call.At.IsZero() {
+		call.At = time.Now()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var alerts []Alert
+	for _, rule := range []func(Call) *Alert{
+		d.honeytoken, d.graphDumpSpike, d.deniedWritesRule,
+		d.unusualHours,
+	} {
+		if alert := rule(call); alert != nil {
+			alert.Tool, alert.SessionID = call.Tool, call.SessionID
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	return alerts
+}
+
+// honeytoken reports a call whose arguments contain a honeytoken. Every
+// such call is reported.
+func (d *Detector) honeytoken(call Call) *Alert {
+	for name, value := range call.Arguments {
+		for _, token := range d.cfg.Honeytokens {
+			if token != "" && containsToken(value, token) {
+				return &Alert{
+					Rule: RuleHoneytoken,
+					Message: "a call used a honeytoken; the client " +
+						"has seen data planted for intruders",
+					Details: map[string]any{"argument": name},
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// containsToken reports whether token appears in value or, for lists and
+// objects, in any value nested in it.
+func containsToken(value any, token string) bool {
+	switch value := value.(type) {
+	case string:
+		return strings.Contains(value, token)
+	case []any:
+		for _, item := range value {
+			if containsToken(item, token) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range value {
+			if containsToken(item, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// graphDumpSpike reports the graph dump that brings the dumps within the
+// window to the limit. The count then starts again, so a steady stream of
+// dumps is reported once per limit's worth.
+func (d *Detector) graphDumpSpike(call Call) *Alert {
+	if d.cfg.GraphDumps == 0 || !graphDumpTools[call.Tool] {
+		return nil
+	}
+
+	d.graphDumps = append(recent(d.graphDumps, call.At,
+		d.cfg.GraphDumpWindow), call.At)
+	if len(d.graphDumps) < d.cfg.GraphDumps {
+		return nil
+	}
+
+	count := len(d.graphDumps)
+	d.graphDumps = nil
+	return &Alert{
+		Rule: RuleGraphDumpSpike,
+		Message: fmt.Sprintf("the network graph was dumped %d times "+
+			"within %s", count, d.cfg.GraphDumpWindow),
+		Details: map[string]any{
+			"count":  count,
+			"window": d.cfg.GraphDumpWindow.String(),
+		},
+	}
+}
+
+// deniedWritesRule reports the refused write that brings a session's
+// refused writes within the window to the limit, then starts the count
+// again.
+func (d *Detector) deniedWritesRule(call Call) *Alert {
+	if d.cfg.DeniedWrites == 0 || !call.Write || !call.Denied {
+		return nil
+	}
+
+	denied := append(recent(d.deniedWrites[call.SessionID], call.At,
+		d.cfg.DeniedWriteWindow), call.At)
+	if len(denied) < d.cfg.DeniedWrites {
+		d.deniedWrites[call.SessionID] = denied
+		return nil
+	}
+
+	delete(d.deniedWrites, call.SessionID)
+	return &Alert{
+		Rule: RuleDeniedWrites,
+		Message: fmt.Sprintf("%d write calls from one session were "+
+			"refused within %s", len(denied),
+			d.cfg.DeniedWriteWindow),
+		Details: map[string]any{
+			"count":  len(denied),
+			"window": d.cfg.DeniedWriteWindow.String(),
+		},
+	}
+}
+
+// unusualHours reports a write call outside the active hours, at most once
+// per session an hour.
+func (d *Detector) unusualHours(call Call) *Alert {
+	if !d.activeHours || !call.Write {
+		return nil
+	}
+
+	at := call.At.In(d.cfg.Location)
+	hour := at.Hour()
+	active := hour >= d.startHour && hour < d.endHour
+	if d.startHour > d.endHour {
+		active = hour >= d.startHour || hour < d.endHour
+	}
+	if active {
+		return nil
+	}
+
+	last, reported := d.offHours[call.SessionID]
+	if reported && call.At.Sub(last) < unusualHoursCooldown {
+		return nil
+	}
+	d.offHours[call.SessionID] = call.At
+
+	return &Alert{
+		Rule: RuleUnusualHours,
+		Message: fmt.Sprintf("a write tool was called at %s, outside "+
+			"the active hours %s", at.Format("15:04 MST"),
+			d.cfg.ActiveHours),
+		Details: map[string]any{
+			"at":           at.Format(time.RFC3339),
+			"active_hours": d.cfg.ActiveHours,
+		},
+	}
+}
+
+// recent returns the times within window before now, reusing times.
+func recent(times []time.Time, now time.Time,
+	window time.Duration) []time.Time {
+	cutoff := now.Add(-window)
+	kept := times[:0]
+	for _, at := range times {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,164 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var noon = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+// Test that the zero config raises no alerts, and a nil detector none.
+func TestObserve_NoRules(t *testing.T) {
+	detector, err := NewDetector(Config{})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		assert.Empty(t, detector.Observe(Call{
+			Tool: graphDumpTool, Write: true, Denied: true,
+			At: noon.Add(-10 * time.Hour),
+		}))
+	}
+
+	var none *Detector
+	assert.Empty(t, none.Observe(Call{Tool: graphDumpTool}))
+}
+
+// Test that graph dumps alert when the limit is reached within the window.
+func TestObserve_GraphDumpSpike(t *testing.T) {
+	detector, err := NewDetector(Config{
+		GraphDumps:      3,
+		GraphDumpWindow: time.Minute,
+	})
+	require.NoError(t, err)
+
+	dump := func(at time.Time) []Alert {
+		return detector.Observe(Call{Tool: graphDumpTool, At: at})
+	}
+
+	// Dumps spread beyond the window do not alert.
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, dump(noon.Add(time.Duration(i)*time.Minute)))
+	}
+
+	// Other tools do not count.
+	assert.Empty(t, detector.Observe(Call{Tool: "lnc_get_info", At: noon}))
+
+	// Anonymized exports are dumps too.
+	start := noon.Add(time.Hour)
+	assert.Empty(t, dump(start))
+	assert.Empty(t, detector.Observe(Call{
+		Tool: "lnc_export_anonymized_graph", At: start.Add(time.Second),
+	}))
+	alerts := dump(start.Add(2 * time.Second))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, RuleGraphDumpSpike, alerts[0].Rule)
+	assert.Equal(t, graphDumpTool, alerts[0].Tool)
+	assert.Equal(t, 3, alerts[0].Details["count"])
+
+	// The count starts again after an alert.
+	assert.Empty(t, dump(start.Add(3*time.Second)))
+}
+
+// Test that refused writes alert per session.
+func TestObserve_DeniedWrites(t *testing.T) {
+	detector, err := NewDetector(Config{DeniedWrites: 2})
+	require.NoError(t, err)
+
+	denied := func(session string, write bool) []Alert {
+		return detector.Observe(Call{
+			Tool: "lnc_pay_invoice", SessionID: session,
+			Write: write, Denied: true, At: noon,
+		})
+	}
+
+	assert.Empty(t, denied("a", true))
+	assert.Empty(t, denied("b", true))
+	assert.Empty(t, denied("a", false))
+	alerts := denied("a", true)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, RuleDeniedWrites, alerts[0].Rule)
+	assert.Equal(t, "a", alerts[0].SessionID)
+
+	// Successful writes do not count.
+	assert.Empty(t, detector.Observe(Call{
+		Tool: "lnc_pay_invoice", SessionID: "b", Write: true, At: noon,
+	}))
+}
+
+// Test that writes outside active hours alert once per session an hour,
+// including across midnight.
+func TestObserve_UnusualHours(t *testing.T) {
+	detector, err := NewDetector(Config{
+		ActiveHours: "08-20",
+		Location:    time.UTC,
+	})
+	require.NoError(t, err)
+
+	write := func(session string, at time.Time) []Alert {
+		return detector.Observe(Call{
+			Tool: "lnc_send_coins", SessionID: session, Write: true,
+			At: at,
+		})
+	}
+
+	night := noon.Add(-9 * time.Hour)
+	assert.Empty(t, write("a", noon))
+	assert.Empty(t, detector.Observe(Call{Tool: "lnc_get_info", At: night}))
+	alerts := write("a", night)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, RuleUnusualHours, alerts[0].Rule)
+	assert.Empty(t, write("a", night.Add(time.Minute)))
+	assert.Len(t, write("b", night), 1)
+	assert.Len(t, write("a", night.Add(time.Hour)), 1)
+
+	overnight, err := NewDetector(Config{
+		ActiveHours: "22-06",
+		Location:    time.UTC,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, overnight.Observe(Call{Write: true, At: night}))
+	assert.Len(t, overnight.Observe(Call{Write: true, At: noon}), 1)
+}
+
+// Test that honeytokens alert wherever they appear in the arguments.
+func TestObserve_Honeytoken(t *testing.T) {
+	detector, err := NewDetector(Config{
+		Honeytokens: []string{"lnbc1decoy", ""},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, detector.Observe(Call{
+		Tool:      "lnc_decode_invoice",
+		Arguments: map[string]any{"invoice": "lnbc1real"},
+	}))
+
+	for _, args := range []map[string]any{
+		{"invoice": "lnbc1decoy"},
+		{"invoices": []any{"lnbc1real", "LIGHTNING:lnbc1decoy"}},
+		{"route": map[string]any{"hint": "lnbc1decoy"}},
+	} {
+		alerts := detector.Observe(Call{
+			Tool: "lnc_decode_invoice", Arguments: args,
+		})
+		require.Len(t, alerts, 1)
+		assert.Equal(t, RuleHoneytoken, alerts[0].Rule)
+	}
+}
+
+// Test that invalid configurations are rejected.
+func TestNewDetector_Invalid(t *testing.T) {
+	for _, cfg := range []Config{
+		{GraphDumps: -1},
+		{DeniedWrites: -1},
+		{ActiveHours: "8"},
+		{ActiveHours: "08-08"},
+		{ActiveHours: "24-06"},
+		{ActiveHours: "morning-evening"},
+	} {
+		_, err := NewDetector(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
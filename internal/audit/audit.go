@@ -0,0 +1,356 @@
+// Package audit records tool invocations as JSON lines so operators can
+// review what an assistant did and correlate each entry with the MCP
+// request that triggered it.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single audit record.
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Tool  string    `json:"tool,omitempty"`
+
+	// MCPRequestID and ProgressToken identify the JSON-RPC call that
+	// triggered the entry, and SessionID the client session it came from.
+	MCPRequestID  any    `json:"mcp_request_id,omitempty"`
+	ProgressToken any    `json:"progress_token,omitempty"`
+	SessionID     string `json:"session_id,omitempty"`
+
+	// TraceID matches the trace_id field in the server's logs.
+	TraceID string `json:"trace_id,omitempty"`
+
+	// Amount and Destination summarise what a write tool moves and where.
+	Amount      any    `json:"amount,omitempty"`
+	Destination string `json:"destination,omitempty"`
+
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	IsError    bool           `json:"is_error"`
+	DurationMS int64          `json:"duration_ms"`
+
+	// Details carries the fields of events that are not tool calls.
+	Details map[string]any `json:"details,omitempty"`
+}
+
+const (
+	// EventToolCall is the event name for a completed tool call.
+	EventToolCall = "tool_call"
+
+	// EventResourceRead is emitted for every resource read, under the
+	// tool whose results the resource pages through.
+	EventResourceRead = "resource_read"
+
+	// EventFirstWrite is emitted the first time a write tool is invoked
+	// in a client session, as a tripwire for unexpected automation.
+	EventFirstWrite = "first_write_tool_call"
+
+	// EventOperationsReconciled is emitted when journaled operations are
+	// checked against the node after a restart or reconnect, summarising
+	// those that finished while the server was not tracking them.
+	EventOperationsReconciled = "operations_reconciled"
+
+	// EventAnomaly is emitted when a tool call trips an anomaly rule,
+	// such as the use of a honeytoken.
+	EventAnomaly = "anomaly_detected"
+
+	// EventWatchedNodeChanged is emitted for each change a check finds
+	// to a node on the watchlist, such as a new fee policy.
+	EventWatchedNodeChanged = "watched_node_changed"
+)
+
+// agePruneInterval is how often a log with a maximum age is pruned.
+const agePruneInterval = time.Hour
+
+// Retention bounds an audit log file. Zero fields leave it unbounded.
+type Retention struct {
+	// MaxAge drops entries older than this.
+	MaxAge time.Duration
+
+	// MaxBytes caps the size of the file. A write that takes the file
+	// past it drops the oldest entries until the file is three quarters
+	// of it, so pruning is not repeated on every write.
+	MaxBytes int64
+}
+
+// PruneResult reports what pruning the log reclaimed.
+type PruneResult struct {
+	// Entries is the number of entries dropped, and Bytes the space
+	// they took.
+	Entries int
+	Bytes   int64
+
+	// Remaining is the size of the file afterwards.
+	Remaining int64
+}
+
+// Logger appends audit entries to a writer as JSON lines. A nil Logger
+// discards entries.
+type Logger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+
+	// path, size and the retention fields are set for file logs.
+	path      string
+	size      int64
+	retention Retention
+	onPrune   func(PruneResult)
+	lastPrune time.Time
+}
+
+// New creates an audit logger that writes to w.
+func New(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// Open creates an audit logger that appends to the file at path, creating
+// it with owner-only permissions if needed.
+func Open(path string) (*Logger, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Logger{
+		w:      file,
+		closer: file,
+		path:   path,
+		size:   info.Size(),
+	}, nil
+}
+
+// openFile opens an audit log file for appending.
+func openFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+}
+
+// SetRetention bounds a file log, which is then pruned as entries are
+// written. onPrune, if set, is told of every prune that dropped entries.
+// It has no effect on loggers that do not write to a file.
+func (l *Logger) SetRetention(retention Retention,
+	onPrune func(PruneResult)) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.retention = retention
+	l.onPrune = onPrune
+}
+
+// Log writes an entry. The time is filled in if unset. Entries past the
+// retention are pruned afterwards when due.
+func (l *Logger) Log(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.w.Write(line); err != nil {
+		return err
+	}
+	l.size += int64(len(line))
+
+	if !l.pruneDue() {
+		return nil
+	}
+	result, err := l.prune()
+	if err != nil {
+		return fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	if result.Entries > 0 && l.onPrune != nil {
+		l.onPrune(result)
+	}
+	return nil
+}
+
+// Prune drops the entries of a file log that are past its retention, and
+// reports what that reclaimed.
+func (l *Logger) Prune() (PruneResult, error) {
+	if l == nil {
+		return PruneResult{}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.prune()
+}
+
+// pruneDue reports whether the log has outgrown its size limit, or was
+// last pruned for age over agePruneInterval ago. The caller holds mu.
+func (l *Logger) pruneDue() bool {
+	switch {
+	case l.path == "":
+		return false
+	case l.retention.MaxBytes > 0 && l.size > l.retention.MaxBytes:
+		return true
+	}
+	return l.retention.MaxAge > 0 &&
+		time.Since(l.lastPrune) >= agePruneInterval
+}
+
+// prune rewrites the log without the entries past its retention, replacing
+// the file atomically so a crash leaves either the old or the new log.
+// The caller holds mu.
+func (l *Logger) prune() (PruneResult, error) {
+	result := PruneResult{Remaining: l.size}
+	if l.path == "" || (l.retention.MaxAge <= 0 &&
+		l.retention.MaxBytes <= 0) {
+		return result, nil
+	}
+	l.lastPrune = time.Now()
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return result, err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	// Entries are appended in time order, so those too old lead the
+	// file. A line whose time cannot be read ends the scan, rather than
+	// being dropped unread.
+	first := 0
+	if l.retention.MaxAge > 0 {
+		cutoff := time.Now().Add(-l.retention.MaxAge)
+		for ; first < len(lines); first++ {
+			var stamped struct {
+				Time time.Time `json:"time"`
+			}
+			err := json.Unmarshal(lines[first], &stamped)
+			if err != nil || !stamped.Time.Before(cutoff) {
+				break
+			}
+		}
+	}
+
+	kept := int64(0)
+	for _, line := range lines[first:] {
+		kept += int64(len(line))
+	}
+	if l.retention.MaxBytes > 0 && kept > l.retention.MaxBytes {
+		target := l.retention.MaxBytes / 4 * 3
+		for ; first < len(lines) && kept > target; first++ {
+			kept -= int64(len(lines[first]))
+		}
+	}
+	if first == 0 {
+		return result, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path),
+		filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(tmp.Name())
+	for _, line := range lines[first:] {
+		if _, err := tmp.Write(line); err != nil {
+			_ = tmp.Close()
+			return result, err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return result, err
+	}
+	if err := tmp.Close(); err != nil {
+		return result, err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return result, err
+	}
+
+	// The old handle still points at the replaced file.
+	file, err := openFile(l.path)
+	if err != nil {
+		return result, err
+	}
+	if l.closer != nil {
+		_ = l.closer.Close()
+	}
+	l.w, l.closer = file, file
+
+	result = PruneResult{
+		Entries:   first,
+		Bytes:     int64(len(data)) - kept,
+		Remaining: kept,
+	}
+	l.size = kept
+	return result, nil
+}
+
+// Close closes the underlying file, if any.
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// sensitiveKeyParts flags argument names whose values must never be
+// written to the audit log.
+var sensitiveKeyParts = []string{
+	"password", "phrase", "secret", "macaroon", "preimage", "seed",
+	"passphrase", "token",
+}
+
+// RedactArguments returns a copy of args with sensitive values replaced.
+func RedactArguments(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+
+	redacted := make(map[string]any, len(args))
+	for key, value := range args {
+		redacted[key] = value
+		if SensitiveArgument(key) {
+			redacted[key] = "[REDACTED]"
+		}
+	}
+
+	return redacted
+}
+
+// SensitiveArgument reports whether the value of the argument called name
+// must never be written down.
+func SensitiveArgument(name string) bool {
+	lower := strings.ToLower(name)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
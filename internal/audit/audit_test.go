@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that entries are written as JSON lines with correlation fields.
+func TestLogger_Log(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+
+	require.NoError(t, logger.Log(Entry{
+		Event:         EventToolCall,
+		Tool:          "lnc_get_info",
+		MCPRequestID:  float64(7),
+		ProgressToken: "tok-1",
+		SessionID:     "session-1",
+		TraceID:       "trace-1",
+	}))
+	require.NoError(t, logger.Log(Entry{
+		Event: EventToolCall,
+		Tool:  "lnc_list_channels",
+	}))
+
+	scanner := bufio.NewScanner(&buf)
+	var entries []map[string]any
+	for scanner.Scan() {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "lnc_get_info", entries[0]["tool"])
+	assert.Equal(t, float64(7), entries[0]["mcp_request_id"])
+	assert.Equal(t, "tok-1", entries[0]["progress_token"])
+	assert.Equal(t, "session-1", entries[0]["session_id"])
+	assert.Equal(t, "trace-1", entries[0]["trace_id"])
+	assert.NotEmpty(t, entries[0]["time"])
+
+	// Missing correlation fields are omitted rather than empty.
+	assert.NotContains(t, entries[1], "mcp_request_id")
+	assert.NotContains(t, entries[1], "progress_token")
+}
+
+// Test that a nil logger silently discards entries.
+func TestLogger_Nil(t *testing.T) {
+	var logger *Logger
+	assert.NoError(t, logger.Log(Entry{Tool: "lnc_get_info"}))
+	assert.NoError(t, logger.Close())
+}
+
+// Test that Open appends to a file with owner-only permissions.
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	logger, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, logger.Log(Entry{Tool: "lnc_get_info"}))
+	require.NoError(t, logger.Close())
+
+	logger, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, logger.Log(Entry{Tool: "lnc_get_balance"}))
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+// Test that entries past the maximum age are pruned, oldest first, and
+// that the log keeps appending to the rewritten file.
+func TestLogger_PruneAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := Open(path)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	old := time.Now().Add(-48 * time.Hour).UTC()
+	require.NoError(t, logger.Log(Entry{Time: old, Tool: "old_1"}))
+	require.NoError(t, logger.Log(Entry{Time: old, Tool: "old_2"}))
+	require.NoError(t, logger.Log(Entry{Tool: "recent"}))
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	var pruned []PruneResult
+	logger.SetRetention(Retention{MaxAge: 24 * time.Hour},
+		func(result PruneResult) { pruned = append(pruned, result) })
+	result, err := logger.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Entries)
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, before.Size()-after.Size(), result.Bytes)
+	assert.Equal(t, after.Size(), result.Remaining)
+	assert.Equal(t, os.FileMode(0o600), after.Mode().Perm())
+
+	// The next prune is not due for an hour, so writes only append.
+	require.NoError(t, logger.Log(Entry{Tool: "next"}))
+	assert.Empty(t, pruned)
+	assert.Equal(t, []string{"recent", "next"}, loggedTools(t, path))
+}
+
+// Test that a log past its maximum size is cut to three quarters of it,
+// with the caller told what was reclaimed.
+func TestLogger_PruneSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := Open(path)
+	require.NoError(t, err)
+	defer logger.Close()
+
+	var pruned []PruneResult
+	logger.SetRetention(Retention{MaxBytes: 1_000},
+		func(result PruneResult) { pruned = append(pruned, result) })
+	for i := 0; i < 20; i++ {
+		require.NoError(t, logger.Log(Entry{
+			Tool: fmt.Sprintf("tool_%02d", i),
+		}))
+	}
+
+	require.NotEmpty(t, pruned)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(1_000))
+	for _, result := range pruned {
+		assert.LessOrEqual(t, result.Remaining, int64(750))
+		assert.Positive(t, result.Bytes)
+	}
+
+	tools := loggedTools(t, path)
+	assert.Equal(t, "tool_19", tools[len(tools)-1])
+	assert.Less(t, len(tools), 20)
+}
+
+// Test that loggers not writing to a file are never pruned.
+func TestLogger_PruneWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+	logger.SetRetention(Retention{MaxBytes: 1}, nil)
+	require.NoError(t, logger.Log(Entry{Tool: "lnc_get_info"}))
+
+	result, err := logger.Prune()
+	require.NoError(t, err)
+	assert.Zero(t, result.Entries)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+}
+
+// loggedTools returns the tools of the entries in an audit log file.
+func loggedTools(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var tools []string
+	for _, line := range bytes.Split(bytes.TrimSpace(data),
+		[]byte("\n")) {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(line, &entry))
+		tools = append(tools, entry.Tool)
+	}
+	return tools
+}
+
+// Test that secrets never reach the audit log.
+func TestRedactArguments(t *testing.T) {
+	args := map[string]any{
+		"pairingPhrase": "one two three",
+		"password":      "hunter2",
+		"amount_sat":    float64(1000),
+		"invoice":       "lnbc1...",
+	}
+
+	redacted := RedactArguments(args)
+	assert.Equal(t, "[REDACTED]", redacted["pairingPhrase"])
+	assert.Equal(t, "[REDACTED]", redacted["password"])
+	assert.Equal(t, float64(1000), redacted["amount_sat"])
+	assert.Equal(t, "lnbc1...", redacted["invoice"])
+
+	// The input is left untouched.
+	assert.Equal(t, "hunter2", args["password"])
+	assert.Nil(t, RedactArguments(nil))
+}
@@ -0,0 +1,108 @@
+// Package backup archives the server's own state, the contact book, the
+// watchlist, the operation journal and the spend ledger, into a single
+// file, so a server moved to a new host can pick up where the old one left
+// off. The node's state is not included; it is backed up by lnd.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+)
+
+// fileVersion is the version of the archive's file format.
+const fileVersion = 1
+
+// Archive is the server state a backup holds. A section is empty when its
+// store was not enabled on the server that wrote the backup.
+type Archive struct {
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	ServerVersion string    `json:"server_version,omitempty"`
+
+	Contacts     []contacts.Contact  `json:"contacts,omitempty"`
+	WatchedNodes []watchlist.Node    `json:"watched_nodes,omitempty"`
+	Operations   []journal.Operation `json:"operations,omitempty"`
+	Spends       []policy.Spend      `json:"spends,omitempty"`
+}
+
+// ErrExists is returned when writing a backup over an existing file.
+var ErrExists = errors.New("a backup with that name already exists")
+
+// Write stores an archive at path with owner-only permissions. It never
+// replaces an existing file, so an earlier backup cannot be overwritten.
+func Write(path string, archive Archive) error {
+	archive.Version = fileVersion
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path),
+		filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Linking, unlike renaming, fails if the target exists.
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return ErrExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Read loads the archive at path.
+func Read(path string) (Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Archive{}, err
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return Archive{}, fmt.Errorf("backup %s is not a valid "+
+			"archive: %w", filepath.Base(path), err)
+	}
+	// Restoring from a newer format could silently drop state this
+	// server does not understand.
+	if archive.Version > fileVersion {
+		return Archive{}, fmt.Errorf("backup %s has format version "+
+			"%d, newer than this server's %d", filepath.Base(path),
+			archive.Version, fileVersion)
+	}
+	if archive.Version < 1 {
+		return Archive{}, fmt.Errorf("backup %s has no format "+
+			"version", filepath.Base(path))
+	}
+
+	return archive, nil
+}
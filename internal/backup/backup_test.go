@@ -0,0 +1,70 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.json")
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, Write(path, Archive{
+		CreatedAt:     createdAt,
+		ServerVersion: "1.0.0",
+		Contacts: []contacts.Contact{{
+			Pubkey: "02" + strings.Repeat("ab", 32),
+			Name:   "Bob",
+		}},
+		Spends: []policy.Spend{{ID: "a", AmountSat: 100}},
+	}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	archive, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archive.Version)
+	assert.True(t, createdAt.Equal(archive.CreatedAt))
+	require.Len(t, archive.Contacts, 1)
+	assert.Equal(t, "Bob", archive.Contacts[0].Name)
+	assert.Len(t, archive.Spends, 1)
+	assert.Empty(t, archive.WatchedNodes)
+
+	// An existing backup is never replaced, and no temporary file is
+	// left behind.
+	err = Write(path, Archive{})
+	assert.ErrorIs(t, err, ErrExists)
+	archive, err = Read(path)
+	require.NoError(t, err)
+	assert.Len(t, archive.Contacts, 1)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"newer.json":   `{"version": 2}`,
+		"unversioned":  `{"contacts": []}`,
+		"corrupt.json": `{"version":`,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := Read(path)
+		assert.Error(t, err, name)
+	}
+
+	_, err := Read(filepath.Join(dir, "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+}
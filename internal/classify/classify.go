@@ -0,0 +1,153 @@
+// Package classify marks tool result fields as public, internal or
+// sensitive, so operators can keep whole classes of data out of the results
+// an assistant sees.
+package classify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Class is a set of data classes.
+type Class uint8
+
+// Data classes.
+const (
+	// Public data is safe to show anyone who may use the node's tools,
+	// such as balances, fees and states.
+	Public Class = 1 << iota
+
+	// Internal data identifies the node's activity on chain or its
+	// infrastructure, such as transaction IDs, outpoints and hosts.
+	Internal
+
+	// Sensitive data proves payments, moves funds or locates the wallet,
+	// such as preimages, raw transactions, PSBTs and addresses.
+	Sensitive
+
+	// All includes every class.
+	All = Public | Internal | Sensitive
+)
+
+// classNames maps the configuration names of the classes to them.
+var classNames = map[string]Class{
+	"public":    Public,
+	"internal":  Internal,
+	"sensitive": Sensitive,
+}
+
+// fields classifies result fields by name, wherever they appear in a
+// result. Fields not listed are public.
+var fields = map[string]Class{
+	// Sensitive: proofs of payment and payment secrets, which BOLT11
+	// payment requests carry too.
+	"payment_preimage": Sensitive,
+	"preimage":         Sensitive,
+	"payment_addr":     Sensitive,
+	"payment_request":  Sensitive,
+
+	// Sensitive: transactions that can be broadcast or signed.
+	"raw_tx":      Sensitive,
+	"raw_tx_hex":  Sensitive,
+	"psbt":        Sensitive,
+	"signed_psbt": Sensitive,
+	"pk_script":   Sensitive,
+
+	// Sensitive: credentials, and static channel backups, which every
+	// channel's funds can be swept with alongside the seed.
+	"macaroon": Sensitive,
+	"backup":   Sensitive,
+
+	// Sensitive: wallet and node addresses, and the extended public keys
+	// every address of an account derives from.
+	"address":             Sensitive,
+	"addresses":           Sensitive,
+	"fallback_address":    Sensitive,
+	"bip21_uri":           Sensitive,
+	"extended_public_key": Sensitive,
+
+	// Internal: on-chain identifiers of the node's transactions.
+	"txid":               Internal,
+	"funding_txid":       Internal,
+	"closing_txid":       Internal,
+	"closing_tx_hash":    Internal,
+	"sweep_txid":         Internal,
+	"tx_hash":            Internal,
+	"outpoint":           Internal,
+	"previous_outpoints": Internal,
+	"label":              Internal,
+	"previous_label":     Internal,
+
+	// Internal: the node's infrastructure and credentials' identifiers.
+	"host":           Internal,
+	"ip_address":     Internal,
+	"mailbox_server": Internal,
+	"lock_id":        Internal,
+	"root_key_id":    Internal,
+	"root_key_ids":   Internal,
+}
+
+// Of returns the class of a result field.
+func Of(field string) Class {
+	if class, ok := fields[field]; ok {
+		return class
+	}
+	return Public
+}
+
+// Parse parses the names of the classes results may include. Public data
+// is always included; no names at all include every class.
+func Parse(names []string) (Class, error) {
+	if len(names) == 0 {
+		return All, nil
+	}
+
+	classes := Public
+	for _, name := range names {
+		class, ok := classNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown data class %q, expected "+
+				"public, internal or sensitive", name)
+		}
+		classes |= class
+	}
+
+	return classes, nil
+}
+
+// Filter removes from value, a decoded JSON value, every object field whose
+// class is not in allowed, at any depth. It returns the names of the fields
+// removed, sorted and without duplicates.
+func Filter(value any, allowed Class) []string {
+	removed := make(map[string]bool)
+	filter(value, allowed, removed)
+
+	names := make([]string, 0, len(removed))
+	for name := range removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// filter removes disallowed fields from value, recording their names.
+func filter(value any, allowed Class, removed map[string]bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if Of(name)&allowed == 0 {
+				delete(value, name)
+				removed[name] = true
+				continue
+			}
+			filter(field, allowed, removed)
+		}
+
+	case []any:
+		for _, item := range value {
+			filter(item, allowed, removed)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test parsing the configured classes.
+func TestParse(t *testing.T) {
+	classes, err := Parse(nil)
+	require.NoError(t, err)
+	assert.Equal(t, All, classes)
+
+	classes, err = Parse([]string{"Internal "})
+	require.NoError(t, err)
+	assert.Equal(t, Public|Internal, classes)
+
+	classes, err = Parse([]string{"public"})
+	require.NoError(t, err)
+	assert.Equal(t, Public, classes)
+
+	_, err = Parse([]string{"secret"})
+	assert.Error(t, err)
+}
+
+// Test that disallowed fields are removed at every depth.
+func TestFilter(t *testing.T) {
+	result := map[string]any{
+		"status":           "SUCCEEDED",
+		"payment_preimage": "bb",
+		"txid":             "aa",
+		"utxos": []any{
+			map[string]any{
+				"address":    "bc1q...",
+				"amount_sat": float64(1000),
+				"outpoint":   "aa:0",
+			},
+		},
+	}
+
+	removed := Filter(result, Public|Internal)
+	assert.Equal(t, []string{"address", "payment_preimage"}, removed)
+	assert.Equal(t, "aa", result["txid"])
+	utxo := result["utxos"].([]any)[0].(map[string]any)
+	assert.NotContains(t, utxo, "address")
+	assert.Equal(t, "aa:0", utxo["outpoint"])
+
+	removed = Filter(result, Public)
+	assert.Equal(t, []string{"outpoint", "txid"}, removed)
+	assert.Equal(t, map[string]any{
+		"status": "SUCCEEDED",
+		"utxos":  []any{map[string]any{"amount_sat": float64(1000)}},
+	}, result)
+
+	assert.Empty(t, Filter(result, All))
+}
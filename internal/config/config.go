@@ -2,8 +2,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,203 @@ type Config struct {
 	MaxConnectionRetries int
 	ConnectionTimeout    time.Duration
 	ShutdownTimeout      time.Duration
+
+	// LSP integration. LSPEndpoints maps an operator-chosen LSP name to the
+	// base URL of its LSPS1 HTTP API.
+	LSPEndpoints   map[string]string
+	LSPAllowOrders bool
+
+	// Dual-funding contribution policy. DualFundPolicy is one of "none",
+	// "match" or "fixed", and the server refuses to start with any other;
+	// contributions never exceed DualFundMaxSat.
+	DualFundPolicy   string
+	DualFundFixedSat int64
+	DualFundMaxSat   int64
+
+	// WriteMode registers the state-changing tool set. The server is
+	// read-only unless the operator opts in.
+	WriteMode bool
+
+	// ToolAllowlist and ToolDenylist narrow the tools registered to those
+	// matching the allowlist, if set, and not matching the denylist.
+	// Entries are path.Match patterns, or @path to read them from a file.
+	ToolAllowlist []string
+	ToolDenylist  []string
+
+	// WithdrawalAllowlist lists the only on-chain addresses write tools
+	// may send funds to. Empty means on-chain withdrawals are denied.
+	WithdrawalAllowlist []string
+
+	// MaxPaymentSat, MaxSpendPerHourSat and MaxSpendPerDaySat cap what
+	// payments, routing fees included, and on-chain sends may spend: each
+	// one, and in total over the last hour and the last 24 hours. Zero
+	// means no cap.
+	MaxPaymentSat      int64
+	MaxSpendPerHourSat int64
+	MaxSpendPerDaySat  int64
+
+	// MaxFeeRate caps the on-chain fee rate, in sat/vB, write tools may
+	// set. Zero means no cap.
+	MaxFeeRate int64
+
+	// SpendLedgerPath is the file spends are recorded in for the hourly
+	// and daily caps, so they hold across restarts. Empty keeps the
+	// ledger in memory.
+	SpendLedgerPath string
+
+	// Sandbox mode routes write tools to a separately connected regtest
+	// node, whatever network the primary connection is on.
+	SandboxMode    bool
+	SandboxMailbox string
+
+	// ElicitTools lists the write tools whose calls the user must approve
+	// through MCP elicitation before they run. Calls to them are refused
+	// when the client cannot ask.
+	ElicitTools []string
+
+	// StrictArguments rejects tool calls with arguments the tool does not
+	// declare, instead of silently ignoring them.
+	StrictArguments bool
+
+	// ResponseSigning is "none", "hmac" or "node". In hmac mode tool
+	// results are signed with ResponseSigningKey; in node mode with the
+	// connected node's key via SignMessage.
+	ResponseSigning    string
+	ResponseSigningKey string
+
+	// MemoScrub is "none", "hash" or "redact": how invoice memos and
+	// payment descriptions are scrubbed from tool results and the audit
+	// log, for operators who must keep customer text out of them.
+	MemoScrub string
+
+	// ResponseClasses lists the data classes tool results may include:
+	// "public", "internal" and "sensitive". Public data is always
+	// included; an empty list includes every class.
+	ResponseClasses []string
+
+	// PreimageDisclosure is "never", "on_request" or "always": when
+	// payment tools return preimages, which prove payment and so stay
+	// out of results unless a call sets include_preimage.
+	PreimageDisclosure string
+
+	// CompressThreshold is the size in bytes above which tool results
+	// are sent gzipped and base64 encoded, to clients that declare they
+	// can decode them. Zero disables compression.
+	CompressThreshold int
+
+	// AuditLogPath is the file tool calls are appended to as JSON lines.
+	// Empty disables audit logging.
+	AuditLogPath string
+
+	// AuditRetention drops audit entries older than this, and
+	// AuditMaxBytes drops the oldest once the log grows past that size.
+	// Zero keeps entries indefinitely.
+	AuditRetention time.Duration
+	AuditMaxBytes  int
+
+	// AnomalyGraphDumps and AnomalyGraphDumpWindow raise an alert when
+	// the network graph is dumped that many times within the window.
+	// Zero disables the rule.
+	AnomalyGraphDumps      int
+	AnomalyGraphDumpWindow time.Duration
+
+	// AnomalyDeniedWrites and AnomalyDeniedWriteWindow raise an alert
+	// when that many write calls from one session are refused within
+	// the window. Zero disables the rule.
+	AnomalyDeniedWrites      int
+	AnomalyDeniedWriteWindow time.Duration
+
+	// AnomalyActiveHours is the operator's working day as "HH-HH" in
+	// local time. Write calls outside it raise an alert. Empty disables
+	// the rule.
+	AnomalyActiveHours string
+
+	// Honeytokens are decoy strings, such as an invoice or node pubkey
+	// planted where only an intruder would find them. Any call using one
+	// raises an alert.
+	Honeytokens []string
+
+	// LeasePath is a lease file shared by instances of the server that
+	// run with the same configuration for high availability. Only the
+	// instance holding the lease serves tool calls and holds the LNC
+	// session; the others stand by to take it over. Empty runs the
+	// instance alone.
+	LeasePath string
+
+	// LeaseTTL is how long the lease lasts unless its holder renews it,
+	// and so how long a failover can take.
+	LeaseTTL time.Duration
+
+	// InstanceID names this instance in the lease file. It defaults to
+	// the host name and process ID.
+	InstanceID string
+
+	// AllowWalletUnlock registers lnc_unlock_wallet, so a node lnc_connect
+	// finds locked can be unlocked with its wallet password through the
+	// client. Only for development nodes.
+	AllowWalletUnlock bool
+
+	// JournalPath is the file payments in flight and channels being
+	// opened are recorded in, so they are followed again after a
+	// restart. Empty disables the journal.
+	JournalPath string
+
+	// JournalRetention is how long finished operations stay in the
+	// journal. Operations in flight are kept until they finish.
+	JournalRetention time.Duration
+
+	// ContactsPath is the file the operator's contact book is kept in,
+	// naming the nodes behind pubkeys in peer and channel results. Empty
+	// disables the contact book and its tools.
+	ContactsPath string
+
+	// WatchlistPath is the file the external nodes watched for changes,
+	// such as LSPs and routing partners, are kept in with what their last
+	// check saw. Empty disables the watchlist and its tools.
+	WatchlistPath string
+
+	// WatchInterval is how often the watched nodes are checked in the
+	// background, raising their changes as events. Zero checks them only
+	// when lnc_watched_nodes is called.
+	WatchInterval time.Duration
+
+	// SchedulePath is the file write tool calls scheduled to run later
+	// are kept in, with their history once cancelled, failed or run.
+	// Empty disables the schedule and its tools, which need write mode.
+	SchedulePath string
+
+	// ScheduleInterval is how often the schedule is checked for calls
+	// that are due, and so how late a call may run.
+	ScheduleInterval time.Duration
+
+	// BackupDir is the directory lnc_server_backup writes backups of the
+	// server's own state to and lnc_server_restore reads them from. Empty
+	// disables both tools.
+	BackupDir string
+
+	// ExportDir is the directory lnc_export_records writes payments,
+	// invoices and forwards to. Empty disables the tool.
+	ExportDir string
+
+	// TipSourceURL serves the current chain height as plain text, for
+	// lnc_get_sync_status to compare the node against. Empty disables
+	// the comparison.
+	TipSourceURL string
+
+	// GraphSalt keys the pseudonyms in lnc_export_anonymized_graph, so
+	// exports made with the same salt use the same pseudonyms. Empty uses
+	// a random salt for each run.
+	GraphSalt string
+
+	// DebugInfoUnredacted makes lnc_get_debug_info return credentials,
+	// hosts, paths and addresses as lnd reports them. The tool always
+	// redacts them otherwise; callers cannot turn redaction off.
+	DebugInfoUnredacted bool
+
+	// MempoolAPIURL is the base URL of a mempool.space compatible API.
+	// When set, fee estimates and send previews include its view of
+	// mempool congestion, labeled as external data.
+	MempoolAPIURL string
 }
 
 // LoadConfig populates Config from environment variables with sensible defaults.
@@ -39,7 +238,8 @@ func LoadConfig() *Config {
 			"mailbox.terminal.lightning.today:443"),
 		DefaultTimeout: getEnvDuration("LNC_DEFAULT_TIMEOUT",
 			30*time.Second),
-		DefaultDevMode:  getEnvBool("LNC_DEFAULT_DEV_MODE", false),
+		DefaultDevMode: getEnvBool("LNC_DEFAULT_DEV_MODE",
+			getEnvBool("LNC_DEV_MODE", false)),
 		DefaultInsecure: getEnvBool("LNC_DEFAULT_INSECURE", false),
 
 		// Security defaults.
@@ -48,11 +248,145 @@ func LoadConfig() *Config {
 			30*time.Second),
 		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT",
 			30*time.Second),
+
+		// LSP defaults.
+		LSPEndpoints:   getEnvMap("LNC_LSP_ENDPOINTS"),
+		LSPAllowOrders: getEnvBool("LNC_LSP_ALLOW_ORDERS", false),
+
+		// Dual-funding defaults: never contribute unless configured.
+		DualFundPolicy: getEnvString("LNC_DUALFUND_POLICY", "none"),
+		DualFundFixedSat: int64(getEnvInt("LNC_DUALFUND_FIXED_SAT",
+			0)),
+		DualFundMaxSat: int64(getEnvInt("LNC_DUALFUND_MAX_SAT", 0)),
+
+		// Write mode is opt-in.
+		WriteMode: getEnvBool("LNC_WRITE_MODE", false),
+
+		// Every tool the other settings enable is registered unless
+		// narrowed.
+		ToolAllowlist: getEnvList("LNC_TOOL_ALLOWLIST"),
+		ToolDenylist:  getEnvList("LNC_TOOL_DENYLIST"),
+
+		// Policy defaults.
+		WithdrawalAllowlist: getEnvList("LNC_WITHDRAWAL_ALLOWLIST"),
+
+		// Spending is not capped unless configured.
+		MaxPaymentSat: int64(getEnvInt("LNC_MAX_PAYMENT_SAT", 0)),
+		MaxSpendPerHourSat: int64(getEnvInt(
+			"LNC_MAX_SPEND_PER_HOUR_SAT", 0)),
+		MaxSpendPerDaySat: int64(getEnvInt(
+			"LNC_MAX_SPEND_PER_DAY_SAT", 0)),
+		MaxFeeRate: int64(getEnvInt(
+			"LNC_MAX_FEE_RATE_SAT_PER_VBYTE", 0)),
+		SpendLedgerPath: getEnvString("LNC_SPEND_LEDGER", ""),
+
+		// Sandbox defaults.
+		SandboxMode: getEnvBool("LNC_SANDBOX_MODE", false),
+		SandboxMailbox: getEnvString("LNC_SANDBOX_MAILBOX",
+			"aperture:11110"),
+
+		// Approval is only asked for the tools the operator lists.
+		ElicitTools: getEnvList("LNC_ELICIT_TOOLS"),
+
+		// Validation defaults.
+		StrictArguments: getEnvBool("LNC_STRICT_ARGUMENTS", false),
+
+		// Memos are passed through unless configured.
+		MemoScrub: getEnvString("LNC_MEMO_SCRUB", "none"),
+
+		// Results include every class of data unless configured.
+		ResponseClasses: getEnvList("LNC_RESPONSE_CLASSES"),
+
+		// Preimages are returned only to calls that ask for them.
+		PreimageDisclosure: getEnvString("LNC_PREIMAGE_DISCLOSURE",
+			"on_request"),
+
+		// Large results are compressed for clients that ask for it.
+		CompressThreshold: getEnvInt("LNC_COMPRESS_THRESHOLD", 32*1024),
+
+		// Response signing is off unless configured.
+		ResponseSigning: getEnvString("LNC_RESPONSE_SIGNING", "none"),
+		ResponseSigningKey: getEnvString("LNC_RESPONSE_SIGNING_KEY",
+			""),
+
+		// Audit defaults.
+		AuditLogPath: getEnvString("LNC_AUDIT_LOG", ""),
+
+		// The audit log grows without bound unless configured.
+		AuditRetention: getEnvDuration("LNC_AUDIT_RETENTION", 0),
+		AuditMaxBytes:  getEnvInt("LNC_AUDIT_MAX_BYTES", 0),
+
+		// Anomaly rules are off unless configured.
+		AnomalyGraphDumps: getEnvInt("LNC_ANOMALY_GRAPH_DUMPS", 0),
+		AnomalyGraphDumpWindow: getEnvDuration(
+			"LNC_ANOMALY_GRAPH_DUMP_WINDOW", time.Hour),
+		AnomalyDeniedWrites: getEnvInt("LNC_ANOMALY_DENIED_WRITES", 0),
+		AnomalyDeniedWriteWindow: getEnvDuration(
+			"LNC_ANOMALY_DENIED_WRITE_WINDOW", 10*time.Minute),
+		AnomalyActiveHours: getEnvString("LNC_ANOMALY_ACTIVE_HOURS", ""),
+		Honeytokens:        getEnvList("LNC_HONEYTOKENS"),
+
+		// Instances run alone unless a lease file is configured.
+		LeasePath: getEnvString("LNC_LEASE_PATH", ""),
+		LeaseTTL:  getEnvDuration("LNC_LEASE_TTL", 15*time.Second),
+		InstanceID: getEnvString("LNC_INSTANCE_ID",
+			defaultInstanceID()),
+
+		// Wallets are never unlocked through the server unless the
+		// operator opts in, for development nodes.
+		AllowWalletUnlock: getEnvBool("LNC_DEV_ALLOW_WALLET_UNLOCK",
+			false),
+
+		// Operations are not journaled unless configured.
+		JournalPath: getEnvString("LNC_JOURNAL_PATH", ""),
+		JournalRetention: getEnvDuration("LNC_JOURNAL_RETENTION",
+			7*24*time.Hour),
+
+		// Peers are left unnamed unless a contact book is configured.
+		ContactsPath: getEnvString("LNC_CONTACTS_PATH", ""),
+
+		// No nodes are watched unless a watchlist is configured.
+		WatchlistPath: getEnvString("LNC_WATCHLIST_PATH", ""),
+		WatchInterval: getEnvDuration("LNC_WATCH_INTERVAL",
+			15*time.Minute),
+
+		// No calls are scheduled unless a schedule is configured.
+		SchedulePath: getEnvString("LNC_SCHEDULE_PATH", ""),
+		ScheduleInterval: getEnvDuration("LNC_SCHEDULE_INTERVAL",
+			time.Minute),
+
+		// The server's state is not backed up unless configured.
+		BackupDir: getEnvString("LNC_BACKUP_DIR", ""),
+
+		// Records are not exported unless configured.
+		ExportDir: getEnvString("LNC_EXPORT_DIR", ""),
+
+		// Sync checks use only the node's own view unless configured.
+		TipSourceURL: getEnvString("LNC_TIP_SOURCE_URL", ""),
+
+		// Debug info is redacted unless the operator opts out.
+		DebugInfoUnredacted: getEnvBool("LNC_DEBUG_INFO_UNREDACTED",
+			false),
+
+		// Fee outputs carry no external data unless configured.
+		MempoolAPIURL: getEnvString("LNC_MEMPOOL_API_URL", ""),
+
+		// Graph exports are keyed with a random salt unless configured.
+		GraphSalt: getEnvString("LNC_GRAPH_SALT", ""),
 	}
 
 	return cfg
 }
 
+// defaultInstanceID names the instance by its host name and process ID.
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
 // getEnvString retrieves a string value from environment variables with a fallback.
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -90,3 +424,29 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvMap parses a comma-separated list of key=value pairs from an
+// environment variable. Malformed entries are skipped.
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// getEnvList parses a comma-separated list from an environment variable.
+// Empty entries are skipped.
+func getEnvList(key string) []string {
+	var result []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
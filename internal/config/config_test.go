@@ -24,6 +24,21 @@ func TestLoadConfig_Defaults(t *testing.T) {
 		config.DefaultMailboxServer)
 	assert.Equal(t, 30*time.Second, config.DefaultTimeout)
 	assert.Equal(t, 30*time.Second, config.ShutdownTimeout)
+	assert.Equal(t, "none", config.DualFundPolicy)
+	assert.False(t, config.SandboxMode)
+	assert.False(t, config.WriteMode)
+	assert.False(t, config.StrictArguments)
+	assert.Equal(t, "none", config.ResponseSigning)
+	assert.Empty(t, config.AuditLogPath)
+	assert.Empty(t, config.JournalPath)
+	assert.False(t, config.AllowWalletUnlock)
+	assert.Zero(t, config.DualFundMaxSat)
+	assert.Empty(t, config.TipSourceURL)
+	assert.False(t, config.DebugInfoUnredacted)
+	assert.Empty(t, config.MempoolAPIURL)
+	assert.Empty(t, config.LeasePath)
+	assert.Equal(t, 15*time.Second, config.LeaseTTL)
+	assert.NotEmpty(t, config.InstanceID)
 }
 
 // Test LoadConfig with environment variables.
@@ -239,6 +254,52 @@ func TestGetEnvBool(t *testing.T) {
 	}
 }
 
+// Test getEnvMap helper function.
+func TestGetEnvMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected map[string]string
+	}{
+		{
+			name:     "single_entry",
+			envValue: "olympus=https://lsps1.example.com",
+			expected: map[string]string{
+				"olympus": "https://lsps1.example.com",
+			},
+		},
+		{
+			name: "multiple_entries_with_spaces",
+			envValue: " a=https://a.example.com , " +
+				"b = https://b.example.com",
+			expected: map[string]string{
+				"a": "https://a.example.com",
+				"b": "https://b.example.com",
+			},
+		},
+		{
+			name:     "malformed_entries_skipped",
+			envValue: "novalue=,=https://x.example.com,bare",
+			expected: map[string]string{},
+		},
+		{
+			name:     "not_set",
+			envValue: "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("TEST_MAP", tt.envValue)
+			defer os.Unsetenv("TEST_MAP")
+
+			result := getEnvMap("TEST_MAP")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 // Test timeout values are reasonable.
 func TestConfig_TimeoutValues(t *testing.T) {
 	config := LoadConfig()
@@ -273,3 +334,12 @@ func BenchmarkGetEnvString(b *testing.B) {
 		_ = getEnvString("BENCH_TEST", "default")
 	}
 }
+
+// Test getEnvList parsing.
+func TestGetEnvList(t *testing.T) {
+	os.Setenv("TEST_LIST", " bc1qaaa , ,bc1qbbb,")
+	defer os.Unsetenv("TEST_LIST")
+
+	assert.Equal(t, []string{"bc1qaaa", "bc1qbbb"}, getEnvList("TEST_LIST"))
+	assert.Empty(t, getEnvList("TEST_LIST_UNSET"))
+}
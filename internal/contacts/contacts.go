@@ -0,0 +1,343 @@
+// Package contacts keeps the operator's contact book: names, notes and
+// contact details for the nodes they have channels with, keyed by pubkey, so
+// tool results can say who a peer is instead of only its pubkey.
+package contacts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// fileVersion is the version of the contact book's file format.
+const fileVersion = 1
+
+// Field length limits, in characters.
+const (
+	maxNameLength  = 64
+	maxNotesLength = 1000
+)
+
+var (
+	// telegramPattern matches a Telegram username, with or without its
+	// leading @.
+	telegramPattern = regexp.MustCompile(`^@?[A-Za-z0-9_]{5,32}$`)
+
+	// nostrPattern matches a Nostr public key as an npub or in hex, or a
+	// NIP-05 identifier.
+	nostrPattern = regexp.MustCompile(`^(npub1[02-9ac-hj-np-z]{58}|` +
+		`[0-9a-f]{64}|[A-Za-z0-9._-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})$`)
+)
+
+// Contact is what the operator knows about a node.
+type Contact struct {
+	Pubkey       string `json:"pubkey"`
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Notes        string `json:"notes,omitempty"`
+	Telegram     string `json:"telegram,omitempty"`
+	Nostr        string `json:"nostr,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Label names the contact as a person would: "Bob (ACINQ)", "Bob" or
+// "ACINQ". It is empty when the contact has neither a name nor an
+// organization.
+func (c Contact) Label() string {
+	switch {
+	case c.Name != "" && c.Organization != "":
+		return c.Name + " (" + c.Organization + ")"
+	case c.Name != "":
+		return c.Name
+	default:
+		return c.Organization
+	}
+}
+
+// Validate checks a contact before it is stored, and normalizes its pubkey
+// to lower case.
+func (c *Contact) Validate() error {
+	c.Pubkey = strings.ToLower(strings.TrimSpace(c.Pubkey))
+	if key, err := hex.DecodeString(c.Pubkey); err != nil ||
+		len(key) != 33 {
+		return fmt.Errorf("pubkey must be a 33-byte hex public key")
+	}
+
+	for field, value := range map[string]string{
+		"name":         c.Name,
+		"organization": c.Organization,
+	} {
+		if utf8.RuneCountInString(value) > maxNameLength {
+			return fmt.Errorf("%s must be at most %d characters",
+				field, maxNameLength)
+		}
+	}
+	if utf8.RuneCountInString(c.Notes) > maxNotesLength {
+		return fmt.Errorf("notes must be at most %d characters",
+			maxNotesLength)
+	}
+	if c.Telegram != "" && !telegramPattern.MatchString(c.Telegram) {
+		return fmt.Errorf("telegram must be a username of 5 to 32 " +
+			"letters, digits or underscores")
+	}
+	if c.Nostr != "" && !nostrPattern.MatchString(c.Nostr) {
+		return fmt.Errorf("nostr must be an npub, a hex public key " +
+			"or a NIP-05 identifier")
+	}
+
+	if c.Name == "" && c.Organization == "" && c.Notes == "" &&
+		c.Telegram == "" && c.Nostr == "" {
+		return fmt.Errorf("a contact needs a name, organization, " +
+			"notes, telegram or nostr")
+	}
+	return nil
+}
+
+// file is the on-disk form of the contact book.
+type file struct {
+	Version  int        `json:"version"`
+	Contacts []*Contact `json:"contacts"`
+}
+
+// Book keeps contacts in a JSON file, rewritten whole on every change so a
+// crash leaves either the old or the new book. A nil Book holds no contacts
+// and refuses changes.
+type Book struct {
+	mu       sync.Mutex
+	path     string
+	contacts map[string]*Contact
+}
+
+// ErrDisabled is returned when a nil Book is changed.
+var ErrDisabled = errors.New("contact book is not enabled")
+
+// Open loads the contact book at path, or starts an empty one if the file
+// does not exist yet. It is created with owner-only permissions on first
+// write.
+func Open(path string) (*Book, error) {
+	b := &Book{
+		path:     path,
+		contacts: make(map[string]*Contact),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	// A newer server may have added fields this one would drop when it
+	// next saved the book.
+	if stored.Version > fileVersion {
+		return nil, fmt.Errorf("contact book %s has format version "+
+			"%d, newer than this server's %d", path,
+			stored.Version, fileVersion)
+	}
+	for _, contact := range stored.Contacts {
+		b.contacts[strings.ToLower(contact.Pubkey)] = contact
+	}
+
+	return b, nil
+}
+
+// Get returns the contact for a pubkey, and whether there is one.
+func (b *Book) Get(pubkey string) (Contact, bool) {
+	if b == nil {
+		return Contact{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	contact, ok := b.contacts[strings.ToLower(pubkey)]
+	if !ok {
+		return Contact{}, false
+	}
+	return *contact, true
+}
+
+// Set validates a contact and stores it, replacing any earlier contact for
+// its pubkey. It returns the contact as stored.
+func (b *Book) Set(contact Contact) (Contact, error) {
+	if b == nil {
+		return Contact{}, ErrDisabled
+	}
+	if err := contact.Validate(); err != nil {
+		return Contact{}, err
+	}
+	contact.UpdatedAt = time.Now().UTC()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous, had := b.contacts[contact.Pubkey]
+	b.contacts[contact.Pubkey] = &contact
+	if err := b.save(); err != nil {
+		if had {
+			b.contacts[contact.Pubkey] = previous
+		} else {
+			delete(b.contacts, contact.Pubkey)
+		}
+		return Contact{}, err
+	}
+	return contact, nil
+}
+
+// Delete removes the contact for a pubkey, and reports whether there was
+// one.
+func (b *Book) Delete(pubkey string) (bool, error) {
+	if b == nil {
+		return false, ErrDisabled
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	contact, ok := b.contacts[pubkey]
+	if !ok {
+		return false, nil
+	}
+	delete(b.contacts, pubkey)
+	if err := b.save(); err != nil {
+		b.contacts[pubkey] = contact
+		return false, err
+	}
+	return true, nil
+}
+
+// Import stores contacts restored from a backup. A contact replaces the
+// stored one for its pubkey only if it was updated more recently, so
+// importing never loses a newer edit. It returns how many contacts were
+// stored; nothing is stored if any contact is invalid.
+func (b *Book) Import(contacts []Contact) (int, error) {
+	if b == nil {
+		return 0, ErrDisabled
+	}
+	for i := range contacts {
+		if err := contacts[i].Validate(); err != nil {
+			return 0, fmt.Errorf("contact %s: %w",
+				contacts[i].Pubkey, err)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := make(map[string]*Contact, len(contacts))
+	for i := range contacts {
+		contact := contacts[i]
+		stored, had := b.contacts[contact.Pubkey]
+		if had && !stored.UpdatedAt.Before(contact.UpdatedAt) {
+			continue
+		}
+		if _, seen := previous[contact.Pubkey]; !seen {
+			previous[contact.Pubkey] = stored
+		}
+		b.contacts[contact.Pubkey] = &contact
+	}
+	if len(previous) == 0 {
+		return 0, nil
+	}
+	if err := b.save(); err != nil {
+		for pubkey, contact := range previous {
+			if contact == nil {
+				delete(b.contacts, pubkey)
+			} else {
+				b.contacts[pubkey] = contact
+			}
+		}
+		return 0, err
+	}
+	return len(previous), nil
+}
+
+// All returns a copy of every contact, ordered by label and then pubkey.
+func (b *Book) All() []Contact {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	contacts := make([]Contact, 0, len(b.contacts))
+	for _, contact := range b.contacts {
+		contacts = append(contacts, *contact)
+	}
+	sortContacts(contacts)
+	return contacts
+}
+
+// sortContacts orders contacts by label, case-insensitively, and then by
+// pubkey.
+func sortContacts(contacts []Contact) {
+	sort.Slice(contacts, func(i, j int) bool {
+		a := strings.ToLower(contacts[i].Label())
+		b := strings.ToLower(contacts[j].Label())
+		if a != b {
+			return a < b
+		}
+		return contacts[i].Pubkey < contacts[j].Pubkey
+	})
+}
+
+// save atomically replaces the contact book file. The caller holds mu.
+func (b *Book) save() error {
+	contacts := make([]Contact, 0, len(b.contacts))
+	for _, contact := range b.contacts {
+		contacts = append(contacts, *contact)
+	}
+	sortContacts(contacts)
+	stored := file{Version: fileVersion, Contacts: []*Contact{}}
+	for i := range contacts {
+		stored.Contacts = append(stored.Contacts, &contacts[i])
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path),
+		filepath.Base(b.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), b.path)
+}
@@ -0,0 +1,147 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	bob   = "02" + strings.Repeat("ab", 32)
+	carol = "03" + strings.Repeat("cd", 32)
+)
+
+// Test that contacts survive reopening the book, as after a restart.
+func TestBook_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contacts.json")
+
+	book, err := Open(path)
+	require.NoError(t, err)
+	assert.Empty(t, book.All())
+
+	stored, err := book.Set(Contact{
+		Pubkey:       strings.ToUpper(bob),
+		Name:         "Bob",
+		Organization: "ACINQ",
+		Telegram:     "@bob_lightning",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bob, stored.Pubkey)
+	assert.False(t, stored.UpdatedAt.IsZero())
+	_, err = book.Set(Contact{Pubkey: carol, Notes: "met at a meetup"})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	all := reopened.All()
+	require.Len(t, all, 2)
+
+	// Contacts without a label sort first.
+	assert.Equal(t, carol, all[0].Pubkey)
+	assert.Equal(t, "Bob (ACINQ)", all[1].Label())
+
+	contact, ok := reopened.Get(strings.ToUpper(bob))
+	require.True(t, ok)
+	assert.Equal(t, "@bob_lightning", contact.Telegram)
+
+	deleted, err := reopened.Delete(bob)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = reopened.Delete(bob)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	_, ok = reopened.Get(bob)
+	assert.False(t, ok)
+}
+
+func TestBook_Nil(t *testing.T) {
+	var book *Book
+	assert.Nil(t, book.All())
+	_, ok := book.Get(bob)
+	assert.False(t, ok)
+	_, err := book.Set(Contact{Pubkey: bob, Name: "Bob"})
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = book.Delete(bob)
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestBook_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contacts.json")
+	require.NoError(t, os.WriteFile(path,
+		[]byte(`{"version": 2, "contacts": []}`), 0o600))
+
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "newer than this server's 1")
+}
+
+func TestContact_Validate(t *testing.T) {
+	valid := []Contact{
+		{Pubkey: bob, Name: "Bob"},
+		{Pubkey: bob, Nostr: "npub1" + strings.Repeat("q", 58)},
+		{Pubkey: bob, Nostr: strings.Repeat("ab", 32)},
+		{Pubkey: bob, Nostr: "bob@example.com"},
+		{Pubkey: bob, Telegram: "bob_ln"},
+	}
+	for _, contact := range valid {
+		assert.NoError(t, contact.Validate(), contact)
+	}
+
+	invalid := []Contact{
+		{Pubkey: "abc", Name: "Bob"},
+		{Pubkey: bob},
+		{Pubkey: bob, Name: strings.Repeat("b", maxNameLength+1)},
+		{Pubkey: bob, Notes: strings.Repeat("n", maxNotesLength+1)},
+		{Pubkey: bob, Telegram: "bob"},
+		{Pubkey: bob, Nostr: "npub1bob"},
+	}
+	for _, contact := range invalid {
+		assert.Error(t, contact.Validate(), contact)
+	}
+}
+
+// Test that importing contacts from a backup keeps the newer of two edits
+// of the same contact.
+func TestBook_Import(t *testing.T) {
+	book, err := Open(filepath.Join(t.TempDir(), "contacts.json"))
+	require.NoError(t, err)
+	stored, err := book.Set(Contact{Pubkey: bob, Name: "Bob"})
+	require.NoError(t, err)
+
+	imported, err := book.Import([]Contact{
+		{Pubkey: bob, Name: "Old Bob",
+			UpdatedAt: stored.UpdatedAt.Add(-time.Hour)},
+		{Pubkey: strings.ToUpper(carol), Notes: "met at a meetup",
+			UpdatedAt: stored.UpdatedAt.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	contact, _ := book.Get(bob)
+	assert.Equal(t, "Bob", contact.Name)
+	contact, ok := book.Get(carol)
+	require.True(t, ok)
+	assert.Equal(t, stored.UpdatedAt.Add(-time.Hour), contact.UpdatedAt)
+
+	imported, err = book.Import([]Contact{{Pubkey: bob, Name: "New Bob",
+		UpdatedAt: stored.UpdatedAt.Add(time.Hour)}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	contact, _ = book.Get(bob)
+	assert.Equal(t, "New Bob", contact.Name)
+
+	// An invalid contact imports nothing.
+	_, err = book.Import([]Contact{
+		{Pubkey: "02" + strings.Repeat("ef", 32), Name: "Dave"},
+		{Pubkey: "abc", Name: "Eve"},
+	})
+	assert.Error(t, err)
+	assert.Len(t, book.All(), 2)
+}
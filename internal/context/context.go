@@ -22,6 +22,10 @@ const (
 	operationKey contextKey = "operation"
 	startTimeKey contextKey = "start_time"
 	deadlineKey  contextKey = "deadline"
+
+	// Context keys for MCP call correlation.
+	mcpRequestIDKey  contextKey = "mcp_request_id"
+	progressTokenKey contextKey = "progress_token"
 )
 
 // RequestContext wraps a standard context with request-specific metadata.
@@ -39,6 +43,8 @@ type RequestContext struct {
 }
 
 // New creates a new RequestContext with generated identifiers and a timeout.
+// A trace or session ID already present on the parent is kept so nested
+// operations stay correlated with the call that started them.
 func New(parent context.Context, operation string, timeout time.Duration) *RequestContext {
 	var (
 		ctx    context.Context
@@ -51,12 +57,18 @@ func New(parent context.Context, operation string, timeout time.Duration) *Reque
 		ctx, cancel = context.WithCancel(parent)
 	}
 
+	traceID := GetTraceID(parent)
+	if traceID == "" {
+		traceID = uuid.New().String()
+	}
+
 	now := time.Now()
 	rc := &RequestContext{
 		Context:   ctx,
 		cancel:    cancel,
 		requestID: uuid.New().String(),
-		traceID:   uuid.New().String(),
+		traceID:   traceID,
+		sessionID: GetSessionID(parent),
 		operation: operation,
 		startTime: now,
 		deadline:  now.Add(timeout),
@@ -215,6 +227,33 @@ func GetNodeID(ctx context.Context) string {
 	return ""
 }
 
+// WithMCPCall records the JSON-RPC request ID, progress token and client
+// session of the MCP tool call being served, so logs and audit entries can
+// be matched with the client's transcript.
+func WithMCPCall(ctx context.Context, requestID, progressToken any,
+	sessionID string) context.Context {
+	if requestID != nil {
+		ctx = context.WithValue(ctx, mcpRequestIDKey, requestID)
+	}
+	if progressToken != nil {
+		ctx = context.WithValue(ctx, progressTokenKey, progressToken)
+	}
+	if sessionID != "" {
+		ctx = context.WithValue(ctx, sessionIDKey, sessionID)
+	}
+	return ctx
+}
+
+// GetMCPRequestID extracts the MCP JSON-RPC request ID from any context.
+func GetMCPRequestID(ctx context.Context) any {
+	return ctx.Value(mcpRequestIDKey)
+}
+
+// GetProgressToken extracts the MCP progress token from any context.
+func GetProgressToken(ctx context.Context) any {
+	return ctx.Value(progressTokenKey)
+}
+
 // GetOperation extracts the operation name from any context.
 func GetOperation(ctx context.Context) string {
 	if op, ok := ctx.Value(operationKey).(string); ok {
@@ -261,6 +300,12 @@ func (rc *RequestContext) Fields() map[string]any {
 	if rc.operation != "" {
 		fields["operation"] = rc.operation
 	}
+	if id := GetMCPRequestID(rc.Context); id != nil {
+		fields["mcp_request_id"] = id
+	}
+	if token := GetProgressToken(rc.Context); token != nil {
+		fields["progress_token"] = token
+	}
 	fields["duration_ms"] = rc.Duration().Milliseconds()
 	fields["time_remaining_ms"] = rc.TimeRemaining().Milliseconds()
 
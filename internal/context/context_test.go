@@ -114,6 +114,31 @@ func TestExtractFromRegularContext(t *testing.T) {
 	assert.Equal(t, time.Duration(0), GetDuration(ctx))
 }
 
+// Test that MCP call identifiers and the trace ID carry into nested
+// request contexts.
+func TestWithMCPCall(t *testing.T) {
+	ctx := WithMCPCall(context.Background(), float64(42), "progress-1",
+		"session-1")
+
+	outer := New(ctx, "tool_call", 0)
+	defer outer.Cancel()
+	inner := New(outer, "get_info", 30*time.Second)
+	defer inner.Cancel()
+
+	assert.Equal(t, outer.TraceID(), inner.TraceID())
+	assert.NotEqual(t, outer.RequestID(), inner.RequestID())
+	assert.Equal(t, "session-1", inner.SessionID())
+	assert.Equal(t, float64(42), GetMCPRequestID(inner))
+	assert.Equal(t, "progress-1", GetProgressToken(inner))
+
+	fields := inner.Fields()
+	assert.Equal(t, float64(42), fields["mcp_request_id"])
+	assert.Equal(t, "progress-1", fields["progress_token"])
+
+	assert.Nil(t, GetMCPRequestID(context.Background()))
+	assert.Nil(t, GetProgressToken(context.Background()))
+}
+
 // Test Fields method for logging.
 func TestFields(t *testing.T) {
 	ctx := New(context.Background(), "test_operation", 30*time.Second)
@@ -0,0 +1,95 @@
+// Package elicit asks the user of an MCP client for input while a tool call
+// is running, through the client's elicitation capability. The MCP library
+// the server uses, mcp-go v0.28, only answers requests and has no
+// elicitation support, so this package also provides the stdio transport
+// that sends elicitation requests and routes their answers back to the
+// waiting tool call. It should give way to the library's transport once the
+// library can send requests to the client.
+package elicit
+
+import (
+	"context"
+	"errors"
+)
+
+// Action is the user's response to an elicitation request.
+type Action string
+
+const (
+	// ActionAccept means the user submitted the requested content.
+	ActionAccept Action = "accept"
+
+	// ActionDecline means the user explicitly refused.
+	ActionDecline Action = "decline"
+
+	// ActionCancel means the user dismissed the request without
+	// choosing.
+	ActionCancel Action = "cancel"
+)
+
+// ErrUnsupported is returned when the client did not declare the
+// elicitation capability, so nobody can be asked.
+var ErrUnsupported = errors.New("client does not support elicitation")
+
+// Result is the client's answer to an elicitation request.
+type Result struct {
+	Action  Action         `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// Requester asks the client's user for content matching schema, a flat
+// JSON Schema object of primitive properties, and blocks until they answer
+// or ctx is done.
+type Requester interface {
+	Elicit(ctx context.Context, message string,
+		schema map[string]any) (*Result, error)
+}
+
+// contextKey is the context key the requester is stored under.
+type contextKey struct{}
+
+// WithRequester returns a context that carries requester to tool handlers.
+func WithRequester(ctx context.Context, requester Requester) context.Context {
+	return context.WithValue(ctx, contextKey{}, requester)
+}
+
+// FromContext returns the requester stored in ctx, or nil if the transport
+// cannot send requests to the client.
+func FromContext(ctx context.Context) Requester {
+	requester, _ := ctx.Value(contextKey{}).(Requester)
+	return requester
+}
+
+// confirmField is the boolean property a confirmation asks for.
+const confirmField = "approve"
+
+// Confirm asks the user to approve the action described by message. It
+// returns true only when the user accepts with approve set; declining,
+// cancelling or leaving approve unset all refuse.
+func Confirm(ctx context.Context, requester Requester,
+	message string) (bool, error) {
+	if requester == nil {
+		return false, ErrUnsupported
+	}
+
+	result, err := requester.Elicit(ctx, message, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			confirmField: map[string]any{
+				"type":        "boolean",
+				"title":       "Approve",
+				"description": "Run this action now",
+			},
+		},
+		"required": []string{confirmField},
+	})
+	if err != nil {
+		return false, err
+	}
+	if result.Action != ActionAccept {
+		return false, nil
+	}
+
+	approved, _ := result.Content[confirmField].(bool)
+	return approved, nil
+}
@@ -0,0 +1,386 @@
+package elicit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pipeClient drives a StdioServer through a pair of pipes.
+type pipeClient struct {
+	in  *io.PipeWriter
+	out *bufio.Reader
+}
+
+// send writes one message to the server.
+func (c *pipeClient) send(t *testing.T, value any) {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	_, err = c.in.Write(append(data, '\n'))
+	require.NoError(t, err)
+}
+
+// receive reads the server's next message.
+func (c *pipeClient) receive(t *testing.T) map[string]any {
+	t.Helper()
+
+	line, err := c.out.ReadBytes('\n')
+	require.NoError(t, err)
+
+	var msg map[string]any
+	require.NoError(t, json.Unmarshal(line, &msg))
+	return msg
+}
+
+// call calls the confirming tool and returns the server's next message.
+func (c *pipeClient) call(t *testing.T, id int) map[string]any {
+	c.callTool(t, id, "confirm", nil)
+	return c.receive(t)
+}
+
+// callTool calls a tool without waiting for the server's answer.
+func (c *pipeClient) callTool(t *testing.T, id int, name string,
+	args map[string]any) {
+	c.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+}
+
+// answer answers an elicitation request.
+func (c *pipeClient) answer(t *testing.T, request map[string]any,
+	action string, approve bool) {
+	c.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      request["id"],
+		"result": map[string]any{
+			"action":  action,
+			"content": map[string]any{"approve": approve},
+		},
+	})
+}
+
+// toolText returns the text of a tool call response.
+func toolText(t *testing.T, msg map[string]any) string {
+	t.Helper()
+
+	result, ok := msg["result"].(map[string]any)
+	require.True(t, ok, "not a result: %v", msg)
+	content := result["content"].([]any)
+	return content[0].(map[string]any)["text"].(string)
+}
+
+// startServer serves tools that ask for confirmation until ctx is done,
+// and initializes the session with the given client capabilities. confirm
+// asks to pay its amount argument, 1000 sat by default, and notify_confirm
+// sends a log message before it asks.
+func startServer(ctx context.Context, t *testing.T,
+	capabilities map[string]any) (*pipeClient, <-chan error) {
+	confirm := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		amount, _ := request.Params.Arguments["amount"].(string)
+		if amount == "" {
+			amount = "1000 sat"
+		}
+		approved, err := Confirm(ctx, FromContext(ctx), "Pay "+amount)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if approved {
+			return mcp.NewToolResultText("approved"), nil
+		}
+		return mcp.NewToolResultText("refused"), nil
+	}
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("confirm"), confirm)
+	mcpServer.AddTool(mcp.NewTool("notify_confirm"), func(
+		ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		err := server.ServerFromContext(ctx).SendNotificationToClient(
+			ctx, "notifications/message", map[string]any{
+				"level": "info",
+				"data":  "asking for approval",
+			})
+		if err != nil {
+			return nil, err
+		}
+		return confirm(ctx, request)
+	})
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := NewStdioServer(mcpServer, zap.NewNop()).Listen(ctx,
+			inReader, outWriter)
+		outWriter.Close()
+		done <- err
+	}()
+
+	client := &pipeClient{in: inWriter, out: bufio.NewReader(outReader)}
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params": map[string]any{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    capabilities,
+			"clientInfo": map[string]any{
+				"name": "test", "version": "1.0.0",
+			},
+		},
+	})
+	require.Contains(t, client.receive(t), "result")
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/initialized",
+	})
+
+	return client, done
+}
+
+// Test that a tool call waits for the user's answer to an elicitation
+// request, and that only an accepted approval approves.
+func TestStdioServer_Confirm(t *testing.T) {
+	client, done := startServer(context.Background(), t, map[string]any{
+		"elicitation": map[string]any{},
+	})
+
+	tests := []struct {
+		name   string
+		answer map[string]any
+		want   string
+	}{
+		{
+			name: "accepted",
+			answer: map[string]any{
+				"action":  "accept",
+				"content": map[string]any{"approve": true},
+			},
+			want: "approved",
+		},
+		{
+			name: "accepted_unchecked",
+			answer: map[string]any{
+				"action":  "accept",
+				"content": map[string]any{"approve": false},
+			},
+			want: "refused",
+		},
+		{
+			name:   "declined",
+			answer: map[string]any{"action": "decline"},
+			want:   "refused",
+		},
+		{
+			name:   "cancelled",
+			answer: map[string]any{"action": "cancel"},
+			want:   "refused",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := client.call(t, 10+i)
+			assert.Equal(t, "elicitation/create", request["method"])
+			params := request["params"].(map[string]any)
+			assert.Equal(t, "Pay 1000 sat", params["message"])
+			schema := params["requestedSchema"].(map[string]any)
+			assert.Contains(t, schema["properties"], "approve")
+
+			client.send(t, map[string]any{
+				"jsonrpc": "2.0",
+				"id":      request["id"],
+				"result":  tt.answer,
+			})
+
+			response := client.receive(t)
+			assert.EqualValues(t, 10+i, response["id"])
+			assert.Equal(t, tt.want, toolText(t, response))
+		})
+	}
+
+	require.NoError(t, client.in.Close())
+	assert.NoError(t, <-done)
+}
+
+// Test that a client without the elicitation capability is never asked.
+func TestStdioServer_Unsupported(t *testing.T) {
+	client, done := startServer(context.Background(), t, map[string]any{})
+
+	response := client.call(t, 2)
+	assert.EqualValues(t, 2, response["id"])
+	assert.Equal(t, ErrUnsupported.Error(), toolText(t, response))
+
+	require.NoError(t, client.in.Close())
+	assert.NoError(t, <-done)
+}
+
+// Test that concurrent tool calls each get the answer to their own
+// elicitation request, whatever order the user answers them in.
+func TestStdioServer_ConcurrentCalls(t *testing.T) {
+	client, done := startServer(context.Background(), t, map[string]any{
+		"elicitation": map[string]any{},
+	})
+
+	client.callTool(t, 20, "confirm", map[string]any{"amount": "1 sat"})
+	client.callTool(t, 21, "confirm", map[string]any{"amount": "2 sat"})
+	requests := make(map[string]map[string]any)
+	for range 2 {
+		request := client.receive(t)
+		require.Equal(t, "elicitation/create", request["method"])
+		params := request["params"].(map[string]any)
+		requests[params["message"].(string)] = request
+	}
+	require.Contains(t, requests, "Pay 1 sat")
+	require.Contains(t, requests, "Pay 2 sat")
+	assert.NotEqual(t, requests["Pay 1 sat"]["id"],
+		requests["Pay 2 sat"]["id"])
+
+	client.answer(t, requests["Pay 2 sat"], "decline", false)
+	response := client.receive(t)
+	assert.EqualValues(t, 21, response["id"])
+	assert.Equal(t, "refused", toolText(t, response))
+
+	client.answer(t, requests["Pay 1 sat"], "accept", true)
+	response = client.receive(t)
+	assert.EqualValues(t, 20, response["id"])
+	assert.Equal(t, "approved", toolText(t, response))
+
+	require.NoError(t, client.in.Close())
+	assert.NoError(t, <-done)
+}
+
+// Test that notifications in both directions, and other requests, pass
+// while a tool call waits for the user.
+func TestStdioServer_InterleavedMessages(t *testing.T) {
+	client, done := startServer(context.Background(), t, map[string]any{
+		"elicitation": map[string]any{},
+	})
+
+	// The tool's notification and its elicitation request are written
+	// from different goroutines, so either may come first.
+	client.callTool(t, 30, "notify_confirm", nil)
+	var request map[string]any
+	for range 2 {
+		msg := client.receive(t)
+		switch msg["method"] {
+		case "notifications/message":
+			assert.NotContains(t, msg, "id")
+		case "elicitation/create":
+			request = msg
+		default:
+			t.Fatalf("unexpected message: %v", msg)
+		}
+	}
+	require.NotNil(t, request)
+
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      31,
+		"method":  "ping",
+	})
+	response := client.receive(t)
+	assert.EqualValues(t, 31, response["id"])
+	assert.Contains(t, response, "result")
+
+	// A notification from the client is not answered, so the next
+	// message is the tool's response.
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/roots/list_changed",
+	})
+	client.answer(t, request, "accept", true)
+	response = client.receive(t)
+	assert.EqualValues(t, 30, response["id"])
+	assert.Equal(t, "approved", toolText(t, response))
+
+	require.NoError(t, client.in.Close())
+	assert.NoError(t, <-done)
+}
+
+// Test that a call the client cancels while it waits for the user stops
+// without a response, and that a late answer to its elicitation request is
+// dropped.
+func TestStdioServer_CancelPending(t *testing.T) {
+	client, done := startServer(context.Background(), t, map[string]any{
+		"elicitation": map[string]any{},
+	})
+
+	stale := client.call(t, 40)
+	require.Equal(t, "elicitation/create", stale["method"])
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params":  map[string]any{"requestId": 40, "reason": "user"},
+	})
+	client.send(t, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      41,
+		"method":  "ping",
+	})
+	assert.EqualValues(t, 41, client.receive(t)["id"])
+
+	client.answer(t, stale, "accept", true)
+	request := client.call(t, 42)
+	require.Equal(t, "elicitation/create", request["method"])
+	assert.NotEqual(t, stale["id"], request["id"])
+	client.answer(t, request, "decline", false)
+	response := client.receive(t)
+	assert.EqualValues(t, 42, response["id"])
+	assert.Equal(t, "refused", toolText(t, response))
+
+	require.NoError(t, client.in.Close())
+	assert.NoError(t, <-done)
+}
+
+// Test that stopping the server while a call waits for the user ends the
+// call instead of waiting for an answer that will not come.
+func TestStdioServer_ShutdownPending(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, done := startServer(ctx, t, map[string]any{
+		"elicitation": map[string]any{},
+	})
+
+	request := client.call(t, 50)
+	require.Equal(t, "elicitation/create", request["method"])
+
+	// Read whatever the server still writes, so it is never blocked.
+	go func() {
+		for {
+			if _, err := client.out.ReadBytes('\n'); err != nil {
+				return
+			}
+		}
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not stop")
+	}
+	require.NoError(t, client.in.Close())
+}
+
+// Test that Confirm without a requester fails.
+func TestConfirm_NoRequester(t *testing.T) {
+	approved, err := Confirm(context.Background(), nil, "Pay")
+	assert.False(t, approved)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,399 @@
+package elicit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// methodElicit is the method of an elicitation request to the client.
+const methodElicit = "elicitation/create"
+
+// methodCancelled is the method of the client's notification that it no
+// longer wants the response to a request.
+const methodCancelled = "notifications/cancelled"
+
+// errCallCancelled is the cause of a tool call's context being cancelled
+// by the client.
+var errCallCancelled = errors.New("the client cancelled the call")
+
+// stdioSession is the single client session of a stdio connection.
+type stdioSession struct {
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+}
+
+// SessionID returns the session's ID; stdio has one client, so it is fixed.
+func (s *stdioSession) SessionID() string {
+	return "stdio"
+}
+
+// NotificationChannel returns the channel notifications are relayed from.
+func (s *stdioSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// Initialize marks the session as initialized.
+func (s *stdioSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+// Initialized reports whether the client has initialized the session.
+func (s *stdioSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+var _ server.ClientSession = (*stdioSession)(nil)
+
+// clientMessage is a JSON-RPC message from the client: a request, a
+// notification or a response to a request the server sent.
+type clientMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// request is a JSON-RPC request from the server to the client.
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// StdioServer serves an MCP server over newline-delimited JSON-RPC, like
+// the library's stdio transport, and also carries requests from the server
+// to the client. Tool calls run concurrently, so one waiting for the user
+// does not hold up the messages that answer it; every other message is
+// handled in order. A tool call the client cancels is stopped and left
+// unanswered, as MCP asks.
+type StdioServer struct {
+	server  *server.MCPServer
+	logger  *zap.Logger
+	session *stdioSession
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	// supported records whether the client declared the elicitation
+	// capability when it initialized.
+	supported atomic.Bool
+
+	// Requests sent to the client, keyed by ID, and the channel their
+	// response is delivered on.
+	pendingMu sync.Mutex
+	pending   map[string]chan *clientMessage
+	nextID    atomic.Uint64
+
+	// Tool calls in progress, keyed by their request ID as sent, and
+	// what cancels them.
+	callsMu sync.Mutex
+	calls   map[string]context.CancelCauseFunc
+}
+
+// NewStdioServer creates a stdio transport for mcpServer.
+func NewStdioServer(mcpServer *server.MCPServer,
+	logger *zap.Logger) *StdioServer {
+	return &StdioServer{
+		server: mcpServer,
+		logger: logger,
+		session: &stdioSession{
+			notifications: make(chan mcp.JSONRPCNotification, 100),
+		},
+		pending: make(map[string]chan *clientMessage),
+		calls:   make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// ServeStdio serves mcpServer on standard input and output until input
+// ends or the process receives SIGTERM or SIGINT.
+func ServeStdio(mcpServer *server.MCPServer, logger *zap.Logger) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	return NewStdioServer(mcpServer, logger).Listen(ctx, os.Stdin,
+		os.Stdout)
+}
+
+// Listen reads messages from in and writes responses, notifications and
+// requests to out until in ends or ctx is done. Tool calls still running
+// then are cancelled and waited for.
+func (s *StdioServer) Listen(ctx context.Context, in io.Reader,
+	out io.Writer) error {
+	s.out = out
+
+	if err := s.server.RegisterSession(ctx, s.session); err != nil {
+		return fmt.Errorf("register session: %w", err)
+	}
+	defer s.server.UnregisterSession(ctx, s.session.SessionID())
+
+	ctx, cancel := context.WithCancel(
+		WithRequester(s.server.WithContext(ctx, s.session), s))
+	var calls sync.WaitGroup
+	defer func() {
+		cancel()
+		calls.Wait()
+	}()
+
+	go s.relayNotifications(ctx)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case line := <-lines:
+			s.dispatch(ctx, line, &calls)
+		}
+	}
+}
+
+// dispatch routes one message from the client. Responses go to the request
+// waiting for them, tool calls run in their own goroutine, cancellations
+// stop the call they name, and everything else is handled before the next
+// message is read.
+func (s *StdioServer) dispatch(ctx context.Context, line []byte,
+	calls *sync.WaitGroup) {
+	var msg clientMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		response := mcp.JSONRPCError{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(nil),
+		}
+		response.Error.Code = mcp.PARSE_ERROR
+		response.Error.Message = "Parse error"
+		s.write(response)
+		return
+	}
+
+	switch mcp.MCPMethod(msg.Method) {
+	case "":
+		if len(msg.ID) > 0 {
+			s.resolve(&msg)
+			return
+		}
+
+	case mcp.MethodInitialize:
+		s.supported.Store(declaresElicitation(msg.Params))
+
+	case methodCancelled:
+		s.cancelCall(msg.Params)
+
+	case mcp.MethodToolsCall:
+		id := string(bytes.TrimSpace(msg.ID))
+		callCtx, cancel := context.WithCancelCause(ctx)
+		s.callsMu.Lock()
+		s.calls[id] = cancel
+		s.callsMu.Unlock()
+
+		calls.Add(1)
+		go func() {
+			defer calls.Done()
+			defer func() {
+				s.callsMu.Lock()
+				delete(s.calls, id)
+				s.callsMu.Unlock()
+				cancel(nil)
+			}()
+
+			response := s.server.HandleMessage(callCtx, line)
+			if response != nil &&
+				context.Cause(callCtx) != errCallCancelled {
+				s.write(response)
+			}
+		}()
+		return
+	}
+
+	s.handle(ctx, line)
+}
+
+// cancelCall cancels the tool call a cancellation notification names, if
+// it is still running.
+func (s *StdioServer) cancelCall(params json.RawMessage) {
+	var cancelled struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(params, &cancelled); err != nil {
+		return
+	}
+
+	s.callsMu.Lock()
+	cancel, ok := s.calls[string(bytes.TrimSpace(cancelled.RequestID))]
+	s.callsMu.Unlock()
+
+	if ok {
+		cancel(errCallCancelled)
+	}
+}
+
+// handle passes a request or notification to the MCP server and writes its
+// response, if any.
+func (s *StdioServer) handle(ctx context.Context, line []byte) {
+	if response := s.server.HandleMessage(ctx, line); response != nil {
+		s.write(response)
+	}
+}
+
+// resolve delivers a response to the request that is waiting for it.
+// Responses to requests nobody waits for any more are dropped.
+func (s *StdioServer) resolve(msg *clientMessage) {
+	var id string
+	if err := json.Unmarshal(msg.ID, &id); err != nil {
+		return
+	}
+
+	s.pendingMu.Lock()
+	reply, ok := s.pending[id]
+	delete(s.pending, id)
+	s.pendingMu.Unlock()
+
+	if ok {
+		reply <- msg
+	}
+}
+
+// relayNotifications writes the session's notifications until ctx is done.
+func (s *StdioServer) relayNotifications(ctx context.Context) {
+	for {
+		select {
+		case notification := <-s.session.notifications:
+			s.write(notification)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// write sends one message to the client. Messages are written whole, one
+// per line, whichever goroutine sends them.
+func (s *StdioServer) write(value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Error("Failed to encode message", zap.Error(err))
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(s.out, "%s\n", data); err != nil {
+		s.logger.Error("Failed to write message", zap.Error(err))
+	}
+}
+
+// Elicit asks the client's user for content matching schema and waits for
+// the answer. It fails with ErrUnsupported if the client did not declare
+// the elicitation capability.
+func (s *StdioServer) Elicit(ctx context.Context, message string,
+	schema map[string]any) (*Result, error) {
+	if !s.supported.Load() {
+		return nil, ErrUnsupported
+	}
+
+	id := fmt.Sprintf("elicit-%d", s.nextID.Add(1))
+	reply := make(chan *clientMessage, 1)
+	s.pendingMu.Lock()
+	s.pending[id] = reply
+	s.pendingMu.Unlock()
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, id)
+		s.pendingMu.Unlock()
+	}()
+
+	s.write(request{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Method:  methodElicit,
+		Params: map[string]any{
+			"message":         message,
+			"requestedSchema": schema,
+		},
+	})
+
+	var response *clientMessage
+	select {
+	case response = <-reply:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("elicitation failed: %s (code %d)",
+			response.Error.Message, response.Error.Code)
+	}
+	var result Result
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid elicitation result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// declaresElicitation reports whether initialize params declare the
+// client's elicitation capability.
+func declaresElicitation(params json.RawMessage) bool {
+	var initialize struct {
+		Capabilities struct {
+			Elicitation json.RawMessage `json:"elicitation"`
+		} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(params, &initialize); err != nil {
+		return false
+	}
+
+	elicitation := initialize.Capabilities.Elicitation
+	return len(elicitation) > 0 && string(elicitation) != "null"
+}
@@ -4,6 +4,7 @@
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -39,6 +40,48 @@ const (
 
 	// ErrCodeServerShutdown represents server shutdown error.
 	ErrCodeServerShutdown ErrorCode = 8
+
+	// ErrCodePermissionDenied represents an operation the node or the
+	// server's policy does not allow.
+	ErrCodePermissionDenied ErrorCode = 9
+
+	// ErrCodeRateLimited represents a request rejected because too many
+	// requests were made.
+	ErrCodeRateLimited ErrorCode = 10
+
+	// ErrCodeGraphUnavailable represents the network graph not being
+	// synced or otherwise unavailable.
+	ErrCodeGraphUnavailable ErrorCode = 11
+
+	// ErrCodePaymentNotFound represents a payment lookup with no match.
+	ErrCodePaymentNotFound ErrorCode = 12
+
+	// ErrCodeInvoiceNotFound represents an invoice lookup with no match.
+	ErrCodeInvoiceNotFound ErrorCode = 13
+
+	// ErrCodeInvalidArgument represents a missing or malformed tool
+	// argument.
+	ErrCodeInvalidArgument ErrorCode = 14
+
+	// ErrCodeRPCFailed represents a call to the node or an external
+	// service that failed in a way no more specific code covers.
+	ErrCodeRPCFailed ErrorCode = 15
+
+	// ErrCodeNotFound represents a lookup of any other resource with no
+	// match.
+	ErrCodeNotFound ErrorCode = 16
+
+	// ErrCodeUnsupported represents an operation the node or this server
+	// does not support.
+	ErrCodeUnsupported ErrorCode = 17
+
+	// ErrCodeWalletLocked represents a node whose wallet is locked or not
+	// yet created, so it cannot serve requests until the operator acts.
+	ErrCodeWalletLocked ErrorCode = 18
+
+	// ErrCodeStandby represents a call to a clustered instance that does
+	// not hold the leader lease.
+	ErrCodeStandby ErrorCode = 19
 )
 
 // String returns a human-readable description of the error code.
@@ -62,16 +105,53 @@ func (e ErrorCode) String() string {
 		return "InvalidAddress"
 	case ErrCodeServerShutdown:
 		return "ServerShutdown"
+	case ErrCodePermissionDenied:
+		return "PermissionDenied"
+	case ErrCodeRateLimited:
+		return "RateLimited"
+	case ErrCodeGraphUnavailable:
+		return "GraphUnavailable"
+	case ErrCodePaymentNotFound:
+		return "PaymentNotFound"
+	case ErrCodeInvoiceNotFound:
+		return "InvoiceNotFound"
+	case ErrCodeInvalidArgument:
+		return "InvalidArgument"
+	case ErrCodeRPCFailed:
+		return "RPCFailed"
+	case ErrCodeNotFound:
+		return "NotFound"
+	case ErrCodeUnsupported:
+		return "Unsupported"
+	case ErrCodeWalletLocked:
+		return "WalletLocked"
+	case ErrCodeStandby:
+		return "Standby"
 	default:
 		return fmt.Sprintf("Unknown(%d)", uint32(e))
 	}
 }
 
+// Retryable reports whether an operation that failed with this code may
+// succeed if retried later without changes.
+func (e ErrorCode) Retryable() bool {
+	switch e {
+	case ErrCodeConnectionFailed, ErrCodeTimeout, ErrCodeRateLimited,
+		ErrCodeGraphUnavailable, ErrCodeStandby:
+		return true
+	default:
+		return false
+	}
+}
+
 // Error represents a structured error with code and context.
 type Error struct {
 	Code    ErrorCode
 	Message string
 	Cause   error
+
+	// Details carries optional machine-readable context for clients.
+	Details map[string]any
 }
 
 // Error implements the error interface.
@@ -87,6 +167,48 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
+// Payload is the machine-readable form of an Error returned to MCP clients.
+type Payload struct {
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Retryable bool           `json:"retryable"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Payload returns the machine-readable form of the error. The cause, if
+// any, is folded into the message.
+func (e *Error) Payload() Payload {
+	message := e.Message
+	if e.Cause != nil {
+		message = fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return Payload{
+		Code:      e.Code.String(),
+		Message:   message,
+		Retryable: e.Code.Retryable(),
+		Details:   e.Details,
+	}
+}
+
+// WithDetails attaches machine-readable context to the error and returns it.
+func (e *Error) WithDetails(details map[string]any) *Error {
+	e.Details = details
+	return e
+}
+
+// JSON returns the error payload encoded as a JSON object.
+func (e *Error) JSON() string {
+	encoded, err := json.Marshal(e.Payload())
+	if err != nil {
+		// Details held something unencodable; drop them rather than
+		// lose the error itself.
+		payload := e.Payload()
+		payload.Details = nil
+		encoded, _ = json.Marshal(payload)
+	}
+	return string(encoded)
+}
+
 // New creates a new structured error.
 func New(code ErrorCode, message string) *Error {
 	return &Error{
@@ -181,3 +303,38 @@ func ErrInvalidAddress(addr string) *Error {
 	return New(ErrCodeInvalidAddress,
 		"invalid address format: "+addr)
 }
+
+// ErrPermissionDenied creates a permission denied error.
+func ErrPermissionDenied(operation string) *Error {
+	return New(ErrCodePermissionDenied,
+		"permission denied: "+operation)
+}
+
+// ErrRateLimited creates a rate limited error.
+func ErrRateLimited(operation string) *Error {
+	return New(ErrCodeRateLimited,
+		"rate limited: "+operation)
+}
+
+// ErrGraphUnavailable creates a graph unavailable error.
+func ErrGraphUnavailable(cause error) *Error {
+	return Wrap(cause, ErrCodeGraphUnavailable,
+		"network graph is unavailable")
+}
+
+// ErrPaymentNotFound creates a payment not found error.
+func ErrPaymentNotFound(paymentHash string) *Error {
+	return New(ErrCodePaymentNotFound,
+		"payment not found: "+paymentHash)
+}
+
+// ErrInvoiceNotFound creates an invoice not found error.
+func ErrInvoiceNotFound(paymentHash string) *Error {
+	return New(ErrCodeInvoiceNotFound,
+		"invoice not found: "+paymentHash)
+}
+
+// ErrInvalidArgument creates an invalid argument error.
+func ErrInvalidArgument(message string) *Error {
+	return New(ErrCodeInvalidArgument, message)
+}
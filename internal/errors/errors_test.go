@@ -1,10 +1,12 @@
 package errors
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test error code constants.
@@ -19,6 +21,17 @@ func TestErrorCodes(t *testing.T) {
 	assert.Equal(t, ErrorCode(6), ErrCodeInsufficientBalance)
 	assert.Equal(t, ErrorCode(7), ErrCodeInvalidAddress)
 	assert.Equal(t, ErrorCode(8), ErrCodeServerShutdown)
+	assert.Equal(t, ErrorCode(9), ErrCodePermissionDenied)
+	assert.Equal(t, ErrorCode(10), ErrCodeRateLimited)
+	assert.Equal(t, ErrorCode(11), ErrCodeGraphUnavailable)
+	assert.Equal(t, ErrorCode(12), ErrCodePaymentNotFound)
+	assert.Equal(t, ErrorCode(13), ErrCodeInvoiceNotFound)
+	assert.Equal(t, ErrorCode(14), ErrCodeInvalidArgument)
+	assert.Equal(t, ErrorCode(15), ErrCodeRPCFailed)
+	assert.Equal(t, ErrorCode(16), ErrCodeNotFound)
+	assert.Equal(t, ErrorCode(17), ErrCodeUnsupported)
+	assert.Equal(t, ErrorCode(18), ErrCodeWalletLocked)
+	assert.Equal(t, ErrorCode(19), ErrCodeStandby)
 }
 
 // Test New function creates proper error.
@@ -258,6 +271,17 @@ func TestErrorCodeString(t *testing.T) {
 		{ErrCodeInsufficientBalance, "InsufficientBalance"},
 		{ErrCodeInvalidAddress, "InvalidAddress"},
 		{ErrCodeServerShutdown, "ServerShutdown"},
+		{ErrCodePermissionDenied, "PermissionDenied"},
+		{ErrCodeRateLimited, "RateLimited"},
+		{ErrCodeGraphUnavailable, "GraphUnavailable"},
+		{ErrCodePaymentNotFound, "PaymentNotFound"},
+		{ErrCodeInvoiceNotFound, "InvoiceNotFound"},
+		{ErrCodeInvalidArgument, "InvalidArgument"},
+		{ErrCodeRPCFailed, "RPCFailed"},
+		{ErrCodeNotFound, "NotFound"},
+		{ErrCodeUnsupported, "Unsupported"},
+		{ErrCodeWalletLocked, "WalletLocked"},
+		{ErrCodeStandby, "Standby"},
 		{ErrorCode(999), "Unknown(999)"},
 	}
 
@@ -328,3 +352,69 @@ func TestCommonErrorConstructors(t *testing.T) {
 		assert.Nil(t, err.Cause)
 	})
 }
+
+// Test which codes are marked retryable.
+func TestErrorCode_Retryable(t *testing.T) {
+	retryable := []ErrorCode{
+		ErrCodeConnectionFailed,
+		ErrCodeTimeout,
+		ErrCodeRateLimited,
+		ErrCodeGraphUnavailable,
+		ErrCodeStandby,
+	}
+	for _, code := range retryable {
+		assert.True(t, code.Retryable(), code.String())
+	}
+
+	permanent := []ErrorCode{
+		ErrCodeInvalidArgument,
+		ErrCodePermissionDenied,
+		ErrCodePaymentNotFound,
+		ErrCodeNotConnected,
+		ErrCodeWalletLocked,
+	}
+	for _, code := range permanent {
+		assert.False(t, code.Retryable(), code.String())
+	}
+}
+
+// Test the machine-readable payload returned to MCP clients.
+func TestError_JSON(t *testing.T) {
+	err := Wrap(errors.New("deadline exceeded"), ErrCodeTimeout,
+		"failed to list channels")
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(err.JSON()), &payload))
+	assert.Equal(t, "Timeout", payload["code"])
+	assert.Equal(t, "failed to list channels: deadline exceeded",
+		payload["message"])
+	assert.Equal(t, true, payload["retryable"])
+
+	payload = nil
+	require.NoError(t, json.Unmarshal(
+		[]byte(ErrInvoiceNotFound("abc").JSON()), &payload))
+	assert.Equal(t, "InvoiceNotFound", payload["code"])
+	assert.Equal(t, false, payload["retryable"])
+}
+
+// Test that details are included in the payload when present.
+func TestError_JSONDetails(t *testing.T) {
+	err := ErrNotConnected().WithDetails(map[string]any{
+		"status": "disconnected",
+	})
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(err.JSON()), &payload))
+	details, ok := payload["details"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "disconnected", details["status"])
+
+	// Unencodable details are dropped, not the error.
+	err = ErrNotConnected().WithDetails(map[string]any{
+		"bad": make(chan int),
+	})
+	payload = nil
+	require.NoError(t, json.Unmarshal([]byte(err.JSON()), &payload))
+	assert.Equal(t, "NotConnected", payload["code"])
+	assert.NotContains(t, payload, "details")
+}
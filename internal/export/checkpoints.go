@@ -0,0 +1,251 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/store"
+)
+
+// CheckpointsFile is the name checkpoints are kept under in the export
+// directory. Export names cannot start with a dot, so no export replaces
+// it.
+const CheckpointsFile = ".checkpoints.json"
+
+// checkpointsVersion is the version of the checkpoint file's format.
+const checkpointsVersion = 1
+
+// checkpointsFormat describes the checkpoint file's format. It started
+// with a checksum, so there is nothing to migrate yet.
+var checkpointsFormat = store.Format{
+	Name:    "export checkpoints",
+	Version: checkpointsVersion,
+	Entries: "checkpoints",
+}
+
+// Checkpoint is where the last incremental export of one kind of records
+// ended.
+type Checkpoint struct {
+	Records   string    `json:"records"`
+	LastIndex uint64    `json:"last_index"`
+	File      string    `json:"file"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// checkpointsFile is the on-disk form of the checkpoints.
+type checkpointsFile struct {
+	Version     int           `json:"version"`
+	Checksum    string        `json:"checksum"`
+	Checkpoints []*Checkpoint `json:"checkpoints"`
+}
+
+// Checkpoints remembers where incremental exports ended, so the next one
+// fetches only newer records. It is safe for concurrent use; a nil
+// Checkpoints remembers nothing.
+type Checkpoints struct {
+	path string
+
+	mu          sync.Mutex
+	checkpoints map[string]*Checkpoint
+}
+
+// OpenCheckpoints loads the checkpoints kept at path, starting with none if
+// the file does not exist yet.
+func OpenCheckpoints(path string) (*Checkpoints, error) {
+	c := &Checkpoints{
+		path:        path,
+		checkpoints: make(map[string]*Checkpoint),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, _, err := decodeCheckpoints(path, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, checkpoint := range checkpoints {
+		c.checkpoints[checkpoint.Records] = checkpoint
+	}
+	return c, nil
+}
+
+// Get returns the checkpoint of records, if an incremental export of them
+// has been made.
+func (c *Checkpoints) Get(records string) (Checkpoint, bool) {
+	if c == nil {
+		return Checkpoint{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checkpoint, ok := c.checkpoints[records]
+	if !ok {
+		return Checkpoint{}, false
+	}
+	return *checkpoint, true
+}
+
+// Set records that the incremental export of records written to file
+// ended at lastIndex.
+func (c *Checkpoints) Set(records string, lastIndex uint64,
+	file string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous, had := c.checkpoints[records]
+	c.checkpoints[records] = &Checkpoint{
+		Records:   records,
+		LastIndex: lastIndex,
+		File:      file,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := c.save(); err != nil {
+		if had {
+			c.checkpoints[records] = previous
+		} else {
+			delete(c.checkpoints, records)
+		}
+		return err
+	}
+	return nil
+}
+
+// All returns the checkpoints, ordered by the records they are for.
+func (c *Checkpoints) All() []Checkpoint {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	all := make([]Checkpoint, 0, len(c.checkpoints))
+	for _, checkpoint := range c.checkpoints {
+		all = append(all, *checkpoint)
+	}
+	sort.Slice(all, func(a, b int) bool {
+		return all[a].Records < all[b].Records
+	})
+	return all
+}
+
+// Path returns the file the checkpoints are kept in.
+func (c *Checkpoints) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.path
+}
+
+// Check reads the checkpoint file back, verifies its integrity and returns
+// the format version it is stored in. A file not written yet passes with
+// version 0.
+func (c *Checkpoints) Check() (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, version, err := decodeCheckpoints(c.path, data)
+	return version, err
+}
+
+// Vacuum removes temporary files left by writes a crash interrupted and
+// rewrites the checkpoints. It returns how many files it removed.
+func (c *Checkpoints) Vacuum() (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed, err := store.RemoveTemp(c.path)
+	if err != nil {
+		return removed, err
+	}
+	return removed, c.save()
+}
+
+// save writes the checkpoints to disk. The caller holds mu.
+func (c *Checkpoints) save() error {
+	stored := checkpointsFile{
+		Version:     checkpointsVersion,
+		Checkpoints: make([]*Checkpoint, 0, len(c.checkpoints)),
+	}
+	for _, checkpoint := range c.checkpoints {
+		stored.Checkpoints = append(stored.Checkpoints, checkpoint)
+	}
+	sort.Slice(stored.Checkpoints, func(a, b int) bool {
+		return stored.Checkpoints[a].Records <
+			stored.Checkpoints[b].Records
+	})
+
+	checksum, err := store.Checksum(stored.Checkpoints)
+	if err != nil {
+		return err
+	}
+	stored.Checksum = checksum
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(c.path, data)
+}
+
+// decodeCheckpoints parses a checkpoint file and checks its integrity: the
+// checksum of its checkpoints, and that each kind of records has at most
+// one. It returns the checkpoints and the version the file was stored at.
+func decodeCheckpoints(path string, data []byte) ([]*Checkpoint, int,
+	error) {
+	entries, version, err := checkpointsFormat.Decode(path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var checkpoints []*Checkpoint
+	if err := json.Unmarshal(entries, &checkpoints); err != nil {
+		return nil, 0, err
+	}
+	seen := make(map[string]bool, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		if checkpoint == nil || checkpoint.Records == "" {
+			return nil, 0, fmt.Errorf("export checkpoints %s has "+
+				"a checkpoint without records", path)
+		}
+		if seen[checkpoint.Records] {
+			return nil, 0, fmt.Errorf("export checkpoints %s has "+
+				"more than one checkpoint for %s", path,
+				checkpoint.Records)
+		}
+		seen[checkpoint.Records] = true
+	}
+	return checkpoints, version, nil
+}
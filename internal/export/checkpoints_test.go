@@ -0,0 +1,78 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that checkpoints survive a restart, and that each kind of records
+// has its own.
+func TestCheckpoints_Persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".checkpoints.json")
+	checkpoints, err := OpenCheckpoints(path)
+	require.NoError(t, err)
+	_, ok := checkpoints.Get("payments")
+	assert.False(t, ok)
+
+	require.NoError(t, checkpoints.Set("payments", 10, "a.jsonl"))
+	require.NoError(t, checkpoints.Set("invoices", 4, "b.parquet"))
+	require.NoError(t, checkpoints.Set("payments", 25, "c.jsonl"))
+
+	reopened, err := OpenCheckpoints(path)
+	require.NoError(t, err)
+	checkpoint, ok := reopened.Get("payments")
+	require.True(t, ok)
+	assert.EqualValues(t, 25, checkpoint.LastIndex)
+	assert.Equal(t, "c.jsonl", checkpoint.File)
+	assert.False(t, checkpoint.UpdatedAt.IsZero())
+
+	all := reopened.All()
+	require.Len(t, all, 2)
+	assert.Equal(t, "invoices", all[0].Records)
+
+	version, err := reopened.Check()
+	require.NoError(t, err)
+	assert.Equal(t, checkpointsVersion, version)
+}
+
+// Test that a checkpoint file changed behind the server's back is refused,
+// as resuming from a wrong index would skip or repeat records.
+func TestCheckpoints_Integrity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".checkpoints.json")
+	checkpoints, err := OpenCheckpoints(path)
+	require.NoError(t, err)
+	require.NoError(t, checkpoints.Set("payments", 10, "a.jsonl"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(
+		string(data), `"last_index": 10`, `"last_index": 1`, 1)),
+		0o600))
+
+	_, err = checkpoints.Check()
+	assert.ErrorContains(t, err, "integrity check")
+	_, err = OpenCheckpoints(path)
+	assert.ErrorContains(t, err, "integrity check")
+
+	// Vacuuming rewrites the file from what the server holds.
+	_, err = checkpoints.Vacuum()
+	require.NoError(t, err)
+	reopened, err := OpenCheckpoints(path)
+	require.NoError(t, err)
+	checkpoint, _ := reopened.Get("payments")
+	assert.EqualValues(t, 10, checkpoint.LastIndex)
+}
+
+// Test that nil checkpoints remember nothing.
+func TestCheckpoints_Nil(t *testing.T) {
+	var checkpoints *Checkpoints
+	assert.NoError(t, checkpoints.Set("payments", 10, "a.jsonl"))
+	_, ok := checkpoints.Get("payments")
+	assert.False(t, ok)
+	assert.Empty(t, checkpoints.All())
+}
@@ -0,0 +1,153 @@
+// Package export writes tables of node records, such as payments, invoices
+// and forwards, to files for analysis outside the server. A table is
+// written as JSON lines, as a Parquet file or as a SQLite database, the
+// latter two for datasets large enough that tools like DuckDB and pandas
+// should not have to parse text. Checkpoints remember where incremental
+// exports ended, so the next one fetches only newer records.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Format is a file format tables are exported in.
+type Format string
+
+// Export formats.
+const (
+	FormatJSONLines Format = "jsonl"
+	FormatParquet   Format = "parquet"
+	FormatSQLite    Format = "sqlite"
+)
+
+// Formats lists the export formats, the default first.
+var Formats = []Format{FormatJSONLines, FormatParquet, FormatSQLite}
+
+// Valid reports whether f is a known format.
+func (f Format) Valid() bool {
+	for _, format := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Type is the type of a column's values.
+type Type int
+
+// Column types. Values are int64, string and bool respectively, and none
+// may be missing.
+const (
+	Int64 Type = iota
+	String
+	Bool
+)
+
+// Column is one column of a table.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Table is a named set of rows, each holding one value per column in the
+// column's order.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+// check verifies that every row has a value of the right type for each
+// column, so the writers can rely on it.
+func (t Table) check() error {
+	for i, row := range t.Rows {
+		if len(row) != len(t.Columns) {
+			return fmt.Errorf("%s row %d has %d values for %d "+
+				"columns", t.Name, i, len(row), len(t.Columns))
+		}
+		for j, column := range t.Columns {
+			var ok bool
+			switch column.Type {
+			case Int64:
+				_, ok = row[j].(int64)
+			case String:
+				_, ok = row[j].(string)
+			case Bool:
+				_, ok = row[j].(bool)
+			}
+			if !ok {
+				return fmt.Errorf("%s row %d has a %T for "+
+					"column %s", t.Name, i, row[j],
+					column.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ErrExists is returned when exporting over an existing file.
+var ErrExists = errors.New("a file with that name already exists")
+
+// Write exports table to a new file at path in format, with owner-only
+// permissions. The file is written next to path first and only then given
+// its name, so a failed export leaves nothing behind, and an existing file
+// is never replaced.
+func Write(path string, format Format, table Table) error {
+	if err := table.check(); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return ErrExists
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path),
+		filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	switch format {
+	case FormatJSONLines:
+		err = writeJSONLines(tmp, table)
+	case FormatParquet:
+		err = writeParquet(tmp, table)
+	case FormatSQLite:
+		// The database opens the file itself.
+		if err = tmp.Close(); err == nil {
+			err = writeSQLite(tmp.Name(), table)
+		}
+	default:
+		err = fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if format != FormatSQLite {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+	}
+
+	// Linking fails rather than replacing a file created meanwhile.
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if os.IsExist(err) {
+			return ErrExists
+		}
+		return err
+	}
+	return nil
+}
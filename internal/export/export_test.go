@@ -0,0 +1,276 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTable has a column of each type and enough rows for the booleans to
+// take more than one byte.
+func testTable() Table {
+	table := Table{
+		Name: "payments",
+		Columns: []Column{
+			{Name: "payment_index", Type: Int64},
+			{Name: "status", Type: String},
+			{Name: "amp", Type: Bool},
+		},
+	}
+	for i := int64(0); i < 10; i++ {
+		status := "SUCCEEDED"
+		if i%3 == 0 {
+			status = "FAILED"
+		}
+		table.Rows = append(table.Rows,
+			[]any{i - 2, status, i%4 == 1})
+	}
+	return table
+}
+
+// Test that JSON lines exports hold one object per row, with every column.
+func TestWrite_JSONLines(t *testing.T) {
+	table := testTable()
+	path := filepath.Join(t.TempDir(), "payments.jsonl")
+	require.NoError(t, Write(path, FormatJSONLines, table))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	lines := bufio.NewScanner(file)
+	var rows []map[string]any
+	for lines.Scan() {
+		var row map[string]any
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	require.Len(t, rows, len(table.Rows))
+	assert.Equal(t, map[string]any{
+		"payment_index": float64(-1),
+		"status":        "SUCCEEDED",
+		"amp":           true,
+	}, rows[1])
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+// Test that SQLite exports hold the table with its column types.
+func TestWrite_SQLite(t *testing.T) {
+	table := testTable()
+	path := filepath.Join(t.TempDir(), "payments.sqlite")
+	require.NoError(t, Write(path, FormatSQLite, table))
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var count int
+	require.NoError(t, db.QueryRow(
+		`SELECT COUNT(*) FROM payments`).Scan(&count))
+	assert.Equal(t, len(table.Rows), count)
+
+	var index int64
+	var status string
+	var amp bool
+	require.NoError(t, db.QueryRow(`SELECT payment_index, status, amp `+
+		`FROM payments WHERE payment_index = -1`).Scan(&index, &status,
+		&amp))
+	assert.Equal(t, []any{int64(-1), "SUCCEEDED", true},
+		[]any{index, status, amp})
+
+	// Nothing but the database is left in the directory.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// Test that Parquet exports carry the schema in their footer and each
+// column's values in a plain data page where the footer says.
+func TestWrite_Parquet(t *testing.T) {
+	table := testTable()
+	path := filepath.Join(t.TempDir(), "payments.parquet")
+	require.NoError(t, Write(path, FormatParquet, table))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, parquetMagic, string(data[:4]))
+	require.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	size := binary.LittleEndian.Uint32(data[len(data)-8:])
+	footer := data[len(data)-8-int(size) : len(data)-8]
+
+	meta, n := readThriftStruct(t, footer)
+	assert.Len(t, footer, n)
+	assert.EqualValues(t, 1, meta[1])
+	assert.EqualValues(t, len(table.Rows), meta[3])
+	assert.Equal(t, "mcp-lnc-server", meta[6])
+
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(table.Columns)+1)
+	assert.Equal(t, "payments", schema[0].(map[int16]any)[4])
+	assert.EqualValues(t, len(table.Columns), schema[0].(map[int16]any)[5])
+	status := schema[2].(map[int16]any)
+	assert.Equal(t, "status", status[4])
+	assert.EqualValues(t, parquetByteArray, status[1])
+	assert.EqualValues(t, parquetRequired, status[3])
+	assert.EqualValues(t, parquetUTF8, status[6])
+
+	groups := meta[4].([]any)
+	require.Len(t, groups, 1)
+	chunks := groups[0].(map[int16]any)[1].([]any)
+	require.Len(t, chunks, len(table.Columns))
+
+	columns := make([][]any, len(chunks))
+	for i, chunk := range chunks {
+		column := chunk.(map[int16]any)[3].(map[int16]any)
+		assert.Equal(t, []any{table.Columns[i].Name}, column[3])
+		assert.EqualValues(t, parquetUncompressed, column[4])
+		assert.EqualValues(t, len(table.Rows), column[5])
+
+		offset := column[9].(int64)
+		header, n := readThriftStruct(t, data[offset:])
+		assert.EqualValues(t, parquetDataPage, header[1])
+		page := header[5].(map[int16]any)
+		assert.EqualValues(t, len(table.Rows), page[1])
+		assert.EqualValues(t, parquetPlain, page[2])
+		assert.EqualValues(t, column[6], int64(n)+int64(
+			header[3].(int64)))
+
+		values := data[offset+int64(n):]
+		for row := range table.Rows {
+			switch table.Columns[i].Type {
+			case Int64:
+				columns[i] = append(columns[i], int64(
+					binary.LittleEndian.Uint64(values)))
+				values = values[8:]
+			case String:
+				length := binary.LittleEndian.Uint32(values)
+				columns[i] = append(columns[i],
+					string(values[4:4+length]))
+				values = values[4+length:]
+			case Bool:
+				columns[i] = append(columns[i],
+					values[row/8]&(1<<(row%8)) != 0)
+			}
+		}
+	}
+	for row, values := range table.Rows {
+		for i := range table.Columns {
+			assert.Equal(t, values[i], columns[i][row])
+		}
+	}
+}
+
+// Test that a table without rows still makes a readable file in every
+// format.
+func TestWrite_Empty(t *testing.T) {
+	table := testTable()
+	table.Rows = nil
+	for _, format := range Formats {
+		path := filepath.Join(t.TempDir(), "empty."+string(format))
+		require.NoError(t, Write(path, format, table), format)
+		assert.FileExists(t, path)
+	}
+}
+
+// Test that exports never replace a file, and refuse rows that do not fit
+// the columns before writing anything.
+func TestWrite_Refused(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "payments.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("kept"), 0o600))
+	assert.ErrorIs(t, Write(path, FormatJSONLines, testTable()),
+		ErrExists)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(data))
+
+	table := testTable()
+	table.Rows[3][0] = "three"
+	assert.ErrorContains(t, Write(filepath.Join(dir, "bad.parquet"),
+		FormatParquet, table), "column payment_index")
+	table.Rows[3] = table.Rows[3][:2]
+	assert.ErrorContains(t, Write(filepath.Join(dir, "bad.parquet"),
+		FormatParquet, table), "2 values for 3 columns")
+
+	assert.Error(t, Write(filepath.Join(dir, "bad.csv"), "csv",
+		testTable()))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// readThriftStruct decodes a thrift compact protocol struct into its fields
+// by ID, as far as the types the exports write, and returns how many bytes
+// it took.
+func readThriftStruct(t *testing.T, data []byte) (map[int16]any, int) {
+	reader := bytes.NewReader(data)
+	fields := readStruct(t, reader)
+	return fields, len(data) - reader.Len()
+}
+
+func readStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header, err := r.ReadByte()
+		require.NoError(t, err)
+		if header == 0 {
+			return fields
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(readZigzag(t, r))
+		}
+		fields[last] = readValue(t, r, typ)
+	}
+}
+
+func readValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return readZigzag(t, r)
+	case thriftBinary:
+		length, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		value := make([]byte, length)
+		_, err = io.ReadFull(r, value)
+		require.NoError(t, err)
+		return string(value)
+	case thriftStruct:
+		return readStruct(t, r)
+	case thriftList:
+		header, err := r.ReadByte()
+		require.NoError(t, err)
+		size := uint64(header >> 4)
+		if size == 15 {
+			size, err = binary.ReadUvarint(r)
+			require.NoError(t, err)
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = readValue(t, r, header&0x0f)
+		}
+		return list
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func readZigzag(t *testing.T, r *bytes.Reader) int64 {
+	value, err := binary.ReadUvarint(r)
+	require.NoError(t, err)
+	return int64(value>>1) ^ -int64(value&1)
+}
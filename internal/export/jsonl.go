@@ -0,0 +1,34 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// writeJSONLines writes each row as a JSON object on a line of its own,
+// with the fields in column order.
+func writeJSONLines(w io.Writer, table Table) error {
+	out := bufio.NewWriter(w)
+	for _, row := range table.Rows {
+		out.WriteByte('{')
+		for i, column := range table.Columns {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			name, err := json.Marshal(column.Name)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(row[i])
+			if err != nil {
+				return err
+			}
+			out.Write(name)
+			out.WriteByte(':')
+			out.Write(value)
+		}
+		out.WriteString("}\n")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,273 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// parquetMagic starts and ends every Parquet file.
+const parquetMagic = "PAR1"
+
+// Values from the Parquet format's thrift definitions: physical types,
+// the repetition type, the converted type for text, encodings, the page
+// type and the codec. Only what these exports write is listed.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetUTF8     = 0
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+// parquetTypes are the physical Parquet types of the column types.
+var parquetTypes = map[Type]int32{
+	Int64:  parquetInt64,
+	String: parquetByteArray,
+	Bool:   parquetBoolean,
+}
+
+// Thrift compact protocol type codes.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// writeParquet writes table as a Parquet file: one row group of required
+// columns, each a single uncompressed data page of plainly encoded values.
+// That is the simplest layout every reader supports, and what the file
+// loses in size it keeps in needing no codec dependency.
+func writeParquet(w io.Writer, table Table) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(table.Columns))
+	if len(table.Rows) > 0 {
+		for i, column := range table.Columns {
+			values := parquetValues(table, i, column.Type)
+
+			var header thriftWriter
+			header.begin()
+			header.i32(1, parquetDataPage)
+			header.i32(2, int32(len(values)))
+			header.i32(3, int32(len(values)))
+			header.structField(5, func() {
+				header.i32(1, int32(len(table.Rows)))
+				header.i32(2, parquetPlain)
+				header.i32(3, parquetRLE)
+				header.i32(4, parquetRLE)
+			})
+			header.end()
+
+			chunks[i].offset = int64(file.Len())
+			file.Write(header.buf.Bytes())
+			file.Write(values)
+			chunks[i].size = int64(file.Len()) - chunks[i].offset
+		}
+	}
+
+	var meta thriftWriter
+	meta.begin()
+	meta.i32(1, 1)
+	meta.structList(2, len(table.Columns)+1, func(i int) {
+		if i == 0 {
+			meta.binary(4, []byte(table.Name))
+			meta.i32(5, int32(len(table.Columns)))
+			return
+		}
+		column := table.Columns[i-1]
+		meta.i32(1, parquetTypes[column.Type])
+		meta.i32(3, parquetRequired)
+		meta.binary(4, []byte(column.Name))
+		if column.Type == String {
+			meta.i32(6, parquetUTF8)
+		}
+	})
+	meta.i64(3, int64(len(table.Rows)))
+
+	// A table without rows has no row group to hold.
+	groups := 0
+	if len(table.Rows) > 0 {
+		groups = 1
+	}
+	meta.structList(4, groups, func(int) {
+		var total int64
+		meta.structList(1, len(table.Columns), func(i int) {
+			column := table.Columns[i]
+			meta.i64(2, chunks[i].offset)
+			meta.structField(3, func() {
+				meta.i32(1, parquetTypes[column.Type])
+				meta.i32List(2, parquetPlain, parquetRLE)
+				meta.binaryList(3, []byte(column.Name))
+				meta.i32(4, parquetUncompressed)
+				meta.i64(5, int64(len(table.Rows)))
+				meta.i64(6, chunks[i].size)
+				meta.i64(7, chunks[i].size)
+				meta.i64(9, chunks[i].offset)
+			})
+			total += chunks[i].size
+		})
+		meta.i64(2, total)
+		meta.i64(3, int64(len(table.Rows)))
+	})
+	meta.binary(6, []byte("mcp-lnc-server"))
+	meta.end()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetValues encodes the values of one column plainly: integers as
+// eight little-endian bytes, text prefixed by its four-byte length and
+// booleans packed eight to a byte, least significant bit first.
+func parquetValues(table Table, column int, typ Type) []byte {
+	var values bytes.Buffer
+	switch typ {
+	case Int64:
+		for _, row := range table.Rows {
+			binary.Write(&values, binary.LittleEndian,
+				row[column].(int64))
+		}
+	case String:
+		for _, row := range table.Rows {
+			text := row[column].(string)
+			binary.Write(&values, binary.LittleEndian,
+				uint32(len(text)))
+			values.WriteString(text)
+		}
+	case Bool:
+		packed := make([]byte, (len(table.Rows)+7)/8)
+		for i, row := range table.Rows {
+			if row[column].(bool) {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		values.Write(packed)
+	}
+	return values.Bytes()
+}
+
+// thriftWriter encodes structs in the thrift compact protocol, which
+// Parquet uses for its page headers and file metadata.
+type thriftWriter struct {
+	buf bytes.Buffer
+
+	// fields holds the last field ID written in each struct being
+	// written, innermost last, as field IDs are encoded as deltas.
+	fields []int16
+}
+
+// begin starts a struct.
+func (w *thriftWriter) begin() {
+	w.fields = append(w.fields, 0)
+}
+
+// end finishes the struct begin started.
+func (w *thriftWriter) end() {
+	w.buf.WriteByte(0)
+	w.fields = w.fields[:len(w.fields)-1]
+}
+
+// field writes the header of a field.
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.fields[len(w.fields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+// list writes the header of a list of size elements of type typ.
+func (w *thriftWriter) list(size int, typ byte) {
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | typ)
+		return
+	}
+	w.buf.WriteByte(0xf0 | typ)
+	w.varint(uint64(size))
+}
+
+func (w *thriftWriter) i32(id int16, value int32) {
+	w.field(id, thriftI32)
+	w.varint(zigzag(int64(value)))
+}
+
+func (w *thriftWriter) i64(id int16, value int64) {
+	w.field(id, thriftI64)
+	w.varint(zigzag(value))
+}
+
+func (w *thriftWriter) binary(id int16, value []byte) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(value)))
+	w.buf.Write(value)
+}
+
+// structField writes a struct field whose fields fill writes.
+func (w *thriftWriter) structField(id int16, fill func()) {
+	w.field(id, thriftStruct)
+	w.begin()
+	fill()
+	w.end()
+}
+
+// structList writes a list of size structs, the fields of the i-th of
+// which fill(i) writes.
+func (w *thriftWriter) structList(id int16, size int, fill func(i int)) {
+	w.field(id, thriftList)
+	w.list(size, thriftStruct)
+	for i := 0; i < size; i++ {
+		w.begin()
+		fill(i)
+		w.end()
+	}
+}
+
+func (w *thriftWriter) i32List(id int16, values ...int32) {
+	w.field(id, thriftList)
+	w.list(len(values), thriftI32)
+	for _, value := range values {
+		w.varint(zigzag(int64(value)))
+	}
+}
+
+func (w *thriftWriter) binaryList(id int16, values ...[]byte) {
+	w.field(id, thriftList)
+	w.list(len(values), thriftBinary)
+	for _, value := range values {
+		w.varint(uint64(len(value)))
+		w.buf.Write(value)
+	}
+}
+
+// varint writes an unsigned LEB128 varint.
+func (w *thriftWriter) varint(value uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, value))
+}
+
+// zigzag maps signed integers to unsigned ones so small magnitudes of
+// either sign encode short.
+func zigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"database/sql"
+	"strings"
+
+	// Registers the pure Go "sqlite" driver, so exports need no cgo.
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTypes are the declared SQLite types of the column types.
+var sqliteTypes = map[Type]string{
+	Int64:  "INTEGER",
+	String: "TEXT",
+	Bool:   "BOOLEAN",
+}
+
+// writeSQLite creates a database at path holding table, with its rows
+// inserted in one transaction.
+func writeSQLite(path string, table Table) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	columns := make([]string, len(table.Columns))
+	names := make([]string, len(table.Columns))
+	params := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		names[i] = quoteIdentifier(column.Name)
+		columns[i] = names[i] + " " + sqliteTypes[column.Type] +
+			" NOT NULL"
+		params[i] = "?"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("CREATE TABLE " + quoteIdentifier(table.Name) +
+		" (" + strings.Join(columns, ", ") + ")")
+	if err != nil {
+		return err
+	}
+	insert, err := tx.Prepare("INSERT INTO " +
+		quoteIdentifier(table.Name) + " (" + strings.Join(names, ", ") +
+		") VALUES (" + strings.Join(params, ", ") + ")")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, row := range table.Rows {
+		if _, err := insert.Exec(row...); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// quoteIdentifier quotes a table or column name for SQL.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
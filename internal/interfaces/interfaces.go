@@ -131,6 +131,13 @@ type MCPServer interface {
 	AddTool(tool mcp.Tool, handler server.ToolHandlerFunc)
 }
 
+// ResourceServer is implemented by MCP servers that can also serve resource
+// templates. Servers without it are given tools only.
+type ResourceServer interface {
+	AddResourceTemplate(template mcp.ResourceTemplate,
+		handler server.ResourceTemplateHandlerFunc)
+}
+
 // LightningClients holds all the Lightning Network client interfaces.
 type LightningClients struct {
 	Lightning LightningClient
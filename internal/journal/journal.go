@@ -0,0 +1,413 @@
+// Package journal persists the asynchronous operations write tools start,
+// such as payments in flight and channels being opened, so a restarted
+// server can resume tracking them and report their outcome instead of
+// losing them with the process.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/store"
+)
+
+// Kinds of journaled operations.
+const (
+	// KindPayment is an outgoing payment, keyed by its payment hash.
+	KindPayment = "payment"
+
+	// KindChannelOpen is a channel being opened, keyed by its channel
+	// point once the funding transaction is published.
+	KindChannelOpen = "channel_open"
+)
+
+// States of a journaled operation.
+const (
+	StateInFlight  = "in_flight"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+// defaultRetention is how long finished operations stay in the journal
+// unless SetRetention changes it.
+const defaultRetention = 7 * 24 * time.Hour
+
+// fileVersion is the version of the journal's file format. Version 2 added
+// the checksum of the operations.
+const fileVersion = 2
+
+// fileFormat describes the journal's file format and how older versions
+// are migrated to it.
+var fileFormat = store.Format{
+	Name:    "journal",
+	Version: fileVersion,
+	Entries: "operations",
+	Migrations: map[int]store.Migration{
+		1: store.AddChecksum("operations"),
+	},
+}
+
+// Operation is one journaled operation.
+type Operation struct {
+	Kind  string `json:"kind"`
+	Key   string `json:"key"`
+	Tool  string `json:"tool"`
+	State string `json:"state"`
+
+	// Detail carries what the tool knew about the operation, and its
+	// outcome once finished.
+	Detail map[string]any `json:"detail,omitempty"`
+
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// file is the on-disk form of the journal.
+type file struct {
+	Version    int          `json:"version"`
+	Checksum   string       `json:"checksum"`
+	Operations []*Operation `json:"operations"`
+}
+
+// Journal keeps operations in a JSON file, rewritten whole on every change
+// so a crash leaves either the old or the new journal. A nil Journal
+// discards operations.
+type Journal struct {
+	mu         sync.Mutex
+	path       string
+	retention  time.Duration
+	operations map[string]*Operation
+}
+
+// Open loads the journal at path, or starts an empty one if the file does
+// not exist yet. It is created with owner-only permissions on first write.
+func Open(path string) (*Journal, error) {
+	j := &Journal{
+		path:       path,
+		retention:  defaultRetention,
+		operations: make(map[string]*Operation),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A journal from an older server is migrated as it is read, and
+	// written in the current format on its next change.
+	operations, _, err := decode(path, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range operations {
+		j.operations[id(op.Kind, op.Key)] = op
+	}
+
+	return j, nil
+}
+
+// SetRetention sets how long finished operations stay in the journal. They
+// are pruned the next time the journal changes. In-flight operations are
+// kept however old they are.
+func (j *Journal) SetRetention(retention time.Duration) {
+	if j == nil || retention <= 0 {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.retention = retention
+}
+
+// Begin records a new in-flight operation, replacing any earlier one with
+// the same kind and key.
+func (j *Journal) Begin(kind, key, tool string,
+	detail map[string]any) error {
+	if j == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.operations[id(kind, key)] = &Operation{
+		Kind:      kind,
+		Key:       key,
+		Tool:      tool,
+		State:     StateInFlight,
+		Detail:    detail,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	return j.save()
+}
+
+// Update sets the state of an operation and merges detail into what is
+// known about it. Unknown operations are ignored.
+func (j *Journal) Update(kind, key, state string,
+	detail map[string]any) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	op, ok := j.operations[id(kind, key)]
+	if !ok {
+		return nil
+	}
+	if op.State == state && len(detail) == 0 {
+		return nil
+	}
+
+	op.State = state
+	op.UpdatedAt = time.Now().UTC()
+	if len(detail) > 0 && op.Detail == nil {
+		op.Detail = make(map[string]any, len(detail))
+	}
+	for k, v := range detail {
+		op.Detail[k] = v
+	}
+	return j.save()
+}
+
+// Import adds operations restored from a backup. Operations already in the
+// journal are left as they are, and finished ones past the retention period
+// are dropped as they would be when saving. It returns how many operations
+// were added. In-flight operations among them are resumed the next time the
+// server starts.
+func (j *Journal) Import(ops []Operation) (int, error) {
+	if j == nil {
+		return 0, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	cutoff := time.Now().Add(-j.retention)
+	var added []string
+	for _, op := range ops {
+		key := id(op.Kind, op.Key)
+		if _, ok := j.operations[key]; ok {
+			continue
+		}
+		if op.State != StateInFlight && op.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		j.operations[key] = &op
+		added = append(added, key)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	if err := j.save(); err != nil {
+		for _, key := range added {
+			delete(j.operations, key)
+		}
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// Operations returns a copy of every journaled operation, oldest first.
+func (j *Journal) Operations() []Operation {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	ops := make([]Operation, 0, len(j.operations))
+	for _, op := range j.operations {
+		copied := *op
+		if op.Detail != nil {
+			copied.Detail = make(map[string]any, len(op.Detail))
+			for k, v := range op.Detail {
+				copied.Detail[k] = v
+			}
+		}
+		ops = append(ops, copied)
+	}
+	sort.Slice(ops, func(a, b int) bool {
+		if !ops[a].StartedAt.Equal(ops[b].StartedAt) {
+			return ops[a].StartedAt.Before(ops[b].StartedAt)
+		}
+		return id(ops[a].Kind, ops[a].Key) < id(ops[b].Kind, ops[b].Key)
+	})
+
+	return ops
+}
+
+// InFlight returns the operations not yet known to have finished, oldest
+// first.
+func (j *Journal) InFlight() []Operation {
+	var ops []Operation
+	for _, op := range j.Operations() {
+		if op.State == StateInFlight {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// save prunes operations that finished longer than the retention period
+// ago and atomically replaces the journal file. The caller holds mu.
+func (j *Journal) save() error {
+	cutoff := time.Now().Add(-j.retention)
+	stored := file{Version: fileVersion, Operations: []*Operation{}}
+	for key, op := range j.operations {
+		if op.State != StateInFlight && op.UpdatedAt.Before(cutoff) {
+			delete(j.operations, key)
+			continue
+		}
+		stored.Operations = append(stored.Operations, op)
+	}
+	sort.Slice(stored.Operations, func(a, b int) bool {
+		return stored.Operations[a].StartedAt.Before(
+			stored.Operations[b].StartedAt)
+	})
+
+	checksum, err := store.Checksum(stored.Operations)
+	if err != nil {
+		return err
+	}
+	stored.Checksum = checksum
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return store.WriteFile(j.path, data)
+}
+
+// decode parses a journal file, migrating it from older format versions,
+// and checks its integrity: the checksum of its operations, and that each
+// operation is identified and known only once. It returns the operations
+// and the version the file was stored at.
+func decode(path string, data []byte) ([]*Operation, int, error) {
+	entries, version, err := fileFormat.Decode(path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var operations []*Operation
+	if err := json.Unmarshal(entries, &operations); err != nil {
+		return nil, 0, err
+	}
+	seen := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		if op == nil || op.Kind == "" || op.Key == "" {
+			return nil, 0, fmt.Errorf("journal %s has an "+
+				"operation without a kind and key", path)
+		}
+		if seen[id(op.Kind, op.Key)] {
+			return nil, 0, fmt.Errorf("journal %s lists %s more "+
+				"than once", path, id(op.Kind, op.Key))
+		}
+		seen[id(op.Kind, op.Key)] = true
+	}
+
+	return operations, version, nil
+}
+
+// Path returns the file the journal is kept in.
+func (j *Journal) Path() string {
+	if j == nil {
+		return ""
+	}
+	return j.path
+}
+
+// Check reads the journal file back, verifies its integrity and returns
+// the format version it is stored in. A journal not written yet passes
+// with version 0.
+func (j *Journal) Check() (int, error) {
+	if j == nil {
+		return 0, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, version, err := decode(j.path, data)
+	return version, err
+}
+
+// Prune drops finished operations last updated longer than olderThan ago,
+// or than the retention period when olderThan is not positive, and returns
+// how many it dropped. Those past the retention period go either way, and
+// in-flight operations are kept however old they are.
+func (j *Journal) Prune(olderThan time.Duration) (int, error) {
+	if j == nil {
+		return 0, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if olderThan <= 0 {
+		olderThan = j.retention
+	}
+	cutoff := time.Now().Add(-olderThan)
+	pruned := make(map[string]*Operation)
+	for key, op := range j.operations {
+		if op.State != StateInFlight && op.UpdatedAt.Before(cutoff) {
+			pruned[key] = op
+			delete(j.operations, key)
+		}
+	}
+
+	before := len(j.operations) + len(pruned)
+	if err := j.save(); err != nil {
+		for key, op := range pruned {
+			j.operations[key] = op
+		}
+		return 0, err
+	}
+	return before - len(j.operations), nil
+}
+
+// Vacuum removes temporary files left by writes a crash interrupted and
+// rewrites the journal, dropping operations past the retention period. It
+// returns how many temporary files it removed.
+func (j *Journal) Vacuum() (int, error) {
+	if j == nil {
+		return 0, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	removed, err := store.RemoveTemp(j.path)
+	if err != nil {
+		return removed, err
+	}
+	return removed, j.save()
+}
+
+// id identifies an operation within the journal.
+func id(kind, key string) string {
+	return kind + ":" + key
+}
@@ -0,0 +1,267 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that operations survive reopening the journal, as after a restart.
+func TestJournal_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+
+	j, err := Open(path)
+	require.NoError(t, err)
+	assert.Empty(t, j.Operations())
+
+	require.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice",
+		map[string]any{"fee_limit_sat": 10}))
+	require.NoError(t, j.Begin(KindChannelOpen, "bb:0", "lnc_open_channel",
+		nil))
+	require.NoError(t, j.Update(KindPayment, "aa", StateSucceeded,
+		map[string]any{"fee_sat": 1}))
+
+	// Updates to operations the journal never saw are ignored.
+	require.NoError(t, j.Update(KindPayment, "cc", StateFailed, nil))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	ops := reopened.Operations()
+	require.Len(t, ops, 2)
+	payment := ops[0]
+	if payment.Kind != KindPayment {
+		payment = ops[1]
+	}
+	assert.Equal(t, "aa", payment.Key)
+	assert.Equal(t, StateSucceeded, payment.State)
+	assert.Equal(t, "lnc_pay_invoice", payment.Tool)
+	assert.EqualValues(t, 10, payment.Detail["fee_limit_sat"])
+	assert.EqualValues(t, 1, payment.Detail["fee_sat"])
+
+	inFlight := reopened.InFlight()
+	require.Len(t, inFlight, 1)
+	assert.Equal(t, "bb:0", inFlight[0].Key)
+
+	// Copies do not alias the journal's own operations.
+	payment.Detail["fee_sat"] = 2
+	for _, op := range reopened.Operations() {
+		if op.Kind == KindPayment {
+			assert.EqualValues(t, 1, op.Detail["fee_sat"])
+		}
+	}
+}
+
+// Test that finished operations are pruned after the retention period,
+// and in-flight ones never are.
+func TestJournal_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	old := time.Now().Add(-2 * defaultRetention).UTC()
+	data, err := json.Marshal(file{Version: 1, Operations: []*Operation{{
+		Kind: KindPayment, Key: "aa", State: StateFailed,
+		StartedAt: old, UpdatedAt: old,
+	}, {
+		Kind: KindPayment, Key: "bb", State: StateInFlight,
+		StartedAt: old, UpdatedAt: old,
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	j, err := Open(path)
+	require.NoError(t, err)
+	require.Len(t, j.Operations(), 2)
+
+	require.NoError(t, j.Begin(KindPayment, "cc", "lnc_keysend", nil))
+	ops := j.Operations()
+	require.Len(t, ops, 2)
+	assert.Equal(t, "bb", ops[0].Key)
+	assert.Equal(t, "cc", ops[1].Key)
+}
+
+// Test that a shorter retention prunes finished operations sooner.
+func TestJournal_SetRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	j, err := Open(path)
+	require.NoError(t, err)
+	j.SetRetention(time.Hour)
+
+	require.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice", nil))
+	require.NoError(t, j.Update(KindPayment, "aa", StateFailed, nil))
+	j.operations[id(KindPayment, "aa")].UpdatedAt = time.Now().Add(
+		-2 * time.Hour)
+
+	require.NoError(t, j.Begin(KindPayment, "bb", "lnc_keysend", nil))
+	ops := j.Operations()
+	require.Len(t, ops, 1)
+	assert.Equal(t, "bb", ops[0].Key)
+}
+
+// Test that a corrupt journal is reported rather than silently replaced.
+func TestJournal_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := Open(path)
+	assert.Error(t, err)
+}
+
+// Test that a journal written by a newer server is not opened, since
+// saving it would drop what this server does not understand.
+func TestJournal_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	data, err := json.Marshal(file{Version: fileVersion + 1})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "newer than this server's")
+}
+
+// Test that a journal from the previous format version is migrated as it
+// is read, and written in the current version on its next change.
+func TestJournal_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "version": 1,
+  "operations": [
+    {"kind": "payment", "key": "aa", "tool": "lnc_pay_invoice",
+     "state": "in_flight", "started_at": "2026-01-01T00:00:00Z",
+     "updated_at": "2026-01-01T00:00:00Z"}
+  ]
+}`), 0o600))
+
+	j, err := Open(path)
+	require.NoError(t, err)
+	require.Len(t, j.InFlight(), 1)
+	assert.Equal(t, "lnc_pay_invoice", j.InFlight()[0].Tool)
+
+	version, err := j.Check()
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	require.NoError(t, j.Begin(KindPayment, "bb", "lnc_keysend", nil))
+	version, err = j.Check()
+	require.NoError(t, err)
+	assert.Equal(t, fileVersion, version)
+
+	var stored file
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, fileVersion, stored.Version)
+	assert.NotEmpty(t, stored.Checksum)
+	assert.Len(t, stored.Operations, 2)
+}
+
+// Test that a journal whose operations were changed behind its back, or
+// that lists an operation twice, fails its integrity check.
+func TestJournal_Integrity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	j, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice", nil))
+	_, err = j.Check()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(
+		string(data), `"aa"`, `"ab"`, 1)), 0o600))
+	_, err = j.Check()
+	assert.ErrorContains(t, err, "integrity check")
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "integrity check")
+
+	op := &Operation{Kind: KindPayment, Key: "aa", State: StateFailed}
+	checksum, err := store.Checksum([]*Operation{op, op})
+	require.NoError(t, err)
+	data, err = json.Marshal(file{Version: fileVersion,
+		Checksum: checksum, Operations: []*Operation{op, op}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "more than once")
+}
+
+// Test that pruning drops finished operations older than asked, and that
+// vacuuming removes the temporary files of interrupted writes.
+func TestJournal_Maintenance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	j, err := Open(path)
+	require.NoError(t, err)
+
+	require.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice", nil))
+	require.NoError(t, j.Update(KindPayment, "aa", StateFailed, nil))
+	require.NoError(t, j.Begin(KindPayment, "bb", "lnc_keysend", nil))
+	for _, key := range []string{"aa", "bb"} {
+		j.operations[id(KindPayment, key)].UpdatedAt = time.Now().Add(
+			-2 * time.Hour)
+	}
+
+	pruned, err := j.Prune(3 * time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+	pruned, err = j.Prune(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	require.Len(t, j.Operations(), 1)
+	assert.Equal(t, "bb", j.Operations()[0].Key)
+
+	leftover := path + ".123.tmp"
+	require.NoError(t, os.WriteFile(leftover, []byte("{"), 0o600))
+	removed, err := j.Vacuum()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, leftover)
+	assert.FileExists(t, path)
+}
+
+// Test that a nil journal silently discards operations.
+func TestJournal_Nil(t *testing.T) {
+	var j *Journal
+	assert.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice", nil))
+	assert.NoError(t, j.Update(KindPayment, "aa", StateFailed, nil))
+	assert.Empty(t, j.Operations())
+	assert.Empty(t, j.InFlight())
+
+	pruned, err := j.Prune(0)
+	assert.NoError(t, err)
+	assert.Zero(t, pruned)
+	_, err = j.Check()
+	assert.NoError(t, err)
+}
+
+// Test that importing operations from a backup adds only those missing,
+// leaving out finished ones past the retention period.
+func TestJournal_Import(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.json"))
+	require.NoError(t, err)
+	require.NoError(t, j.Begin(KindPayment, "aa", "lnc_pay_invoice", nil))
+
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	added, err := j.Import([]Operation{
+		{Kind: KindPayment, Key: "aa", State: StateFailed},
+		{Kind: KindPayment, Key: "bb", Tool: "lnc_keysend",
+			State: StateInFlight, StartedAt: old, UpdatedAt: old},
+		{Kind: KindChannelOpen, Key: "cc:0", State: StateSucceeded,
+			StartedAt: old, UpdatedAt: old},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	ops := j.Operations()
+	require.Len(t, ops, 2)
+	assert.Equal(t, "bb", ops[0].Key)
+	assert.Equal(t, StateInFlight, ops[1].State)
+	assert.Len(t, j.InFlight(), 2)
+}
@@ -0,0 +1,230 @@
+// Package lease elects one leader among server instances that share a
+// configuration, through a lease file on storage they can all reach. The
+// leader renews the lease well within its TTL; when it stops, another
+// instance takes the lease over once it expires.
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockRetry and lockWait pace the attempts to take the guard file that
+// serialises lease updates, and bound how long one update waits for it.
+const (
+	lockRetry = 10 * time.Millisecond
+	lockWait  = time.Second
+)
+
+// Record is the on-disk form of the lease.
+type Record struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Lease is one instance's handle on a lease file.
+type Lease struct {
+	path   string
+	holder string
+	ttl    time.Duration
+
+	// now returns the current time; tests replace it.
+	now func() time.Time
+
+	mu        sync.Mutex
+	heldUntil time.Time
+}
+
+// New returns a handle on the lease file at path for the instance named
+// holder. A lease taken through it lasts ttl unless renewed.
+func New(path, holder string, ttl time.Duration) (*Lease, error) {
+	switch {
+	case path == "":
+		return nil, errors.New("lease path is required")
+	case holder == "":
+		return nil, errors.New("lease holder is required")
+	case ttl <= 0:
+		return nil, errors.New("lease TTL must be positive")
+	}
+
+	return &Lease{
+		path:   path,
+		holder: holder,
+		ttl:    ttl,
+		now:    time.Now,
+	}, nil
+}
+
+// Holder returns the name this instance holds the lease under.
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// TTL returns how long the lease lasts unless renewed.
+func (l *Lease) TTL() time.Duration {
+	return l.ttl
+}
+
+// TryAcquire takes the lease when it is free or has expired, or renews it
+// when this instance already holds it. It returns whether this instance
+// holds the lease afterwards. A lease file another instance is updating
+// at the same moment counts as held by it.
+func (l *Lease) TryAcquire() (bool, error) {
+	unlock, ok, err := l.lock()
+	if err != nil || !ok {
+		return false, err
+	}
+	defer unlock()
+
+	now := l.now()
+	current, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	if current != nil && current.Holder != l.holder &&
+		now.Before(current.ExpiresAt) {
+		return false, nil
+	}
+
+	record := Record{Holder: l.holder, ExpiresAt: now.Add(l.ttl).UTC()}
+	if err := l.write(record); err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	l.heldUntil = record.ExpiresAt
+	l.mu.Unlock()
+
+	return true, nil
+}
+
+// HeldUntil returns when the lease this instance last took or renewed
+// expires, or the zero time if it never held it.
+func (l *Lease) HeldUntil() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.heldUntil
+}
+
+// Current returns the lease record, or nil if the lease is free or has
+// expired.
+func (l *Lease) Current() (*Record, error) {
+	current, err := l.read()
+	if err != nil || current == nil {
+		return nil, err
+	}
+	if !l.now().Before(current.ExpiresAt) {
+		return nil, nil
+	}
+	return current, nil
+}
+
+// Release gives the lease up if this instance holds it, so another can
+// take over without waiting for it to expire.
+func (l *Lease) Release() error {
+	unlock, ok, err := l.lock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("lease is being updated by another instance")
+	}
+	defer unlock()
+
+	l.mu.Lock()
+	l.heldUntil = time.Time{}
+	l.mu.Unlock()
+
+	current, err := l.read()
+	if err != nil || current == nil || current.Holder != l.holder {
+		return err
+	}
+	return os.Remove(l.path)
+}
+
+// lock takes the guard file that serialises updates of the lease file,
+// waiting up to lockWait for another instance to finish with it. A guard
+// older than the TTL was left behind by an instance that stopped while
+// holding it, and is removed. It returns false if the guard stayed taken.
+func (l *Lease) lock() (func(), bool, error) {
+	guard := l.path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY,
+			0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(guard) }, true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, false, err
+		}
+
+		info, err := os.Stat(guard)
+		if err == nil && time.Since(info.ModTime()) > l.ttl {
+			os.Remove(guard)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, false, nil
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// read loads the lease record, or returns nil if there is none.
+func (l *Lease) read() (*Record, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// write replaces the lease record, so a reader sees either the old or the
+// new one.
+func (l *Lease) write(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path),
+		filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), l.path)
+}
@@ -0,0 +1,129 @@
+package lease
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLease returns a lease handle whose clock reads *now.
+func newLease(t *testing.T, path, holder string,
+	now *time.Time) *Lease {
+	l, err := New(path, holder, 15*time.Second)
+	require.NoError(t, err)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+// Test that one instance holds the lease at a time, and another takes it
+// over once it expires.
+func TestLease_Failover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	a := newLease(t, path, "a", &now)
+	b := newLease(t, path, "b", &now)
+
+	held, err := a.TryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, now.Add(15*time.Second), a.HeldUntil())
+
+	held, err = b.TryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held)
+	assert.True(t, b.HeldUntil().IsZero())
+
+	current, err := b.Current()
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "a", current.Holder)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Renewing keeps the lease past its first expiry.
+	now = now.Add(10 * time.Second)
+	held, err = a.TryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	now = now.Add(10 * time.Second)
+	held, err = b.TryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	// Once it expires, the other instance takes it and the first loses
+	// it.
+	now = now.Add(10 * time.Second)
+	current, err = a.Current()
+	require.NoError(t, err)
+	assert.Nil(t, current)
+	held, err = b.TryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+	held, err = a.TryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held)
+}
+
+// Test that releasing the lease hands it over at once, and that releasing
+// a lease held by another instance leaves it alone.
+func TestLease_Release(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	a := newLease(t, path, "a", &now)
+	b := newLease(t, path, "b", &now)
+
+	held, err := a.TryAcquire()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	require.NoError(t, b.Release())
+	current, err := b.Current()
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "a", current.Holder)
+
+	require.NoError(t, a.Release())
+	assert.True(t, a.HeldUntil().IsZero())
+	held, err = b.TryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+}
+
+// Test that a guard file left by a stopped instance does not block the
+// lease forever, while a fresh one does.
+func TestLease_Guard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	l, err := New(path, "a", time.Minute)
+	require.NoError(t, err)
+
+	guard := path + ".lock"
+	require.NoError(t, os.WriteFile(guard, nil, 0o600))
+	held, err := l.TryAcquire()
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	stale := time.Now().Add(-2 * time.Minute)
+	require.NoError(t, os.Chtimes(guard, stale, stale))
+	held, err = l.TryAcquire()
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	_, err = os.Stat(guard)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// Test that incomplete settings are rejected.
+func TestNew_Invalid(t *testing.T) {
+	_, err := New("", "a", time.Second)
+	assert.Error(t, err)
+	_, err = New("leader.lease", "", time.Second)
+	assert.Error(t, err)
+	_, err = New("leader.lease", "a", 0)
+	assert.Error(t, err)
+}
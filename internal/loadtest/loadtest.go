@@ -0,0 +1,457 @@
+// Package loadtest drives weighted mixes of tool calls against the MCP
+// server from many goroutines and reports latency percentiles and memory
+// use, so concurrency problems and regressions show up before users do.
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// memorySampleInterval is how often memory use is sampled during a run.
+const memorySampleInterval = 50 * time.Millisecond
+
+// Caller invokes a single tool. isError reports a tool error result, which
+// is counted but is not a failure of the run; err reports a call that did
+// not produce a result at all.
+type Caller interface {
+	Call(ctx context.Context, tool string,
+		args map[string]any) (isError bool, err error)
+}
+
+// Weighted is one tool in a call mix, chosen in proportion to its weight.
+type Weighted struct {
+	Tool   string
+	Weight int
+}
+
+// ParseMix parses a mix such as "lnc_get_info=3,lnc_list_channels". Tools
+// without a weight get weight 1.
+func ParseMix(spec string) ([]Weighted, error) {
+	var mix []Weighted
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tool, weightText, hasWeight := strings.Cut(entry, "=")
+		weight := 1
+		if hasWeight {
+			var err error
+			weight, err = strconv.Atoi(strings.TrimSpace(weightText))
+			if err != nil || weight < 1 {
+				return nil, fmt.Errorf("invalid weight in %q: "+
+					"must be a positive integer", entry)
+			}
+		}
+		mix = append(mix, Weighted{
+			Tool:   strings.TrimSpace(tool),
+			Weight: weight,
+		})
+	}
+
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("mix must name at least one tool")
+	}
+	return mix, nil
+}
+
+// Config describes a load test run.
+type Config struct {
+	// Mix is the weighted set of tools to call.
+	Mix []Weighted
+
+	// Args holds the arguments to call each tool with. Tools without an
+	// entry are called with no arguments.
+	Args map[string]map[string]any
+
+	// Concurrency is the number of goroutines issuing calls.
+	Concurrency int
+
+	// Calls is the total number of calls to make. When zero the run lasts
+	// for Duration instead.
+	Calls    int
+	Duration time.Duration
+
+	// Seed makes the sequence of tools reproducible.
+	Seed int64
+}
+
+// validate checks that the configuration describes a runnable test.
+func (c *Config) validate() error {
+	switch {
+	case len(c.Mix) == 0:
+		return fmt.Errorf("mix must name at least one tool")
+	case c.Concurrency < 1:
+		return fmt.Errorf("concurrency must be at least 1")
+	case c.Calls < 0 || (c.Calls == 0 && c.Duration <= 0):
+		return fmt.Errorf("set a positive number of calls or a duration")
+	}
+	for _, entry := range c.Mix {
+		if entry.Tool == "" || entry.Weight < 1 {
+			return fmt.Errorf("mix entries need a tool name and a " +
+				"positive weight")
+		}
+	}
+	return nil
+}
+
+// Stats summarises the calls to one tool, or to all of them.
+type Stats struct {
+	Tool     string
+	Calls    int
+	Errors   int
+	Failures int
+	Mean     time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+// MarshalJSON encodes the latencies in milliseconds.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return json.Marshal(map[string]any{
+		"tool":     s.Tool,
+		"calls":    s.Calls,
+		"errors":   s.Errors,
+		"failures": s.Failures,
+		"mean_ms":  ms(s.Mean),
+		"p50_ms":   ms(s.P50),
+		"p90_ms":   ms(s.P90),
+		"p99_ms":   ms(s.P99),
+		"max_ms":   ms(s.Max),
+	})
+}
+
+// MemoryStats describes memory use over a run.
+type MemoryStats struct {
+	HeapAllocStart uint64 `json:"heap_alloc_start_bytes"`
+	HeapAllocEnd   uint64 `json:"heap_alloc_end_bytes"`
+	PeakHeapInuse  uint64 `json:"peak_heap_inuse_bytes"`
+	TotalAlloc     uint64 `json:"total_alloc_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	PeakGoroutines int    `json:"peak_goroutines"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	Throughput float64       `json:"calls_per_second"`
+	Overall    Stats         `json:"overall"`
+	Tools      []Stats       `json:"tools"`
+	Memory     MemoryStats   `json:"memory"`
+
+	// FailureSamples holds the first few call failures, to explain them.
+	FailureSamples []string `json:"failure_samples,omitempty"`
+}
+
+// maxFailureSamples bounds Report.FailureSamples.
+const maxFailureSamples = 5
+
+// WriteText writes the report as a human-readable table.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%d calls in %s (%.1f calls/s), %d tool errors, "+
+		"%d failures\n\n", r.Overall.Calls,
+		r.Elapsed.Round(time.Millisecond), r.Throughput,
+		r.Overall.Errors, r.Overall.Failures)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "tool\tcalls\terrors\tfailures\tmean\tp50\tp90\t"+
+		"p99\tmax\t")
+	for _, stats := range append(r.Tools, r.Overall) {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n",
+			stats.Tool, stats.Calls, stats.Errors, stats.Failures,
+			roundLatency(stats.Mean), roundLatency(stats.P50),
+			roundLatency(stats.P90), roundLatency(stats.P99),
+			roundLatency(stats.Max))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nheap: %s at start, %s at end, %s peak in use; "+
+		"%s allocated over %d GCs; peak %d goroutines\n",
+		formatBytes(r.Memory.HeapAllocStart),
+		formatBytes(r.Memory.HeapAllocEnd),
+		formatBytes(r.Memory.PeakHeapInuse),
+		formatBytes(r.Memory.TotalAlloc), r.Memory.NumGC,
+		r.Memory.PeakGoroutines)
+
+	for _, failure := range r.FailureSamples {
+		fmt.Fprintf(w, "failure: %s\n", failure)
+	}
+	return nil
+}
+
+// sample is the outcome of one call.
+type sample struct {
+	latency time.Duration
+	isError bool
+	err     error
+}
+
+// Run executes a load test and reports on it. Calls already in flight when
+// the duration expires are allowed to finish.
+func Run(ctx context.Context, caller Caller, cfg Config) (*Report, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	sampler := startMemorySampler()
+
+	jobs := make(chan string, cfg.Concurrency)
+	go produce(ctx, cfg, jobs)
+
+	// Each worker records into its own map, so recording adds no lock
+	// contention to the latencies being measured.
+	results := make([]map[string][]sample, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range results {
+		results[i] = make(map[string][]sample)
+		wg.Add(1)
+		go func(recorded map[string][]sample) {
+			defer wg.Done()
+			for tool := range jobs {
+				callStart := time.Now()
+				isError, err := caller.Call(ctx, tool,
+					cfg.Args[tool])
+				recorded[tool] = append(recorded[tool], sample{
+					latency: time.Since(callStart),
+					isError: isError,
+					err:     err,
+				})
+			}
+		}(results[i])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	peakHeap, peakGoroutines := sampler.stop()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	report := summarise(results)
+	report.Elapsed = elapsed
+	if elapsed > 0 {
+		report.Throughput = float64(report.Overall.Calls) /
+			elapsed.Seconds()
+	}
+	report.Memory = MemoryStats{
+		HeapAllocStart: before.HeapAlloc,
+		HeapAllocEnd:   after.HeapAlloc,
+		PeakHeapInuse:  max(peakHeap, after.HeapInuse),
+		TotalAlloc:     after.TotalAlloc - before.TotalAlloc,
+		NumGC:          after.NumGC - before.NumGC,
+		PeakGoroutines: peakGoroutines,
+	}
+
+	return report, nil
+}
+
+// produce feeds tools to the workers until the call count or duration is
+// reached, then closes jobs.
+func produce(ctx context.Context, cfg Config, jobs chan<- string) {
+	defer close(jobs)
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	total := 0
+	for _, entry := range cfg.Mix {
+		total += entry.Weight
+	}
+	random := rand.New(rand.NewSource(cfg.Seed))
+
+	for sent := 0; cfg.Calls == 0 || sent < cfg.Calls; sent++ {
+		pick := random.Intn(total)
+		var tool string
+		for _, entry := range cfg.Mix {
+			if pick < entry.Weight {
+				tool = entry.Tool
+				break
+			}
+			pick -= entry.Weight
+		}
+
+		select {
+		case jobs <- tool:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// summarise merges the workers' samples into a report.
+func summarise(results []map[string][]sample) *Report {
+	byTool := make(map[string][]sample)
+	for _, recorded := range results {
+		for tool, samples := range recorded {
+			byTool[tool] = append(byTool[tool], samples...)
+		}
+	}
+
+	tools := make([]string, 0, len(byTool))
+	for tool := range byTool {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	report := &Report{}
+	var all []sample
+	for _, tool := range tools {
+		report.Tools = append(report.Tools,
+			computeStats(tool, byTool[tool]))
+		all = append(all, byTool[tool]...)
+
+		for _, s := range byTool[tool] {
+			if s.err != nil &&
+				len(report.FailureSamples) < maxFailureSamples {
+				report.FailureSamples = append(
+					report.FailureSamples,
+					fmt.Sprintf("%s: %v", tool, s.err))
+			}
+		}
+	}
+	report.Overall = computeStats("all", all)
+
+	return report
+}
+
+// computeStats summarises the samples of one tool.
+func computeStats(tool string, samples []sample) Stats {
+	stats := Stats{Tool: tool, Calls: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	latencies := make([]time.Duration, len(samples))
+	var sum time.Duration
+	for i, s := range samples {
+		latencies[i] = s.latency
+		sum += s.latency
+		switch {
+		case s.err != nil:
+			stats.Failures++
+		case s.isError:
+			stats.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	stats.Mean = sum / time.Duration(len(latencies))
+	stats.P50 = percentile(latencies, 50)
+	stats.P90 = percentile(latencies, 90)
+	stats.P99 = percentile(latencies, 99)
+	stats.Max = latencies[len(latencies)-1]
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// memorySampler tracks peak heap use and goroutine count during a run.
+type memorySampler struct {
+	done   chan struct{}
+	result chan [2]uint64
+}
+
+// startMemorySampler starts sampling in the background.
+func startMemorySampler() *memorySampler {
+	sampler := &memorySampler{
+		done:   make(chan struct{}),
+		result: make(chan [2]uint64, 1),
+	}
+
+	go func() {
+		var peakHeap, peakGoroutines uint64
+		ticker := time.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+
+		for {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			peakHeap = max(peakHeap, stats.HeapInuse)
+			peakGoroutines = max(peakGoroutines,
+				uint64(runtime.NumGoroutine()))
+
+			select {
+			case <-ticker.C:
+			case <-sampler.done:
+				sampler.result <- [2]uint64{peakHeap,
+					peakGoroutines}
+				return
+			}
+		}
+	}()
+
+	return sampler
+}
+
+// stop ends sampling and returns the peak heap in use and goroutine count.
+func (m *memorySampler) stop() (uint64, int) {
+	close(m.done)
+	peaks := <-m.result
+	return peaks[0], int(peaks[1])
+}
+
+// roundLatency rounds a latency for display.
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// formatBytes formats a byte count with a binary unit.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div),
+		"KMGTPE"[exp])
+}
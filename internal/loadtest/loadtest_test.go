@@ -0,0 +1,172 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCaller records the tools it is asked to call.
+type countingCaller struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingCaller) Call(ctx context.Context, tool string,
+	args map[string]any) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[tool]++
+
+	switch tool {
+	case "tool_error":
+		return true, nil
+	case "broken":
+		return false, fmt.Errorf("no result")
+	}
+	return false, nil
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("lnc_get_info=3, lnc_list_channels ,")
+	require.NoError(t, err)
+	assert.Equal(t, []Weighted{
+		{Tool: "lnc_get_info", Weight: 3},
+		{Tool: "lnc_list_channels", Weight: 1},
+	}, mix)
+
+	for _, bad := range []string{"", " , ", "lnc_get_info=0",
+		"lnc_get_info=x"} {
+		_, err := ParseMix(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, time.Millisecond, percentile(latencies, 0))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestRun(t *testing.T) {
+	caller := &countingCaller{}
+	report, err := Run(context.Background(), caller, Config{
+		Mix: []Weighted{
+			{Tool: "ok", Weight: 8},
+			{Tool: "tool_error", Weight: 1},
+			{Tool: "broken", Weight: 1},
+		},
+		Concurrency: 4,
+		Calls:       500,
+	})
+	require.NoError(t, err)
+
+	// Every call is made and accounted for exactly once.
+	assert.Equal(t, 500, report.Overall.Calls)
+	total := 0
+	for _, stats := range report.Tools {
+		assert.Equal(t, caller.calls[stats.Tool], stats.Calls)
+		total += stats.Calls
+	}
+	assert.Equal(t, 500, total)
+
+	// The weights are respected, loosely.
+	assert.Greater(t, caller.calls["ok"], caller.calls["broken"])
+
+	assert.Equal(t, caller.calls["tool_error"], report.Overall.Errors)
+	assert.Equal(t, caller.calls["broken"], report.Overall.Failures)
+	assert.NotEmpty(t, report.FailureSamples)
+	assert.LessOrEqual(t, len(report.FailureSamples), maxFailureSamples)
+	assert.Positive(t, report.Memory.PeakGoroutines)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "500 calls")
+
+	encoded, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"p99_ms"`)
+}
+
+func TestRun_Duration(t *testing.T) {
+	report, err := Run(context.Background(), &countingCaller{}, Config{
+		Mix:         []Weighted{{Tool: "ok", Weight: 1}},
+		Concurrency: 2,
+		Duration:    50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Positive(t, report.Overall.Calls)
+	assert.GreaterOrEqual(t, report.Elapsed, 50*time.Millisecond)
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	mix := []Weighted{{Tool: "ok", Weight: 1}}
+	for _, cfg := range []Config{
+		{Concurrency: 1, Calls: 1},
+		{Mix: mix, Calls: 1},
+		{Mix: mix, Concurrency: 1},
+		{Mix: []Weighted{{Tool: "ok"}}, Concurrency: 1, Calls: 1},
+	} {
+		_, err := Run(context.Background(), &countingCaller{}, cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestSimNode(t *testing.T) {
+	sim := &SimNode{Channels: 3}
+	conn, stop, err := sim.Dial()
+	require.NoError(t, err)
+	defer stop()
+	defer conn.Close()
+
+	client := lnrpc.NewLightningClient(conn)
+	info, err := client.GetInfo(context.Background(),
+		&lnrpc.GetInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "regtest", info.Chains[0].Network)
+
+	channels, err := client.ListChannels(context.Background(),
+		&lnrpc.ListChannelsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, channels.Channels, 3)
+
+	recovery, err := client.GetRecoveryInfo(context.Background(),
+		&lnrpc.GetRecoveryInfoRequest{})
+	require.NoError(t, err)
+	assert.False(t, recovery.RecoveryMode)
+
+	sim.RecoveryProgress = 0.25
+	recovery, err = client.GetRecoveryInfo(context.Background(),
+		&lnrpc.GetRecoveryInfoRequest{})
+	require.NoError(t, err)
+	assert.True(t, recovery.RecoveryMode)
+	assert.False(t, recovery.RecoveryFinished)
+	assert.Equal(t, 0.25, recovery.Progress)
+
+	// Latency is applied, and cut short by the caller's deadline.
+	sim.Latency = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(),
+		20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	assert.Error(t, err)
+}
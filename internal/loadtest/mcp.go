@@ -0,0 +1,95 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MCPCaller calls tools through an MCP server's JSON-RPC message handler,
+// so hooks, auditing and signing are included in the measured latency.
+type MCPCaller struct {
+	Server *server.MCPServer
+
+	nextID atomic.Int64
+}
+
+// NewMCPCaller creates a caller for an MCP server.
+func NewMCPCaller(mcpServer *server.MCPServer) *MCPCaller {
+	return &MCPCaller{Server: mcpServer}
+}
+
+// Call implements Caller.
+func (c *MCPCaller) Call(ctx context.Context, tool string,
+	args map[string]any) (bool, error) {
+	isError, _, err := c.CallText(ctx, tool, args)
+	return isError, err
+}
+
+// CallText calls a tool and also returns the text of the result's first
+// text content: the JSON result or error payload, without the signature
+// that may follow it.
+func (c *MCPCaller) CallText(ctx context.Context, tool string,
+	args map[string]any) (bool, string, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      tool,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to encode request: %w",
+			err)
+	}
+
+	response := c.Server.HandleMessage(ctx, message)
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to encode response: %w",
+			err)
+	}
+
+	var decoded struct {
+		Result *struct {
+			IsError bool `json:"isError"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return false, "", fmt.Errorf("failed to decode response: %w",
+			err)
+	}
+
+	switch {
+	case decoded.Error != nil:
+		return false, "", fmt.Errorf("%s", decoded.Error.Message)
+	case decoded.Result == nil:
+		return false, "", fmt.Errorf("response has no result")
+	}
+
+	text := ""
+	for _, content := range decoded.Result.Content {
+		if content.Type == "text" {
+			text = content.Text
+			break
+		}
+	}
+	return decoded.Result.IsError, text, nil
+}
@@ -0,0 +1,320 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// simBufferSize is the in-memory listener's buffer size.
+const simBufferSize = 1 << 20
+
+// SimNode is an in-memory lnd that answers the read RPCs the tools use with
+// synthetic regtest data, so the server can be loaded without a real node.
+// RPCs it does not simulate return Unimplemented.
+type SimNode struct {
+	lnrpc.UnimplementedLightningServer
+
+	// Latency is added to every RPC to model a remote node.
+	Latency time.Duration
+
+	// Channels sets the size of the synthetic node: its channel, peer,
+	// invoice, payment and UTXO counts.
+	Channels int
+
+	// RecoveryProgress, between 0 and 1, puts the wallet in recovery mode
+	// with its rescan that far along; 1 is a finished scan. Zero leaves
+	// the wallet out of recovery mode.
+	RecoveryProgress float64
+}
+
+// Dial serves the node over an in-memory gRPC listener and returns a client
+// connection to it, along with a function that stops the server.
+func (s *SimNode) Dial() (*grpc.ClientConn, func(), error) {
+	listener := bufconn.Listen(simBufferSize)
+	server := grpc.NewServer()
+	lnrpc.RegisterLightningServer(server, s)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///simnode",
+		grpc.WithContextDialer(func(ctx context.Context,
+			_ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		server.Stop()
+		return nil, nil, fmt.Errorf("failed to dial sim node: %w", err)
+	}
+
+	return conn, server.Stop, nil
+}
+
+// wait applies the configured latency, returning early if ctx ends.
+func (s *SimNode) wait(ctx context.Context) error {
+	if s.Latency <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(s.Latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// simPubkey returns a deterministic compressed pubkey for index i.
+func simPubkey(i int) string {
+	return fmt.Sprintf("02%064x", i+1)
+}
+
+// simHash returns a deterministic 32-byte hex hash for index i.
+func simHash(i int) string {
+	return fmt.Sprintf("%064x", i+1)
+}
+
+// GetInfo returns a synced regtest node.
+func (s *SimNode) GetInfo(ctx context.Context,
+	_ *lnrpc.GetInfoRequest) (*lnrpc.GetInfoResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &lnrpc.GetInfoResponse{
+		IdentityPubkey:    simPubkey(0),
+		Alias:             "simnode",
+		Version:           "0.19.3-beta commit=v0.19.3-beta",
+		NumPeers:          uint32(s.Channels),
+		NumActiveChannels: uint32(s.Channels),
+		SyncedToChain:     true,
+		SyncedToGraph:     true,
+		BlockHeight:       1_000,
+		BlockHash:         simHash(1_000),
+		Chains: []*lnrpc.Chain{
+			{Chain: "bitcoin", Network: "regtest"},
+		},
+	}, nil
+}
+
+// WalletBalance returns a fixed on-chain balance.
+func (s *SimNode) WalletBalance(ctx context.Context,
+	_ *lnrpc.WalletBalanceRequest) (*lnrpc.WalletBalanceResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &lnrpc.WalletBalanceResponse{
+		TotalBalance:     10_000_000,
+		ConfirmedBalance: 10_000_000,
+	}, nil
+}
+
+// ChannelBalance returns the sum of the synthetic channels' balances.
+func (s *SimNode) ChannelBalance(ctx context.Context,
+	_ *lnrpc.ChannelBalanceRequest) (*lnrpc.ChannelBalanceResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	side := int64(s.Channels) * 500_000
+	return &lnrpc.ChannelBalanceResponse{
+		LocalBalance: &lnrpc.Amount{
+			Sat: uint64(side), Msat: uint64(side) * 1_000,
+		},
+		RemoteBalance: &lnrpc.Amount{
+			Sat: uint64(side), Msat: uint64(side) * 1_000,
+		},
+	}, nil
+}
+
+// ListChannels returns one balanced channel per synthetic peer.
+func (s *SimNode) ListChannels(ctx context.Context,
+	_ *lnrpc.ListChannelsRequest) (*lnrpc.ListChannelsResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	channels := make([]*lnrpc.Channel, s.Channels)
+	for i := range channels {
+		channels[i] = &lnrpc.Channel{
+			Active:        true,
+			RemotePubkey:  simPubkey(i + 1),
+			ChannelPoint:  simHash(i) + ":0",
+			ChanId:        uint64(1_000+i) << 40,
+			Capacity:      1_000_000,
+			LocalBalance:  500_000,
+			RemoteBalance: 500_000,
+			Initiator:     i%2 == 0,
+		}
+	}
+	return &lnrpc.ListChannelsResponse{Channels: channels}, nil
+}
+
+// PendingChannels reports no pending channels.
+func (s *SimNode) PendingChannels(ctx context.Context,
+	_ *lnrpc.PendingChannelsRequest) (*lnrpc.PendingChannelsResponse,
+	error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &lnrpc.PendingChannelsResponse{}, nil
+}
+
+// ListInvoices returns settled invoices.
+func (s *SimNode) ListInvoices(ctx context.Context,
+	_ *lnrpc.ListInvoiceRequest) (*lnrpc.ListInvoiceResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	invoices := make([]*lnrpc.Invoice, s.Channels)
+	for i := range invoices {
+		invoices[i] = &lnrpc.Invoice{
+			Memo:     fmt.Sprintf("invoice %d", i),
+			Value:    1_000,
+			AddIndex: uint64(i + 1),
+			State:    lnrpc.Invoice_SETTLED,
+		}
+	}
+	return &lnrpc.ListInvoiceResponse{
+		Invoices:         invoices,
+		FirstIndexOffset: 1,
+		LastIndexOffset:  uint64(len(invoices)),
+	}, nil
+}
+
+// ListPayments returns succeeded payments.
+func (s *SimNode) ListPayments(ctx context.Context,
+	_ *lnrpc.ListPaymentsRequest) (*lnrpc.ListPaymentsResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	payments := make([]*lnrpc.Payment, s.Channels)
+	for i := range payments {
+		payments[i] = &lnrpc.Payment{
+			PaymentHash:  simHash(i),
+			ValueSat:     1_000,
+			Status:       lnrpc.Payment_SUCCEEDED,
+			PaymentIndex: uint64(i + 1),
+		}
+	}
+	return &lnrpc.ListPaymentsResponse{
+		Payments:         payments,
+		FirstIndexOffset: 1,
+		LastIndexOffset:  uint64(len(payments)),
+	}, nil
+}
+
+// ListPeers returns one peer per synthetic channel.
+func (s *SimNode) ListPeers(ctx context.Context,
+	_ *lnrpc.ListPeersRequest) (*lnrpc.ListPeersResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	peers := make([]*lnrpc.Peer, s.Channels)
+	for i := range peers {
+		peers[i] = &lnrpc.Peer{
+			PubKey:   simPubkey(i + 1),
+			Address:  fmt.Sprintf("127.0.0.1:%d", 9_736+i),
+			SyncType: lnrpc.Peer_ACTIVE_SYNC,
+		}
+	}
+	return &lnrpc.ListPeersResponse{Peers: peers}, nil
+}
+
+// DescribeGraph returns the node and its peers as the graph.
+func (s *SimNode) DescribeGraph(ctx context.Context,
+	_ *lnrpc.ChannelGraphRequest) (*lnrpc.ChannelGraph, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	graph := &lnrpc.ChannelGraph{}
+	for i := 0; i <= s.Channels; i++ {
+		graph.Nodes = append(graph.Nodes, &lnrpc.LightningNode{
+			PubKey: simPubkey(i),
+			Alias:  fmt.Sprintf("simnode-%d", i),
+		})
+	}
+	for i := 0; i < s.Channels; i++ {
+		graph.Edges = append(graph.Edges, &lnrpc.ChannelEdge{
+			ChannelId: uint64(1_000+i) << 40,
+			ChanPoint: simHash(i) + ":0",
+			Node1Pub:  simPubkey(0),
+			Node2Pub:  simPubkey(i + 1),
+			Capacity:  1_000_000,
+		})
+	}
+	return graph, nil
+}
+
+// ListUnspent returns confirmed UTXOs.
+func (s *SimNode) ListUnspent(ctx context.Context,
+	_ *lnrpc.ListUnspentRequest) (*lnrpc.ListUnspentResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	utxos := make([]*lnrpc.Utxo, s.Channels)
+	for i := range utxos {
+		utxos[i] = &lnrpc.Utxo{
+			AmountSat: 100_000,
+			Outpoint: &lnrpc.OutPoint{
+				TxidStr: simHash(i),
+			},
+			Confirmations: 6,
+		}
+	}
+	return &lnrpc.ListUnspentResponse{Utxos: utxos}, nil
+}
+
+// GetTransactions returns one confirmed transaction per UTXO.
+func (s *SimNode) GetTransactions(ctx context.Context,
+	_ *lnrpc.GetTransactionsRequest) (*lnrpc.TransactionDetails, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	txs := make([]*lnrpc.Transaction, s.Channels)
+	for i := range txs {
+		txs[i] = &lnrpc.Transaction{
+			TxHash:           simHash(i),
+			Amount:           100_000,
+			NumConfirmations: 6,
+			BlockHeight:      int32(994 + i%6),
+			RawTxHex:         strings.Repeat("00", 100),
+		}
+	}
+	return &lnrpc.TransactionDetails{Transactions: txs}, nil
+}
+
+// EstimateFee returns a flat fee rate.
+func (s *SimNode) EstimateFee(ctx context.Context,
+	_ *lnrpc.EstimateFeeRequest) (*lnrpc.EstimateFeeResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &lnrpc.EstimateFeeResponse{FeeSat: 282, SatPerVbyte: 2}, nil
+}
+
+// GetRecoveryInfo reports the simulated recovery scan, if any.
+func (s *SimNode) GetRecoveryInfo(ctx context.Context,
+	_ *lnrpc.GetRecoveryInfoRequest) (*lnrpc.GetRecoveryInfoResponse,
+	error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	if s.RecoveryProgress <= 0 {
+		return &lnrpc.GetRecoveryInfoResponse{}, nil
+	}
+	return &lnrpc.GetRecoveryInfoResponse{
+		RecoveryMode:     true,
+		RecoveryFinished: s.RecoveryProgress >= 1,
+		Progress:         min(s.RecoveryProgress, 1),
+	}, nil
+}
@@ -55,6 +55,7 @@ func (cl *ContextLogger) extractContextFields(ctx context.Context) []zap.Field {
 		if rc.Operation() != "" {
 			fields = append(fields, zap.String("operation", rc.Operation()))
 		}
+		fields = append(fields, mcpCallFields(ctx)...)
 		fields = append(fields,
 			zap.Duration("duration", rc.Duration()),
 			zap.Duration("time_remaining", rc.TimeRemaining()),
@@ -81,6 +82,7 @@ func (cl *ContextLogger) extractContextFields(ctx context.Context) []zap.Field {
 	if operation := lnccontext.GetOperation(ctx); operation != "" {
 		fields = append(fields, zap.String("operation", operation))
 	}
+	fields = append(fields, mcpCallFields(ctx)...)
 	if duration := lnccontext.GetDuration(ctx); duration > 0 {
 		fields = append(fields, zap.Duration("duration", duration))
 	}
@@ -88,6 +90,19 @@ func (cl *ContextLogger) extractContextFields(ctx context.Context) []zap.Field {
 	return fields
 }
 
+// mcpCallFields returns the MCP request ID and progress token of the tool
+// call being served, if any.
+func mcpCallFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if id := lnccontext.GetMCPRequestID(ctx); id != nil {
+		fields = append(fields, zap.Any("mcp_request_id", id))
+	}
+	if token := lnccontext.GetProgressToken(ctx); token != nil {
+		fields = append(fields, zap.Any("progress_token", token))
+	}
+	return fields
+}
+
 // Debug logs a debug message with context.
 func (cl *ContextLogger) Debug(ctx context.Context, msg string,
 	fields ...zap.Field) {
@@ -0,0 +1,301 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/store"
+)
+
+// ledgerWindow is how long spends stay in the ledger: the longest period a
+// spend limit covers.
+const ledgerWindow = 24 * time.Hour
+
+// ledgerVersion is the version of the ledger's file format. Version 2 added
+// the checksum of the spends.
+const ledgerVersion = 2
+
+// ledgerFormat describes the ledger's file format and how older versions
+// are migrated to it.
+var ledgerFormat = store.Format{
+	Name:    "spend ledger",
+	Version: ledgerVersion,
+	Entries: "spends",
+	Migrations: map[int]store.Migration{
+		1: store.AddChecksum("spends"),
+	},
+}
+
+// Spend is one amount a write tool spent, or has reserved while its
+// outcome is unknown.
+type Spend struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	AmountSat int64     `json:"amount_sat"`
+	At        time.Time `json:"at"`
+}
+
+// ledgerFile is the on-disk form of the ledger.
+type ledgerFile struct {
+	Version  int      `json:"version"`
+	Checksum string   `json:"checksum"`
+	Spends   []*Spend `json:"spends"`
+}
+
+// Ledger records what write tools spent over the last day, so spend limits
+// hold across restarts. A ledger without a path keeps spends in memory
+// only. Like the operation journal, the file is rewritten whole on every
+// change.
+type Ledger struct {
+	mu     sync.Mutex
+	path   string
+	spends map[string]*Spend
+}
+
+// NewLedger creates a ledger that keeps spends in memory only.
+func NewLedger() *Ledger {
+	return &Ledger{spends: make(map[string]*Spend)}
+}
+
+// OpenLedger loads the ledger at path, or starts an empty one if the file
+// does not exist yet. It is created with owner-only permissions on first
+// write.
+func OpenLedger(path string) (*Ledger, error) {
+	l := NewLedger()
+	l.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A ledger from an older server is migrated as it is read, and
+	// written in the current format on its next change.
+	spends, _, err := decodeLedger(path, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, spend := range spends {
+		l.spends[spend.ID] = spend
+	}
+
+	return l, nil
+}
+
+// decodeLedger parses a ledger file, migrating it from older format
+// versions, and checks its integrity: the checksum of its spends, and that
+// each spend has an ID of its own and an amount that is not negative. It
+// returns the spends and the version the file was stored at.
+func decodeLedger(path string, data []byte) ([]*Spend, int, error) {
+	entries, version, err := ledgerFormat.Decode(path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var spends []*Spend
+	if err := json.Unmarshal(entries, &spends); err != nil {
+		return nil, 0, err
+	}
+	seen := make(map[string]bool, len(spends))
+	for _, spend := range spends {
+		if spend == nil || spend.ID == "" || spend.AmountSat < 0 {
+			return nil, 0, fmt.Errorf("spend ledger %s has a "+
+				"spend without an ID or with a negative "+
+				"amount", path)
+		}
+		if seen[spend.ID] {
+			return nil, 0, fmt.Errorf("spend ledger %s lists "+
+				"spend %s more than once", path, spend.ID)
+		}
+		seen[spend.ID] = true
+	}
+
+	return spends, version, nil
+}
+
+// spentSince returns the total spent at or after since. The caller holds
+// mu.
+func (l *Ledger) spentSince(since time.Time) int64 {
+	var total int64
+	for _, spend := range l.spends {
+		if !spend.At.Before(since) {
+			total += spend.AmountSat
+		}
+	}
+	return total
+}
+
+// record adds or replaces a spend and saves the ledger. The caller holds
+// mu.
+func (l *Ledger) record(spend *Spend) error {
+	l.spends[spend.ID] = spend
+	return l.save()
+}
+
+// remove deletes a spend and saves the ledger. Unknown spends are ignored.
+// The caller holds mu.
+func (l *Ledger) remove(id string) error {
+	if _, ok := l.spends[id]; !ok {
+		return nil
+	}
+	delete(l.spends, id)
+	return l.save()
+}
+
+// Import adds spends restored from a backup, so the spend caps count what
+// was spent before a move to a new host. Spends already recorded, and
+// those older than a day, are left out. It returns how many spends were
+// added.
+func (l *Ledger) Import(spends []Spend) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-ledgerWindow)
+	var added []string
+	for _, spend := range spends {
+		if _, ok := l.spends[spend.ID]; ok || spend.At.Before(cutoff) {
+			continue
+		}
+		l.spends[spend.ID] = &spend
+		added = append(added, spend.ID)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	if err := l.save(); err != nil {
+		for _, id := range added {
+			delete(l.spends, id)
+		}
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// Spends returns a copy of the spends of the last day, oldest first.
+func (l *Ledger) Spends() []Spend {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-ledgerWindow)
+	spends := make([]Spend, 0, len(l.spends))
+	for _, spend := range l.spends {
+		if !spend.At.Before(cutoff) {
+			spends = append(spends, *spend)
+		}
+	}
+	sort.Slice(spends, func(a, b int) bool {
+		return spends[a].At.Before(spends[b].At)
+	})
+
+	return spends
+}
+
+// save prunes spends older than a day and, for a ledger with a path,
+// atomically replaces the ledger file. The caller holds mu.
+func (l *Ledger) save() error {
+	cutoff := time.Now().Add(-ledgerWindow)
+	stored := ledgerFile{Version: ledgerVersion, Spends: []*Spend{}}
+	for id, spend := range l.spends {
+		if spend.At.Before(cutoff) {
+			delete(l.spends, id)
+			continue
+		}
+		stored.Spends = append(stored.Spends, spend)
+	}
+	if l.path == "" {
+		return nil
+	}
+	sort.Slice(stored.Spends, func(a, b int) bool {
+		return stored.Spends[a].At.Before(stored.Spends[b].At)
+	})
+
+	checksum, err := store.Checksum(stored.Spends)
+	if err != nil {
+		return err
+	}
+	stored.Checksum = checksum
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return store.WriteFile(l.path, data)
+}
+
+// Path returns the file the ledger is kept in, empty for a ledger kept in
+// memory only.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Check reads the ledger file back, verifies its integrity and returns the
+// format version it is stored in. A ledger not written yet, or kept in
+// memory only, passes with version 0.
+func (l *Ledger) Check() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		return 0, nil
+	}
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, version, err := decodeLedger(l.path, data)
+	return version, err
+}
+
+// Prune drops the spends older than a day, which no limit counts any more,
+// and returns how many it dropped. Younger spends cannot be pruned, as
+// that would lift the spend limits.
+func (l *Ledger) Prune() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	before := len(l.spends)
+	expired := make(map[string]*Spend)
+	cutoff := time.Now().Add(-ledgerWindow)
+	for id, spend := range l.spends {
+		if spend.At.Before(cutoff) {
+			expired[id] = spend
+		}
+	}
+	if err := l.save(); err != nil {
+		for id, spend := range expired {
+			l.spends[id] = spend
+		}
+		return 0, err
+	}
+	return before - len(l.spends), nil
+}
+
+// Vacuum removes temporary files left by writes a crash interrupted and
+// rewrites the ledger, dropping spends older than a day. It returns how
+// many temporary files it removed.
+func (l *Ledger) Vacuum() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" {
+		return 0, l.save()
+	}
+	removed, err := store.RemoveTemp(l.path)
+	if err != nil {
+		return removed, err
+	}
+	return removed, l.save()
+}
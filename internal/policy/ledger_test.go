@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that spends survive reopening the ledger, so the caps hold after a
+// restart, and that spends older than a day are dropped.
+func TestLedger_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+
+	ledger, err := OpenLedger(path)
+	require.NoError(t, err)
+	engine := NewEngine(Config{MaxDailySat: 1_000})
+	engine.SetLedger(ledger)
+
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 600))
+	require.NoError(t, engine.ReserveSpend("old", "lnc_keysend", 300))
+	ledger.spends["old"].At = time.Now().Add(-25 * time.Hour)
+	require.NoError(t, engine.SettleSpend("a", 500))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := OpenLedger(path)
+	require.NoError(t, err)
+	spends := reopened.Spends()
+	require.Len(t, spends, 1)
+	assert.Equal(t, "a", spends[0].ID)
+	assert.Equal(t, "lnc_pay_invoice", spends[0].Tool)
+	assert.EqualValues(t, 500, spends[0].AmountSat)
+
+	restarted := NewEngine(Config{MaxDailySat: 1_000})
+	restarted.SetLedger(reopened)
+	assert.NoError(t, restarted.CheckSpend(500))
+	assert.Error(t, restarted.CheckSpend(501))
+}
+
+// Test that a corrupt ledger is reported rather than silently emptied,
+// which would lift the caps.
+func TestLedger_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := OpenLedger(path)
+	assert.Error(t, err)
+}
+
+// Test that a ledger written by a newer server is not opened, since saving
+// it would drop what this server does not understand.
+func TestLedger_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(
+		`{"version": %d, "spends": []}`, ledgerVersion+1)), 0o600))
+
+	_, err := OpenLedger(path)
+	assert.ErrorContains(t, err, "newer than this server's")
+}
+
+// Test that a ledger from the previous format version is migrated as it is
+// read, so its spends still count, and written in the current version on
+// its next change.
+func TestLedger_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	at := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "version": 1,
+  "spends": [
+    {"id": "a", "tool": "lnc_pay_invoice", "amount_sat": 700,
+     "at": "`+at+`"}
+  ]
+}`), 0o600))
+
+	ledger, err := OpenLedger(path)
+	require.NoError(t, err)
+	engine := NewEngine(Config{MaxDailySat: 1_000})
+	engine.SetLedger(ledger)
+	assert.Error(t, engine.CheckSpend(301))
+
+	version, err := ledger.Check()
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	require.NoError(t, engine.ReserveSpend("b", "lnc_keysend", 100))
+	version, err = ledger.Check()
+	require.NoError(t, err)
+	assert.Equal(t, ledgerVersion, version)
+
+	reopened, err := OpenLedger(path)
+	require.NoError(t, err)
+	assert.Len(t, reopened.Spends(), 2)
+}
+
+// Test that a ledger whose spends were changed behind its back, such as an
+// amount lowered to lift the caps, fails its integrity check.
+func TestLedger_Integrity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	ledger, err := OpenLedger(path)
+	require.NoError(t, err)
+	engine := NewEngine(Config{MaxDailySat: 1_000})
+	engine.SetLedger(ledger)
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 900))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(
+		string(data), `"amount_sat": 900`, `"amount_sat": 9`, 1)),
+		0o600))
+
+	_, err = ledger.Check()
+	assert.ErrorContains(t, err, "integrity check")
+	_, err = OpenLedger(path)
+	assert.ErrorContains(t, err, "integrity check")
+}
+
+// Test that pruning drops only spends no limit counts, and that vacuuming
+// removes the temporary files of interrupted writes.
+func TestLedger_Maintenance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	ledger, err := OpenLedger(path)
+	require.NoError(t, err)
+	engine := NewEngine(Config{MaxDailySat: 1_000})
+	engine.SetLedger(ledger)
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 100))
+	require.NoError(t, engine.ReserveSpend("b", "lnc_keysend", 200))
+	ledger.spends["a"].At = time.Now().Add(-25 * time.Hour)
+
+	pruned, err := ledger.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	require.Len(t, ledger.Spends(), 1)
+
+	leftover := path + ".123.tmp"
+	require.NoError(t, os.WriteFile(leftover, []byte("{"), 0o600))
+	removed, err := ledger.Vacuum()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, leftover)
+
+	// A ledger kept in memory only has no file to check or vacuum.
+	version, err := NewLedger().Check()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+}
+
+// Test that spends imported from a backup count towards the caps, and that
+// importing never drops what the ledger already recorded.
+func TestLedger_Import(t *testing.T) {
+	ledger := NewLedger()
+	engine := NewEngine(Config{MaxDailySat: 1_000})
+	engine.SetLedger(ledger)
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 600))
+
+	added, err := ledger.Import([]Spend{
+		{ID: "a", Tool: "lnc_pay_invoice", AmountSat: 1,
+			At: time.Now()},
+		{ID: "b", Tool: "lnc_keysend", AmountSat: 300,
+			At: time.Now().Add(-time.Hour)},
+		{ID: "old", Tool: "lnc_keysend", AmountSat: 300,
+			At: time.Now().Add(-25 * time.Hour)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	require.Len(t, ledger.Spends(), 2)
+	assert.EqualValues(t, 600, ledger.Spends()[1].AmountSat)
+
+	assert.Error(t, engine.CheckSpend(200))
+}
@@ -0,0 +1,269 @@
+// Package policy enforces operator-configured limits on write operations.
+// Every tool that moves funds asks the Engine before touching the node, so
+// the limits hold no matter what arguments a model supplies.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+)
+
+// Config holds the operator-configured policy settings.
+type Config struct {
+	// WithdrawalAllowlist lists the only on-chain addresses funds may be
+	// sent to. An empty list denies all on-chain withdrawals.
+	WithdrawalAllowlist []string
+
+	// MaxPaymentSat caps what a single payment or on-chain send may
+	// spend, routing fees included. Zero means no cap.
+	MaxPaymentSat int64
+
+	// MaxHourlySat and MaxDailySat cap what payments and on-chain sends
+	// may spend in total over the last hour and the last 24 hours. Zero
+	// means no cap.
+	MaxHourlySat int64
+	MaxDailySat  int64
+
+	// MaxFeeRate caps the on-chain fee rate, in sat/vB, write tools may
+	// set. Zero means no cap.
+	MaxFeeRate uint64
+}
+
+// Engine evaluates write operations against the configured policy. Its
+// spend methods may be called on a nil Engine, which enforces no limits.
+type Engine struct {
+	withdrawalAllowlist map[string]struct{}
+
+	maxPaymentSat int64
+	maxHourlySat  int64
+	maxDailySat   int64
+	maxFeeRate    uint64
+
+	// ledger records spends for the hourly and daily caps.
+	ledger *Ledger
+}
+
+// NewEngine creates a policy engine from the given configuration.
+func NewEngine(cfg Config) *Engine {
+	allowlist := make(map[string]struct{}, len(cfg.WithdrawalAllowlist))
+	for _, addr := range cfg.WithdrawalAllowlist {
+		if addr = normalizeAddress(addr); addr != "" {
+			allowlist[addr] = struct{}{}
+		}
+	}
+
+	return &Engine{
+		withdrawalAllowlist: allowlist,
+		maxPaymentSat:       cfg.MaxPaymentSat,
+		maxHourlySat:        cfg.MaxHourlySat,
+		maxDailySat:         cfg.MaxDailySat,
+		maxFeeRate:          cfg.MaxFeeRate,
+		ledger:              NewLedger(),
+	}
+}
+
+// SetLedger replaces the in-memory ledger spends are recorded in, so the
+// hourly and daily caps hold across restarts. It must be called before
+// tools are served.
+func (e *Engine) SetLedger(ledger *Ledger) {
+	e.ledger = ledger
+}
+
+// SpendLimited reports whether any spend cap is configured.
+func (e *Engine) SpendLimited() bool {
+	return e != nil && (e.maxPaymentSat > 0 || e.maxHourlySat > 0 ||
+		e.maxDailySat > 0)
+}
+
+// FeeRateLimited reports whether the on-chain fee rate is capped.
+func (e *Engine) FeeRateLimited() bool {
+	return e != nil && e.maxFeeRate > 0
+}
+
+// WithdrawalAllowlistSize returns the number of allowlisted addresses.
+func (e *Engine) WithdrawalAllowlistSize() int {
+	return len(e.withdrawalAllowlist)
+}
+
+// CheckWithdrawal returns a PermissionDenied error unless the address is on
+// the withdrawal allowlist.
+func (e *Engine) CheckWithdrawal(address string) error {
+	if len(e.withdrawalAllowlist) == 0 {
+		return errors.ErrPermissionDenied("on-chain withdrawals are " +
+			"disabled; configure LNC_WITHDRAWAL_ALLOWLIST to enable")
+	}
+
+	if _, ok := e.withdrawalAllowlist[normalizeAddress(address)]; !ok {
+		return errors.ErrPermissionDenied("address " + address +
+			" is not on the withdrawal allowlist")
+	}
+
+	return nil
+}
+
+// CheckFeeRate returns a PermissionDenied error if satPerVbyte exceeds the
+// maximum on-chain fee rate.
+func (e *Engine) CheckFeeRate(satPerVbyte uint64) error {
+	if e == nil || e.maxFeeRate == 0 || satPerVbyte <= e.maxFeeRate {
+		return nil
+	}
+
+	return errors.ErrPermissionDenied(fmt.Sprintf("a fee rate of %d "+
+		"sat/vB exceeds the limit of %d sat/vB set by "+
+		"LNC_MAX_FEE_RATE_SAT_PER_VBYTE", satPerVbyte,
+		e.maxFeeRate)).WithDetails(map[string]any{
+		"limit":                   "fee_rate",
+		"limit_sat_per_vbyte":     e.maxFeeRate,
+		"requested_sat_per_vbyte": satPerVbyte,
+	})
+}
+
+// CheckSpend returns a PermissionDenied error if spending amountSat now
+// would exceed a spend cap. Nothing is recorded, so previews use it.
+func (e *Engine) CheckSpend(amountSat int64) error {
+	if !e.SpendLimited() {
+		return nil
+	}
+
+	e.ledger.mu.Lock()
+	defer e.ledger.mu.Unlock()
+
+	return e.checkSpend("", amountSat)
+}
+
+// ReserveSpend checks a spend like CheckSpend and records it under id, so
+// it counts against the caps from now on. A spend whose outcome is unknown
+// stays reserved; call SettleSpend once the amount actually spent is known
+// and ReleaseSpend if nothing was spent. An earlier spend with the same id
+// is replaced rather than counted twice.
+func (e *Engine) ReserveSpend(id, tool string, amountSat int64) error {
+	if !e.SpendLimited() {
+		return nil
+	}
+
+	e.ledger.mu.Lock()
+	defer e.ledger.mu.Unlock()
+
+	if err := e.checkSpend(id, amountSat); err != nil {
+		return err
+	}
+	err := e.ledger.record(&Spend{
+		ID:        id,
+		Tool:      tool,
+		AmountSat: amountSat,
+		At:        time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to record the spend in the ledger")
+	}
+
+	return nil
+}
+
+// SettleSpend sets the amount spent under id to amountSat, once known.
+// Unknown spends are ignored.
+func (e *Engine) SettleSpend(id string, amountSat int64) error {
+	if !e.SpendLimited() {
+		return nil
+	}
+
+	e.ledger.mu.Lock()
+	defer e.ledger.mu.Unlock()
+
+	spend, ok := e.ledger.spends[id]
+	if !ok || spend.AmountSat == amountSat {
+		return nil
+	}
+	settled := *spend
+	settled.AmountSat = amountSat
+	return e.ledger.record(&settled)
+}
+
+// ReleaseSpend removes the spend recorded under id, for a spend that
+// failed without spending anything.
+func (e *Engine) ReleaseSpend(id string) error {
+	if !e.SpendLimited() {
+		return nil
+	}
+
+	e.ledger.mu.Lock()
+	defer e.ledger.mu.Unlock()
+
+	return e.ledger.remove(id)
+}
+
+// checkSpend returns the error for the first cap spending amountSat would
+// exceed. The spend recorded under replacing, if any, is left out of the
+// totals. The caller holds the ledger's mu.
+func (e *Engine) checkSpend(replacing string, amountSat int64) error {
+	if e.maxPaymentSat > 0 && amountSat > e.maxPaymentSat {
+		return errors.ErrPermissionDenied(fmt.Sprintf("spending %d "+
+			"sat exceeds the per-payment limit of %d sat set by "+
+			"LNC_MAX_PAYMENT_SAT", amountSat,
+			e.maxPaymentSat)).WithDetails(map[string]any{
+			"limit":         "per_payment",
+			"limit_sat":     e.maxPaymentSat,
+			"requested_sat": amountSat,
+		})
+	}
+
+	replaced := e.ledger.spends[replacing]
+	windows := []struct {
+		name     string
+		period   string
+		variable string
+		window   time.Duration
+		limitSat int64
+	}{
+		{"per_hour", "hour", "LNC_MAX_SPEND_PER_HOUR_SAT", time.Hour,
+			e.maxHourlySat},
+		{"per_day", "24 hours", "LNC_MAX_SPEND_PER_DAY_SAT",
+			ledgerWindow, e.maxDailySat},
+	}
+	for _, window := range windows {
+		if window.limitSat == 0 {
+			continue
+		}
+
+		since := time.Now().Add(-window.window)
+		spent := e.ledger.spentSince(since)
+		if replaced != nil && !replaced.At.Before(since) {
+			spent -= replaced.AmountSat
+		}
+		if spent+amountSat <= window.limitSat {
+			continue
+		}
+
+		return errors.ErrPermissionDenied(fmt.Sprintf("spending %d "+
+			"sat would exceed the limit of %d sat per %s set by "+
+			"%s; %d sat was spent in the last %s", amountSat,
+			window.limitSat, window.period, window.variable, spent,
+			window.period)).WithDetails(map[string]any{
+			"limit":         window.name,
+			"limit_sat":     window.limitSat,
+			"spent_sat":     spent,
+			"requested_sat": amountSat,
+		})
+	}
+
+	return nil
+}
+
+// normalizeAddress trims whitespace and lower-cases bech32 addresses, which
+// are case-insensitive. Base58 addresses are case-sensitive and kept as is.
+func normalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+
+	lower := strings.ToLower(address)
+	for _, hrp := range []string{"bc1", "tb1", "bcrt1", "sb1"} {
+		if strings.HasPrefix(lower, hrp) {
+			return lower
+		}
+	}
+
+	return address
+}
@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	coldStorage = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	legacy      = "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+)
+
+// Test that an empty allowlist denies every withdrawal.
+func TestCheckWithdrawal_EmptyAllowlist(t *testing.T) {
+	engine := NewEngine(Config{})
+
+	err := engine.CheckWithdrawal(coldStorage)
+	assert.True(t, errors.Is(err, errors.ErrCodePermissionDenied))
+	assert.Zero(t, engine.WithdrawalAllowlistSize())
+}
+
+// Test allowlist matching and normalization.
+func TestCheckWithdrawal_Allowlist(t *testing.T) {
+	engine := NewEngine(Config{
+		WithdrawalAllowlist: []string{" " + coldStorage + " ", legacy, ""},
+	})
+	assert.Equal(t, 2, engine.WithdrawalAllowlistSize())
+
+	tests := []struct {
+		name    string
+		address string
+		allowed bool
+	}{
+		{"exact_bech32", coldStorage, true},
+		{"uppercase_bech32", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true},
+		{"exact_base58", legacy, true},
+		{"base58_case_matters", "1bvbmseystwetqtfn5au4m4gfg7xjanvn2", false},
+		{"other_address", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.CheckWithdrawal(tt.address)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, errors.ErrCodePermissionDenied))
+		})
+	}
+}
+
+// Test that the per-payment, hourly and daily caps reject spends that
+// would exceed them, and that released and settled spends count as such.
+func TestReserveSpend(t *testing.T) {
+	engine := NewEngine(Config{
+		MaxPaymentSat: 1_000,
+		MaxHourlySat:  1_500,
+		MaxDailySat:   2_000,
+	})
+	assert.True(t, engine.SpendLimited())
+
+	details := func(err error) map[string]any {
+		var policyErr *errors.Error
+		require.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, errors.ErrCodePermissionDenied, policyErr.Code)
+		return policyErr.Details
+	}
+
+	err := engine.ReserveSpend("a", "lnc_pay_invoice", 1_001)
+	assert.Equal(t, "per_payment", details(err)["limit"])
+
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 1_000))
+	err = engine.CheckSpend(600)
+	assert.Equal(t, "per_hour", details(err)["limit"])
+	assert.EqualValues(t, 1_000, details(err)["spent_sat"])
+
+	// Reserving under the same ID replaces the earlier spend.
+	require.NoError(t, engine.ReserveSpend("a", "lnc_pay_invoice", 900))
+	require.NoError(t, engine.CheckSpend(600))
+
+	// A failed spend is released and a settled one counts what it spent.
+	require.NoError(t, engine.ReleaseSpend("a"))
+	require.NoError(t, engine.ReserveSpend("b", "lnc_keysend", 1_000))
+	require.NoError(t, engine.SettleSpend("b", 400))
+	require.NoError(t, engine.ReserveSpend("c", "lnc_send_coins", 1_000))
+
+	// Spends older than an hour only count against the daily cap.
+	for _, spend := range engine.ledger.spends {
+		spend.At = spend.At.Add(-2 * time.Hour)
+	}
+	require.NoError(t, engine.CheckSpend(600))
+	err = engine.CheckSpend(601)
+	assert.Equal(t, "per_day", details(err)["limit"])
+	assert.EqualValues(t, 1_400, details(err)["spent_sat"])
+}
+
+// Test the on-chain fee rate cap.
+func TestCheckFeeRate(t *testing.T) {
+	engine := NewEngine(Config{MaxFeeRate: 50})
+	assert.True(t, engine.FeeRateLimited())
+	assert.NoError(t, engine.CheckFeeRate(0))
+	assert.NoError(t, engine.CheckFeeRate(50))
+	err := engine.CheckFeeRate(51)
+	assert.True(t, errors.Is(err, errors.ErrCodePermissionDenied))
+	assert.Contains(t, err.Error(), "LNC_MAX_FEE_RATE_SAT_PER_VBYTE")
+
+	assert.False(t, NewEngine(Config{}).FeeRateLimited())
+	assert.NoError(t, NewEngine(Config{}).CheckFeeRate(1_000))
+}
+
+// Test that without caps, or without an engine, nothing is limited or
+// recorded.
+func TestSpend_Unlimited(t *testing.T) {
+	engine := NewEngine(Config{})
+	assert.False(t, engine.SpendLimited())
+	assert.NoError(t, engine.ReserveSpend("a", "lnc_keysend", 1<<40))
+	assert.Empty(t, engine.ledger.Spends())
+
+	var none *Engine
+	assert.False(t, none.SpendLimited())
+	assert.False(t, none.FeeRateLimited())
+	assert.NoError(t, none.CheckFeeRate(1_000))
+	assert.NoError(t, none.CheckSpend(1_000))
+	assert.NoError(t, none.ReserveSpend("a", "lnc_keysend", 1_000))
+	assert.NoError(t, none.SettleSpend("a", 1_000))
+	assert.NoError(t, none.ReleaseSpend("a"))
+}
@@ -0,0 +1,280 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Caller calls a tool and returns whether the result is an error, and its
+// text. err reports a call that did not produce a result at all.
+type Caller interface {
+	CallText(ctx context.Context, tool string,
+		args map[string]any) (isError bool, text string, err error)
+}
+
+// StepResult is the outcome of one step.
+type StepResult struct {
+	Name     string        `json:"name"`
+	Tool     string        `json:"tool"`
+	Passed   bool          `json:"passed"`
+	Failures []string      `json:"failures,omitempty"`
+	Elapsed  time.Duration `json:"elapsed_ns"`
+}
+
+// Result is the outcome of a scenario.
+type Result struct {
+	Name   string       `json:"name"`
+	Passed bool         `json:"passed"`
+	Steps  []StepResult `json:"steps"`
+
+	// Error reports a scenario that could not be run.
+	Error string `json:"error,omitempty"`
+}
+
+// Run makes a scenario's calls in order and checks each result. Every step
+// is run even after one fails, so a report shows all that went wrong.
+func Run(ctx context.Context, caller Caller, scenario *Scenario) Result {
+	result := Result{Name: scenario.Name, Passed: true}
+	for _, step := range scenario.Steps {
+		name := step.Name
+		if name == "" {
+			name = step.Tool
+		}
+
+		start := time.Now()
+		isError, text, err := caller.CallText(ctx, step.Tool, step.Args)
+		stepResult := StepResult{
+			Name:    name,
+			Tool:    step.Tool,
+			Elapsed: time.Since(start),
+		}
+		if err != nil {
+			stepResult.Failures = []string{fmt.Sprintf("call "+
+				"failed: %v", err)}
+		} else {
+			stepResult.Failures = step.Expect.check(isError, text)
+		}
+
+		stepResult.Passed = len(stepResult.Failures) == 0
+		if !stepResult.Passed {
+			result.Passed = false
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+	return result
+}
+
+// WriteText writes scenario results as a human-readable report, listing
+// each failed assertion under its step.
+func WriteText(w io.Writer, results []Result) error {
+	passed := 0
+	for _, result := range results {
+		status := "PASS"
+		if result.Passed {
+			passed++
+		} else {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s\n", status, result.Name)
+		if result.Error != "" {
+			fmt.Fprintf(w, "    error: %s\n", result.Error)
+		}
+
+		for i, step := range result.Steps {
+			if step.Passed {
+				continue
+			}
+			fmt.Fprintf(w, "  step %d (%s):\n", i+1, step.Name)
+			for _, failure := range step.Failures {
+				fmt.Fprintf(w, "    %s\n", failure)
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d of %d scenarios passed\n", passed,
+		len(results))
+	return err
+}
+
+// check returns the assertions a result fails.
+func (e *Expect) check(isError bool, text string) []string {
+	var failures []string
+	if isError != e.Error {
+		if isError {
+			failures = append(failures, fmt.Sprintf("expected "+
+				"success, got error: %s", text))
+		} else {
+			failures = append(failures, "expected an error result")
+		}
+		return failures
+	}
+
+	for _, want := range e.Contains {
+		if !strings.Contains(text, want) {
+			failures = append(failures, fmt.Sprintf("result does "+
+				"not contain %q", want))
+		}
+	}
+
+	if len(e.Fields) == 0 {
+		return failures
+	}
+	var document any
+	if err := json.Unmarshal([]byte(text), &document); err != nil {
+		return append(failures, fmt.Sprintf("result is not JSON: %v",
+			err))
+	}
+
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		matcher := e.Fields[path]
+		value, found := lookup(document, path)
+		for _, failure := range matcher.check(value, found) {
+			failures = append(failures, path+": "+failure)
+		}
+	}
+	return failures
+}
+
+// check returns the ways a value fails the matcher.
+func (m *Matcher) check(value any, found bool) []string {
+	if m.absent() {
+		if found {
+			return []string{fmt.Sprintf("expected no value, got %s",
+				format(value))}
+		}
+		return nil
+	}
+	if !found {
+		return []string{"not found"}
+	}
+
+	var failures []string
+	if m.HasEquals {
+		want := normalize(m.Equals)
+		if !reflect.DeepEqual(want, value) {
+			failures = append(failures, fmt.Sprintf("expected %s, "+
+				"got %s", format(want), format(value)))
+		}
+	}
+
+	if m.Contains != nil {
+		want := normalize(m.Contains)
+		if !contains(value, want) {
+			failures = append(failures, fmt.Sprintf("%s does not "+
+				"contain %s", format(value), format(want)))
+		}
+	}
+
+	if m.Min != nil || m.Max != nil {
+		number, ok := value.(float64)
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("expected a "+
+				"number, got %s", format(value)))
+		case m.Min != nil && number < *m.Min:
+			failures = append(failures, fmt.Sprintf("%s is below "+
+				"the minimum %v", format(value), *m.Min))
+		case m.Max != nil && number > *m.Max:
+			failures = append(failures, fmt.Sprintf("%s is above "+
+				"the maximum %v", format(value), *m.Max))
+		}
+	}
+
+	if m.Length != nil {
+		length := -1
+		switch value := value.(type) {
+		case string:
+			length = len(value)
+		case []any:
+			length = len(value)
+		case map[string]any:
+			length = len(value)
+		}
+		if length != *m.Length {
+			failures = append(failures, fmt.Sprintf("expected "+
+				"length %d, got %s", *m.Length, format(value)))
+		}
+	}
+
+	return failures
+}
+
+// lookup follows a dotted path into a decoded JSON document. Numeric
+// segments index lists.
+func lookup(document any, path string) (any, bool) {
+	value := document
+	for _, segment := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			value = node[index]
+
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// contains reports whether a string holds a substring or a list holds an
+// element.
+func contains(value, want any) bool {
+	switch value := value.(type) {
+	case string:
+		substring, ok := want.(string)
+		return ok && strings.Contains(value, substring)
+
+	case []any:
+		for _, element := range value {
+			if reflect.DeepEqual(element, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalize converts a value decoded from YAML to the types the same value
+// decodes to from JSON, so the two can be compared.
+func normalize(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// format renders a value in a failure message.
+func format(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,270 @@
+// Package scenario runs declarative test scenarios against the MCP server:
+// sequences of tool calls, written in YAML, with assertions on each result.
+// Teams use them to regression-test how their assistants use the server.
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a named sequence of tool calls made against one simulated
+// node.
+type Scenario struct {
+	// Name identifies the scenario in reports. It defaults to the name of
+	// the file the scenario was loaded from.
+	Name string `yaml:"name"`
+
+	// Description says what the scenario checks.
+	Description string `yaml:"description"`
+
+	// Sim sizes the simulated node the scenario runs against.
+	Sim Sim `yaml:"sim"`
+
+	// Steps are the calls to make, in order.
+	Steps []Step `yaml:"steps"`
+}
+
+// Sim configures the simulated node. Zero values take the runner's
+// defaults.
+type Sim struct {
+	// Channels sets the node's channel, peer, invoice, payment and UTXO
+	// counts.
+	Channels int `yaml:"channels"`
+
+	// Latency is added to every RPC, as "5ms".
+	Latency time.Duration `yaml:"latency"`
+
+	// RecoveryProgress, between 0 and 1, has the wallet part way through
+	// a recovery rescan; 1 is a finished scan.
+	RecoveryProgress float64 `yaml:"recovery_progress"`
+}
+
+// Step is one tool call and what its result must look like.
+type Step struct {
+	// Name describes the step in reports. It defaults to the tool name.
+	Name string `yaml:"name"`
+
+	// Tool is the tool to call.
+	Tool string `yaml:"tool"`
+
+	// Args are the call's arguments.
+	Args map[string]any `yaml:"args"`
+
+	// Expect holds the assertions on the result.
+	Expect Expect `yaml:"expect"`
+}
+
+// Expect holds the assertions on a tool result. A step passes when the
+// result's error state matches Error and every other assertion holds.
+type Expect struct {
+	// Error is whether the tool must return an error result. Steps expect
+	// success unless they say otherwise.
+	Error bool `yaml:"error"`
+
+	// Contains lists strings the result text must contain.
+	Contains []string `yaml:"contains"`
+
+	// Fields maps paths into the JSON result, such as "channels.0.active",
+	// to the value found there or a Matcher. Error results are JSON too,
+	// so their "code" can be checked the same way.
+	Fields map[string]Matcher `yaml:"fields"`
+}
+
+// matcherKeys are the keys of a YAML mapping that make it a Matcher rather
+// than a value to compare with.
+var matcherKeys = map[string]bool{
+	"equals":   true,
+	"contains": true,
+	"min":      true,
+	"max":      true,
+	"exists":   true,
+	"length":   true,
+}
+
+// Matcher is an assertion on the value at a path. Written as a plain
+// value, it requires the path to hold that value; written as a mapping of
+// the keys below, every key given must hold:
+//
+//	equals:   the value, which may itself be a mapping or a list
+//	contains: a substring of a string, or an element of a list
+//	min, max: bounds on a number
+//	exists:   whether the path must be present
+//	length:   the length of a string, list or object
+type Matcher struct {
+	Equals    any
+	HasEquals bool
+	Contains  any
+	Min       *float64
+	Max       *float64
+	Exists    *bool
+	Length    *int
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Matcher) UnmarshalYAML(node *yaml.Node) error {
+	if !isMatcherNode(node) {
+		m.HasEquals = true
+		return node.Decode(&m.Equals)
+	}
+
+	var fields struct {
+		Equals   yaml.Node `yaml:"equals"`
+		Contains any       `yaml:"contains"`
+		Min      *float64  `yaml:"min"`
+		Max      *float64  `yaml:"max"`
+		Exists   *bool     `yaml:"exists"`
+		Length   *int      `yaml:"length"`
+	}
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	if fields.Equals.Kind != 0 {
+		m.HasEquals = true
+		if err := fields.Equals.Decode(&m.Equals); err != nil {
+			return err
+		}
+	}
+	m.Contains = fields.Contains
+	m.Min = fields.Min
+	m.Max = fields.Max
+	m.Exists = fields.Exists
+	m.Length = fields.Length
+	return nil
+}
+
+// isMatcherNode reports whether a node is a non-empty mapping whose keys
+// are all matcher keys.
+func isMatcherNode(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return false
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if !matcherKeys[node.Content[i].Value] {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses a scenario. Unknown keys are rejected, so a misspelt
+// assertion fails loudly rather than passing unchecked.
+func Parse(data []byte) (*Scenario, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var scenario Scenario
+	if err := decoder.Decode(&scenario); err != nil {
+		return nil, err
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
+// Load reads the scenario in a file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path),
+			filepath.Ext(path))
+	}
+	return scenario, nil
+}
+
+// Files expands paths into the scenario files they name: files are kept
+// as given and directories contribute their .yaml and .yml files, in name
+// order.
+func Files(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var found []string
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			found = append(found, filepath.Join(path, entry.Name()))
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no scenario files found")
+	}
+	return files, nil
+}
+
+// validate checks that a scenario can be run.
+func (s *Scenario) validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	if s.Sim.Channels < 0 || s.Sim.Latency < 0 {
+		return fmt.Errorf("sim channels and latency must not be " +
+			"negative")
+	}
+	if s.Sim.RecoveryProgress < 0 || s.Sim.RecoveryProgress > 1 {
+		return fmt.Errorf("sim recovery_progress must be between 0 " +
+			"and 1")
+	}
+
+	for i, step := range s.Steps {
+		if step.Tool == "" {
+			return fmt.Errorf("step %d has no tool", i+1)
+		}
+		for path, matcher := range step.Expect.Fields {
+			if path == "" {
+				return fmt.Errorf("step %d has an empty field "+
+					"path", i+1)
+			}
+			if matcher.absent() && matcher.matchesValue() {
+				return fmt.Errorf("step %d: field %s cannot "+
+					"both be absent and match a value",
+					i+1, path)
+			}
+		}
+	}
+	return nil
+}
+
+// absent reports whether the matcher requires its path to be missing.
+func (m *Matcher) absent() bool {
+	return m.Exists != nil && !*m.Exists
+}
+
+// matchesValue reports whether the matcher checks the value at its path.
+func (m *Matcher) matchesValue() bool {
+	return m.HasEquals || m.Contains != nil || m.Min != nil ||
+		m.Max != nil || m.Length != nil
+}
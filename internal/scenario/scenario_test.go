@@ -0,0 +1,237 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cannedCaller answers each tool with a fixed result.
+type cannedCaller struct {
+	results map[string]string
+	errors  map[string]bool
+	calls   []string
+}
+
+func (c *cannedCaller) CallText(ctx context.Context, tool string,
+	args map[string]any) (bool, string, error) {
+	c.calls = append(c.calls, tool)
+
+	text, ok := c.results[tool]
+	if !ok {
+		return false, "", fmt.Errorf("unknown tool %s", tool)
+	}
+	return c.errors[tool], text, nil
+}
+
+func TestParse(t *testing.T) {
+	scenario, err := Parse([]byte(`
+name: overview
+sim:
+  channels: 2
+  latency: 5ms
+  recovery_progress: 0.5
+steps:
+  - tool: lnc_get_info
+    args:
+      verbose: true
+    expect:
+      contains: [simnode]
+      fields:
+        alias: simnode
+        chains:
+          equals: [regtest]
+        num_peers:
+          min: 1
+          max: 10
+        missing:
+          exists: false
+        nested:
+          value: 1
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "overview", scenario.Name)
+	assert.Equal(t, Sim{
+		Channels:         2,
+		Latency:          5 * time.Millisecond,
+		RecoveryProgress: 0.5,
+	}, scenario.Sim)
+	require.Len(t, scenario.Steps, 1)
+
+	step := scenario.Steps[0]
+	assert.Equal(t, map[string]any{"verbose": true}, step.Args)
+	assert.Equal(t, []string{"simnode"}, step.Expect.Contains)
+
+	fields := step.Expect.Fields
+	assert.Equal(t, Matcher{Equals: "simnode", HasEquals: true},
+		fields["alias"])
+	assert.Equal(t, []any{"regtest"}, fields["chains"].Equals)
+	assert.Equal(t, 1.0, *fields["num_peers"].Min)
+	assert.Equal(t, 10.0, *fields["num_peers"].Max)
+	assert.False(t, fields["num_peers"].HasEquals)
+	assert.False(t, *fields["missing"].Exists)
+
+	// A mapping with other keys is a value, not a matcher.
+	assert.Equal(t, Matcher{
+		Equals: map[string]any{"value": 1}, HasEquals: true,
+	}, fields["nested"])
+}
+
+func TestParseRejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"no steps":     "name: empty\n",
+		"no tool":      "steps:\n  - expect: {error: true}\n",
+		"unknown key":  "steps:\n  - tool: a\n    expcet: {}\n",
+		"negative sim": "sim: {channels: -1}\nsteps:\n  - tool: a\n",
+		"recovery past 1": "sim: {recovery_progress: 1.5}\n" +
+			"steps:\n  - tool: a\n",
+		"absent and matched": "steps:\n  - tool: a\n    expect:\n" +
+			"      fields:\n        x: {exists: false, min: 1}\n",
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	caller := &cannedCaller{
+		results: map[string]string{
+			"info": `{"alias":"simnode","chains":["regtest"],` +
+				`"num_peers":3,"channels":[{"active":true}]}`,
+			"fail": `{"code":"InvalidArgument","message":"bad"}`,
+			"text": "not json",
+		},
+		errors: map[string]bool{"fail": true},
+	}
+
+	scenario, err := Parse([]byte(`
+name: run
+steps:
+  - tool: info
+    expect:
+      contains: [simnode]
+      fields:
+        alias: simnode
+        num_peers: {min: 1, max: 3}
+        chains: {contains: regtest, length: 1}
+        channels.0.active: true
+        channels.1: {exists: false}
+  - name: rejected
+    tool: fail
+    expect:
+      error: true
+      fields:
+        code: InvalidArgument
+  - tool: text
+    expect:
+      contains: [json]
+`))
+	require.NoError(t, err)
+
+	result := Run(context.Background(), caller, scenario)
+	assert.True(t, result.Passed, "%+v", result)
+	assert.Equal(t, []string{"info", "fail", "text"}, caller.calls)
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, "info", result.Steps[0].Name)
+	assert.Equal(t, "rejected", result.Steps[1].Name)
+
+	scenario, err = Parse([]byte(`
+name: failing
+steps:
+  - tool: info
+    expect:
+      contains: [lnd]
+      fields:
+        alias: other
+        num_peers: {max: 2}
+        chains: {contains: mainnet}
+        channels: {length: 2}
+        channels.5.active: true
+        node_id: {exists: false}
+        alias.0: {exists: false}
+  - tool: fail
+  - tool: info
+    expect:
+      error: true
+  - tool: text
+    expect:
+      fields:
+        alias: simnode
+  - tool: missing
+`))
+	require.NoError(t, err)
+
+	result = Run(context.Background(), caller, scenario)
+	assert.False(t, result.Passed)
+	require.Len(t, result.Steps, 5)
+	for _, step := range result.Steps {
+		assert.False(t, step.Passed, step.Name)
+	}
+
+	// Assertions that hold are not reported; those that fail are, in
+	// path order.
+	assert.Equal(t, []string{
+		`result does not contain "lnd"`,
+		`alias: expected "other", got "simnode"`,
+		`chains: ["regtest"] does not contain "mainnet"`,
+		`channels: expected length 2, got [{"active":true}]`,
+		`channels.5.active: not found`,
+		`num_peers: 3 is above the maximum 2`,
+	}, result.Steps[0].Failures)
+	assert.Contains(t, result.Steps[1].Failures[0],
+		"expected success, got error")
+	assert.Equal(t, []string{"expected an error result"},
+		result.Steps[2].Failures)
+	assert.Contains(t, result.Steps[3].Failures[0], "result is not JSON")
+	assert.Equal(t, []string{"call failed: unknown tool missing"},
+		result.Steps[4].Failures)
+
+	var report bytes.Buffer
+	require.NoError(t, WriteText(&report, []Result{result,
+		{Name: "broken", Error: "failed to create server"}}))
+	assert.Contains(t, report.String(), "FAIL failing\n  step 1 (info):\n")
+	assert.Contains(t, report.String(),
+		"FAIL broken\n    error: failed to create server\n")
+	assert.Contains(t, report.String(), "0 of 2 scenarios passed")
+}
+
+func TestLoadAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	step := []byte("steps:\n  - tool: lnc_get_info\n")
+	for _, name := range []string{"b.yml", "a.yaml", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), step,
+			0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.yaml"), 0o700))
+
+	files, err := Files([]string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml"),
+	}, files)
+
+	scenario, err := Load(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, "b", scenario.Name)
+
+	_, err = Files([]string{t.TempDir()})
+	assert.Error(t, err)
+	_, err = Files([]string{filepath.Join(dir, "missing.yaml")})
+	assert.Error(t, err)
+
+	// The examples shipped with the docs must stay valid.
+	examples, err := Files([]string{"../../docs/scenarios"})
+	require.NoError(t, err)
+	for _, example := range examples {
+		_, err = Load(example)
+		assert.NoError(t, err, example)
+	}
+}
@@ -0,0 +1,533 @@
+// Package schedule persists write tool calls set to run at a later time.
+// Operations that are cancelled or fail are soft-deleted rather than
+// dropped: they keep their arguments and the history of everything that
+// happened to them, so automation changes stay auditable, and a restore
+// schedules them again.
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/store"
+)
+
+// States of a scheduled operation.
+const (
+	StateScheduled = "scheduled"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// States lists the states of a scheduled operation.
+var States = []string{
+	StateScheduled, StateRunning, StateSucceeded, StateFailed,
+	StateCancelled,
+}
+
+// Actions recorded in an operation's history, besides the states it
+// finished in.
+const (
+	ActionScheduled = "scheduled"
+	ActionStarted   = "started"
+	ActionRestored  = "restored"
+)
+
+// defaultPruneAge is how long finished operations are kept when Prune is
+// not told otherwise. They are never pruned unless asked.
+const defaultPruneAge = 30 * 24 * time.Hour
+
+// fileVersion is the version of the schedule's file format.
+const fileVersion = 1
+
+// fileFormat describes the schedule's file format. It started with a
+// checksum, so there is nothing to migrate yet.
+var fileFormat = store.Format{
+	Name:    "schedule",
+	Version: fileVersion,
+	Entries: "operations",
+}
+
+var (
+	// ErrNotFound is returned for an ID no operation has.
+	ErrNotFound = errors.New("no scheduled operation has that ID")
+
+	// ErrNotScheduled is returned when cancelling an operation that is
+	// no longer waiting to run.
+	ErrNotScheduled = errors.New("only operations waiting to run can " +
+		"be cancelled")
+
+	// ErrNotDeleted is returned when restoring an operation that was
+	// neither cancelled nor failed.
+	ErrNotDeleted = errors.New("only cancelled or failed operations " +
+		"can be restored")
+)
+
+// Event is one entry in an operation's history.
+type Event struct {
+	At     time.Time `json:"at"`
+	Action string    `json:"action"`
+	Note   string    `json:"note,omitempty"`
+}
+
+// Operation is one scheduled write tool call.
+type Operation struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	RunAt     time.Time      `json:"run_at"`
+	State     string         `json:"state"`
+
+	// DeletedAt is when the operation was cancelled or failed. Such
+	// operations are kept, and listed only when asked for, until they
+	// are restored or pruned.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// History records every change to the operation, oldest first.
+	History []Event `json:"history"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Deleted reports whether the operation is soft-deleted.
+func (op Operation) Deleted() bool {
+	return op.DeletedAt != nil
+}
+
+// file is the on-disk form of the schedule.
+type file struct {
+	Version    int          `json:"version"`
+	Checksum   string       `json:"checksum"`
+	Operations []*Operation `json:"operations"`
+}
+
+// Schedule keeps operations in a JSON file, rewritten whole on every
+// change so a crash leaves either the old or the new schedule. It is safe
+// for concurrent use; a nil Schedule holds nothing.
+type Schedule struct {
+	mu         sync.Mutex
+	path       string
+	operations map[string]*Operation
+}
+
+// Open loads the schedule at path, or starts an empty one if the file does
+// not exist yet. It is created with owner-only permissions on first write.
+// Operations the previous server stopped while running are failed, as
+// whether their call took effect is unknown, and running them again could
+// repeat it.
+func Open(path string) (*Schedule, error) {
+	s := &Schedule{
+		path:       path,
+		operations: make(map[string]*Operation),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	operations, _, err := decode(path, data)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for _, op := range operations {
+		if op.State == StateRunning {
+			finish(op, StateFailed, "interrupted: the server "+
+				"stopped while the operation ran", now)
+		}
+		s.operations[op.ID] = op
+	}
+
+	return s, nil
+}
+
+// Add schedules a call of tool with args at runAt and returns the new
+// operation.
+func (s *Schedule) Add(tool string, args map[string]any, runAt time.Time,
+	note string) (Operation, error) {
+	if s == nil {
+		return Operation{}, errors.New("no schedule is configured")
+	}
+
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return Operation{}, err
+	}
+	now := time.Now().UTC()
+	op := &Operation{
+		ID:        hex.EncodeToString(raw[:]),
+		Tool:      tool,
+		Arguments: args,
+		RunAt:     runAt.UTC(),
+		State:     StateScheduled,
+		History: []Event{{
+			At:     now,
+			Action: ActionScheduled,
+			Note:   note,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.operations[op.ID] = op
+	if err := s.save(); err != nil {
+		delete(s.operations, op.ID)
+		return Operation{}, err
+	}
+	return copyOperation(op), nil
+}
+
+// Get returns the operation with id.
+func (s *Schedule) Get(id string) (Operation, bool) {
+	if s == nil {
+		return Operation{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.operations[id]
+	if !ok {
+		return Operation{}, false
+	}
+	return copyOperation(op), true
+}
+
+// Operations returns a copy of every operation, soft-deleted ones
+// included, in the order they run.
+func (s *Schedule) Operations() []Operation {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops := make([]Operation, 0, len(s.operations))
+	for _, op := range s.operations {
+		ops = append(ops, copyOperation(op))
+	}
+	sortOperations(ops)
+	return ops
+}
+
+// Due returns the operations waiting to run whose time has come by now,
+// in the order they are due.
+func (s *Schedule) Due(now time.Time) []Operation {
+	var due []Operation
+	for _, op := range s.Operations() {
+		if op.State == StateScheduled && !op.RunAt.After(now) {
+			due = append(due, op)
+		}
+	}
+	return due
+}
+
+// Cancel cancels an operation waiting to run, soft-deleting it.
+func (s *Schedule) Cancel(id, note string) (Operation, error) {
+	return s.change(id, func(op *Operation, now time.Time) error {
+		if op.State != StateScheduled {
+			return ErrNotScheduled
+		}
+		finish(op, StateCancelled, note, now)
+		return nil
+	})
+}
+
+// Start marks an operation waiting to run as running. It reports false
+// when the operation is no longer waiting, such as when it was cancelled
+// after it was found due.
+func (s *Schedule) Start(id string) (bool, error) {
+	_, err := s.change(id, func(op *Operation, now time.Time) error {
+		if op.State != StateScheduled {
+			return ErrNotScheduled
+		}
+		op.State = StateRunning
+		op.History = append(op.History, Event{
+			At:     now,
+			Action: ActionStarted,
+		})
+		return nil
+	})
+	if errors.Is(err, ErrNotScheduled) || errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Finish records the outcome of a running operation: succeeded, or failed
+// with problem when it is not empty, which soft-deletes it.
+func (s *Schedule) Finish(id, problem string) error {
+	_, err := s.change(id, func(op *Operation, now time.Time) error {
+		if op.State != StateRunning {
+			return fmt.Errorf("scheduled operation %s is %s, not "+
+				"running", id, op.State)
+		}
+		if problem != "" {
+			finish(op, StateFailed, problem, now)
+		} else {
+			finish(op, StateSucceeded, "", now)
+		}
+		return nil
+	})
+	return err
+}
+
+// Restore schedules a cancelled or failed operation again at runAt,
+// undoing its soft deletion. Its history is kept, with the restore added.
+func (s *Schedule) Restore(id string, runAt time.Time,
+	note string) (Operation, error) {
+	return s.change(id, func(op *Operation, now time.Time) error {
+		if !op.Deleted() {
+			return ErrNotDeleted
+		}
+		op.State = StateScheduled
+		op.RunAt = runAt.UTC()
+		op.DeletedAt = nil
+		op.History = append(op.History, Event{
+			At:     now,
+			Action: ActionRestored,
+			Note:   note,
+		})
+		return nil
+	})
+}
+
+// change applies update to the operation with id and saves the schedule,
+// leaving the operation as it was if either fails.
+func (s *Schedule) change(id string,
+	update func(op *Operation, now time.Time) error) (Operation, error) {
+	if s == nil {
+		return Operation{}, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.operations[id]
+	if !ok {
+		return Operation{}, ErrNotFound
+	}
+	previous := copyOperation(op)
+
+	now := time.Now().UTC()
+	if err := update(op, now); err != nil {
+		return Operation{}, err
+	}
+	op.UpdatedAt = now
+	if err := s.save(); err != nil {
+		*op = previous
+		return Operation{}, err
+	}
+	return copyOperation(op), nil
+}
+
+// finish moves op to a final state, soft-deleting it unless it succeeded.
+func finish(op *Operation, state, note string, now time.Time) {
+	op.State = state
+	op.UpdatedAt = now
+	if state != StateSucceeded {
+		deletedAt := now
+		op.DeletedAt = &deletedAt
+	}
+	op.History = append(op.History, Event{
+		At:     now,
+		Action: state,
+		Note:   note,
+	})
+}
+
+// save atomically replaces the schedule file. The caller holds mu.
+func (s *Schedule) save() error {
+	stored := file{Version: fileVersion, Operations: []*Operation{}}
+	for _, op := range s.operations {
+		stored.Operations = append(stored.Operations, op)
+	}
+	sort.Slice(stored.Operations, func(a, b int) bool {
+		return stored.Operations[a].ID < stored.Operations[b].ID
+	})
+
+	checksum, err := store.Checksum(stored.Operations)
+	if err != nil {
+		return err
+	}
+	stored.Checksum = checksum
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return store.WriteFile(s.path, data)
+}
+
+// decode parses a schedule file and checks its integrity: the checksum of
+// its operations, and that each operation is identified only once and in
+// a known state. It returns the operations and the version the file was
+// stored at.
+func decode(path string, data []byte) ([]*Operation, int, error) {
+	entries, version, err := fileFormat.Decode(path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var operations []*Operation
+	if err := json.Unmarshal(entries, &operations); err != nil {
+		return nil, 0, err
+	}
+	seen := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		if op == nil || op.ID == "" || op.Tool == "" {
+			return nil, 0, fmt.Errorf("schedule %s has an "+
+				"operation without an ID and tool", path)
+		}
+		if seen[op.ID] {
+			return nil, 0, fmt.Errorf("schedule %s lists %s more "+
+				"than once", path, op.ID)
+		}
+		seen[op.ID] = true
+		if !knownState(op.State) {
+			return nil, 0, fmt.Errorf("schedule %s has operation "+
+				"%s in unknown state %q", path, op.ID, op.State)
+		}
+	}
+
+	return operations, version, nil
+}
+
+// Path returns the file the schedule is kept in.
+func (s *Schedule) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.path
+}
+
+// Check reads the schedule file back, verifies its integrity and returns
+// the format version it is stored in. A schedule not written yet passes
+// with version 0.
+func (s *Schedule) Check() (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, version, err := decode(s.path, data)
+	return version, err
+}
+
+// Prune drops finished operations, succeeded or soft-deleted, last
+// updated longer than olderThan ago, or than 30 days when olderThan is not
+// positive, and returns how many it dropped. Operations waiting to run are
+// kept however old they are.
+func (s *Schedule) Prune(olderThan time.Duration) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if olderThan <= 0 {
+		olderThan = defaultPruneAge
+	}
+	cutoff := time.Now().Add(-olderThan)
+	pruned := make(map[string]*Operation)
+	for id, op := range s.operations {
+		finished := op.State == StateSucceeded || op.Deleted()
+		if finished && op.UpdatedAt.Before(cutoff) {
+			pruned[id] = op
+			delete(s.operations, id)
+		}
+	}
+
+	if err := s.save(); err != nil {
+		for id, op := range pruned {
+			s.operations[id] = op
+		}
+		return 0, err
+	}
+	return len(pruned), nil
+}
+
+// Vacuum removes temporary files left by writes a crash interrupted and
+// rewrites the schedule. It returns how many temporary files it removed.
+func (s *Schedule) Vacuum() (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed, err := store.RemoveTemp(s.path)
+	if err != nil {
+		return removed, err
+	}
+	return removed, s.save()
+}
+
+// knownState reports whether state is a state of a scheduled operation.
+func knownState(state string) bool {
+	for _, known := range States {
+		if state == known {
+			return true
+		}
+	}
+	return false
+}
+
+// copyOperation returns a copy of op that shares nothing the schedule
+// changes.
+func copyOperation(op *Operation) Operation {
+	copied := *op
+	if op.Arguments != nil {
+		copied.Arguments = make(map[string]any, len(op.Arguments))
+		for k, v := range op.Arguments {
+			copied.Arguments[k] = v
+		}
+	}
+	if op.DeletedAt != nil {
+		deletedAt := *op.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	copied.History = append([]Event(nil), op.History...)
+	return copied
+}
+
+// sortOperations orders operations by when they run, then by ID.
+func sortOperations(ops []Operation) {
+	sort.Slice(ops, func(a, b int) bool {
+		if !ops[a].RunAt.Equal(ops[b].RunAt) {
+			return ops[a].RunAt.Before(ops[b].RunAt)
+		}
+		return ops[a].ID < ops[b].ID
+	})
+}
@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that an operation is due once its time comes, and that its run is
+// recorded in its history and survives reopening the schedule.
+func TestSchedule_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	s, err := Open(path)
+	require.NoError(t, err)
+
+	now := time.Now()
+	op, err := s.Add("lnc_keysend", map[string]any{"amount_sat": 10},
+		now.Add(time.Hour), "weekly tip")
+	require.NoError(t, err)
+	assert.Len(t, op.ID, 16)
+	assert.Equal(t, StateScheduled, op.State)
+
+	assert.Empty(t, s.Due(now))
+	due := s.Due(now.Add(2 * time.Hour))
+	require.Len(t, due, 1)
+	assert.Equal(t, op.ID, due[0].ID)
+
+	started, err := s.Start(op.ID)
+	require.NoError(t, err)
+	assert.True(t, started)
+	started, err = s.Start(op.ID)
+	require.NoError(t, err)
+	assert.False(t, started, "a running operation is not started twice")
+	require.NoError(t, s.Finish(op.ID, ""))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	got, ok := reopened.Get(op.ID)
+	require.True(t, ok)
+	assert.Equal(t, StateSucceeded, got.State)
+	assert.False(t, got.Deleted())
+	assert.EqualValues(t, 10, got.Arguments["amount_sat"])
+	assert.Equal(t, []string{ActionScheduled, ActionStarted,
+		StateSucceeded}, actions(got))
+	assert.Equal(t, "weekly tip", got.History[0].Note)
+
+	version, err := reopened.Check()
+	require.NoError(t, err)
+	assert.Equal(t, fileVersion, version)
+}
+
+// Test that cancelled and failed operations are soft-deleted with their
+// history, and that restoring one schedules it again without losing it.
+func TestSchedule_SoftDeleteRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	s, err := Open(path)
+	require.NoError(t, err)
+
+	runAt := time.Now().Add(time.Hour)
+	cancelled, err := s.Add("lnc_keysend", nil, runAt, "")
+	require.NoError(t, err)
+	failed, err := s.Add("lnc_send_coins", nil, runAt, "")
+	require.NoError(t, err)
+
+	cancelled, err = s.Cancel(cancelled.ID, "not needed")
+	require.NoError(t, err)
+	assert.Equal(t, StateCancelled, cancelled.State)
+	assert.True(t, cancelled.Deleted())
+	_, err = s.Cancel(cancelled.ID, "")
+	assert.ErrorIs(t, err, ErrNotScheduled)
+
+	_, err = s.Start(failed.ID)
+	require.NoError(t, err)
+	require.NoError(t, s.Finish(failed.ID, "insufficient balance"))
+	failed, _ = s.Get(failed.ID)
+	assert.Equal(t, StateFailed, failed.State)
+	assert.True(t, failed.Deleted())
+	assert.Equal(t, "insufficient balance",
+		failed.History[len(failed.History)-1].Note)
+
+	// Soft-deleted operations are kept but never run.
+	assert.Len(t, s.Operations(), 2)
+	assert.Empty(t, s.Due(runAt.Add(time.Hour)))
+
+	later := time.Now().Add(2 * time.Hour)
+	restored, err := s.Restore(cancelled.ID, later, "needed after all")
+	require.NoError(t, err)
+	assert.Equal(t, StateScheduled, restored.State)
+	assert.False(t, restored.Deleted())
+	assert.True(t, later.Equal(restored.RunAt))
+	assert.Equal(t, []string{ActionScheduled, StateCancelled,
+		ActionRestored}, actions(restored))
+	_, err = s.Restore(cancelled.ID, later, "")
+	assert.ErrorIs(t, err, ErrNotDeleted)
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	due := reopened.Due(later)
+	require.Len(t, due, 1)
+	assert.Equal(t, cancelled.ID, due[0].ID)
+
+	_, err = s.Restore("missing", later, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Test that an operation the server stopped while running is failed when
+// the schedule is opened, rather than run again.
+func TestSchedule_Interrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	s, err := Open(path)
+	require.NoError(t, err)
+	op, err := s.Add("lnc_keysend", nil, time.Now(), "")
+	require.NoError(t, err)
+	_, err = s.Start(op.ID)
+	require.NoError(t, err)
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	got, _ := reopened.Get(op.ID)
+	assert.Equal(t, StateFailed, got.State)
+	assert.True(t, got.Deleted())
+	assert.Contains(t, got.History[len(got.History)-1].Note,
+		"interrupted")
+	assert.Empty(t, reopened.Due(time.Now()))
+}
+
+// Test that pruning drops only finished operations past the age, and
+// that a schedule file changed behind the server's back is refused.
+func TestSchedule_PruneIntegrity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	s, err := Open(path)
+	require.NoError(t, err)
+
+	waiting, err := s.Add("lnc_keysend", nil, time.Now(), "")
+	require.NoError(t, err)
+	cancelled, err := s.Add("lnc_keysend", nil, time.Now(), "")
+	require.NoError(t, err)
+	_, err = s.Cancel(cancelled.ID, "")
+	require.NoError(t, err)
+
+	pruned, err := s.Prune(0)
+	require.NoError(t, err)
+	assert.Zero(t, pruned, "recent operations are kept")
+	pruned, err = s.Prune(time.Nanosecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+	_, ok := s.Get(waiting.ID)
+	assert.True(t, ok, "operations waiting to run are never pruned")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(
+		string(data), "lnc_keysend", "lnc_send_coins", 1)), 0o600))
+	_, err = s.Check()
+	assert.ErrorContains(t, err, "integrity check")
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "integrity check")
+}
+
+// actions returns the actions in an operation's history.
+func actions(op Operation) []string {
+	var list []string
+	for _, event := range op.History {
+		list = append(list, event.Action)
+	}
+	return list
+}
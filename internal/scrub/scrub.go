@@ -0,0 +1,88 @@
+// Package scrub keeps free text that may identify customers, such as
+// invoice memos and payment descriptions, out of tool results and logs by
+// hashing or redacting it.
+package scrub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Scrubbing modes.
+const (
+	// ModeNone leaves memos as they are.
+	ModeNone = "none"
+
+	// ModeHash replaces a memo with its SHA-256 hash, so equal memos can
+	// still be matched up without being readable.
+	ModeHash = "hash"
+
+	// ModeRedact replaces a memo with Redacted.
+	ModeRedact = "redact"
+)
+
+// Redacted replaces scrubbed text in redact mode.
+const Redacted = "[REDACTED]"
+
+// hashPrefix marks a hashed memo, so it is not mistaken for the memo itself.
+const hashPrefix = "sha256:"
+
+// Valid reports whether mode is a known scrubbing mode. The empty mode is
+// ModeNone.
+func Valid(mode string) bool {
+	switch mode {
+	case "", ModeNone, ModeHash, ModeRedact:
+		return true
+	default:
+		return false
+	}
+}
+
+// Enabled reports whether mode scrubs anything.
+func Enabled(mode string) bool {
+	return mode == ModeHash || mode == ModeRedact
+}
+
+// Text returns text scrubbed as mode requires. Empty text stays empty, so
+// results still show that there was no memo.
+func Text(mode, text string) string {
+	if text == "" {
+		return text
+	}
+
+	switch mode {
+	case ModeHash:
+		sum := sha256.Sum256([]byte(text))
+		return hashPrefix + hex.EncodeToString(sum[:])
+	case ModeRedact:
+		return Redacted
+	default:
+		return text
+	}
+}
+
+// textArguments name the tool arguments that carry memos, or BOLT11
+// invoices, whose description is one.
+var textArguments = []string{
+	"memo", "description", "invoice", "payment_request",
+}
+
+// Arguments returns args with the string values of memo and invoice
+// arguments scrubbed as mode requires. args itself is not modified.
+func Arguments(mode string, args map[string]any) map[string]any {
+	if !Enabled(mode) || len(args) == 0 {
+		return args
+	}
+
+	scrubbed := make(map[string]any, len(args))
+	for key, value := range args {
+		scrubbed[key] = value
+	}
+	for _, key := range textArguments {
+		if text, ok := scrubbed[key].(string); ok {
+			scrubbed[key] = Text(mode, text)
+		}
+	}
+
+	return scrubbed
+}
@@ -0,0 +1,60 @@
+package scrub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test scrubbing text in each mode.
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		text string
+		want string
+	}{
+		{"none", ModeNone, "Alice's coffee", "Alice's coffee"},
+		{"unset", "", "Alice's coffee", "Alice's coffee"},
+		{"redact", ModeRedact, "Alice's coffee", Redacted},
+		{"hash", ModeHash, "abc", "sha256:ba7816bf8f01cfea414140de5dae2223" +
+			"b00361a396177a9cb410ff61f20015ad"},
+		{"empty_hash", ModeHash, "", ""},
+		{"empty_redact", ModeRedact, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Text(tt.mode, tt.text))
+		})
+	}
+}
+
+// Test that only memo and invoice arguments are scrubbed, in a copy.
+func TestArguments(t *testing.T) {
+	args := map[string]any{
+		"memo":       "order 1234 for Bob",
+		"invoice":    "lnbc1...",
+		"amount_sat": float64(100),
+		"label":      "kept",
+	}
+
+	scrubbed := Arguments(ModeRedact, args)
+	assert.Equal(t, Redacted, scrubbed["memo"])
+	assert.Equal(t, Redacted, scrubbed["invoice"])
+	assert.Equal(t, float64(100), scrubbed["amount_sat"])
+	assert.Equal(t, "kept", scrubbed["label"])
+	assert.Equal(t, "order 1234 for Bob", args["memo"])
+
+	assert.Equal(t, args, Arguments(ModeNone, args))
+	assert.Nil(t, Arguments(ModeHash, nil))
+}
+
+// Test mode validation.
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(""))
+	assert.True(t, Valid(ModeHash))
+	assert.False(t, Valid("encrypt"))
+	assert.False(t, Enabled(ModeNone))
+	assert.True(t, Enabled(ModeRedact))
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+
+	"github.com/jbrill/mcp-lnc-server/internal/anomaly"
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// watchForAnomalies applies the anomaly rules to a completed tool call and
+// raises each alert it trips through the operator notification sinks: an
+// audit event and a warning from the operator logger. It returns the
+// alerts raised.
+func (m *Manager) watchForAnomalies(ctx context.Context, tool mcp.Tool,
+	args map[string]any, result *mcp.CallToolResult) []anomaly.Alert {
+	alerts := m.anomalies.Observe(anomaly.Call{
+		Tool:      tool.Name,
+		SessionID: lnccontext.GetSessionID(ctx),
+		Arguments: args,
+		Write:     m.writeTools[tool.Name],
+		Denied:    resultCode(result) == errors.ErrCodePermissionDenied.String(),
+	})
+
+	for _, alert := range alerts {
+		details := map[string]any{
+			"rule":    alert.Rule,
+			"message": alert.Message,
+		}
+		maps.Copy(details, alert.Details)
+
+		entry := audit.Entry{
+			Event:         audit.EventAnomaly,
+			Tool:          alert.Tool,
+			MCPRequestID:  lnccontext.GetMCPRequestID(ctx),
+			ProgressToken: lnccontext.GetProgressToken(ctx),
+			SessionID:     alert.SessionID,
+			TraceID:       lnccontext.GetTraceID(ctx),
+			Details:       details,
+		}
+		if err := m.audit.Log(entry); err != nil {
+			m.logger.Error("Failed to write audit entry", zap.Error(err))
+		}
+
+		m.logger.Named("operator").Warn("ANOMALOUS TOOL USAGE: "+
+			alert.Message,
+			zap.String("rule", alert.Rule),
+			zap.String("tool", alert.Tool),
+			zap.String("session_id", alert.SessionID),
+			zap.String("trace_id", entry.TraceID),
+		)
+	}
+
+	return alerts
+}
+
+// resultCode returns the error code of an error result, or "" for
+// successful and unstructured results.
+func resultCode(result *mcp.CallToolResult) string {
+	if result == nil || !result.IsError || len(result.Content) == 0 {
+		return ""
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		return ""
+	}
+
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(text.Text), &payload); err != nil {
+		return ""
+	}
+	return payload.Code
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jbrill/mcp-lnc-server/internal/elicit"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// previewArgument is the argument a two-step tool is confirmed with. A call
+// without it only previews the action.
+const previewArgument = "confirmation_id"
+
+// approveWrite asks the client's user to approve a call to a tool listed in
+// LNC_ELICIT_TOOLS, presenting the parsed action, and returns the error
+// result to reply with unless they approve. A client that cannot ask is
+// refused, so listing a tool never lets its calls through unapproved. The
+// preview call of a two-step tool changes nothing and is not asked about.
+func (m *Manager) approveWrite(ctx context.Context, tool mcp.Tool,
+	args map[string]any) *mcp.CallToolResult {
+	if !m.elicitTools[tool.Name] {
+		return nil
+	}
+	if _, twoStep := tool.InputSchema.Properties[previewArgument]; twoStep {
+		if _, confirming := args[previewArgument]; !confirming {
+			return nil
+		}
+	}
+
+	logger := logging.LogWithContext(ctx)
+	approved, err := elicit.Confirm(ctx, elicit.FromContext(ctx),
+		tools.DescribeAction(tool.Name, args))
+
+	var denied *errors.Error
+	switch {
+	case err == elicit.ErrUnsupported:
+		denied = errors.New(errors.ErrCodePermissionDenied,
+			tool.Name+" needs the user's approval, and this client "+
+				"cannot ask for it; use a client that supports "+
+				"MCP elicitation")
+	case err != nil:
+		denied = errors.Wrap(err, errors.ErrCodePermissionDenied,
+			"could not ask the user to approve "+tool.Name)
+	case !approved:
+		denied = errors.New(errors.ErrCodePermissionDenied,
+			"the user did not approve "+tool.Name)
+	default:
+		logger.Info("User approved tool call",
+			zap.String("tool", tool.Name))
+		return nil
+	}
+
+	logger.Warn("Tool call not approved", zap.String("tool", tool.Name),
+		zap.Error(err))
+	return mcp.NewToolResultError(denied.WithDetails(map[string]any{
+		"approval": "elicitation",
+	}).JSON())
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/jbrill/mcp-lnc-server/tools"
+)
+
+// FuzzUnexpectedArguments checks the strict-mode argument check against
+// arbitrary argument objects: every undeclared name, and only those, is
+// reported, and the rejection is always a well-formed error payload.
+func FuzzUnexpectedArguments(f *testing.F) {
+	tool := tools.NewPaymentService(nil).KeysendTool()
+
+	f.Add(`{}`)
+	f.Add(`{"destination": "02ab", "amount_sat": 1}`)
+	f.Add(`{"destination": "02ab", "amount": 1, "dest": "02ab"}`)
+	f.Add(`{"": null, "Destination": 1, "destination ": 2}`)
+	f.Add(`{"tlv_records": {"65537": "00"}, "\u0000": "\ud800"}`)
+
+	f.Fuzz(func(t *testing.T, rawArgs string) {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(rawArgs), &args); err != nil {
+			return
+		}
+
+		unexpected := unexpectedArguments(tool, args)
+		if !sort.StringsAreSorted(unexpected) {
+			t.Fatalf("unexpected arguments not sorted: %q", unexpected)
+		}
+
+		undeclared := 0
+		for name := range args {
+			if _, ok := tool.InputSchema.Properties[name]; !ok {
+				undeclared++
+			}
+		}
+		if len(unexpected) != undeclared {
+			t.Fatalf("reported %q for %d undeclared arguments",
+				unexpected, undeclared)
+		}
+		for _, name := range unexpected {
+			if _, ok := tool.InputSchema.Properties[name]; ok {
+				t.Fatalf("declared argument %q reported", name)
+			}
+		}
+		if undeclared == 0 {
+			return
+		}
+
+		var payload struct {
+			Code    string `json:"code"`
+			Details struct {
+				Unexpected []string `json:"unexpected_arguments"`
+			} `json:"details"`
+		}
+		encoded := strictArgumentsError(tool, unexpected).JSON()
+		if err := json.Unmarshal([]byte(encoded), &payload); err != nil {
+			t.Fatalf("strict mode error is not JSON: %v", err)
+		}
+		if payload.Code == "" ||
+			len(payload.Details.Unexpected) != undeclared {
+			t.Fatalf("strict mode error misreports the arguments: %s",
+				encoded)
+		}
+	})
+}
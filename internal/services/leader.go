@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/lease"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// SetLease makes the manager one of several instances sharing a
+// configuration. It stays on standby, refusing tool calls, until Campaign
+// takes l. It must be called before RegisterTools.
+func (m *Manager) SetLease(l *lease.Lease) {
+	m.lease = l
+	m.standby.Store(true)
+}
+
+// Campaign tries to take the lease, and renews it while it is held, every
+// interval until ctx is done. The manager serves tool calls only while it
+// holds the lease; on losing it, it closes the node connection so that only
+// the leader holds the LNC session and follows operations in flight, and on
+// taking it back it opens the session it last held again. The lease is
+// released on return.
+func (m *Manager) Campaign(ctx context.Context, interval time.Duration) {
+	if m.lease == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		held, err := m.lease.TryAcquire()
+		if err != nil {
+			// Keep leading while the lease last taken still has
+			// more than an interval left, so one failed renewal
+			// does not drop the session.
+			m.logger.Error("Failed to renew leader lease",
+				zap.Error(err))
+			held = time.Now().Add(interval).Before(
+				m.lease.HeldUntil())
+		}
+		m.setLeader(held)
+
+		select {
+		case <-ctx.Done():
+			m.setLeader(false)
+			if err := m.lease.Release(); err != nil {
+				m.logger.Error("Failed to release leader lease",
+					zap.Error(err))
+			}
+			return
+
+		case <-ticker.C:
+		}
+	}
+}
+
+// setLeader switches the manager between leading and standby.
+func (m *Manager) setLeader(leader bool) {
+	if wasStandby := m.standby.Swap(!leader); wasStandby == !leader {
+		return
+	}
+
+	if leader {
+		m.logger.Info("Acquired leader lease, serving tool calls",
+			zap.String("holder", m.lease.Holder()))
+
+		// Calls made with the clients of a session held before the
+		// lease was lost see them replaced.
+		m.connMu.Lock()
+		m.clients.Clear()
+		m.connMu.Unlock()
+		go m.reconnect()
+		return
+	}
+
+	m.logger.Named("operator").Warn("Lost leader lease, on standby",
+		zap.String("holder", m.lease.Holder()))
+
+	ctx := lnccontext.New(context.Background(), "leader_standby", 0)
+	defer ctx.Cancel()
+	m.connectionService.Disconnect(ctx, "leader lease lost")
+}
+
+// standbyError refuses a tool call on an instance that does not hold the
+// leader lease, naming the instance that does when it is known.
+func (m *Manager) standbyError() *mcp.CallToolResult {
+	return mcp.NewToolResultError(m.standbyErr().JSON())
+}
+
+// standbyErr is the error standbyError reports.
+func (m *Manager) standbyErr() *errors.Error {
+	details := map[string]any{"instance": m.lease.Holder()}
+	if current, err := m.lease.Current(); err == nil && current != nil {
+		details["leader"] = current.Holder
+	}
+
+	return errors.New(errors.ErrCodeStandby, "this instance is on "+
+		"standby; another instance holds the leader "+
+		"lease").WithDetails(details)
+}
+
+// reconnect re-establishes the LNC session this instance last held, so a
+// leader taking the lease back serves tool calls without a new lnc_connect.
+func (m *Manager) reconnect() {
+	ctx := lnccontext.New(context.Background(), "leader_reconnect", 0)
+	defer ctx.Cancel()
+
+	reconnected, err := m.connectionService.Reconnect(ctx)
+	switch {
+	case err != nil:
+		m.logger.Named("operator").Warn("Failed to re-establish the "+
+			"LNC session as leader", zap.Error(err))
+		return
+
+	case !reconnected:
+		return
+	}
+
+	// The lease may have been lost again while connecting.
+	if m.standby.Load() {
+		m.connectionService.Disconnect(ctx, "leader lease lost")
+		return
+	}
+	m.logger.Info("Re-established the LNC session as leader")
+}
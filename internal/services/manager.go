@@ -5,13 +5,35 @@ package services
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/jbrill/mcp-lnc-server/internal/anomaly"
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	"github.com/jbrill/mcp-lnc-server/internal/classify"
+	"github.com/jbrill/mcp-lnc-server/internal/config"
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
 	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/export"
 	"github.com/jbrill/mcp-lnc-server/internal/interfaces"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/lease"
 	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
+	"github.com/jbrill/mcp-lnc-server/internal/signing"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
 	"github.com/jbrill/mcp-lnc-server/tools"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -19,10 +41,59 @@ import (
 // Manager manages all Lightning Network services and their lifecycle.
 type Manager struct {
 	logger *zap.Logger
+	cfg    *config.Config
+	policy *policy.Engine
 
-	// Global connection and clients.
-	lncConnection   *grpc.ClientConn
-	lightningClient lnrpc.LightningClient
+	// Audit log of tool calls, nil when disabled.
+	audit *audit.Logger
+
+	// Journal of operations started by write tools, nil when disabled.
+	journal *journal.Journal
+
+	// Contact book naming peers in results, nil when disabled.
+	contacts *contacts.Book
+
+	// External nodes watched for changes, nil when disabled.
+	watchlist *watchlist.List
+
+	// Write tool calls scheduled to run later, nil when disabled.
+	schedule *schedule.Schedule
+
+	// MCP call identifiers captured by the before-call hook, keyed by the
+	// request context until the tool handler picks them up.
+	pendingCalls sync.Map
+
+	// Tools that change node or LSP state, and the client sessions that
+	// have already called one. Scheduled operations call the handlers.
+	writeTools    map[string]bool
+	writeHandlers map[string]writeHandler
+	writeSessions sync.Map
+
+	// Anomaly rules applied to every tool call.
+	anomalies *anomaly.Detector
+
+	// Leader lease shared with the other instances of a cluster, nil
+	// when running alone. Tool calls are refused while on standby.
+	lease   *lease.Lease
+	standby atomic.Bool
+
+	// Tools whose calls the user approves through MCP elicitation.
+	elicitTools map[string]bool
+
+	// Client sessions that negotiated compressed results.
+	compressSessions sync.Map
+
+	// Primary node connection, and the clients every read service and,
+	// outside sandbox mode, every write service uses. connMu guards the
+	// connections; the providers guard their own clients.
+	connMu        sync.Mutex
+	lncConnection *grpc.ClientConn
+	clients       *tools.ClientProvider
+
+	// Sandbox regtest connection and clients, used by write tools in
+	// sandbox mode.
+	sandboxConnection *grpc.ClientConn
+	sandboxClients    *tools.ClientProvider
 
 	// Services - read-only operations only.
 	connectionService *tools.ConnectionService
@@ -32,60 +103,543 @@ type Manager struct {
 	onchainService    *tools.OnChainService
 	peerService       *tools.PeerService
 	nodeService       *tools.NodeService
+	macaroonService   *tools.MacaroonService
+	lspService        *tools.LSPService
+	sandboxService    *tools.SandboxService
+	statsService      *tools.ServerStatsService
+
+	// Signs tool results, nil when response signing is disabled.
+	signingService *tools.SigningService
+
+	// Reports and resumes journaled operations on the write clients.
+	operationService *tools.OperationService
+
+	// Lists and edits the contact book.
+	contactService *tools.ContactService
+
+	// Watches external nodes through the primary node's graph.
+	watchService *tools.WatchService
+
+	// Backs up and restores the server's own state, when a backup
+	// directory is configured.
+	backupService *tools.BackupService
+
+	// Exports the primary node's records, when an export directory is
+	// configured.
+	exportService *tools.ExportService
+
+	// Schedules write tool calls, when a schedule is configured.
+	scheduleService *tools.ScheduleService
+
+	// Write services - registered only in write mode. Their client is the
+	// sandbox node's in sandbox mode and the primary node's otherwise.
+	writeChannelService  *tools.ChannelService
+	writePaymentService  *tools.PaymentService
+	writeOnChainService  *tools.OnChainService
+	writePeerService     *tools.PeerService
+	writeInvoiceService  *tools.InvoiceService
+	writeMacaroonService *tools.MacaroonService
 }
 
-// NewManager creates a new service manager for read-only operations.
-func NewManager(logger *zap.Logger) *Manager {
+// NewManager creates a new service manager. Only read-only tools are
+// registered unless cfg enables write mode.
+func NewManager(logger *zap.Logger, cfg *config.Config) *Manager {
+	if cfg == nil {
+		cfg = config.LoadConfig()
+	}
+
+	elicitTools := make(map[string]bool, len(cfg.ElicitTools))
+	for _, name := range cfg.ElicitTools {
+		elicitTools[name] = true
+	}
+
 	return &Manager{
-		logger: logger,
+		logger:      logger,
+		cfg:         cfg,
+		elicitTools: elicitTools,
+		policy: policy.NewEngine(policy.Config{
+			WithdrawalAllowlist: cfg.WithdrawalAllowlist,
+			MaxPaymentSat:       cfg.MaxPaymentSat,
+			MaxHourlySat:        cfg.MaxSpendPerHourSat,
+			MaxDailySat:         cfg.MaxSpendPerDaySat,
+			MaxFeeRate:          uint64(max(cfg.MaxFeeRate, 0)),
+		}),
+		clients:        tools.NewClientProvider(nil),
+		sandboxClients: tools.NewClientProvider(nil),
+	}
+}
+
+// mcpCall identifies the JSON-RPC call that invoked a tool.
+type mcpCall struct {
+	requestID     any
+	progressToken any
+	sessionID     string
+}
+
+// SetAuditLogger enables audit logging of tool calls.
+func (m *Manager) SetAuditLogger(logger *audit.Logger) {
+	m.audit = logger
+}
+
+// SetJournal enables journaling of the operations write tools start. It
+// must be called after InitializeServices.
+func (m *Manager) SetJournal(operations *journal.Journal) {
+	m.journal = operations
+	m.operationService.Journal = operations
+	m.backupService.Journal = operations
+	m.writePaymentService.Journal = operations
+	m.writeChannelService.Journal = operations
+}
+
+// SetContacts enables the contact book, naming peers in peer and channel
+// results. It must be called after InitializeServices.
+func (m *Manager) SetContacts(book *contacts.Book) {
+	m.contacts = book
+	m.contactService.Book = book
+	m.peerService.Contacts = book
+	m.channelService.Contacts = book
+	m.watchService.Contacts = book
+	m.backupService.Contacts = book
+}
+
+// SetExportCheckpoints keeps where incremental exports ended in
+// checkpoints, which lnc_store_maintenance also maintains.
+func (m *Manager) SetExportCheckpoints(checkpoints *export.Checkpoints) {
+	m.exportService.Checkpoints = checkpoints
+	m.backupService.Checkpoints = checkpoints
+}
+
+// SetSpendLedger records spends in ledger, so the hourly and daily spend
+// caps hold across restarts. It must be called after InitializeServices
+// and before RegisterTools.
+func (m *Manager) SetSpendLedger(ledger *policy.Ledger) {
+	m.policy.SetLedger(ledger)
+	m.backupService.Ledger = ledger
+}
+
+// Hooks returns the MCP server hooks that capture each tool call's request
+// ID and progress token. They must be installed on the server the tools are
+// registered with for audit entries and logs to carry those identifiers.
+func (m *Manager) Hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any,
+		request *mcp.CallToolRequest) {
+		call := mcpCall{requestID: id}
+		if meta := request.Params.Meta; meta != nil {
+			call.progressToken = meta.ProgressToken
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			call.sessionID = session.SessionID()
+		}
+		m.pendingCalls.Store(ctx, call)
+	})
+	hooks.AddOnError(func(ctx context.Context, id any,
+		method mcp.MCPMethod, message any, err error) {
+		// Unknown tools fail before reaching a handler.
+		m.pendingCalls.Delete(ctx)
+	})
+
+	// Compression is offered to every client, and used for those that
+	// accept it in their initialize request.
+	if m.cfg.CompressThreshold > 0 {
+		hooks.AddAfterInitialize(m.negotiateCompression)
+		hooks.AddOnUnregisterSession(func(ctx context.Context,
+			session server.ClientSession) {
+			m.compressSessions.Delete(session.SessionID())
+		})
+	}
+	return hooks
+}
+
+// negotiateCompression advertises compressed results in the server's
+// capabilities, and records the session when the client accepts them.
+func (m *Manager) negotiateCompression(ctx context.Context, id any,
+	request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	if result.Capabilities.Experimental == nil {
+		result.Capabilities.Experimental = make(map[string]any)
+	}
+	result.Capabilities.Experimental[tools.CompressionCapability] =
+		map[string]any{
+			"encodings":       []string{tools.CompressionEncoding},
+			"threshold_bytes": m.cfg.CompressThreshold,
+		}
+
+	session := server.ClientSessionFromContext(ctx)
+	experimental := request.Params.Capabilities.Experimental
+	if session == nil || !tools.AcceptsCompression(experimental) {
+		return
+	}
+	m.compressSessions.Store(session.SessionID(), true)
+}
+
+// servingClients returns the clients a tool's handler uses, and whether
+// they are the sandbox node's because the tool is a write tool redirected
+// to it in sandbox mode.
+func (m *Manager) servingClients(name string) (*tools.ClientProvider,
+	bool) {
+	if m.cfg.SandboxMode && m.writeTools[name] {
+		return m.sandboxClients, true
+	}
+	return m.clients, false
+}
+
+// wrapHandler correlates a tool call with the MCP request that triggered it
+// and records it in the audit log. In strict mode, calls with arguments the
+// tool does not declare are rejected before reaching the handler, as are
+// calls the user must approve and does not.
+func (m *Manager) wrapHandler(tool mcp.Tool,
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error),
+) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var call mcpCall
+		if value, ok := m.pendingCalls.LoadAndDelete(ctx); ok {
+			call = value.(mcpCall)
+		}
+
+		callCtx := lnccontext.New(lnccontext.WithMCPCall(ctx,
+			call.requestID, call.progressToken, call.sessionID),
+			tool.Name, 0)
+		defer callCtx.Cancel()
+
+		start := time.Now()
+		var (
+			result *mcp.CallToolResult
+			err    error
+		)
+		unexpected := unexpectedArguments(tool, request.Params.Arguments)
+		if m.standby.Load() {
+			result = m.standbyError()
+		} else if m.cfg.StrictArguments && len(unexpected) > 0 {
+			result = mcp.NewToolResultError(
+				strictArgumentsError(tool, unexpected).JSON())
+		} else if denied := m.approveWrite(callCtx, tool,
+			request.Params.Arguments); denied != nil {
+			result = denied
+		} else {
+			m.notifyFirstWrite(callCtx, tool, request.Params.Arguments)
+			result, err = handler(callCtx, request)
+		}
+
+		// The attestation is added before signing, so signed results
+		// vouch for it too.
+		if err == nil {
+			clients, sandboxed := m.servingClients(tool.Name)
+			attestation := tools.CurrentAttestation(m.cfg.WriteMode,
+				sandboxed, clients)
+			if attestErr := tools.Attest(result,
+				attestation); attestErr != nil {
+				logging.LogWithContext(callCtx).Error(
+					"Failed to attest tool result",
+					zap.Error(attestErr))
+			}
+		}
+
+		if m.signingService != nil && err == nil {
+			if signErr := m.signingService.SignResult(callCtx,
+				tool.Name, result); signErr != nil {
+				logging.LogWithContext(callCtx).Error(
+					"Failed to sign tool result",
+					zap.Error(signErr))
+			}
+		}
+
+		// Compression comes last, so the signature covers the text
+		// the client gets back once it decompresses.
+		if _, ok := m.compressSessions.Load(call.sessionID); ok &&
+			err == nil {
+			if compressErr := tools.Compress(result,
+				m.cfg.CompressThreshold); compressErr != nil {
+				logging.LogWithContext(callCtx).Error(
+					"Failed to compress tool result",
+					zap.Error(compressErr))
+			}
+		}
+
+		entry := audit.Entry{
+			Event:         audit.EventToolCall,
+			Tool:          tool.Name,
+			MCPRequestID:  call.requestID,
+			ProgressToken: call.progressToken,
+			SessionID:     call.sessionID,
+			TraceID:       callCtx.TraceID(),
+			Arguments: scrub.Arguments(m.cfg.MemoScrub,
+				audit.RedactArguments(request.Params.Arguments)),
+			IsError:    err != nil || (result != nil && result.IsError),
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		if logErr := m.audit.Log(entry); logErr != nil {
+			logging.LogWithContext(callCtx).Error(
+				"Failed to write audit entry", zap.Error(logErr))
+		}
+		m.watchForAnomalies(callCtx, tool, request.Params.Arguments,
+			result)
+
+		return result, err
 	}
 }
 
-// InitializeServices prepares all services with nil clients. Clients are
-// provided once an LNC connection is established via the callback.
+// wrapResource applies to resource reads what wrapHandler applies to tool
+// calls that could serve the same entries: reads are refused on standby and
+// recorded in the audit log under the tool's name.
+func (m *Manager) wrapResource(tool string,
+	handler server.ResourceTemplateHandlerFunc,
+) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context,
+		request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		readCtx := lnccontext.New(ctx, tool, 0)
+		defer readCtx.Cancel()
+
+		start := time.Now()
+		var (
+			contents []mcp.ResourceContents
+			err      error
+		)
+		if m.standby.Load() {
+			err = m.standbyErr()
+		} else {
+			contents, err = handler(readCtx, request)
+		}
+
+		entry := audit.Entry{
+			Event:      audit.EventResourceRead,
+			Tool:       tool,
+			TraceID:    readCtx.TraceID(),
+			Arguments:  map[string]any{"uri": request.Params.URI},
+			IsError:    err != nil,
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		if logErr := m.audit.Log(entry); logErr != nil {
+			logging.LogWithContext(readCtx).Error(
+				"Failed to write audit entry", zap.Error(logErr))
+		}
+
+		return contents, err
+	}
+}
+
+// InitializeServices prepares all services with empty client providers.
+// Clients are provided once an LNC connection is established via the
+// callback.
 func (m *Manager) InitializeServices() {
 	m.logger.Info("Initializing read-only services...")
 
-	// Initialize connection service with callback.
+	// Initialize connection service with callbacks.
 	m.connectionService = tools.NewConnectionService(
 		m.onLNCConnectionEstablished)
+	m.connectionService.DisconnectCallback = m.onLNCDisconnected
+	m.connectionService.AllowWalletUnlock = m.cfg.AllowWalletUnlock
+	m.connectionService.State = m.clients.State()
 
 	// Initialize all read-only services with nil clients.
 	m.invoiceService = tools.NewInvoiceService(nil)
 	m.channelService = tools.NewChannelService(nil)
+	m.channelService.DualFundPolicy = tools.DualFundPolicy{
+		Mode:     m.cfg.DualFundPolicy,
+		FixedSat: m.cfg.DualFundFixedSat,
+		MaxSat:   m.cfg.DualFundMaxSat,
+	}
 	m.paymentService = tools.NewPaymentService(nil)
 	m.onchainService = tools.NewOnChainService(nil)
+	if m.cfg.MempoolAPIURL != "" {
+		m.onchainService.Mempool = tools.NewMempoolSource(
+			m.cfg.MempoolAPIURL)
+	}
+	m.channelService.Mempool = m.onchainService.Mempool
 	m.peerService = tools.NewPeerService(nil)
+	if m.cfg.GraphSalt != "" {
+		m.peerService.GraphSalt = []byte(m.cfg.GraphSalt)
+	}
 	m.nodeService = tools.NewNodeService(nil)
+	m.nodeService.TipSource = m.cfg.TipSourceURL
+	m.nodeService.DebugUnredacted = m.cfg.DebugInfoUnredacted
+	m.macaroonService = tools.NewMacaroonService(nil)
+	m.lspService = tools.NewLSPService(nil, m.cfg.LSPEndpoints)
+	m.sandboxService = tools.NewSandboxService(m.cfg.SandboxMailbox,
+		m.onSandboxConnectionEstablished)
+	m.sandboxService.Clients = m.sandboxClients
+	m.sandboxService.Connection.State = m.sandboxClients.State()
+
+	m.invoiceService.Clients = m.clients
+	m.invoiceService.MemoScrub = m.cfg.MemoScrub
+	m.paymentService.MemoScrub = m.cfg.MemoScrub
+	m.paymentService.PreimageDisclosure = m.cfg.PreimageDisclosure
+	m.channelService.Clients = m.clients
+	m.paymentService.Clients = m.clients
+	m.onchainService.Clients = m.clients
+	m.peerService.Clients = m.clients
+	m.nodeService.Clients = m.clients
+	m.macaroonService.Clients = m.clients
+	m.lspService.Clients = m.clients
 
-	m.logger.Info("Read-only services initialized successfully")
+	m.statsService = tools.NewServerStatsService(m.clients)
+	if m.cfg.SandboxMode {
+		m.statsService.SandboxClients = m.sandboxClients
+	}
+
+	if m.cfg.ResponseSigning != "" &&
+		m.cfg.ResponseSigning != signing.ModeNone {
+		m.signingService = tools.NewSigningService(nil,
+			m.cfg.ResponseSigning, []byte(m.cfg.ResponseSigningKey))
+		m.signingService.Clients = m.clients
+	}
+
+	// Write services target the sandbox node in sandbox mode and the
+	// primary node otherwise.
+	writeClients := m.clients
+	if m.cfg.SandboxMode {
+		writeClients = m.sandboxClients
+	}
+	m.writeChannelService = tools.NewChannelService(nil)
+	m.writeChannelService.Clients = writeClients
+	m.writeChannelService.Policy = m.policy
+	m.writePaymentService = tools.NewPaymentService(nil)
+	m.writePaymentService.Clients = writeClients
+	m.writePaymentService.PreimageDisclosure = m.cfg.PreimageDisclosure
+	m.writePaymentService.Policy = m.policy
+	m.writeOnChainService = tools.NewOnChainService(nil)
+	m.writeOnChainService.Clients = writeClients
+	m.writeOnChainService.Policy = m.policy
+	m.writeOnChainService.Mempool = m.onchainService.Mempool
+	m.writePeerService = tools.NewPeerService(nil)
+	m.writePeerService.Clients = writeClients
+	m.writeInvoiceService = tools.NewInvoiceService(nil)
+	m.writeInvoiceService.Clients = writeClients
+	m.writeMacaroonService = tools.NewMacaroonService(nil)
+	m.writeMacaroonService.Clients = writeClients
+	m.operationService = tools.NewOperationService(nil, nil)
+	m.contactService = tools.NewContactService(nil)
+	m.operationService.Clients = writeClients
+	m.watchService = tools.NewWatchService(nil, nil)
+	m.watchService.Clients = m.clients
+	m.backupService = tools.NewBackupService(m.cfg.BackupDir,
+		m.cfg.ServerVersion)
+	m.exportService = tools.NewExportService(nil, m.cfg.ExportDir)
+	m.exportService.Clients = m.clients
+	m.exportService.MemoScrub = m.cfg.MemoScrub
+	m.scheduleService = tools.NewScheduleService(nil)
+
+	m.logger.Info("Read-only services initialized successfully",
+		zap.Int("withdrawal_allowlist",
+			m.policy.WithdrawalAllowlistSize()))
 }
 
-// RegisterTools registers all read-only tools with the MCP server.
+// RegisterTools registers the read-only tools with the MCP server, plus the
+// write tools when write mode is enabled.
 func (m *Manager) RegisterTools(mcpServer interfaces.MCPServer) error {
 	if mcpServer == nil {
 		return errors.New(errors.ErrCodeUnknown,
 			"MCP server cannot be nil")
 	}
 
-	m.logger.Info("Registering read-only MCP tools with server")
+	if err := m.validateSigning(); err != nil {
+		return err
+	}
+	if !scrub.Valid(m.cfg.MemoScrub) {
+		return errors.New(errors.ErrCodeUnknown, "unknown memo "+
+			"scrubbing mode: "+m.cfg.MemoScrub)
+	}
+	if m.cfg.MaxPaymentSat < 0 || m.cfg.MaxSpendPerHourSat < 0 ||
+		m.cfg.MaxSpendPerDaySat < 0 || m.cfg.MaxFeeRate < 0 {
+		return errors.New(errors.ErrCodeUnknown,
+			"spend and fee rate limits must not be negative")
+	}
+	if !tools.ValidPreimageDisclosure(m.cfg.PreimageDisclosure) {
+		return errors.New(errors.ErrCodeUnknown, "unknown preimage "+
+			"disclosure policy: "+m.cfg.PreimageDisclosure)
+	}
+	if !tools.ValidDualFundPolicy(m.cfg.DualFundPolicy) {
+		return errors.New(errors.ErrCodeUnknown, "unknown "+
+			"dual-funding contribution policy: "+
+			m.cfg.DualFundPolicy)
+	}
+	if m.cfg.DualFundFixedSat < 0 || m.cfg.DualFundMaxSat < 0 {
+		return errors.New(errors.ErrCodeUnknown,
+			"dual-funding amounts must not be negative")
+	}
+
+	// Results are filtered as they are encoded, so the classes apply to
+	// every tool registered below.
+	classes, err := classify.Parse(m.cfg.ResponseClasses)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeUnknown,
+			"invalid LNC_RESPONSE_CLASSES")
+	}
+	tools.SetResponseClasses(classes)
+
+	filter, err := newToolFilter(m.cfg.ToolAllowlist, m.cfg.ToolDenylist)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeUnknown,
+			"invalid tool filter")
+	}
+
+	m.anomalies, err = anomaly.NewDetector(anomaly.Config{
+		GraphDumps:        m.cfg.AnomalyGraphDumps,
+		GraphDumpWindow:   m.cfg.AnomalyGraphDumpWindow,
+		DeniedWrites:      m.cfg.AnomalyDeniedWrites,
+		DeniedWriteWindow: m.cfg.AnomalyDeniedWriteWindow,
+		ActiveHours:       m.cfg.AnomalyActiveHours,
+		Honeytokens:       m.cfg.Honeytokens,
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeUnknown,
+			"invalid anomaly rules")
+	}
 
-	registrations := 0
+	m.logger.Info("Registering MCP tools with server",
+		zap.Bool("write_mode", m.cfg.WriteMode))
+
+	// The schema service reports output schemas for exactly the tools
+	// registered below, including itself.
+	schemaService := tools.NewSchemaService(nil)
+
+	registrations, filtered := 0, 0
 	register := func(tool mcp.Tool,
 		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
-		mcpServer.AddTool(tool, handler)
+		if !filter.allows(tool.Name) {
+			m.logger.Debug("Tool excluded by tool filter",
+				zap.String("tool", tool.Name))
+			filtered++
+			return
+		}
+		if _, ok := tools.OutputSchema(tool.Name); !ok {
+			m.logger.Warn("Tool has no declared output schema",
+				zap.String("tool", tool.Name))
+		}
+		mcpServer.AddTool(tool, m.wrapHandler(tool, handler))
+		schemaService.Tools = append(schemaService.Tools, tool.Name)
 		registrations++
 	}
 
+	// registerWrite registers a tool that changes state, so its first use
+	// in each session trips the operator notification, and keeps its
+	// handler for scheduled operations to call.
+	m.writeTools = make(map[string]bool)
+	m.writeHandlers = make(map[string]writeHandler)
+	registerWrite := func(tool mcp.Tool,
+		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+		if filter.allows(tool.Name) {
+			m.writeTools[tool.Name] = true
+			m.writeHandlers[tool.Name] = writeHandler{tool, handler}
+		}
+		register(tool, handler)
+	}
+
 	// Connection tools - always required.
 	register(m.connectionService.ConnectTool(),
 		m.connectionService.HandleConnect)
 	register(m.connectionService.DisconnectTool(),
 		m.connectionService.HandleDisconnect)
+	if m.cfg.AllowWalletUnlock {
+		registerWrite(m.connectionService.UnlockWalletTool(),
+			m.connectionService.HandleUnlockWallet)
+	}
 
 	// Invoice tools - read-only operations.
 	register(m.invoiceService.DecodeInvoiceTool(),
 		m.invoiceService.HandleDecodeInvoice)
+	register(m.invoiceService.DecodeInvoiceOfflineTool(),
+		m.invoiceService.HandleDecodeInvoiceOffline)
 	register(m.invoiceService.ListInvoicesTool(),
 		m.invoiceService.HandleListInvoices)
 	register(m.invoiceService.LookupInvoiceTool(),
@@ -94,14 +648,44 @@ func (m *Manager) RegisterTools(mcpServer interfaces.MCPServer) error {
 	// Channel tools - read-only operations.
 	register(m.channelService.ListChannelsTool(),
 		m.channelService.HandleListChannels)
+	register(m.channelService.ChannelBalanceByPeerTool(),
+		m.channelService.HandleChannelBalanceByPeer)
+	register(m.channelService.ListAliasesTool(),
+		m.channelService.HandleListAliases)
+	register(m.channelService.LookupHtlcResolutionTool(),
+		m.channelService.HandleLookupHtlcResolution)
+	register(m.channelService.PartnerSLATool(),
+		m.channelService.HandlePartnerSLA)
+	register(m.channelService.ListHTLCsTool(),
+		m.channelService.HandleListHTLCs)
 	register(m.channelService.PendingChannelsTool(),
 		m.channelService.HandlePendingChannels)
+	register(m.channelService.CloseProgressTool(),
+		m.channelService.HandleCloseProgress)
+	register(m.channelService.ForwardingHistoryTool(),
+		m.channelService.HandleForwardingHistory)
+	register(m.channelService.CheckChannelPoliciesTool(),
+		m.channelService.HandleCheckChannelPolicies)
+	register(m.channelService.EstimateForceCloseTool(),
+		m.channelService.HandleEstimateForceClose)
 
 	// Payment tools - read-only operations.
 	register(m.paymentService.ListPaymentsTool(),
 		m.paymentService.HandleListPayments)
 	register(m.paymentService.TrackPaymentTool(),
 		m.paymentService.HandleTrackPayment)
+	register(m.paymentService.QueryRoutesTool(),
+		m.paymentService.HandleQueryRoutes)
+	register(m.paymentService.EstimateRouteFeeTool(),
+		m.paymentService.HandleEstimateRouteFee)
+	register(m.paymentService.BuildRouteTool(),
+		m.paymentService.HandleBuildRoute)
+	register(m.paymentService.QueryMissionControlTool(),
+		m.paymentService.HandleQueryMissionControl)
+	register(m.paymentService.QueryProbabilityTool(),
+		m.paymentService.HandleQueryProbability)
+	register(m.paymentService.GetMissionControlConfigTool(),
+		m.paymentService.HandleGetMissionControlConfig)
 
 	// On-chain tools - read-only operations.
 	register(m.onchainService.ListUnspentTool(),
@@ -110,50 +694,424 @@ func (m *Manager) RegisterTools(mcpServer interfaces.MCPServer) error {
 		m.onchainService.HandleGetTransactions)
 	register(m.onchainService.EstimateFeesTool(),
 		m.onchainService.HandleEstimateFee)
+	register(m.onchainService.ValidateAddressTool(),
+		m.onchainService.HandleValidateAddress)
+	register(m.onchainService.ListBumpableTool(),
+		m.onchainService.HandleListBumpable)
+	register(m.onchainService.ListAccountsTool(),
+		m.onchainService.HandleListAccounts)
+	register(m.onchainService.ListAddressesTool(),
+		m.onchainService.HandleListAddresses)
+	register(m.onchainService.ListSweepsTool(),
+		m.onchainService.HandleListSweeps)
+	register(m.onchainService.PendingSweepsTool(),
+		m.onchainService.HandlePendingSweeps)
+	register(m.onchainService.RequiredReserveTool(),
+		m.onchainService.HandleRequiredReserve)
+	register(m.onchainService.ListLeasesTool(),
+		m.onchainService.HandleListLeases)
 
 	// Peer tools - read-only operations.
 	register(m.peerService.ListPeersTool(),
 		m.peerService.HandleListPeers)
 	register(m.peerService.DescribeGraphTool(),
 		m.peerService.HandleDescribeGraph)
+	register(m.peerService.AnonymizedGraphTool(),
+		m.peerService.HandleAnonymizedGraph)
 	register(m.peerService.GetNodeInfoTool(),
 		m.peerService.HandleGetNodeInfo)
+	register(m.peerService.GetChanInfoTool(),
+		m.peerService.HandleGetChanInfo)
 
 	// Node tools - read-only operations.
 	register(m.nodeService.GetBalanceTool(),
 		m.nodeService.HandleGetBalance)
 	register(m.nodeService.GetInfoTool(),
 		m.nodeService.HandleGetInfo)
+	register(m.nodeService.GetSyncStatusTool(),
+		m.nodeService.HandleGetSyncStatus)
+	register(m.nodeService.GetRecoveryInfoTool(),
+		m.nodeService.HandleGetRecoveryInfo)
+	register(m.nodeService.SecurityReportTool(),
+		m.nodeService.HandleSecurityReport)
+	register(m.nodeService.ListTowersTool(),
+		m.nodeService.HandleListTowers)
+	register(m.nodeService.TowerStatsTool(),
+		m.nodeService.HandleTowerStats)
+	register(m.nodeService.TowerPolicyTool(),
+		m.nodeService.HandleTowerPolicy)
+	register(m.nodeService.ExportChannelBackupsTool(),
+		m.nodeService.HandleExportChannelBackups)
+	register(m.nodeService.VerifyChannelBackupTool(),
+		m.nodeService.HandleVerifyChannelBackup)
+	register(m.nodeService.ConfigCheckTool(),
+		m.nodeService.HandleConfigCheck)
+	register(m.nodeService.DebugInfoTool(),
+		m.nodeService.HandleDebugInfo)
+	register(m.statsService.ServerStatsTool(),
+		m.statsService.HandleServerStats)
+
+	// Macaroon tools - read-only operations.
+	register(m.macaroonService.ListPermissionsTool(),
+		m.macaroonService.HandleListPermissions)
+	register(m.macaroonService.ListMacaroonIDsTool(),
+		m.macaroonService.HandleListMacaroonIDs)
+
+	// LSP tools - only when at least one LSP is configured. Ordering is
+	// registered with the write tools.
+	if len(m.cfg.LSPEndpoints) > 0 {
+		register(m.lspService.GetInfoTool(),
+			m.lspService.HandleGetInfo)
+		register(m.lspService.GetOrderTool(),
+			m.lspService.HandleGetOrder)
+	}
+
+	// Signature verification - only when results are signed.
+	if m.signingService != nil {
+		register(m.signingService.VerifyResponseTool(),
+			m.signingService.HandleVerifyResponse)
+	}
+
+	// Operation journal - only when one is configured.
+	if m.journal != nil {
+		register(m.operationService.ListOperationsTool(),
+			m.operationService.HandleListOperations)
+	}
+
+	// Contact book - only when one is configured. Its edits change the
+	// server's own file, not the node, so they are not write tools.
+	if m.contacts != nil {
+		register(m.contactService.ListContactsTool(),
+			m.contactService.HandleListContacts)
+		register(m.contactService.SetContactTool(),
+			m.contactService.HandleSetContact)
+		register(m.contactService.DeleteContactTool(),
+			m.contactService.HandleDeleteContact)
+	}
+
+	// Watchlist - only when one is configured. Like the contact book,
+	// it is the server's own file, so its edits are not write tools.
+	if m.watchlist != nil {
+		register(m.watchService.WatchNodeTool(),
+			m.watchService.HandleWatchNode)
+		register(m.watchService.UnwatchNodeTool(),
+			m.watchService.HandleUnwatchNode)
+		register(m.watchService.WatchedNodesTool(),
+			m.watchService.HandleWatchedNodes)
+	}
+
+	// Server state backups - only when a backup directory is
+	// configured. Like the contact book, they touch only the server's
+	// own files.
+	if m.cfg.BackupDir != "" {
+		register(m.backupService.ServerBackupTool(),
+			m.backupService.HandleServerBackup)
+		register(m.backupService.ServerRestoreTool(),
+			m.backupService.HandleServerRestore)
+	}
+
+	// Local store maintenance - when the journal, spend ledger, export
+	// checkpoints or schedule are kept. Exports go to the backup
+	// directory.
+	if m.backupService.Journal != nil || m.backupService.Ledger != nil ||
+		m.backupService.Checkpoints != nil ||
+		m.backupService.Schedule != nil {
+		register(m.backupService.StoreMaintenanceTool(),
+			m.backupService.HandleStoreMaintenance)
+	}
+
+	// Record exports - only when an export directory is configured.
+	if m.cfg.ExportDir != "" {
+		register(m.exportService.ExportRecordsTool(),
+			m.exportService.HandleExportRecords)
+	}
+
+	// Sandbox tools - only in sandbox mode.
+	if m.cfg.SandboxMode {
+		register(m.sandboxService.ConnectTool(),
+			m.sandboxService.HandleConnect)
+		register(m.sandboxService.StatusTool(),
+			m.sandboxService.HandleStatus)
+	}
+
+	// Resources - pages of the longest lists, for clients that fetch
+	// resources instead of calling tools. Each is served only when the
+	// tool listing the same entries is.
+	if resources, ok := mcpServer.(interfaces.ResourceServer); ok {
+		registerResource := func(tool string,
+			template mcp.ResourceTemplate,
+			handler server.ResourceTemplateHandlerFunc) {
+			if !filter.allows(tool) {
+				return
+			}
+			resources.AddResourceTemplate(template,
+				m.wrapResource(tool, handler))
+		}
+
+		registerResource("lnc_list_payments",
+			m.paymentService.PaymentsResourceTemplate(),
+			m.paymentService.HandlePaymentsResource)
+		registerResource("lnc_list_channels",
+			m.channelService.ChannelsResourceTemplate(),
+			m.channelService.HandleChannelsResource)
+	}
+
+	// Write tools - only when the operator opts in to write mode.
+	if m.cfg.WriteMode {
+		registerWrite(m.writeChannelService.SpliceChannelTool(),
+			m.writeChannelService.HandleSpliceChannel)
+		registerWrite(m.writeChannelService.OpenChannelTool(),
+			m.writeChannelService.HandleOpenChannel)
+		registerWrite(m.writeChannelService.BatchOpenChannelsTool(),
+			m.writeChannelService.HandleBatchOpenChannels)
+		registerWrite(m.writeChannelService.UpdateChannelPolicyTool(),
+			m.writeChannelService.HandleUpdateChannelPolicy)
+		registerWrite(m.writePaymentService.PayInvoiceTool(),
+			m.writePaymentService.HandlePayInvoice)
+		registerWrite(m.writePaymentService.KeysendTool(),
+			m.writePaymentService.HandleKeysend)
+		registerWrite(m.writePaymentService.SendToRouteTool(),
+			m.writePaymentService.HandleSendToRoute)
+		registerWrite(
+			m.writePaymentService.SetMissionControlConfigTool(),
+			m.writePaymentService.HandleSetMissionControlConfig)
+		registerWrite(m.writeOnChainService.SendCoinsTool(),
+			m.writeOnChainService.HandleSendCoins)
+		registerWrite(m.writeOnChainService.NewAddressTool(),
+			m.writeOnChainService.HandleNewAddress)
+		registerWrite(m.writeOnChainService.BumpFeeTool(),
+			m.writeOnChainService.HandleBumpFee)
+		registerWrite(m.writeOnChainService.LabelTransactionTool(),
+			m.writeOnChainService.HandleLabelTransaction)
+		registerWrite(m.writeOnChainService.FundPsbtTool(),
+			m.writeOnChainService.HandleFundPsbt)
+		registerWrite(m.writeOnChainService.FinalizePsbtTool(),
+			m.writeOnChainService.HandleFinalizePsbt)
+		registerWrite(m.writeOnChainService.ReleaseOutputTool(),
+			m.writeOnChainService.HandleReleaseOutput)
+		registerWrite(m.writePeerService.ConnectPeerTool(),
+			m.writePeerService.HandleConnectPeer)
+		registerWrite(m.writePeerService.DisconnectPeerTool(),
+			m.writePeerService.HandleDisconnectPeer)
+		registerWrite(m.writeInvoiceService.AddInvoiceTool(),
+			m.writeInvoiceService.HandleAddInvoice)
+		registerWrite(m.writeInvoiceService.AddHoldInvoiceTool(),
+			m.writeInvoiceService.HandleAddHoldInvoice)
+		registerWrite(m.writeInvoiceService.CancelInvoiceTool(),
+			m.writeInvoiceService.HandleCancelInvoice)
+		registerWrite(m.writeInvoiceService.SettleInvoiceTool(),
+			m.writeInvoiceService.HandleSettleInvoice)
+		registerWrite(m.writeMacaroonService.BakeMacaroonTool(),
+			m.writeMacaroonService.HandleBakeMacaroon)
+		registerWrite(m.writeMacaroonService.DeleteMacaroonIDTool(),
+			m.writeMacaroonService.HandleDeleteMacaroonID)
+
+		// Ordering liquidity creates state at the LSP, so it also
+		// needs an explicit opt-in.
+		if len(m.cfg.LSPEndpoints) > 0 && m.cfg.LSPAllowOrders {
+			registerWrite(m.lspService.CreateOrderTool(),
+				m.lspService.HandleCreateOrder)
+		}
+
+		// Abandoning channels loses their funds, so it is only offered
+		// to developers; the handler also refuses non-regtest nodes.
+		if m.cfg.DefaultDevMode {
+			registerWrite(m.writeChannelService.AbandonChannelTool(),
+				m.writeChannelService.HandleAbandonChannel)
+		}
+
+		// Scheduled operations - only when a schedule is configured.
+		// Scheduling and restoring are writes, as the calls act
+		// later, but they are not kept for scheduled operations to
+		// call, so one cannot schedule another.
+		if m.schedule != nil {
+			registerScheduling := func(tool mcp.Tool,
+				handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)) {
+				if filter.allows(tool.Name) {
+					m.writeTools[tool.Name] = true
+				}
+				register(tool, handler)
+			}
+			scheduler := m.scheduleService
+			registerScheduling(scheduler.ScheduleOperationTool(),
+				scheduler.HandleScheduleOperation)
+			registerScheduling(
+				scheduler.RestoreScheduledOperationTool(),
+				scheduler.HandleRestoreScheduledOperation)
+			register(scheduler.ListScheduledOperationsTool(),
+				scheduler.HandleListScheduledOperations)
+			register(scheduler.CancelScheduledOperationTool(),
+				scheduler.HandleCancelScheduledOperation)
+		}
+	}
+
+	// Output schemas - always available.
+	register(schemaService.OutputSchemaTool(),
+		schemaService.HandleOutputSchema)
+
+	for _, pattern := range filter.unmatched() {
+		m.logger.Warn("Tool filter pattern matches no tool",
+			zap.String("pattern", pattern))
+	}
+	if !filter.allows("lnc_connect") {
+		m.logger.Warn("Tool filter excludes lnc_connect; no other tool " +
+			"can reach the node")
+	}
 
-	m.logger.Info("Read-only MCP tools registered",
-		zap.Int("total_tools", registrations))
+	// Approval applies to write tools only; a misspelt or read-only name
+	// would otherwise protect nothing without notice.
+	for name := range m.elicitTools {
+		if !m.writeTools[name] {
+			m.logger.Warn("LNC_ELICIT_TOOLS names a tool that is not "+
+				"a registered write tool", zap.String("tool", name))
+		}
+	}
+
+	m.logger.Info("MCP tools registered",
+		zap.Int("total_tools", registrations),
+		zap.Int("filtered_tools", filtered),
+		zap.Int("write_tools", len(m.writeTools)),
+		zap.Bool("write_mode", m.cfg.WriteMode))
 	return nil
 }
 
 // onLNCConnectionEstablished updates service clients when a new LNC
-// connection becomes available.
+// connection becomes available. A connection it replaces is closed after
+// the switch, so calls still streaming over it fail, see that their
+// generation was replaced, and resume on the new connection.
 func (m *Manager) onLNCConnectionEstablished(conn *grpc.ClientConn) {
 	logger := logging.LogWithContext(context.Background())
 	logger.Info("LNC connection established successfully")
 
+	m.connMu.Lock()
+	previous := m.lncConnection
 	m.lncConnection = conn
-	m.lightningClient = lnrpc.NewLightningClient(conn)
+	generation := m.clients.SetClients(newNodeClients(conn))
+	m.connMu.Unlock()
+
+	if previous != nil && previous != conn {
+		if err := previous.Close(); err != nil {
+			logger.Warn("Error closing replaced LNC connection",
+				zap.Error(err))
+		}
+	}
+
+	logger.Info("All services updated with new connection",
+		zap.Uint64("client_generation", generation))
+
+	if !m.cfg.SandboxMode {
+		m.resumeOperations()
+	}
+}
+
+// newNodeClients creates the clients for every RPC service the tools use
+// over conn.
+func newNodeClients(conn *grpc.ClientConn) tools.NodeClients {
+	return tools.NodeClients{
+		Lightning: lnrpc.NewLightningClient(conn),
+		Router:    routerrpc.NewRouterClient(conn),
+		Invoices:  invoicesrpc.NewInvoicesClient(conn),
+		WalletKit: walletrpc.NewWalletKitClient(conn),
+		Towers:    wtclientrpc.NewWatchtowerClientClient(conn),
+	}
+}
 
-	// Update existing read-only services with new connection.
-	m.invoiceService.LightningClient = m.lightningClient
-	m.channelService.LightningClient = m.lightningClient
-	m.paymentService.LightningClient = m.lightningClient
-	m.onchainService.LightningClient = m.lightningClient
-	m.peerService.LightningClient = m.lightningClient
-	m.nodeService.LightningClient = m.lightningClient
+// onLNCDisconnected drops the primary clients after lnc_disconnect, so
+// handlers report that no node is connected.
+func (m *Manager) onLNCDisconnected() {
+	m.connMu.Lock()
+	m.lncConnection = nil
+	m.clients.Clear()
+	m.connMu.Unlock()
+}
 
-	logger.Info("All read-only services updated with new connection")
+// AttachConnection points every service at an already established node
+// connection, as a successful lnc_connect does. The load-test harness uses
+// it to drive the tools against a simulated node.
+func (m *Manager) AttachConnection(conn *grpc.ClientConn) {
+	m.onLNCConnectionEstablished(conn)
+}
+
+// onSandboxConnectionEstablished stores the regtest connection used by
+// write tools in sandbox mode.
+func (m *Manager) onSandboxConnectionEstablished(conn *grpc.ClientConn) {
+	logger := logging.LogWithContext(context.Background())
+	logger.Info("Sandbox connection established successfully")
+
+	m.connMu.Lock()
+	previous := m.sandboxConnection
+	m.sandboxConnection = conn
+	m.sandboxClients.SetClients(newNodeClients(conn))
+	m.connMu.Unlock()
+
+	if previous != nil && previous != conn {
+		if err := previous.Close(); err != nil {
+			logger.Warn("Error closing replaced sandbox connection",
+				zap.Error(err))
+		}
+	}
+
+	m.resumeOperations()
+}
+
+// resumeOperations reconciles the journaled operations still in flight
+// against the write clients in the background, once they have been
+// replaced, reports what finished while the server was not tracking them,
+// and follows the rest.
+func (m *Manager) resumeOperations() {
+	if m.journal == nil || len(m.journal.InFlight()) == 0 {
+		return
+	}
+
+	go func() {
+		ctx := lnccontext.New(context.Background(),
+			"resume_operations", 0)
+		defer ctx.Cancel()
+
+		resolved, err := m.operationService.Reconcile(ctx)
+		m.reportReconciled(resolved, err)
+
+		m.operationService.Resume(ctx)
+	}()
+}
+
+// validateSigning checks that the response signing configuration is
+// usable before any tool is registered.
+func (m *Manager) validateSigning() error {
+	switch m.cfg.ResponseSigning {
+	case "", signing.ModeNone, signing.ModeNode:
+		return nil
+	case signing.ModeHMAC:
+		if m.cfg.ResponseSigningKey == "" {
+			return errors.New(errors.ErrCodeUnknown,
+				"LNC_RESPONSE_SIGNING_KEY is required for hmac "+
+					"response signing")
+		}
+		return nil
+	default:
+		return errors.New(errors.ErrCodeUnknown, "unknown response "+
+			"signing mode: "+m.cfg.ResponseSigning)
+	}
 }
 
 // Shutdown gracefully closes the LNC connection and logs shutdown results.
 func (m *Manager) Shutdown() error {
 	m.logger.Info("Shutting down service manager...")
 
+	if err := m.audit.Close(); err != nil {
+		m.logger.Error("Error closing audit log", zap.Error(err))
+	}
+
+	m.connMu.Lock()
+	defer m.connMu.Unlock()
+
+	if m.sandboxConnection != nil {
+		if err := m.sandboxConnection.Close(); err != nil {
+			m.logger.Error("Error closing sandbox connection",
+				zap.Error(err))
+		}
+	}
+
 	if m.lncConnection != nil {
 		if err := m.lncConnection.Close(); err != nil {
 			m.logger.Error("Error closing LNC connection",
@@ -1,10 +1,33 @@
 package services
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	"github.com/jbrill/mcp-lnc-server/internal/classify"
+	"github.com/jbrill/mcp-lnc-server/internal/config"
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/elicit"
 	"github.com/jbrill/mcp-lnc-server/internal/interfaces"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/lease"
 	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+	"github.com/jbrill/mcp-lnc-server/tools"
+	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +49,7 @@ func TestManager_Creation(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	assert.NotNil(t, manager)
 	assert.Equal(t, zap.L(), manager.logger)
 
@@ -41,7 +64,7 @@ func TestManager_RegisterTools(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 	stub := &stubMCPServer{}
 
@@ -55,6 +78,7 @@ func TestManager_RegisterTools(t *testing.T) {
 
 	// Test read-only tools are registered
 	assert.Contains(t, names, "lnc_decode_invoice")
+	assert.Contains(t, names, "lnc_decode_invoice_offline")
 	assert.Contains(t, names, "lnc_list_channels")
 	assert.Contains(t, names, "lnc_list_unspent")
 	assert.NotZero(t, len(stub.tools))
@@ -64,7 +88,7 @@ func TestManager_RegisterTools_ReadOnlyMode(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 	stub := &stubMCPServer{}
 
@@ -80,28 +104,541 @@ func TestManager_RegisterTools_ReadOnlyMode(t *testing.T) {
 	assert.NotContains(t, names, "lnc_send_payment")
 	assert.NotContains(t, names, "lnc_pay_invoice")
 	assert.NotContains(t, names, "lnc_open_channel")
+	assert.NotContains(t, names, "lnc_batch_open_channels")
+	assert.NotContains(t, names, "lnc_fund_psbt")
+	assert.NotContains(t, names, "lnc_finalize_psbt")
+	assert.NotContains(t, names, "lnc_release_output")
 	assert.NotContains(t, names, "lnc_close_channel")
 	assert.NotContains(t, names, "lnc_send_coins")
 	assert.NotContains(t, names, "lnc_new_address")
+	assert.NotContains(t, names, "lnc_bump_fee")
+	assert.NotContains(t, names, "lnc_label_transaction")
 	assert.NotContains(t, names, "lnc_create_invoice")
 	assert.NotContains(t, names, "lnc_connect_peer")
 	assert.NotContains(t, names, "lnc_disconnect_peer")
+	assert.NotContains(t, names, "lnc_update_channel_policy")
+	assert.NotContains(t, names, "lnc_send_to_route")
+	assert.NotContains(t, names, "lnc_set_mission_control_config")
+	assert.NotContains(t, names, "lnc_add_invoice")
+	assert.NotContains(t, names, "lnc_add_hold_invoice")
+	assert.NotContains(t, names, "lnc_cancel_invoice")
+	assert.NotContains(t, names, "lnc_settle_invoice")
+	assert.NotContains(t, names, "lnc_bake_macaroon")
+	assert.NotContains(t, names, "lnc_delete_macaroon_id")
 
 	// Verify read-only operations are available
 	assert.Contains(t, names, "lnc_list_channels")
 	assert.Contains(t, names, "lnc_get_info")
+	assert.Contains(t, names, "lnc_server_stats")
+	assert.Contains(t, names, "lnc_get_sync_status")
+	assert.Contains(t, names, "lnc_get_recovery_info")
+	assert.Contains(t, names, "lnc_security_report")
+	assert.Contains(t, names, "lnc_list_towers")
+	assert.Contains(t, names, "lnc_tower_stats")
+	assert.Contains(t, names, "lnc_tower_policy")
+	assert.Contains(t, names, "lnc_export_channel_backups")
+	assert.Contains(t, names, "lnc_verify_channel_backup")
+	assert.Contains(t, names, "lnc_close_progress")
+	assert.Contains(t, names, "lnc_forwarding_history")
+	assert.Contains(t, names, "lnc_get_chan_info")
+	assert.Contains(t, names, "lnc_query_routes")
+	assert.Contains(t, names, "lnc_config_check")
+	assert.Contains(t, names, "lnc_get_debug_info")
+	assert.Contains(t, names, "lnc_check_channel_policies")
+	assert.Contains(t, names, "lnc_estimate_route_fee")
+	assert.Contains(t, names, "lnc_build_route")
+	assert.Contains(t, names, "lnc_query_mission_control")
+	assert.Contains(t, names, "lnc_query_payment_probability")
+	assert.Contains(t, names, "lnc_get_mission_control_config")
+	assert.Contains(t, names, "lnc_channel_balance_by_peer")
+	assert.Contains(t, names, "lnc_list_aliases")
+	assert.Contains(t, names, "lnc_lookup_htlc_resolution")
+	assert.Contains(t, names, "lnc_partner_sla")
+	assert.Contains(t, names, "lnc_list_htlcs")
+	assert.Contains(t, names, "lnc_list_permissions")
+	assert.Contains(t, names, "lnc_export_anonymized_graph")
+	assert.Contains(t, names, "lnc_list_macaroon_ids")
+	assert.Contains(t, names, "lnc_estimate_force_close")
 	assert.Contains(t, names, "lnc_list_unspent")
+	assert.Contains(t, names, "lnc_list_bumpable")
+	assert.Contains(t, names, "lnc_list_accounts")
+	assert.Contains(t, names, "lnc_list_addresses")
+	assert.Contains(t, names, "lnc_list_sweeps")
+	assert.Contains(t, names, "lnc_pending_sweeps")
+	assert.Contains(t, names, "lnc_required_reserve")
+	assert.Contains(t, names, "lnc_list_leases")
 	assert.Contains(t, names, "lnc_decode_invoice")
 	assert.Contains(t, names, "lnc_list_peers")
 	assert.Len(t, stub.tools, len(names))
 }
 
+// registeredToolNames returns the names of the tools a manager built from
+// cfg registers.
+func registeredToolNames(t *testing.T,
+	cfg *config.Config) map[string]struct{} {
+	t.Helper()
+
+	manager := NewManager(zap.L(), cfg)
+	manager.InitializeServices()
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	names := make(map[string]struct{})
+	for _, tool := range stub.tools {
+		names[tool.Name] = struct{}{}
+	}
+	return names
+}
+
+func TestManager_RegisterTools_LSP(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	registered := func(cfg *config.Config) map[string]struct{} {
+		return registeredToolNames(t, cfg)
+	}
+
+	// No LSPs configured: no LSP tools.
+	names := registered(&config.Config{})
+	assert.NotContains(t, names, "lnc_lsp_get_info")
+	assert.NotContains(t, names, "lnc_lsp_create_order")
+
+	// LSP configured without order opt-in: read tools only.
+	cfg := &config.Config{
+		LSPEndpoints: map[string]string{"lsp": "https://lsp.example.com"},
+	}
+	names = registered(cfg)
+	assert.Contains(t, names, "lnc_lsp_get_info")
+	assert.Contains(t, names, "lnc_lsp_get_order")
+	assert.NotContains(t, names, "lnc_lsp_create_order")
+
+	// Orders allowed outside write mode: still read tools only.
+	cfg.LSPAllowOrders = true
+	names = registered(cfg)
+	assert.NotContains(t, names, "lnc_lsp_create_order")
+
+	// Orders allowed in write mode.
+	cfg.WriteMode = true
+	names = registered(cfg)
+	assert.Contains(t, names, "lnc_lsp_create_order")
+}
+
+func TestManager_RegisterTools_Sandbox(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_sandbox_connect")
+	assert.NotContains(t, names, "lnc_sandbox_status")
+
+	names = registeredToolNames(t, &config.Config{SandboxMode: true})
+	assert.Contains(t, names, "lnc_sandbox_connect")
+	assert.Contains(t, names, "lnc_sandbox_status")
+}
+
+// Test that in sandbox mode, write tools report the sandbox connection
+// when it is missing, not the primary one.
+func TestManager_SandboxConnectionState(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{
+		WriteMode:   true,
+		SandboxMode: true,
+	})
+	manager.InitializeServices()
+	assert.Same(t, manager.sandboxClients.State(),
+		manager.sandboxService.Connection.State)
+	assert.Same(t, manager.clients.State(),
+		manager.connectionService.State)
+
+	manager.clients.State().SetFailed("mailbox unreachable")
+	status := func(handler server.ToolHandlerFunc) any {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"payment_hash": strings.Repeat("ab", 32),
+		}
+		result, err := handler(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+
+		var payload map[string]any
+		text := result.Content[0].(mcp.TextContent).Text
+		require.NoError(t, json.Unmarshal([]byte(text), &payload))
+		return payload["details"].(map[string]any)["connection_status"]
+	}
+	assert.Equal(t, "failed",
+		status(manager.paymentService.HandleTrackPayment))
+	assert.Equal(t, "never_connected",
+		status(manager.writePaymentService.HandleTrackPayment))
+}
+
+// Test that write tools are registered only in write mode.
+func TestManager_RegisterTools_WriteMode(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_open_channel")
+
+	manager := NewManager(zap.L(), &config.Config{WriteMode: true})
+	manager.InitializeServices()
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	names = make(map[string]struct{})
+	for _, tool := range stub.tools {
+		names[tool.Name] = struct{}{}
+	}
+	assert.Contains(t, names, "lnc_splice_channel")
+	assert.Contains(t, names, "lnc_open_channel")
+	assert.Contains(t, names, "lnc_batch_open_channels")
+	assert.Contains(t, names, "lnc_pay_invoice")
+	assert.Contains(t, names, "lnc_keysend")
+	assert.Contains(t, names, "lnc_send_to_route")
+	assert.Contains(t, names, "lnc_set_mission_control_config")
+	assert.Contains(t, names, "lnc_add_invoice")
+	assert.Contains(t, names, "lnc_add_hold_invoice")
+	assert.Contains(t, names, "lnc_cancel_invoice")
+	assert.Contains(t, names, "lnc_settle_invoice")
+	assert.Contains(t, names, "lnc_send_coins")
+	assert.Contains(t, names, "lnc_new_address")
+	assert.Contains(t, names, "lnc_bump_fee")
+	assert.Contains(t, names, "lnc_label_transaction")
+	assert.Contains(t, names, "lnc_fund_psbt")
+	assert.Contains(t, names, "lnc_finalize_psbt")
+	assert.Contains(t, names, "lnc_release_output")
+	assert.Contains(t, names, "lnc_connect_peer")
+	assert.Contains(t, names, "lnc_disconnect_peer")
+	assert.Contains(t, names, "lnc_update_channel_policy")
+	assert.Contains(t, names, "lnc_bake_macaroon")
+	assert.Contains(t, names, "lnc_list_macaroon_ids")
+	assert.Contains(t, names, "lnc_delete_macaroon_id")
+	assert.True(t, manager.writeTools["lnc_splice_channel"])
+	assert.True(t, manager.writeTools["lnc_open_channel"])
+	assert.True(t, manager.writeTools["lnc_batch_open_channels"])
+	assert.True(t, manager.writeTools["lnc_pay_invoice"])
+	assert.True(t, manager.writeTools["lnc_keysend"])
+	assert.True(t, manager.writeTools["lnc_send_to_route"])
+	assert.True(t, manager.writeTools["lnc_set_mission_control_config"])
+	assert.True(t, manager.writeTools["lnc_add_invoice"])
+	assert.True(t, manager.writeTools["lnc_add_hold_invoice"])
+	assert.True(t, manager.writeTools["lnc_cancel_invoice"])
+	assert.True(t, manager.writeTools["lnc_settle_invoice"])
+	assert.True(t, manager.writeTools["lnc_send_coins"])
+	assert.True(t, manager.writeTools["lnc_new_address"])
+	assert.True(t, manager.writeTools["lnc_bump_fee"])
+	assert.True(t, manager.writeTools["lnc_label_transaction"])
+	assert.True(t, manager.writeTools["lnc_fund_psbt"])
+	assert.True(t, manager.writeTools["lnc_finalize_psbt"])
+	assert.True(t, manager.writeTools["lnc_release_output"])
+	assert.True(t, manager.writeTools["lnc_connect_peer"])
+	assert.True(t, manager.writeTools["lnc_disconnect_peer"])
+	assert.True(t, manager.writeTools["lnc_update_channel_policy"])
+	assert.True(t, manager.writeTools["lnc_bake_macaroon"])
+	assert.True(t, manager.writeTools["lnc_delete_macaroon_id"])
+	assert.False(t, manager.writeTools["lnc_list_macaroon_ids"])
+
+	// Read-only tools stay available alongside the write set.
+	assert.Contains(t, names, "lnc_list_channels")
+}
+
+// Test that response signing registers the verification tool and rejects
+// unusable configurations.
+func TestManager_RegisterTools_Signing(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_verify_response")
+
+	names = registeredToolNames(t, &config.Config{
+		ResponseSigning:    "hmac",
+		ResponseSigningKey: "secret",
+	})
+	assert.Contains(t, names, "lnc_verify_response")
+
+	for _, cfg := range []*config.Config{
+		{ResponseSigning: "hmac"},
+		{ResponseSigning: "rsa"},
+	} {
+		manager := NewManager(zap.L(), cfg)
+		manager.InitializeServices()
+		assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+	}
+}
+
+// Test that the operation journal registers its tool and reaches the write
+// services.
+func TestManager_RegisterTools_Journal(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{WriteMode: true})
+	assert.NotContains(t, names, "lnc_list_operations")
+
+	operations, err := journal.Open(
+		filepath.Join(t.TempDir(), "journal.json"))
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{WriteMode: true})
+	manager.InitializeServices()
+	manager.SetJournal(operations)
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	assert.Contains(t, registered, "lnc_list_operations")
+	assert.False(t, manager.writeTools["lnc_list_operations"])
+	_, ok := tools.OutputSchema("lnc_list_operations")
+	assert.True(t, ok)
+	assert.Same(t, operations, manager.writePaymentService.Journal)
+	assert.Same(t, operations, manager.writeChannelService.Journal)
+	assert.Nil(t, manager.paymentService.Journal)
+}
+
+// Test that the contact book registers its tools, which are not write
+// tools, and reaches the peer and channel services.
+func TestManager_RegisterTools_Contacts(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_list_contacts")
+	assert.NotContains(t, names, "lnc_set_contact")
+
+	book, err := contacts.Open(filepath.Join(t.TempDir(), "contacts.json"))
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{})
+	manager.InitializeServices()
+	manager.SetContacts(book)
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	for _, name := range []string{
+		"lnc_list_contacts", "lnc_set_contact", "lnc_delete_contact",
+	} {
+		assert.Contains(t, registered, name)
+		assert.False(t, manager.writeTools[name], name)
+	}
+	assert.Same(t, book, manager.peerService.Contacts)
+	assert.Same(t, book, manager.channelService.Contacts)
+}
+
+// Test that the watchlist registers its tools, which are not write tools,
+// and that the changes its checks find are raised as audit events.
+func TestManager_Backups(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_server_backup")
+
+	// Restoring touches only the server's own files, so the tools are
+	// not write tools.
+	manager := NewManager(zap.L(), &config.Config{
+		BackupDir: t.TempDir(),
+	})
+	manager.InitializeServices()
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	for _, name := range []string{
+		"lnc_server_backup", "lnc_server_restore",
+	} {
+		assert.Contains(t, registered, name)
+		assert.False(t, manager.writeTools[name], name)
+	}
+}
+
+func TestManager_Watchlist(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{})
+	assert.NotContains(t, names, "lnc_watched_nodes")
+
+	list, err := watchlist.Open(filepath.Join(t.TempDir(),
+		"watchlist.json"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{})
+	manager.SetAuditLogger(audit.New(&buf))
+	manager.InitializeServices()
+	manager.SetWatchlist(list)
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	for _, name := range []string{
+		"lnc_watch_node", "lnc_unwatch_node", "lnc_watched_nodes",
+	} {
+		assert.Contains(t, registered, name)
+		assert.False(t, manager.writeTools[name], name)
+	}
+
+	manager.watchService.OnChanges(context.Background(),
+		[]map[string]any{{
+			"pubkey":  "02" + strings.Repeat("ab", 32),
+			"kind":    watchlist.ChangePolicy,
+			"chan_id": "123",
+		}})
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, audit.EventWatchedNodeChanged, entry["event"])
+	details := entry["details"].(map[string]any)
+	assert.Equal(t, watchlist.ChangePolicy, details["kind"])
+	assert.Equal(t, "123", details["chan_id"])
+}
+
+// Test that scheduled operations call the registered write tool through
+// the same wrapper as a client, and that only calls which can run
+// unattended are scheduled.
+func TestManager_Schedule(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{WriteMode: true})
+	assert.NotContains(t, names, "lnc_schedule_operation")
+
+	operations, err := schedule.Open(filepath.Join(t.TempDir(),
+		"schedule.json"))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{
+		WriteMode:   true,
+		ElicitTools: []string{"lnc_open_channel"},
+	})
+	manager.SetAuditLogger(audit.New(&buf))
+	manager.InitializeServices()
+	manager.SetSchedule(operations)
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+
+	// Scheduling and restoring lead to writes; listing and cancelling
+	// do not.
+	assert.True(t, manager.writeTools["lnc_schedule_operation"])
+	assert.True(t, manager.writeTools["lnc_restore_scheduled_operation"])
+	assert.False(t, manager.writeTools["lnc_list_scheduled_operations"])
+	assert.False(t, manager.writeTools["lnc_cancel_scheduled_operation"])
+
+	pubkey := "02" + strings.Repeat("ab", 32)
+	assert.NoError(t, manager.checkSchedulable("lnc_keysend",
+		map[string]any{"destination": pubkey,
+			"amount_sat": float64(10)}))
+	for tool, args := range map[string]map[string]any{
+		"lnc_get_info":           nil,
+		"lnc_schedule_operation": nil,
+		"lnc_open_channel":       nil,
+		"lnc_send_coins":         nil,
+		"lnc_settle_invoice": {
+			"preimage": strings.Repeat("00", 32),
+		},
+		"lnc_add_invoice": {"amount": float64(10)},
+	} {
+		assert.Error(t, manager.checkSchedulable(tool, args), tool)
+	}
+
+	// The first call succeeds and the second fails.
+	calls := 0
+	keysend := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		if calls > 1 {
+			return mcp.NewToolResultError(`{"code":"RPCFailed",` +
+				`"message":"no route"}`), nil
+		}
+		return mcp.NewToolResultText(`{"status":"SUCCEEDED"}`), nil
+	}
+	manager.writeHandlers["lnc_keysend"] = writeHandler{
+		tool:    mcp.NewTool("lnc_keysend"),
+		handler: keysend,
+	}
+	first, err := operations.Add("lnc_keysend", nil, time.Now(), "")
+	require.NoError(t, err)
+	second, err := operations.Add("lnc_keysend", nil, time.Now(), "")
+	require.NoError(t, err)
+
+	// Nothing runs while no node is connected.
+	manager.runScheduled(context.Background(), first)
+	assert.Zero(t, calls)
+
+	manager.clients.SetClients(tools.NodeClients{
+		Lightning: lnrpc.NewLightningClient(nil),
+	})
+	manager.runScheduled(context.Background(), first)
+	manager.runScheduled(context.Background(), second)
+	assert.Equal(t, 2, calls)
+
+	ran, _ := operations.Get(first.ID)
+	assert.Equal(t, schedule.StateSucceeded, ran.State)
+	failed, _ := operations.Get(second.ID)
+	assert.Equal(t, schedule.StateFailed, failed.State)
+	assert.True(t, failed.Deleted())
+	assert.Equal(t, "no route",
+		failed.History[len(failed.History)-1].Note)
+
+	// A finished operation is not run again.
+	manager.runScheduled(context.Background(), first)
+	assert.Equal(t, 2, calls)
+
+	// The first scheduled call trips the first write notification, and
+	// each is audited under its operation's ID.
+	var requestIDs []any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()),
+		"\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["event"] == audit.EventToolCall {
+			requestIDs = append(requestIDs, entry["mcp_request_id"])
+		}
+	}
+	assert.Equal(t, []any{"schedule:" + first.ID,
+		"schedule:" + second.ID}, requestIDs)
+}
+
+// Test that every tool, in every mode, declares an output schema.
+func TestManager_RegisterTools_OutputSchemas(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{
+		LSPEndpoints:       map[string]string{"lsp": "https://lsp.example.com"},
+		LSPAllowOrders:     true,
+		SandboxMode:        true,
+		WriteMode:          true,
+		ResponseSigning:    "hmac",
+		ResponseSigningKey: "secret",
+	})
+	assert.Contains(t, names, "lnc_get_output_schema")
+
+	for name := range names {
+		_, ok := tools.OutputSchema(name)
+		assert.True(t, ok, "%s has no output schema", name)
+	}
+}
+
 // Test RegisterTools with nil MCP server.
 func TestManager_RegisterTools_NilServer(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 
 	err = manager.RegisterTools(nil)
@@ -114,7 +651,7 @@ func TestManager_ConnectionCallback(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 
 	// Create a mock connection - this would normally be a real gRPC connection
@@ -131,21 +668,933 @@ func TestManager_ConnectionCallback(t *testing.T) {
 	_ = mockConn
 }
 
+// Test that tool calls carry the MCP request ID into the handler context and
+// the audit log.
+func TestManager_AuditCorrelation(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), config.LoadConfig())
+	manager.SetAuditLogger(audit.New(&buf))
+
+	ctx := context.Background()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "lnc_connect"
+	request.Params.Arguments = map[string]any{
+		"pairingPhrase": "one two three",
+		"mailbox":       "mailbox.example.com:443",
+	}
+	request.Params.Meta = &mcp.Meta{ProgressToken: "progress-1"}
+
+	hooks := manager.Hooks()
+	for _, hook := range hooks.OnBeforeCallTool {
+		hook(ctx, float64(7), &request)
+	}
+
+	var handlerTraceID string
+	handler := manager.wrapHandler(mcp.Tool{Name: "lnc_connect"},
+		func(ctx context.Context,
+			request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			assert.Equal(t, float64(7), lnccontext.GetMCPRequestID(ctx))
+			assert.Equal(t, "progress-1",
+				lnccontext.GetProgressToken(ctx))
+			handlerTraceID = lnccontext.GetTraceID(ctx)
+			return mcp.NewToolResultError("failed"), nil
+		})
+
+	_, err = handler(ctx, request)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "lnc_connect", entry["tool"])
+	assert.Equal(t, float64(7), entry["mcp_request_id"])
+	assert.Equal(t, "progress-1", entry["progress_token"])
+	assert.Equal(t, handlerTraceID, entry["trace_id"])
+	assert.Equal(t, true, entry["is_error"])
+
+	arguments := entry["arguments"].(map[string]any)
+	assert.Equal(t, "[REDACTED]", arguments["pairingPhrase"])
+	assert.Equal(t, "mailbox.example.com:443", arguments["mailbox"])
+
+	// The captured call is consumed by the handler.
+	_, pending := manager.pendingCalls.Load(ctx)
+	assert.False(t, pending)
+}
+
+// Test that memos are scrubbed from audit entries as configured, and that
+// an unknown scrubbing mode is refused.
+func TestManager_MemoScrub(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{
+		MemoScrub: scrub.ModeRedact,
+	})
+	manager.SetAuditLogger(audit.New(&buf))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"memo":         "Order 1234 for Alice Smith",
+		"payment_hash": "aa",
+	}
+	handler := manager.wrapHandler(mcp.Tool{Name: "lnc_add_hold_invoice"},
+		func(ctx context.Context,
+			request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// The handler still sees the memo it was given.
+			assert.Equal(t, "Order 1234 for Alice Smith",
+				request.Params.Arguments["memo"])
+			return mcp.NewToolResultText("{}"), nil
+		})
+	_, err = handler(context.Background(), request)
+	require.NoError(t, err)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	arguments := entry["arguments"].(map[string]any)
+	assert.Equal(t, scrub.Redacted, arguments["memo"])
+	assert.Equal(t, "aa", arguments["payment_hash"])
+
+	manager = NewManager(zap.L(), &config.Config{MemoScrub: "encrypt"})
+	manager.InitializeServices()
+	assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+}
+
+// Test that the configured data classes are applied to every result, and
+// that unknown classes are refused.
+func TestManager_ResponseClasses(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+	defer tools.SetResponseClasses(classify.All)
+
+	manager := NewManager(zap.L(), &config.Config{
+		ResponseClasses: []string{"secret"},
+	})
+	manager.InitializeServices()
+	assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+
+	manager = NewManager(zap.L(), &config.Config{
+		ResponseClasses: []string{"internal"},
+	})
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+
+	schema, ok := tools.OutputSchema("lnc_new_address")
+	require.True(t, ok)
+	assert.NotContains(t, schema["required"], "address")
+}
+
+// Test that an unknown dual-funding policy or a negative amount is rejected,
+// and that the policy reaches the channel service.
+func TestManager_DualFundPolicy(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	for _, cfg := range []*config.Config{
+		{DualFundPolicy: "always"},
+		{DualFundPolicy: tools.DualFundFixed, DualFundFixedSat: -1},
+		{DualFundPolicy: tools.DualFundMatch, DualFundMaxSat: -1},
+	} {
+		manager := NewManager(zap.L(), cfg)
+		manager.InitializeServices()
+		assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+	}
+
+	manager := NewManager(zap.L(), &config.Config{
+		DualFundPolicy: tools.DualFundMatch,
+		DualFundMaxSat: 500_000,
+	})
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+	assert.Equal(t, tools.DualFundMatch,
+		manager.channelService.DualFundPolicy.Mode)
+}
+
+// Test that an unknown preimage disclosure policy is rejected, and that the
+// policy reaches the payment services.
+func TestManager_PreimageDisclosure(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{
+		PreimageDisclosure: "sometimes",
+	})
+	manager.InitializeServices()
+	assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+
+	manager = NewManager(zap.L(), &config.Config{
+		WriteMode:          true,
+		PreimageDisclosure: tools.PreimageNever,
+	})
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+	assert.Equal(t, tools.PreimageNever,
+		manager.paymentService.PreimageDisclosure)
+	assert.Equal(t, tools.PreimageNever,
+		manager.writePaymentService.PreimageDisclosure)
+}
+
+// Test that negative spend limits are rejected, and that the write services
+// share the manager's policy engine.
+func TestManager_SpendLimits(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{MaxSpendPerDaySat: -1})
+	manager.InitializeServices()
+	assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+
+	manager = NewManager(zap.L(), &config.Config{
+		WriteMode:     true,
+		MaxPaymentSat: 1_000,
+	})
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+	assert.True(t, manager.policy.SpendLimited())
+	assert.Same(t, manager.policy, manager.writePaymentService.Policy)
+	assert.Same(t, manager.policy, manager.writeChannelService.Policy)
+	assert.Same(t, manager.policy, manager.writeOnChainService.Policy)
+}
+
+// Test that the tool allowlist and denylist narrow the registered tools.
+func TestManager_ToolFilter(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{
+		WriteMode:     true,
+		ToolAllowlist: []string{"lnc_connect", "lnc_*_invoice"},
+		ToolDenylist:  []string{"lnc_pay_invoice"},
+	})
+	assert.Contains(t, names, "lnc_connect")
+	assert.Contains(t, names, "lnc_add_hold_invoice")
+	assert.Contains(t, names, "lnc_settle_invoice")
+	assert.NotContains(t, names, "lnc_pay_invoice")
+	assert.NotContains(t, names, "lnc_open_channel")
+	assert.NotContains(t, names, "lnc_get_info")
+
+	// Only the denylist: everything else stays.
+	names = registeredToolNames(t, &config.Config{
+		WriteMode:    true,
+		ToolDenylist: []string{"*channel*"},
+	})
+	assert.Contains(t, names, "lnc_pay_invoice")
+	assert.NotContains(t, names, "lnc_open_channel")
+	assert.NotContains(t, names, "lnc_list_channels")
+
+	// Patterns from a file, with comments.
+	file := filepath.Join(t.TempDir(), "tools.txt")
+	require.NoError(t, os.WriteFile(file,
+		[]byte("# read-only\nlnc_connect, lnc_get_info\n\nlnc_list_*\n"),
+		0o600))
+	names = registeredToolNames(t, &config.Config{
+		ToolAllowlist: []string{"@" + file},
+	})
+	assert.Contains(t, names, "lnc_get_info")
+	assert.Contains(t, names, "lnc_list_payments")
+	assert.NotContains(t, names, "lnc_get_balance")
+
+	for _, cfg := range []*config.Config{
+		{ToolAllowlist: []string{"lnc_["}},
+		{ToolDenylist: []string{"@" + filepath.Join(t.TempDir(), "none")}},
+	} {
+		manager := NewManager(zap.L(), cfg)
+		manager.InitializeServices()
+		assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+	}
+}
+
+// Test that list resources are served alongside the tools listing the same
+// entries, and that reads are audited.
+func TestManager_Resources(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{
+		ToolDenylist: []string{"lnc_list_channels"},
+	})
+	manager.InitializeServices()
+	manager.SetAuditLogger(audit.New(&buf))
+	mcpServer := server.NewMCPServer("test-server", "1.0.0")
+	require.NoError(t, manager.RegisterTools(mcpServer))
+
+	call := func(method string, params map[string]any) map[string]any {
+		message, err := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  method,
+			"params":  params,
+		})
+		require.NoError(t, err)
+		encoded, err := json.Marshal(mcpServer.HandleMessage(
+			context.Background(), message))
+		require.NoError(t, err)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &response))
+		return response
+	}
+
+	// The channels resource goes with the filtered out tool.
+	response := call("resources/templates/list", map[string]any{})
+	templates := response["result"].(map[string]any)["resourceTemplates"]
+	require.Len(t, templates, 1)
+	assert.Equal(t, "lnc://payments{?cursor,limit}",
+		templates.([]any)[0].(map[string]any)["uriTemplate"])
+
+	// Without a connection the read fails, and is audited either way.
+	response = call("resources/read", map[string]any{
+		"uri": "lnc://payments?cursor=10&limit=5",
+	})
+	assert.Contains(t, response, "error")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, audit.EventResourceRead, entry["event"])
+	assert.Equal(t, "lnc_list_payments", entry["tool"])
+	assert.Equal(t, true, entry["is_error"])
+	assert.Equal(t, "lnc://payments?cursor=10&limit=5",
+		entry["arguments"].(map[string]any)["uri"])
+}
+
+// testSession is a client session for messages handed straight to a server.
+type testSession struct {
+	id string
+}
+
+func (s testSession) Initialize() {}
+
+func (s testSession) Initialized() bool { return true }
+
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+
+func (s testSession) SessionID() string { return s.id }
+
+// Test that large results are compressed only for sessions that accepted
+// compression when they initialized.
+func TestManager_Compression(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{CompressThreshold: 64})
+	mcpServer := server.NewMCPServer("test-server", "1.0.0",
+		server.WithHooks(manager.Hooks()))
+	text := `{"data":"` + strings.Repeat("a", 1_000) + `"}`
+	tool := mcp.NewTool("lnc_large")
+	mcpServer.AddTool(tool, manager.wrapHandler(tool,
+		func(ctx context.Context,
+			request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(text), nil
+		}))
+
+	send := func(session testSession, method string,
+		params map[string]any) map[string]any {
+		message, err := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  method,
+			"params":  params,
+		})
+		require.NoError(t, err)
+		ctx := mcpServer.WithContext(context.Background(), session)
+		encoded, err := json.Marshal(mcpServer.HandleMessage(ctx,
+			message))
+		require.NoError(t, err)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &response))
+		return response["result"].(map[string]any)
+	}
+	initialize := func(session testSession,
+		capabilities map[string]any) map[string]any {
+		require.NoError(t, mcpServer.RegisterSession(
+			context.Background(), session))
+		return send(session, "initialize", map[string]any{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    capabilities,
+			"clientInfo": map[string]any{
+				"name": "test", "version": "1.0.0",
+			},
+		})
+	}
+	call := func(session testSession) string {
+		result := send(session, "tools/call", map[string]any{
+			"name": "lnc_large",
+		})
+		content := result["content"].([]any)[0].(map[string]any)
+		return content["text"].(string)
+	}
+
+	// Every client is offered compression.
+	accepting := testSession{id: "accepting"}
+	result := initialize(accepting, map[string]any{
+		"experimental": map[string]any{
+			tools.CompressionCapability: map[string]any{
+				"encodings": []any{tools.CompressionEncoding},
+			},
+		},
+	})
+	offered := result["capabilities"].(map[string]any)["experimental"]
+	assert.Equal(t, map[string]any{
+		"encodings":       []any{"gzip+base64"},
+		"threshold_bytes": float64(64),
+	}, offered.(map[string]any)[tools.CompressionCapability])
+
+	// Results carry their attestation, so the plain result is the
+	// tool's own with it added.
+	plain := testSession{id: "plain"}
+	initialize(plain, map[string]any{})
+	uncompressed := call(plain)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(uncompressed), &payload))
+	assert.Equal(t, strings.Repeat("a", 1_000), payload["data"])
+	assert.Contains(t, payload, "attestation")
+
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal([]byte(call(accepting)), &envelope))
+	assert.Equal(t, "gzip+base64", envelope["content_encoding"])
+	assert.Equal(t, float64(len(uncompressed)), envelope["original_bytes"])
+	compressed, err := base64.StdEncoding.DecodeString(
+		envelope["data"].(string))
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, uncompressed, string(decompressed))
+
+	// A session that ends is forgotten.
+	mcpServer.UnregisterSession(context.Background(), accepting.id)
+	_, ok := manager.compressSessions.Load(accepting.id)
+	assert.False(t, ok)
+}
+
+// Test that a tool left out by the filter is not treated as a write tool.
+func TestManager_ToolFilter_WriteTools(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	manager := NewManager(zap.L(), &config.Config{
+		WriteMode:    true,
+		ToolDenylist: []string{"lnc_keysend"},
+	})
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+	assert.True(t, manager.writeTools["lnc_pay_invoice"])
+	assert.False(t, manager.writeTools["lnc_keysend"])
+}
+
+// Test that strict mode rejects undeclared arguments.
+func TestManager_StrictArguments(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	tool := mcp.NewTool("lnc_lookup_invoice",
+		mcp.WithString("payment_hash", mcp.Required()))
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"payment_hash": "abc",
+		"paymenthash":  "abc",
+		"limit":        float64(5),
+	}
+
+	called := false
+	handler := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("{}"), nil
+	}
+
+	// Unknown arguments are ignored by default.
+	manager := NewManager(zap.L(), &config.Config{})
+	result, err := manager.wrapHandler(tool, handler)(
+		context.Background(), request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+
+	called = false
+	manager = NewManager(zap.L(), &config.Config{StrictArguments: true})
+	result, err = manager.wrapHandler(tool, handler)(
+		context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.False(t, called)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(text), &payload))
+	assert.Equal(t, "InvalidArgument", payload["code"])
+	assert.Contains(t, payload["message"], "limit, paymenthash")
+	assert.Equal(t, []any{"limit", "paymenthash"},
+		payload["details"].(map[string]any)["unexpected_arguments"])
+}
+
+// approver answers elicitation requests with a fixed result.
+type approver struct {
+	result   *elicit.Result
+	err      error
+	messages []string
+}
+
+func (a *approver) Elicit(ctx context.Context, message string,
+	schema map[string]any) (*elicit.Result, error) {
+	a.messages = append(a.messages, message)
+	return a.result, a.err
+}
+
+// Test that calls to tools listed in LNC_ELICIT_TOOLS run only once the user
+// approves them, and that other tools and previews are not asked about.
+func TestManager_ElicitApproval(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	send := mcp.NewTool("lnc_send_coins",
+		mcp.WithString("address"), mcp.WithNumber("amount_sat"),
+		mcp.WithString("confirmation_id"))
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"address":         "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"amount_sat":      float64(5000),
+		"confirmation_id": "abc",
+	}
+
+	called := false
+	handler := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("{}"), nil
+	}
+	manager := NewManager(zap.L(), &config.Config{
+		ElicitTools: []string{"lnc_send_coins"},
+	})
+	wrapped := manager.wrapHandler(send, handler)
+
+	denied := func(t *testing.T, result *mcp.CallToolResult) string {
+		t.Helper()
+		require.True(t, result.IsError)
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal([]byte(
+			result.Content[0].(mcp.TextContent).Text), &payload))
+		assert.Equal(t, "PermissionDenied", payload["code"])
+		return payload["message"].(string)
+	}
+
+	// A client that cannot ask is refused.
+	result, err := wrapped(context.Background(), request)
+	require.NoError(t, err)
+	assert.Contains(t, denied(t, result), "MCP elicitation")
+	assert.False(t, called)
+
+	// Declining refuses, and the user is shown the parsed action.
+	user := &approver{result: &elicit.Result{Action: elicit.ActionDecline}}
+	ctx := elicit.WithRequester(context.Background(), user)
+	result, err = wrapped(ctx, request)
+	require.NoError(t, err)
+	assert.Contains(t, denied(t, result), "did not approve")
+	assert.False(t, called)
+	require.Len(t, user.messages, 1)
+	assert.Contains(t, user.messages[0], "Amount: 5000 sat")
+	assert.Contains(t, user.messages[0],
+		"Address: bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+
+	// Approving runs the tool.
+	user.result = &elicit.Result{
+		Action:  elicit.ActionAccept,
+		Content: map[string]any{"approve": true},
+	}
+	result, err = wrapped(ctx, request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+
+	// The preview call of a two-step tool is not asked about.
+	called = false
+	user.result = &elicit.Result{Action: elicit.ActionCancel}
+	delete(request.Params.Arguments, "confirmation_id")
+	result, err = wrapped(ctx, request)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+	assert.Len(t, user.messages, 2)
+
+	// Unlisted tools run without asking.
+	called = false
+	keysend := mcp.NewTool("lnc_keysend", mcp.WithString("destination"))
+	result, err = manager.wrapHandler(keysend, handler)(
+		context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.True(t, called)
+}
+
+// Test that the first write tool call in a session trips the operator
+// notification, and later calls do not.
+func TestManager_FirstWriteNotification(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{
+		LSPEndpoints:   map[string]string{"acme": "https://lsp.example"},
+		LSPAllowOrders: true,
+		WriteMode:      true,
+	})
+	manager.SetAuditLogger(audit.New(&buf))
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+
+	assert.True(t, manager.writeTools["lnc_lsp_create_order"])
+	assert.False(t, manager.writeTools["lnc_get_info"])
+
+	args := map[string]any{"lsp": "acme", "lsp_balance_sat": float64(500000)}
+	order := mcp.Tool{Name: "lnc_lsp_create_order"}
+	ctx := lnccontext.WithMCPCall(context.Background(), nil, nil,
+		"session-1")
+
+	assert.False(t, manager.notifyFirstWrite(ctx,
+		mcp.Tool{Name: "lnc_get_info"}, nil))
+	assert.True(t, manager.notifyFirstWrite(ctx, order, args))
+	assert.False(t, manager.notifyFirstWrite(ctx, order, args))
+
+	other := lnccontext.WithMCPCall(context.Background(), nil, nil,
+		"session-2")
+	assert.True(t, manager.notifyFirstWrite(other, order, args))
+
+	var entry map[string]any
+	line, err := buf.ReadBytes('\n')
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(line, &entry))
+	assert.Equal(t, audit.EventFirstWrite, entry["event"])
+	assert.Equal(t, "lnc_lsp_create_order", entry["tool"])
+	assert.Equal(t, float64(500000), entry["amount"])
+	assert.Equal(t, "acme", entry["destination"])
+	assert.Equal(t, "session-1", entry["session_id"])
+}
+
+// Test that tool calls tripping an anomaly rule raise an audit event, and
+// that refused writes count towards the repeated denials rule.
+func TestManager_AnomalyAlerts(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{
+		ElicitTools:         []string{"lnc_keysend"},
+		AnomalyDeniedWrites: 2,
+		Honeytokens:         []string{"decoy-pubkey"},
+	})
+	manager.SetAuditLogger(audit.New(&buf))
+	manager.InitializeServices()
+	require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+	manager.writeTools["lnc_keysend"] = true
+
+	handler := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("{}"), nil
+	}
+	ctx := lnccontext.WithMCPCall(context.Background(), nil, nil,
+		"session-1")
+	events := func() []map[string]any {
+		var anomalies []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			var entry map[string]any
+			if json.Unmarshal(line, &entry) == nil &&
+				entry["event"] == audit.EventAnomaly {
+				anomalies = append(anomalies, entry)
+			}
+		}
+		return anomalies
+	}
+
+	// Keysends are refused, since this client cannot ask for approval.
+	keysend := manager.wrapHandler(mcp.NewTool("lnc_keysend",
+		mcp.WithString("destination")), handler)
+	_, err = keysend(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, events())
+	_, err = keysend(ctx, mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.Len(t, events(), 1)
+	entry := events()[0]
+	assert.Equal(t, "lnc_keysend", entry["tool"])
+	assert.Equal(t, "session-1", entry["session_id"])
+	assert.Equal(t, "repeated_denied_writes",
+		entry["details"].(map[string]any)["rule"])
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"pubkey": "decoy-pubkey"}
+	_, err = manager.wrapHandler(mcp.NewTool("lnc_get_node_info",
+		mcp.WithString("pubkey")), handler)(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, events(), 2)
+	details := events()[1]["details"].(map[string]any)
+	assert.Equal(t, "honeytoken", details["rule"])
+	assert.Equal(t, "pubkey", details["argument"])
+
+	// Invalid rules are rejected at registration.
+	manager = NewManager(zap.L(), &config.Config{
+		AnomalyActiveHours: "evenings",
+	})
+	manager.InitializeServices()
+	assert.Error(t, manager.RegisterTools(&stubMCPServer{}))
+}
+
+// Test that wrapped results and errors are attested with the server mode
+// and the node of the clients that served the call.
+func TestManager_Attestation(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	attestation := func(manager *Manager, name string,
+		result *mcp.CallToolResult) map[string]any {
+		handler := func(ctx context.Context,
+			request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return result, nil
+		}
+
+		wrapped, err := manager.wrapHandler(mcp.NewTool(name),
+			handler)(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+
+		var payload map[string]any
+		text := wrapped.Content[0].(mcp.TextContent).Text
+		require.NoError(t, json.Unmarshal([]byte(text), &payload))
+		return payload["attestation"].(map[string]any)
+	}
+	newManager := func(cfg *config.Config) *Manager {
+		manager := NewManager(zap.L(), cfg)
+		manager.InitializeServices()
+		require.NoError(t, manager.RegisterTools(&stubMCPServer{}))
+		return manager
+	}
+
+	got := attestation(newManager(&config.Config{}), "lnc_get_info",
+		mcp.NewToolResultText(`{"alias":"node"}`))
+	assert.Equal(t, "read_only", got["mode"])
+	assert.Equal(t, false, got["dry_run"])
+	assert.NotContains(t, got, "node_pubkey")
+
+	// In sandbox mode, only write tools act on the sandbox node, and
+	// their results name it.
+	manager := newManager(&config.Config{WriteMode: true,
+		SandboxMode: true})
+	manager.clients.State().SetNode("primary-pubkey", "mainnet")
+	manager.clients.State().SetConnected()
+	manager.sandboxClients.State().SetNode("sandbox-pubkey", "regtest")
+	manager.sandboxClients.State().SetConnected()
+
+	got = attestation(manager, "lnc_get_info",
+		mcp.NewToolResultText(`{"alias":"node"}`))
+	assert.Equal(t, "write_enabled", got["mode"])
+	assert.Equal(t, false, got["dry_run"])
+	assert.Equal(t, "primary-pubkey", got["node_pubkey"])
+
+	got = attestation(manager, "lnc_pay_invoice",
+		mcp.NewToolResultError(`{"code":"NotConnected"}`))
+	assert.Equal(t, "write_enabled", got["mode"])
+	assert.Equal(t, true, got["dry_run"])
+	assert.Equal(t, "sandbox-pubkey", got["node_pubkey"])
+	assert.Equal(t, "regtest", got["network"])
+
+	// Without sandbox mode, write tools act on the primary node.
+	manager = newManager(&config.Config{WriteMode: true})
+	got = attestation(manager, "lnc_pay_invoice",
+		mcp.NewToolResultText(`{}`))
+	assert.Equal(t, false, got["dry_run"])
+}
+
+// Test that an instance refuses tool calls until it takes the leader lease,
+// and hands the lease over when the campaign ends.
+func TestManager_Campaign(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "leader.lease")
+	other, err := lease.New(path, "other", time.Minute)
+	require.NoError(t, err)
+	held, err := other.TryAcquire()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	own, err := lease.New(path, "own", time.Minute)
+	require.NoError(t, err)
+	manager := NewManager(zap.L(), &config.Config{})
+	manager.InitializeServices()
+	manager.SetLease(own)
+
+	handler := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("{}"), nil
+	}
+	call := manager.wrapHandler(mcp.NewTool("lnc_get_info"), handler)
+	result, err := call(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(
+		[]byte(result.Content[0].(mcp.TextContent).Text), &payload))
+	assert.Equal(t, "Standby", payload["code"])
+	assert.Equal(t, true, payload["retryable"])
+	assert.Equal(t, "other",
+		payload["details"].(map[string]any)["leader"])
+
+	// Once the other instance lets go, this one takes over, replacing
+	// the clients of any session held before.
+	generation := manager.clients.Generation()
+	require.NoError(t, other.Release())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		manager.Campaign(ctx, 10*time.Millisecond)
+	}()
+	require.Eventually(t, func() bool {
+		result, err := call(context.Background(), mcp.CallToolRequest{})
+		return err == nil && !result.IsError
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, manager.clients.Replaced(generation))
+
+	cancel()
+	<-done
+	current, err := other.Current()
+	require.NoError(t, err)
+	assert.Nil(t, current)
+	result, err = call(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+// Test that wallet unlocking is only offered when the operator opts in.
+func TestManager_RegisterTools_WalletUnlock(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{WriteMode: true})
+	assert.NotContains(t, names, "lnc_unlock_wallet")
+
+	manager := NewManager(zap.L(), &config.Config{AllowWalletUnlock: true})
+	manager.InitializeServices()
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	assert.Contains(t, registered, "lnc_unlock_wallet")
+	assert.True(t, manager.writeTools["lnc_unlock_wallet"])
+	assert.True(t, manager.connectionService.AllowWalletUnlock)
+}
+
+// Test that lnc_abandon_channel is only registered for write mode in dev
+// mode.
+func TestManager_RegisterTools_AbandonChannel(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	names := registeredToolNames(t, &config.Config{WriteMode: true})
+	assert.NotContains(t, names, "lnc_abandon_channel")
+
+	names = registeredToolNames(t, &config.Config{DefaultDevMode: true})
+	assert.NotContains(t, names, "lnc_abandon_channel")
+
+	manager := NewManager(zap.L(), &config.Config{
+		WriteMode:      true,
+		DefaultDevMode: true,
+	})
+	manager.InitializeServices()
+	stub := &stubMCPServer{}
+	require.NoError(t, manager.RegisterTools(stub))
+
+	var registered []string
+	for _, tool := range stub.tools {
+		registered = append(registered, tool.Name)
+	}
+	assert.Contains(t, registered, "lnc_abandon_channel")
+	assert.True(t, manager.writeTools["lnc_abandon_channel"])
+}
+
+// Test that operations resolved after a restart are reported in one audit
+// event.
+func TestManager_ReportReconciled(t *testing.T) {
+	err := logging.InitLogger(true)
+	require.NoError(t, err)
+
+	operations, err := journal.Open(
+		filepath.Join(t.TempDir(), "journal.json"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manager := NewManager(zap.L(), &config.Config{WriteMode: true})
+	manager.SetAuditLogger(audit.New(&buf))
+	manager.InitializeServices()
+	manager.SetJournal(operations)
+
+	assert.False(t, manager.reportReconciled(nil, nil))
+	assert.Zero(t, buf.Len())
+
+	for _, key := range []string{"paid", "failed", "pending"} {
+		require.NoError(t, operations.Begin(journal.KindPayment, key,
+			"lnc_pay_invoice", nil))
+	}
+	require.NoError(t, operations.Update(journal.KindPayment, "paid",
+		journal.StateSucceeded, nil))
+	require.NoError(t, operations.Update(journal.KindPayment, "failed",
+		journal.StateFailed, map[string]any{
+			"failure_reason": "FAILURE_REASON_NO_ROUTE",
+		}))
+
+	var resolved []journal.Operation
+	for _, op := range operations.Operations() {
+		if op.State != journal.StateInFlight {
+			resolved = append(resolved, op)
+		}
+	}
+	assert.True(t, manager.reportReconciled(resolved, nil))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, audit.EventOperationsReconciled, entry["event"])
+	assert.NotContains(t, entry, "tool")
+	details := entry["details"].(map[string]any)
+	assert.EqualValues(t, 1, details["succeeded"])
+	assert.EqualValues(t, 1, details["failed"])
+	assert.EqualValues(t, 1, details["in_flight"])
+	reported := details["operations"].([]any)
+	require.Len(t, reported, 2)
+	for _, op := range reported {
+		op := op.(map[string]any)
+		if op["key"] == "failed" {
+			assert.Equal(t, "FAILURE_REASON_NO_ROUTE",
+				op["failure_reason"])
+		}
+	}
+}
+
 // Test services start with nil clients.
 func TestManager_ServicesStartWithNilClients(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 
 	// Services should start with nil clients until connection is established
-	assert.Nil(t, manager.invoiceService.LightningClient)
-	assert.Nil(t, manager.channelService.LightningClient)
-	assert.Nil(t, manager.paymentService.LightningClient)
-	assert.Nil(t, manager.onchainService.LightningClient)
-	assert.Nil(t, manager.peerService.LightningClient)
-	assert.Nil(t, manager.nodeService.LightningClient)
+	for _, clients := range []*tools.ClientProvider{
+		manager.invoiceService.Clients,
+		manager.channelService.Clients,
+		manager.paymentService.Clients,
+		manager.onchainService.Clients,
+		manager.peerService.Clients,
+		manager.nodeService.Clients,
+	} {
+		client, _ := clients.Lightning()
+		assert.Nil(t, client)
+	}
 }
 
 // Test Shutdown functionality.
@@ -153,7 +1602,7 @@ func TestManager_Shutdown(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 
 	// Test shutdown - should not error
 	err = manager.Shutdown()
@@ -165,7 +1614,7 @@ func TestManager_ServiceIntegration(t *testing.T) {
 	err := logging.InitLogger(true)
 	require.NoError(t, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 
 	// Test that services are properly initialized
@@ -213,7 +1662,7 @@ func BenchmarkManager_Creation(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = NewManager(zap.L())
+		_ = NewManager(zap.L(), config.LoadConfig())
 	}
 }
 
@@ -222,7 +1671,7 @@ func BenchmarkManager_RegisterTools(b *testing.B) {
 	err := logging.InitLogger(true)
 	require.NoError(b, err)
 
-	manager := NewManager(zap.L())
+	manager := NewManager(zap.L(), config.LoadConfig())
 	manager.InitializeServices()
 	mcpServer := server.NewMCPServer("test-server", "1.0.0")
 
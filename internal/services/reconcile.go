@@ -0,0 +1,86 @@
+package services
+
+import (
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+// reportReconciled emits an event summarising the journaled operations that
+// finished while the server was not tracking them, and those still in
+// flight, once they have been checked against the node. It returns whether
+// the event was emitted.
+func (m *Manager) reportReconciled(resolved []journal.Operation,
+	reconcileErr error) bool {
+	inFlight := len(m.journal.InFlight())
+	if len(resolved) == 0 && inFlight == 0 && reconcileErr == nil {
+		return false
+	}
+
+	succeeded, failed := 0, 0
+	operations := make([]map[string]any, 0, len(resolved))
+	for _, op := range resolved {
+		switch op.State {
+		case journal.StateSucceeded:
+			succeeded++
+		case journal.StateFailed:
+			failed++
+		}
+
+		entry := map[string]any{
+			"kind":       op.Kind,
+			"key":        op.Key,
+			"tool":       op.Tool,
+			"state":      op.State,
+			"started_at": op.StartedAt,
+		}
+		if reason, ok := op.Detail["failure_reason"]; ok {
+			entry["failure_reason"] = reason
+		}
+		operations = append(operations, entry)
+	}
+
+	details := map[string]any{
+		"succeeded":  succeeded,
+		"failed":     failed,
+		"in_flight":  inFlight,
+		"operations": operations,
+	}
+	if reconcileErr != nil {
+		details["error"] = status.Convert(reconcileErr).Message()
+	}
+
+	entry := audit.Entry{
+		Event:   audit.EventOperationsReconciled,
+		IsError: reconcileErr != nil,
+		Details: details,
+	}
+	if err := m.audit.Log(entry); err != nil {
+		m.logger.Error("Failed to write audit entry", zap.Error(err))
+	}
+
+	fields := []zap.Field{
+		zap.Int("succeeded", succeeded),
+		zap.Int("failed", failed),
+		zap.Int("in_flight", inFlight),
+	}
+	for _, op := range resolved {
+		m.logger.Named("operator").Info("Journaled operation finished "+
+			"while the server was not tracking it",
+			zap.String("kind", op.Kind),
+			zap.String("key", op.Key),
+			zap.String("tool", op.Tool),
+			zap.String("state", op.State))
+	}
+	if reconcileErr != nil {
+		m.logger.Named("operator").Warn("Journaled operations could "+
+			"not all be checked against the node",
+			append(fields, zap.Error(reconcileErr))...)
+		return true
+	}
+	m.logger.Named("operator").Info("Journaled operations reconciled",
+		fields...)
+
+	return true
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// maxProblemLength caps the failure recorded in a scheduled operation's
+// history, so a long error does not bloat the schedule file.
+const maxProblemLength = 500
+
+// writeHandler is a registered write tool, kept so scheduled operations
+// can call it.
+type writeHandler struct {
+	tool    mcp.Tool
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+}
+
+// SetSchedule enables scheduled operations, registering the schedule tools
+// in write mode. It must be called after InitializeServices.
+func (m *Manager) SetSchedule(operations *schedule.Schedule) {
+	m.schedule = operations
+	m.scheduleService.Schedule = operations
+	m.scheduleService.Schedulable = m.checkSchedulable
+	m.backupService.Schedule = operations
+}
+
+// RunSchedule runs the scheduled operations that are due every interval
+// until ctx is done. Operations wait while no node is connected and while
+// on standby, so only the leader of a cluster runs them.
+func (m *Manager) RunSchedule(ctx context.Context, interval time.Duration) {
+	if m.schedule == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if m.standby.Load() {
+			continue
+		}
+		for _, op := range m.schedule.Due(time.Now()) {
+			if ctx.Err() != nil {
+				return
+			}
+			m.runScheduled(ctx, op)
+		}
+	}
+}
+
+// runScheduled runs one due operation and records its outcome. A failed
+// operation is soft-deleted by the schedule and not retried.
+func (m *Manager) runScheduled(ctx context.Context, op schedule.Operation) {
+	clients, _ := m.servingClients(op.Tool)
+	if client, _ := clients.Lightning(); client == nil {
+		return
+	}
+
+	started, err := m.schedule.Start(op.ID)
+	if err != nil {
+		m.logger.Error("Failed to start scheduled operation",
+			zap.String("id", op.ID), zap.Error(err))
+		return
+	}
+	if !started {
+		return
+	}
+
+	problem := m.callScheduled(ctx, op)
+	if err := m.schedule.Finish(op.ID, problem); err != nil {
+		m.logger.Error("Failed to record scheduled operation outcome",
+			zap.String("id", op.ID), zap.Error(err))
+	}
+
+	fields := []zap.Field{
+		zap.String("id", op.ID),
+		zap.String("tool", op.Tool),
+		zap.Bool("succeeded", problem == ""),
+	}
+	if problem != "" {
+		fields = append(fields, zap.String("problem", problem))
+	}
+	m.logger.Named("operator").Info("Scheduled operation ran", fields...)
+}
+
+// callScheduled calls the tool of a scheduled operation through the same
+// wrapper as a client's call, so limits, approval, auditing and signing
+// apply alike. The audit entry carries "schedule:<id>" as its request ID.
+// It returns why the call failed, or "" if it succeeded.
+func (m *Manager) callScheduled(ctx context.Context,
+	op schedule.Operation) string {
+	registered, ok := m.writeHandlers[op.Tool]
+	if !ok {
+		return op.Tool + " is not a write tool this server offers"
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.pendingCalls.Store(callCtx, mcpCall{requestID: "schedule:" + op.ID})
+
+	var request mcp.CallToolRequest
+	request.Params.Name = op.Tool
+	request.Params.Arguments = op.Arguments
+	result, err := m.wrapHandler(registered.tool, registered.handler)(
+		callCtx, request)
+	switch {
+	case err != nil:
+		return truncateProblem(err.Error())
+	case result == nil:
+		return op.Tool + " returned no result"
+	case result.IsError:
+		return truncateProblem(resultMessage(result))
+	}
+	return ""
+}
+
+// checkSchedulable returns why a call of tool with args cannot be
+// scheduled, or nil if it can: the tool must be a registered write tool
+// that acts in one call and needs no approval, and the arguments must be
+// ones it takes, none of them secret, as they are kept on disk.
+func (m *Manager) checkSchedulable(tool string, args map[string]any) error {
+	registered, ok := m.writeHandlers[tool]
+	if !ok {
+		return fmt.Errorf("%s is not a write tool this server offers",
+			tool)
+	}
+	if m.elicitTools[tool] {
+		return fmt.Errorf("%s needs the user's approval, which a "+
+			"scheduled call cannot ask for", tool)
+	}
+	properties := registered.tool.InputSchema.Properties
+	if _, twoStep := properties[previewArgument]; twoStep {
+		return fmt.Errorf("%s takes a %s, which expires before a "+
+			"scheduled call runs", tool, previewArgument)
+	}
+	if unexpected := unexpectedArguments(registered.tool,
+		args); len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return fmt.Errorf("%s does not take %s", tool,
+			strings.Join(unexpected, ", "))
+	}
+	for name := range args {
+		if audit.SensitiveArgument(name) {
+			return fmt.Errorf("%s would be stored on disk; calls "+
+				"with secrets cannot be scheduled", name)
+		}
+	}
+	return nil
+}
+
+// resultMessage returns the message of an error result, or its text when
+// it is not structured.
+func resultMessage(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return "the call failed"
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		return "the call failed"
+	}
+
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(text.Text), &payload); err != nil ||
+		payload.Message == "" {
+		return text.Text
+	}
+	return payload.Message
+}
+
+// truncateProblem caps problem at maxProblemLength bytes.
+func truncateProblem(problem string) string {
+	if len(problem) <= maxProblemLength {
+		return problem
+	}
+	return problem[:maxProblemLength] + "..."
+}
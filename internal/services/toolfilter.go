@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// toolFilter decides which of the tools the configuration enables are
+// registered. Patterns use path.Match syntax, so "lnc_list_*" names every
+// listing tool.
+type toolFilter struct {
+	allow []string
+	deny  []string
+
+	// matched records the patterns that named at least one tool.
+	matched map[string]bool
+}
+
+// newToolFilter builds a filter from the configured allowlist and
+// denylist. Entries of the form @path name a file of further patterns.
+func newToolFilter(allow, deny []string) (*toolFilter, error) {
+	allowPatterns, err := expandToolPatterns(allow)
+	if err != nil {
+		return nil, fmt.Errorf("LNC_TOOL_ALLOWLIST: %w", err)
+	}
+	denyPatterns, err := expandToolPatterns(deny)
+	if err != nil {
+		return nil, fmt.Errorf("LNC_TOOL_DENYLIST: %w", err)
+	}
+
+	return &toolFilter{
+		allow:   allowPatterns,
+		deny:    denyPatterns,
+		matched: make(map[string]bool),
+	}, nil
+}
+
+// expandToolPatterns validates patterns and replaces each @path entry with
+// the patterns in that file: one or more per line, separated by commas,
+// with # starting a comment. A file that cannot be read is an error rather
+// than an empty list, since an empty allowlist allows every tool.
+func expandToolPatterns(entries []string) ([]string, error) {
+	var patterns []string
+	for _, entry := range entries {
+		file, ok := strings.CutPrefix(entry, "@")
+		if !ok {
+			patterns = append(patterns, entry)
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			for _, pattern := range strings.Split(line, ",") {
+				if pattern = strings.TrimSpace(pattern); pattern != "" {
+					patterns = append(patterns, pattern)
+				}
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w",
+				pattern, err)
+		}
+	}
+
+	return patterns, nil
+}
+
+// allows reports whether the named tool may be registered: it must match
+// the allowlist, when there is one, and must not match the denylist.
+func (f *toolFilter) allows(name string) bool {
+	if f == nil {
+		return true
+	}
+
+	allowed := len(f.allow) == 0
+	for _, pattern := range f.allow {
+		if f.match(pattern, name) {
+			allowed = true
+		}
+	}
+	for _, pattern := range f.deny {
+		if f.match(pattern, name) {
+			allowed = false
+		}
+	}
+
+	return allowed
+}
+
+// match reports whether pattern names the tool, recording that it did.
+func (f *toolFilter) match(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		f.matched[pattern] = true
+		return true
+	}
+	return false
+}
+
+// unmatched returns the patterns that named no tool the configuration
+// enables, sorted. They are usually typos.
+func (f *toolFilter) unmatched() []string {
+	if f == nil {
+		return nil
+	}
+
+	var unmatched []string
+	for _, patterns := range [][]string{f.allow, f.deny} {
+		for _, pattern := range patterns {
+			if !f.matched[pattern] {
+				unmatched = append(unmatched, pattern)
+			}
+		}
+	}
+	sort.Strings(unmatched)
+
+	return unmatched
+}
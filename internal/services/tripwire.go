@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// writeAmountArguments and writeDestinationArguments name the arguments
+// write tools use for the amount moved and where it goes, in order of
+// preference.
+var (
+	writeAmountArguments = []string{
+		"amount_sat", "amt", "amount", "lsp_balance_sat",
+	}
+	writeDestinationArguments = []string{
+		"address", "dest", "destination", "invoice", "payment_request", "pubkey",
+		"node_pubkey", "channel_point", "lsp",
+	}
+)
+
+// notifyFirstWrite emits a tripwire event the first time a write tool is
+// invoked in a client session, so operators notice unexpected automation.
+// Memos and invoices named as the destination are scrubbed as configured.
+// It returns whether the event was emitted.
+func (m *Manager) notifyFirstWrite(ctx context.Context, tool mcp.Tool,
+	args map[string]any) bool {
+	if !m.writeTools[tool.Name] {
+		return false
+	}
+
+	sessionID := lnccontext.GetSessionID(ctx)
+	if _, seen := m.writeSessions.LoadOrStore(sessionID, struct{}{}); seen {
+		return false
+	}
+
+	args = scrub.Arguments(m.cfg.MemoScrub, args)
+	entry := audit.Entry{
+		Event:         audit.EventFirstWrite,
+		Tool:          tool.Name,
+		MCPRequestID:  lnccontext.GetMCPRequestID(ctx),
+		ProgressToken: lnccontext.GetProgressToken(ctx),
+		SessionID:     sessionID,
+		TraceID:       lnccontext.GetTraceID(ctx),
+		Amount:        firstArgument(args, writeAmountArguments),
+	}
+	if destination := firstArgument(args,
+		writeDestinationArguments); destination != nil {
+		entry.Destination = fmt.Sprint(destination)
+	}
+
+	if err := m.audit.Log(entry); err != nil {
+		m.logger.Error("Failed to write audit entry", zap.Error(err))
+	}
+
+	m.logger.Named("operator").Warn(
+		"FIRST WRITE TOOL CALL IN SESSION",
+		zap.String("tool", tool.Name),
+		zap.Any("amount", entry.Amount),
+		zap.String("destination", entry.Destination),
+		zap.String("session_id", sessionID),
+		zap.String("trace_id", entry.TraceID),
+	)
+
+	return true
+}
+
+// firstArgument returns the value of the first of names present in args.
+func firstArgument(args map[string]any, names []string) any {
+	for _, name := range names {
+		if value, ok := args[name]; ok {
+			return value
+		}
+	}
+	return nil
+}
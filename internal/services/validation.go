@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// unexpectedArguments returns, sorted, the argument names that the tool's
+// input schema does not declare.
+func unexpectedArguments(tool mcp.Tool, args map[string]any) []string {
+	var unexpected []string
+	for name := range args {
+		if _, ok := tool.InputSchema.Properties[name]; !ok {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(unexpected)
+
+	return unexpected
+}
+
+// strictArgumentsError reports arguments rejected in strict mode.
+func strictArgumentsError(tool mcp.Tool, unexpected []string) *errors.Error {
+	return errors.ErrInvalidArgument(fmt.Sprintf(
+		"unexpected arguments for %s: %s", tool.Name,
+		strings.Join(unexpected, ", "))).WithDetails(map[string]any{
+		"unexpected_arguments": unexpected,
+	})
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+	"go.uber.org/zap"
+)
+
+// SetWatchlist enables watching external nodes, registering the watchlist
+// tools. It must be called after InitializeServices.
+func (m *Manager) SetWatchlist(list *watchlist.List) {
+	m.watchlist = list
+	m.watchService.List = list
+	m.backupService.Watchlist = list
+	m.watchService.OnChanges = m.reportWatchChanges
+}
+
+// WatchNodes checks the watched nodes every interval until ctx is done,
+// so their changes are raised as events even when no client asks for the
+// report. Checks are skipped while no node is connected or on standby.
+func (m *Manager) WatchNodes(ctx context.Context, interval time.Duration) {
+	if m.watchlist == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		if m.standby.Load() || len(m.watchlist.All()) == 0 {
+			continue
+		}
+		if client, _ := m.clients.Lightning(); client == nil {
+			continue
+		}
+
+		checkCtx := lnccontext.New(ctx, "watch_nodes", interval)
+		changes, err := m.watchService.Check(checkCtx)
+		checkCtx.Cancel()
+		if err != nil {
+			m.logger.Warn("Failed to check watched nodes",
+				zap.Int("changes", changes), zap.Error(err))
+		}
+	}
+}
+
+// reportWatchChanges raises each change found to a watched node through
+// the operator notification sinks: an audit event and a notice from the
+// operator logger.
+func (m *Manager) reportWatchChanges(ctx context.Context,
+	changes []map[string]any) {
+	for _, change := range changes {
+		entry := audit.Entry{
+			Event:   audit.EventWatchedNodeChanged,
+			TraceID: lnccontext.GetTraceID(ctx),
+			Details: change,
+		}
+		if err := m.audit.Log(entry); err != nil {
+			m.logger.Error("Failed to write audit entry",
+				zap.Error(err))
+		}
+
+		fields := []zap.Field{
+			zap.Any("pubkey", change["pubkey"]),
+			zap.Any("kind", change["kind"]),
+		}
+		if label := m.watchedNodeLabel(change); label != "" {
+			fields = append(fields, zap.String("contact", label))
+		}
+		if chanID, ok := change["chan_id"]; ok {
+			fields = append(fields, zap.Any("chan_id", chanID))
+		}
+		m.logger.Named("operator").Info("Watched node changed",
+			fields...)
+	}
+}
+
+// watchedNodeLabel names the node a change is for from the contact book,
+// or returns "" when it has no contact.
+func (m *Manager) watchedNodeLabel(change map[string]any) string {
+	pubkey, _ := change["pubkey"].(string)
+	contact, ok := m.contacts.Get(pubkey)
+	if !ok {
+		return ""
+	}
+	return contact.Label()
+}
@@ -0,0 +1,74 @@
+// Package signing lets downstream systems verify that a tool result came
+// from this server rather than being fabricated by the model relaying it.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Signing modes.
+const (
+	// ModeNone leaves tool results unsigned.
+	ModeNone = "none"
+
+	// ModeHMAC signs with HMAC-SHA256 under a shared key.
+	ModeHMAC = "hmac"
+
+	// ModeNode signs with the connected node's identity key via
+	// SignMessage, verifiable by anyone with the node's public key.
+	ModeNode = "node"
+)
+
+// Algorithm names reported in signatures.
+const (
+	AlgorithmHMAC = "HMAC-SHA256"
+	AlgorithmNode = "lnd-signmessage"
+)
+
+// Signature accompanies a signed tool result.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	Tool      string `json:"tool"`
+	SignedAt  string `json:"signed_at"`
+	Value     string `json:"value"`
+}
+
+// JSON returns the signature wrapped in a {"signature": ...} object, the
+// form appended to signed tool results.
+func (s *Signature) JSON() string {
+	data, err := json.Marshal(map[string]*Signature{"signature": s})
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// Message returns the bytes that are signed for a tool result: the tool
+// name, signing time and result text, separated by newlines.
+func Message(tool, signedAt, content string) []byte {
+	return []byte(strings.Join([]string{tool, signedAt, content}, "\n"))
+}
+
+// HMAC computes the hex-encoded HMAC-SHA256 of message under key.
+func HMAC(key, message []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMAC reports whether signature is the HMAC of message under key,
+// in constant time.
+func VerifyHMAC(key, message []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
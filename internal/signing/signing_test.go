@@ -0,0 +1,51 @@
+package signing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test HMAC signing round-trips and rejects tampering.
+func TestHMAC(t *testing.T) {
+	key := []byte("shared-secret")
+	message := Message("lnc_get_balance", "2025-01-01T00:00:00Z",
+		`{"confirmed_balance": 100000}`)
+
+	signature := HMAC(key, message)
+	assert.Len(t, signature, 64)
+	assert.True(t, VerifyHMAC(key, message, signature))
+
+	tampered := Message("lnc_get_balance", "2025-01-01T00:00:00Z",
+		`{"confirmed_balance": 900000}`)
+	assert.False(t, VerifyHMAC(key, tampered, signature))
+	assert.False(t, VerifyHMAC([]byte("other"), message, signature))
+	assert.False(t, VerifyHMAC(key, message, "not-hex"))
+}
+
+// Test that the tool and time are bound into the signed message.
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "lnc_get_info\n2025-01-01T00:00:00Z\n{}",
+		string(Message("lnc_get_info", "2025-01-01T00:00:00Z", "{}")))
+	assert.NotEqual(t,
+		Message("lnc_get_info", "2025-01-01T00:00:00Z", "{}"),
+		Message("lnc_get_balance", "2025-01-01T00:00:00Z", "{}"))
+}
+
+// Test the signature payload format.
+func TestSignature_JSON(t *testing.T) {
+	sig := &Signature{
+		Algorithm: AlgorithmHMAC,
+		Tool:      "lnc_get_info",
+		SignedAt:  "2025-01-01T00:00:00Z",
+		Value:     "abcd",
+	}
+
+	var payload map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(sig.JSON()), &payload))
+	assert.Equal(t, AlgorithmHMAC, payload["signature"]["algorithm"])
+	assert.Equal(t, "lnc_get_info", payload["signature"]["tool"])
+	assert.Equal(t, "abcd", payload["signature"]["value"])
+}
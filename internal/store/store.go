@@ -0,0 +1,200 @@
+// Package store holds what the server's local JSON stores, the operation
+// journal and the spend ledger, share: format versions with the migrations
+// between them, a checksum over the stored entries that tells a damaged or
+// hand-edited file from a good one, and atomic rewrites of the file.
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Migration upgrades a document from one format version to the next. It
+// is given the document's top-level fields and changes them in place.
+type Migration func(doc map[string]json.RawMessage) error
+
+// Format describes the file format of one store.
+type Format struct {
+	// Name is how errors refer to the store, such as "journal".
+	Name string
+
+	// Version is the format version this server writes.
+	Version int
+
+	// Entries names the field holding the store's entries, which the
+	// checksum covers.
+	Entries string
+
+	// Migrations upgrade a document from the version they are keyed by
+	// to the next one.
+	Migrations map[int]Migration
+}
+
+// Decode parses the document read from path, migrates it to the current
+// version and verifies the checksum of its entries. It returns the entries
+// and the version the document was stored at. Documents written by a newer
+// server are refused, since saving them would drop fields this server does
+// not understand.
+func (f Format) Decode(path string, data []byte) (json.RawMessage, int,
+	error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	var stored int
+	raw, ok := doc["version"]
+	if !ok {
+		return nil, 0, fmt.Errorf("%s %s has no format version",
+			f.Name, path)
+	}
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, 0, fmt.Errorf("%s %s has an invalid format "+
+			"version: %w", f.Name, path, err)
+	}
+	if stored > f.Version {
+		return nil, 0, fmt.Errorf("%s %s has format version %d, "+
+			"newer than this server's %d", f.Name, path, stored,
+			f.Version)
+	}
+
+	for version := stored; version < f.Version; version++ {
+		migrate, ok := f.Migrations[version]
+		if !ok {
+			return nil, 0, fmt.Errorf("%s %s has format version "+
+				"%d, which this server cannot migrate", f.Name,
+				path, stored)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to migrate %s %s "+
+				"from format version %d: %w", f.Name, path,
+				version, err)
+		}
+	}
+
+	var checksum string
+	if raw, ok := doc["checksum"]; ok {
+		if err := json.Unmarshal(raw, &checksum); err != nil {
+			return nil, 0, fmt.Errorf("%s %s has an invalid "+
+				"checksum: %w", f.Name, path, err)
+		}
+	}
+	entries := doc[f.Entries]
+	actual, err := rawChecksum(entries)
+	if err != nil {
+		return nil, 0, err
+	}
+	if actual != checksum {
+		return nil, 0, fmt.Errorf("%s %s fails its integrity check: "+
+			"its %s do not match their checksum", f.Name, path,
+			f.Entries)
+	}
+
+	return entries, stored, nil
+}
+
+// Checksum returns the checksum of entries as a document stores them.
+func Checksum(entries any) (string, error) {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return rawChecksum(data)
+}
+
+// AddChecksum returns the migration to the first format version with a
+// checksum, which records the checksum of the entries in the named field as
+// they are.
+func AddChecksum(entries string) Migration {
+	return func(doc map[string]json.RawMessage) error {
+		checksum, err := rawChecksum(doc[entries])
+		if err != nil {
+			return err
+		}
+		doc["checksum"], err = json.Marshal(checksum)
+		return err
+	}
+}
+
+// rawChecksum hashes encoded entries with their whitespace removed, so
+// indenting the document does not change it. Missing entries hash as null.
+func rawChecksum(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		raw = json.RawMessage("null")
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// WriteFile atomically replaces the file at path with data, so a crash
+// leaves either the old or the new file. The file is readable by its owner
+// only.
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path),
+		filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// RemoveTemp removes the temporary files WriteFile left next to path when
+// the process died mid-write, and returns how many it removed. The caller
+// must not be writing path at the same time.
+func RemoveTemp(path string) (int, error) {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path),
+		globEscape(filepath.Base(path))+".*.tmp"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// globEscape escapes the characters filepath.Match treats specially.
+func globEscape(name string) string {
+	var escaped bytes.Buffer
+	for _, r := range name {
+		switch r {
+		case '*', '?', '[', '\\':
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFormat is at version 3: version 2 added the checksum, and version 3
+// renamed the entries from "items" to "entries".
+var testFormat = Format{
+	Name:    "test store",
+	Version: 3,
+	Entries: "entries",
+	Migrations: map[int]Migration{
+		1: AddChecksum("items"),
+		2: func(doc map[string]json.RawMessage) error {
+			doc["entries"] = doc["items"]
+			delete(doc, "items")
+			return nil
+		},
+	},
+}
+
+// Test that documents are migrated one version at a time up to the
+// current one, and that current documents are read as they are.
+func TestFormat_Decode(t *testing.T) {
+	entries, version, err := testFormat.Decode("v1.json",
+		[]byte(`{"version": 1, "items": [1, 2]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.JSONEq(t, `[1, 2]`, string(entries))
+
+	checksum, err := Checksum([]int{1, 2})
+	require.NoError(t, err)
+	data, err := json.MarshalIndent(map[string]any{
+		"version":  3,
+		"checksum": checksum,
+		"entries":  []int{1, 2},
+	}, "", "  ")
+	require.NoError(t, err)
+	entries, version, err = testFormat.Decode("v3.json", data)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.JSONEq(t, `[1, 2]`, string(entries))
+}
+
+// Test that documents that cannot be read safely are refused.
+func TestFormat_DecodeRefused(t *testing.T) {
+	checksum, err := Checksum([]int{1, 2})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		doc  string
+		want string
+	}{
+		"unversioned": {`{"entries": []}`, "no format version"},
+		"newer":       {`{"version": 4}`, "newer than this server's"},
+		"unmigrated":  {`{"version": 0}`, "cannot migrate"},
+		"tampered": {`{"version": 3, "checksum": "` + checksum +
+			`", "entries": [1, 3]}`, "integrity check"},
+		"no_checksum": {`{"version": 3, "entries": [1, 2]}`,
+			"integrity check"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := testFormat.Decode(name, []byte(tt.doc))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+// Test that files are written owner-only, and that the temporary files of
+// interrupted writes are removed while others are left alone.
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store[1].json")
+	require.NoError(t, WriteFile(path, []byte(`{}`)))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	for _, name := range []string{"store[1].json.42.tmp", "other.tmp"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil,
+			0o600))
+	}
+	removed, err := RemoveTemp(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.FileExists(t, path)
+	assert.FileExists(t, filepath.Join(dir, "other.tmp"))
+}
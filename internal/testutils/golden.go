@@ -0,0 +1,89 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites golden files with the current output instead of
+// comparing against them. Run `go test ./tools -run TestGolden -update`
+// after an intentional output change and review the diff.
+var updateGolden = flag.Bool("update", false,
+	"rewrite golden files with the current output")
+
+// GoldenPlaceholder replaces the value of every volatile field, so results
+// that embed times, random IDs or test server URLs still compare equal.
+const GoldenPlaceholder = "VOLATILE"
+
+// goldenDir is where golden files live, relative to the package under test.
+const goldenDir = "testdata/golden"
+
+// CanonicalJSON re-encodes a JSON document in the form golden files are
+// stored in: two-space indentation, sorted keys and a trailing newline.
+// Fields named in volatile are replaced with GoldenPlaceholder at any depth.
+// Numbers are kept exactly as written.
+func CanonicalJSON(t testing.TB, data []byte, volatile ...string) []byte {
+	t.Helper()
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	require.NoError(t, decoder.Decode(&value), "invalid JSON: %s", data)
+
+	skip := make(map[string]bool, len(volatile))
+	for _, key := range volatile {
+		skip[key] = true
+	}
+	value = scrubVolatile(value, skip)
+
+	canonical, err := json.MarshalIndent(value, "", "  ")
+	require.NoError(t, err)
+
+	return append(canonical, '\n')
+}
+
+// scrubVolatile replaces the values of the named fields throughout value.
+func scrubVolatile(value any, volatile map[string]bool) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if volatile[key] {
+				value[key] = GoldenPlaceholder
+				continue
+			}
+			value[key] = scrubVolatile(field, volatile)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = scrubVolatile(item, volatile)
+		}
+	}
+	return value
+}
+
+// AssertGolden compares got with testdata/golden/<name>.golden. With the
+// -update flag it writes got to the file instead.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join(goldenDir, name+".golden")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(goldenDir, 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run the test with "+
+		"-update to create it")
+	require.Equal(t, string(want), string(got),
+		"output differs from %s; if the change is intended, rerun "+
+			"with -update and review the diff", path)
+}
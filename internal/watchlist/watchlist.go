@@ -0,0 +1,473 @@
+// Package watchlist keeps the external nodes the operator watches, such as
+// LSPs and large routing partners, with the last view of each the node's
+// channel graph gave, so changes to them can be reported as they are seen.
+package watchlist
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// fileVersion is the version of the watchlist's file format.
+const fileVersion = 1
+
+const (
+	// MaxNodes caps the watchlist, since every check looks each node up
+	// in the graph.
+	MaxNodes = 100
+
+	// MaxNoteLength caps a node's note, in characters.
+	MaxNoteLength = 200
+)
+
+// Kinds of change a check can find.
+const (
+	// ChangeDisappeared means the node is no longer in the graph, as when
+	// its last channel closed or its announcements went stale.
+	ChangeDisappeared = "disappeared"
+
+	// ChangeAppeared means a node that had disappeared is back in the
+	// graph.
+	ChangeAppeared = "appeared"
+
+	// ChangeAlias means the node announced a new alias.
+	ChangeAlias = "alias_changed"
+
+	// ChangeChannelOpened and ChangeChannelClosed mean a channel of the
+	// node was added to or removed from the graph.
+	ChangeChannelOpened = "channel_opened"
+	ChangeChannelClosed = "channel_closed"
+
+	// ChangePolicy means the node changed its routing policy on a
+	// channel, including announcing or disabling it.
+	ChangePolicy = "policy_changed"
+)
+
+// Policy is the routing policy a watched node set on one of its channels.
+type Policy struct {
+	FeeBaseMsat   int64  `json:"fee_base_msat"`
+	FeeRatePPM    int64  `json:"fee_rate_ppm"`
+	TimeLockDelta uint32 `json:"time_lock_delta"`
+	MinHTLCMsat   int64  `json:"min_htlc_msat"`
+	MaxHTLCMsat   uint64 `json:"max_htlc_msat"`
+	Disabled      bool   `json:"disabled"`
+}
+
+// Channel is a channel of a watched node as the graph shows it.
+type Channel struct {
+	Peer     string `json:"peer"`
+	Capacity int64  `json:"capacity"`
+
+	// Policy is the watched node's side of the channel, nil until it
+	// announces one.
+	Policy *Policy `json:"policy,omitempty"`
+}
+
+// Snapshot is what a check saw of a watched node.
+type Snapshot struct {
+	CheckedAt time.Time `json:"checked_at"`
+	InGraph   bool      `json:"in_graph"`
+	Alias     string    `json:"alias,omitempty"`
+
+	// Channels are keyed by channel ID.
+	Channels map[uint64]Channel `json:"channels,omitempty"`
+}
+
+// Capacity returns the total capacity of the node's channels.
+func (s Snapshot) Capacity() int64 {
+	var total int64
+	for _, channel := range s.Channels {
+		total += channel.Capacity
+	}
+	return total
+}
+
+// Change is a difference between two snapshots of a watched node.
+type Change struct {
+	Pubkey string
+	Kind   string
+
+	// ChanID, Peer and Capacity identify the channel of channel and
+	// policy changes.
+	ChanID   uint64
+	Peer     string
+	Capacity int64
+
+	// OldAlias and NewAlias are set for alias changes, and OldPolicy and
+	// NewPolicy for policy changes. A nil policy was not announced.
+	OldAlias  string
+	NewAlias  string
+	OldPolicy *Policy
+	NewPolicy *Policy
+}
+
+// Diff returns the changes from old to current: an alias change first, then
+// channel changes by kind and channel ID. A node seen for the first time
+// has no changes; its first snapshot is the baseline later ones are
+// compared to. Channel changes are not reported for a node that disappeared
+// or reappeared, since its channels leave and return with it.
+func Diff(pubkey string, old *Snapshot, current Snapshot) []Change {
+	if old == nil {
+		return nil
+	}
+
+	switch {
+	case old.InGraph && !current.InGraph:
+		return []Change{{Pubkey: pubkey, Kind: ChangeDisappeared}}
+	case !old.InGraph && current.InGraph:
+		return []Change{{Pubkey: pubkey, Kind: ChangeAppeared}}
+	case !current.InGraph:
+		return nil
+	}
+
+	var changes []Change
+	if old.Alias != current.Alias {
+		changes = append(changes, Change{
+			Pubkey:   pubkey,
+			Kind:     ChangeAlias,
+			OldAlias: old.Alias,
+			NewAlias: current.Alias,
+		})
+	}
+
+	var channelChanges []Change
+	for chanID, channel := range current.Channels {
+		change := Change{
+			Pubkey:    pubkey,
+			ChanID:    chanID,
+			Peer:      channel.Peer,
+			Capacity:  channel.Capacity,
+			NewPolicy: channel.Policy,
+		}
+		previous, existed := old.Channels[chanID]
+		switch {
+		case !existed:
+			change.Kind = ChangeChannelOpened
+		case !samePolicy(previous.Policy, channel.Policy):
+			change.Kind = ChangePolicy
+			change.OldPolicy = previous.Policy
+		default:
+			continue
+		}
+		channelChanges = append(channelChanges, change)
+	}
+	for chanID, channel := range old.Channels {
+		if _, ok := current.Channels[chanID]; ok {
+			continue
+		}
+		channelChanges = append(channelChanges, Change{
+			Pubkey:    pubkey,
+			Kind:      ChangeChannelClosed,
+			ChanID:    chanID,
+			Peer:      channel.Peer,
+			Capacity:  channel.Capacity,
+			OldPolicy: channel.Policy,
+		})
+	}
+	sort.Slice(channelChanges, func(i, j int) bool {
+		a, b := channelChanges[i], channelChanges[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ChanID < b.ChanID
+	})
+
+	return append(changes, channelChanges...)
+}
+
+// samePolicy reports whether two policies, either of which may be missing,
+// are the same.
+func samePolicy(a, b *Policy) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Node is a watched node.
+type Node struct {
+	Pubkey  string    `json:"pubkey"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+
+	// Snapshot is what the last check saw, nil until the node is first
+	// checked.
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+}
+
+// file is the on-disk form of the watchlist.
+type file struct {
+	Version int     `json:"version"`
+	Nodes   []*Node `json:"nodes"`
+}
+
+// List keeps the watched nodes in a JSON file, rewritten whole on every
+// change so a crash leaves either the old or the new list. A nil List
+// watches nothing and refuses changes.
+type List struct {
+	mu    sync.Mutex
+	path  string
+	nodes map[string]*Node
+}
+
+var (
+	// ErrDisabled is returned when a nil List is changed.
+	ErrDisabled = errors.New("watchlist is not enabled")
+
+	// ErrFull is returned when adding a node to a full watchlist.
+	ErrFull = fmt.Errorf("watchlist already has the maximum of %d "+
+		"nodes", MaxNodes)
+)
+
+// Open loads the watchlist at path, or starts an empty one if the file does
+// not exist yet. It is created with owner-only permissions on first write.
+func Open(path string) (*List, error) {
+	l := &List{
+		path:  path,
+		nodes: make(map[string]*Node),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Version > fileVersion {
+		return nil, fmt.Errorf("watchlist %s has format version %d, "+
+			"newer than this server's %d", path, stored.Version,
+			fileVersion)
+	}
+	for _, node := range stored.Nodes {
+		l.nodes[strings.ToLower(node.Pubkey)] = node
+	}
+
+	return l, nil
+}
+
+// Add watches a node, or updates the note of one already watched. It
+// returns the node as stored and whether it was newly added.
+func (l *List) Add(pubkey, note string) (Node, bool, error) {
+	if l == nil {
+		return Node{}, false, ErrDisabled
+	}
+	pubkey = strings.ToLower(strings.TrimSpace(pubkey))
+	if key, err := hex.DecodeString(pubkey); err != nil || len(key) != 33 {
+		return Node{}, false, fmt.Errorf("pubkey must be a 33-byte " +
+			"hex public key")
+	}
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return Node{}, false, fmt.Errorf("note must be at most %d "+
+			"characters", MaxNoteLength)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, existed := l.nodes[pubkey]
+	if !existed && len(l.nodes) >= MaxNodes {
+		return Node{}, false, ErrFull
+	}
+
+	node := &Node{Pubkey: pubkey, Note: note, AddedAt: time.Now().UTC()}
+	if existed {
+		node.AddedAt = previous.AddedAt
+		node.Snapshot = previous.Snapshot
+	}
+	l.nodes[pubkey] = node
+	if err := l.save(); err != nil {
+		if existed {
+			l.nodes[pubkey] = previous
+		} else {
+			delete(l.nodes, pubkey)
+		}
+		return Node{}, false, err
+	}
+	return *node, !existed, nil
+}
+
+// Remove stops watching a node, and reports whether it was watched.
+func (l *List) Remove(pubkey string) (bool, error) {
+	if l == nil {
+		return false, ErrDisabled
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	node, ok := l.nodes[pubkey]
+	if !ok {
+		return false, nil
+	}
+	delete(l.nodes, pubkey)
+	if err := l.save(); err != nil {
+		l.nodes[pubkey] = node
+		return false, err
+	}
+	return true, nil
+}
+
+// Get returns a watched node, and whether it is watched.
+func (l *List) Get(pubkey string) (Node, bool) {
+	if l == nil {
+		return Node{}, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	node, ok := l.nodes[strings.ToLower(pubkey)]
+	if !ok {
+		return Node{}, false
+	}
+	return *node, true
+}
+
+// All returns a copy of every watched node, ordered by when it was added
+// and then by pubkey.
+func (l *List) All() []Node {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.sorted()
+}
+
+// Import watches nodes restored from a backup, with their notes and
+// snapshots. Nodes already watched are left as they are, and nodes that
+// would take the list past MaxNodes are left out. It returns how many nodes
+// were added.
+func (l *List) Import(nodes []Node) (int, error) {
+	if l == nil {
+		return 0, ErrDisabled
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var added []string
+	for _, node := range nodes {
+		pubkey := strings.ToLower(node.Pubkey)
+		key, err := hex.DecodeString(pubkey)
+		if err != nil || len(key) != 33 {
+			continue
+		}
+		if _, ok := l.nodes[pubkey]; ok || len(l.nodes) >= MaxNodes {
+			continue
+		}
+		node.Pubkey = pubkey
+		l.nodes[pubkey] = &node
+		added = append(added, pubkey)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	if err := l.save(); err != nil {
+		for _, pubkey := range added {
+			delete(l.nodes, pubkey)
+		}
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// Record stores a new snapshot of a watched node and returns the changes
+// since the last one. A node removed while it was being checked is left
+// out, with no changes.
+func (l *List) Record(pubkey string, snapshot Snapshot) ([]Change,
+	error) {
+	if l == nil {
+		return nil, ErrDisabled
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	node, ok := l.nodes[pubkey]
+	if !ok {
+		return nil, nil
+	}
+
+	previous := node.Snapshot
+	node.Snapshot = &snapshot
+	if err := l.save(); err != nil {
+		node.Snapshot = previous
+		return nil, err
+	}
+	return Diff(pubkey, previous, snapshot), nil
+}
+
+// sorted returns a copy of every node in order. The caller holds mu.
+func (l *List) sorted() []Node {
+	nodes := make([]Node, 0, len(l.nodes))
+	for _, node := range l.nodes {
+		nodes = append(nodes, *node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if !nodes[i].AddedAt.Equal(nodes[j].AddedAt) {
+			return nodes[i].AddedAt.Before(nodes[j].AddedAt)
+		}
+		return nodes[i].Pubkey < nodes[j].Pubkey
+	})
+	return nodes
+}
+
+// save atomically replaces the watchlist file. The caller holds mu.
+func (l *List) save() error {
+	nodes := l.sorted()
+	stored := file{Version: fileVersion, Nodes: []*Node{}}
+	for i := range nodes {
+		stored.Nodes = append(stored.Nodes, &nodes[i])
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path),
+		filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), l.path)
+}
@@ -0,0 +1,222 @@
+package watchlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	lsp   = "02" + strings.Repeat("ab", 32)
+	peer  = "03" + strings.Repeat("cd", 32)
+	other = "02" + strings.Repeat("ef", 32)
+)
+
+// Test that watched nodes and their snapshots survive reopening the list,
+// as after a restart, so changes made while the server was down are still
+// reported.
+func TestList_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+
+	list, err := Open(path)
+	require.NoError(t, err)
+	assert.Empty(t, list.All())
+
+	node, created, err := list.Add(strings.ToUpper(lsp), "our LSP")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, lsp, node.Pubkey)
+	assert.Nil(t, node.Snapshot)
+
+	baseline := Snapshot{
+		InGraph: true,
+		Alias:   "lsp",
+		Channels: map[uint64]Channel{
+			1: {Peer: peer, Capacity: 1_000_000},
+		},
+	}
+	changes, err := list.Record(lsp, baseline)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	stored, ok := reopened.Get(lsp)
+	require.True(t, ok)
+	assert.Equal(t, "our LSP", stored.Note)
+	require.NotNil(t, stored.Snapshot)
+	assert.EqualValues(t, 1_000_000, stored.Snapshot.Capacity())
+
+	changes, err = reopened.Record(lsp, Snapshot{})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeDisappeared, changes[0].Kind)
+
+	// Updating the note keeps the snapshot.
+	node, created, err = reopened.Add(lsp, "former LSP")
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.NotNil(t, node.Snapshot)
+
+	removed, err := reopened.Remove(lsp)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = reopened.Remove(lsp)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	// A node removed while it was checked records nothing.
+	changes, err = reopened.Record(lsp, baseline)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Empty(t, reopened.All())
+}
+
+func TestList_Limits(t *testing.T) {
+	list, err := Open(filepath.Join(t.TempDir(), "watchlist.json"))
+	require.NoError(t, err)
+
+	_, _, err = list.Add("abc", "")
+	assert.Error(t, err)
+	_, _, err = list.Add(lsp, strings.Repeat("n", MaxNoteLength+1))
+	assert.Error(t, err)
+
+	for i := 0; i < MaxNodes; i++ {
+		_, _, err := list.Add(fmt.Sprintf("02%064x", i), "")
+		require.NoError(t, err)
+	}
+	_, _, err = list.Add(lsp, "")
+	assert.ErrorIs(t, err, ErrFull)
+
+	// Existing nodes can still be edited.
+	_, created, err := list.Add(fmt.Sprintf("02%064x", 0), "edited")
+	require.NoError(t, err)
+	assert.False(t, created)
+}
+
+func TestList_Nil(t *testing.T) {
+	var list *List
+	assert.Nil(t, list.All())
+	_, ok := list.Get(lsp)
+	assert.False(t, ok)
+	_, _, err := list.Add(lsp, "")
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = list.Remove(lsp)
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = list.Record(lsp, Snapshot{})
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestList_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+	require.NoError(t, os.WriteFile(path,
+		[]byte(`{"version": 2, "nodes": []}`), 0o600))
+
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "newer than this server's 1")
+}
+
+func TestDiff(t *testing.T) {
+	policy := &Policy{FeeBaseMsat: 1000, FeeRatePPM: 100,
+		TimeLockDelta: 80}
+	raised := &Policy{FeeBaseMsat: 1000, FeeRatePPM: 500,
+		TimeLockDelta: 80}
+
+	old := &Snapshot{
+		InGraph: true,
+		Alias:   "lsp",
+		Channels: map[uint64]Channel{
+			1: {Peer: peer, Capacity: 1_000_000, Policy: policy},
+			2: {Peer: other, Capacity: 2_000_000, Policy: policy},
+			3: {Peer: other, Capacity: 3_000_000},
+		},
+	}
+	current := Snapshot{
+		InGraph: true,
+		Alias:   "lsp ⚡",
+		Channels: map[uint64]Channel{
+			1: {Peer: peer, Capacity: 1_000_000, Policy: policy},
+			3: {Peer: other, Capacity: 3_000_000, Policy: raised},
+			4: {Peer: peer, Capacity: 4_000_000},
+		},
+	}
+
+	// The first snapshot is the baseline.
+	assert.Empty(t, Diff(lsp, nil, current))
+	assert.Empty(t, Diff(lsp, old, *old))
+
+	changes := Diff(lsp, old, current)
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, ChangeAlias, changes[0].Kind)
+	assert.Equal(t, "lsp", changes[0].OldAlias)
+	assert.Equal(t, "lsp ⚡", changes[0].NewAlias)
+
+	assert.Equal(t, ChangeChannelClosed, changes[1].Kind)
+	assert.EqualValues(t, 2, changes[1].ChanID)
+	assert.Equal(t, other, changes[1].Peer)
+	assert.Equal(t, policy, changes[1].OldPolicy)
+
+	assert.Equal(t, ChangeChannelOpened, changes[2].Kind)
+	assert.EqualValues(t, 4, changes[2].ChanID)
+	assert.EqualValues(t, 4_000_000, changes[2].Capacity)
+
+	// Announcing a policy is a policy change.
+	assert.Equal(t, ChangePolicy, changes[3].Kind)
+	assert.EqualValues(t, 3, changes[3].ChanID)
+	assert.Nil(t, changes[3].OldPolicy)
+	assert.Equal(t, raised, changes[3].NewPolicy)
+
+	// Leaving and returning to the graph hide the channel changes.
+	gone := Snapshot{}
+	changes = Diff(lsp, old, gone)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeDisappeared, changes[0].Kind)
+	assert.Empty(t, Diff(lsp, &gone, gone))
+	changes = Diff(lsp, &gone, current)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeAppeared, changes[0].Kind)
+}
+
+// Test that importing nodes from a backup adds only those not yet watched,
+// with their snapshots, and stops at the limit.
+func TestList_Import(t *testing.T) {
+	list, err := Open(filepath.Join(t.TempDir(), "watchlist.json"))
+	require.NoError(t, err)
+	_, _, err = list.Add(lsp, "our LSP")
+	require.NoError(t, err)
+
+	snapshot := &Snapshot{InGraph: true, Alias: "peer"}
+	added, err := list.Import([]Node{
+		{Pubkey: lsp, Note: "restored note"},
+		{Pubkey: strings.ToUpper(peer), Note: "partner",
+			Snapshot: snapshot},
+		{Pubkey: "abc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	node, _ := list.Get(lsp)
+	assert.Equal(t, "our LSP", node.Note)
+	node, ok := list.Get(peer)
+	require.True(t, ok)
+	assert.Equal(t, peer, node.Pubkey)
+	assert.Equal(t, snapshot, node.Snapshot)
+
+	var nodes []Node
+	for i := 0; i < MaxNodes; i++ {
+		nodes = append(nodes, Node{Pubkey: fmt.Sprintf("02%064x", i)})
+	}
+	added, err = list.Import(nodes)
+	require.NoError(t, err)
+	assert.Equal(t, MaxNodes-2, added)
+	assert.Len(t, list.All(), MaxNodes)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jbrill/mcp-lnc-server/internal/config"
+	"github.com/jbrill/mcp-lnc-server/internal/loadtest"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+)
+
+// defaultLoadMix weights the read tools roughly as an assistant uses them.
+const defaultLoadMix = "lnc_get_info=4,lnc_get_balance=3," +
+	"lnc_list_channels=3,lnc_list_invoices=2,lnc_list_payments=2," +
+	"lnc_pending_channels=1,lnc_list_peers=1,lnc_describe_graph=1," +
+	"lnc_list_unspent=1,lnc_estimate_fee=1"
+
+// runLoadTest implements the loadtest subcommand. The server is built from
+// the same environment as a normal run, so auditing and response signing
+// are included in what is measured.
+func runLoadTest(args []string) error {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	backend := flags.String("backend", "sim",
+		"Node to load: sim (in-memory) or regtest (over LNC, using "+
+			"LNC_PAIRING_PHRASE and LNC_PASSWORD)")
+	mixSpec := flags.String("mix", defaultLoadMix,
+		"Comma-separated tool=weight list of tools to call")
+	argsJSON := flags.String("args", "",
+		"JSON object mapping tool names to call arguments")
+	calls := flags.Int("calls", 1000,
+		"Total number of calls (ignored when -duration is set)")
+	duration := flags.Duration("duration", 0,
+		"Run for this long instead of a fixed number of calls")
+	concurrency := flags.Int("concurrency", 8,
+		"Number of concurrent callers")
+	seed := flags.Int64("seed", 1, "Seed for the order of calls")
+	simLatency := flags.Duration("sim-latency", 0,
+		"Latency added to every simulated RPC")
+	simChannels := flags.Int("sim-channels", 50,
+		"Channels, peers, invoices and payments on the simulated node")
+	mailbox := flags.String("mailbox", "",
+		"Mailbox server for the regtest backend")
+	jsonOutput := flags.Bool("json", false, "Print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	mix, err := loadtest.ParseMix(*mixSpec)
+	if err != nil {
+		return err
+	}
+	var toolArgs map[string]map[string]any
+	if *argsJSON != "" {
+		if err := json.Unmarshal([]byte(*argsJSON), &toolArgs); err != nil {
+			return fmt.Errorf("invalid -args: %w", err)
+		}
+	}
+	if *duration > 0 {
+		*calls = 0
+	}
+
+	// Per-call logs would swamp the report and skew the latencies.
+	if os.Getenv("LOG_LEVEL") == "" {
+		_ = os.Setenv("LOG_LEVEL", "warn")
+	}
+	cfg := config.LoadConfig()
+	if err := logging.InitLogger(cfg.Development); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer logging.Sync()
+
+	server, err := NewServer(cfg, logging.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			cfg.ShutdownTimeout)
+		defer cancel()
+		_ = server.Stop(ctx)
+	}()
+
+	ctx := context.Background()
+	caller := loadtest.NewMCPCaller(server.mcpServer)
+
+	switch *backend {
+	case "sim":
+		sim := &loadtest.SimNode{
+			Latency:  *simLatency,
+			Channels: *simChannels,
+		}
+		conn, stop, err := sim.Dial()
+		if err != nil {
+			return err
+		}
+		defer stop()
+		server.serviceManager.AttachConnection(conn)
+
+	case "regtest":
+		connectArgs := map[string]any{
+			"pairingPhrase": os.Getenv("LNC_PAIRING_PHRASE"),
+			"password":      os.Getenv("LNC_PASSWORD"),
+			"devMode":       true,
+			"insecure":      cfg.DefaultInsecure,
+		}
+		if *mailbox != "" {
+			connectArgs["mailbox"] = *mailbox
+		}
+		isError, err := caller.Call(ctx, "lnc_connect", connectArgs)
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to connect: %w", err)
+		case isError:
+			return fmt.Errorf("failed to connect; check " +
+				"LNC_PAIRING_PHRASE, LNC_PASSWORD and -mailbox")
+		}
+
+	default:
+		return fmt.Errorf("unknown backend %q: use sim or regtest",
+			*backend)
+	}
+
+	report, err := loadtest.Run(ctx, caller, loadtest.Config{
+		Mix:         mix,
+		Args:        toolArgs,
+		Concurrency: *concurrency,
+		Calls:       *calls,
+		Duration:    *duration,
+		Seed:        *seed,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteText(os.Stdout)
+}
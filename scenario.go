@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jbrill/mcp-lnc-server/internal/config"
+	"github.com/jbrill/mcp-lnc-server/internal/loadtest"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/internal/scenario"
+)
+
+// defaultScenarioChannels sizes the simulated node of scenarios that do not
+// size it themselves.
+const defaultScenarioChannels = 3
+
+// errScenariosFailed is returned when a scenario fails, after the report
+// has been written.
+var errScenariosFailed = fmt.Errorf("scenarios failed")
+
+// runScenarios implements the scenario subcommand. Each scenario runs
+// against a fresh server, built from the same environment as a normal run,
+// and its own simulated node, so scenarios cannot affect one another.
+func runScenarios(args []string) error {
+	flags := flag.NewFlagSet("scenario", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: mcp-lnc-server scenario "+
+			"[flags] <file or directory>...")
+		flags.PrintDefaults()
+	}
+	jsonOutput := flags.Bool("json", false, "Print the results as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no scenario files given")
+	}
+
+	files, err := scenario.Files(flags.Args())
+	if err != nil {
+		return err
+	}
+	scenarios := make([]*scenario.Scenario, len(files))
+	for i, file := range files {
+		scenarios[i], err = scenario.Load(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Server logs would bury the report.
+	if os.Getenv("LOG_LEVEL") == "" {
+		_ = os.Setenv("LOG_LEVEL", "warn")
+	}
+	cfg := config.LoadConfig()
+	if err := logging.InitLogger(cfg.Development); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	defer logging.Sync()
+
+	results := make([]scenario.Result, len(scenarios))
+	passed := true
+	for i, s := range scenarios {
+		results[i] = runScenario(cfg, s)
+		passed = passed && results[i].Passed
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(results)
+	} else {
+		err = scenario.WriteText(os.Stdout, results)
+	}
+	switch {
+	case err != nil:
+		return err
+	case !passed:
+		return errScenariosFailed
+	}
+	return nil
+}
+
+// runScenario runs one scenario against a new server and simulated node.
+func runScenario(cfg *config.Config, s *scenario.Scenario) scenario.Result {
+	failed := func(err error) scenario.Result {
+		return scenario.Result{Name: s.Name, Error: err.Error()}
+	}
+
+	server, err := NewServer(cfg, logging.Logger)
+	if err != nil {
+		return failed(fmt.Errorf("failed to create server: %w", err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			cfg.ShutdownTimeout)
+		defer cancel()
+		_ = server.Stop(ctx)
+	}()
+
+	sim := &loadtest.SimNode{
+		Latency:          s.Sim.Latency,
+		Channels:         s.Sim.Channels,
+		RecoveryProgress: s.Sim.RecoveryProgress,
+	}
+	if sim.Channels == 0 {
+		sim.Channels = defaultScenarioChannels
+	}
+	conn, stop, err := sim.Dial()
+	if err != nil {
+		return failed(err)
+	}
+	defer stop()
+	server.serviceManager.AttachConnection(conn)
+
+	caller := loadtest.NewMCPCaller(server.mcpServer)
+	return scenario.Run(context.Background(), caller, s)
+}
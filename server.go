@@ -5,11 +5,23 @@ package main
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/jbrill/mcp-lnc-server/internal/audit"
 	"github.com/jbrill/mcp-lnc-server/internal/config"
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
 	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/elicit"
+	"github.com/jbrill/mcp-lnc-server/internal/export"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/lease"
 	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
 	"github.com/jbrill/mcp-lnc-server/internal/services"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
 	"github.com/mark3labs/mcp-go/server"
 	"go.uber.org/zap"
 )
@@ -20,6 +32,25 @@ type Server struct {
 	logger         *zap.Logger
 	mcpServer      *server.MCPServer
 	serviceManager *services.Manager
+
+	// campaign runs the leader election until stopCampaign is called,
+	// and campaignDone is closed once it has released the lease. All
+	// are nil when running alone.
+	campaign     func()
+	stopCampaign context.CancelFunc
+	campaignDone chan struct{}
+
+	// watch checks the watched nodes in the background until
+	// stopWatching is called. Both are nil when there are no background
+	// checks.
+	watch        func()
+	stopWatching context.CancelFunc
+
+	// runSchedule runs the scheduled operations that are due until
+	// stopSchedule is called. Both are nil outside write mode or
+	// without a schedule.
+	runSchedule  func()
+	stopSchedule context.CancelFunc
 }
 
 // NewServer creates a new MCP server instance.
@@ -27,24 +58,172 @@ func NewServer(cfg *config.Config, logger *zap.Logger) (*Server, error) {
 	// Initialize context logger.
 	logging.InitContextLogger()
 
-	// Create MCP server.
-	mcpServer := server.NewMCPServer(cfg.ServerName, cfg.ServerVersion)
-
 	// Initialize service manager for read-only operations.
-	serviceManager := services.NewManager(logger)
+	serviceManager := services.NewManager(logger, cfg)
 	serviceManager.InitializeServices()
 
+	if cfg.AuditLogPath != "" {
+		auditLog, err := audit.Open(cfg.AuditLogPath)
+		if err != nil {
+			return nil, err
+		}
+		auditLog.SetRetention(audit.Retention{
+			MaxAge:   cfg.AuditRetention,
+			MaxBytes: int64(cfg.AuditMaxBytes),
+		}, func(result audit.PruneResult) {
+			logAuditPrune(logger, result)
+		})
+		result, err := auditLog.Prune()
+		if err != nil {
+			logger.Warn("Failed to prune audit log", zap.Error(err))
+		} else if result.Entries > 0 {
+			logAuditPrune(logger, result)
+		}
+		serviceManager.SetAuditLogger(auditLog)
+		logger.Info("Audit logging enabled",
+			zap.String("path", cfg.AuditLogPath),
+			zap.Duration("retention", cfg.AuditRetention),
+			zap.Int("max_bytes", cfg.AuditMaxBytes))
+	}
+
+	if cfg.JournalPath != "" {
+		operations, err := journal.Open(cfg.JournalPath)
+		if err != nil {
+			return nil, err
+		}
+		operations.SetRetention(cfg.JournalRetention)
+		serviceManager.SetJournal(operations)
+		logger.Info("Operation journal enabled",
+			zap.String("path", cfg.JournalPath),
+			zap.Int("in_flight", len(operations.InFlight())))
+	}
+
+	if cfg.ContactsPath != "" {
+		book, err := contacts.Open(cfg.ContactsPath)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetContacts(book)
+		logger.Info("Contact book enabled",
+			zap.String("path", cfg.ContactsPath),
+			zap.Int("contacts", len(book.All())))
+	}
+
+	if cfg.WatchlistPath != "" {
+		list, err := watchlist.Open(cfg.WatchlistPath)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetWatchlist(list)
+		logger.Info("Watchlist enabled",
+			zap.String("path", cfg.WatchlistPath),
+			zap.Int("nodes", len(list.All())),
+			zap.Duration("interval", cfg.WatchInterval))
+	}
+
+	if cfg.SchedulePath != "" {
+		operations, err := schedule.Open(cfg.SchedulePath)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetSchedule(operations)
+		logger.Info("Schedule enabled",
+			zap.String("path", cfg.SchedulePath),
+			zap.Int("due", len(operations.Due(time.Now()))),
+			zap.Duration("interval", cfg.ScheduleInterval))
+	}
+
+	if cfg.SpendLedgerPath != "" {
+		ledger, err := policy.OpenLedger(cfg.SpendLedgerPath)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetSpendLedger(ledger)
+		logger.Info("Spend ledger enabled",
+			zap.String("path", cfg.SpendLedgerPath),
+			zap.Int("spends", len(ledger.Spends())))
+	}
+
+	if cfg.BackupDir != "" {
+		if err := os.MkdirAll(cfg.BackupDir, 0o700); err != nil {
+			return nil, err
+		}
+		logger.Info("Server backups enabled",
+			zap.String("dir", cfg.BackupDir))
+	}
+
+	if cfg.ExportDir != "" {
+		if err := os.MkdirAll(cfg.ExportDir, 0o700); err != nil {
+			return nil, err
+		}
+		checkpoints, err := export.OpenCheckpoints(filepath.Join(
+			cfg.ExportDir, export.CheckpointsFile))
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetExportCheckpoints(checkpoints)
+		logger.Info("Record exports enabled",
+			zap.String("dir", cfg.ExportDir),
+			zap.Int("checkpoints", len(checkpoints.All())))
+	}
+
+	if cfg.LeasePath != "" {
+		leaderLease, err := lease.New(cfg.LeasePath, cfg.InstanceID,
+			cfg.LeaseTTL)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetLease(leaderLease)
+		logger.Info("Leader election enabled",
+			zap.String("path", cfg.LeasePath),
+			zap.String("instance", cfg.InstanceID),
+			zap.Duration("ttl", cfg.LeaseTTL))
+	}
+
+	// Create MCP server with hooks that correlate tool calls with their
+	// MCP request IDs.
+	mcpServer := server.NewMCPServer(cfg.ServerName, cfg.ServerVersion,
+		server.WithHooks(serviceManager.Hooks()))
+
 	// Register all tools with the MCP server.
 	if err := serviceManager.RegisterTools(mcpServer); err != nil {
 		return nil, err
 	}
 
-	return &Server{
+	s := &Server{
 		cfg:            cfg,
 		logger:         logger,
 		mcpServer:      mcpServer,
 		serviceManager: serviceManager,
-	}, nil
+	}
+	if cfg.LeasePath != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopCampaign = cancel
+		s.campaignDone = make(chan struct{})
+		s.campaign = func() {
+			defer close(s.campaignDone)
+			serviceManager.Campaign(ctx, cfg.LeaseTTL/3)
+		}
+	}
+
+	if cfg.WatchlistPath != "" && cfg.WatchInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopWatching = cancel
+		s.watch = func() {
+			serviceManager.WatchNodes(ctx, cfg.WatchInterval)
+		}
+	}
+
+	if cfg.SchedulePath != "" && cfg.WriteMode &&
+		cfg.ScheduleInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopSchedule = cancel
+		s.runSchedule = func() {
+			serviceManager.RunSchedule(ctx, cfg.ScheduleInterval)
+		}
+	}
+
+	return s, nil
 }
 
 // Start runs the MCP server and blocks until it is stopped.
@@ -57,7 +236,20 @@ func (s *Server) Start() error {
 		zap.String("server_name", s.cfg.ServerName),
 		zap.String("version", s.cfg.ServerVersion))
 
-	return server.ServeStdio(s.mcpServer)
+	if s.campaign != nil {
+		go s.campaign()
+	}
+	if s.watch != nil {
+		go s.watch()
+	}
+	if s.runSchedule != nil {
+		go s.runSchedule()
+	}
+
+	// mcp-go's ServeStdio cannot send requests to the client, so write
+	// tools could not ask for approval; elicit's transport serves the
+	// same protocol and can.
+	return elicit.ServeStdio(s.mcpServer, s.logger)
 }
 
 // Stop gracefully stops the MCP server.
@@ -68,6 +260,23 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	logger.Info("Stopping MCP server...")
 
+	// Hand the lease over before closing the connection, so a standby
+	// instance can take over at once.
+	if s.stopCampaign != nil {
+		s.stopCampaign()
+		select {
+		case <-s.campaignDone:
+		case <-reqCtx.Done():
+		}
+	}
+
+	if s.stopWatching != nil {
+		s.stopWatching()
+	}
+	if s.stopSchedule != nil {
+		s.stopSchedule()
+	}
+
 	// Shutdown the service manager.
 	if err := s.serviceManager.Shutdown(); err != nil {
 		logger.Error("Error shutting down service manager",
@@ -79,3 +288,11 @@ func (s *Server) Stop(ctx context.Context) error {
 		zap.Duration("shutdown_duration", reqCtx.Duration()))
 	return nil
 }
+
+// logAuditPrune reports the space pruning the audit log reclaimed.
+func logAuditPrune(logger *zap.Logger, result audit.PruneResult) {
+	logger.Info("Audit log pruned",
+		zap.Int("entries", result.Entries),
+		zap.Int64("reclaimed_bytes", result.Bytes),
+		zap.Int64("remaining_bytes", result.Remaining))
+}
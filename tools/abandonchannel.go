@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// abandonNetworks are the networks whose channels may be abandoned.
+var abandonNetworks = map[string]bool{"regtest": true, "simnet": true}
+
+// AbandonChannelTool returns the MCP tool definition for abandoning a
+// channel.
+func (s *ChannelService) AbandonChannelTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_abandon_channel",
+		Description: "Development only: make a regtest node forget a " +
+			"channel it holds, such as one stuck pending, without " +
+			"closing it on chain. Any funds in the channel are " +
+			"lost to the node. Refused on any network other than " +
+			"regtest and simnet",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Channel to abandon " +
+						"(funding_txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"pending_funding_shim_only": map[string]any{
+					"type": "boolean",
+					"description": "Only cancel a pending " +
+						"funding shim, leaving any " +
+						"channel alone",
+				},
+			},
+			Required: []string{"channel_point"},
+		},
+	}
+}
+
+// HandleAbandonChannel handles the abandon channel request. The node's
+// network is checked on every call, as the connection may have been replaced
+// since the tool was registered.
+func (s *ChannelService) HandleAbandonChannel(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	value, _ := args["channel_point"].(string)
+	txid, index, err := parseChannelPoint("channel_point", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	channelPoint := fmt.Sprintf("%s:%d", txid, index)
+	shimOnly, _ := args["pending_funding_shim_only"].(bool)
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	network := nodeNetwork(info)
+	if !abandonNetworks[network] {
+		return toolError(errors.New(errors.ErrCodePermissionDenied,
+			fmt.Sprintf("node is on %s; channels are only "+
+				"abandoned on regtest and simnet nodes",
+				network))), nil
+	}
+
+	state, err := s.channelState(ctx, client, channelPoint)
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+	if state == "" && !shimOnly {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"the node holds no open or pending channel with "+
+				"channel point "+channelPoint)), nil
+	}
+
+	// The node is known to be a development node, so the confirmation
+	// lnd asks of builds without its dev tag is given.
+	_, err = client.AbandonChannel(ctx, &lnrpc.AbandonChannelRequest{
+		ChannelPoint: &lnrpc.ChannelPoint{
+			FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+				FundingTxidStr: txid,
+			},
+			OutputIndex: index,
+		},
+		PendingFundingShimOnly: shimOnly,
+		IKnowWhatIAmDoing:      true,
+	})
+	if err != nil {
+		return rpcError(err, "failed to abandon channel"), nil
+	}
+
+	result := map[string]any{
+		"channel_point":             channelPoint,
+		"abandoned":                 true,
+		"pending_funding_shim_only": shimOnly,
+		"network":                   network,
+	}
+	if state != "" {
+		result["previous_state"] = state
+	}
+
+	return jsonResult("lnc_abandon_channel", result), nil
+}
+
+// channelState returns the state of the node's channel with the given
+// channel point: "open", "pending_open", "waiting_close" or
+// "pending_force_close", or "" if the node holds no such channel.
+func (s *ChannelService) channelState(ctx context.Context,
+	client lnrpc.LightningClient, channelPoint string) (string, error) {
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return "", err
+	}
+	for _, ch := range list.Channels {
+		if ch.ChannelPoint == channelPoint {
+			return "open", nil
+		}
+	}
+
+	pending, err := client.PendingChannels(ctx,
+		&lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return "", err
+	}
+	for _, ch := range pending.PendingOpenChannels {
+		if ch.Channel != nil && ch.Channel.ChannelPoint == channelPoint {
+			return "pending_open", nil
+		}
+	}
+	for _, ch := range pending.WaitingCloseChannels {
+		if ch.Channel != nil && ch.Channel.ChannelPoint == channelPoint {
+			return "waiting_close", nil
+		}
+	}
+	for _, ch := range pending.PendingForceClosingChannels {
+		if ch.Channel != nil && ch.Channel.ChannelPoint == channelPoint {
+			return "pending_force_close", nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// accountAddressTypes maps the address type argument to the wallet kit's
+// account address types. hybrid_np2wkh accounts hand out nested segwit
+// addresses but take change to native segwit ones.
+var accountAddressTypes = map[string]walletrpc.AddressType{
+	"p2wkh":         walletrpc.AddressType_WITNESS_PUBKEY_HASH,
+	"np2wkh":        walletrpc.AddressType_NESTED_WITNESS_PUBKEY_HASH,
+	"hybrid_np2wkh": walletrpc.AddressType_HYBRID_NESTED_WITNESS_PUBKEY_HASH,
+	"p2tr":          walletrpc.AddressType_TAPROOT_PUBKEY,
+}
+
+// ListAccountsTool returns the MCP tool definition for listing the wallet's
+// accounts.
+func (s *OnChainService) ListAccountsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_accounts",
+		Description: "List the on-chain wallet's accounts with their " +
+			"address type, BIP32 key scope and derivation path, " +
+			"extended public key, key counts and whether they " +
+			"are watch-only. Account names are what the account " +
+			"filters of lnc_list_unspent, lnc_get_transactions " +
+			"and lnc_new_address take",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type": "string",
+					"description": "Only list accounts " +
+						"with this name",
+				},
+				"address_type": map[string]any{
+					"type": "string",
+					"description": "Only list accounts " +
+						"of this address type",
+					"enum": []string{
+						"p2wkh", "np2wkh",
+						"hybrid_np2wkh", "p2tr",
+					},
+				},
+			},
+		},
+	}
+}
+
+// HandleListAccounts handles the list accounts request.
+func (s *OnChainService) HandleListAccounts(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	walletKit, generation := s.Clients.WalletKit()
+	if walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	name, _ := args["name"].(string)
+	typeArg, _ := args["address_type"].(string)
+	var addressType walletrpc.AddressType
+	if typeArg != "" {
+		lndType, ok := accountAddressTypes[typeArg]
+		if !ok {
+			return invalidArgumentError("address_type must be " +
+				"p2wkh, np2wkh, hybrid_np2wkh or p2tr"), nil
+		}
+		addressType = lndType
+	}
+
+	resp, err := walletKit.ListAccounts(ctx,
+		&walletrpc.ListAccountsRequest{
+			Name:        name,
+			AddressType: addressType,
+		})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to list accounts"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	accounts := make([]map[string]any, 0, len(resp.Accounts))
+	watchOnly := 0
+	for _, account := range resp.Accounts {
+		accounts = append(accounts, accountEntry(account))
+		if account.WatchOnly {
+			watchOnly++
+		}
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i]["name"].(string) <
+			accounts[j]["name"].(string)
+	})
+
+	return jsonResult("lnc_list_accounts", map[string]any{
+		"accounts":         accounts,
+		"count":            len(accounts),
+		"watch_only_count": watchOnly,
+	}), nil
+}
+
+// accountEntry formats a wallet account. The default imported account,
+// which holds individually imported keys, has no extended key or
+// derivation path.
+func accountEntry(account *walletrpc.Account) map[string]any {
+	entry := map[string]any{
+		"name":               account.Name,
+		"address_type":       accountTypeName(account.AddressType),
+		"external_key_count": account.ExternalKeyCount,
+		"internal_key_count": account.InternalKeyCount,
+		"watch_only":         account.WatchOnly,
+		"imported":           account.ExtendedPublicKey == "",
+	}
+	if account.ExtendedPublicKey != "" {
+		entry["extended_public_key"] = account.ExtendedPublicKey
+	}
+	if len(account.MasterKeyFingerprint) > 0 {
+		entry["master_key_fingerprint"] = hex.EncodeToString(
+			account.MasterKeyFingerprint)
+	}
+	if account.DerivationPath != "" {
+		entry["derivation_path"] = account.DerivationPath
+		if purpose, coinType, index, ok := parseAccountPath(
+			account.DerivationPath); ok {
+			entry["key_scope"] = fmt.Sprintf("%d'/%d'", purpose,
+				coinType)
+			entry["purpose"] = purpose
+			entry["coin_type"] = coinType
+			entry["account_index"] = index
+		}
+	}
+
+	return entry
+}
+
+// accountTypeName returns the address type argument naming an account
+// address type, or lnd's name for types without one.
+func accountTypeName(addressType walletrpc.AddressType) string {
+	for name, lndType := range accountAddressTypes {
+		if lndType == addressType {
+			return name
+		}
+	}
+	return strings.ToLower(addressType.String())
+}
+
+// parseAccountPath splits an account's BIP32 derivation path, such as
+// m/84'/0'/0', into its purpose, coin type and account index. Every step
+// of an account path is hardened.
+func parseAccountPath(path string) (uint32, uint32, uint32, bool) {
+	steps := strings.Split(path, "/")
+	if len(steps) != 4 || steps[0] != "m" {
+		return 0, 0, 0, false
+	}
+
+	var values [3]uint32
+	for i, step := range steps[1:] {
+		step, hardened := strings.CutSuffix(step, "'")
+		if !hardened {
+			return 0, 0, 0, false
+		}
+		value, err := strconv.ParseUint(step, 10, 31)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		values[i] = uint32(value)
+	}
+
+	return values[0], values[1], values[2], true
+}
@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// invoiceTerms are the invoice arguments lnc_add_invoice and
+// lnc_add_hold_invoice share.
+type invoiceTerms struct {
+	value           int64
+	valueMsat       int64
+	memo            string
+	descriptionHash []byte
+	expiry          int64
+	private         bool
+	routeHints      []*lnrpc.RouteHint
+}
+
+// parseInvoiceTerms parses and validates the shared invoice arguments.
+func parseInvoiceTerms(args map[string]any) (*invoiceTerms, error) {
+	terms := &invoiceTerms{expiry: defaultInvoiceExpiry}
+
+	amountSat, hasSat := args["amount_sat"].(float64)
+	amountMsat, hasMsat := args["amount_msat"].(float64)
+	switch {
+	case hasSat && hasMsat:
+		return nil, fmt.Errorf("set either amount_sat or " +
+			"amount_msat, not both")
+	case amountSat < 0 || amountMsat < 0:
+		return nil, fmt.Errorf("the amount must not be negative")
+	case hasMsat:
+		terms.valueMsat = int64(amountMsat)
+	default:
+		terms.value = int64(amountSat)
+	}
+
+	terms.memo, _ = args["memo"].(string)
+	if len(terms.memo) > maxInvoiceMemo {
+		return nil, fmt.Errorf("memo must be at most %d bytes",
+			maxInvoiceMemo)
+	}
+	if value, ok := args["description_hash"].(string); ok {
+		if terms.memo != "" {
+			return nil, fmt.Errorf("set either memo or " +
+				"description_hash, not both")
+		}
+		hash, err := parseHash("description_hash", value)
+		if err != nil {
+			return nil, err
+		}
+		terms.descriptionHash = hash
+	}
+
+	if expiry, ok := args["expiry_seconds"].(float64); ok {
+		if expiry < 1 || expiry > maxInvoiceExpiry {
+			return nil, fmt.Errorf("expiry_seconds must be between "+
+				"1 and %d", maxInvoiceExpiry)
+		}
+		terms.expiry = int64(expiry)
+	}
+	terms.private, _ = args["private"].(bool)
+
+	hints, err := parseRouteHints(args["route_hints"])
+	if err != nil {
+		return nil, err
+	}
+	terms.routeHints = hints
+
+	return terms, nil
+}
+
+// amountMsat returns the invoice amount in millisatoshis.
+func (t *invoiceTerms) amountMsat() int64 {
+	if t.valueMsat != 0 {
+		return t.valueMsat
+	}
+	return t.value * 1000
+}
+
+// AddInvoiceTool returns the MCP tool definition for creating an invoice.
+func (s *InvoiceService) AddInvoiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_add_invoice",
+		Description: "Create a BOLT11 invoice for receiving a " +
+			"payment. The node generates and keeps the preimage. " +
+			"Set amp for an AMP invoice, which payers split into " +
+			"independently routed shards and can pay more than once",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount in satoshis; omit " +
+						"for an invoice the payer chooses " +
+						"the amount of",
+					"minimum": 0,
+				},
+				"amount_msat": map[string]any{
+					"type": "number",
+					"description": "Amount in millisatoshis, " +
+						"instead of amount_sat",
+					"minimum": 0,
+				},
+				"memo": map[string]any{
+					"type":        "string",
+					"description": "Description shown to the payer",
+					"maxLength":   maxInvoiceMemo,
+				},
+				"description_hash": map[string]any{
+					"type": "string",
+					"description": "SHA-256 hash of a longer " +
+						"description, instead of memo " +
+						"(hex encoded)",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+				"expiry_seconds": map[string]any{
+					"type": "number",
+					"description": "Seconds until the invoice " +
+						"expires (default 86400)",
+					"minimum": 1,
+					"maximum": maxInvoiceExpiry,
+				},
+				"private": map[string]any{
+					"type": "boolean",
+					"description": "Add route hints for the " +
+						"node's private channels",
+				},
+				"route_hints": map[string]any{
+					"type": "array",
+					"description": "Route hints to embed, in " +
+						"the form lnc_decode_invoice " +
+						"reports them",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"hop_hints": map[string]any{
+								"type": "array",
+								"items": map[string]any{
+									"type": "object",
+								},
+							},
+						},
+						"required": []string{"hop_hints"},
+					},
+				},
+				"amp": map[string]any{
+					"type": "boolean",
+					"description": "Create an AMP invoice, which " +
+						"payers must pay with AMP (default false)",
+				},
+			},
+		},
+	}
+}
+
+// HandleAddInvoice handles the add invoice request.
+func (s *InvoiceService) HandleAddInvoice(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	terms, err := parseInvoiceTerms(args)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	amp, _ := args["amp"].(bool)
+
+	resp, err := client.AddInvoice(ctx, &lnrpc.Invoice{
+		Memo:            terms.memo,
+		Value:           terms.value,
+		ValueMsat:       terms.valueMsat,
+		DescriptionHash: terms.descriptionHash,
+		Expiry:          terms.expiry,
+		Private:         terms.private,
+		RouteHints:      terms.routeHints,
+		IsAmp:           amp,
+	})
+	if err != nil {
+		return rpcError(err, "failed to add invoice"), nil
+	}
+
+	result := map[string]any{
+		"payment_request":  resp.PaymentRequest,
+		"payment_hash":     hex.EncodeToString(resp.RHash),
+		"payment_addr":     hex.EncodeToString(resp.PaymentAddr),
+		"add_index":        resp.AddIndex,
+		"state":            lnrpc.Invoice_OPEN.String(),
+		"value_msat":       terms.amountMsat(),
+		"expiry_seconds":   terms.expiry,
+		"private":          terms.private,
+		"route_hint_count": len(terms.routeHints),
+		"amp":              amp,
+	}
+	if amp {
+		result["note"] = "Payers must pay this invoice with AMP, " +
+			"such as lnc_pay_invoice with amp set. Each payment " +
+			"to it is settled under its own set ID and hashes, " +
+			"so payment_hash does not identify the payments"
+	}
+
+	return jsonResult("lnc_add_invoice", result), nil
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addressNetwork pairs a network name, as lnd reports it, with its params.
+type addressNetwork struct {
+	name   string
+	params *chaincfg.Params
+}
+
+// addressNetworks lists the networks an address is checked against.
+var addressNetworks = []addressNetwork{
+	{name: "mainnet", params: &chaincfg.MainNetParams},
+	{name: "testnet", params: &chaincfg.TestNet3Params},
+	{name: "signet", params: &chaincfg.SigNetParams},
+	{name: "regtest", params: &chaincfg.RegressionNetParams},
+	{name: "simnet", params: &chaincfg.SimNetParams},
+}
+
+// networkParams returns the params of a network as lnd names it.
+func networkParams(network string) (*chaincfg.Params, bool) {
+	for _, known := range addressNetworks {
+		if known.name == network {
+			return known.params, true
+		}
+	}
+	return nil, false
+}
+
+// addressType returns a short name for the script type behind an address.
+func addressType(addr btcutil.Address) string {
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return "P2PKH"
+	case *btcutil.AddressScriptHash:
+		return "P2SH"
+	case *btcutil.AddressWitnessPubKeyHash:
+		return "P2WPKH"
+	case *btcutil.AddressWitnessScriptHash:
+		return "P2WSH"
+	case *btcutil.AddressTaproot:
+		return "P2TR"
+	case *btcutil.AddressPubKey:
+		return "P2PK"
+	default:
+		return "unknown"
+	}
+}
+
+// decodeAddress decodes an address against every known network. Testnet,
+// signet and regtest share base58 version bytes, and testnet and signet
+// share the "tb" bech32 prefix, so more than one network may match.
+func decodeAddress(address string) (btcutil.Address, []string, error) {
+	var (
+		decoded  btcutil.Address
+		networks []string
+		lastErr  error
+	)
+	for _, network := range addressNetworks {
+		addr, err := btcutil.DecodeAddress(address, network.params)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !addr.IsForNet(network.params) {
+			continue
+		}
+		decoded = addr
+		networks = append(networks, network.name)
+	}
+
+	if decoded == nil {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("address does not match any network")
+		}
+		return nil, nil, lastErr
+	}
+
+	return decoded, networks, nil
+}
+
+// ValidateAddressTool returns the MCP tool definition for validating
+// on-chain addresses.
+func (s *OnChainService) ValidateAddressTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_validate_address",
+		Description: "Validate a Bitcoin address and report its type " +
+			"and network",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"address": map[string]any{
+					"type":        "string",
+					"description": "Bitcoin address to validate",
+				},
+			},
+			Required: []string{"address"},
+		},
+	}
+}
+
+// HandleValidateAddress handles the validate address request. Validation
+// is local; the node is only consulted to check for a network mismatch.
+func (s *OnChainService) HandleValidateAddress(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	address, ok := request.Params.Arguments["address"].(string)
+	address = strings.TrimSpace(address)
+	if !ok || address == "" {
+		return invalidArgumentError("address is required"), nil
+	}
+
+	addr, networks, err := decodeAddress(address)
+	if err != nil {
+		return jsonResult("lnc_validate_address", map[string]any{
+			"address": address,
+			"valid":   false,
+			"error":   err.Error(),
+		}), nil
+	}
+
+	var connectedNetwork, warning string
+	if client, _ := s.Clients.Lightning(); client != nil {
+		info, err := client.GetInfo(ctx,
+			&lnrpc.GetInfoRequest{})
+		if err == nil {
+			connectedNetwork = nodeNetwork(info)
+		}
+	}
+	if connectedNetwork != "" &&
+		!slices.Contains(networks, connectedNetwork) {
+		warning = fmt.Sprintf("address is for %s but the connected "+
+			"node is on %s", strings.Join(networks, "/"),
+			connectedNetwork)
+	}
+
+	return jsonResult("lnc_validate_address", map[string]any{
+		"address":          addr.String(),
+		"valid":            true,
+		"type":             addressType(addr),
+		"networks":         networks,
+		"node_network":     connectedNetwork,
+		"network_mismatch": warning != "",
+		"warning":          warning,
+	}), nil
+}
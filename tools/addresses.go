@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultListedAddresses and maxListedAddresses bound how many
+	// addresses one call lists.
+	defaultListedAddresses = 500
+	maxListedAddresses     = 10_000
+)
+
+// walletAddress is a wallet address with the derivation index lnd's
+// unordered listing is sorted by.
+type walletAddress struct {
+	entry  map[string]any
+	change bool
+	index  int64
+}
+
+// ListAddressesTool returns the MCP tool definition for listing the wallet's
+// addresses.
+func (s *OnChainService) ListAddressesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_addresses",
+		Description: "List every address the on-chain wallet has " +
+			"derived or imported, grouped by account, with its " +
+			"balance, whether it is a change address and its " +
+			"derivation path, to audit the receive addresses " +
+			"handed out over time. Account totals cover all " +
+			"addresses even when the list is cut short",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"account": map[string]any{
+					"type": "string",
+					"description": "Only list this " +
+						"account's addresses",
+				},
+				"include_change": map[string]any{
+					"type": "boolean",
+					"description": "Also list change " +
+						"addresses (default true)",
+				},
+				"only_funded": map[string]any{
+					"type": "boolean",
+					"description": "Only list addresses " +
+						"with a balance",
+				},
+				"max_addresses": map[string]any{
+					"type": "number",
+					"description": "Maximum number of " +
+						"addresses to list " +
+						"(default 500)",
+					"minimum": 1,
+					"maximum": maxListedAddresses,
+				},
+			},
+		},
+	}
+}
+
+// HandleListAddresses handles the list addresses request.
+func (s *OnChainService) HandleListAddresses(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	walletKit, generation := s.Clients.WalletKit()
+	if walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	account, _ := args["account"].(string)
+	includeChange := true
+	if value, ok := args["include_change"].(bool); ok {
+		includeChange = value
+	}
+	onlyFunded, _ := args["only_funded"].(bool)
+	limit, _ := args["max_addresses"].(float64)
+	switch {
+	case limit < 0 || limit > maxListedAddresses ||
+		limit != math.Trunc(limit):
+		return invalidArgumentError(fmt.Sprintf("max_addresses must "+
+			"be a whole number between 1 and %d",
+			maxListedAddresses)), nil
+	case limit == 0:
+		limit = defaultListedAddresses
+	}
+
+	resp, err := walletKit.ListAddresses(ctx,
+		&walletrpc.ListAddressesRequest{AccountName: account})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to list addresses"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	var listed, total, funded int
+	var totalBalance int64
+	truncated := false
+	accounts := make([]map[string]any, 0,
+		len(resp.AccountWithAddresses))
+	for _, group := range resp.AccountWithAddresses {
+		var balance int64
+		addresses := make([]walletAddress, 0, len(group.Addresses))
+		for _, address := range group.Addresses {
+			balance += address.Balance
+			if address.Balance > 0 {
+				funded++
+			}
+			if (address.IsInternal && !includeChange) ||
+				(onlyFunded && address.Balance == 0) {
+				continue
+			}
+			addresses = append(addresses,
+				walletAddressEntry(address))
+		}
+		total += len(group.Addresses)
+		totalBalance += balance
+
+		// Receive addresses come first, each branch in the order
+		// it was derived.
+		sort.SliceStable(addresses, func(i, j int) bool {
+			a, b := addresses[i], addresses[j]
+			if a.change != b.change {
+				return !a.change
+			}
+			return a.index < b.index
+		})
+		entries := make([]map[string]any, 0, len(addresses))
+		for _, address := range addresses {
+			if listed == int(limit) {
+				truncated = true
+				break
+			}
+			entries = append(entries, address.entry)
+			listed++
+		}
+
+		entry := map[string]any{
+			"name":          group.Name,
+			"address_type":  accountTypeName(group.AddressType),
+			"address_count": len(group.Addresses),
+			"balance_sat":   balance,
+			"addresses":     entries,
+		}
+		if group.DerivationPath != "" {
+			entry["derivation_path"] = group.DerivationPath
+		}
+		accounts = append(accounts, entry)
+	}
+
+	return jsonResult("lnc_list_addresses", map[string]any{
+		"accounts":          accounts,
+		"listed_addresses":  listed,
+		"total_addresses":   total,
+		"funded_addresses":  funded,
+		"total_balance_sat": totalBalance,
+		"truncated":         truncated,
+	}), nil
+}
+
+// walletAddressEntry formats a wallet address. Imported addresses have no
+// derivation path or public key, and sort after derived ones.
+func walletAddressEntry(address *walletrpc.AddressProperty) walletAddress {
+	entry := map[string]any{
+		"address":     address.Address,
+		"change":      address.IsInternal,
+		"balance_sat": address.Balance,
+	}
+	index := int64(math.MaxInt64)
+	if address.DerivationPath != "" {
+		entry["derivation_path"] = address.DerivationPath
+		steps := strings.Split(address.DerivationPath, "/")
+		last := steps[len(steps)-1]
+		if value, err := strconv.ParseUint(last, 10, 31); err == nil {
+			index = int64(value)
+			entry["index"] = value
+		}
+	}
+	if len(address.PublicKey) > 0 {
+		entry["public_key"] = hex.EncodeToString(address.PublicKey)
+	}
+
+	return walletAddress{
+		entry:  entry,
+		change: address.IsInternal,
+		index:  index,
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// aliasStartHeight is the block height lnd's SCID aliases start at. No real
+// short channel ID has a block that high, so it tells aliases apart.
+const aliasStartHeight = 16_000_000
+
+// ListAliasesTool returns the MCP tool definition for listing SCID aliases.
+func (s *ChannelService) ListAliasesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_aliases",
+		Description: "List the SCID aliases of the node's channels: " +
+			"for each base SCID, the confirmed short channel ID " +
+			"or, for a zero-conf channel, its first alias, every " +
+			"alias stored for it, and the open channel it " +
+			"belongs to. Zero-conf and option_scid_alias " +
+			"channels are routed and invoiced under these " +
+			"aliases",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"chan_id": map[string]any{
+					"type":        "string",
+					"description": "Only list the mapping with this base SCID or alias, in decimal or as a short channel ID such as 800000x1234x0",
+				},
+			},
+		},
+	}
+}
+
+// HandleListAliases handles the list aliases request.
+func (s *ChannelService) HandleListAliases(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	var filter uint64
+	value, _ := request.Params.Arguments["chan_id"].(string)
+	if value != "" {
+		chanID, err := parseChanID("chan_id", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		filter = chanID
+	}
+
+	resp, err := client.ListAliases(ctx, &lnrpc.ListAliasesRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list aliases"), nil
+	}
+	channels, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	// Every SCID a channel is known by points at it.
+	byScid := make(map[uint64]*lnrpc.Channel)
+	for _, ch := range channels.Channels {
+		byScid[ch.ChanId] = ch
+		if ch.ZeroConfConfirmedScid != 0 {
+			byScid[ch.ZeroConfConfirmedScid] = ch
+		}
+		for _, alias := range ch.AliasScids {
+			byScid[alias] = ch
+		}
+	}
+
+	aliasMaps := append([]*lnrpc.AliasMap(nil), resp.AliasMaps...)
+	sort.Slice(aliasMaps, func(i, j int) bool {
+		return aliasMaps[i].BaseScid < aliasMaps[j].BaseScid
+	})
+
+	maps := make([]map[string]any, 0, len(aliasMaps))
+	for _, aliasMap := range aliasMaps {
+		scids := append([]uint64{aliasMap.BaseScid},
+			aliasMap.Aliases...)
+		if filter != 0 && !containsScid(scids, filter) {
+			continue
+		}
+
+		aliases := make([]map[string]any, len(aliasMap.Aliases))
+		for i, alias := range aliasMap.Aliases {
+			aliases[i] = scidMap(alias)
+		}
+		entry := map[string]any{
+			"base_scid":     scidMap(aliasMap.BaseScid),
+			"aliases":       aliases,
+			"alias_count":   len(aliases),
+			"base_is_alias": isAliasScid(aliasMap.BaseScid),
+		}
+
+		var channel *lnrpc.Channel
+		for _, scid := range scids {
+			if ch, ok := byScid[scid]; ok {
+				channel = ch
+				break
+			}
+		}
+		if channel != nil {
+			entry["channel"] = aliasChannelMap(channel)
+		}
+		maps = append(maps, entry)
+	}
+
+	return jsonResult("lnc_list_aliases", map[string]any{
+		"alias_maps": maps,
+		"count":      len(maps),
+	}), nil
+}
+
+// aliasChannelMap describes the open channel an alias mapping belongs to.
+func aliasChannelMap(ch *lnrpc.Channel) map[string]any {
+	channel := map[string]any{
+		"channel_point": ch.ChannelPoint,
+		"chan_id":       strconv.FormatUint(ch.ChanId, 10),
+		"remote_pubkey": ch.RemotePubkey,
+		"active":        ch.Active,
+		"private":       ch.Private,
+		"zero_conf":     ch.ZeroConf,
+	}
+	if ch.ZeroConfConfirmedScid != 0 {
+		channel["confirmed_scid"] = scidMap(ch.ZeroConfConfirmedScid)
+	}
+	if ch.PeerScidAlias != 0 {
+		channel["peer_scid_alias"] = scidMap(ch.PeerScidAlias)
+	}
+	return channel
+}
+
+// scidMap formats a short channel ID in lnd's decimal form and as block x
+// transaction x output.
+func scidMap(scid uint64) map[string]any {
+	return map[string]any{
+		"chan_id":          strconv.FormatUint(scid, 10),
+		"short_channel_id": formatShortChanID(scid),
+	}
+}
+
+// isAliasScid reports whether a short channel ID is one of lnd's aliases
+// rather than a position in the chain.
+func isAliasScid(scid uint64) bool {
+	return scid>>40 >= aliasStartHeight
+}
+
+// containsScid reports whether scids holds scid.
+func containsScid(scids []uint64, scid uint64) bool {
+	for _, candidate := range scids {
+		if candidate == scid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"encoding/hex"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/zpay32"
+)
+
+// isAMPInvoice reports whether a decoded invoice must be paid with AMP.
+func isAMPInvoice(invoice *zpay32.Invoice) bool {
+	return invoice.Features != nil &&
+		invoice.Features.HasFeature(lnwire.AMPOptional)
+}
+
+// addAMPFields adds the set ID of an AMP payment and its child payments,
+// one per HTLC attempt, to the payment's result fields. Payments without
+// AMP records are left as they are. The root share is a payment secret and
+// is left out.
+func addAMPFields(fields map[string]any, payment *lnrpc.Payment) {
+	var (
+		setID    string
+		children []map[string]any
+	)
+	for _, htlc := range payment.Htlcs {
+		hops := htlc.GetRoute().GetHops()
+		if len(hops) == 0 {
+			continue
+		}
+		last := hops[len(hops)-1]
+		if last.AmpRecord == nil {
+			continue
+		}
+
+		setID = hex.EncodeToString(last.AmpRecord.SetId)
+		children = append(children, map[string]any{
+			"child_index": last.AmpRecord.ChildIndex,
+			"attempt_id":  htlc.AttemptId,
+			"status":      htlc.Status.String(),
+			"amount_msat": last.AmtToForwardMsat,
+		})
+	}
+	if children == nil {
+		return
+	}
+
+	fields["amp"] = true
+	fields["set_id"] = setID
+	fields["child_payments"] = children
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// pseudonymBytes is the length of the pseudonyms that replace node and
+// channel identifiers: long enough not to collide across the graph.
+const pseudonymBytes = 16
+
+// graphStripped lists what the anonymized graph leaves out, for the
+// result's own description of it.
+var graphStripped = []string{
+	"pub_key", "alias", "color", "addresses", "features",
+	"node last_update", "channel_id", "chan_point",
+	"policy last_update", "custom_records", "unannounced channels",
+}
+
+// newGraphSalt returns a random salt, used when none is configured.
+func newGraphSalt() []byte {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic("failed to generate graph salt: " + err.Error())
+	}
+	return salt
+}
+
+// AnonymizedGraphTool returns the MCP tool definition for exporting the
+// network graph with its nodes and channels pseudonymized.
+func (s *PeerService) AnonymizedGraphTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_export_anonymized_graph",
+		Description: "Export the public network graph for sharing " +
+			"with researchers or posting publicly: every node " +
+			"and channel, with pubkeys and channel IDs replaced " +
+			"by salted hashes and aliases, colors, addresses, " +
+			"features and update times stripped. Channel " +
+			"capacities and fee policies are kept, so large or " +
+			"distinctive nodes may still be recognised from the " +
+			"topology",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"mark_own_node": map[string]any{
+					"type": "boolean",
+					"description": "Add own_node_id, this " +
+						"node's pseudonym, for local " +
+						"analysis. Leave it off when " +
+						"sharing the export",
+				},
+			},
+		},
+	}
+}
+
+// HandleAnonymizedGraph handles the anonymized graph request. Pseudonyms
+// are keyed with the server's salt, so they are stable across exports made
+// with the same salt, but cannot be reversed or recomputed without it.
+func (s *PeerService) HandleAnonymizedGraph(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	markOwn, _ := request.Params.Arguments["mark_own_node"].(bool)
+
+	graph, err := client.DescribeGraph(ctx, &lnrpc.ChannelGraphRequest{})
+	if err != nil {
+		return rpcError(err, "failed to describe graph"), nil
+	}
+
+	channelCounts := make(map[string]int, len(graph.Nodes))
+	edges := make([]map[string]any, len(graph.Edges))
+	for i, edge := range graph.Edges {
+		node1 := s.pseudonym("node", edge.Node1Pub)
+		node2 := s.pseudonym("node", edge.Node2Pub)
+		channelCounts[node1]++
+		channelCounts[node2]++
+
+		edges[i] = map[string]any{
+			"id": s.pseudonym("channel",
+				strconv.FormatUint(edge.ChannelId, 10)),
+			"node1":        node1,
+			"node2":        node2,
+			"capacity":     edge.Capacity,
+			"node1_policy": anonymizedPolicy(edge.Node1Policy),
+			"node2_policy": anonymizedPolicy(edge.Node2Policy),
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i]["id"].(string) < edges[j]["id"].(string)
+	})
+
+	nodes := make([]map[string]any, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		id := s.pseudonym("node", node.PubKey)
+		nodes[i] = map[string]any{
+			"id":            id,
+			"channel_count": channelCounts[id],
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i]["id"].(string) < nodes[j]["id"].(string)
+	})
+
+	result := map[string]any{
+		"nodes":      nodes,
+		"edges":      edges,
+		"node_count": len(nodes),
+		"edge_count": len(edges),
+		"anonymization": map[string]any{
+			"method":   "hmac-sha256",
+			"id_bytes": pseudonymBytes,
+			"stripped": graphStripped,
+		},
+	}
+
+	if markOwn {
+		info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get node info"), nil
+		}
+		result["own_node_id"] = s.pseudonym("node",
+			info.IdentityPubkey)
+		result["note"] = "own_node_id identifies this node in the " +
+			"export; remove it before sharing"
+	}
+
+	return jsonResult("lnc_export_anonymized_graph", result), nil
+}
+
+// pseudonym keys an identifier of a kind with the graph salt. The kind
+// keeps a node and a channel with the same identifier apart.
+func (s *PeerService) pseudonym(kind, value string) string {
+	mac := hmac.New(sha256.New, s.GraphSalt)
+	mac.Write([]byte(kind + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil)[:pseudonymBytes])
+}
+
+// anonymizedPolicy formats a routing policy without its update time, which
+// would help match the channel to the public graph. Nodes that have not
+// announced a policy have none.
+func anonymizedPolicy(policy *lnrpc.RoutingPolicy) map[string]any {
+	if policy == nil {
+		return nil
+	}
+	formatted := routingPolicyMap(policy)
+	delete(formatted, "last_update")
+	return formatted
+}
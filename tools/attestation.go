@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Server modes an attestation reports.
+const (
+	// ModeReadOnly means no tool can change node state or move funds.
+	ModeReadOnly = "read_only"
+
+	// ModeWriteEnabled means write tools are registered.
+	ModeWriteEnabled = "write_enabled"
+)
+
+// Attestation is what the server asserts about its own context in every
+// tool result, so reviewers of a transcript can check which mode and node
+// a result came from without trusting the assistant's account of it.
+type Attestation struct {
+	Mode string `json:"mode"`
+
+	// DryRun is set when the call was a write tool redirected to the
+	// sandbox regtest node rather than the connected node.
+	DryRun bool `json:"dry_run"`
+
+	ConnectionStatus ConnectionStatus `json:"connection_status"`
+
+	// Network and NodePubkey identify the connected node, and are left
+	// out while none is connected.
+	Network    string `json:"network,omitempty"`
+	NodePubkey string `json:"node_pubkey,omitempty"`
+}
+
+// CurrentAttestation returns the attestation for a call served by clients
+// in a server of the given mode, naming the node those clients reach.
+func CurrentAttestation(writeEnabled, dryRun bool,
+	clients *ClientProvider) Attestation {
+	state := clients.State()
+	attestation := Attestation{
+		Mode:             ModeReadOnly,
+		DryRun:           dryRun,
+		ConnectionStatus: state.Status(),
+	}
+	if writeEnabled {
+		attestation.Mode = ModeWriteEnabled
+	}
+	attestation.NodePubkey, attestation.Network = state.Node()
+
+	return attestation
+}
+
+// Attest adds attestation to a tool result under "attestation". Results
+// and errors alike are JSON objects; text that is not one is left as it
+// is.
+func Attest(result *mcp.CallToolResult, attestation Attestation) error {
+	if result == nil {
+		return nil
+	}
+
+	for i, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader([]byte(text.Text)))
+		decoder.UseNumber()
+
+		var payload map[string]any
+		if err := decoder.Decode(&payload); err != nil || payload == nil {
+			return nil
+		}
+		payload["attestation"] = attestation
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		text.Text = string(data)
+		result.Content[i] = text
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxBatchChannels bounds the channels one funding transaction opens.
+const maxBatchChannels = 20
+
+// batchChannel holds the validated arguments of one channel in a batch.
+type batchChannel struct {
+	nodePubkey string
+	pubkey     []byte
+	amountSat  int64
+	pushSat    int64
+	private    bool
+}
+
+// BatchOpenChannelsTool returns the MCP tool definition for opening several
+// channels in one funding transaction.
+func (s *ChannelService) BatchOpenChannelsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_batch_open_channels",
+		Description: "Open channels to several connected peers, funded " +
+			"by a single on-chain transaction, which costs less in " +
+			"fees than opening them one by one. Either every " +
+			"channel opens or none does. Returns once the funding " +
+			"transaction is broadcast, with its estimated fee",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channels": map[string]any{
+					"type":        "array",
+					"description": "Channels to open, one per peer",
+					"minItems":    1,
+					"maxItems":    maxBatchChannels,
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"node_pubkey": map[string]any{
+								"type":    "string",
+								"pattern": "^[0-9a-fA-F]{66}$",
+							},
+							"amount_sat": map[string]any{
+								"type":    "number",
+								"minimum": minChannelSizeSat,
+							},
+							"push_sat": map[string]any{
+								"type":    "number",
+								"minimum": 0,
+							},
+							"private": map[string]any{
+								"type": "boolean",
+							},
+						},
+						"required": []string{
+							"node_pubkey", "amount_sat",
+						},
+					},
+				},
+				"sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Funding fee rate; cannot be " +
+						"combined with target_conf",
+					"minimum": 1,
+				},
+				"target_conf": map[string]any{
+					"type": "number",
+					"description": "Confirmation target used to " +
+						"pick the funding fee rate (default 6)",
+					"minimum": 1,
+					"maximum": 1008,
+				},
+				"min_confs": map[string]any{
+					"type": "number",
+					"description": "Confirmations required of " +
+						"the UTXOs that fund the channels; 0 " +
+						"spends unconfirmed outputs (default 1)",
+					"minimum": 0,
+				},
+				"label": map[string]any{
+					"type": "string",
+					"description": "Wallet label for the " +
+						"funding transaction",
+					"maxLength": maxTxLabelLength,
+				},
+			},
+			Required: []string{"channels"},
+		},
+	}
+}
+
+// parseBatchChannels validates the channels of a batch open. A peer may
+// appear only once, as lnd negotiates each channel with its peer by pubkey.
+func parseBatchChannels(value any) ([]batchChannel, error) {
+	items, _ := value.([]any)
+	if len(items) == 0 || len(items) > maxBatchChannels {
+		return nil, fmt.Errorf("channels must list 1 to %d channels",
+			maxBatchChannels)
+	}
+
+	channels := make([]batchChannel, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("channels[%d] must be an object",
+				i)
+		}
+
+		nodePubkey, _ := fields["node_pubkey"].(string)
+		name := fmt.Sprintf("channels[%d].node_pubkey", i)
+		pubkey, err := parsePubkey(name, nodePubkey)
+		if err != nil {
+			return nil, err
+		}
+		nodePubkey = strings.ToLower(nodePubkey)
+		if seen[nodePubkey] {
+			return nil, fmt.Errorf("channels[%d] opens a second "+
+				"channel to %s; a batch opens one channel per "+
+				"peer", i, nodePubkey)
+		}
+		seen[nodePubkey] = true
+
+		amountSat, _ := fields["amount_sat"].(float64)
+		if amountSat < minChannelSizeSat {
+			return nil, fmt.Errorf("channels[%d].amount_sat must "+
+				"be at least %d", i, minChannelSizeSat)
+		}
+		pushSat, _ := fields["push_sat"].(float64)
+		if pushSat < 0 || pushSat >= amountSat {
+			return nil, fmt.Errorf("channels[%d].push_sat must be "+
+				"at least 0 and less than amount_sat", i)
+		}
+		private, _ := fields["private"].(bool)
+
+		channels = append(channels, batchChannel{
+			nodePubkey: nodePubkey,
+			pubkey:     pubkey,
+			amountSat:  int64(amountSat),
+			pushSat:    int64(pushSat),
+			private:    private,
+		})
+	}
+
+	return channels, nil
+}
+
+// HandleBatchOpenChannels handles the batch open channels request. The fee
+// of the funding transaction is estimated first, and the estimate is
+// reported with the channels once lnd has published it.
+func (s *ChannelService) HandleBatchOpenChannels(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	channels, err := parseBatchChannels(args["channels"])
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	satPerVbyte, _ := args["sat_per_vbyte"].(float64)
+	targetConf, _ := args["target_conf"].(float64)
+	switch {
+	case satPerVbyte > 0 && targetConf > 0:
+		return invalidArgumentError("set either target_conf or " +
+			"sat_per_vbyte, not both"), nil
+	case satPerVbyte == 0 && targetConf == 0:
+		targetConf = defaultSendTargetConf
+	}
+	fundingRate, failure := checkFeeRate(ctx, s.Clients, s.Policy,
+		uint64(satPerVbyte), int32(targetConf))
+	if failure != nil {
+		return failure, nil
+	}
+
+	minConfs := float64(1)
+	if value, ok := args["min_confs"].(float64); ok {
+		minConfs = value
+	}
+	if minConfs < 0 {
+		return invalidArgumentError("min_confs must not be negative"), nil
+	}
+
+	label, _ := args["label"].(string)
+	label = strings.TrimSpace(label)
+	if len(label) > maxTxLabelLength {
+		return invalidArgumentError(fmt.Sprintf("label must be at "+
+			"most %d characters", maxTxLabelLength)), nil
+	}
+
+	estimate, err := s.estimateBatchFee(ctx, client, channels,
+		int32(targetConf), int32(minConfs))
+	if err != nil {
+		return rpcError(err, "failed to estimate the funding fee"), nil
+	}
+
+	if fundingRate > 0 {
+		targetConf = 0
+	}
+
+	var pushSat int64
+	batch := make([]*lnrpc.BatchOpenChannel, len(channels))
+	for i, ch := range channels {
+		batch[i] = &lnrpc.BatchOpenChannel{
+			NodePubkey:         ch.pubkey,
+			LocalFundingAmount: ch.amountSat,
+			PushSat:            ch.pushSat,
+			Private:            ch.private,
+		}
+		pushSat += ch.pushSat
+	}
+
+	// What is pushed to the peers is spent; the rest of the funding
+	// stays ours.
+	spendID := fmt.Sprintf("batch_open:%d", time.Now().UnixNano())
+	if pushSat > 0 {
+		err := s.Policy.ReserveSpend(spendID,
+			"lnc_batch_open_channels", pushSat)
+		if err != nil {
+			return policyError(err), nil
+		}
+	}
+	resp, err := client.BatchOpenChannel(ctx,
+		&lnrpc.BatchOpenChannelRequest{
+			Channels:         batch,
+			TargetConf:       int32(targetConf),
+			SatPerVbyte:      int64(fundingRate),
+			MinConfs:         int32(minConfs),
+			SpendUnconfirmed: minConfs == 0,
+			Label:            label,
+		})
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return rpcError(err, "failed to open channels"), nil
+	}
+	if len(resp.PendingChannels) != len(channels) {
+		return toolError(errors.New(errors.ErrCodeRPCFailed,
+			fmt.Sprintf("node reported %d pending channels for a "+
+				"batch of %d", len(resp.PendingChannels),
+				len(channels)))), nil
+	}
+
+	var (
+		fundingTxid string
+		feeRate     int64
+		totalSat    int64
+		opened      = make([]map[string]any, len(channels))
+	)
+	for i, pending := range resp.PendingChannels {
+		ch := channels[i]
+		txid, err := chainhash.NewHash(pending.Txid)
+		if err != nil {
+			return toolError(errors.Wrap(err,
+				errors.ErrCodeRPCFailed,
+				"invalid funding txid from node")), nil
+		}
+		fundingTxid = txid.String()
+		feeRate = pending.FeePerVbyte
+		totalSat += ch.amountSat
+
+		point := fmt.Sprintf("%s:%d", txid, pending.OutputIndex)
+		logJournalError(ctx, s.Journal.Begin(journal.KindChannelOpen,
+			point, "lnc_batch_open_channels", map[string]any{
+				"node_pubkey": ch.nodePubkey,
+				"amount_sat":  ch.amountSat,
+			}))
+
+		opened[i] = map[string]any{
+			"node_pubkey":   ch.nodePubkey,
+			"amount_sat":    ch.amountSat,
+			"push_sat":      ch.pushSat,
+			"private":       ch.private,
+			"output_index":  pending.OutputIndex,
+			"channel_point": point,
+		}
+	}
+	notifyProgress(ctx, 1, fmt.Sprintf("%d channels pending in %s",
+		len(channels), fundingTxid))
+
+	// The estimate was made at the rate lnd picked then; scale it to the
+	// rate the published transaction pays.
+	feeSat := estimate.FeeSat
+	if feeRate > 0 && estimate.SatPerVbyte > 0 &&
+		uint64(feeRate) != estimate.SatPerVbyte {
+		rate := int64(estimate.SatPerVbyte)
+		feeSat = (feeSat*feeRate + rate - 1) / rate
+	}
+
+	result := map[string]any{
+		"status":            "pending",
+		"funding_txid":      fundingTxid,
+		"channel_count":     len(channels),
+		"total_amount_sat":  totalSat,
+		"estimated_fee_sat": feeSat,
+		"channels":          opened,
+		"message": "The funding transaction is published; follow " +
+			"the channels with lnc_pending_channels",
+	}
+	if feeRate > 0 {
+		result["sat_per_vbyte"] = feeRate
+	}
+	if label != "" {
+		result["label"] = label
+	}
+
+	return jsonResult("lnc_batch_open_channels", result), nil
+}
+
+// estimateBatchFee estimates the fee of a funding transaction for the
+// channels. lnd estimates fees for payments to addresses, so each channel is
+// stood in for by a P2WSH address of its own, whose output is the size of a
+// funding output. With a fixed fee rate, targetConf is 0 and the default
+// target is used; the caller scales the estimate to the rate paid.
+func (s *ChannelService) estimateBatchFee(ctx context.Context,
+	client lnrpc.LightningClient, channels []batchChannel, targetConf,
+	minConfs int32) (*lnrpc.EstimateFeeResponse, error) {
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return nil, err
+	}
+	network := nodeNetwork(info)
+	params, ok := networkParams(network)
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q", network)
+	}
+
+	outputs := make(map[string]int64, len(channels))
+	for _, ch := range channels {
+		witness := sha256.Sum256(append([]byte("funding:"), ch.pubkey...))
+		addr, err := btcutil.NewAddressWitnessScriptHash(witness[:],
+			params)
+		if err != nil {
+			return nil, err
+		}
+		outputs[addr.EncodeAddress()] = ch.amountSat
+	}
+
+	if targetConf == 0 {
+		targetConf = defaultSendTargetConf
+	}
+	return client.EstimateFee(ctx, &lnrpc.EstimateFeeRequest{
+		AddrToAmount:     outputs,
+		TargetConf:       targetConf,
+		MinConfs:         minConfs,
+		SpendUnconfirmed: minConfs == 0,
+	})
+}
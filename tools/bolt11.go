@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
+	"github.com/lightningnetwork/lnd/zpay32"
+)
+
+// bolt11Network pairs a BOLT11 human-readable prefix with its chain params.
+type bolt11Network struct {
+	prefix string
+	name   string
+	params *chaincfg.Params
+}
+
+// bolt11Networks lists the known invoice prefixes. Longer prefixes come
+// first because "bc" is a prefix of "bcrt" and "tb" is a prefix of "tbs".
+var bolt11Networks = []bolt11Network{
+	{prefix: "lnbcrt", name: "regtest", params: &chaincfg.RegressionNetParams},
+	{prefix: "lntbs", name: "signet", params: &chaincfg.SigNetParams},
+	{prefix: "lnbc", name: "mainnet", params: &chaincfg.MainNetParams},
+	{prefix: "lntb", name: "testnet", params: &chaincfg.TestNet3Params},
+	{prefix: "lnsb", name: "simnet", params: &chaincfg.SimNetParams},
+}
+
+// normalizeBolt11 strips whitespace and an optional "lightning:" URI scheme
+// and lower-cases the invoice, since QR codes often carry it upper-cased.
+func normalizeBolt11(invoice string) string {
+	invoice = strings.ToLower(strings.TrimSpace(invoice))
+	return strings.TrimPrefix(invoice, "lightning:")
+}
+
+// detectBolt11Network returns the network an invoice was issued for based on
+// its human-readable prefix.
+func detectBolt11Network(invoice string) (bolt11Network, bool) {
+	for _, network := range bolt11Networks {
+		if strings.HasPrefix(invoice, network.prefix) {
+			return network, true
+		}
+	}
+	return bolt11Network{}, false
+}
+
+// decodeBolt11 fully decodes an invoice with zpay32, validating the
+// human-readable part, bech32 checksum, signature and required fields.
+func decodeBolt11(invoice string) (*zpay32.Invoice, bolt11Network, error) {
+	invoice = normalizeBolt11(invoice)
+
+	network, ok := detectBolt11Network(invoice)
+	if !ok {
+		return nil, bolt11Network{}, fmt.Errorf("unknown invoice " +
+			"prefix, expected lnbc, lntb, lntbs, lnbcrt or lnsb")
+	}
+
+	decoded, err := zpay32.Decode(invoice, network.params)
+	if err != nil {
+		return nil, network, err
+	}
+
+	return decoded, network, nil
+}
+
+// scrubPaymentRequest scrubs a BOLT11 payment request as mode requires when
+// it carries a description, since anyone can decode the memo from it. One
+// that cannot be decoded is scrubbed too, as it may still carry one.
+func scrubPaymentRequest(mode, paymentRequest string) string {
+	if !scrub.Enabled(mode) || paymentRequest == "" {
+		return paymentRequest
+	}
+
+	decoded, _, err := decodeBolt11(paymentRequest)
+	if err == nil && (decoded.Description == nil ||
+		*decoded.Description == "") {
+		return paymentRequest
+	}
+	return scrub.Text(mode, paymentRequest)
+}
+
+// isValidBolt11 reports whether the string is a well-formed BOLT11 invoice
+// for any known network.
+func isValidBolt11(invoice string) bool {
+	_, _, err := decodeBolt11(invoice)
+	return err == nil
+}
@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxRouteHops is the most hops lnd puts in a route, the onion's limit.
+const maxRouteHops = 20
+
+// buildRouteNote explains what to do with a built route.
+const buildRouteNote = "Pass route to lnc_send_to_route with the " +
+	"invoice's payment_hash to pay along it. Each hop's fee_msat and " +
+	"timelock_delta are what that hop's node charges to forward to the " +
+	"next; the final hop charges nothing"
+
+// BuildRouteTool returns the MCP tool definition for building a route
+// through given nodes.
+func (s *PaymentService) BuildRouteTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_build_route",
+		Description: "Build a route through the given nodes, in " +
+			"order, without pathfinding or sending anything, and " +
+			"show what each hop charges in fees and time lock. " +
+			"lnd picks the channel between each pair of nodes " +
+			"from the graph. The route can be paid with " +
+			"lnc_send_to_route",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"hop_pubkeys": map[string]any{
+					"type": "array",
+					"description": "Public keys of " +
+						"the nodes to pass, in " +
+						"order, ending with the " +
+						"destination and leaving " +
+						"out this node",
+					"items": map[string]any{
+						"type":    "string",
+						"pattern": "^[0-9a-fA-F]{66}$",
+					},
+					"minItems": 1,
+					"maxItems": maxRouteHops,
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to " +
+						"deliver, in satoshis " +
+						"(default the smallest the " +
+						"route can carry)",
+					"minimum": 0,
+				},
+				"final_cltv_delta": map[string]any{
+					"type": "number",
+					"description": "CLTV delta for the final " +
+						"hop (default lnd's, 80 blocks)",
+					"minimum": 0,
+				},
+				"outgoing_chan_id": map[string]any{
+					"type": "string",
+					"description": "Channel of this node the " +
+						"route must leave through",
+				},
+				"payment_addr": map[string]any{
+					"type": "string",
+					"description": "Payment address from the " +
+						"invoice (hex encoded), added to " +
+						"the final hop",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+			},
+			Required: []string{"hop_pubkeys"},
+		},
+	}
+}
+
+// HandleBuildRoute handles the build route request.
+func (s *PaymentService) HandleBuildRoute(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	req, err := buildRouteRequest(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	resp, err := router.BuildRoute(ctx, req)
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to build route"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	route := queriedRoute(resp.Route)
+	hops, _ := route["hops"].([]map[string]any)
+	incoming := resp.Route.TotalTimeLock
+	for i, hop := range resp.Route.Hops {
+		hops[i]["timelock_delta"] = incoming - hop.Expiry
+		incoming = hop.Expiry
+		if hop.MppRecord != nil {
+			hops[i]["mpp_record"] = map[string]any{
+				"payment_addr": hex.EncodeToString(
+					hop.MppRecord.PaymentAddr),
+				"total_amt_msat": hop.MppRecord.TotalAmtMsat,
+			}
+		}
+	}
+
+	return jsonResult("lnc_build_route", map[string]any{
+		"route": route,
+		"note":  buildRouteNote,
+	}), nil
+}
+
+// buildRouteRequest builds the BuildRoute request from the tool's
+// arguments.
+func buildRouteRequest(args map[string]any) (*routerrpc.BuildRouteRequest,
+	error) {
+	pubkeys, _ := args["hop_pubkeys"].([]any)
+	if len(pubkeys) == 0 || len(pubkeys) > maxRouteHops {
+		return nil, fmt.Errorf("hop_pubkeys must list between 1 and "+
+			"%d nodes", maxRouteHops)
+	}
+	req := &routerrpc.BuildRouteRequest{}
+	for i, value := range pubkeys {
+		text, _ := value.(string)
+		pubkey, err := parsePubkey(fmt.Sprintf("hop_pubkeys[%d]", i),
+			text)
+		if err != nil {
+			return nil, err
+		}
+		req.HopPubkeys = append(req.HopPubkeys, pubkey)
+	}
+
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < 0 || amountSat != math.Trunc(amountSat) ||
+		amountSat > math.MaxInt64/1000 {
+		return nil, fmt.Errorf("amount_sat must be a whole number of " +
+			"satoshis")
+	}
+	req.AmtMsat = int64(amountSat) * 1000
+
+	finalCltvDelta, _ := args["final_cltv_delta"].(float64)
+	if finalCltvDelta < 0 || finalCltvDelta > math.MaxUint16 ||
+		finalCltvDelta != math.Trunc(finalCltvDelta) {
+		return nil, fmt.Errorf("final_cltv_delta must be a whole " +
+			"number of blocks")
+	}
+	req.FinalCltvDelta = int32(finalCltvDelta)
+
+	if value, ok := args["outgoing_chan_id"].(string); ok {
+		chanID, err := parseChanID("outgoing_chan_id", value)
+		if err != nil {
+			return nil, err
+		}
+		req.OutgoingChanId = chanID
+	}
+	if value, ok := args["payment_addr"].(string); ok {
+		paymentAddr, err := parseHash("payment_addr", value)
+		if err != nil {
+			return nil, err
+		}
+		req.PaymentAddr = paymentAddr
+	}
+
+	return req, nil
+}
@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Ways an output's fee can be bumped.
+const (
+	// bumpRBF re-offers an output lnd is already sweeping at a higher
+	// fee rate, replacing its sweep transaction.
+	bumpRBF = "rbf"
+
+	// bumpCPFP spends an unconfirmed wallet output in a child transaction
+	// whose fee pulls its parent into a block.
+	bumpCPFP = "cpfp"
+)
+
+// bumpable is what the node can currently bump: the outputs its sweeper is
+// handling, and unconfirmed wallet outputs that are not among them.
+type bumpable struct {
+	sweeps      []*walletrpc.PendingSweep
+	unconfirmed []*lnrpc.Utxo
+}
+
+// ListBumpableTool returns the MCP tool definition for listing outputs whose
+// fee can be bumped.
+func (s *OnChainService) ListBumpableTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_bumpable",
+		Description: "List outputs whose confirmation can be sped up " +
+			"with lnc_bump_fee: outputs the node is sweeping, with " +
+			"their current fee rate, budget and deadline, and " +
+			"unconfirmed wallet outputs that a child transaction " +
+			"can pay for",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleListBumpable handles the list bumpable request.
+func (s *OnChainService) HandleListBumpable(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	outputs, failure := s.bumpable(ctx)
+	if failure != nil {
+		return failure, nil
+	}
+
+	sweeps := make([]map[string]any, len(outputs.sweeps))
+	for i, sweep := range outputs.sweeps {
+		entry := pendingSweepEntry(sweep, info.BlockHeight)
+		entry["bump"] = bumpRBF
+		sweeps[i] = entry
+	}
+
+	unconfirmed := make([]map[string]any, len(outputs.unconfirmed))
+	for i, utxo := range outputs.unconfirmed {
+		unconfirmed[i] = map[string]any{
+			"outpoint":   formatOutPoint(utxo.Outpoint),
+			"address":    utxo.Address,
+			"amount_sat": utxo.AmountSat,
+			"bump":       bumpCPFP,
+		}
+	}
+
+	return jsonResult("lnc_list_bumpable", map[string]any{
+		"block_height":        info.BlockHeight,
+		"pending_sweeps":      sweeps,
+		"unconfirmed_outputs": unconfirmed,
+		"total_bumpable":      len(sweeps) + len(unconfirmed),
+	}), nil
+}
+
+// BumpFeeTool returns the MCP tool definition for bumping the fee of an
+// output.
+func (s *OnChainService) BumpFeeTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_bump_fee",
+		Description: "Speed up the confirmation of a stuck output at a " +
+			"new fee rate. An output the node is sweeping has its " +
+			"sweep replaced (RBF); an unconfirmed wallet output is " +
+			"spent by a child transaction paying for its parent " +
+			"(CPFP). Use lnc_list_bumpable to find outputs",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"outpoint": map[string]any{
+					"type": "string",
+					"description": "Output to bump " +
+						"(txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Fee rate to start the " +
+						"bump at; it must exceed an " +
+						"existing sweep's rate",
+					"minimum": 1,
+				},
+				"budget_sat": map[string]any{
+					"type": "number",
+					"description": "Most the node may spend " +
+						"on fees for the output as it " +
+						"raises the rate towards its " +
+						"deadline (default chosen by " +
+						"the node)",
+					"minimum": 1,
+				},
+				"immediate": map[string]any{
+					"type": "boolean",
+					"description": "Broadcast right away " +
+						"instead of with the next block",
+				},
+			},
+			Required: []string{"outpoint", "sat_per_vbyte"},
+		},
+	}
+}
+
+// HandleBumpFee handles the bump fee request. The outpoint is looked up
+// among the bumpable outputs first, so the method is known and a rate that
+// would not replace an existing sweep is rejected before reaching the node.
+func (s *OnChainService) HandleBumpFee(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	value, _ := args["outpoint"].(string)
+	txid, index, err := parseChannelPoint("outpoint", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	outpoint := fmt.Sprintf("%s:%d", txid, index)
+
+	rate, _ := args["sat_per_vbyte"].(float64)
+	if rate < 1 {
+		return invalidArgumentError("sat_per_vbyte must be at " +
+			"least 1"), nil
+	}
+	if err := s.Policy.CheckFeeRate(uint64(rate)); err != nil {
+		return policyError(err), nil
+	}
+	budget, _ := args["budget_sat"].(float64)
+	if budget < 0 {
+		return invalidArgumentError("budget_sat must not be " +
+			"negative"), nil
+	}
+	immediate, _ := args["immediate"].(bool)
+
+	outputs, failure := s.bumpable(ctx)
+	if failure != nil {
+		return failure, nil
+	}
+
+	result := map[string]any{
+		"outpoint":      outpoint,
+		"sat_per_vbyte": uint64(rate),
+		"immediate":     immediate,
+	}
+	for _, sweep := range outputs.sweeps {
+		if formatOutPoint(sweep.Outpoint) != outpoint {
+			continue
+		}
+		if uint64(rate) <= sweep.SatPerVbyte {
+			return toolError(errors.New(errors.ErrCodeInvalidArgument,
+				fmt.Sprintf("the output is already swept at "+
+					"%d sat/vbyte; a replacement must pay "+
+					"more", sweep.SatPerVbyte)).WithDetails(
+				map[string]any{
+					"sat_per_vbyte": sweep.SatPerVbyte,
+				})), nil
+		}
+		result["bump"] = bumpRBF
+		result["previous_sat_per_vbyte"] = sweep.SatPerVbyte
+	}
+	for _, utxo := range outputs.unconfirmed {
+		if formatOutPoint(utxo.Outpoint) == outpoint {
+			result["bump"] = bumpCPFP
+		}
+	}
+	if result["bump"] == nil {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"the outpoint is neither being swept nor an "+
+				"unconfirmed wallet output; list candidates "+
+				"with lnc_list_bumpable").WithDetails(
+			map[string]any{"outpoint": outpoint})), nil
+	}
+
+	resp, err := walletKit.BumpFee(ctx, &walletrpc.BumpFeeRequest{
+		Outpoint: &lnrpc.OutPoint{
+			TxidStr:     txid,
+			OutputIndex: index,
+		},
+		SatPerVbyte: uint64(rate),
+		Budget:      uint64(budget),
+		Immediate:   immediate,
+	})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to bump fee"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	if budget > 0 {
+		result["budget_sat"] = uint64(budget)
+	}
+	result["status"] = resp.Status
+
+	return jsonResult("lnc_bump_fee", result), nil
+}
+
+// bumpable collects the outputs whose fee can be bumped. Unconfirmed wallet
+// outputs already handed to the sweeper are only listed as sweeps.
+func (s *OnChainService) bumpable(
+	ctx context.Context) (*bumpable, *mcp.CallToolResult) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return nil, notConnectedError(s.Clients)
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return nil, missing
+	}
+
+	pending, err := walletKit.PendingSweeps(ctx,
+		&walletrpc.PendingSweepsRequest{})
+	if err != nil {
+		return nil, subserverError(s.Clients, generation, "walletkit",
+			err, "failed to list pending sweeps")
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	// Zero minimum and maximum confirmations list only unconfirmed
+	// outputs.
+	unspent, err := client.ListUnspent(ctx, &lnrpc.ListUnspentRequest{
+		MinConfs: 0,
+		MaxConfs: 0,
+	})
+	if err != nil {
+		return nil, rpcError(err, "failed to list unspent")
+	}
+
+	swept := make(map[string]bool, len(pending.PendingSweeps))
+	for _, sweep := range pending.PendingSweeps {
+		swept[formatOutPoint(sweep.Outpoint)] = true
+	}
+
+	outputs := &bumpable{sweeps: pending.PendingSweeps}
+	for _, utxo := range unspent.Utxos {
+		if utxo.Confirmations == 0 &&
+			!swept[formatOutPoint(utxo.Outpoint)] {
+			outputs.unconfirmed = append(outputs.unconfirmed, utxo)
+		}
+	}
+
+	return outputs, nil
+}
+
+// formatOutPoint renders an outpoint as txid:output_index.
+func formatOutPoint(outpoint *lnrpc.OutPoint) string {
+	if outpoint == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", outpoint.TxidStr, outpoint.OutputIndex)
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subserver describes an optional lnd RPC service and the tools that
+// cannot work without it.
+type subserver struct {
+	// service is the gRPC service name, as the node reports it when the
+	// service is unknown.
+	service string
+
+	// guidance tells the operator how to make the service available.
+	guidance string
+
+	// tools lists the tools that depend on the service.
+	tools []string
+}
+
+// subservers is the degradation matrix: for each optional subserver, the
+// tools that are degraded while the node runs without it.
+var subservers = map[string]subserver{
+	"invoices": {
+		service: "invoicesrpc.Invoices",
+		guidance: "lnd only includes it when built with the " +
+			"invoicesrpc build tag, as release builds are; " +
+			"rebuild lnd with that tag or run a release build",
+		tools: []string{
+			"lnc_add_hold_invoice", "lnc_cancel_invoice",
+			"lnc_settle_invoice",
+		},
+	},
+	"router": {
+		service: "routerrpc.Router",
+		guidance: "every lnd build includes it, so the connection " +
+			"likely ends at a proxy or backend that does not " +
+			"expose it; pair with lnd or Lightning Terminal " +
+			"directly",
+		tools: []string{
+			"lnc_pay_invoice", "lnc_keysend", "lnc_send_to_route",
+			"lnc_track_payment", "lnc_build_route",
+			"lnc_estimate_route_fee",
+			"lnc_query_mission_control",
+			"lnc_query_payment_probability",
+			"lnc_get_mission_control_config",
+			"lnc_set_mission_control_config",
+		},
+	},
+	"walletkit": {
+		service: "walletrpc.WalletKit",
+		guidance: "lnd only includes it when built with the " +
+			"walletrpc build tag, as release builds are; " +
+			"rebuild lnd with that tag or run a release build",
+		tools: []string{
+			"lnc_list_bumpable", "lnc_bump_fee",
+			"lnc_label_transaction", "lnc_fund_psbt",
+			"lnc_finalize_psbt", "lnc_release_output",
+			"lnc_list_accounts", "lnc_list_addresses",
+			"lnc_list_sweeps", "lnc_pending_sweeps",
+			"lnc_required_reserve", "lnc_list_leases",
+		},
+	},
+	"wtclient": {
+		service: "wtclientrpc.WatchtowerClient",
+		guidance: "lnd only includes it when built with the " +
+			"wtclientrpc build tag, as release builds are; " +
+			"rebuild lnd with that tag or run a release build",
+		tools: []string{
+			"lnc_security_report", "lnc_list_towers",
+			"lnc_tower_stats", "lnc_tower_policy",
+		},
+	},
+}
+
+// subserverNames returns the subserver names in a stable order.
+func subserverNames() []string {
+	names := make([]string, 0, len(subservers))
+	for name := range subservers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// subserverMissing reports whether err says the called service is not
+// running on the node, rather than that the call failed. lnd answers
+// Unimplemented for services it was built or configured without, and
+// Lightning Terminal answers Unavailable for subservers it has not started.
+// Other Unavailable errors are transport failures and are not treated as
+// a missing service.
+func subserverMissing(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch st.Code() {
+	case codes.Unimplemented:
+		return true
+	case codes.Unavailable:
+		message := strings.ToLower(st.Message())
+		return strings.Contains(message, "subserver") ||
+			strings.Contains(message, "not running") ||
+			strings.Contains(message, "disabled")
+	default:
+		return false
+	}
+}
+
+// knownMissingSubserver returns the error for a subserver that an earlier
+// call found missing on the current clients, so tools that need it fail
+// fast, or nil if it is not known to be missing.
+func knownMissingSubserver(clients *ClientProvider,
+	name string) *mcp.CallToolResult {
+	state, ok := clients.subserver(name)
+	if !ok || state.available {
+		return nil
+	}
+
+	return subserverMissingError(name, state.reason)
+}
+
+// subserverReport describes what is known about each subserver on the
+// given clients, and lists the tools degraded by those found missing.
+func subserverReport(clients *ClientProvider) ([]map[string]any, []string) {
+	var (
+		report   []map[string]any
+		degraded []string
+	)
+	for _, name := range subserverNames() {
+		sub := subservers[name]
+		entry := map[string]any{
+			"name":    name,
+			"service": sub.service,
+			"status":  "unknown",
+			"tools":   sub.tools,
+		}
+
+		if state, ok := clients.subserver(name); ok {
+			entry["status"] = "available"
+			entry["checked_at"] = state.checkedAt.UTC().
+				Format(time.RFC3339)
+			if !state.available {
+				entry["status"] = "missing"
+				entry["reason"] = state.reason
+				entry["guidance"] = sub.guidance
+				degraded = append(degraded, sub.tools...)
+			}
+		}
+		report = append(report, entry)
+	}
+
+	return report, degraded
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// GetChanInfoTool returns the MCP tool definition for looking up one channel
+// in the graph.
+func (s *PeerService) GetChanInfoTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_chan_info",
+		Description: "Get one channel from the network graph by " +
+			"channel ID or channel point: its capacity, the two " +
+			"nodes and the routing policy each sets for payments " +
+			"leaving through it",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"chan_id": map[string]any{
+					"type": "string",
+					"description": "Channel ID, in decimal or " +
+						"as a short channel ID such as " +
+						"800000x1234x0",
+					"pattern": "^([0-9]+|[0-9]+x[0-9]+x[0-9]+)$",
+				},
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Channel point " +
+						"(txid:output_index), instead " +
+						"of chan_id",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+			},
+		},
+	}
+}
+
+// HandleGetChanInfo handles the channel info request.
+func (s *PeerService) HandleGetChanInfo(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	chanIDArg, _ := request.Params.Arguments["chan_id"].(string)
+	channelPoint, _ := request.Params.Arguments["channel_point"].(string)
+	if (chanIDArg == "") == (channelPoint == "") {
+		return invalidArgumentError("exactly one of chan_id and " +
+			"channel_point is required"), nil
+	}
+
+	req := &lnrpc.ChanInfoRequest{}
+	if chanIDArg != "" {
+		chanID, err := parseChanID("chan_id", chanIDArg)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		req.ChanId = chanID
+	} else {
+		txid, index, err := parseChannelPoint("channel_point",
+			channelPoint)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		req.ChanPoint = fmt.Sprintf("%s:%d", txid, index)
+	}
+
+	edge, err := client.GetChanInfo(ctx, req)
+	if err != nil {
+		return rpcError(err, "failed to get channel info"), nil
+	}
+
+	result := map[string]any{
+		"chan_id":       strconv.FormatUint(edge.ChannelId, 10),
+		"channel_point": edge.ChanPoint,
+		"capacity_sat":  edge.Capacity,
+		"last_update":   edge.LastUpdate,
+		"node1_pub":     edge.Node1Pub,
+		"node2_pub":     edge.Node2Pub,
+	}
+	// A side that has not announced a policy yet has none to report.
+	if edge.Node1Policy != nil {
+		result["node1_policy"] = routingPolicyMap(edge.Node1Policy)
+	}
+	if edge.Node2Policy != nil {
+		result["node2_policy"] = routingPolicyMap(edge.Node2Policy)
+	}
+
+	return jsonResult("lnc_get_chan_info", result), nil
+}
+
+// routingPolicyMap formats the routing policy one node sets on a channel.
+func routingPolicyMap(policy *lnrpc.RoutingPolicy) map[string]any {
+	return map[string]any{
+		"fee_base_msat":         policy.FeeBaseMsat,
+		"fee_rate_ppm":          policy.FeeRateMilliMsat,
+		"inbound_fee_base_msat": policy.InboundFeeBaseMsat,
+		"inbound_fee_rate_ppm":  policy.InboundFeeRateMilliMsat,
+		"time_lock_delta":       policy.TimeLockDelta,
+		"min_htlc_msat":         policy.MinHtlc,
+		"max_htlc_msat":         policy.MaxHtlcMsat,
+		"disabled":              policy.Disabled,
+		"last_update":           policy.LastUpdate,
+	}
+}
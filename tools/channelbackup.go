@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxChannelBackupBytes bounds a backup passed in for verification. Each
+// channel takes a few hundred bytes, so this holds thousands of channels.
+const maxChannelBackupBytes = 4 << 20
+
+// channelBackupNote tells how the exported backups are used.
+const channelBackupNote = "Backups are encrypted with a key derived " +
+	"from the node's seed, so they are only useful together with it. " +
+	"Restore them with lncli restorechanbackup, or when recreating " +
+	"the wallet from the seed; the node then asks each peer to force " +
+	"close. The multi-channel backup replaces any older one and must " +
+	"be saved again after every channel open"
+
+// ExportChannelBackupsTool returns the MCP tool definition for exporting
+// static channel backups.
+func (s *NodeService) ExportChannelBackupsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_export_channel_backups",
+		Description: "Export the node's static channel backups, " +
+			"base64 encoded: the multi-channel backup covering " +
+			"every channel, the same as lnd's channel.backup " +
+			"file, or with channel_point the single backup of " +
+			"one channel. They let the funds in the channels be " +
+			"recovered with the seed if the node is lost",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Only export this " +
+						"channel's single backup " +
+						"(funding_txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"include_singles": map[string]any{
+					"type": "boolean",
+					"description": "Also export each " +
+						"channel's single backup " +
+						"alongside the multi-channel " +
+						"backup",
+				},
+			},
+		},
+	}
+}
+
+// HandleExportChannelBackups handles the channel backup export request.
+func (s *NodeService) HandleExportChannelBackups(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	includeSingles, _ := args["include_singles"].(bool)
+
+	if value, _ := args["channel_point"].(string); value != "" {
+		txid, index, err := parseChannelPoint("channel_point", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+
+		point := &lnrpc.ChannelPoint{
+			FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+				FundingTxidStr: txid,
+			},
+			OutputIndex: index,
+		}
+		single, err := client.ExportChannelBackup(ctx,
+			&lnrpc.ExportChannelBackupRequest{ChanPoint: point})
+		if err != nil {
+			return rpcError(err, "failed to export channel "+
+				"backup"), nil
+		}
+
+		result := backupEntry(single.ChanBackup)
+		result["kind"] = "single"
+		result["channel_point"] = fmt.Sprintf("%s:%d", txid, index)
+		result["note"] = channelBackupNote
+
+		return jsonResult("lnc_export_channel_backups", result), nil
+	}
+
+	snapshot, err := client.ExportAllChannelBackups(ctx,
+		&lnrpc.ChanBackupExportRequest{})
+	if err != nil {
+		return rpcError(err, "failed to export channel backups"), nil
+	}
+
+	result := map[string]any{
+		"kind": "multi",
+		"note": channelBackupNote,
+	}
+	if multi := snapshot.MultiChanBackup; multi != nil {
+		entry := backupEntry(multi.MultiChanBackup)
+		points := make([]string, 0, len(multi.ChanPoints))
+		for _, point := range multi.ChanPoints {
+			points = append(points, channelPointString(point))
+		}
+		entry["channel_points"] = points
+		entry["channel_count"] = len(points)
+		result["multi_chan_backup"] = entry
+	}
+	if includeSingles {
+		singles := make([]map[string]any, 0)
+		if snapshot.SingleChanBackups != nil {
+			for _, single := range snapshot.SingleChanBackups.
+				ChanBackups {
+				entry := backupEntry(single.ChanBackup)
+				entry["channel_point"] = channelPointString(
+					single.ChanPoint)
+				singles = append(singles, entry)
+			}
+		}
+		result["single_chan_backups"] = singles
+	}
+
+	return jsonResult("lnc_export_channel_backups", result), nil
+}
+
+// backupEntry formats an encrypted backup with its size and checksum, so a
+// saved copy can be compared with it.
+func backupEntry(backup []byte) map[string]any {
+	sum := sha256.Sum256(backup)
+	return map[string]any{
+		"backup":     base64.StdEncoding.EncodeToString(backup),
+		"size_bytes": len(backup),
+		"sha256":     hex.EncodeToString(sum[:]),
+	}
+}
+
+// VerifyChannelBackupTool returns the MCP tool definition for verifying a
+// static channel backup.
+func (s *NodeService) VerifyChannelBackupTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_verify_channel_backup",
+		Description: "Check that a static channel backup can be " +
+			"restored by this node: lnd decrypts it with the " +
+			"node's key and lists the channels it holds, which " +
+			"are compared with the node's channels. Pass a saved " +
+			"multi-channel backup or single channel backup, " +
+			"base64 encoded, or nothing to check the node's " +
+			"current backup",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"multi_chan_backup": map[string]any{
+					"type": "string",
+					"description": "Multi-channel " +
+						"backup, such as lnd's " +
+						"channel.backup file, base64 " +
+						"encoded",
+				},
+				"chan_backup": map[string]any{
+					"type": "string",
+					"description": "Single channel " +
+						"backup, base64 encoded",
+				},
+			},
+		},
+	}
+}
+
+// HandleVerifyChannelBackup handles the channel backup verification
+// request. A backup lnd cannot decrypt or parse is reported as invalid
+// rather than failing the call.
+func (s *NodeService) HandleVerifyChannelBackup(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	multiArg, _ := args["multi_chan_backup"].(string)
+	singleArg, _ := args["chan_backup"].(string)
+	if multiArg != "" && singleArg != "" {
+		return invalidArgumentError("pass either multi_chan_backup " +
+			"or chan_backup, not both"), nil
+	}
+
+	snapshot := &lnrpc.ChanBackupSnapshot{}
+	source, kind := "provided", "multi"
+	switch {
+	case multiArg != "":
+		backup, err := decodeChannelBackup("multi_chan_backup",
+			multiArg)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		snapshot.MultiChanBackup = &lnrpc.MultiChanBackup{
+			MultiChanBackup: backup,
+		}
+
+	case singleArg != "":
+		backup, err := decodeChannelBackup("chan_backup", singleArg)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		snapshot.SingleChanBackups = &lnrpc.ChannelBackups{
+			ChanBackups: []*lnrpc.ChannelBackup{{
+				ChanBackup: backup,
+			}},
+		}
+		kind = "single"
+
+	default:
+		export, err := client.ExportAllChannelBackups(ctx,
+			&lnrpc.ChanBackupExportRequest{})
+		if err != nil {
+			return rpcError(err, "failed to export channel "+
+				"backups"), nil
+		}
+		if export.MultiChanBackup == nil {
+			return toolError(errors.New(errors.ErrCodeUnknown,
+				"the node returned no multi-channel "+
+					"backup")), nil
+		}
+		snapshot.MultiChanBackup = &lnrpc.MultiChanBackup{
+			MultiChanBackup: export.MultiChanBackup.MultiChanBackup,
+		}
+		source = "node"
+	}
+
+	result := map[string]any{
+		"source": source,
+		"kind":   kind,
+	}
+
+	resp, err := client.VerifyChanBackup(ctx, snapshot)
+	switch {
+	// lnd fails verification with an unclassified error naming the
+	// backup invalid, unlike a failed call.
+	case status.Code(err) == codes.Unknown && strings.HasPrefix(
+		status.Convert(err).Message(), "invalid "):
+		result["valid"] = false
+		result["reason"] = status.Convert(err).Message()
+		return jsonResult("lnc_verify_channel_backup", result), nil
+	case err != nil:
+		return rpcError(err, "failed to verify channel backup"), nil
+	}
+	result["valid"] = true
+	result["channel_points"] = resp.ChanPoints
+	result["channel_count"] = len(resp.ChanPoints)
+
+	// A single backup covers one channel by design, so only a multi
+	// backup is expected to hold every channel.
+	if kind == "multi" {
+		list, err := client.ListChannels(ctx,
+			&lnrpc.ListChannelsRequest{})
+		if err != nil {
+			return rpcError(err, "failed to list channels"), nil
+		}
+		pending, err := client.PendingChannels(ctx,
+			&lnrpc.PendingChannelsRequest{})
+		if err != nil {
+			return rpcError(err, "failed to list pending "+
+				"channels"), nil
+		}
+
+		inBackup := make(map[string]bool, len(resp.ChanPoints))
+		for _, point := range resp.ChanPoints {
+			inBackup[point] = true
+		}
+		missing := make([]string, 0)
+		for _, point := range backupChannelPoints(list, pending) {
+			if !inBackup[point] {
+				missing = append(missing, point)
+			}
+		}
+		result["missing_channels"] = missing
+		result["covers_all_channels"] = len(missing) == 0
+	}
+
+	return jsonResult("lnc_verify_channel_backup", result), nil
+}
+
+// decodeChannelBackup decodes a base64 encoded backup argument.
+func decodeChannelBackup(name, value string) ([]byte, error) {
+	if base64.StdEncoding.DecodedLen(len(value)) > maxChannelBackupBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name,
+			maxChannelBackupBytes)
+	}
+	backup, err := base64.StdEncoding.DecodeString(
+		strings.TrimSpace(value))
+	if err != nil || len(backup) == 0 {
+		return nil, fmt.Errorf("%s must be base64 encoded", name)
+	}
+
+	return backup, nil
+}
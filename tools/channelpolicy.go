@@ -0,0 +1,384 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// minTimeLockDelta and maxTimeLockDelta are the bounds lnd accepts for
+	// a channel's time lock delta.
+	minTimeLockDelta = 18
+	maxTimeLockDelta = 2016
+)
+
+// UpdateChannelPolicyTool returns the MCP tool definition for updating
+// routing policies.
+func (s *ChannelService) UpdateChannelPolicyTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_update_channel_policy",
+		Description: "Update the routing fees and HTLC limits of one " +
+			"channel or of every channel. Use dry_run to preview " +
+			"the channels affected and their current policies " +
+			"without changing anything",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Channel to update " +
+						"(txid:output_index); omit " +
+						"with global",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"global": map[string]any{
+					"type": "boolean",
+					"description": "Update every channel; " +
+						"base_fee_msat, fee_rate_ppm and " +
+						"time_lock_delta are then required",
+				},
+				"base_fee_msat": map[string]any{
+					"type":        "number",
+					"description": "Base fee per forward, in millisatoshis",
+					"minimum":     0,
+				},
+				"fee_rate_ppm": map[string]any{
+					"type": "number",
+					"description": "Proportional fee, in parts " +
+						"per million of the amount forwarded",
+					"minimum": 0,
+				},
+				"time_lock_delta": map[string]any{
+					"type": "number",
+					"description": "CLTV delta required of " +
+						"forwarded HTLCs, in blocks",
+					"minimum": minTimeLockDelta,
+					"maximum": maxTimeLockDelta,
+				},
+				"min_htlc_msat": map[string]any{
+					"type":        "number",
+					"description": "Smallest HTLC forwarded, in millisatoshis",
+					"minimum":     0,
+				},
+				"max_htlc_msat": map[string]any{
+					"type":        "number",
+					"description": "Largest HTLC forwarded, in millisatoshis",
+					"minimum":     1,
+				},
+				"dry_run": map[string]any{
+					"type": "boolean",
+					"description": "Only preview the update and " +
+						"the channels it would affect",
+				},
+			},
+		},
+	}
+}
+
+// channelPolicy is the routing policy this node announces for a channel.
+type channelPolicy struct {
+	baseFeeMsat   int64
+	feeRatePPM    int64
+	timeLockDelta uint32
+	minHTLCMsat   int64
+	maxHTLCMsat   uint64
+}
+
+// toMap formats the policy for a tool result.
+func (p channelPolicy) toMap() map[string]any {
+	return map[string]any{
+		"base_fee_msat":   p.baseFeeMsat,
+		"fee_rate_ppm":    p.feeRatePPM,
+		"time_lock_delta": p.timeLockDelta,
+		"min_htlc_msat":   p.minHTLCMsat,
+		"max_htlc_msat":   p.maxHTLCMsat,
+	}
+}
+
+// policyArgs holds the policy fields given to lnc_update_channel_policy;
+// nil fields keep their current values.
+type policyArgs struct {
+	baseFeeMsat   *int64
+	feeRatePPM    *int64
+	timeLockDelta *uint32
+	minHTLCMsat   *int64
+	maxHTLCMsat   *uint64
+}
+
+// parsePolicyArgs validates the policy fields of a request.
+func parsePolicyArgs(args map[string]any) (policyArgs, error) {
+	var policy policyArgs
+
+	if value, ok := args["base_fee_msat"].(float64); ok {
+		if value < 0 {
+			return policy, fmt.Errorf("base_fee_msat must not be " +
+				"negative")
+		}
+		baseFee := int64(value)
+		policy.baseFeeMsat = &baseFee
+	}
+	if value, ok := args["fee_rate_ppm"].(float64); ok {
+		if value < 0 || value > 1_000_000 {
+			return policy, fmt.Errorf("fee_rate_ppm must be " +
+				"between 0 and 1000000")
+		}
+		feeRate := int64(value)
+		policy.feeRatePPM = &feeRate
+	}
+	if value, ok := args["time_lock_delta"].(float64); ok {
+		if value < minTimeLockDelta || value > maxTimeLockDelta {
+			return policy, fmt.Errorf("time_lock_delta must be "+
+				"between %d and %d", minTimeLockDelta,
+				maxTimeLockDelta)
+		}
+		delta := uint32(value)
+		policy.timeLockDelta = &delta
+	}
+	if value, ok := args["min_htlc_msat"].(float64); ok {
+		if value < 0 {
+			return policy, fmt.Errorf("min_htlc_msat must not be " +
+				"negative")
+		}
+		minHTLC := int64(value)
+		policy.minHTLCMsat = &minHTLC
+	}
+	if value, ok := args["max_htlc_msat"].(float64); ok {
+		if value < 1 {
+			return policy, fmt.Errorf("max_htlc_msat must be at " +
+				"least 1")
+		}
+		maxHTLC := uint64(value)
+		policy.maxHTLCMsat = &maxHTLC
+	}
+
+	if policy.baseFeeMsat == nil && policy.feeRatePPM == nil &&
+		policy.timeLockDelta == nil && policy.minHTLCMsat == nil &&
+		policy.maxHTLCMsat == nil {
+		return policy, fmt.Errorf("set at least one of base_fee_msat, " +
+			"fee_rate_ppm, time_lock_delta, min_htlc_msat and " +
+			"max_htlc_msat")
+	}
+	if policy.minHTLCMsat != nil && policy.maxHTLCMsat != nil &&
+		uint64(*policy.minHTLCMsat) > *policy.maxHTLCMsat {
+		return policy, fmt.Errorf("min_htlc_msat must not exceed " +
+			"max_htlc_msat")
+	}
+
+	return policy, nil
+}
+
+// apply returns current with the given fields replaced.
+func (a policyArgs) apply(current channelPolicy) channelPolicy {
+	updated := current
+	if a.baseFeeMsat != nil {
+		updated.baseFeeMsat = *a.baseFeeMsat
+	}
+	if a.feeRatePPM != nil {
+		updated.feeRatePPM = *a.feeRatePPM
+	}
+	if a.timeLockDelta != nil {
+		updated.timeLockDelta = *a.timeLockDelta
+	}
+	if a.minHTLCMsat != nil {
+		updated.minHTLCMsat = *a.minHTLCMsat
+	}
+	if a.maxHTLCMsat != nil {
+		updated.maxHTLCMsat = *a.maxHTLCMsat
+	}
+	return updated
+}
+
+// HandleUpdateChannelPolicy handles the update channel policy request.
+// lnd replaces the base fee, fee rate and time lock delta on every update,
+// so for a single channel the fields not given are filled in from its
+// current policy; a global update must set all three.
+func (s *ChannelService) HandleUpdateChannelPolicy(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	channelPoint, _ := args["channel_point"].(string)
+	global, _ := args["global"].(bool)
+	if global == (channelPoint != "") {
+		return invalidArgumentError("set either channel_point or " +
+			"global"), nil
+	}
+
+	var (
+		txid  string
+		index uint32
+	)
+	if !global {
+		var err error
+		txid, index, err = parseChannelPoint("channel_point",
+			channelPoint)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		channelPoint = fmt.Sprintf("%s:%d", txid, index)
+	}
+
+	fields, err := parsePolicyArgs(args)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	if global && (fields.baseFeeMsat == nil || fields.feeRatePPM == nil ||
+		fields.timeLockDelta == nil) {
+		return invalidArgumentError("a global update replaces the " +
+			"policy of every channel, so base_fee_msat, " +
+			"fee_rate_ppm and time_lock_delta are all required"), nil
+	}
+
+	dryRun, _ := args["dry_run"].(bool)
+
+	// Collect the current policy of every affected channel, both for the
+	// preview and to fill in the fields of a single-channel update.
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	report, err := client.FeeReport(ctx, &lnrpc.FeeReportRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get fee report"), nil
+	}
+
+	var (
+		channels []map[string]any
+		current  channelPolicy
+	)
+	for _, fees := range report.ChannelFees {
+		if !global && fees.ChannelPoint != channelPoint {
+			continue
+		}
+
+		edge, err := client.GetChanInfo(ctx, &lnrpc.ChanInfoRequest{
+			ChanId: fees.ChanId,
+		})
+		if err != nil {
+			return rpcError(err, "failed to get channel info"), nil
+		}
+		current = localPolicy(edge, info.IdentityPubkey)
+		current.baseFeeMsat = fees.BaseFeeMsat
+		current.feeRatePPM = fees.FeePerMil
+
+		channels = append(channels, map[string]any{
+			"channel_point": fees.ChannelPoint,
+			"chan_id":       fees.ChanId,
+			"current":       current.toMap(),
+			"updated":       fields.apply(current).toMap(),
+		})
+	}
+	if !global && len(channels) == 0 {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no open channel with channel point "+channelPoint)), nil
+	}
+
+	// A global update keeps each channel's HTLC limits unless they are
+	// given, so only the limits given are part of its policy.
+	policy := fields.apply(current)
+	policyMap := policy.toMap()
+	if global {
+		policy = fields.apply(channelPolicy{})
+		policyMap = policy.toMap()
+		if fields.minHTLCMsat == nil {
+			delete(policyMap, "min_htlc_msat")
+		}
+		if fields.maxHTLCMsat == nil {
+			delete(policyMap, "max_htlc_msat")
+		}
+	}
+
+	result := map[string]any{
+		"scope":          "channel",
+		"dry_run":        dryRun,
+		"policy":         policyMap,
+		"channels":       channels,
+		"affected_count": len(channels),
+	}
+	if global {
+		result["scope"] = "global"
+	} else {
+		result["channel_point"] = channelPoint
+	}
+	if dryRun {
+		return jsonResult("lnc_update_channel_policy", result), nil
+	}
+
+	req := &lnrpc.PolicyUpdateRequest{
+		BaseFeeMsat:   policy.baseFeeMsat,
+		FeeRatePpm:    uint32(policy.feeRatePPM),
+		TimeLockDelta: policy.timeLockDelta,
+		MaxHtlcMsat:   policy.maxHTLCMsat,
+	}
+	if fields.minHTLCMsat != nil {
+		req.MinHtlcMsat = uint64(*fields.minHTLCMsat)
+		req.MinHtlcMsatSpecified = true
+	}
+	if global {
+		req.Scope = &lnrpc.PolicyUpdateRequest_Global{Global: true}
+	} else {
+		req.Scope = &lnrpc.PolicyUpdateRequest_ChanPoint{
+			ChanPoint: &lnrpc.ChannelPoint{
+				FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+					FundingTxidStr: txid,
+				},
+				OutputIndex: index,
+			},
+		}
+	}
+
+	resp, err := client.UpdateChannelPolicy(ctx, req)
+	if err != nil {
+		return rpcError(err, "failed to update channel policy"), nil
+	}
+
+	failed := make([]map[string]any, 0, len(resp.FailedUpdates))
+	for _, update := range resp.FailedUpdates {
+		entry := map[string]any{
+			"reason": update.Reason.String(),
+			"error":  update.UpdateError,
+		}
+		if update.Outpoint != nil {
+			entry["channel_point"] = fmt.Sprintf("%s:%d",
+				update.Outpoint.TxidStr, update.Outpoint.OutputIndex)
+		}
+		failed = append(failed, entry)
+	}
+	result["failed_updates"] = failed
+
+	return jsonResult("lnc_update_channel_policy", result), nil
+}
+
+// edgePolicies returns the routing policies of a channel's two sides: the
+// one this node sets, then the peer's. Either is nil until announced.
+func edgePolicies(edge *lnrpc.ChannelEdge,
+	ownPubkey string) (*lnrpc.RoutingPolicy, *lnrpc.RoutingPolicy) {
+	if edge.Node2Pub == ownPubkey {
+		return edge.Node2Policy, edge.Node1Policy
+	}
+	return edge.Node1Policy, edge.Node2Policy
+}
+
+// localPolicy returns this node's side of a channel's routing policy.
+func localPolicy(edge *lnrpc.ChannelEdge, ownPubkey string) channelPolicy {
+	policy, _ := edgePolicies(edge, ownPubkey)
+	if policy == nil {
+		return channelPolicy{}
+	}
+
+	return channelPolicy{
+		baseFeeMsat:   policy.FeeBaseMsat,
+		feeRatePPM:    policy.FeeRateMilliMsat,
+		timeLockDelta: policy.TimeLockDelta,
+		minHTLCMsat:   policy.MinHtlc,
+		maxHTLCMsat:   policy.MaxHtlcMsat,
+	}
+}
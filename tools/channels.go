@@ -2,22 +2,43 @@ package tools
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // ChannelService handles Lightning channel operations.
 type ChannelService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// DualFundPolicy is reported alongside interactive-funding sessions.
+	DualFundPolicy DualFundPolicy
+
+	// Mempool supplies the default fee rate of force close estimates
+	// when set.
+	Mempool *MempoolSource
+
+	// Journal records channels being opened, so their outcome is known
+	// after a restart. Nil disables journaling.
+	Journal *journal.Journal
+
+	// Policy caps the fee rate of funding transactions. Nil enforces no
+	// cap.
+	Policy *policy.Engine
+
+	// Contacts names the channel peers in results. Nil leaves them
+	// unnamed.
+	Contacts *contacts.Book
 }
 
 // NewChannelService creates a new channel service.
 func NewChannelService(client lnrpc.LightningClient) *ChannelService {
 	return &ChannelService{
-		LightningClient: client,
+		Clients: NewClientProvider(client),
 	}
 }
 
@@ -53,9 +74,9 @@ func (s *ChannelService) ListChannelsTool() mcp.Tool {
 // HandleListChannels handles the list channels request.
 func (s *ChannelService) HandleListChannels(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	// Parse filter options
@@ -64,7 +85,7 @@ func (s *ChannelService) HandleListChannels(ctx context.Context,
 	publicOnly, _ := request.Params.Arguments["public_only"].(bool)
 	privateOnly, _ := request.Params.Arguments["private_only"].(bool)
 
-	channels, err := s.LightningClient.ListChannels(ctx,
+	channels, err := client.ListChannels(ctx,
 		&lnrpc.ListChannelsRequest{
 			ActiveOnly:   activeOnly,
 			InactiveOnly: inactiveOnly,
@@ -72,47 +93,56 @@ func (s *ChannelService) HandleListChannels(ctx context.Context,
 			PrivateOnly:  privateOnly,
 		})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf(
-			"Failed to list channels: %v", err)), nil
+		return rpcError(err, "failed to list channels"), nil
 	}
 
+	splicing := spliceStatus(ctx, s.Clients)
+
 	channelList := make([]map[string]any, len(channels.Channels))
 	for i, ch := range channels.Channels {
-		entry := map[string]any{
-			"active":                  ch.Active,
-			"remote_pubkey":           ch.RemotePubkey,
-			"channel_point":           ch.ChannelPoint,
-			"chan_id":                 strconv.FormatUint(ch.ChanId, 10),
-			"capacity":                ch.Capacity,
-			"local_balance":           ch.LocalBalance,
-			"remote_balance":          ch.RemoteBalance,
-			"commit_fee":              ch.CommitFee,
-			"commit_weight":           ch.CommitWeight,
-			"fee_per_kw":              ch.FeePerKw,
-			"unsettled_balance":       ch.UnsettledBalance,
-			"total_satoshis_sent":     ch.TotalSatoshisSent,
-			"total_satoshis_received": ch.TotalSatoshisReceived,
-			"num_updates":             ch.NumUpdates,
-			"pending_htlcs":           len(ch.PendingHtlcs),
-			"private":                 ch.Private,
-			"initiator":               ch.Initiator,
-			"chan_status_flags":       ch.ChanStatusFlags,
-		}
+		channelList[i] = channelEntry(ch, splicing)
+		addContact(s.Contacts, channelList[i], ch.RemotePubkey)
+	}
 
-		if local := constraintsToMap(ch.GetLocalConstraints()); local != nil {
-			entry["local_constraints"] = local
-		}
-		if remote := constraintsToMap(ch.GetRemoteConstraints()); remote != nil {
-			entry["remote_constraints"] = remote
-		}
+	return jsonResult("lnc_list_channels", map[string]any{
+		"channels":       channelList,
+		"total_channels": len(channelList),
+		"splicing":       splicing.toMap(),
+	}), nil
+}
+
+// channelEntry formats a channel as lnc_list_channels lists it.
+func channelEntry(ch *lnrpc.Channel, splicing spliceSupport) map[string]any {
+	entry := map[string]any{
+		"active":                  ch.Active,
+		"remote_pubkey":           ch.RemotePubkey,
+		"channel_point":           ch.ChannelPoint,
+		"chan_id":                 strconv.FormatUint(ch.ChanId, 10),
+		"capacity":                ch.Capacity,
+		"local_balance":           ch.LocalBalance,
+		"remote_balance":          ch.RemoteBalance,
+		"commit_fee":              ch.CommitFee,
+		"commit_weight":           ch.CommitWeight,
+		"fee_per_kw":              ch.FeePerKw,
+		"unsettled_balance":       ch.UnsettledBalance,
+		"total_satoshis_sent":     ch.TotalSatoshisSent,
+		"total_satoshis_received": ch.TotalSatoshisReceived,
+		"num_updates":             ch.NumUpdates,
+		"pending_htlcs":           len(ch.PendingHtlcs),
+		"private":                 ch.Private,
+		"initiator":               ch.Initiator,
+		"chan_status_flags":       ch.ChanStatusFlags,
+		"splice_status":           channelSpliceStatus(splicing),
+	}
 
-		channelList[i] = entry
+	if local := constraintsToMap(ch.GetLocalConstraints()); local != nil {
+		entry["local_constraints"] = local
+	}
+	if remote := constraintsToMap(ch.GetRemoteConstraints()); remote != nil {
+		entry["remote_constraints"] = remote
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"channels": %s,
-		"total_channels": %d
-	}`, toJSONString(channelList), len(channelList))), nil
+	return entry
 }
 
 // PendingChannelsTool returns the MCP tool definition for listing pending channels.
@@ -130,30 +160,75 @@ func (s *ChannelService) PendingChannelsTool() mcp.Tool {
 // HandlePendingChannels handles the pending channels request.
 func (s *ChannelService) HandlePendingChannels(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
-	pending, err := s.LightningClient.PendingChannels(ctx,
+	pending, err := client.PendingChannels(ctx,
 		&lnrpc.PendingChannelsRequest{})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf(
-			"Failed to get pending channels: %v", err)), nil
+		return rpcError(err, "failed to get pending channels"), nil
+	}
+
+	pendingOpen := formatPendingOpenChannels(pending.PendingOpenChannels)
+
+	// A splice shows up as a new funding transaction with a peer we
+	// already have an open channel with, so flag those as candidates.
+	splicing := spliceStatus(ctx, s.Clients)
+	if splicing.Supported {
+		open, err := client.ListChannels(ctx,
+			&lnrpc.ListChannelsRequest{})
+		if err == nil {
+			peers := make(map[string]struct{}, len(open.Channels))
+			for _, ch := range open.Channels {
+				peers[ch.RemotePubkey] = struct{}{}
+			}
+			for i, ch := range pending.PendingOpenChannels {
+				_, ok := peers[ch.Channel.RemoteNodePub]
+				pendingOpen[i]["splice_candidate"] = ok
+			}
+		}
+	}
+
+	// Contributions are read from the funding transactions, which are
+	// only fetched when there is a dual-funded open to report.
+	var fundingTxs map[string]*lnrpc.Transaction
+	for _, ch := range pending.PendingOpenChannels {
+		if !isDualFunded(ch.Channel) {
+			continue
+		}
+		txs, err := client.GetTransactions(ctx,
+			&lnrpc.GetTransactionsRequest{EndHeight: -1})
+		if err != nil {
+			return rpcError(err, "failed to get funding "+
+				"transactions"), nil
+		}
+		fundingTxs = make(map[string]*lnrpc.Transaction,
+			len(txs.Transactions))
+		for _, tx := range txs.Transactions {
+			fundingTxs[tx.TxHash] = tx
+		}
+		break
 	}
 
 	// Format pending channels
 	result := map[string]any{
-		"pending_open_channels": formatPendingOpenChannels(
-			pending.PendingOpenChannels),
+		"pending_open_channels": pendingOpen,
 		"pending_force_closing_channels": formatPendingForceClosingChannels(
 			pending.PendingForceClosingChannels),
 		"waiting_close_channels": formatWaitingCloseChannels(
 			pending.WaitingCloseChannels),
 		"total_limbo_balance": pending.TotalLimboBalance,
+		"splicing":            splicing.toMap(),
+		"interactive_funding": map[string]any{
+			"sessions": formatInteractiveFunding(
+				pending.PendingOpenChannels, fundingTxs),
+			"contribution_policy": s.DualFundPolicy.toMap(),
+		},
 	}
 
-	return mcp.NewToolResultText(toJSONString(result)), nil
+	return jsonResult("lnc_pending_channels", result), nil
 }
 
 // FormatPendingOpenChannels formats pending open channel data for JSON output.
@@ -225,12 +300,7 @@ func formatPendingChannel(
 		"capacity":        ch.Capacity,
 		"local_balance":   ch.LocalBalance,
 		"remote_balance":  ch.RemoteBalance,
+		"initiator":       ch.Initiator.String(),
+		"dual_funded":     isDualFunded(ch),
 	}
 }
-
-// ToJSONString converts an interface to JSON string for output formatting.
-func toJSONString(v any) string {
-	// This is a simplified version - in production you'd use proper
-	// JSON marshaling
-	return fmt.Sprintf("%+v", v)
-}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
+)
+
+// NodeClients are the RPC clients of one node connection. Subserver clients
+// are created for every connection; whether the node actually runs the
+// subserver only shows when they are called.
+type NodeClients struct {
+	Lightning lnrpc.LightningClient
+	Router    routerrpc.RouterClient
+	Invoices  invoicesrpc.InvoicesClient
+	WalletKit walletrpc.WalletKitClient
+	Towers    wtclientrpc.WatchtowerClientClient
+}
+
+// ClientProvider hands out the current node clients. The clients are
+// replaced whenever the connection is, which can happen while a handler is
+// running, so every client is returned with the generation it belongs to.
+// A long-running call keeps its generation and checks Replaced when its
+// stream fails, to tell a replaced connection from a node error.
+type ClientProvider struct {
+	mu sync.RWMutex
+
+	clients    NodeClients
+	generation uint64
+
+	// What calls over the current clients have shown about the node's
+	// optional subservers, by name. Replacing the clients forgets it, as
+	// the new connection may be to a differently built node.
+	subservers map[string]subserverState
+
+	// splicing is the current node's splice support, once a listing has
+	// asked the node for it. Its version and features only change with
+	// the connection.
+	splicing *spliceSupport
+
+	// state tracks the lifecycle of the connection the clients come
+	// from, so errors name that connection rather than another.
+	state *ConnectionState
+}
+
+// subserverState records whether a subserver answered the last call made to
+// it.
+type subserverState struct {
+	available bool
+	reason    string
+	checkedAt time.Time
+}
+
+// NewClientProvider creates a provider holding client, which may be nil
+// until a connection is established.
+func NewClientProvider(client lnrpc.LightningClient) *ClientProvider {
+	p := &ClientProvider{state: NewConnectionState()}
+	if client != nil {
+		p.Set(client, nil)
+	}
+	return p
+}
+
+// State returns the lifecycle of the connection the clients come from.
+func (p *ClientProvider) State() *ConnectionState {
+	return p.state
+}
+
+// Set replaces the clients with a Lightning and router client only, and
+// returns their generation.
+func (p *ClientProvider) Set(lightning lnrpc.LightningClient,
+	router routerrpc.RouterClient) uint64 {
+	return p.SetClients(NodeClients{
+		Lightning: lightning,
+		Router:    router,
+	})
+}
+
+// SetClients replaces all clients and returns their generation.
+func (p *ClientProvider) SetClients(clients NodeClients) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clients = clients
+	p.generation++
+	p.subservers = nil
+	p.splicing = nil
+
+	return p.generation
+}
+
+// Clear drops the clients after the connection is closed, so handlers
+// report that the node is not connected instead of using a dead
+// connection.
+func (p *ClientProvider) Clear() {
+	p.Set(nil, nil)
+}
+
+// Lightning returns the current Lightning client, nil if there is none,
+// and its generation.
+func (p *ClientProvider) Lightning() (lnrpc.LightningClient, uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clients.Lightning, p.generation
+}
+
+// Router returns the current router client, nil if there is none, and its
+// generation.
+func (p *ClientProvider) Router() (routerrpc.RouterClient, uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clients.Router, p.generation
+}
+
+// Invoices returns the current invoices client, nil if there is none, and
+// its generation.
+func (p *ClientProvider) Invoices() (invoicesrpc.InvoicesClient, uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clients.Invoices, p.generation
+}
+
+// WalletKit returns the current wallet kit client, nil if there is none,
+// and its generation.
+func (p *ClientProvider) WalletKit() (walletrpc.WalletKitClient, uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clients.WalletKit, p.generation
+}
+
+// Towers returns the current watchtower client, nil if there is none, and
+// its generation.
+func (p *ClientProvider) Towers() (wtclientrpc.WatchtowerClientClient,
+	uint64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.clients.Towers, p.generation
+}
+
+// Generation returns the generation of the current clients.
+func (p *ClientProvider) Generation() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.generation
+}
+
+// Replaced reports whether the clients of the given generation have since
+// been replaced or cleared.
+func (p *ClientProvider) Replaced(generation uint64) bool {
+	return p.Generation() != generation
+}
+
+// recordSubserver records whether a call to the named subserver, made over
+// the clients of the given generation, found it running. Results from
+// replaced clients say nothing about the current node and are dropped.
+func (p *ClientProvider) recordSubserver(generation uint64, name string,
+	available bool, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if generation != p.generation {
+		return
+	}
+	if p.subservers == nil {
+		p.subservers = make(map[string]subserverState)
+	}
+	p.subservers[name] = subserverState{
+		available: available,
+		reason:    reason,
+		checkedAt: time.Now(),
+	}
+}
+
+// subserver returns what is known about the named subserver on the current
+// clients, and false if it has not been called yet.
+func (p *ClientProvider) subserver(name string) (subserverState, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state, ok := p.subservers[name]
+	return state, ok
+}
+
+// recordSplicing records the splice support of the node behind the clients
+// of the given generation, unless they have been replaced since.
+func (p *ClientProvider) recordSplicing(generation uint64,
+	support spliceSupport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if generation == p.generation {
+		p.splicing = &support
+	}
+}
+
+// splicingSupport returns the current node's recorded splice support, and
+// false if it has not been asked for yet.
+func (p *ClientProvider) splicingSupport() (spliceSupport, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.splicing == nil {
+		return spliceSupport{}, false
+	}
+	return *p.splicing, true
+}
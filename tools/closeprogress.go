@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// averageBlockInterval converts block counts into rough wall-clock
+	// estimates.
+	averageBlockInterval = 10 * time.Minute
+
+	// recentCloseBlocks is how far back include_completed looks for
+	// channels that finished closing, about a week.
+	recentCloseBlocks = 1008
+)
+
+// Close progress stages, in the order a channel passes through them.
+const (
+	closeStageAwaitingConfirmation = "awaiting_close_confirmation"
+	closeStageTimeLocked           = "time_locked"
+	closeStageSweeping             = "sweeping"
+	closeStageComplete             = "complete"
+)
+
+// CloseProgressTool returns the MCP tool definition for following channel
+// closes.
+func (s *ChannelService) CloseProgressTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_close_progress",
+		Description: "Follow closing channels until their funds are " +
+			"back in the on-chain wallet: whether the closing " +
+			"transaction has confirmed, which outputs are still " +
+			"time-locked and until which block, and what is " +
+			"waiting to be swept, with an estimate of when the " +
+			"funds become available",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Only report this channel " +
+						"(txid:output_index), including " +
+						"once it has finished closing",
+				},
+				"include_completed": map[string]any{
+					"type": "boolean",
+					"description": "Also report channels that " +
+						"finished closing in the last week",
+				},
+			},
+		},
+	}
+}
+
+// HandleCloseProgress handles the close progress request. Progress is
+// derived from the node's pending and closed channel lists on every call,
+// so closes initiated by either side, or before this server started, are
+// covered.
+func (s *ChannelService) HandleCloseProgress(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	channelPoint := ""
+	if value, ok := args["channel_point"].(string); ok && value != "" {
+		txid, index, err := parseChannelPoint("channel_point", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		channelPoint = fmt.Sprintf("%s:%d", txid, index)
+	}
+	includeCompleted, _ := args["include_completed"].(bool)
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	pending, err := client.PendingChannels(ctx,
+		&lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get pending channels"), nil
+	}
+
+	height := info.BlockHeight
+	wanted := func(point string) bool {
+		return channelPoint == "" || point == channelPoint
+	}
+
+	channels := make([]map[string]any, 0)
+	seen := make(map[string]bool)
+	for _, ch := range pending.WaitingCloseChannels {
+		if wanted(ch.Channel.ChannelPoint) {
+			channels = append(channels, waitingCloseProgress(ch))
+			seen[ch.Channel.ChannelPoint] = true
+		}
+	}
+	for _, ch := range pending.PendingForceClosingChannels {
+		if wanted(ch.Channel.ChannelPoint) {
+			channels = append(channels, forceCloseProgress(ch))
+			seen[ch.Channel.ChannelPoint] = true
+		}
+	}
+
+	if includeCompleted || (channelPoint != "" && len(channels) == 0) {
+		closed, err := client.ClosedChannels(ctx,
+			&lnrpc.ClosedChannelsRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get closed channels"), nil
+		}
+		for _, ch := range closed.Channels {
+			recent := ch.CloseHeight+recentCloseBlocks >= height
+			if channelPoint == "" && !recent {
+				continue
+			}
+			if wanted(ch.ChannelPoint) && !seen[ch.ChannelPoint] {
+				channels = append(channels,
+					closedProgress(ch, height))
+			}
+		}
+	}
+
+	if channelPoint != "" && len(channels) == 0 {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no closing or closed channel has that channel "+
+				"point").WithDetails(map[string]any{
+			"channel_point": channelPoint,
+		})), nil
+	}
+
+	return jsonResult("lnc_close_progress", map[string]any{
+		"block_height":            height,
+		"channels":                channels,
+		"total_limbo_balance_sat": pending.TotalLimboBalance,
+		"note": "Times are estimated from an average block " +
+			"interval of 10 minutes",
+	}), nil
+}
+
+// waitingCloseProgress describes a channel whose closing transaction has
+// not confirmed.
+func waitingCloseProgress(
+	ch *lnrpc.PendingChannelsResponse_WaitingCloseChannel) map[string]any {
+	return map[string]any{
+		"channel_point":     ch.Channel.ChannelPoint,
+		"remote_node_pub":   ch.Channel.RemoteNodePub,
+		"stage":             closeStageAwaitingConfirmation,
+		"closing_txid":      ch.ClosingTxid,
+		"limbo_balance_sat": ch.LimboBalance,
+		"summary": fmt.Sprintf("The closing transaction has not "+
+			"confirmed yet. %d sat return once it confirms, or "+
+			"after a time lock if this node force closed.",
+			ch.LimboBalance),
+	}
+}
+
+// forceCloseProgress describes a force-closed channel whose outputs are
+// still being resolved. Each output is either time-locked until its
+// maturity height or matured and waiting for its sweep to confirm.
+func forceCloseProgress(
+	ch *lnrpc.PendingChannelsResponse_ForceClosedChannel) map[string]any {
+	outputs := make([]map[string]any, 0)
+	remaining := int32(0)
+	lastMaturity := uint32(0)
+
+	if ch.MaturityHeight > 0 {
+		outputs = append(outputs, map[string]any{
+			"kind":                "commitment",
+			"maturity_height":     ch.MaturityHeight,
+			"blocks_til_maturity": ch.BlocksTilMaturity,
+			"status":              outputStatus(ch.BlocksTilMaturity),
+		})
+		remaining = max(remaining, ch.BlocksTilMaturity)
+		lastMaturity = max(lastMaturity, ch.MaturityHeight)
+	}
+	for _, htlc := range ch.PendingHtlcs {
+		kind := "outgoing_htlc"
+		if htlc.Incoming {
+			kind = "incoming_htlc"
+		}
+		outputs = append(outputs, map[string]any{
+			"kind":                kind,
+			"outpoint":            htlc.Outpoint,
+			"amount_sat":          htlc.Amount,
+			"stage":               htlc.Stage,
+			"maturity_height":     htlc.MaturityHeight,
+			"blocks_til_maturity": htlc.BlocksTilMaturity,
+			"status":              outputStatus(htlc.BlocksTilMaturity),
+		})
+		remaining = max(remaining, htlc.BlocksTilMaturity)
+		lastMaturity = max(lastMaturity, htlc.MaturityHeight)
+	}
+
+	progress := map[string]any{
+		"channel_point":         ch.Channel.ChannelPoint,
+		"remote_node_pub":       ch.Channel.RemoteNodePub,
+		"close_type":            "force",
+		"closing_txid":          ch.ClosingTxid,
+		"limbo_balance_sat":     ch.LimboBalance,
+		"recovered_balance_sat": ch.RecoveredBalance,
+		"anchor":                ch.Anchor.String(),
+		"outputs":               outputs,
+		"blocks_remaining":      remaining,
+	}
+
+	switch {
+	case remaining > 0:
+		progress["stage"] = closeStageTimeLocked
+		progress["funds_available_height"] = lastMaturity
+		progress["estimated_available_at"] = time.Now().Add(
+			time.Duration(remaining) * averageBlockInterval).UTC().
+			Format(time.RFC3339)
+		progress["summary"] = fmt.Sprintf("%d sat are time-locked "+
+			"until block %d, %d blocks or about %s from now, "+
+			"and are then swept to the wallet.", ch.LimboBalance,
+			lastMaturity, remaining,
+			approximateDuration(remaining))
+
+	case ch.LimboBalance > 0:
+		progress["stage"] = closeStageSweeping
+		progress["summary"] = fmt.Sprintf("All time locks have "+
+			"expired; %d sat are waiting for their sweep "+
+			"transactions to confirm, usually within a few "+
+			"blocks.", ch.LimboBalance)
+
+	default:
+		progress["stage"] = closeStageSweeping
+		progress["summary"] = fmt.Sprintf("No funds remain in "+
+			"limbo; %d sat have been recovered and lnd is "+
+			"resolving the last outputs.", ch.RecoveredBalance)
+	}
+
+	return progress
+}
+
+// closedProgress describes a channel that has finished closing, with how
+// each of its outputs was resolved.
+func closedProgress(ch *lnrpc.ChannelCloseSummary,
+	height uint32) map[string]any {
+	resolutions := make([]map[string]any, len(ch.Resolutions))
+	for i, res := range ch.Resolutions {
+		outpoint := ""
+		if res.Outpoint != nil {
+			outpoint = fmt.Sprintf("%s:%d", res.Outpoint.TxidStr,
+				res.Outpoint.OutputIndex)
+		}
+		resolutions[i] = map[string]any{
+			"type":       res.ResolutionType.String(),
+			"outcome":    res.Outcome.String(),
+			"outpoint":   outpoint,
+			"amount_sat": res.AmountSat,
+			"sweep_txid": res.SweepTxid,
+		}
+	}
+
+	confirmations := uint32(0)
+	if height >= ch.CloseHeight {
+		confirmations = height - ch.CloseHeight + 1
+	}
+
+	return map[string]any{
+		"channel_point":       ch.ChannelPoint,
+		"remote_node_pub":     ch.RemotePubkey,
+		"stage":               closeStageComplete,
+		"close_type":          ch.CloseType.String(),
+		"closing_txid":        ch.ClosingTxHash,
+		"close_height":        ch.CloseHeight,
+		"close_confirmations": confirmations,
+		"settled_balance_sat": ch.SettledBalance,
+		"resolutions":         resolutions,
+		"summary": fmt.Sprintf("Closed at block %d; %d sat were "+
+			"returned to the wallet.", ch.CloseHeight,
+			ch.SettledBalance),
+	}
+}
+
+// outputStatus describes a close output by its remaining time lock.
+func outputStatus(blocksTilMaturity int32) string {
+	if blocksTilMaturity > 0 {
+		return "time_locked"
+	}
+	return "awaiting_sweep"
+}
+
+// approximateDuration renders a block count as a rough duration.
+func approximateDuration(blocks int32) string {
+	d := time.Duration(blocks) * averageBlockInterval
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.0f hours", d.Hours())
+	default:
+		return fmt.Sprintf("%.0f days", d.Hours()/24)
+	}
+}
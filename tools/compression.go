@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// CompressionCapability is the experimental MCP capability a client
+	// declares, listing the encodings it can decode, to receive large
+	// tool results compressed.
+	CompressionCapability = "lnc/compression"
+
+	// CompressionEncoding is the only encoding results are compressed
+	// with: gzip, then standard base64.
+	CompressionEncoding = "gzip+base64"
+)
+
+// AcceptsCompression reports whether the experimental capabilities a client
+// declared in its initialize request ask for compressed results, as
+// {"lnc/compression": {"encodings": ["gzip+base64"]}}.
+func AcceptsCompression(experimental map[string]any) bool {
+	capability, _ := experimental[CompressionCapability].(map[string]any)
+	encodings, _ := capability["encodings"].([]any)
+	for _, encoding := range encodings {
+		if encoding == CompressionEncoding {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress replaces the JSON text of a result longer than threshold bytes
+// with an envelope carrying it compressed:
+//
+//	{"content_encoding": "gzip+base64", "data": "...", "original_bytes": n}
+//
+// Error results, text that would not shrink and the signature following a
+// signed result are left as they are, so signatures are checked against
+// the decompressed text.
+func Compress(result *mcp.CallToolResult, threshold int) error {
+	if result == nil || result.IsError || threshold <= 0 {
+		return nil
+	}
+
+	for i, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		if len(text.Text) <= threshold {
+			return nil
+		}
+
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		if _, err := writer.Write([]byte(text.Text)); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(map[string]any{
+			"content_encoding": CompressionEncoding,
+			"data": base64.StdEncoding.EncodeToString(
+				compressed.Bytes()),
+			"original_bytes": len(text.Text),
+		})
+		if err != nil {
+			return err
+		}
+		if len(data) >= len(text.Text) {
+			return nil
+		}
+
+		text.Text = string(data)
+		result.Content[i] = text
+		return nil
+	}
+
+	return nil
+}
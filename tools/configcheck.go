@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// busyNodeInvoices is the invoice count from which canceled invoices
+	// left in the database are worth collecting.
+	busyNodeInvoices = 10_000
+
+	// minPendingChannels is the smallest maxpendingchannels that lets a
+	// peer open more than one channel at a time.
+	minPendingChannels = 2
+)
+
+// Finding severities, from the most to the least urgent.
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+)
+
+// severityRank orders findings by severity.
+var severityRank = map[string]int{
+	severityHigh:   0,
+	severityMedium: 1,
+	severityLow:    2,
+}
+
+// lndConfig is lnd's configuration as GetDebugInfo flattens it: long option
+// names, prefixed by their section, with values printed by fmt.
+type lndConfig map[string]string
+
+// bool returns a boolean option, and whether lnd reported it.
+func (c lndConfig) bool(key string) (bool, bool) {
+	value, err := strconv.ParseBool(c[key])
+	return value, err == nil
+}
+
+// int returns an integer option, and whether lnd reported it.
+func (c lndConfig) int(key string) (int64, bool) {
+	value, err := strconv.ParseInt(c[key], 10, 64)
+	return value, err == nil
+}
+
+// list returns a list option, printed by fmt as [a b].
+func (c lndConfig) list(key string) []string {
+	return strings.Fields(strings.Trim(c[key], "[]"))
+}
+
+// ConfigCheckTool returns the MCP tool definition for checking the node's
+// configuration.
+func (s *NodeService) ConfigCheckTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_config_check",
+		Description: "Check the node's lnd configuration for risky " +
+			"or inconsistent settings, such as clearnet " +
+			"connections leaking past Tor, a tiny " +
+			"maxpendingchannels or " +
+			"canceled invoices piling up on a busy node, and " +
+			"list recommendations, most urgent first. Only the " +
+			"settings behind a finding are returned",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleConfigCheck handles the config check request.
+func (s *NodeService) HandleConfigCheck(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	debug, err := client.GetDebugInfo(ctx, &lnrpc.GetDebugInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get debug info"), nil
+	}
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+
+	// The invoice count only matters while canceled invoices are kept.
+	invoiceCount := uint64(0)
+	config := lndConfig(debug.Config)
+	gcStartup, _ := config.bool("gc-canceled-invoices-on-startup")
+	gcOnTheFly, _ := config.bool("gc-canceled-invoices-on-the-fly")
+	if !gcStartup && !gcOnTheFly {
+		invoices, err := client.ListInvoices(ctx,
+			&lnrpc.ListInvoiceRequest{
+				NumMaxInvoices: 1,
+				Reversed:       true,
+			})
+		if err != nil {
+			return rpcError(err, "failed to list invoices"), nil
+		}
+		invoiceCount = invoices.LastIndexOffset
+	}
+
+	channels := info.NumActiveChannels + info.NumInactiveChannels +
+		info.NumPendingChannels
+	findings := checkConfig(config, channels, invoiceCount)
+	recommendations := make([]string, len(findings))
+	for i, finding := range findings {
+		recommendations[i] = finding["recommendation"].(string)
+	}
+
+	return jsonResult("lnc_config_check", map[string]any{
+		"settings_reported": len(config),
+		"findings":          findings,
+		"finding_count":     len(findings),
+		"recommendations":   recommendations,
+	}), nil
+}
+
+// checkConfig returns the findings for a configuration, most severe first,
+// for a node with the given number of channels and invoices.
+func checkConfig(config lndConfig, channels uint32,
+	invoices uint64) []map[string]any {
+	findings := make([]map[string]any, 0)
+	add := func(check, severity, message, recommendation string,
+		keys ...string) {
+		settings := make(map[string]string, len(keys))
+		for _, key := range keys {
+			settings[key] = config[key]
+		}
+		findings = append(findings, map[string]any{
+			"check":          check,
+			"severity":       severity,
+			"message":        message,
+			"recommendation": recommendation,
+			"settings":       settings,
+		})
+	}
+
+	if noMacaroons, _ := config.bool("no-macaroons"); noMacaroons {
+		add("no_macaroons", severityHigh, "macaroon authentication "+
+			"is disabled, so anyone who reaches the RPC port "+
+			"controls the node's funds",
+			"remove no-macaroons", "no-macaroons")
+	}
+
+	if tor, _ := config.bool("tor.active"); tor {
+		skip, _ := config.bool("tor.skip-proxy-for-clearnet-targets")
+		if skip {
+			add("tor_clearnet_leak", severityHigh, "Tor is active "+
+				"but connections to clearnet peers bypass it, "+
+				"revealing the node's IP address to them",
+				"unset tor.skip-proxy-for-clearnet-targets "+
+					"unless hybrid mode is intended",
+				"tor.active",
+				"tor.skip-proxy-for-clearnet-targets")
+		}
+		if len(config.list("externalip")) > 0 {
+			add("tor_external_ip", severityMedium, "Tor is "+
+				"active but externalip advertises a clearnet "+
+				"address, tying the node to its IP",
+				"remove externalip unless hybrid mode is "+
+					"intended", "tor.active", "externalip")
+		}
+		if nat, _ := config.bool("nat"); nat {
+			add("tor_nat", severityMedium, "Tor is active but "+
+				"NAT traversal discovers and advertises the "+
+				"node's public IP address",
+				"unset nat", "tor.active", "nat")
+		}
+		isolation, _ := config.bool("tor.streamisolation")
+		if !isolation && !skip {
+			add("tor_stream_isolation", severityLow, "Tor "+
+				"connections share circuits, so they can be "+
+				"linked to one another",
+				"set tor.streamisolation", "tor.active",
+				"tor.streamisolation")
+		}
+	}
+
+	if wtclient, ok := config.bool("wtclient.active"); ok && !wtclient &&
+		channels > 0 {
+		add("no_watchtower", severityMedium, fmt.Sprintf("the "+
+			"watchtower client is off, so nothing defends the "+
+			"node's %d channels against a revoked state while it "+
+			"is offline", channels),
+			"set wtclient.active and add a tower",
+			"wtclient.active")
+	}
+
+	minChan, minSet := config.int("minchansize")
+	maxChan, maxSet := config.int("maxchansize")
+	if minSet && maxSet && minChan > 0 && maxChan > 0 &&
+		maxChan < minChan {
+		add("channel_size_range", severityMedium, "maxchansize is "+
+			"below minchansize, so every incoming channel is "+
+			"rejected", "raise maxchansize above minchansize",
+			"minchansize", "maxchansize")
+	}
+
+	if invoices >= busyNodeInvoices {
+		add("canceled_invoices_kept", severityMedium, fmt.Sprintf(
+			"the node has created %d invoices and keeps the "+
+				"canceled ones, which grows the database and "+
+				"slows invoice lookups", invoices),
+			"set gc-canceled-invoices-on-the-fly, and "+
+				"gc-canceled-invoices-on-startup once to "+
+				"collect the backlog",
+			"gc-canceled-invoices-on-startup",
+			"gc-canceled-invoices-on-the-fly")
+	}
+
+	pending, ok := config.int("maxpendingchannels")
+	if ok && pending < minPendingChannels {
+		add("max_pending_channels", severityLow, fmt.Sprintf("a "+
+			"peer can only have %d channel pending with the "+
+			"node, so batch opens and LSPs opening several at "+
+			"once fail", pending),
+			fmt.Sprintf("raise maxpendingchannels to at least %d",
+				minPendingChannels), "maxpendingchannels")
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i]["severity"].(string)] <
+			severityRank[findings[j]["severity"].(string)]
+	})
+	return findings
+}
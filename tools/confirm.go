@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// confirmationArgument is the argument that carries a confirmation
+	// ID back to a tool that requires one.
+	confirmationArgument = "confirmation_id"
+
+	// defaultConfirmationTTL is how long a preview can be confirmed.
+	defaultConfirmationTTL = 5 * time.Minute
+)
+
+// pendingConfirmation is an action that was previewed but not yet run.
+type pendingConfirmation struct {
+	tool      string
+	digest    [sha256.Size]byte
+	expiresAt time.Time
+}
+
+// confirmationStore implements the two-step flow for tools that move funds:
+// the first call returns a preview and a confirmation ID, and only a second
+// call with that ID and identical arguments performs the action. IDs are
+// single-use and expire, so a stale or replayed confirmation cannot spend.
+type confirmationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]pendingConfirmation
+}
+
+// newConfirmationStore creates a store whose IDs expire after ttl.
+func newConfirmationStore(ttl time.Duration) *confirmationStore {
+	return &confirmationStore{
+		ttl:     ttl,
+		pending: make(map[string]pendingConfirmation),
+	}
+}
+
+// issue records a preview of tool with args and returns its confirmation
+// ID and expiry.
+func (c *confirmationStore) issue(tool string,
+	args map[string]any) (string, time.Time, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(id[:])
+	expiresAt := time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pruneLocked()
+	c.pending[token] = pendingConfirmation{
+		tool:      tool,
+		digest:    argumentsDigest(args),
+		expiresAt: expiresAt,
+	}
+
+	return token, expiresAt, nil
+}
+
+// redeem consumes a confirmation ID. It reports whether the ID was issued
+// for the same tool and arguments and has not expired. An ID is consumed
+// even when the arguments do not match, so it cannot be probed.
+func (c *confirmationStore) redeem(tool, token string,
+	args map[string]any) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok {
+		return false
+	}
+	delete(c.pending, token)
+
+	return pending.tool == tool &&
+		pending.digest == argumentsDigest(args) &&
+		time.Now().Before(pending.expiresAt)
+}
+
+// pruneLocked drops expired confirmations. The caller must hold the lock.
+func (c *confirmationStore) pruneLocked() {
+	now := time.Now()
+	for token, pending := range c.pending {
+		if !now.Before(pending.expiresAt) {
+			delete(c.pending, token)
+		}
+	}
+}
+
+// argumentsDigest hashes the arguments other than the confirmation ID.
+// Map keys are marshalled in sorted order, so equal arguments always
+// produce the same digest.
+func argumentsDigest(args map[string]any) [sha256.Size]byte {
+	filtered := make(map[string]any, len(args))
+	for key, value := range args {
+		if key != confirmationArgument {
+			filtered[key] = value
+		}
+	}
+
+	data, _ := json.Marshal(filtered)
+	return sha256.Sum256(data)
+}
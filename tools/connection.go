@@ -7,13 +7,16 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
 	"github.com/jbrill/mcp-lnc-server/internal/logging"
 	"github.com/lightninglabs/lightning-node-connect/mailbox"
 	"github.com/lightningnetwork/lnd/keychain"
@@ -27,14 +30,63 @@ import (
 type ConnectionService struct {
 	Connection         *grpc.ClientConn
 	ConnectionCallback func(*grpc.ClientConn)
+
+	// DisconnectCallback, when set, is called after lnc_disconnect or
+	// Disconnect closes the connection.
+	DisconnectCallback func()
+
+	// DefaultMailbox, when set, is used ahead of LNC_MAILBOX_SERVER if
+	// the request does not name a mailbox.
+	DefaultMailbox string
+
+	// RequiredNetwork, when set, rejects nodes on any other network.
+	RequiredNetwork string
+
+	// AllowWalletUnlock keeps the connection to a node whose wallet is
+	// locked, for lnc_unlock_wallet to unlock. For development nodes
+	// only: the wallet password passes through the client.
+	AllowWalletUnlock bool
+
+	// lockedConn is the connection waiting for its node's wallet to be
+	// unlocked.
+	lockedMu   sync.Mutex
+	lockedConn *grpc.ClientConn
+
+	// session holds what the last connection was established with, in
+	// memory only, for Reconnect. lnc_disconnect forgets it.
+	sessionMu sync.Mutex
+	session   *lncSession
+
+	// dial opens an LNC connection; tests replace it.
+	dial func(ctx context.Context, pairingPhrase, password,
+		mailboxServer string, devMode, insecure bool) (*grpc.ClientConn,
+		*lnrpc.GetInfoResponse, error)
+
+	// State tracks the connection lifecycle for error reporting.
+	State *ConnectionState
 }
 
-// NewConnectionService creates a new connection service.
+// NewConnectionService creates a new connection service with a connection
+// state of its own. Set State to that of the ClientProvider the callback
+// fills, so handlers using those clients report this connection.
 func NewConnectionService(
 	callback func(*grpc.ClientConn)) *ConnectionService {
-	return &ConnectionService{
+	s := &ConnectionService{
 		ConnectionCallback: callback,
+		State:              NewConnectionState(),
 	}
+	s.dial = s.connectToLNC
+	return s
+}
+
+// lncSession is what an LNC connection was established with.
+type lncSession struct {
+	pairingPhrase string
+	password      string
+	mailboxServer string
+	proxy         *url.URL
+	devMode       bool
+	insecure      bool
 }
 
 // ConnectTool returns the MCP tool definition for connecting to LNC.
@@ -58,6 +110,14 @@ func (s *ConnectionService) ConnectTool() mcp.Tool {
 					"description": "Custom mailbox server address " +
 						"(optional, e.g., 'localhost:11110' for regtest)",
 				},
+				"proxy": map[string]any{
+					"type": "string",
+					"description": "Proxy to reach the mailbox " +
+						"through, such as " +
+						"socks5h://127.0.0.1:9050 for Tor " +
+						"or http://proxy:3128 (optional, " +
+						"defaults to LNC_MAILBOX_PROXY)",
+				},
 				"devMode": map[string]any{
 					"type":        "boolean",
 					"description": "Enable dev mode for local/regtest environments (optional)",
@@ -91,13 +151,13 @@ func (s *ConnectionService) HandleConnect(ctx context.Context,
 	pairingPhrase, ok := request.Params.Arguments["pairingPhrase"].(string)
 	if !ok {
 		logger.Error("Missing pairing phrase in request")
-		return mcp.NewToolResultError("pairingPhrase is required"), nil
+		return invalidArgumentError("pairingPhrase is required"), nil
 	}
 
 	password, ok := request.Params.Arguments["password"].(string)
 	if !ok {
 		logger.Error("Missing password in request")
-		return mcp.NewToolResultError("password is required"), nil
+		return invalidArgumentError("password is required"), nil
 	}
 
 	// Validate pairing phrase format
@@ -105,12 +165,15 @@ func (s *ConnectionService) HandleConnect(ctx context.Context,
 	if len(words) != 10 {
 		logger.Error("Invalid pairing phrase format",
 			zap.Int("word_count", len(words)))
-		return mcp.NewToolResultError(
-			"pairingPhrase must contain exactly 10 words"), nil
+		return toolError(errors.ErrInvalidPairingPhrase(
+			"must contain exactly 10 words")), nil
 	}
 
 	// Get connection parameters with environment variable defaults
 	mailboxServer := getMailboxServer(request.Params.Arguments)
+	if mailboxServer == "" && s.DefaultMailbox != "" {
+		mailboxServer = s.DefaultMailbox
+	}
 	if mailboxServer == "" {
 		if envMailbox := os.Getenv("LNC_MAILBOX_SERVER"); envMailbox != "" {
 			mailboxServer = envMailbox
@@ -119,6 +182,20 @@ func (s *ConnectionService) HandleConnect(ctx context.Context,
 		}
 	}
 
+	// Get the mailbox proxy with environment variable default
+	proxyValue, _ := request.Params.Arguments["proxy"].(string)
+	if proxyValue == "" {
+		proxyValue = os.Getenv("LNC_MAILBOX_PROXY")
+	}
+	var proxy *url.URL
+	if proxyValue != "" {
+		parsed, err := parseProxyURL(proxyValue)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		proxy = parsed
+	}
+
 	// Check for dev mode with environment variable default
 	devMode := false
 	if dev, ok := request.Params.Arguments["devMode"].(bool); ok {
@@ -144,26 +221,89 @@ func (s *ConnectionService) HandleConnect(ctx context.Context,
 	}
 
 	// Use request context for connection (it already has timeout)
+	proxyName := ""
+	if proxy != nil {
+		proxyName = proxy.Redacted()
+	}
 	logger.Info("Attempting LNC connection",
 		zap.String("mailbox", mailboxServer),
+		zap.String("proxy", proxyName),
 		zap.Bool("devMode", devMode),
 		zap.Bool("insecure", insecure),
 		zap.Duration("timeout", timeout),
 	)
 
 	// Establish LNC connection
-	conn, nodeInfo, err := s.connectToLNC(reqCtx, pairingPhrase,
-		password, mailboxServer, devMode, insecure)
+	setMailboxProxy(mailboxServer, proxy)
+	s.State.SetConnecting()
+	conn, nodeInfo, err := s.dial(reqCtx, pairingPhrase, password,
+		mailboxServer, devMode, insecure)
 	if err != nil {
 		logger.Error("LNC connection failed",
 			zap.Error(err),
 			zap.Duration("failed_after", reqCtx.Duration()))
-		return mcp.NewToolResultError(fmt.Sprintf(
-			"Failed to connect to Lightning node: %v", err)), nil
+		if notReady, ok := err.(*walletNotReadyError); ok {
+			return s.walletNotReady(notReady), nil
+		}
+		s.State.SetFailed(err.Error())
+
+		diagnosis := diagnoseMailbox(mailboxServer, proxy)
+		logger.Info("Diagnosed mailbox dial",
+			zap.Any("diagnosis", diagnosis))
+		return toolError(errors.ErrConnectionFailed(err,
+			mailboxServer).WithDetails(map[string]any{
+			"diagnosis": diagnosis,
+		})), nil
+	}
+
+	summary, failure := s.establish(reqCtx, conn, nodeInfo)
+	if failure != nil {
+		return failure, nil
+	}
+	s.sessionMu.Lock()
+	s.session = &lncSession{
+		pairingPhrase: pairingPhrase,
+		password:      password,
+		mailboxServer: mailboxServer,
+		proxy:         proxy,
+		devMode:       devMode,
+		insecure:      insecure,
+	}
+	s.sessionMu.Unlock()
+	summary["mailbox_server"] = mailboxServer
+	if proxy != nil {
+		summary["mailbox_proxy"] = proxy.Redacted()
+	}
+
+	return jsonResult("lnc_connect", summary), nil
+}
+
+// establish makes conn, whose node answered GetInfo with nodeInfo, the
+// service's connection and notifies the manager. A node on a network not
+// allowed here is disconnected instead. It returns a summary of the node,
+// or the error result to return.
+func (s *ConnectionService) establish(reqCtx *lnccontext.RequestContext,
+	conn *grpc.ClientConn,
+	nodeInfo *lnrpc.GetInfoResponse) (map[string]any, *mcp.CallToolResult) {
+	logger := logging.LogWithContext(reqCtx)
+
+	if s.RequiredNetwork != "" && nodeNetwork(nodeInfo) != s.RequiredNetwork {
+		logger.Error("Node is on the wrong network",
+			zap.String("network", nodeNetwork(nodeInfo)),
+			zap.String("required", s.RequiredNetwork))
+		conn.Close()
+		s.State.SetFailed("node is on " + nodeNetwork(nodeInfo))
+		return nil, toolError(errors.New(errors.ErrCodePermissionDenied,
+			fmt.Sprintf("node is on %s, only %s nodes are allowed "+
+				"here", nodeNetwork(nodeInfo),
+				s.RequiredNetwork)))
 	}
 
 	// Store connection
 	s.Connection = conn
+	s.State.SetNode(nodeInfo.IdentityPubkey, nodeNetwork(nodeInfo))
+	s.State.SetConnected()
+	go s.State.watch(conn)
 
 	// Add node ID to context for future operations
 	reqCtx = reqCtx.WithNode(nodeInfo.IdentityPubkey)
@@ -179,17 +319,50 @@ func (s *ConnectionService) HandleConnect(ctx context.Context,
 		zap.Uint32("num_channels", nodeInfo.NumActiveChannels),
 		zap.Uint32("num_peers", nodeInfo.NumPeers))
 
-	// Return success response
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"connected": true,
-		"node_pubkey": "%s",
-		"alias": "%s",
-		"num_channels": %d,
-		"num_peers": %d,
-		"version": "%s",
-		"mailbox_server": "%s"
-	}`, nodeInfo.IdentityPubkey, nodeInfo.Alias, nodeInfo.NumActiveChannels,
-		nodeInfo.NumPeers, nodeInfo.Version, mailboxServer)), nil
+	return map[string]any{
+		"connected":    true,
+		"node_pubkey":  nodeInfo.IdentityPubkey,
+		"alias":        nodeInfo.Alias,
+		"num_channels": nodeInfo.NumActiveChannels,
+		"num_peers":    nodeInfo.NumPeers,
+		"version":      nodeInfo.Version,
+	}, nil
+}
+
+// Reconnect opens a new LNC session with what the last connection was
+// established with, as an instance does on taking the leader lease back.
+// It reports false when no connection was established since the last
+// lnc_disconnect, leaving lnc_connect to the client.
+func (s *ConnectionService) Reconnect(ctx context.Context) (bool, error) {
+	s.sessionMu.Lock()
+	session := s.session
+	s.sessionMu.Unlock()
+	if session == nil {
+		return false, nil
+	}
+
+	reqCtx := lnccontext.New(ctx, "lnc_reconnect", 45*time.Second)
+	defer reqCtx.Cancel()
+
+	setMailboxProxy(session.mailboxServer, session.proxy)
+	s.State.SetConnecting()
+	conn, nodeInfo, err := s.dial(reqCtx, session.pairingPhrase,
+		session.password, session.mailboxServer, session.devMode,
+		session.insecure)
+	if notReady, ok := err.(*walletNotReadyError); ok {
+		s.walletNotReady(notReady)
+		return true, err
+	}
+	if err != nil {
+		s.State.SetFailed(err.Error())
+		return true, err
+	}
+
+	if _, failure := s.establish(reqCtx, conn, nodeInfo); failure != nil {
+		return true, fmt.Errorf("node is on %s, only %s nodes are "+
+			"allowed", nodeNetwork(nodeInfo), s.RequiredNetwork)
+	}
+	return true, nil
 }
 
 // ConnectToLNC establishes the actual LNC connection.
@@ -333,6 +506,21 @@ func (s *ConnectionService) connectToLNC(ctx context.Context,
 		logger.Error("Failed to get node info",
 			zap.Error(err),
 			zap.Duration("failed_after", reqCtx.Duration()))
+
+		// A node whose wallet is not unlocked only serves the state
+		// and unlocker services. A locked wallet's connection is kept
+		// when lnc_unlock_wallet may unlock it.
+		state, known := walletStateOf(reqCtx, conn, err)
+		if known && !walletReady(state) {
+			notReady := &walletNotReadyError{state: state}
+			if state == lnrpc.WalletState_LOCKED && s.AllowWalletUnlock {
+				notReady.conn = conn
+			} else {
+				conn.Close()
+			}
+			return nil, nil, notReady
+		}
+
 		conn.Close()
 		return nil, nil, fmt.Errorf("connected but failed to get node info: %w", err)
 	}
@@ -363,9 +551,25 @@ func (s *ConnectionService) HandleDisconnect(ctx context.Context,
 	// Create request context
 	reqCtx := lnccontext.New(ctx, "lnc_disconnect", 10*time.Second)
 	defer reqCtx.Cancel()
-	logger := logging.LogWithContext(reqCtx)
 
-	logger.Info("Disconnecting from Lightning node")
+	s.Disconnect(reqCtx, "closed by lnc_disconnect")
+	s.sessionMu.Lock()
+	s.session = nil
+	s.sessionMu.Unlock()
+
+	return jsonResult("lnc_disconnect", map[string]any{
+		"disconnected": true,
+		"message":      "Disconnected from Lightning node",
+	}), nil
+}
+
+// Disconnect closes the node connection, and any connection waiting for
+// its wallet to be unlocked, recording reason as the cause.
+func (s *ConnectionService) Disconnect(ctx context.Context, reason string) {
+	logger := logging.LogWithContext(ctx)
+
+	logger.Info("Disconnecting from Lightning node",
+		zap.String("reason", reason))
 
 	if s.Connection != nil {
 		err := s.Connection.Close()
@@ -375,14 +579,29 @@ func (s *ConnectionService) HandleDisconnect(ctx context.Context,
 			logger.Info("Connection closed successfully")
 		}
 		s.Connection = nil
+		s.State.SetDisconnected(reason, false)
+		if s.DisconnectCallback != nil {
+			s.DisconnectCallback()
+		}
 	} else {
 		logger.Debug("No active connection to close")
 	}
 
-	return mcp.NewToolResultText(`{
-		"disconnected": true,
-		"message": "Disconnected from Lightning node"
-	}`), nil
+	s.lockedMu.Lock()
+	if s.lockedConn != nil {
+		s.lockedConn.Close()
+		s.lockedConn = nil
+	}
+	s.lockedMu.Unlock()
+}
+
+// nodeNetwork returns the network the node reports, such as "mainnet" or
+// "regtest".
+func nodeNetwork(info *lnrpc.GetInfoResponse) string {
+	if len(info.Chains) == 0 {
+		return ""
+	}
+	return info.Chains[0].Network
 }
 
 // GetMailboxServer retrieves the mailbox server from tool arguments.
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnectionStatus describes the lifecycle state of a node connection.
+type ConnectionStatus string
+
+const (
+	// StatusNeverConnected means no connection has been attempted yet.
+	StatusNeverConnected ConnectionStatus = "never_connected"
+
+	// StatusConnecting means lnc_connect is in progress.
+	StatusConnecting ConnectionStatus = "connecting"
+
+	// StatusConnected means the node is reachable.
+	StatusConnected ConnectionStatus = "connected"
+
+	// StatusDisconnected means a previous connection was closed or lost.
+	StatusDisconnected ConnectionStatus = "disconnected"
+
+	// StatusFailed means the last connection attempt failed.
+	StatusFailed ConnectionStatus = "failed"
+)
+
+// ConnectionState tracks a node connection so "not connected" errors can
+// tell the client what happened and what to do next. Each ClientProvider
+// has one for the connection its clients come from.
+type ConnectionState struct {
+	mu sync.RWMutex
+
+	status          ConnectionStatus
+	lastConnectedAt time.Time
+	lastReason      string
+	reconnecting    bool
+
+	// The identity and network of the node last connected to.
+	nodePubkey string
+	network    string
+}
+
+// NewConnectionState creates a tracker in the never-connected state.
+func NewConnectionState() *ConnectionState {
+	return &ConnectionState{
+		status: StatusNeverConnected,
+	}
+}
+
+// SetConnecting records that a connection attempt has started.
+func (s *ConnectionState) SetConnecting() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = StatusConnecting
+}
+
+// SetConnected records a successful connection.
+func (s *ConnectionState) SetConnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = StatusConnected
+	s.lastConnectedAt = time.Now()
+	s.reconnecting = false
+}
+
+// SetNode records the identity and network of the node being connected
+// to.
+func (s *ConnectionState) SetNode(pubkey, network string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nodePubkey = pubkey
+	s.network = network
+}
+
+// Node returns the identity and network of the connected node, or empty
+// strings while no node is connected. A connection the transport is
+// re-establishing still counts, since it returns to the same node.
+func (s *ConnectionState) Node() (pubkey, network string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.status != StatusConnected && !s.reconnecting {
+		return "", ""
+	}
+	return s.nodePubkey, s.network
+}
+
+// Status returns the current connection status.
+func (s *ConnectionState) Status() ConnectionStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status
+}
+
+// SetFailed records a failed connection attempt and why it failed.
+func (s *ConnectionState) SetFailed(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = StatusFailed
+	s.lastReason = reason
+	s.reconnecting = false
+}
+
+// SetDisconnected records a closed or lost connection. reconnecting is true
+// while the transport is retrying on its own.
+func (s *ConnectionState) SetDisconnected(reason string, reconnecting bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = StatusDisconnected
+	s.lastReason = reason
+	s.reconnecting = reconnecting
+}
+
+// Details returns the current state for inclusion in error payloads.
+func (s *ConnectionState) Details() map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	details := map[string]any{
+		"connection_status": string(s.status),
+		"reconnecting":      s.reconnecting,
+		"hint":              s.hintLocked(),
+	}
+	if !s.lastConnectedAt.IsZero() {
+		details["last_connected_at"] = s.lastConnectedAt.UTC().
+			Format(time.RFC3339)
+	}
+	if s.lastReason != "" {
+		details["last_disconnect_reason"] = s.lastReason
+	}
+
+	return details
+}
+
+// hintLocked suggests the next step for the client. The caller must hold
+// the lock.
+func (s *ConnectionState) hintLocked() string {
+	switch {
+	case s.reconnecting:
+		return "The connection dropped and is being re-established; " +
+			"retry shortly."
+	case s.status == StatusConnecting:
+		return "A connection attempt is in progress; retry shortly."
+	case s.status == StatusFailed:
+		return "The last lnc_connect failed. Check the pairing " +
+			"phrase, password and mailbox, then call lnc_connect again."
+	case s.status == StatusDisconnected:
+		return "Call lnc_connect to reconnect. A fresh pairing phrase " +
+			"may be needed if the previous one was single-use."
+	default:
+		return "Call lnc_connect with a pairing phrase and password."
+	}
+}
+
+// watch follows the gRPC connection's state until it shuts down, recording
+// drops and the transport's automatic reconnection attempts.
+func (s *ConnectionState) watch(conn *grpc.ClientConn) {
+	ctx := context.Background()
+	state := conn.GetState()
+	for {
+		switch state {
+		case connectivity.Ready:
+			s.SetConnected()
+		case connectivity.TransientFailure:
+			s.SetDisconnected("connection to node lost", true)
+		case connectivity.Shutdown:
+			return
+		}
+
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+		state = conn.GetState()
+	}
+}
@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// contactFields are the contact fields lnc_set_contact takes, with their
+// descriptions.
+var contactFields = map[string]string{
+	"name": "The person's name, such as \"Bob\"",
+	"organization": "The company or project running the node, " +
+		"such as \"ACINQ\"",
+	"notes":    "Free-form notes, such as how the channel came about",
+	"telegram": "Telegram username",
+	"nostr":    "Nostr npub, hex public key or NIP-05 identifier",
+}
+
+// ContactService manages the operator's contact book, which names the
+// nodes the operator has channels with.
+type ContactService struct {
+	Book *contacts.Book
+}
+
+// NewContactService creates a new contact service.
+func NewContactService(book *contacts.Book) *ContactService {
+	return &ContactService{Book: book}
+}
+
+// ListContactsTool returns the MCP tool definition for listing contacts.
+func (s *ContactService) ListContactsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_contacts",
+		Description: "List the operator's contacts: the names, " +
+			"organizations, notes and Telegram or Nostr details " +
+			"recorded for node pubkeys. Peer and channel results " +
+			"carry the same details in their contact field",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"query": map[string]any{
+					"type":        "string",
+					"description": "Only list contacts with this text in a field or pubkey (case-insensitive)",
+				},
+			},
+		},
+	}
+}
+
+// HandleListContacts handles the list contacts request.
+func (s *ContactService) HandleListContacts(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, _ := request.Params.Arguments["query"].(string)
+	query = strings.ToLower(query)
+
+	all := s.Book.All()
+	list := make([]map[string]any, 0, len(all))
+	for _, contact := range all {
+		if query != "" && !contactMatches(contact, query) {
+			continue
+		}
+		entry := contactMap(contact)
+		entry["pubkey"] = contact.Pubkey
+		list = append(list, entry)
+	}
+
+	return jsonResult("lnc_list_contacts", map[string]any{
+		"contacts":       list,
+		"count":          len(list),
+		"total_contacts": len(all),
+	}), nil
+}
+
+// SetContactTool returns the MCP tool definition for adding or editing a
+// contact.
+func (s *ContactService) SetContactTool() mcp.Tool {
+	properties := map[string]any{
+		"pubkey": map[string]any{
+			"type":        "string",
+			"description": "Public key of the node, in hex",
+		},
+	}
+	for field, description := range contactFields {
+		properties[field] = map[string]any{
+			"type": "string",
+			"description": description + ". Omit it to keep " +
+				"the current value; an empty string clears it",
+		}
+	}
+
+	return mcp.Tool{
+		Name: "lnc_set_contact",
+		Description: "Add or edit the contact for a node pubkey, so " +
+			"peers and channels are named after the person or " +
+			"organization behind them. Only the fields given " +
+			"change. This edits the server's local contact book, " +
+			"not the node",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: properties,
+			Required:   []string{"pubkey"},
+		},
+	}
+}
+
+// HandleSetContact handles the set contact request.
+func (s *ContactService) HandleSetContact(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	pubkey, _ := args["pubkey"].(string)
+	if _, err := parsePubkey("pubkey", pubkey); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	contact, existed := s.Book.Get(pubkey)
+	contact.Pubkey = pubkey
+	fields := map[string]*string{
+		"name":         &contact.Name,
+		"organization": &contact.Organization,
+		"notes":        &contact.Notes,
+		"telegram":     &contact.Telegram,
+		"nostr":        &contact.Nostr,
+	}
+	for field, target := range fields {
+		value, ok := args[field]
+		if !ok {
+			continue
+		}
+		text, ok := value.(string)
+		if !ok {
+			return invalidArgumentError(field + " must be a " +
+				"string"), nil
+		}
+		*target = strings.TrimSpace(text)
+	}
+
+	if err := contact.Validate(); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	stored, err := s.Book.Set(contact)
+	switch {
+	case err == contacts.ErrDisabled:
+		return contactsDisabledError(), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to save contact")), nil
+	}
+
+	entry := contactMap(stored)
+	entry["pubkey"] = stored.Pubkey
+	return jsonResult("lnc_set_contact", map[string]any{
+		"contact": entry,
+		"created": !existed,
+	}), nil
+}
+
+// DeleteContactTool returns the MCP tool definition for deleting a
+// contact.
+func (s *ContactService) DeleteContactTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_delete_contact",
+		Description: "Delete the contact for a node pubkey from the " +
+			"server's local contact book",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"pubkey": map[string]any{
+					"type":        "string",
+					"description": "Public key of the node, in hex",
+				},
+			},
+			Required: []string{"pubkey"},
+		},
+	}
+}
+
+// HandleDeleteContact handles the delete contact request.
+func (s *ContactService) HandleDeleteContact(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pubkey, _ := request.Params.Arguments["pubkey"].(string)
+	if _, err := parsePubkey("pubkey", pubkey); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	deleted, err := s.Book.Delete(pubkey)
+	switch {
+	case err == contacts.ErrDisabled:
+		return contactsDisabledError(), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to save contacts")), nil
+	}
+
+	return jsonResult("lnc_delete_contact", map[string]any{
+		"pubkey":  strings.ToLower(pubkey),
+		"deleted": deleted,
+	}), nil
+}
+
+// contactsDisabledError reports that no contact book is configured.
+func contactsDisabledError() *mcp.CallToolResult {
+	return toolError(errors.New(errors.ErrCodeUnsupported, "the "+
+		"contact book is not enabled; set LNC_CONTACTS_PATH to "+
+		"enable it"))
+}
+
+// contactMatches reports whether a contact has query, in lower case, in
+// any of its fields.
+func contactMatches(contact contacts.Contact, query string) bool {
+	for _, field := range []string{
+		contact.Pubkey, contact.Name, contact.Organization,
+		contact.Notes, contact.Telegram, contact.Nostr,
+	} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// contactMap formats a contact's details, leaving out empty fields.
+func contactMap(contact contacts.Contact) map[string]any {
+	entry := map[string]any{
+		"updated_at": contact.UpdatedAt.Format(time.RFC3339),
+	}
+	if label := contact.Label(); label != "" {
+		entry["label"] = label
+	}
+	for field, value := range map[string]string{
+		"name":         contact.Name,
+		"organization": contact.Organization,
+		"notes":        contact.Notes,
+		"telegram":     contact.Telegram,
+		"nostr":        contact.Nostr,
+	} {
+		if value != "" {
+			entry[field] = value
+		}
+	}
+	return entry
+}
+
+// addContact adds the contact recorded for pubkey to a peer or channel
+// entry, if there is one.
+func addContact(book *contacts.Book, entry map[string]any, pubkey string) {
+	if contact, ok := book.Get(pubkey); ok {
+		entry["contact"] = contactMap(contact)
+	}
+}
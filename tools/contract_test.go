@@ -0,0 +1,2092 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/jbrill/mcp-lnc-server/internal/signing"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/macaroon.v2"
+)
+
+// contractExempt lists tools the contract tests cannot drive, and why.
+var contractExempt = map[string]string{
+	"lnc_connect":         "needs a live LNC mailbox",
+	"lnc_sandbox_connect": "needs a live LNC mailbox",
+	"lnc_splice_channel":  "always reports Unsupported until lnd exposes splicing",
+	"lnc_unlock_wallet":   "needs a connection waiting on a locked wallet",
+}
+
+var (
+	contractAddress  = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	contractPubkey   = "02" + strings.Repeat("ab", 32)
+	contractHash     = strings.Repeat("cd", 32)
+	contractOutpoint = strings.Repeat("ef", 32) + ":1"
+
+	// contractTaprootAddress is an address of the wallet's default
+	// account.
+	contractTaprootAddress = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs" +
+		"20cac6yqjjwudpxqkedrcr"
+
+	// contractXpub is the account key of the wallet's default account.
+	contractXpub = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHw" +
+		"CD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+
+	// contractRoute is a two-hop route in the form lncli prints.
+	contractRoute = map[string]any{
+		"total_time_lock": float64(800_160),
+		"hops": []any{
+			map[string]any{
+				"chan_id":             "871234567890123777",
+				"pub_key":             contractPubkey,
+				"amt_to_forward_msat": "250000",
+				"fee_msat":            "1000",
+				"expiry":              float64(800_120),
+			},
+			map[string]any{
+				"chan_id":             "871234567890123999",
+				"pub_key":             contractRoutePubkey,
+				"amt_to_forward_msat": "250000",
+				"fee_msat":            "0",
+				"expiry":              float64(800_080),
+			},
+		},
+	}
+	contractRoutePubkey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+)
+
+// contractClient serves a fully populated fixture for every RPC the tools
+// use, so every optional field of every result is exercised.
+type contractClient struct {
+	lnrpc.LightningClient
+}
+
+func (c *contractClient) GetInfo(ctx context.Context,
+	req *lnrpc.GetInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.GetInfoResponse, error) {
+	return &lnrpc.GetInfoResponse{
+		IdentityPubkey:      contractPubkey,
+		Alias:               "contract",
+		Version:             "0.20.0-beta commit=v0.20.0-beta",
+		NumPeers:            1,
+		NumActiveChannels:   1,
+		NumInactiveChannels: 1,
+		NumPendingChannels:  1,
+		SyncedToChain:       true,
+		SyncedToGraph:       true,
+		BlockHeight:         800_000,
+		BestHeaderTimestamp: 1_690_000_000,
+		BlockHash:           contractHash,
+		Chains: []*lnrpc.Chain{
+			{Chain: "bitcoin", Network: "mainnet"},
+		},
+		Features: map[uint32]*lnrpc.Feature{
+			featureBitSpliceOptional: {Name: "splice", IsKnown: true},
+		},
+	}, nil
+}
+
+func (c *contractClient) GetDebugInfo(ctx context.Context,
+	req *lnrpc.GetDebugInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.GetDebugInfoResponse, error) {
+	return &lnrpc.GetDebugInfoResponse{
+		Config: map[string]string{
+			"tor.active":                          "true",
+			"tor.skip-proxy-for-clearnet-targets": "true",
+			"tor.streamisolation":                 "false",
+			"externalip":                          "[]",
+			"nat":                                 "false",
+			"no-macaroons":                        "false",
+			"wtclient.active":                     "true",
+			"maxpendingchannels":                  "1",
+			"gc-canceled-invoices-on-startup":     "false",
+			"gc-canceled-invoices-on-the-fly":     "false",
+		},
+		Log: []string{
+			"2025-01-02 03:04:05.000 [INF] SRVR: Established " +
+				"connection to peer at 203.0.113.5:9735",
+			"2025-01-02 03:04:06.000 [ERR] HSWC: link failed",
+			"",
+		},
+	}, nil
+}
+
+func (c *contractClient) WalletBalance(ctx context.Context,
+	req *lnrpc.WalletBalanceRequest,
+	opts ...grpc.CallOption) (*lnrpc.WalletBalanceResponse, error) {
+	return &lnrpc.WalletBalanceResponse{
+		TotalBalance:       3_000,
+		ConfirmedBalance:   2_000,
+		UnconfirmedBalance: 1_000,
+	}, nil
+}
+
+func (c *contractClient) ChannelBalance(ctx context.Context,
+	req *lnrpc.ChannelBalanceRequest,
+	opts ...grpc.CallOption) (*lnrpc.ChannelBalanceResponse, error) {
+	amount := &lnrpc.Amount{Sat: 1_000, Msat: 1_000_000}
+	return &lnrpc.ChannelBalanceResponse{
+		Balance:                  1_000,
+		PendingOpenBalance:       1_000,
+		LocalBalance:             amount,
+		RemoteBalance:            amount,
+		UnsettledLocalBalance:    amount,
+		UnsettledRemoteBalance:   amount,
+		PendingOpenLocalBalance:  amount,
+		PendingOpenRemoteBalance: amount,
+	}, nil
+}
+
+func (c *contractClient) ListChannels(ctx context.Context,
+	req *lnrpc.ListChannelsRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListChannelsResponse, error) {
+	constraints := &lnrpc.ChannelConstraints{
+		CsvDelay:          144,
+		ChanReserveSat:    1_000,
+		DustLimitSat:      354,
+		MaxPendingAmtMsat: 990_000_000,
+		MinHtlcMsat:       1,
+		MaxAcceptedHtlcs:  483,
+	}
+	hashLock, _ := hex.DecodeString(contractHash)
+	return &lnrpc.ListChannelsResponse{
+		Channels: []*lnrpc.Channel{{
+			Active:                true,
+			RemotePubkey:          contractPubkey,
+			ChannelPoint:          contractOutpoint,
+			ChanId:                123,
+			Capacity:              1_000_000,
+			LocalBalance:          500_000,
+			RemoteBalance:         500_000,
+			CommitFee:             200,
+			CommitWeight:          700,
+			FeePerKw:              250,
+			UnsettledBalance:      10,
+			TotalSatoshisSent:     20,
+			TotalSatoshisReceived: 30,
+			NumUpdates:            40,
+			PendingHtlcs: []*lnrpc.HTLC{{
+				Amount:            5_000,
+				HashLock:          hashLock,
+				ExpirationHeight:  800_100,
+				HtlcIndex:         7,
+				ForwardingChannel: 871234567890123777,
+				LockedIn:          true,
+			}},
+			Lifetime:          3_600,
+			Uptime:            3_240,
+			Initiator:         true,
+			ChanStatusFlags:   "ChanStatusDefault",
+			CommitmentType:    lnrpc.CommitmentType_ANCHORS,
+			LocalConstraints:  constraints,
+			RemoteConstraints: constraints,
+		}},
+	}, nil
+}
+
+func (c *contractClient) PendingChannels(ctx context.Context,
+	req *lnrpc.PendingChannelsRequest,
+	opts ...grpc.CallOption) (*lnrpc.PendingChannelsResponse, error) {
+	channel := &lnrpc.PendingChannelsResponse_PendingChannel{
+		RemoteNodePub: contractPubkey,
+		ChannelPoint:  contractOutpoint,
+		Capacity:      1_000_000,
+		LocalBalance:  400_000,
+		RemoteBalance: 600_000,
+		Initiator:     lnrpc.Initiator_INITIATOR_BOTH,
+	}
+	return &lnrpc.PendingChannelsResponse{
+		TotalLimboBalance: 5_000,
+		PendingOpenChannels: []*lnrpc.PendingChannelsResponse_PendingOpenChannel{{
+			Channel:      channel,
+			CommitFee:    200,
+			CommitWeight: 700,
+			FeePerKw:     250,
+		}},
+		PendingForceClosingChannels: []*lnrpc.PendingChannelsResponse_ForceClosedChannel{{
+			Channel:           channel,
+			ClosingTxid:       contractHash,
+			LimboBalance:      5_000,
+			MaturityHeight:    800_144,
+			BlocksTilMaturity: 144,
+			RecoveredBalance:  1,
+			PendingHtlcs: []*lnrpc.PendingHTLC{{
+				Incoming:          true,
+				Amount:            2_000,
+				Outpoint:          contractOutpoint,
+				MaturityHeight:    800_040,
+				BlocksTilMaturity: 40,
+				Stage:             1,
+			}},
+			Anchor: lnrpc.PendingChannelsResponse_ForceClosedChannel_RECOVERED,
+		}},
+		WaitingCloseChannels: []*lnrpc.PendingChannelsResponse_WaitingCloseChannel{{
+			Channel:      channel,
+			LimboBalance: 5_000,
+			ClosingTxid:  contractHash,
+		}},
+	}, nil
+}
+
+func (c *contractClient) ClosedChannels(ctx context.Context,
+	req *lnrpc.ClosedChannelsRequest,
+	opts ...grpc.CallOption) (*lnrpc.ClosedChannelsResponse, error) {
+	// A peer once broadcast a revoked state, and the node swept the
+	// whole channel.
+	if req.Breach {
+		return &lnrpc.ClosedChannelsResponse{
+			Channels: []*lnrpc.ChannelCloseSummary{{
+				ChannelPoint:   strings.Repeat("02", 32) + ":0",
+				ChanId:         2,
+				ClosingTxHash:  contractHash,
+				RemotePubkey:   contractPubkey,
+				Capacity:       500_000,
+				CloseHeight:    790_000,
+				SettledBalance: 499_000,
+				CloseType:      lnrpc.ChannelCloseSummary_BREACH_CLOSE,
+			}},
+		}, nil
+	}
+
+	return &lnrpc.ClosedChannelsResponse{
+		Channels: []*lnrpc.ChannelCloseSummary{{
+			ChannelPoint:   strings.Repeat("01", 32) + ":1",
+			ChanId:         1,
+			ClosingTxHash:  contractHash,
+			RemotePubkey:   contractPubkey,
+			Capacity:       1_000_000,
+			CloseHeight:    799_900,
+			SettledBalance: 395_000,
+			CloseType:      lnrpc.ChannelCloseSummary_REMOTE_FORCE_CLOSE,
+			Resolutions: []*lnrpc.Resolution{{
+				ResolutionType: lnrpc.ResolutionType_COMMIT,
+				Outcome:        lnrpc.ResolutionOutcome_CLAIMED,
+				Outpoint: &lnrpc.OutPoint{
+					TxidStr:     contractHash,
+					OutputIndex: 0,
+				},
+				AmountSat: 395_000,
+				SweepTxid: contractHash,
+			}},
+		}},
+	}, nil
+}
+
+func (c *contractClient) ExportAllChannelBackups(ctx context.Context,
+	req *lnrpc.ChanBackupExportRequest,
+	opts ...grpc.CallOption) (*lnrpc.ChanBackupSnapshot, error) {
+	// The channel being opened is not in the backup yet.
+	point := &lnrpc.ChannelPoint{
+		FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+			FundingTxidStr: strings.Repeat("ef", 32),
+		},
+		OutputIndex: 1,
+	}
+	return &lnrpc.ChanBackupSnapshot{
+		MultiChanBackup: &lnrpc.MultiChanBackup{
+			ChanPoints:      []*lnrpc.ChannelPoint{point},
+			MultiChanBackup: []byte("encrypted backup"),
+		},
+		SingleChanBackups: &lnrpc.ChannelBackups{
+			ChanBackups: []*lnrpc.ChannelBackup{{
+				ChanPoint:  point,
+				ChanBackup: []byte("encrypted single"),
+			}},
+		},
+	}, nil
+}
+
+func (c *contractClient) ExportChannelBackup(ctx context.Context,
+	req *lnrpc.ExportChannelBackupRequest,
+	opts ...grpc.CallOption) (*lnrpc.ChannelBackup, error) {
+	return &lnrpc.ChannelBackup{
+		ChanPoint:  req.ChanPoint,
+		ChanBackup: []byte("encrypted single"),
+	}, nil
+}
+
+func (c *contractClient) VerifyChanBackup(ctx context.Context,
+	req *lnrpc.ChanBackupSnapshot,
+	opts ...grpc.CallOption) (*lnrpc.VerifyChanBackupResponse, error) {
+	return &lnrpc.VerifyChanBackupResponse{
+		ChanPoints: []string{contractOutpoint},
+	}, nil
+}
+
+func (c *contractClient) DecodePayReq(ctx context.Context,
+	req *lnrpc.PayReqString,
+	opts ...grpc.CallOption) (*lnrpc.PayReq, error) {
+	return &lnrpc.PayReq{
+		Destination:     contractPubkey,
+		PaymentHash:     contractHash,
+		NumSatoshis:     250,
+		NumMsat:         250_000,
+		Timestamp:       1_700_000_000,
+		Expiry:          3_600,
+		Description:     "contract",
+		DescriptionHash: contractHash,
+		FallbackAddr:    contractAddress,
+		CltvExpiry:      40,
+		RouteHints: []*lnrpc.RouteHint{{
+			HopHints: []*lnrpc.HopHint{{
+				NodeId:                    contractPubkey,
+				ChanId:                    123,
+				FeeBaseMsat:               1_000,
+				FeeProportionalMillionths: 1,
+				CltvExpiryDelta:           40,
+			}},
+		}},
+		PaymentAddr: []byte{0x01, 0x02},
+		Features: map[uint32]*lnrpc.Feature{
+			9: {Name: "tlv-onion", IsKnown: true},
+		},
+	}, nil
+}
+
+// contractInvoice is the invoice behind the invoice listing and lookup.
+func contractInvoice() *lnrpc.Invoice {
+	return &lnrpc.Invoice{
+		Memo:           "contract",
+		PaymentRequest: "lnbc1contract",
+		RHash:          []byte{0xcd},
+		Value:          250,
+		ValueMsat:      250_000,
+		CreationDate:   1_700_000_000,
+		SettleDate:     1_700_000_100,
+		Expiry:         3_600,
+		CltvExpiry:     40,
+		AddIndex:       1,
+		SettleIndex:    1,
+		AmtPaidSat:     250,
+		AmtPaidMsat:    250_000,
+		State:          lnrpc.Invoice_SETTLED,
+		PaymentAddr:    []byte{0x01, 0x02},
+	}
+}
+
+func (c *contractClient) ListInvoices(ctx context.Context,
+	req *lnrpc.ListInvoiceRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListInvoiceResponse, error) {
+	return &lnrpc.ListInvoiceResponse{
+		Invoices:         []*lnrpc.Invoice{contractInvoice()},
+		FirstIndexOffset: 1,
+		LastIndexOffset:  1,
+	}, nil
+}
+
+func (c *contractClient) LookupInvoice(ctx context.Context,
+	req *lnrpc.PaymentHash,
+	opts ...grpc.CallOption) (*lnrpc.Invoice, error) {
+	return contractInvoice(), nil
+}
+
+// holdInvoiceClient serves the contract invoice as a hold invoice whose
+// HTLC has been accepted but not yet settled.
+type holdInvoiceClient struct {
+	contractClient
+}
+
+func (c *holdInvoiceClient) LookupInvoice(ctx context.Context,
+	req *lnrpc.PaymentHash,
+	opts ...grpc.CallOption) (*lnrpc.Invoice, error) {
+	invoice := contractInvoice()
+	invoice.State = lnrpc.Invoice_ACCEPTED
+	invoice.SettleDate = 0
+	invoice.SettleIndex = 0
+	invoice.Htlcs = []*lnrpc.InvoiceHTLC{{
+		AmtMsat: 250_000,
+		State:   lnrpc.InvoiceHTLCState_ACCEPTED,
+	}}
+	return invoice, nil
+}
+
+// unknownInvoiceClient knows no invoices, so every payment hash is free
+// for a new one.
+type unknownInvoiceClient struct {
+	contractClient
+}
+
+func (c *unknownInvoiceClient) LookupInvoice(ctx context.Context,
+	req *lnrpc.PaymentHash,
+	opts ...grpc.CallOption) (*lnrpc.Invoice, error) {
+	return nil, status.Error(codes.NotFound, "unable to locate invoice")
+}
+
+// contractInvoices accepts every hold invoice and resolution.
+type contractInvoices struct {
+	invoicesrpc.InvoicesClient
+}
+
+func (c *contractInvoices) AddHoldInvoice(ctx context.Context,
+	req *invoicesrpc.AddHoldInvoiceRequest,
+	opts ...grpc.CallOption) (*invoicesrpc.AddHoldInvoiceResp, error) {
+	return &invoicesrpc.AddHoldInvoiceResp{
+		PaymentRequest: "lnbc2500n1contract",
+		AddIndex:       7,
+		PaymentAddr:    []byte(strings.Repeat("\x11", 32)),
+	}, nil
+}
+
+func (c *contractInvoices) CancelInvoice(ctx context.Context,
+	req *invoicesrpc.CancelInvoiceMsg,
+	opts ...grpc.CallOption) (*invoicesrpc.CancelInvoiceResp, error) {
+	return &invoicesrpc.CancelInvoiceResp{}, nil
+}
+
+func (c *contractInvoices) SettleInvoice(ctx context.Context,
+	req *invoicesrpc.SettleInvoiceMsg,
+	opts ...grpc.CallOption) (*invoicesrpc.SettleInvoiceResp, error) {
+	return &invoicesrpc.SettleInvoiceResp{}, nil
+}
+
+func (c *contractClient) AddInvoice(ctx context.Context,
+	req *lnrpc.Invoice,
+	opts ...grpc.CallOption) (*lnrpc.AddInvoiceResponse, error) {
+	return &lnrpc.AddInvoiceResponse{
+		RHash:          bytes.Repeat([]byte{0xcd}, 32),
+		PaymentRequest: "lnbc2500n1contract",
+		AddIndex:       8,
+		PaymentAddr:    bytes.Repeat([]byte{0x11}, 32),
+	}, nil
+}
+
+func (c *contractClient) ListPayments(ctx context.Context,
+	req *lnrpc.ListPaymentsRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListPaymentsResponse, error) {
+	return &lnrpc.ListPaymentsResponse{
+		Payments:         []*lnrpc.Payment{contractPayment()},
+		FirstIndexOffset: 1,
+		LastIndexOffset:  1,
+	}, nil
+}
+
+// contractPayment is the node's one payment, which settled.
+func contractPayment() *lnrpc.Payment {
+	return &lnrpc.Payment{
+		PaymentHash:     contractHash,
+		ValueSat:        250,
+		ValueMsat:       250_000,
+		PaymentPreimage: contractHash,
+		PaymentRequest:  "lnbc1contract",
+		Status:          lnrpc.Payment_SUCCEEDED,
+		FeeSat:          1,
+		FeeMsat:         1_000,
+		CreationTimeNs:  1_700_000_000_000_000_000,
+		PaymentIndex:    1,
+		Htlcs:           []*lnrpc.HTLCAttempt{{}},
+	}
+}
+
+func (c *contractClient) QueryRoutes(ctx context.Context,
+	req *lnrpc.QueryRoutesRequest,
+	opts ...grpc.CallOption) (*lnrpc.QueryRoutesResponse, error) {
+	return &lnrpc.QueryRoutesResponse{
+		Routes: []*lnrpc.Route{{
+			TotalTimeLock: 800_120,
+			TotalFeesMsat: 1_000,
+			TotalAmtMsat:  250_001_000,
+			Hops: []*lnrpc.Hop{{
+				ChanId:           871234567890123777,
+				PubKey:           "03" + strings.Repeat("ab", 32),
+				AmtToForwardMsat: 250_000_000,
+				FeeMsat:          1_000,
+				Expiry:           800_080,
+			}, {
+				ChanId:           871234567890123999,
+				PubKey:           req.PubKey,
+				AmtToForwardMsat: 250_000_000,
+				Expiry:           800_080,
+			}},
+		}},
+		SuccessProb: 0.5,
+	}, nil
+}
+
+func (c *contractClient) ListUnspent(ctx context.Context,
+	req *lnrpc.ListUnspentRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListUnspentResponse, error) {
+	return &lnrpc.ListUnspentResponse{
+		Utxos: []*lnrpc.Utxo{{
+			Address:   contractAddress,
+			AmountSat: 10_000,
+			PkScript:  "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+			Outpoint: &lnrpc.OutPoint{
+				TxidStr:     contractHash,
+				OutputIndex: 1,
+			},
+			Confirmations: 6,
+		}},
+	}, nil
+}
+
+// contractMissionControl remembers a pair that failed at a larger amount
+// after succeeding at a smaller one, and a success the other way.
+func contractMissionControl() []*routerrpc.PairHistory {
+	self, _ := hex.DecodeString(contractPubkey)
+	peer, _ := hex.DecodeString("03" + strings.Repeat("ab", 32))
+	return []*routerrpc.PairHistory{{
+		NodeFrom: peer,
+		NodeTo:   self,
+		History: &routerrpc.PairData{
+			SuccessTime:    1_700_000_300,
+			SuccessAmtSat:  20_000,
+			SuccessAmtMsat: 20_000_000,
+		},
+	}, {
+		NodeFrom: self,
+		NodeTo:   peer,
+		History: &routerrpc.PairData{
+			FailTime:       1_700_000_600,
+			FailAmtSat:     50_000,
+			FailAmtMsat:    50_000_000,
+			SuccessTime:    1_700_000_000,
+			SuccessAmtSat:  10_000,
+			SuccessAmtMsat: 10_000_000,
+		},
+	}}
+}
+
+// contractMissionControlConfig is lnd's default mission control
+// configuration.
+func contractMissionControlConfig() *routerrpc.MissionControlConfig {
+	return &routerrpc.MissionControlConfig{
+		MaximumPaymentResults:       1_000,
+		MinimumFailureRelaxInterval: 60,
+		EstimatorConfig: &routerrpc.MissionControlConfig_Apriori{
+			Apriori: &routerrpc.AprioriParameters{
+				HalfLifeSeconds:  3_600,
+				HopProbability:   0.6,
+				Weight:           0.5,
+				CapacityFraction: 0.9999,
+			},
+		},
+	}
+}
+
+// contractBuiltRoute is contractRoute as lnd builds it, with the payment
+// address on its final hop.
+func contractBuiltRoute() *lnrpc.Route {
+	return &lnrpc.Route{
+		TotalTimeLock: 800_160,
+		TotalFeesMsat: 1_000,
+		TotalAmtMsat:  251_000,
+		Hops: []*lnrpc.Hop{{
+			ChanId:           871234567890123777,
+			PubKey:           contractPubkey,
+			AmtToForwardMsat: 250_000,
+			FeeMsat:          1_000,
+			Expiry:           800_120,
+		}, {
+			ChanId:           871234567890123999,
+			PubKey:           contractRoutePubkey,
+			AmtToForwardMsat: 250_000,
+			Expiry:           800_080,
+			MppRecord: &lnrpc.MPPRecord{
+				PaymentAddr:  bytes.Repeat([]byte{0xef}, 32),
+				TotalAmtMsat: 250_000,
+			},
+		}},
+	}
+}
+
+// contractWalletKit sweeps a time-locked commitment output and accepts
+// every fee bump and label.
+type contractWalletKit struct {
+	walletrpc.WalletKitClient
+}
+
+func (c *contractWalletKit) PendingSweeps(ctx context.Context,
+	req *walletrpc.PendingSweepsRequest,
+	opts ...grpc.CallOption) (*walletrpc.PendingSweepsResponse, error) {
+	return &walletrpc.PendingSweepsResponse{
+		PendingSweeps: []*walletrpc.PendingSweep{{
+			Outpoint: &lnrpc.OutPoint{
+				TxidStr:     contractHash,
+				OutputIndex: 0,
+			},
+			WitnessType:          walletrpc.WitnessType_COMMITMENT_TIME_LOCK,
+			AmountSat:            5_000,
+			SatPerVbyte:          2,
+			RequestedSatPerVbyte: 2,
+			BroadcastAttempts:    1,
+			Budget:               2_500,
+			DeadlineHeight:       800_144,
+			MaturityHeight:       799_990,
+		}},
+	}, nil
+}
+
+// RequiredReserve reserves for the fixture's one public anchor channel and
+// any additional ones, as lnd does.
+func (c *contractWalletKit) RequiredReserve(ctx context.Context,
+	req *walletrpc.RequiredReserveRequest,
+	opts ...grpc.CallOption) (*walletrpc.RequiredReserveResponse, error) {
+	channels := int64(1 + req.AdditionalPublicChannels)
+	return &walletrpc.RequiredReserveResponse{
+		RequiredReserve: min(channels*10_000, 100_000),
+	}, nil
+}
+
+// EstimateFee estimates 2 sat/vB for every target, as the Lightning
+// fixture does.
+func (c *contractWalletKit) EstimateFee(ctx context.Context,
+	req *walletrpc.EstimateFeeRequest,
+	opts ...grpc.CallOption) (*walletrpc.EstimateFeeResponse, error) {
+	return &walletrpc.EstimateFeeResponse{
+		SatPerKw:            500,
+		MinRelayFeeSatPerKw: 253,
+	}, nil
+}
+
+func (c *contractWalletKit) ListAccounts(ctx context.Context,
+	req *walletrpc.ListAccountsRequest,
+	opts ...grpc.CallOption) (*walletrpc.ListAccountsResponse, error) {
+	return &walletrpc.ListAccountsResponse{
+		Accounts: []*walletrpc.Account{{
+			Name:                 "default",
+			AddressType:          walletrpc.AddressType_TAPROOT_PUBKEY,
+			ExtendedPublicKey:    contractXpub,
+			MasterKeyFingerprint: []byte{0x73, 0xc5, 0xda, 0x0a},
+			DerivationPath:       "m/86'/0'/0'",
+			ExternalKeyCount:     12,
+			InternalKeyCount:     4,
+		}, {
+			Name:        "imported",
+			AddressType: walletrpc.AddressType_WITNESS_PUBKEY_HASH,
+			WatchOnly:   true,
+		}},
+	}, nil
+}
+
+func (c *contractWalletKit) ListAddresses(ctx context.Context,
+	req *walletrpc.ListAddressesRequest,
+	opts ...grpc.CallOption) (*walletrpc.ListAddressesResponse, error) {
+	key, _ := hex.DecodeString(contractPubkey)
+	return &walletrpc.ListAddressesResponse{
+		AccountWithAddresses: []*walletrpc.AccountWithAddresses{{
+			Name:           "default",
+			AddressType:    walletrpc.AddressType_TAPROOT_PUBKEY,
+			DerivationPath: "m/86'/0'/0'",
+			Addresses: []*walletrpc.AddressProperty{{
+				Address:        contractTaprootAddress,
+				IsInternal:     true,
+				Balance:        20_000,
+				DerivationPath: "m/86'/0'/0'/1/0",
+				PublicKey:      key,
+			}, {
+				Address:        contractAddress,
+				Balance:        50_000,
+				DerivationPath: "m/86'/0'/0'/0/1",
+				PublicKey:      key,
+			}, {
+				Address:        contractTaprootAddress,
+				DerivationPath: "m/86'/0'/0'/0/0",
+				PublicKey:      key,
+			}},
+		}, {
+			Name:        "imported",
+			AddressType: walletrpc.AddressType_WITNESS_PUBKEY_HASH,
+			Addresses: []*walletrpc.AddressProperty{{
+				Address: contractAddress,
+				Balance: 1_000,
+			}},
+		}},
+	}, nil
+}
+
+func (c *contractWalletKit) ListSweeps(ctx context.Context,
+	req *walletrpc.ListSweepsRequest,
+	opts ...grpc.CallOption) (*walletrpc.ListSweepsResponse, error) {
+	sweep := &lnrpc.Transaction{
+		TxHash:           strings.Repeat("5e", 32),
+		NumConfirmations: 51,
+		BlockHeight:      799_950,
+		TimeStamp:        1_700_000_000,
+		TotalFees:        1_000,
+		OutputDetails: []*lnrpc.OutputDetail{{
+			Address:      contractAddress,
+			Amount:       394_000,
+			IsOurAddress: true,
+		}},
+		Label: "0:sweep",
+		PreviousOutpoints: []*lnrpc.PreviousOutPoint{{
+			Outpoint: contractHash + ":0",
+		}},
+	}
+	return &walletrpc.ListSweepsResponse{
+		Sweeps: &walletrpc.ListSweepsResponse_TransactionDetails{
+			TransactionDetails: &lnrpc.TransactionDetails{
+				Transactions: []*lnrpc.Transaction{sweep},
+			},
+		},
+	}, nil
+}
+
+func (c *contractWalletKit) BumpFee(ctx context.Context,
+	req *walletrpc.BumpFeeRequest,
+	opts ...grpc.CallOption) (*walletrpc.BumpFeeResponse, error) {
+	return &walletrpc.BumpFeeResponse{
+		Status: "Successfully registered rbf-tx with sweeper",
+	}, nil
+}
+
+func (c *contractWalletKit) LabelTransaction(ctx context.Context,
+	req *walletrpc.LabelTransactionRequest,
+	opts ...grpc.CallOption) (*walletrpc.LabelTransactionResponse, error) {
+	return &walletrpc.LabelTransactionResponse{}, nil
+}
+
+// contractPsbt is a funded PSBT spending one 200,000 sat wallet output to
+// contractAddress, with change, at a fee of 300 sat.
+func contractPsbt() (*psbt.Packet, []byte) {
+	address, _, err := decodeAddress(contractAddress)
+	if err != nil {
+		panic(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		panic(err)
+	}
+	prev, err := chainhash.NewHashFromStr(contractHash)
+	if err != nil {
+		panic(err)
+	}
+
+	packet, err := psbt.New(
+		[]*wire.OutPoint{{Hash: *prev, Index: 0}},
+		[]*wire.TxOut{
+			{Value: 100_000, PkScript: pkScript},
+			{Value: 99_700, PkScript: pkScript},
+		}, 2, 0, []uint32{wire.MaxTxInSequenceNum})
+	if err != nil {
+		panic(err)
+	}
+	packet.Inputs[0].WitnessUtxo = &wire.TxOut{
+		Value:    200_000,
+		PkScript: pkScript,
+	}
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		panic(err)
+	}
+	return packet, buf.Bytes()
+}
+
+func (c *contractWalletKit) FundPsbt(ctx context.Context,
+	req *walletrpc.FundPsbtRequest,
+	opts ...grpc.CallOption) (*walletrpc.FundPsbtResponse, error) {
+	_, raw := contractPsbt()
+	return &walletrpc.FundPsbtResponse{
+		FundedPsbt:        raw,
+		ChangeOutputIndex: 1,
+		LockedUtxos: []*walletrpc.UtxoLease{{
+			Id: bytes.Repeat([]byte{0x11}, 32),
+			Outpoint: &lnrpc.OutPoint{
+				TxidStr:     contractHash,
+				OutputIndex: 0,
+			},
+			Expiration: 1_900_000_000,
+			Value:      200_000,
+		}},
+	}, nil
+}
+
+func (c *contractWalletKit) FinalizePsbt(ctx context.Context,
+	req *walletrpc.FinalizePsbtRequest,
+	opts ...grpc.CallOption) (*walletrpc.FinalizePsbtResponse, error) {
+	packet, raw := contractPsbt()
+	var tx bytes.Buffer
+	if err := packet.UnsignedTx.Serialize(&tx); err != nil {
+		return nil, err
+	}
+	return &walletrpc.FinalizePsbtResponse{
+		SignedPsbt: raw,
+		RawFinalTx: tx.Bytes(),
+	}, nil
+}
+
+func (c *contractWalletKit) ListLeases(ctx context.Context,
+	req *walletrpc.ListLeasesRequest,
+	opts ...grpc.CallOption) (*walletrpc.ListLeasesResponse, error) {
+	resp, err := c.FundPsbt(ctx, &walletrpc.FundPsbtRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &walletrpc.ListLeasesResponse{
+		LockedUtxos: resp.LockedUtxos,
+	}, nil
+}
+
+func (c *contractWalletKit) ReleaseOutput(ctx context.Context,
+	req *walletrpc.ReleaseOutputRequest,
+	opts ...grpc.CallOption) (*walletrpc.ReleaseOutputResponse, error) {
+	return &walletrpc.ReleaseOutputResponse{
+		Status: "output " + formatOutPoint(req.Outpoint) + " released",
+	}, nil
+}
+
+// contractWatchtower backs up anchor channels to a single tower.
+type contractWatchtower struct {
+	wtclientrpc.WatchtowerClientClient
+}
+
+func (c *contractWatchtower) ListTowers(ctx context.Context,
+	req *wtclientrpc.ListTowersRequest,
+	opts ...grpc.CallOption) (*wtclientrpc.ListTowersResponse, error) {
+	var sessions []*wtclientrpc.TowerSession
+	if req.IncludeSessions {
+		sessions = []*wtclientrpc.TowerSession{{
+			NumBackups:        40,
+			NumPendingBackups: 1,
+			MaxBackups:        1_024,
+			SweepSatPerVbyte:  10,
+			Id:                []byte{0x03, 0xcd},
+		}}
+	}
+	return &wtclientrpc.ListTowersResponse{
+		Towers: []*wtclientrpc.Tower{{
+			Pubkey:    []byte{0x02, 0xab},
+			Addresses: []string{"203.0.113.1:9911"},
+			SessionInfo: []*wtclientrpc.TowerSessionInfo{{
+				ActiveSessionCandidate: true,
+				NumSessions:            1,
+				Sessions:               sessions,
+				PolicyType:             wtclientrpc.PolicyType_ANCHOR,
+			}, {
+				PolicyType: wtclientrpc.PolicyType_TAPROOT,
+			}},
+		}},
+	}, nil
+}
+
+func (c *contractWatchtower) Stats(ctx context.Context,
+	req *wtclientrpc.StatsRequest,
+	opts ...grpc.CallOption) (*wtclientrpc.StatsResponse, error) {
+	return &wtclientrpc.StatsResponse{
+		NumBackups:          40,
+		NumPendingBackups:   1,
+		NumSessionsAcquired: 1,
+	}, nil
+}
+
+// Policy serves lnd's default policy. The node runs no taproot client.
+func (c *contractWatchtower) Policy(ctx context.Context,
+	req *wtclientrpc.PolicyRequest,
+	opts ...grpc.CallOption) (*wtclientrpc.PolicyResponse, error) {
+	if req.PolicyType == wtclientrpc.PolicyType_TAPROOT {
+		return nil, status.Error(codes.Unknown,
+			"no client for the given blob type")
+	}
+	return &wtclientrpc.PolicyResponse{
+		MaxUpdates:       1_024,
+		SweepSatPerVbyte: 10,
+	}, nil
+}
+
+func (c *contractClient) GetTransactions(ctx context.Context,
+	req *lnrpc.GetTransactionsRequest,
+	opts ...grpc.CallOption) (*lnrpc.TransactionDetails, error) {
+	return &lnrpc.TransactionDetails{
+		Transactions: []*lnrpc.Transaction{{
+			TxHash:           contractHash,
+			Amount:           10_000,
+			NumConfirmations: 6,
+			BlockHash:        contractHash,
+			BlockHeight:      800_000,
+			TimeStamp:        1_700_000_000,
+			TotalFees:        150,
+			RawTxHex:         "00",
+			Label:            "0:openchannel:shortchanid-871234567890123777",
+			PreviousOutpoints: []*lnrpc.PreviousOutPoint{{
+				Outpoint:    contractOutpoint,
+				IsOurOutput: true,
+			}},
+		}},
+	}, nil
+}
+
+func (c *contractClient) EstimateFee(ctx context.Context,
+	req *lnrpc.EstimateFeeRequest,
+	opts ...grpc.CallOption) (*lnrpc.EstimateFeeResponse, error) {
+	return &lnrpc.EstimateFeeResponse{
+		FeeSat:      300,
+		SatPerVbyte: 2,
+	}, nil
+}
+
+func (c *contractClient) NewAddress(ctx context.Context,
+	req *lnrpc.NewAddressRequest,
+	opts ...grpc.CallOption) (*lnrpc.NewAddressResponse, error) {
+	return &lnrpc.NewAddressResponse{Address: contractAddress}, nil
+}
+
+func (c *contractClient) OpenChannel(ctx context.Context,
+	req *lnrpc.OpenChannelRequest,
+	opts ...grpc.CallOption) (lnrpc.Lightning_OpenChannelClient, error) {
+	txid := make([]byte, 32)
+	for i := range txid {
+		txid[i] = 0xef
+	}
+	return &fakeOpenStream{updates: []*lnrpc.OpenStatusUpdate{{
+		Update: &lnrpc.OpenStatusUpdate_ChanPending{
+			ChanPending: &lnrpc.PendingUpdate{
+				Txid:        txid,
+				OutputIndex: 1,
+			},
+		},
+	}}}, nil
+}
+
+func (c *contractClient) BatchOpenChannel(ctx context.Context,
+	req *lnrpc.BatchOpenChannelRequest,
+	opts ...grpc.CallOption) (*lnrpc.BatchOpenChannelResponse, error) {
+	txid := make([]byte, 32)
+	for i := range txid {
+		txid[i] = 0xef
+	}
+	resp := &lnrpc.BatchOpenChannelResponse{}
+	for i := range req.Channels {
+		resp.PendingChannels = append(resp.PendingChannels,
+			&lnrpc.PendingUpdate{
+				Txid:        txid,
+				OutputIndex: uint32(i),
+				FeePerVbyte: 3,
+			})
+	}
+	return resp, nil
+}
+
+func (c *contractClient) ConnectPeer(ctx context.Context,
+	req *lnrpc.ConnectPeerRequest,
+	opts ...grpc.CallOption) (*lnrpc.ConnectPeerResponse, error) {
+	return &lnrpc.ConnectPeerResponse{}, nil
+}
+
+func (c *contractClient) DisconnectPeer(ctx context.Context,
+	req *lnrpc.DisconnectPeerRequest,
+	opts ...grpc.CallOption) (*lnrpc.DisconnectPeerResponse, error) {
+	return &lnrpc.DisconnectPeerResponse{}, nil
+}
+
+func (c *contractClient) BakeMacaroon(ctx context.Context,
+	req *lnrpc.BakeMacaroonRequest,
+	opts ...grpc.CallOption) (*lnrpc.BakeMacaroonResponse, error) {
+	mac, err := macaroon.New([]byte("root key"), []byte("contract"),
+		"lnd", macaroon.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mac.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &lnrpc.BakeMacaroonResponse{
+		Macaroon: hex.EncodeToString(data),
+	}, nil
+}
+
+func (c *contractClient) ListMacaroonIDs(ctx context.Context,
+	req *lnrpc.ListMacaroonIDsRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListMacaroonIDsResponse, error) {
+	return &lnrpc.ListMacaroonIDsResponse{
+		RootKeyIds: []uint64{42, 0, 7},
+	}, nil
+}
+
+func (c *contractClient) ListPermissions(ctx context.Context,
+	req *lnrpc.ListPermissionsRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListPermissionsResponse, error) {
+	permissions := func(names ...string) *lnrpc.MacaroonPermissionList {
+		list := &lnrpc.MacaroonPermissionList{}
+		for _, name := range names {
+			entity, action, _ := strings.Cut(name, ":")
+			list.Permissions = append(list.Permissions,
+				&lnrpc.MacaroonPermission{
+					Entity: entity, Action: action,
+				})
+		}
+		return list
+	}
+	return &lnrpc.ListPermissionsResponse{
+		MethodPermissions: map[string]*lnrpc.MacaroonPermissionList{
+			"/lnrpc.Lightning/GetInfo": permissions("info:read"),
+			"/lnrpc.Lightning/ListChannels": permissions(
+				"offchain:read"),
+			"/lnrpc.Lightning/SendCoins": permissions(
+				"onchain:write"),
+			"/routerrpc.Router/SendPaymentV2": permissions(
+				"offchain:write", "offchain:read"),
+		},
+	}, nil
+}
+
+func (c *contractClient) DeleteMacaroonID(ctx context.Context,
+	req *lnrpc.DeleteMacaroonIDRequest,
+	opts ...grpc.CallOption) (*lnrpc.DeleteMacaroonIDResponse, error) {
+	return &lnrpc.DeleteMacaroonIDResponse{Deleted: req.RootKeyId == 42}, nil
+}
+
+// contractRegtestClient is the contract node on regtest, where channels may
+// be abandoned.
+type contractRegtestClient struct {
+	*contractClient
+}
+
+func (c *contractRegtestClient) GetInfo(ctx context.Context,
+	req *lnrpc.GetInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.GetInfoResponse, error) {
+	info, err := c.contractClient.GetInfo(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	info.Chains = []*lnrpc.Chain{{Chain: "bitcoin", Network: "regtest"}}
+	return info, nil
+}
+
+func (c *contractRegtestClient) AbandonChannel(ctx context.Context,
+	req *lnrpc.AbandonChannelRequest,
+	opts ...grpc.CallOption) (*lnrpc.AbandonChannelResponse, error) {
+	return &lnrpc.AbandonChannelResponse{}, nil
+}
+
+func (c *contractClient) FeeReport(ctx context.Context,
+	req *lnrpc.FeeReportRequest,
+	opts ...grpc.CallOption) (*lnrpc.FeeReportResponse, error) {
+	return &lnrpc.FeeReportResponse{
+		ChannelFees: []*lnrpc.ChannelFeeReport{{
+			ChanId:       123,
+			ChannelPoint: contractOutpoint,
+			BaseFeeMsat:  1_000,
+			FeePerMil:    100,
+			FeeRate:      0.0001,
+		}},
+		DayFeeSum:   1,
+		WeekFeeSum:  7,
+		MonthFeeSum: 30,
+	}, nil
+}
+
+func (c *contractClient) ForwardingHistory(ctx context.Context,
+	req *lnrpc.ForwardingHistoryRequest,
+	opts ...grpc.CallOption) (*lnrpc.ForwardingHistoryResponse, error) {
+	incoming, outgoing := uint64(4), uint64(9)
+	event := &lnrpc.ForwardingEvent{
+		TimestampNs:    1_700_000_000_000_000_000,
+		ChanIdIn:       871234567890123777,
+		ChanIdOut:      871234567890123999,
+		AmtInMsat:      250_025_000,
+		AmtOutMsat:     250_000_000,
+		FeeMsat:        25_000,
+		IncomingHtlcId: &incoming,
+		OutgoingHtlcId: &outgoing,
+	}
+	if req.PeerAliasLookup {
+		event.PeerAliasIn = "alice"
+		event.PeerAliasOut = "bob"
+	}
+	return &lnrpc.ForwardingHistoryResponse{
+		ForwardingEvents: []*lnrpc.ForwardingEvent{event},
+		LastOffsetIndex:  1,
+	}, nil
+}
+
+func (c *contractClient) GetRecoveryInfo(ctx context.Context,
+	req *lnrpc.GetRecoveryInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.GetRecoveryInfoResponse, error) {
+	return &lnrpc.GetRecoveryInfoResponse{
+		RecoveryMode: true,
+		Progress:     0.25,
+	}, nil
+}
+
+// contractAlias is the first SCID alias lnd assigns.
+const contractAlias uint64 = 16_000_000 << 40
+
+func (c *contractClient) LookupHtlcResolution(ctx context.Context,
+	req *lnrpc.LookupHtlcResolutionRequest,
+	opts ...grpc.CallOption) (*lnrpc.LookupHtlcResolutionResponse, error) {
+	return &lnrpc.LookupHtlcResolutionResponse{Settled: true}, nil
+}
+
+func (c *contractClient) ListAliases(ctx context.Context,
+	req *lnrpc.ListAliasesRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListAliasesResponse, error) {
+	return &lnrpc.ListAliasesResponse{
+		AliasMaps: []*lnrpc.AliasMap{{
+			BaseScid: 123,
+			Aliases:  []uint64{contractAlias, contractAlias + 1},
+		}},
+	}, nil
+}
+
+func (c *contractClient) GetChanInfo(ctx context.Context,
+	req *lnrpc.ChanInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.ChannelEdge, error) {
+	return &lnrpc.ChannelEdge{
+		ChannelId: req.ChanId,
+		ChanPoint: contractOutpoint,
+		Node1Pub:  contractPubkey,
+		Node2Pub:  "03" + strings.Repeat("ab", 32),
+		Capacity:  1_000_000,
+		Node1Policy: &lnrpc.RoutingPolicy{
+			TimeLockDelta:    80,
+			MinHtlc:          1_000,
+			FeeBaseMsat:      1_000,
+			FeeRateMilliMsat: 100,
+			MaxHtlcMsat:      990_000_000,
+		},
+	}, nil
+}
+
+func (c *contractClient) UpdateChannelPolicy(ctx context.Context,
+	req *lnrpc.PolicyUpdateRequest,
+	opts ...grpc.CallOption) (*lnrpc.PolicyUpdateResponse, error) {
+	return &lnrpc.PolicyUpdateResponse{
+		FailedUpdates: []*lnrpc.FailedUpdate{{
+			Outpoint: &lnrpc.OutPoint{
+				TxidStr:     strings.Repeat("ef", 32),
+				OutputIndex: 2,
+			},
+			Reason:      lnrpc.UpdateFailure_UPDATE_FAILURE_PENDING,
+			UpdateError: "channel is pending",
+		}},
+	}, nil
+}
+
+func (c *contractClient) ListPeers(ctx context.Context,
+	req *lnrpc.ListPeersRequest,
+	opts ...grpc.CallOption) (*lnrpc.ListPeersResponse, error) {
+	return &lnrpc.ListPeersResponse{
+		Peers: []*lnrpc.Peer{{
+			PubKey:    contractPubkey,
+			Address:   "127.0.0.1:9735",
+			BytesSent: 1,
+			BytesRecv: 2,
+			SatSent:   3,
+			SatRecv:   4,
+			Inbound:   true,
+			PingTime:  5,
+			SyncType:  lnrpc.Peer_ACTIVE_SYNC,
+			Features: map[uint32]*lnrpc.Feature{
+				9: {Name: "tlv-onion", IsKnown: true},
+			},
+			Errors: []*lnrpc.TimestampedError{{
+				Timestamp: 1_700_000_000,
+				Error:     "contract",
+			}},
+			FlapCount: 1,
+		}},
+	}, nil
+}
+
+// contractNode and contractEdge back the graph fixtures.
+func contractNode() *lnrpc.LightningNode {
+	return &lnrpc.LightningNode{
+		PubKey: contractPubkey,
+		Alias:  "contract",
+		Addresses: []*lnrpc.NodeAddress{
+			{Network: "tcp", Addr: "127.0.0.1:9735"},
+		},
+		Color: "#3399ff",
+	}
+}
+
+func contractEdge() *lnrpc.ChannelEdge {
+	return &lnrpc.ChannelEdge{
+		ChannelId: 123,
+		ChanPoint: contractOutpoint,
+		Node1Pub:  contractPubkey,
+		Node2Pub:  contractPubkey,
+		Capacity:  1_000_000,
+	}
+}
+
+func (c *contractClient) DescribeGraph(ctx context.Context,
+	req *lnrpc.ChannelGraphRequest,
+	opts ...grpc.CallOption) (*lnrpc.ChannelGraph, error) {
+	return &lnrpc.ChannelGraph{
+		Nodes: []*lnrpc.LightningNode{contractNode()},
+		Edges: []*lnrpc.ChannelEdge{contractEdge()},
+	}, nil
+}
+
+func (c *contractClient) GetNodeInfo(ctx context.Context,
+	req *lnrpc.NodeInfoRequest,
+	opts ...grpc.CallOption) (*lnrpc.NodeInfo, error) {
+	return &lnrpc.NodeInfo{
+		Node:          contractNode(),
+		NumChannels:   1,
+		TotalCapacity: 1_000_000,
+		Channels:      []*lnrpc.ChannelEdge{contractEdge()},
+	}, nil
+}
+
+// contractLSP serves the LSPS1 endpoints the LSP tools call.
+func contractLSP(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	order := `{"order_id": "order-1", "order_state": "CREATED",` +
+		`"lsp_balance_sat": "500000"}`
+	lsp := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/api/v1/get_info":
+				_, _ = w.Write([]byte(`{"options": {` +
+					`"min_initial_lsp_balance_sat": "100000",` +
+					`"max_initial_lsp_balance_sat": "5000000"}}`))
+			default:
+				_, _ = w.Write([]byte(order))
+			}
+		}))
+	t.Cleanup(lsp.Close)
+
+	return lsp
+}
+
+// contractTipSource serves a chain tip three blocks ahead of the contract
+// node.
+func contractTipSource(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	tip := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("800003\n"))
+		}))
+	t.Cleanup(tip.Close)
+
+	return tip
+}
+
+// contractMempool starts a mempool API whose projected blocks place the
+// contract fee rate of 2 sat/vbyte in the third block.
+func contractMempool(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	mempool := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/fees/recommended":
+				_, _ = w.Write([]byte(`{"fastestFee": 12, ` +
+					`"halfHourFee": 8, "hourFee": 5, ` +
+					`"economyFee": 2, "minimumFee": 1}`))
+			case "/api/v1/fees/mempool-blocks":
+				_, _ = w.Write([]byte(`[` +
+					`{"blockVSize": 997000, "nTx": 3000, ` +
+					`"medianFee": 14.2, ` +
+					`"feeRange": [10.1, 12, 20, 150]}, ` +
+					`{"blockVSize": 997500, "nTx": 2500, ` +
+					`"medianFee": 6, ` +
+					`"feeRange": [4, 5, 7, 9.9]}, ` +
+					`{"blockVSize": 997800, "nTx": 2800, ` +
+					`"medianFee": 2.3, ` +
+					`"feeRange": [1.8, 2, 3.9]}, ` +
+					`{"blockVSize": 2300000, "nTx": 8000, ` +
+					`"medianFee": 1.2, ` +
+					`"feeRange": [1, 1.1, 1.7]}]`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	t.Cleanup(mempool.Close)
+
+	return mempool
+}
+
+// contractCase calls one tool's handler with arguments that produce a
+// successful result.
+type contractCase struct {
+	tool    string
+	handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+	args    map[string]any
+}
+
+// contractCases builds a case for every tool that can be driven offline.
+func contractCases(t testing.TB) []contractCase {
+	client := &contractClient{}
+
+	invoices := NewInvoiceService(client)
+	channels := NewChannelService(client)
+	regtest := NewChannelService(&contractRegtestClient{client})
+	payments := NewPaymentService(client)
+	onchain := NewOnChainService(client)
+	onchain.Mempool = NewMempoolSource(contractMempool(t).URL + "/api")
+	sweeper := NewOnChainService(nil)
+	sweeper.Clients.SetClients(NodeClients{
+		Lightning: client,
+		WalletKit: &contractWalletKit{},
+	})
+	peers := NewPeerService(client)
+	peers.GraphSalt = []byte("contract")
+
+	// The contract peer is in the contact book, as is a former peer.
+	book, err := contacts.Open(filepath.Join(t.TempDir(), "contacts.json"))
+	require.NoError(t, err)
+	_, err = book.Set(contacts.Contact{
+		Pubkey:       contractPubkey,
+		Name:         "Carol",
+		Organization: "Contract",
+		Telegram:     "carol_contract",
+	})
+	require.NoError(t, err)
+	_, err = book.Set(contacts.Contact{
+		Pubkey: contractRoutePubkey,
+		Notes:  "closed our channel last year",
+	})
+	require.NoError(t, err)
+	peers.Contacts = book
+	channels.Contacts = book
+	contactBook := NewContactService(book)
+
+	// The contract peer is watched, and was last seen under another alias
+	// with a channel that has since closed.
+	watched, err := watchlist.Open(filepath.Join(t.TempDir(),
+		"watchlist.json"))
+	require.NoError(t, err)
+	_, _, err = watched.Add(contractPubkey, "our LSP")
+	require.NoError(t, err)
+	_, err = watched.Record(contractPubkey, watchlist.Snapshot{
+		InGraph: true,
+		Alias:   "contract-old",
+		Channels: map[uint64]watchlist.Channel{
+			456: {
+				Peer:     contractRoutePubkey,
+				Capacity: 500_000,
+				Policy: &watchlist.Policy{
+					FeeBaseMsat:   1000,
+					FeeRatePPM:    100,
+					TimeLockDelta: 80,
+					MinHTLCMsat:   1000,
+					MaxHTLCMsat:   495_000_000,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	watch := NewWatchService(client, watched)
+	watch.Contacts = book
+	node := NewNodeService(client)
+	node.TipSource = contractTipSource(t).URL
+	guarded := NewNodeService(nil)
+	guarded.Clients.SetClients(NodeClients{
+		Lightning: client,
+		Towers:    &contractWatchtower{},
+	})
+
+	lsp := NewLSPService(client, map[string]string{
+		"contract": contractLSP(t).URL,
+	})
+
+	sandbox := NewSandboxService("", nil)
+	sandbox.Clients.Set(client, nil)
+
+	// The primary node runs without the router subserver.
+	statsClients := NewClientProvider(client)
+	statsClients.recordSubserver(statsClients.Generation(), "router",
+		false, "unknown service routerrpc.Router")
+	stats := NewServerStatsService(statsClients)
+	stats.SandboxClients = sandbox.Clients
+
+	signer := NewSigningService(client, signing.ModeHMAC, []byte("secret"))
+	signedAt := "2026-01-01T00:00:00Z"
+	signature := signing.HMAC([]byte("secret"),
+		signing.Message("lnc_get_info", signedAt, "{}"))
+
+	router := &fakeRouter{updates: []*lnrpc.Payment{{
+		PaymentHash:     contractHash,
+		Status:          lnrpc.Payment_SUCCEEDED,
+		PaymentPreimage: contractHash,
+		ValueSat:        250,
+		FeeSat:          1,
+		FeeMsat:         1_000,
+		Htlcs:           []*lnrpc.HTLCAttempt{{}},
+	}}, feeEstimate: &routerrpc.RouteFeeResponse{
+		RoutingFeeMsat: 1_250,
+		TimeLockDelay:  120,
+	}, missionControl: contractMissionControl(),
+		probability: &routerrpc.QueryProbabilityResponse{
+			Probability: 0.42,
+			History: &routerrpc.PairData{
+				FailTime:    1_700_000_600,
+				FailAmtMsat: 50_000_000,
+			},
+		}, missionControlConfig: contractMissionControlConfig(),
+		builtRoute: contractBuiltRoute()}
+	payer := NewPaymentService(client)
+	payer.Clients.Set(client, router)
+	tracker := NewPaymentService(client)
+	tracker.Clients.Set(client, &trackingRouter{fakeRouter: fakeRouter{
+		updates: []*lnrpc.Payment{contractPayment()},
+	}})
+
+	// The second hop's node rejects the fee it was offered.
+	routeRouter := &fakeRouter{attempt: &lnrpc.HTLCAttempt{
+		AttemptId: 7,
+		Status:    lnrpc.HTLCAttempt_FAILED,
+		Failure: &lnrpc.Failure{
+			Code:               lnrpc.Failure_FEE_INSUFFICIENT,
+			FailureSourceIndex: 1,
+			HtlcMsat:           250_000,
+			ChannelUpdate: &lnrpc.ChannelUpdate{
+				ChanId:          871_234_567_890_123_999,
+				Timestamp:       1_700_000_000,
+				TimeLockDelta:   80,
+				HtlcMinimumMsat: 1_000,
+				BaseFee:         1_000,
+				FeeRate:         500,
+				HtlcMaximumMsat: 990_000_000,
+			},
+		},
+	}}
+	routePayer := NewPaymentService(client)
+	routePayer.Clients.Set(client, routeRouter)
+
+	holdInvoices := NewInvoiceService(nil)
+	holdInvoices.Clients.SetClients(NodeClients{
+		Lightning: &holdInvoiceClient{},
+		Invoices:  &contractInvoices{},
+	})
+
+	newInvoices := NewInvoiceService(nil)
+	newInvoices.Clients.SetClients(NodeClients{
+		Lightning: &unknownInvoiceClient{},
+		Invoices:  &contractInvoices{},
+	})
+
+	// A channel open and a payment were in flight when the server last
+	// stopped; both have since completed.
+	journaled, err := journal.Open(filepath.Join(t.TempDir(),
+		"journal.json"))
+	require.NoError(t, err)
+	require.NoError(t, journaled.Begin(journal.KindChannelOpen,
+		contractOutpoint, "lnc_open_channel", map[string]any{
+			"node_pubkey": contractPubkey,
+			"amount_sat":  100_000,
+		}))
+	require.NoError(t, journaled.Begin(journal.KindPayment, contractHash,
+		"lnc_pay_invoice", map[string]any{"fee_limit_sat": 10}))
+	operations := NewOperationService(nil, journaled)
+	operations.Clients.SetClients(NodeClients{
+		Lightning: client,
+		Router: &trackingRouter{fakeRouter: fakeRouter{
+			updates: []*lnrpc.Payment{{
+				PaymentHash: contractHash,
+				Status:      lnrpc.Payment_SUCCEEDED,
+				ValueSat:    250,
+				FeeSat:      1,
+			}},
+		}},
+	})
+
+	// The server's state is backed up, then restored into a server
+	// whose stores are empty.
+	backupDir := t.TempDir()
+	backups := NewBackupService(backupDir, "1.0.0")
+	backups.Contacts = book
+	backups.Watchlist = watched
+	backups.Journal = journaled
+	backups.Ledger = policy.NewLedger()
+	restorer := NewBackupService(backupDir, "1.0.0")
+	exporter := NewExportService(client, t.TempDir())
+
+	// Two calls are scheduled and one of them cancelled, for the
+	// schedule tools to list, cancel and restore.
+	scheduled, err := schedule.Open(filepath.Join(t.TempDir(),
+		"schedule.json"))
+	require.NoError(t, err)
+	waiting, err := scheduled.Add("lnc_keysend", map[string]any{
+		"destination": contractRoutePubkey,
+		"amount_sat":  float64(1000),
+	}, time.Now().Add(time.Hour), "weekly tip")
+	require.NoError(t, err)
+	cancelled, err := scheduled.Add("lnc_update_channel_policy",
+		map[string]any{"fee_rate_ppm": float64(100)},
+		time.Now().Add(2*time.Hour), "")
+	require.NoError(t, err)
+	_, err = scheduled.Cancel(cancelled.ID, "fees settled")
+	require.NoError(t, err)
+	scheduler := NewScheduleService(scheduled)
+	scheduler.Schedulable = func(string, map[string]any) error {
+		return nil
+	}
+	backups.Schedule = scheduled
+	restorer.Contacts, err = contacts.Open(filepath.Join(t.TempDir(),
+		"contacts.json"))
+	require.NoError(t, err)
+	restorer.Watchlist, err = watchlist.Open(filepath.Join(t.TempDir(),
+		"watchlist.json"))
+	require.NoError(t, err)
+	restorer.Journal, err = journal.Open(filepath.Join(t.TempDir(),
+		"journal.json"))
+	require.NoError(t, err)
+
+	schemas := NewSchemaService([]string{
+		"lnc_get_info", "lnc_get_output_schema",
+	})
+
+	macaroons := NewMacaroonService(client)
+
+	sender := NewOnChainService(client)
+	sender.Policy = policy.NewEngine(policy.Config{
+		WithdrawalAllowlist: []string{contractAddress},
+	})
+	sender.Mempool = onchain.Mempool
+
+	// The PSBT is signed as if lnc_fund_psbt had funded it.
+	funder := NewOnChainService(nil)
+	funder.Clients.SetClients(NodeClients{
+		Lightning: client,
+		WalletKit: &contractWalletKit{},
+	})
+	funder.Policy = sender.Policy
+	funded, raw := contractPsbt()
+	funder.psbts.record(funded.UnsignedTx.TxHash(),
+		time.Now().Add(time.Hour), 0)
+
+	invoice := newTestBolt11(t, &chaincfg.MainNetParams, 250_000)
+
+	return []contractCase{
+		{"lnc_disconnect", NewConnectionService(nil).HandleDisconnect, nil},
+		{"lnc_decode_invoice", invoices.HandleDecodeInvoice,
+			map[string]any{"invoice": invoice}},
+		{"lnc_decode_invoice_offline",
+			invoices.HandleDecodeInvoiceOffline,
+			map[string]any{"invoice": invoice}},
+		{"lnc_list_invoices", invoices.HandleListInvoices, nil},
+		{"lnc_lookup_invoice", invoices.HandleLookupInvoice,
+			map[string]any{"payment_hash": contractHash}},
+		{"lnc_list_channels", channels.HandleListChannels, nil},
+		{"lnc_channel_balance_by_peer",
+			channels.HandleChannelBalanceByPeer, nil},
+		{"lnc_list_aliases", channels.HandleListAliases, nil},
+		{"lnc_lookup_htlc_resolution",
+			channels.HandleLookupHtlcResolution,
+			map[string]any{
+				"chan_id":    "871234567890123999",
+				"htlc_index": float64(9),
+			}},
+		{"lnc_partner_sla", channels.HandlePartnerSLA, nil},
+		{"lnc_list_htlcs", channels.HandleListHTLCs, nil},
+		{"lnc_pending_channels", channels.HandlePendingChannels, nil},
+		{"lnc_estimate_force_close", channels.HandleEstimateForceClose,
+			map[string]any{
+				"channel_point":        contractOutpoint,
+				"target_sat_per_vbyte": float64(10),
+			}},
+		{"lnc_close_progress", channels.HandleCloseProgress,
+			map[string]any{"include_completed": true}},
+		{"lnc_check_channel_policies",
+			channels.HandleCheckChannelPolicies, nil},
+		{"lnc_forwarding_history", channels.HandleForwardingHistory,
+			map[string]any{"include_peer_alias": true}},
+		{"lnc_list_payments", payments.HandleListPayments,
+			map[string]any{"include_preimage": true}},
+		{"lnc_query_mission_control",
+			payer.HandleQueryMissionControl, nil},
+		{"lnc_query_payment_probability",
+			payer.HandleQueryProbability, map[string]any{
+				"to_node":    "03" + strings.Repeat("ab", 32),
+				"amount_sat": float64(10_000),
+			}},
+		{"lnc_get_mission_control_config",
+			payer.HandleGetMissionControlConfig, nil},
+		{"lnc_set_mission_control_config",
+			payer.HandleSetMissionControlConfig, map[string]any{
+				"half_life_seconds": float64(7_200),
+			}},
+		{"lnc_estimate_route_fee", payer.HandleEstimateRouteFee,
+			map[string]any{
+				"pub_key":    contractPubkey,
+				"amount_sat": float64(250_000),
+			}},
+		{"lnc_query_routes", payments.HandleQueryRoutes,
+			map[string]any{
+				"pub_key":    contractPubkey,
+				"amount_sat": float64(250_000),
+			}},
+		{"lnc_build_route", payer.HandleBuildRoute,
+			map[string]any{
+				"hop_pubkeys": []any{
+					contractPubkey, contractRoutePubkey,
+				},
+				"amount_sat":   float64(250),
+				"payment_addr": strings.Repeat("ef", 32),
+			}},
+		{"lnc_track_payment", tracker.HandleTrackPayment,
+			map[string]any{
+				"payment_hash":     contractHash,
+				"include_preimage": true,
+			}},
+		{"lnc_list_unspent", onchain.HandleListUnspent, nil},
+		{"lnc_get_transactions", onchain.HandleGetTransactions, nil},
+		{"lnc_estimate_fee", onchain.HandleEstimateFee, nil},
+		{"lnc_validate_address", onchain.HandleValidateAddress,
+			map[string]any{
+				"address": contractAddress,
+			}},
+		{"lnc_list_bumpable", sweeper.HandleListBumpable, nil},
+		{"lnc_list_accounts", sweeper.HandleListAccounts, nil},
+		{"lnc_list_addresses", sweeper.HandleListAddresses, nil},
+		{"lnc_list_sweeps", sweeper.HandleListSweeps, nil},
+		{"lnc_pending_sweeps", sweeper.HandlePendingSweeps, nil},
+		{"lnc_required_reserve", sweeper.HandleRequiredReserve,
+			map[string]any{
+				"additional_public_channels": float64(2),
+			}},
+		{"lnc_list_leases", sweeper.HandleListLeases, nil},
+		{"lnc_bump_fee", sweeper.HandleBumpFee, map[string]any{
+			"outpoint":      contractHash + ":0",
+			"sat_per_vbyte": float64(10),
+			"budget_sat":    float64(3_000),
+			"immediate":     true,
+		}},
+		{"lnc_label_transaction", sweeper.HandleLabelTransaction,
+			map[string]any{
+				"txid":      contractHash,
+				"label":     "funding for acme",
+				"overwrite": true,
+			}},
+		{"lnc_list_peers", peers.HandleListPeers, nil},
+		{"lnc_describe_graph", peers.HandleDescribeGraph, nil},
+		{"lnc_export_anonymized_graph", peers.HandleAnonymizedGraph,
+			map[string]any{"mark_own_node": true}},
+		{"lnc_get_chan_info", peers.HandleGetChanInfo,
+			map[string]any{"chan_id": "800000x1234x1"}},
+		{"lnc_get_node_info", peers.HandleGetNodeInfo,
+			map[string]any{
+				"pub_key":          contractPubkey,
+				"include_channels": true,
+			}},
+		{"lnc_get_balance", node.HandleGetBalance, nil},
+		{"lnc_get_info", node.HandleGetInfo, nil},
+		{"lnc_get_sync_status", node.HandleGetSyncStatus, nil},
+		{"lnc_get_recovery_info", node.HandleGetRecoveryInfo, nil},
+		{"lnc_config_check", node.HandleConfigCheck, nil},
+		{"lnc_get_debug_info", node.HandleDebugInfo,
+			map[string]any{"config_filter": "tor."}},
+		{"lnc_security_report", guarded.HandleSecurityReport, nil},
+		{"lnc_list_towers", guarded.HandleListTowers,
+			map[string]any{"include_sessions": true}},
+		{"lnc_tower_stats", guarded.HandleTowerStats, nil},
+		{"lnc_tower_policy", guarded.HandleTowerPolicy, nil},
+		{"lnc_export_channel_backups", node.HandleExportChannelBackups,
+			map[string]any{"include_singles": true}},
+		{"lnc_verify_channel_backup", node.HandleVerifyChannelBackup,
+			nil},
+		{"lnc_lsp_get_info", lsp.HandleGetInfo,
+			map[string]any{"required_inbound_sat": float64(250_000)}},
+		{"lnc_lsp_get_order", lsp.HandleGetOrder,
+			map[string]any{"order_id": "order-1"}},
+		{"lnc_lsp_create_order", lsp.HandleCreateOrder,
+			map[string]any{"lsp_balance_sat": float64(500_000)}},
+		{"lnc_sandbox_status", sandbox.HandleStatus, nil},
+		{"lnc_server_stats", stats.HandleServerStats, nil},
+		{"lnc_verify_response", signer.HandleVerifyResponse,
+			map[string]any{
+				"tool":      "lnc_get_info",
+				"signed_at": signedAt,
+				"content":   "{}",
+				"signature": signature,
+			}},
+		{"lnc_get_output_schema", schemas.HandleOutputSchema, nil},
+		{"lnc_list_operations", operations.HandleListOperations, nil},
+		{"lnc_pay_invoice", payer.HandlePayInvoice,
+			map[string]any{
+				"invoice":          invoice,
+				"include_preimage": true,
+			}},
+		{"lnc_send_coins", sender.HandleSendCoins,
+			map[string]any{
+				"address":    contractAddress,
+				"amount_sat": float64(10_000),
+			}},
+		{"lnc_open_channel", channels.HandleOpenChannel,
+			map[string]any{
+				"node_pubkey": contractPubkey,
+				"amount_sat":  float64(100_000),
+				"push_sat":    float64(1_000),
+			}},
+		{"lnc_batch_open_channels", channels.HandleBatchOpenChannels,
+			map[string]any{
+				"channels": []any{
+					map[string]any{
+						"node_pubkey": contractPubkey,
+						"amount_sat":  float64(100_000),
+						"push_sat":    float64(1_000),
+					},
+					map[string]any{
+						"node_pubkey": contractRoutePubkey,
+						"amount_sat":  float64(250_000),
+						"private":     true,
+					},
+				},
+				"label": "batch",
+			}},
+		{"lnc_new_address", onchain.HandleNewAddress,
+			map[string]any{
+				"amount_sat": float64(10_000),
+				"label":      "contract",
+			}},
+		{"lnc_keysend", payer.HandleKeysend,
+			map[string]any{
+				"destination":      contractPubkey,
+				"amount_sat":       float64(250),
+				"include_preimage": true,
+			}},
+		{"lnc_send_to_route", routePayer.HandleSendToRoute,
+			map[string]any{
+				"payment_hash":     contractHash,
+				"route":            contractRoute,
+				"include_preimage": true,
+			}},
+		{"lnc_add_invoice", newInvoices.HandleAddInvoice,
+			map[string]any{
+				"amount_sat":     float64(250),
+				"memo":           "contract",
+				"expiry_seconds": float64(3_600),
+				"amp":            true,
+			}},
+		{"lnc_add_hold_invoice", newInvoices.HandleAddHoldInvoice,
+			map[string]any{
+				"payment_hash":   contractHash,
+				"amount_sat":     float64(250),
+				"memo":           "contract",
+				"expiry_seconds": float64(3_600),
+				"route_hints": []any{map[string]any{
+					"hop_hints": []any{map[string]any{
+						"node_id":    contractRoutePubkey,
+						"chan_id":    "824633720833",
+						"fee_base":   float64(1_000),
+						"fee_prop":   float64(1),
+						"cltv_delta": float64(40),
+					}},
+				}},
+			}},
+		{"lnc_cancel_invoice", holdInvoices.HandleCancelInvoice,
+			map[string]any{"payment_hash": contractHash}},
+		{"lnc_settle_invoice", holdInvoices.HandleSettleInvoice,
+			map[string]any{"preimage": strings.Repeat("ab", 32)}},
+		{"lnc_update_channel_policy", channels.HandleUpdateChannelPolicy,
+			map[string]any{
+				"channel_point": contractOutpoint,
+				"fee_rate_ppm":  float64(250),
+				"min_htlc_msat": float64(2_000),
+			}},
+		{"lnc_connect_peer", peers.HandleConnectPeer,
+			map[string]any{
+				"address": contractPubkey + "@127.0.0.1:9735",
+			}},
+		{"lnc_disconnect_peer", peers.HandleDisconnectPeer,
+			map[string]any{"node_pubkey": contractPubkey}},
+		{"lnc_bake_macaroon", macaroons.HandleBakeMacaroon,
+			map[string]any{
+				"permissions": []any{
+					"info:read", "offchain:read",
+					"uri:/lnrpc.Lightning/GetInfo",
+				},
+				"root_key_id":     float64(42),
+				"timeout_seconds": float64(3_600),
+				"ip_address":      "127.0.0.1",
+			}},
+		{"lnc_list_macaroon_ids", macaroons.HandleListMacaroonIDs, nil},
+		{"lnc_list_permissions", macaroons.HandleListPermissions, nil},
+		{"lnc_delete_macaroon_id", macaroons.HandleDeleteMacaroonID,
+			map[string]any{"root_key_id": float64(42)}},
+		{"lnc_fund_psbt", funder.HandleFundPsbt,
+			map[string]any{
+				"outputs": []any{map[string]any{
+					"address":    contractAddress,
+					"amount_sat": float64(100_000),
+				}},
+				"sat_per_vbyte": float64(2),
+			}},
+		{"lnc_finalize_psbt", funder.HandleFinalizePsbt,
+			map[string]any{
+				"psbt": base64.StdEncoding.EncodeToString(raw),
+			}},
+		{"lnc_release_output", funder.HandleReleaseOutput,
+			map[string]any{"outpoint": contractHash + ":0"}},
+		{"lnc_abandon_channel", regtest.HandleAbandonChannel,
+			map[string]any{"channel_point": contractOutpoint}},
+		{"lnc_list_contacts", contactBook.HandleListContacts, nil},
+		{"lnc_set_contact", contactBook.HandleSetContact,
+			map[string]any{
+				"pubkey": contractPubkey,
+				"notes":  "opened a channel to us in 2024",
+			}},
+		{"lnc_delete_contact", contactBook.HandleDeleteContact,
+			map[string]any{"pubkey": contractRoutePubkey}},
+		{"lnc_watched_nodes", watch.HandleWatchedNodes, nil},
+		{"lnc_watch_node", watch.HandleWatchNode,
+			map[string]any{
+				"pubkey": contractRoutePubkey,
+				"note":   "routing partner",
+			}},
+		{"lnc_unwatch_node", watch.HandleUnwatchNode,
+			map[string]any{"pubkey": contractRoutePubkey}},
+		{"lnc_server_backup", backups.HandleServerBackup,
+			map[string]any{"name": "contract-backup.json"}},
+		{"lnc_server_restore", restorer.HandleServerRestore,
+			map[string]any{"name": "contract-backup.json"}},
+		{"lnc_export_records", exporter.HandleExportRecords,
+			map[string]any{
+				"records": "payments",
+				"format":  "parquet",
+				"name":    "contract-payments.parquet",
+			}},
+		{"lnc_store_maintenance", backups.HandleStoreMaintenance,
+			map[string]any{
+				"action": "export",
+				"name":   "contract-stores.jsonl",
+			}},
+		{"lnc_list_scheduled_operations",
+			scheduler.HandleListScheduledOperations,
+			map[string]any{"include_deleted": true}},
+		{"lnc_schedule_operation", scheduler.HandleScheduleOperation,
+			map[string]any{
+				"tool":      "lnc_keysend",
+				"arguments": map[string]any{"amount_sat": 500},
+				"run_at": time.Now().Add(time.Hour).
+					Format(time.RFC3339),
+				"note": "one-off tip",
+			}},
+		{"lnc_cancel_scheduled_operation",
+			scheduler.HandleCancelScheduledOperation,
+			map[string]any{"id": waiting.ID, "note": "paused"}},
+		{"lnc_restore_scheduled_operation",
+			scheduler.HandleRestoreScheduledOperation,
+			map[string]any{"id": cancelled.ID}},
+	}
+}
+
+// Test that every tool's result matches its declared output schema, and
+// that every field it emits is declared, so renamed or retyped fields are
+// caught before clients are.
+func TestOutputContracts(t *testing.T) {
+	covered := make(map[string]bool)
+	for _, tc := range contractCases(t) {
+		covered[tc.tool] = true
+
+		t.Run(tc.tool, func(t *testing.T) {
+			schema, ok := OutputSchema(tc.tool)
+			require.True(t, ok, "no output schema declared")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+			if request.Params.Arguments == nil {
+				request.Params.Arguments = map[string]any{}
+			}
+
+			result, err := tc.handler(context.Background(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError,
+				result.Content[0].(mcp.TextContent).Text)
+
+			var payload any
+			text := result.Content[0].(mcp.TextContent).Text
+			require.NoError(t, json.Unmarshal([]byte(text), &payload))
+
+			violations := validateContract("$", jsonSchema(t, schema),
+				payload)
+			assert.Empty(t, violations)
+		})
+	}
+
+	// Every tool with a schema is either driven above or exempt.
+	for tool := range outputSchemas {
+		if _, exempt := contractExempt[tool]; exempt {
+			continue
+		}
+		assert.True(t, covered[tool], "%s has no contract case", tool)
+	}
+}
+
+// Test that the contract validator itself catches the regressions it is
+// meant to.
+func TestValidateContract(t *testing.T) {
+	schema := jsonSchema(t, objectOf(map[string]any{
+		"count": integerSchema,
+		"names": arrayOf(stringSchema),
+	}, "count"))
+
+	assert.Empty(t, validateContract("$", schema, map[string]any{
+		"count": float64(1),
+		"names": []any{"a"},
+	}))
+
+	// Missing, renamed, retyped and fractional fields are all reported.
+	violations := validateContract("$", schema, map[string]any{
+		"cnt":   float64(1),
+		"names": []any{float64(1)},
+	})
+	assert.ElementsMatch(t, []string{
+		"$.count: required field missing",
+		"$.cnt: field not declared in schema",
+		"$.names[0]: want string, got number",
+	}, violations)
+
+	assert.Equal(t, []string{"$.count: want integer, got number"},
+		validateContract("$", schema, map[string]any{
+			"count": 1.5,
+		}))
+}
+
+// jsonSchema round-trips a schema through JSON so it has the same generic
+// shape a client would see.
+func jsonSchema(t testing.TB, schema map[string]any) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return decoded
+}
+
+// validateContract checks value against the subset of JSON Schema used by
+// the output schemas. Unlike the schemas themselves, which allow clients
+// to ignore unknown fields, it also reports fields the schema does not
+// declare.
+func validateContract(path string, schema map[string]any,
+	value any) []string {
+	if !schemaAllowsType(schema["type"], value) {
+		return []string{fmt.Sprintf("%s: want %v, got %s", path,
+			schema["type"], jsonType(value))}
+	}
+
+	if want, ok := schema["const"]; ok && !reflect.DeepEqual(want, value) {
+		return []string{fmt.Sprintf("%s: want %v, got %v", path, want,
+			value)}
+	}
+
+	var violations []string
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				violations = append(violations, fmt.Sprintf(
+					"%s.%s: required field missing", path, name))
+			}
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldPath := path + "." + key
+			fieldSchema, ok := properties[key].(map[string]any)
+			switch {
+			case ok:
+			case additional != nil:
+				fieldSchema = additional
+			case properties == nil:
+				// Free-form objects are not checked further.
+				continue
+			default:
+				violations = append(violations, fieldPath+
+					": field not declared in schema")
+				continue
+			}
+			violations = append(violations, validateContract(
+				fieldPath, fieldSchema, value[key])...)
+		}
+
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			break
+		}
+		for i, item := range value {
+			violations = append(violations, validateContract(
+				fmt.Sprintf("%s[%d]", path, i), items, item)...)
+		}
+	}
+
+	return violations
+}
+
+// schemaAllowsType reports whether value matches a schema "type", which
+// may be a single type name or a list of them.
+func schemaAllowsType(want any, value any) bool {
+	switch want := want.(type) {
+	case nil:
+		return true
+	case string:
+		got := jsonType(value)
+		if got == want {
+			return true
+		}
+		number, ok := value.(float64)
+		return ok && want == "integer" &&
+			number == math.Trunc(number)
+	case []any:
+		for _, name := range want {
+			if schemaAllowsType(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultDebugLogLines is how much of the log tail is returned unless
+	// log_lines says otherwise.
+	defaultDebugLogLines = 100
+
+	// maxDebugLogLines caps the log tail, since lnd returns its whole log
+	// file.
+	maxDebugLogLines = 1000
+
+	// maxDebugLineLength caps each log line; longer lines are cut and
+	// counted in truncated_lines.
+	maxDebugLineLength = 1000
+
+	// debugRedacted replaces redacted values and addresses.
+	debugRedacted = "[redacted]"
+)
+
+// debugSensitiveKeys are substrings of the config keys whose values are
+// redacted: credentials, and the hosts, addresses and paths that identify
+// the machine. lnd itself only redacts passwords and database DSNs.
+var debugSensitiveKeys = []string{
+	"pass", "secret", "token", "dsn", "key", "macaroon", "cert", "user",
+	"host", "listen", "externalip", "zmq", "tor.control", "tor.socks",
+	"tor.dns", "tor.targetipaddress", "dir", "path", "file",
+}
+
+// debugAddressPatterns match the network addresses redacted from log lines:
+// IPv4, bracketed IPv6 and onion addresses, with any port.
+var debugAddressPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`),
+	regexp.MustCompile(`\[[0-9a-fA-F:.]*:[0-9a-fA-F:.]*\](?::\d+)?`),
+	regexp.MustCompile(`\b[a-z2-7]{56}\.onion(?::\d+)?\b`),
+}
+
+// DebugInfoTool returns the MCP tool definition for getting the node's
+// configuration and log tail.
+func (s *NodeService) DebugInfoTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_debug_info",
+		Description: "Get the node's effective lnd configuration " +
+			"and the tail of its log for troubleshooting. " +
+			"Credentials, hosts, listen addresses and file paths " +
+			"in the config and IP and onion addresses in the log " +
+			"are redacted unless the operator has turned " +
+			"redaction off. The log is limited to log_lines lines",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"section": map[string]any{
+					"type":        "string",
+					"description": "What to return: config, log or all (default all)",
+					"enum":        []string{"all", "config", "log"},
+				},
+				"config_filter": map[string]any{
+					"type": "string",
+					"description": "Only return config keys " +
+						"containing this text, such as " +
+						"\"tor.\" (case-insensitive)",
+				},
+				"log_filter": map[string]any{
+					"type": "string",
+					"description": "Only return log lines " +
+						"containing this text, such as " +
+						"\"[ERR]\" or \"PEER\" " +
+						"(case-insensitive)",
+				},
+				"log_lines": map[string]any{
+					"type": "number",
+					"description": fmt.Sprintf("How many of the "+
+						"last matching log lines to "+
+						"return (default %d, at most %d)",
+						defaultDebugLogLines,
+						maxDebugLogLines),
+					"minimum": 1,
+					"maximum": maxDebugLogLines,
+				},
+			},
+		},
+	}
+}
+
+// HandleDebugInfo handles the debug info request.
+func (s *NodeService) HandleDebugInfo(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	section, _ := args["section"].(string)
+	configFilter, _ := args["config_filter"].(string)
+	logFilter, _ := args["log_filter"].(string)
+	logLines, _ := args["log_lines"].(float64)
+	redact := !s.DebugUnredacted
+
+	switch {
+	case section != "" && section != "all" && section != "config" &&
+		section != "log":
+		return invalidArgumentError("section must be config, log " +
+			"or all"), nil
+	case logLines < 0 || logLines > maxDebugLogLines:
+		return invalidArgumentError(fmt.Sprintf("log_lines must be "+
+			"between 1 and %d", maxDebugLogLines)), nil
+	case logLines == 0:
+		logLines = defaultDebugLogLines
+	}
+
+	debug, err := client.GetDebugInfo(ctx, &lnrpc.GetDebugInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get debug info"), nil
+	}
+
+	result := map[string]any{"redacted": redact}
+	if section != "log" {
+		config, redactedKeys := debugConfig(debug.Config,
+			configFilter, redact)
+		result["config"] = config
+		result["config_count"] = len(config)
+		result["config_total"] = len(debug.Config)
+		if redact {
+			result["redacted_keys"] = redactedKeys
+		}
+	}
+	if section != "config" {
+		lines, matched, truncated := debugLogTail(debug.Log,
+			logFilter, int(logLines), redact)
+		result["log"] = lines
+		result["log_lines_returned"] = len(lines)
+		result["log_lines_matched"] = matched
+		result["truncated_lines"] = truncated
+	}
+
+	return jsonResult("lnc_get_debug_info", result), nil
+}
+
+// debugConfig returns the config entries whose keys contain filter, with
+// sensitive values redacted if redact is set, and the keys it redacted in
+// order. Empty values are kept, since they show a setting is unset without
+// revealing anything.
+func debugConfig(config map[string]string, filter string,
+	redact bool) (map[string]string, []string) {
+	filter = strings.ToLower(filter)
+	selected := make(map[string]string)
+	redactedKeys := make([]string, 0)
+	for key, value := range config {
+		lower := strings.ToLower(key)
+		if !strings.Contains(lower, filter) {
+			continue
+		}
+		if redact && value != "" && value != "[]" &&
+			sensitiveDebugKey(lower) {
+			value = debugRedacted
+			redactedKeys = append(redactedKeys, key)
+		}
+		selected[key] = value
+	}
+	sort.Strings(redactedKeys)
+	return selected, redactedKeys
+}
+
+// sensitiveDebugKey reports whether a lower-case config key may hold a
+// sensitive value.
+func sensitiveDebugKey(key string) bool {
+	for _, sensitive := range debugSensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugLogTail returns the last count log lines containing filter, cut to
+// maxDebugLineLength and with addresses redacted if redact is set. It also
+// returns how many lines matched and how many of those returned were cut.
+func debugLogTail(log []string, filter string, count int,
+	redact bool) ([]string, int, int) {
+	filter = strings.ToLower(filter)
+	var matching []string
+	for _, line := range log {
+		// The log file ends with a newline, so lnd's split leaves an
+		// empty last line.
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(line), filter) {
+			continue
+		}
+		matching = append(matching, line)
+	}
+
+	tail := matching
+	if len(tail) > count {
+		tail = tail[len(tail)-count:]
+	}
+	lines := make([]string, len(tail))
+	truncated := 0
+	for i, line := range tail {
+		if redact {
+			for _, pattern := range debugAddressPatterns {
+				line = pattern.ReplaceAllString(line,
+					debugRedacted)
+			}
+		}
+		if len(line) > maxDebugLineLength {
+			line = strings.ToValidUTF8(
+				line[:maxDebugLineLength], "")
+			truncated++
+		}
+		lines[i] = line
+	}
+	return lines, len(matching), truncated
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// describeTargets names the arguments that say where a write tool's action
+// goes, with the label each is shown under.
+var describeTargets = []struct {
+	argument string
+	label    string
+}{
+	{"address", "Address"},
+	{"destination", "Destination"},
+	{"node_pubkey", "Peer"},
+	{"channel_point", "Channel"},
+	{"outpoint", "Outpoint"},
+}
+
+// DescribeAction summarises a write tool call for its user to approve: the
+// amount it moves, where to, and what it may pay in fees, as far as the
+// arguments tell. Invoices are decoded offline for their amount and payee.
+// The arguments are not validated; the handler still does that.
+func DescribeAction(tool string, args map[string]any) string {
+	lines := []string{"Approve " + tool + "?"}
+	add := func(label, format string, values ...any) {
+		lines = append(lines, label+": "+fmt.Sprintf(format, values...))
+	}
+
+	amountSat, _ := args["amount_sat"].(float64)
+	sat := int64(amountSat)
+
+	if invoice, ok := args["invoice"].(string); ok {
+		decoded, network, err := decodeBolt11(invoice)
+		switch {
+		case err != nil:
+			add("Invoice", "cannot be decoded (%v)", err)
+		default:
+			if decoded.MilliSat != nil {
+				sat = int64(*decoded.MilliSat / 1000)
+			}
+			add("Payee", "%s", hex.EncodeToString(
+				decoded.Destination.SerializeCompressed()))
+			if decoded.Description != nil &&
+				*decoded.Description != "" {
+				add("Description", "%s", *decoded.Description)
+			}
+			add("Network", "%s", network.name)
+		}
+	}
+	for _, target := range describeTargets {
+		if value, _ := args[target.argument].(string); value != "" {
+			add(target.label, "%s", value)
+		}
+	}
+
+	if channels, ok := args["channels"].([]any); ok {
+		var total float64
+		for _, item := range channels {
+			fields, _ := item.(map[string]any)
+			value, _ := fields["amount_sat"].(float64)
+			total += value
+		}
+		add("Channels", "%d", len(channels))
+		sat = int64(total)
+	}
+
+	switch sendAll, _ := args["send_all"].(bool); {
+	case sendAll:
+		add("Amount", "entire confirmed wallet balance")
+	case sat > 0:
+		add("Amount", "%d sat", sat)
+	}
+	if push, _ := args["push_sat"].(float64); push > 0 {
+		add("Pushed to peer", "%d sat", int64(push))
+	}
+
+	switch tool {
+	case "lnc_pay_invoice", "lnc_keysend":
+		add("Fee limit", "%d sat", paymentFeeLimit(args, sat))
+		if amp, _ := args["amp"].(bool); amp {
+			add("Payment", "AMP, split into shards")
+		}
+	}
+	if rate, _ := args["sat_per_vbyte"].(float64); rate > 0 {
+		add("Fee rate", "%d sat/vB", int64(rate))
+	} else if target, _ := args["target_conf"].(float64); target > 0 {
+		add("Fee rate", "estimated for confirmation within %d blocks",
+			int64(target))
+	}
+	if budget, _ := args["budget_sat"].(float64); budget > 0 {
+		add("Fee budget", "%d sat", int64(budget))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+)
+
+const (
+	// diagnoseTimeout bounds the whole diagnosis of a failed connect,
+	// and diagnoseDialTimeout each TCP attempt within it.
+	diagnoseTimeout     = 10 * time.Second
+	diagnoseDialTimeout = 3 * time.Second
+
+	// maxDiagnoseAddresses caps how many resolved addresses are dialed.
+	maxDiagnoseAddresses = 6
+)
+
+// Diagnosis stages, in the order a dial passes through them. The stage a
+// diagnosis reports is the first that failed.
+const (
+	dialStageResolve = "resolve"
+	dialStageConnect = "tcp_connect"
+	dialStageMailbox = "mailbox"
+)
+
+// diagnoseMailbox works out where dialing mailboxServer fails, stage by
+// stage: resolving its host name, then opening a TCP connection to every
+// address it resolves to. The mailbox library reports DNS and dual-stack
+// problems alike as a bare timeout, so failed connects carry this instead.
+// With a proxy, the proxy is diagnosed, since it resolves the mailbox.
+func diagnoseMailbox(mailboxServer string, proxy *url.URL) map[string]any {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		diagnoseTimeout)
+	defer cancel()
+
+	target, address := "mailbox", mailboxServer
+	if proxy != nil {
+		target, address = "proxy", proxy.Host
+	}
+	diagnosis := map[string]any{
+		"target":  target,
+		"address": address,
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		diagnosis["stage"] = dialStageResolve
+		diagnosis["error"] = err.Error()
+		diagnosis["hint"] = "the address must be host:port"
+		return diagnosis
+	}
+
+	start := time.Now()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	resolution := map[string]any{
+		"host":        host,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	diagnosis["resolution"] = resolution
+	if err != nil {
+		resolution["error"] = err.Error()
+		diagnosis["stage"] = dialStageResolve
+		diagnosis["hint"] = "the " + target + " host name did not " +
+			"resolve; check the DNS configuration and the name"
+		return diagnosis
+	}
+
+	var ipv4, ipv6 int
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if ip.IP.To4() != nil {
+			ipv4++
+		} else {
+			ipv6++
+		}
+		addresses = append(addresses, ip.String())
+	}
+	resolution["addresses"] = addresses
+	resolution["ipv4_count"] = ipv4
+	resolution["ipv6_count"] = ipv6
+
+	var ipv4OK, ipv6OK bool
+	attempts := make([]map[string]any, 0, len(ips))
+	dialer := &net.Dialer{Timeout: diagnoseDialTimeout}
+	for i, ip := range ips {
+		if i == maxDiagnoseAddresses {
+			break
+		}
+
+		family := "ipv6"
+		if ip.IP.To4() != nil {
+			family = "ipv4"
+		}
+		attempt := map[string]any{
+			"address": net.JoinHostPort(ip.String(), port),
+			"family":  family,
+		}
+
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp",
+			attempt["address"].(string))
+		attempt["duration_ms"] = time.Since(start).Milliseconds()
+		if err != nil {
+			attempt["error"] = err.Error()
+		} else {
+			conn.Close()
+			attempt["connected"] = true
+			if family == "ipv4" {
+				ipv4OK = true
+			} else {
+				ipv6OK = true
+			}
+		}
+		attempts = append(attempts, attempt)
+	}
+	diagnosis["attempts"] = attempts
+
+	switch {
+	case ipv4OK || ipv6OK:
+		diagnosis["stage"] = dialStageMailbox
+		diagnosis["hint"] = "the " + target + " accepts TCP " +
+			"connections, so the failure came later: in TLS, " +
+			"the WebSocket upgrade or the pairing handshake"
+		if ipv6 > 0 && !ipv6OK && ipv4OK {
+			diagnosis["hint"] = diagnosis["hint"].(string) +
+				". Its IPv6 addresses are unreachable, so " +
+				"check IPv6 routing if dials stall"
+		}
+
+	case ipv4 == 0 && ipv6 > 0:
+		diagnosis["stage"] = dialStageConnect
+		diagnosis["hint"] = "the " + target + " only has IPv6 " +
+			"addresses and none accepted a connection; check " +
+			"that this host has IPv6 connectivity"
+
+	default:
+		diagnosis["stage"] = dialStageConnect
+		diagnosis["hint"] = "no address of the " + target +
+			" accepted a TCP connection; check firewalls, " +
+			"outbound port " + port + " and the network"
+	}
+
+	return diagnosis
+}
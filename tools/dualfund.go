@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// Dual-funding contribution policy modes.
+const (
+	// DualFundNone never contributes to a peer's channel.
+	DualFundNone = "none"
+
+	// DualFundMatch contributes as much as the peer, up to the cap.
+	DualFundMatch = "match"
+
+	// DualFundFixed contributes a fixed amount, up to the cap.
+	DualFundFixed = "fixed"
+)
+
+// DualFundPolicy describes how much this node would contribute to an
+// interactive (dual-funded) channel opened by a peer. lnd up to 0.19 cannot
+// contribute as the acceptor, so the policy is only reported, ready for the
+// open flow once lnd can.
+type DualFundPolicy struct {
+	// Mode is one of DualFundNone, DualFundMatch or DualFundFixed.
+	Mode string
+
+	// FixedSat is the contribution used by the "fixed" mode.
+	FixedSat int64
+
+	// MaxSat caps any contribution. Zero means no contribution.
+	MaxSat int64
+}
+
+// ValidDualFundPolicy reports whether mode is a known contribution policy
+// mode. The empty mode is DualFundNone.
+func ValidDualFundPolicy(mode string) bool {
+	switch mode {
+	case "", DualFundNone, DualFundMatch, DualFundFixed:
+		return true
+	}
+	return false
+}
+
+// toMap formats the policy for JSON output.
+func (p DualFundPolicy) toMap() map[string]any {
+	mode := p.Mode
+	if mode == "" {
+		mode = DualFundNone
+	}
+	return map[string]any{
+		"mode":      mode,
+		"fixed_sat": p.FixedSat,
+		"max_sat":   p.MaxSat,
+	}
+}
+
+// isDualFunded reports whether both sides contributed to a channel's funding.
+func isDualFunded(ch *lnrpc.PendingChannelsResponse_PendingChannel) bool {
+	return ch != nil && ch.Initiator == lnrpc.Initiator_INITIATOR_BOTH
+}
+
+// formatInteractiveFunding lists the pending opens where both sides
+// contributed. Commitment balances are what each side holds after the push
+// and commitment fees, not what it put in, so the contributions are read
+// from the funding transactions instead: fundingTxs holds the wallet's
+// transactions by txid, and a funding transaction the wallet does not know
+// spent none of its coins.
+func formatInteractiveFunding(
+	channels []*lnrpc.PendingChannelsResponse_PendingOpenChannel,
+	fundingTxs map[string]*lnrpc.Transaction) []map[string]any {
+	sessions := make([]map[string]any, 0)
+	for _, ch := range channels {
+		if !isDualFunded(ch.Channel) {
+			continue
+		}
+		txid, _, _ := strings.Cut(ch.Channel.ChannelPoint, ":")
+		local := localContribution(fundingTxs[txid])
+		remote := max(ch.Channel.Capacity-local, 0)
+		sessions = append(sessions, map[string]any{
+			"remote_node_pub":         ch.Channel.RemoteNodePub,
+			"channel_point":           ch.Channel.ChannelPoint,
+			"capacity":                ch.Channel.Capacity,
+			"local_contribution_sat":  local,
+			"remote_contribution_sat": remote,
+		})
+	}
+	return sessions
+}
+
+// localContribution returns what the wallet put into a funding transaction:
+// its inputs less its change, as the transaction's amount records it. The
+// fee is taken out when the wallet reports it, which it only does when it
+// funded every input; otherwise the node's share of the fee stays in.
+func localContribution(tx *lnrpc.Transaction) int64 {
+	if tx == nil {
+		return 0
+	}
+	return max(-tx.Amount-tx.TotalFees, 0)
+}
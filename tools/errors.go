@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toolError converts a structured error into an MCP error result whose text
+// is a machine-readable {code, message, retryable} JSON object.
+func toolError(err *errors.Error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(err.JSON())
+}
+
+// notConnectedError is returned by handlers that need a node connection.
+// It carries the state of the connection clients come from and a hint on
+// how to recover.
+func notConnectedError(clients *ClientProvider) *mcp.CallToolResult {
+	return toolError(errors.ErrNotConnected().WithDetails(
+		clients.State().Details()))
+}
+
+// connectionReplacedError reports a long-running call whose connection was
+// replaced before it could tell how the operation ended.
+func connectionReplacedError(message string,
+	details map[string]any) *mcp.CallToolResult {
+	details["connection_replaced"] = true
+	return toolError(errors.New(errors.ErrCodeConnectionFailed,
+		message).WithDetails(details))
+}
+
+// invalidArgumentError reports a missing or malformed tool argument.
+func invalidArgumentError(message string) *mcp.CallToolResult {
+	return toolError(errors.ErrInvalidArgument(message))
+}
+
+// policyError reports an operation the policy engine rejected. Its errors
+// are structured already; anything else is reported as PermissionDenied.
+func policyError(err error) *mcp.CallToolResult {
+	var policyErr *errors.Error
+	if !errors.As(err, &policyErr) {
+		policyErr = errors.Wrap(err, errors.ErrCodePermissionDenied,
+			"rejected by policy")
+	}
+	return toolError(policyErr)
+}
+
+// rpcError reports a failed node RPC, classified by its gRPC status.
+func rpcError(err error, message string) *mcp.CallToolResult {
+	return rpcLookupError(err, errors.ErrCodeNotFound, message)
+}
+
+// subserverError is rpcError for calls to an optional subserver. When the
+// node reports the subserver missing, that is recorded against the clients
+// of the given generation and the caller is told how to enable it instead
+// of seeing the raw gRPC error.
+func subserverError(clients *ClientProvider, generation uint64, name string,
+	err error, message string) *mcp.CallToolResult {
+	if !subserverMissing(err) {
+		return rpcError(err, message)
+	}
+
+	reason := status.Convert(err).Message()
+	clients.recordSubserver(generation, name, false, reason)
+	return subserverMissingError(name, reason)
+}
+
+// subserverMissingError reports that a tool needs a subserver the node is
+// not running, and how to enable it.
+func subserverMissingError(name, reason string) *mcp.CallToolResult {
+	sub := subservers[name]
+	return toolError(errors.New(errors.ErrCodeUnsupported, fmt.Sprintf(
+		"the node is not running the %s subserver (%s); %s", name,
+		sub.service, sub.guidance)).WithDetails(map[string]any{
+		"subserver":      name,
+		"reason":         reason,
+		"degraded_tools": sub.tools,
+	}))
+}
+
+// rpcLookupError is rpcError for lookups, where a NotFound status maps to
+// the given, more specific code.
+func rpcLookupError(err error, notFound errors.ErrorCode,
+	message string) *mcp.CallToolResult {
+	// A node whose wallet is not ready fails every call the same way;
+	// explain that rather than the call.
+	if state, ok := walletStateFromError(err); ok {
+		return toolError(walletStateError(state, false))
+	}
+	return toolError(errors.Wrap(err, classifyRPCError(err, notFound),
+		message))
+}
+
+// classifyRPCError maps a gRPC status code onto the server's error codes.
+func classifyRPCError(err error, notFound errors.ErrorCode) errors.ErrorCode {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return errors.ErrCodeConnectionFailed
+	case codes.DeadlineExceeded:
+		return errors.ErrCodeTimeout
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return errors.ErrCodePermissionDenied
+	case codes.ResourceExhausted:
+		return errors.ErrCodeRateLimited
+	case codes.InvalidArgument:
+		return errors.ErrCodeInvalidArgument
+	case codes.NotFound:
+		return notFound
+	default:
+		return errors.ErrCodeRPCFailed
+	}
+}
@@ -0,0 +1,432 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/export"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// exportPageSize is how many payments or invoices an export asks
+	// lnd for at a time. Forwards are fetched maxForwardingEvents at a
+	// time.
+	exportPageSize = 10_000
+
+	// maxExportRecords is the most records one export writes. A larger
+	// history is exported over several calls, starting each where the
+	// last ended.
+	maxExportRecords = 1_000_000
+)
+
+// exportKinds are the kinds of records lnc_export_records exports.
+var exportKinds = []string{"payments", "invoices", "forwards"}
+
+// ExportService exports the node's payments, invoices and forwards to
+// files in the export directory.
+type ExportService struct {
+	Clients *ClientProvider
+	Dir     string
+
+	// MemoScrub is how invoice memos are scrubbed from exports:
+	// scrub.ModeNone, scrub.ModeHash or scrub.ModeRedact.
+	MemoScrub string
+
+	// Checkpoints remembers where incremental exports ended. Nil
+	// disables incremental exports.
+	Checkpoints *export.Checkpoints
+
+	// incrementalMu keeps incremental exports from starting at the same
+	// checkpoint, which would export the same records twice.
+	incrementalMu sync.Mutex
+}
+
+// NewExportService creates an export service writing to dir.
+func NewExportService(client lnrpc.LightningClient,
+	dir string) *ExportService {
+	return &ExportService{
+		Clients: NewClientProvider(client),
+		Dir:     dir,
+	}
+}
+
+// ExportRecordsTool returns the MCP tool definition for exporting records.
+func (s *ExportService) ExportRecordsTool() mcp.Tool {
+	formats := make([]string, len(export.Formats))
+	for i, format := range export.Formats {
+		formats[i] = string(format)
+	}
+
+	return mcp.Tool{
+		Name: "lnc_export_records",
+		Description: "Export the node's payments, invoices or " +
+			"forwards to a file in the server's export directory " +
+			"for accounting and analysis, one row per record. " +
+			"jsonl writes JSON lines; parquet and sqlite write " +
+			"typed columns that DuckDB and pandas read without " +
+			"parsing text. Records are exported in index order " +
+			"from index_offset, at most 1,000,000 per call; " +
+			"pass last_index back as index_offset to continue. " +
+			"With incremental set, the export starts where the " +
+			"last incremental export of the same records ended, " +
+			"and records where it ends for the next one, so a " +
+			"nightly job fetches only new records",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"records": map[string]any{
+					"type":        "string",
+					"description": "What to export: payments, invoices or forwards",
+					"enum":        exportKinds,
+				},
+				"format": map[string]any{
+					"type":        "string",
+					"description": "File format: jsonl (default), parquet or sqlite",
+					"enum":        formats,
+				},
+				"index_offset": map[string]any{
+					"type": "number",
+					"description": "Export only " +
+						"records after this index " +
+						"(default 0, the whole " +
+						"history, or the checkpoint " +
+						"when incremental)",
+					"minimum": 0,
+				},
+				"incremental": map[string]any{
+					"type": "boolean",
+					"description": "Start from the " +
+						"checkpoint of the last " +
+						"incremental export of " +
+						"these records, and move it " +
+						"to where this one ends. An " +
+						"index_offset given as well " +
+						"overrides the checkpoint",
+				},
+				"name": map[string]any{
+					"type": "string",
+					"description": "File name in the " +
+						"export directory (default " +
+						"<records>-<time> with the " +
+						"format's extension). " +
+						"Existing files are never " +
+						"overwritten",
+					"pattern": backupNamePattern.String(),
+				},
+			},
+			Required: []string{"records"},
+		},
+	}
+}
+
+// HandleExportRecords handles the export records request.
+func (s *ExportService) HandleExportRecords(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	kind, _ := args["records"].(string)
+	formatArg, _ := args["format"].(string)
+	offset, hasOffset := args["index_offset"].(float64)
+	name, _ := args["name"].(string)
+	incremental, _ := args["incremental"].(bool)
+
+	format := export.Format(formatArg)
+	if format == "" {
+		format = export.FormatJSONLines
+	}
+	switch {
+	case kind != "payments" && kind != "invoices" && kind != "forwards":
+		return invalidArgumentError("records must be payments, " +
+			"invoices or forwards"), nil
+	case !format.Valid():
+		return invalidArgumentError("format must be jsonl, parquet " +
+			"or sqlite"), nil
+	case offset < 0 || offset != float64(uint64(offset)):
+		return invalidArgumentError("index_offset must be a whole " +
+			"number that is not negative"), nil
+	case kind == "forwards" && offset > float64(^uint32(0)):
+		return invalidArgumentError("index_offset is past the " +
+			"forwarding history"), nil
+	case incremental && s.Checkpoints == nil:
+		return invalidArgumentError("incremental exports are not " +
+			"enabled on this server"), nil
+	}
+
+	now := time.Now().UTC()
+	if name == "" {
+		name = exportFileName(kind, format, now)
+	}
+	if !backupNamePattern.MatchString(name) {
+		return invalidArgumentError("name must be a file name of " +
+			"letters, digits, dots, dashes and underscores"), nil
+	}
+
+	// An incremental export holds the checkpoints until it has moved
+	// its own, so the next one starts where it ended.
+	if incremental {
+		s.incrementalMu.Lock()
+		defer s.incrementalMu.Unlock()
+		if checkpoint, ok := s.Checkpoints.Get(kind); ok && !hasOffset {
+			offset = float64(checkpoint.LastIndex)
+		}
+	}
+
+	var page exportPage
+	var err error
+	switch kind {
+	case "payments":
+		page, err = exportPayments(ctx, client, uint64(offset))
+	case "invoices":
+		page, err = s.exportInvoices(ctx, client, uint64(offset))
+	case "forwards":
+		page, err = exportForwards(ctx, client, uint64(offset))
+	}
+	if err != nil {
+		return rpcError(err, "failed to list "+kind), nil
+	}
+
+	path := filepath.Join(s.Dir, name)
+	err = export.Write(path, format, page.table)
+	switch {
+	case err == export.ErrExists:
+		return invalidArgumentError("a file named " + name +
+			" already exists; choose another name"), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to write the export")), nil
+	}
+
+	// The last index is the offset to continue from, so an export
+	// that found nothing new hands back the one it was given.
+	lastIndex := uint64(offset)
+	if page.lastIndex > lastIndex {
+		lastIndex = page.lastIndex
+	}
+	if incremental {
+		if err := s.Checkpoints.Set(kind, lastIndex, name); err != nil {
+			return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+				"the export was written to "+name+" but "+
+					"its checkpoint could not be "+
+					"recorded")), nil
+		}
+	}
+
+	return jsonResult("lnc_export_records", map[string]any{
+		"records":      kind,
+		"format":       string(format),
+		"name":         name,
+		"path":         path,
+		"count":        len(page.table.Rows),
+		"index_offset": uint64(offset),
+		"last_index":   lastIndex,
+		"truncated":    page.truncated,
+		"incremental":  incremental,
+		"exported_at":  now.Format(time.RFC3339),
+	}), nil
+}
+
+// exportPage is the records one export fetched.
+type exportPage struct {
+	table export.Table
+
+	// lastIndex is the index of the last record, which the next export
+	// starts after.
+	lastIndex uint64
+
+	// truncated is set when the export stopped at the most records one
+	// export writes, so there may be more after it.
+	truncated bool
+}
+
+// full reports whether the page holds as many records as one export
+// writes, marking it truncated if so.
+func (p *exportPage) full() bool {
+	if len(p.table.Rows) >= maxExportRecords {
+		p.truncated = true
+		return true
+	}
+	return false
+}
+
+// exportPayments fetches the payments after offset, including those that
+// failed or are still in flight.
+func exportPayments(ctx context.Context, client lnrpc.LightningClient,
+	offset uint64) (exportPage, error) {
+	page := exportPage{table: export.Table{
+		Name: "payments",
+		Columns: []export.Column{
+			{Name: "payment_index", Type: export.Int64},
+			{Name: "payment_hash", Type: export.String},
+			{Name: "status", Type: export.String},
+			{Name: "value_msat", Type: export.Int64},
+			{Name: "fee_msat", Type: export.Int64},
+			{Name: "creation_time_ns", Type: export.Int64},
+			{Name: "failure_reason", Type: export.String},
+			{Name: "htlc_count", Type: export.Int64},
+		},
+	}}
+
+	for !page.full() {
+		resp, err := client.ListPayments(ctx,
+			&lnrpc.ListPaymentsRequest{
+				IncludeIncomplete: true,
+				IndexOffset:       offset,
+				MaxPayments:       exportPageSize,
+			})
+		if err != nil {
+			return page, err
+		}
+		for _, payment := range resp.Payments {
+			if page.full() {
+				break
+			}
+			page.table.Rows = append(page.table.Rows, []any{
+				int64(payment.PaymentIndex),
+				payment.PaymentHash,
+				payment.Status.String(),
+				payment.ValueMsat,
+				payment.FeeMsat,
+				payment.CreationTimeNs,
+				payment.FailureReason.String(),
+				int64(len(payment.Htlcs)),
+			})
+			page.lastIndex = payment.PaymentIndex
+		}
+		if len(resp.Payments) < exportPageSize ||
+			resp.LastIndexOffset <= offset {
+			break
+		}
+		offset = resp.LastIndexOffset
+	}
+	return page, nil
+}
+
+// exportInvoices fetches the invoices added after offset, scrubbing their
+// memos as results do.
+func (s *ExportService) exportInvoices(ctx context.Context,
+	client lnrpc.LightningClient, offset uint64) (exportPage, error) {
+	page := exportPage{table: export.Table{
+		Name: "invoices",
+		Columns: []export.Column{
+			{Name: "add_index", Type: export.Int64},
+			{Name: "settle_index", Type: export.Int64},
+			{Name: "payment_hash", Type: export.String},
+			{Name: "memo", Type: export.String},
+			{Name: "value_msat", Type: export.Int64},
+			{Name: "amt_paid_msat", Type: export.Int64},
+			{Name: "state", Type: export.String},
+			{Name: "creation_date", Type: export.Int64},
+			{Name: "settle_date", Type: export.Int64},
+			{Name: "is_keysend", Type: export.Bool},
+			{Name: "is_amp", Type: export.Bool},
+		},
+	}}
+
+	for !page.full() {
+		resp, err := client.ListInvoices(ctx,
+			&lnrpc.ListInvoiceRequest{
+				IndexOffset:    offset,
+				NumMaxInvoices: exportPageSize,
+			})
+		if err != nil {
+			return page, err
+		}
+		for _, invoice := range resp.Invoices {
+			if page.full() {
+				break
+			}
+			page.table.Rows = append(page.table.Rows, []any{
+				int64(invoice.AddIndex),
+				int64(invoice.SettleIndex),
+				hex.EncodeToString(invoice.RHash),
+				scrub.Text(s.MemoScrub, invoice.Memo),
+				invoice.ValueMsat,
+				invoice.AmtPaidMsat,
+				invoice.State.String(),
+				invoice.CreationDate,
+				invoice.SettleDate,
+				invoice.IsKeysend,
+				invoice.IsAmp,
+			})
+			page.lastIndex = invoice.AddIndex
+		}
+		if len(resp.Invoices) < exportPageSize ||
+			resp.LastIndexOffset <= offset {
+			break
+		}
+		offset = resp.LastIndexOffset
+	}
+	return page, nil
+}
+
+// exportForwards fetches the forwards after offset over the whole history.
+// Forwards have no index of their own, so each is given its position in
+// the history, which is what lnd's offsets count.
+func exportForwards(ctx context.Context, client lnrpc.LightningClient,
+	offset uint64) (exportPage, error) {
+	page := exportPage{table: export.Table{
+		Name: "forwards",
+		Columns: []export.Column{
+			{Name: "offset_index", Type: export.Int64},
+			{Name: "timestamp_ns", Type: export.Int64},
+			{Name: "chan_id_in", Type: export.String},
+			{Name: "chan_id_out", Type: export.String},
+			{Name: "amt_in_msat", Type: export.Int64},
+			{Name: "amt_out_msat", Type: export.Int64},
+			{Name: "fee_msat", Type: export.Int64},
+		},
+	}}
+
+	for !page.full() {
+		resp, err := client.ForwardingHistory(ctx,
+			&lnrpc.ForwardingHistoryRequest{
+				IndexOffset:  uint32(offset),
+				NumMaxEvents: maxForwardingEvents,
+			})
+		if err != nil {
+			return page, err
+		}
+		for i, event := range resp.ForwardingEvents {
+			if page.full() {
+				break
+			}
+			index := offset + uint64(i) + 1
+			page.table.Rows = append(page.table.Rows, []any{
+				int64(index),
+				int64(event.TimestampNs),
+				strconv.FormatUint(event.ChanIdIn, 10),
+				strconv.FormatUint(event.ChanIdOut, 10),
+				int64(event.AmtInMsat),
+				int64(event.AmtOutMsat),
+				int64(event.FeeMsat),
+			})
+			page.lastIndex = index
+		}
+		if len(resp.ForwardingEvents) < maxForwardingEvents ||
+			uint64(resp.LastOffsetIndex) <= offset {
+			break
+		}
+		offset = uint64(resp.LastOffsetIndex)
+	}
+	return page, nil
+}
+
+// exportFileName returns the default name of an export of kind in format.
+func exportFileName(kind string, format export.Format,
+	now time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", kind, now.Format("20060102T150405Z"),
+		format)
+}
@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// anchorSweepVsize approximates the child transaction that spends an
+	// anchor output together with a wallet input to bump a commitment
+	// transaction's fee.
+	anchorSweepVsize = 160
+
+	// anchorOutputSat is the value of an anchor output, which the child
+	// transaction recovers.
+	anchorOutputSat = 330
+
+	// htlcTimeoutVsize and htlcSuccessVsize are the sizes of the
+	// second-level HTLC transactions of anchor channels, which carry no
+	// fee of their own and are funded from the wallet.
+	htlcTimeoutVsize = 167
+	htlcSuccessVsize = 177
+
+	// coopCloseVsize approximates a cooperative closing transaction with
+	// an output for each side.
+	coopCloseVsize = 170
+)
+
+// EstimateForceCloseTool returns the MCP tool definition for projecting a
+// force close.
+func (s *ChannelService) EstimateForceCloseTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_estimate_force_close",
+		Description: "Project how long the funds of an open channel " +
+			"would be locked by a force close, and what fees it " +
+			"needs, compared with a cooperative close: the CSV " +
+			"delay on this node's balance, the time locks of " +
+			"pending HTLCs, and for anchor channels the fee " +
+			"needed to bump the commitment transaction. Nothing " +
+			"is closed",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Channel to estimate " +
+						"(txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"target_sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Fee rate the closing " +
+						"transactions should pay; " +
+						"defaults to the half-hour rate " +
+						"of the mempool API when one " +
+						"is configured",
+					"minimum": 1,
+				},
+			},
+			Required: []string{"channel_point"},
+		},
+	}
+}
+
+// HandleEstimateForceClose handles the force close estimate request. The
+// projection assumes this node broadcasts its own commitment transaction
+// and that it confirms in the next block; block times are averages.
+func (s *ChannelService) HandleEstimateForceClose(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	value, _ := args["channel_point"].(string)
+	txid, index, err := parseChannelPoint("channel_point", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	channelPoint := fmt.Sprintf("%s:%d", txid, index)
+
+	target, hasTarget := args["target_sat_per_vbyte"].(float64)
+	if hasTarget && target < 1 {
+		return invalidArgumentError("target_sat_per_vbyte must be " +
+			"at least 1"), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	var channel *lnrpc.Channel
+	for _, ch := range list.Channels {
+		if ch.ChannelPoint == channelPoint {
+			channel = ch
+			break
+		}
+	}
+	if channel == nil {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no open channel with channel point "+channelPoint)), nil
+	}
+
+	fees := map[string]any{}
+	targetSource := "argument"
+	if !hasTarget && s.Mempool != nil {
+		snapshot, err := s.Mempool.fetch(ctx)
+		if err != nil {
+			fees["target_error"] = err.Error()
+		} else {
+			target, hasTarget = snapshot.recommended.HalfHourFee, true
+			targetSource = "external"
+		}
+	}
+
+	height := info.BlockHeight
+	csvDelay := uint32(0)
+	if channel.LocalConstraints != nil {
+		csvDelay = channel.LocalConstraints.CsvDelay
+	}
+	anchors := anchorCommitment(channel.CommitmentType)
+
+	// This node's balance is swept once the commitment transaction has
+	// confirmed and the CSV delay has passed.
+	toLocal := 1 + csvDelay
+	longest := toLocal
+
+	htlcs := make([]map[string]any, 0, len(channel.PendingHtlcs))
+	htlcFees := int64(0)
+	for _, htlc := range channel.PendingHtlcs {
+		entry := map[string]any{
+			"amount_sat":        htlc.Amount,
+			"expiration_height": htlc.ExpirationHeight,
+		}
+
+		// An outgoing HTLC can only be timed out at its expiry,
+		// an incoming one claimed as soon as the commitment
+		// confirms if the preimage is known. Either way the
+		// second-level output is then subject to the CSV delay.
+		blocks := toLocal
+		vsize := int64(htlcSuccessVsize)
+		if htlc.Incoming {
+			entry["direction"] = "incoming"
+			entry["note"] = "claimable once the commitment " +
+				"confirms if the preimage is known; " +
+				"otherwise it returns to the peer at expiry"
+		} else {
+			entry["direction"] = "outgoing"
+			if htlc.ExpirationHeight > height+1 {
+				blocks = htlc.ExpirationHeight - height + csvDelay
+			}
+			vsize = htlcTimeoutVsize
+			entry["note"] = "reclaimable only after the HTLC " +
+				"expires, then after the CSV delay"
+		}
+		entry["available_after_blocks"] = blocks
+		entry["estimated_hours"] = blockHours(blocks)
+		if anchors && hasTarget {
+			fee := int64(math.Ceil(target * float64(vsize)))
+			entry["second_level_fee_sat"] = fee
+			htlcFees += fee
+		}
+
+		longest = max(longest, blocks)
+		htlcs = append(htlcs, entry)
+	}
+
+	forceClose := map[string]any{
+		"csv_delay_blocks":             csvDelay,
+		"to_local_sat":                 channel.LocalBalance,
+		"to_local_available_blocks":    toLocal,
+		"to_local_estimated_hours":     blockHours(toLocal),
+		"htlcs":                        htlcs,
+		"longest_wait_blocks":          longest,
+		"longest_wait_estimated_hours": blockHours(longest),
+		"funds_available_height":       height + longest,
+		"estimated_available_at": time.Now().Add(
+			time.Duration(longest) * averageBlockInterval).UTC().
+			Format(time.RFC3339),
+	}
+
+	// The peer's commitment pays this node without a CSV delay, apart
+	// from the one-block delay of anchor channels.
+	remoteBlocks := uint32(1)
+	if anchors {
+		remoteBlocks = 2
+	}
+
+	commitVsize := (channel.CommitWeight + 3) / 4
+	fees["anchor_channel"] = anchors
+	fees["commitment_fee_sat"] = channel.CommitFee
+	fees["commitment_vsize"] = commitVsize
+	fees["commitment_sat_per_vbyte"] = float64(channel.FeePerKw) * 4 / 1000
+
+	coopClose := map[string]any{
+		"blocks":          1,
+		"estimated_hours": blockHours(1),
+		"peer_online":     channel.Active,
+	}
+	if channel.ThawHeight > 0 && channel.Initiator {
+		coopClose["thaw_height"] = channel.ThawHeight
+		coopClose["note"] = "the channel is frozen, so this node " +
+			"cannot close it cooperatively before the thaw height"
+	}
+
+	if hasTarget {
+		fees["target_sat_per_vbyte"] = target
+		fees["target_source"] = targetSource
+		coopClose["fee_sat"] = int64(math.Ceil(target * coopCloseVsize))
+
+		if anchors {
+			cpfp := int64(math.Ceil(target*float64(
+				commitVsize+anchorSweepVsize))) - channel.CommitFee
+			cpfp = max(cpfp, 0)
+			fees["cpfp_fee_sat"] = cpfp
+			fees["htlc_fee_sat"] = htlcFees
+
+			balance, err := client.WalletBalance(ctx,
+				&lnrpc.WalletBalanceRequest{})
+			if err != nil {
+				return rpcError(err, "failed to get wallet "+
+					"balance"), nil
+			}
+			needed := max(cpfp+htlcFees-anchorOutputSat, 0)
+			fees["wallet_funds_needed_sat"] = needed
+			fees["wallet_confirmed_sat"] = balance.ConfirmedBalance
+			fees["anchor_reserve_sat"] =
+				balance.ReservedBalanceAnchorChan
+			fees["can_fund_fees"] = balance.ConfirmedBalance >= needed
+		} else if float64(channel.FeePerKw)*4/1000 < target {
+			fees["warning"] = "the commitment transaction pays " +
+				"less than the target rate and cannot be fee " +
+				"bumped, so a force close may take long to " +
+				"confirm"
+		}
+	}
+
+	summary := fmt.Sprintf("A cooperative close returns %d sat after "+
+		"about one block. A force close locks them for %d blocks, "+
+		"about %s", channel.LocalBalance, toLocal,
+		approximateDuration(int32(toLocal)))
+	if longest > toLocal {
+		summary += fmt.Sprintf(", and pending HTLCs for up to %d "+
+			"blocks, about %s", longest,
+			approximateDuration(int32(longest)))
+	}
+	summary += "."
+
+	return jsonResult("lnc_estimate_force_close", map[string]any{
+		"channel_point":     channel.ChannelPoint,
+		"chan_id":           strconv.FormatUint(channel.ChanId, 10),
+		"remote_pubkey":     channel.RemotePubkey,
+		"commitment_type":   channel.CommitmentType.String(),
+		"initiator":         channel.Initiator,
+		"capacity_sat":      channel.Capacity,
+		"local_balance_sat": channel.LocalBalance,
+		"block_height":      height,
+		"force_close":       forceClose,
+		"remote_force_close": map[string]any{
+			"blocks":          remoteBlocks,
+			"estimated_hours": blockHours(remoteBlocks),
+		},
+		"cooperative_close": coopClose,
+		"fees":              fees,
+		"summary":           summary,
+		"note": "Assumes the closing transaction confirms in the " +
+			"next block; times are estimated from an average " +
+			"block interval of 10 minutes and fees from typical " +
+			"transaction sizes",
+	}), nil
+}
+
+// anchorCommitment reports whether a commitment type has anchor outputs,
+// through which the commitment transaction's fee can be bumped.
+func anchorCommitment(commitment lnrpc.CommitmentType) bool {
+	switch commitment {
+	case lnrpc.CommitmentType_ANCHORS,
+		lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE,
+		lnrpc.CommitmentType_SIMPLE_TAPROOT,
+		lnrpc.CommitmentType_SIMPLE_TAPROOT_OVERLAY:
+		return true
+	default:
+		return false
+	}
+}
+
+// blockHours converts a block count to hours, to one decimal place.
+func blockHours(blocks uint32) float64 {
+	hours := float64(blocks) * averageBlockInterval.Hours()
+	return math.Round(hours*10) / 10
+}
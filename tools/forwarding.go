@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultForwardingEvents is how many forwards a page holds unless
+	// max_events says otherwise, matching lnd's default.
+	defaultForwardingEvents = 100
+
+	// maxForwardingEvents is the most forwards lnd returns in one page.
+	maxForwardingEvents = 50_000
+)
+
+// ForwardingHistoryTool returns the MCP tool definition for listing the
+// payments the node forwarded.
+func (s *ChannelService) ForwardingHistoryTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_forwarding_history",
+		Description: "List the payments this node routed, with the " +
+			"channels each came in and went out on, the amounts " +
+			"and the fee earned, for a time range. Page through " +
+			"long ranges with index_offset",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"start_time": map[string]any{
+					"type": "number",
+					"description": "Start of the range as a Unix " +
+						"timestamp in seconds (default the " +
+						"start of the node's history)",
+					"minimum": 0,
+				},
+				"end_time": map[string]any{
+					"type": "number",
+					"description": "End of the range as a Unix " +
+						"timestamp in seconds (default now)",
+					"minimum": 0,
+				},
+				"index_offset": map[string]any{
+					"type": "number",
+					"description": "Number of forwards in the " +
+						"range to skip; pass the previous " +
+						"page's last_offset_index",
+					"minimum": 0,
+				},
+				"max_events": map[string]any{
+					"type": "number",
+					"description": "Maximum number of " +
+						"forwards to return (default 100)",
+					"minimum": 1,
+					"maximum": maxForwardingEvents,
+				},
+				"include_peer_alias": map[string]any{
+					"type": "boolean",
+					"description": "Look up the alias of the " +
+						"peer on each side of every forward",
+				},
+			},
+		},
+	}
+}
+
+// HandleForwardingHistory handles the forwarding history request.
+func (s *ChannelService) HandleForwardingHistory(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	startTime, _ := args["start_time"].(float64)
+	endTime, _ := args["end_time"].(float64)
+	indexOffset, _ := args["index_offset"].(float64)
+	maxEvents, _ := args["max_events"].(float64)
+	includeAlias, _ := args["include_peer_alias"].(bool)
+
+	switch {
+	case startTime < 0 || endTime < 0 || indexOffset < 0:
+		return invalidArgumentError("start_time, end_time and " +
+			"index_offset must not be negative"), nil
+	case endTime != 0 && endTime <= startTime:
+		return invalidArgumentError("end_time must be after " +
+			"start_time"), nil
+	case maxEvents < 0 || maxEvents > maxForwardingEvents:
+		return invalidArgumentError(fmt.Sprintf("max_events must be "+
+			"between 1 and %d", maxForwardingEvents)), nil
+	case maxEvents == 0:
+		maxEvents = defaultForwardingEvents
+	}
+
+	resp, err := client.ForwardingHistory(ctx,
+		&lnrpc.ForwardingHistoryRequest{
+			StartTime:       uint64(startTime),
+			EndTime:         uint64(endTime),
+			IndexOffset:     uint32(indexOffset),
+			NumMaxEvents:    uint32(maxEvents),
+			PeerAliasLookup: includeAlias,
+		})
+	if err != nil {
+		return rpcError(err, "failed to fetch forwarding history"), nil
+	}
+
+	var totalFeeMsat, totalAmtOutMsat uint64
+	forwards := make([]map[string]any, len(resp.ForwardingEvents))
+	for i, event := range resp.ForwardingEvents {
+		forward := map[string]any{
+			"timestamp_ns": event.TimestampNs,
+			"chan_id_in":   strconv.FormatUint(event.ChanIdIn, 10),
+			"chan_id_out":  strconv.FormatUint(event.ChanIdOut, 10),
+			"amt_in_msat":  event.AmtInMsat,
+			"amt_out_msat": event.AmtOutMsat,
+			"fee_msat":     event.FeeMsat,
+		}
+		if includeAlias {
+			forward["peer_alias_in"] = event.PeerAliasIn
+			forward["peer_alias_out"] = event.PeerAliasOut
+		}
+		// The HTLC indexes, for lnc_lookup_htlc_resolution, are only
+		// reported by lnd 0.19 and later.
+		if event.IncomingHtlcId != nil {
+			forward["htlc_index_in"] = *event.IncomingHtlcId
+		}
+		if event.OutgoingHtlcId != nil {
+			forward["htlc_index_out"] = *event.OutgoingHtlcId
+		}
+		forwards[i] = forward
+
+		totalFeeMsat += event.FeeMsat
+		totalAmtOutMsat += event.AmtOutMsat
+	}
+
+	// A full page may be followed by more forwards in the range.
+	return jsonResult("lnc_forwarding_history", map[string]any{
+		"forwards":           forwards,
+		"total_forwards":     len(forwards),
+		"total_fee_msat":     totalFeeMsat,
+		"total_amt_out_msat": totalAmtOutMsat,
+		"last_offset_index":  resp.LastOffsetIndex,
+		"has_more":           len(forwards) == int(maxEvents),
+	}), nil
+}
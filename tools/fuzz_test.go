@@ -0,0 +1,323 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The fuzz targets below check that malformed arguments, as a model may
+// generate them, are either rejected cleanly or produce results that are
+// consistent with the input. Run one with, for example:
+//
+//	go test ./tools -run '^$' -fuzz FuzzParsePubkey -fuzztime 30s
+
+func FuzzParsePubkey(f *testing.F) {
+	f.Add(contractPubkey)
+	f.Add(strings.ToUpper(contractPubkey))
+	f.Add("04" + strings.Repeat("ab", 32))
+	f.Add("02" + strings.Repeat("ff", 32))
+	f.Add("02ab")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		pubkey, err := parsePubkey("pubkey", value)
+		if err != nil {
+			if pubkey != nil {
+				t.Fatalf("rejected %q but returned a key", value)
+			}
+			return
+		}
+
+		if len(pubkey) != 33 || (pubkey[0] != 0x02 && pubkey[0] != 0x03) {
+			t.Fatalf("accepted %q as %x, not a compressed key",
+				value, pubkey)
+		}
+		if hex.EncodeToString(pubkey) != strings.ToLower(value) {
+			t.Fatalf("accepted %q as a different key %x", value,
+				pubkey)
+		}
+	})
+}
+
+func FuzzParseHash(f *testing.F) {
+	f.Add(contractHash)
+	f.Add(strings.ToUpper(contractHash))
+	f.Add(strings.Repeat("z", 64))
+	f.Add(contractHash[:63])
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		hash, err := parseHash("payment_hash", value)
+		if err != nil {
+			return
+		}
+
+		if len(hash) != 32 {
+			t.Fatalf("accepted %q as %d bytes", value, len(hash))
+		}
+		if hex.EncodeToString(hash) != strings.ToLower(value) {
+			t.Fatalf("accepted %q as a different hash %x", value,
+				hash)
+		}
+	})
+}
+
+func FuzzParseChannelPoint(f *testing.F) {
+	f.Add(contractOutpoint)
+	f.Add(strings.Repeat("a", 64) + ":0")
+	f.Add(strings.Repeat("a", 64) + ":4294967296")
+	f.Add(strings.Repeat("a", 64) + ":-1")
+	f.Add(strings.Repeat("a", 63) + ":0")
+	f.Add(":")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		txid, index, err := parseChannelPoint("channel_point", value)
+		if err != nil {
+			return
+		}
+
+		if len(txid) != 64 || strings.ToLower(value[:64]) != txid {
+			t.Fatalf("accepted %q with a different txid %q",
+				value, txid)
+		}
+
+		// The canonical form parses back to the same outpoint.
+		canonical := fmt.Sprintf("%s:%d", txid, index)
+		again, againIndex, err := parseChannelPoint("channel_point",
+			canonical)
+		if err != nil || again != txid || againIndex != index {
+			t.Fatalf("%q does not round-trip via %q", value,
+				canonical)
+		}
+	})
+}
+
+func FuzzParsePeerAddress(f *testing.F) {
+	f.Add(contractPubkey + "@127.0.0.1:9735")
+	f.Add(contractPubkey + "@[::1]")
+	f.Add(contractPubkey + "@::1")
+	f.Add(contractPubkey + "@node.example.onion:0")
+	f.Add(contractPubkey + "@host:65536")
+	f.Add(contractPubkey + "@")
+	f.Add("@host")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		pubkey, host, err := parsePeerAddress("address", value)
+		if err != nil {
+			return
+		}
+
+		key, _, _ := strings.Cut(value, "@")
+		if hex.EncodeToString(pubkey) != strings.ToLower(key) {
+			t.Fatalf("accepted %q with a different key %x", value,
+				pubkey)
+		}
+
+		// The host returned always carries a port and parses back to
+		// itself.
+		again, againHost, err := parsePeerAddress("address",
+			key+"@"+host)
+		if err != nil || againHost != host ||
+			!bytes.Equal(again, pubkey) {
+			t.Fatalf("%q does not round-trip via host %q", value,
+				host)
+		}
+	})
+}
+
+func FuzzDecodeBolt11(f *testing.F) {
+	invoice := newTestBolt11(f, &chaincfg.MainNetParams, 250_000)
+	f.Add(invoice)
+	f.Add("lightning:" + strings.ToUpper(invoice))
+	f.Add(" " + newTestBolt11(f, &chaincfg.RegressionNetParams, 0) + "\n")
+	f.Add(newTestBolt11(f, &chaincfg.SigNetParams, 1))
+	f.Add(invoice[:len(invoice)-1])
+	f.Add("lnbc1")
+	f.Add("lightning:")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		decoded, network, err := decodeBolt11(value)
+		if isValidBolt11(value) != (err == nil) {
+			t.Fatalf("isValidBolt11 disagrees with decodeBolt11 "+
+				"for %q", value)
+		}
+		if err != nil {
+			return
+		}
+
+		normalized := normalizeBolt11(value)
+		if normalizeBolt11(normalized) != normalized {
+			t.Fatalf("normalizing %q is not idempotent", value)
+		}
+
+		// The network reported is the one the invoice was decoded
+		// for.
+		detected, ok := detectBolt11Network(normalized)
+		if !ok || detected.name != network.name {
+			t.Fatalf("%q decoded as %s but detected as %s", value,
+				network.name, detected.name)
+		}
+		if decoded.Net != network.params {
+			t.Fatalf("%q decoded with %s params but reported as %s",
+				value, decoded.Net.Name, network.name)
+		}
+		if decoded.PaymentHash == nil || decoded.Destination == nil {
+			t.Fatalf("%q decoded without a payment hash or "+
+				"destination", value)
+		}
+	})
+}
+
+func FuzzDecodeAddress(f *testing.F) {
+	f.Add(contractAddress)
+	f.Add(strings.ToUpper(contractAddress))
+	f.Add("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa")
+	f.Add("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy")
+	f.Add("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx")
+	f.Add("bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080")
+	f.Add("bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		addr, networks, err := decodeAddress(value)
+		if err != nil {
+			return
+		}
+
+		if len(networks) == 0 {
+			t.Fatalf("decoded %q without any network", value)
+		}
+
+		// Every network reported accepts the address's own encoding.
+		for _, name := range networks {
+			var params *chaincfg.Params
+			for _, network := range addressNetworks {
+				if network.name == name {
+					params = network.params
+				}
+			}
+			again, err := btcutil.DecodeAddress(
+				addr.EncodeAddress(), params)
+			if err != nil || !again.IsForNet(params) {
+				t.Fatalf("%q reported for %s but does not "+
+					"decode there", value, name)
+			}
+		}
+	})
+}
+
+func FuzzKeysendRecords(f *testing.F) {
+	f.Add("65537", "cafe")
+	f.Add("65536", "")
+	f.Add("65535", "00")
+	f.Add("5482373484", "00")
+	f.Add("18446744073709551616", "00")
+	f.Add("+65537", "00")
+	f.Add("65537", "zz")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		records, err := keysendRecords(map[string]any{key: value})
+		if err != nil {
+			return
+		}
+
+		for recordType, data := range records {
+			if recordType < customRecordTypeStart ||
+				recordType == keysendRecordType {
+				t.Fatalf("accepted reserved record type %d from "+
+					"%q", recordType, key)
+			}
+			if hex.EncodeToString(data) != strings.ToLower(value) {
+				t.Fatalf("record %d holds %x, not %q",
+					recordType, data, value)
+			}
+		}
+	})
+}
+
+// FuzzHandlerArguments drives every offline-capable handler with arbitrary
+// JSON arguments. Whatever the arguments, a handler must return either a
+// structured error or a result that matches its output schema.
+func FuzzHandlerArguments(f *testing.F) {
+	cases := contractCases(f)
+
+	hostile := []string{
+		`{}`,
+		`{"amount_sat": -1}`,
+		`{"amount_sat": 1e308, "timeout_seconds": 1e308}`,
+		`{"invoice": 5, "destination": null, "node_pubkey": []}`,
+		`{"tlv_records": {"65537": 12}}`,
+		`{"payment_hash": "` + strings.ToUpper(contractHash) + `"}`,
+		`{"channel_point": "` + contractOutpoint + `x"}`,
+	}
+	for i, tc := range cases {
+		args, err := json.Marshal(tc.args)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(uint8(i), string(args))
+		for _, extra := range hostile {
+			f.Add(uint8(i), extra)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, index uint8, rawArgs string) {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(rawArgs), &args); err != nil {
+			return
+		}
+		if args == nil {
+			args = map[string]any{}
+		}
+
+		tc := cases[int(index)%len(cases)]
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+
+		result, err := tc.handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s returned a protocol error: %v", tc.tool, err)
+		}
+		if result == nil || len(result.Content) == 0 {
+			t.Fatalf("%s returned no content", tc.tool)
+		}
+		text, ok := result.Content[0].(mcp.TextContent)
+		if !ok {
+			t.Fatalf("%s returned non-text content", tc.tool)
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(text.Text), &payload); err != nil {
+			t.Fatalf("%s returned invalid JSON: %v", tc.tool, err)
+		}
+
+		if result.IsError {
+			code, _ := payload["code"].(string)
+			message, _ := payload["message"].(string)
+			if code == "" || message == "" {
+				t.Fatalf("%s returned an unstructured error: %s",
+					tc.tool, text.Text)
+			}
+			return
+		}
+
+		schema, _ := OutputSchema(tc.tool)
+		if violations := validateContract("$", jsonSchema(t, schema),
+			payload); len(violations) > 0 {
+			t.Fatalf("%s with %s violates its schema: %v", tc.tool,
+				rawArgs, violations)
+		}
+	})
+}
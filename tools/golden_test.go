@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbrill/mcp-lnc-server/internal/testutils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// goldenVolatile lists, per tool, result fields whose values differ between
+// runs. The contract fixtures pin everything else.
+var goldenVolatile = map[string][]string{
+	// The test invoice is signed with a fresh key at the current time.
+	"lnc_decode_invoice_offline": {
+		"destination", "payment_hash", "timestamp", "expires_at",
+	},
+	"lnc_lsp_get_info": {"url"},
+	"lnc_pay_invoice":  {"at_ms"},
+	"lnc_keysend":      {"at_ms"},
+	"lnc_open_channel": {"at_ms"},
+	"lnc_server_stats": {"checked_at"},
+	"lnc_list_leases":  {"expires_in_seconds"},
+
+	// The macaroon's timeout caveat is set from the current time.
+	"lnc_bake_macaroon": {"macaroon", "expires_at"},
+
+	// Operations are journaled when the fixtures are built.
+	"lnc_list_operations": {"started_at", "updated_at"},
+
+	// Contacts are recorded when the fixtures are built.
+	"lnc_list_peers":              {"updated_at"},
+	"lnc_list_channels":           {"updated_at"},
+	"lnc_channel_balance_by_peer": {"updated_at"},
+	"lnc_get_node_info":           {"updated_at"},
+	"lnc_list_contacts":           {"updated_at"},
+	"lnc_set_contact":             {"updated_at"},
+
+	// Watched nodes are added and checked when the fixtures are built
+	// and when the tools are called.
+	"lnc_watched_nodes": {"added_at", "checked_at", "updated_at"},
+	"lnc_watch_node":    {"added_at", "checked_at"},
+
+	// Backups are written to a temporary directory at the current time.
+	"lnc_server_backup":  {"path", "created_at"},
+	"lnc_server_restore": {"created_at"},
+
+	// The local stores are exported to the same directory and checked
+	// at the current time.
+	"lnc_store_maintenance": {"path", "checked_at"},
+
+	// Records are exported to a temporary directory at the current
+	// time.
+	"lnc_export_records": {"path", "exported_at"},
+
+	// Calls are scheduled under random IDs, relative to the current
+	// time.
+	"lnc_list_scheduled_operations":   scheduleVolatile,
+	"lnc_schedule_operation":          scheduleVolatile,
+	"lnc_cancel_scheduled_operation":  scheduleVolatile,
+	"lnc_restore_scheduled_operation": scheduleVolatile,
+
+	// Availability is estimated from the current time.
+	"lnc_close_progress":       {"estimated_available_at"},
+	"lnc_estimate_force_close": {"estimated_available_at"},
+
+	// The forwarding window and expiry estimates are taken from the
+	// current time, and contacts are recorded when the fixtures are
+	// built.
+	"lnc_partner_sla": {"since", "updated_at"},
+	"lnc_list_htlcs":  {"estimated_expiry_at", "updated_at"},
+
+	// The mempool API listens on a random port and is read at call
+	// time.
+	"lnc_estimate_fee": {"provider", "fetched_at"},
+	"lnc_send_coins": {
+		"confirmation_id", "expires_at", "provider", "fetched_at",
+	},
+
+	// The header age depends on the current time, and the tip source
+	// listens on a random port.
+	"lnc_get_sync_status": {"header_age_seconds", "tip_source"},
+}
+
+// scheduleVolatile are the fields of a scheduled operation that differ
+// between runs.
+var scheduleVolatile = []string{
+	"id", "run_at", "at", "deleted_at", "created_at", "updated_at",
+}
+
+// Test that every tool's result is byte-for-byte what it was, so any change
+// to field names, values or formatting shows up as a golden file diff.
+// Run with -update to accept an intended change.
+func TestGoldenResponses(t *testing.T) {
+	for _, tc := range contractCases(t) {
+		t.Run(tc.tool, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tc.args
+			if request.Params.Arguments == nil {
+				request.Params.Arguments = map[string]any{}
+			}
+
+			result, err := tc.handler(context.Background(), request)
+			require.NoError(t, err)
+
+			text := result.Content[0].(mcp.TextContent).Text
+			require.False(t, result.IsError, text)
+
+			testutils.AssertGolden(t, tc.tool, testutils.CanonicalJSON(
+				t, []byte(text), goldenVolatile[tc.tool]...))
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxInvoiceMemo is the longest description BOLT11 can carry, in
+	// bytes.
+	maxInvoiceMemo = 639
+
+	// maxInvoiceExpiry is the longest expiry accepted for a hold
+	// invoice, one year.
+	maxInvoiceExpiry = 365 * 24 * 60 * 60
+
+	// defaultInvoiceExpiry is lnd's expiry for invoices that do not set
+	// one.
+	defaultInvoiceExpiry = 24 * 60 * 60
+)
+
+// AddHoldInvoiceTool returns the MCP tool definition for creating a hold
+// invoice.
+func (s *InvoiceService) AddHoldInvoiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_add_hold_invoice",
+		Description: "Create a hold invoice for a payment hash whose " +
+			"preimage the caller keeps. Incoming payments are " +
+			"accepted but not settled until lnc_settle_invoice " +
+			"reveals the preimage, or are failed back with " +
+			"lnc_cancel_invoice, so a payment can be made " +
+			"conditional on something else happening",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"payment_hash": map[string]any{
+					"type": "string",
+					"description": "SHA-256 hash of the preimage " +
+						"that settles the invoice (hex encoded)",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount in satoshis; omit " +
+						"for an invoice the payer chooses " +
+						"the amount of",
+					"minimum": 0,
+				},
+				"amount_msat": map[string]any{
+					"type": "number",
+					"description": "Amount in millisatoshis, " +
+						"instead of amount_sat",
+					"minimum": 0,
+				},
+				"memo": map[string]any{
+					"type":        "string",
+					"description": "Description shown to the payer",
+					"maxLength":   maxInvoiceMemo,
+				},
+				"description_hash": map[string]any{
+					"type": "string",
+					"description": "SHA-256 hash of a longer " +
+						"description, instead of memo " +
+						"(hex encoded)",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+				"expiry_seconds": map[string]any{
+					"type": "number",
+					"description": "Seconds until the invoice " +
+						"expires (default 86400)",
+					"minimum": 1,
+					"maximum": maxInvoiceExpiry,
+				},
+				"cltv_expiry": map[string]any{
+					"type": "number",
+					"description": "Minimum CLTV delta of the " +
+						"final hop, which bounds how long " +
+						"accepted payments can be held " +
+						"(default chosen by the node)",
+					"minimum": 18,
+				},
+				"private": map[string]any{
+					"type": "boolean",
+					"description": "Add route hints for the " +
+						"node's private channels",
+				},
+				"route_hints": map[string]any{
+					"type": "array",
+					"description": "Route hints to embed, in " +
+						"the form lnc_decode_invoice " +
+						"reports them",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"hop_hints": map[string]any{
+								"type": "array",
+								"items": map[string]any{
+									"type": "object",
+								},
+							},
+						},
+						"required": []string{"hop_hints"},
+					},
+				},
+			},
+			Required: []string{"payment_hash"},
+		},
+	}
+}
+
+// HandleAddHoldInvoice handles the add hold invoice request. The hash is
+// looked up first, so reusing the hash of an existing invoice is reported
+// with that invoice's state instead of the node's error.
+func (s *InvoiceService) HandleAddHoldInvoice(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, generation := s.Clients.Lightning()
+	invoices, _ := s.Clients.Invoices()
+	if client == nil || invoices == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "invoices"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+
+	value, _ := args["payment_hash"].(string)
+	hash, err := parseHash("payment_hash", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	terms, err := parseInvoiceTerms(args)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	req := &invoicesrpc.AddHoldInvoiceRequest{
+		Hash:            hash,
+		Memo:            terms.memo,
+		Value:           terms.value,
+		ValueMsat:       terms.valueMsat,
+		DescriptionHash: terms.descriptionHash,
+		Expiry:          terms.expiry,
+		Private:         terms.private,
+		RouteHints:      terms.routeHints,
+	}
+
+	if cltv, ok := args["cltv_expiry"].(float64); ok {
+		if cltv < 18 {
+			return invalidArgumentError("cltv_expiry must be at " +
+				"least 18"), nil
+		}
+		req.CltvExpiry = uint64(cltv)
+	}
+
+	existing, err := client.LookupInvoice(ctx, &lnrpc.PaymentHash{
+		RHash: hash,
+	})
+	switch {
+	case err == nil:
+		return toolError(errors.New(errors.ErrCodeInvalidArgument,
+			"an invoice with that payment hash already exists; "+
+				"use a new preimage for every hold "+
+				"invoice").WithDetails(map[string]any{
+			"state": existing.State.String(),
+		})), nil
+
+	case status.Code(err) != codes.NotFound:
+		return rpcError(err, "failed to lookup invoice"), nil
+	}
+
+	resp, err := invoices.AddHoldInvoice(ctx, req)
+	if err != nil {
+		return subserverError(s.Clients, generation, "invoices", err,
+			"failed to add hold invoice"), nil
+	}
+	s.Clients.recordSubserver(generation, "invoices", true, "")
+
+	return jsonResult("lnc_add_hold_invoice", map[string]any{
+		"payment_request":  resp.PaymentRequest,
+		"payment_hash":     hex.EncodeToString(hash),
+		"payment_addr":     hex.EncodeToString(resp.PaymentAddr),
+		"add_index":        resp.AddIndex,
+		"state":            lnrpc.Invoice_OPEN.String(),
+		"value_msat":       terms.amountMsat(),
+		"expiry_seconds":   req.Expiry,
+		"private":          req.Private,
+		"route_hint_count": len(req.RouteHints),
+		"note": "Payments to this invoice are held once accepted. " +
+			"Settle them with lnc_settle_invoice and the " +
+			"preimage, or fail them back with " +
+			"lnc_cancel_invoice, before the HTLCs near their " +
+			"expiry height; the node cancels them itself then",
+	}), nil
+}
+
+// parseRouteHints parses the route_hints argument, given in the form
+// lnc_decode_invoice reports route hints.
+func parseRouteHints(value any) ([]*lnrpc.RouteHint, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("route_hints must be an array")
+	}
+
+	hints := make([]*lnrpc.RouteHint, len(list))
+	for i, item := range list {
+		hint, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("route_hints[%d] must be an "+
+				"object", i)
+		}
+		hops, ok := hint["hop_hints"].([]any)
+		if !ok || len(hops) == 0 {
+			return nil, fmt.Errorf("route_hints[%d].hop_hints must "+
+				"be a non-empty array", i)
+		}
+
+		hints[i] = &lnrpc.RouteHint{
+			HopHints: make([]*lnrpc.HopHint, len(hops)),
+		}
+		for j, item := range hops {
+			name := fmt.Sprintf("route_hints[%d].hop_hints[%d]", i, j)
+			hop, err := parseHopHint(name, item)
+			if err != nil {
+				return nil, err
+			}
+			hints[i].HopHints[j] = hop
+		}
+	}
+
+	return hints, nil
+}
+
+// parseHopHint parses one hop hint of the route_hints argument.
+func parseHopHint(name string, value any) (*lnrpc.HopHint, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", name)
+	}
+
+	nodeID, _ := fields["node_id"].(string)
+	pubkey, err := parsePubkey(name+".node_id", nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if fields["chan_id"] == nil {
+		return nil, fmt.Errorf("%s.chan_id is required", name)
+	}
+	chanID, err := routeInteger(fields, "chan_id", name, 64)
+	if err != nil {
+		return nil, err
+	}
+	feeBase, err := routeInteger(fields, "fee_base", name, 32)
+	if err != nil {
+		return nil, err
+	}
+	feeProp, err := routeInteger(fields, "fee_prop", name, 32)
+	if err != nil {
+		return nil, err
+	}
+	cltvDelta, err := routeInteger(fields, "cltv_delta", name, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.HopHint{
+		NodeId:                    hex.EncodeToString(pubkey),
+		ChanId:                    chanID,
+		FeeBaseMsat:               uint32(feeBase),
+		FeeProportionalMillionths: uint32(feeProp),
+		CltvExpiryDelta:           uint32(cltvDelta),
+	}, nil
+}
+
+// CancelInvoiceTool returns the MCP tool definition for canceling an
+// invoice.
+func (s *InvoiceService) CancelInvoiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_cancel_invoice",
+		Description: "Cancel an open or accepted invoice so it can no " +
+			"longer be paid. For a hold invoice whose HTLCs are " +
+			"accepted, the payment is failed back to the payer",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"payment_hash": map[string]any{
+					"type":        "string",
+					"description": "Payment hash of the invoice (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{64}$",
+				},
+			},
+			Required: []string{"payment_hash"},
+		},
+	}
+}
+
+// HandleCancelInvoice handles the cancel invoice request. Canceling an
+// invoice that is already canceled succeeds without calling the node.
+func (s *InvoiceService) HandleCancelInvoice(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	value, _ := request.Params.Arguments["payment_hash"].(string)
+	hash, err := parseHash("payment_hash", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	return s.resolveHoldInvoice(ctx, "lnc_cancel_invoice", hash,
+		lnrpc.Invoice_CANCELED, func(ctx context.Context,
+			client invoicesrpc.InvoicesClient) error {
+			_, err := client.CancelInvoice(ctx,
+				&invoicesrpc.CancelInvoiceMsg{PaymentHash: hash})
+			return err
+		})
+}
+
+// SettleInvoiceTool returns the MCP tool definition for settling a hold
+// invoice.
+func (s *InvoiceService) SettleInvoiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_settle_invoice",
+		Description: "Settle a hold invoice whose HTLCs have been " +
+			"accepted by revealing its preimage, which completes " +
+			"the payment",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"preimage": map[string]any{
+					"type": "string",
+					"description": "Preimage of the invoice's " +
+						"payment hash (hex encoded)",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+			},
+			Required: []string{"preimage"},
+		},
+	}
+}
+
+// HandleSettleInvoice handles the settle invoice request. The invoice is
+// found by the hash of the preimage, so a wrong preimage is reported as an
+// unknown invoice. Settling an invoice that is already settled succeeds
+// without calling the node.
+func (s *InvoiceService) HandleSettleInvoice(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	value, _ := request.Params.Arguments["preimage"].(string)
+	preimage, err := parseHash("preimage", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	hash := sha256.Sum256(preimage)
+
+	return s.resolveHoldInvoice(ctx, "lnc_settle_invoice", hash[:],
+		lnrpc.Invoice_SETTLED, func(ctx context.Context,
+			client invoicesrpc.InvoicesClient) error {
+			_, err := client.SettleInvoice(ctx,
+				&invoicesrpc.SettleInvoiceMsg{Preimage: preimage})
+			return err
+		})
+}
+
+// resolveHoldInvoice moves the invoice with the given hash to the target
+// state, canceled or settled, by calling resolve. The invoice is looked up
+// first, so an invoice that already reached a final state is reported
+// clearly instead of with the node's error.
+func (s *InvoiceService) resolveHoldInvoice(ctx context.Context, tool string,
+	hash []byte, target lnrpc.Invoice_InvoiceState,
+	resolve func(context.Context,
+		invoicesrpc.InvoicesClient) error) (*mcp.CallToolResult, error) {
+	client, generation := s.Clients.Lightning()
+	invoices, _ := s.Clients.Invoices()
+	if client == nil || invoices == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "invoices"); missing != nil {
+		return missing, nil
+	}
+
+	invoice, err := client.LookupInvoice(ctx, &lnrpc.PaymentHash{
+		RHash: hash,
+	})
+	if err != nil {
+		return rpcLookupError(err, errors.ErrCodeInvoiceNotFound,
+			"failed to lookup invoice"), nil
+	}
+
+	state := invoice.State
+	switch {
+	case state == target:
+		// Nothing to do.
+
+	case state == lnrpc.Invoice_SETTLED ||
+		state == lnrpc.Invoice_CANCELED:
+		return toolError(errors.New(errors.ErrCodeInvalidArgument,
+			fmt.Sprintf("the invoice is already %s",
+				invoiceStateName(state))).WithDetails(
+			map[string]any{"state": state.String()})), nil
+
+	case target == lnrpc.Invoice_SETTLED &&
+		state == lnrpc.Invoice_OPEN:
+		return toolError(errors.New(errors.ErrCodeInvalidArgument,
+			"the invoice has no accepted HTLCs to settle yet; "+
+				"wait for the payer to pay it").WithDetails(
+			map[string]any{"state": state.String()})), nil
+
+	default:
+		if err := resolve(ctx, invoices); err != nil {
+			return subserverError(s.Clients, generation, "invoices",
+				err, fmt.Sprintf("failed to %s invoice",
+					invoiceAction(target))), nil
+		}
+		s.Clients.recordSubserver(generation, "invoices", true, "")
+	}
+
+	acceptedHTLCs := 0
+	for _, htlc := range invoice.Htlcs {
+		if htlc.State == lnrpc.InvoiceHTLCState_ACCEPTED {
+			acceptedHTLCs++
+		}
+	}
+
+	return jsonResult(tool, map[string]any{
+		"payment_hash":   hex.EncodeToString(hash),
+		"state":          target.String(),
+		"previous_state": state.String(),
+		"changed":        state != target,
+		"value_msat":     invoice.ValueMsat,
+		"amt_paid_msat":  invoice.AmtPaidMsat,
+		"accepted_htlcs": acceptedHTLCs,
+	}), nil
+}
+
+// invoiceStateName returns a final invoice state as it reads in a
+// sentence.
+func invoiceStateName(state lnrpc.Invoice_InvoiceState) string {
+	if state == lnrpc.Invoice_SETTLED {
+		return "settled"
+	}
+	return "canceled"
+}
+
+// invoiceAction returns the verb that moves an invoice to state.
+func invoiceAction(state lnrpc.Invoice_InvoiceState) string {
+	if state == lnrpc.Invoice_SETTLED {
+		return "settle"
+	}
+	return "cancel"
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// htlcResolutionsFlag is the lnd option that makes it record how HTLCs were
+// finally resolved. lnd refuses lookups while it is off.
+const htlcResolutionsFlag = "store-final-htlc-resolutions"
+
+// LookupHtlcResolutionTool returns the MCP tool definition for looking up
+// how an HTLC was finally resolved.
+func (s *ChannelService) LookupHtlcResolutionTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_lookup_htlc_resolution",
+		Description: "Look up how one HTLC was finally resolved: " +
+			"whether it was settled or failed, and whether that " +
+			"happened off-chain or on-chain after the channel " +
+			"was force closed. HTLCs are identified by channel " +
+			"and HTLC index, as given by lnc_forwarding_history " +
+			"for forwards. Needs lnd to run with " +
+			htlcResolutionsFlag + "=true, and only HTLCs " +
+			"resolved since it was enabled are recorded",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"chan_id": map[string]any{
+					"type": "string",
+					"description": "Channel ID, in " +
+						"decimal or as a short " +
+						"channel ID such as " +
+						"800000x1234x0",
+					"pattern": "^([0-9]+|[0-9]+x[0-9]+x[0-9]+)$",
+				},
+				"htlc_index": map[string]any{
+					"type":        "number",
+					"description": "Index of the HTLC in the channel",
+					"minimum":     0,
+				},
+			},
+			Required: []string{"chan_id", "htlc_index"},
+		},
+	}
+}
+
+// HandleLookupHtlcResolution handles the HTLC resolution lookup request.
+func (s *ChannelService) HandleLookupHtlcResolution(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	chanIDArg, _ := args["chan_id"].(string)
+	if chanIDArg == "" {
+		return invalidArgumentError("chan_id is required"), nil
+	}
+	chanID, err := parseChanID("chan_id", chanIDArg)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	index, ok := args["htlc_index"].(float64)
+	if !ok || index < 0 || index > maxExactJSONInteger ||
+		index != math.Trunc(index) {
+		return invalidArgumentError("htlc_index must be a " +
+			"non-negative integer"), nil
+	}
+	htlcIndex := uint64(index)
+
+	resp, err := client.LookupHtlcResolution(ctx,
+		&lnrpc.LookupHtlcResolutionRequest{
+			ChanId:    chanID,
+			HtlcIndex: htlcIndex,
+		})
+	switch {
+	// lnd reports the option being off as Unavailable, which would
+	// otherwise read as a lost connection.
+	case status.Code(err) == codes.Unavailable &&
+		strings.Contains(status.Convert(err).Message(),
+			htlcResolutionsFlag):
+		return toolError(errors.New(errors.ErrCodeUnsupported,
+			"the node does not record final HTLC resolutions; "+
+				"set "+htlcResolutionsFlag+"=true in "+
+				"lnd.conf and restart lnd. Only HTLCs "+
+				"resolved after that are recorded")), nil
+	case status.Code(err) == codes.NotFound:
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no final resolution is recorded for this HTLC: it "+
+				"may not exist, may still be pending, or was "+
+				"resolved before "+htlcResolutionsFlag+
+				" was enabled")), nil
+	case err != nil:
+		return rpcError(err, "failed to look up HTLC resolution"), nil
+	}
+
+	outcome := "failed"
+	if resp.Settled {
+		outcome = "settled"
+	}
+	resolvedOn := "on_chain"
+	if resp.Offchain {
+		resolvedOn = "off_chain"
+	}
+
+	return jsonResult("lnc_lookup_htlc_resolution", map[string]any{
+		"chan_id":          strconv.FormatUint(chanID, 10),
+		"short_channel_id": formatShortChanID(chanID),
+		"htlc_index":       htlcIndex,
+		"settled":          resp.Settled,
+		"offchain":         resp.Offchain,
+		"outcome":          outcome,
+		"resolved_on":      resolvedOn,
+	}), nil
+}
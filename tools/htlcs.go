@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ListHTLCsTool returns the MCP tool definition for listing the HTLCs in
+// flight across all channels.
+func (s *ChannelService) ListHTLCsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_htlcs",
+		Description: "List the HTLCs in flight on every open " +
+			"channel, soonest to expire first, with their " +
+			"direction, amount, payment hash, expiry height and " +
+			"the blocks and estimated time left until it. Use it " +
+			"to find stuck HTLCs, which force close their " +
+			"channel if they reach expiry unresolved",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"chan_id": map[string]any{
+					"type": "string",
+					"description": "Only list this " +
+						"channel's HTLCs, in decimal " +
+						"or as a short channel ID " +
+						"such as 800000x1234x0",
+					"pattern": "^([0-9]+|[0-9]+x[0-9]+x[0-9]+)$",
+				},
+				"direction": map[string]any{
+					"type": "string",
+					"description": "Only list HTLCs in " +
+						"this direction",
+					"enum": []string{
+						"incoming", "outgoing",
+					},
+				},
+				"expiring_within_blocks": map[string]any{
+					"type": "number",
+					"description": "Only list HTLCs " +
+						"that expire within this " +
+						"many blocks, including " +
+						"those already expired",
+					"minimum": 0,
+				},
+			},
+		},
+	}
+}
+
+// HandleListHTLCs handles the in-flight HTLC listing request.
+func (s *ChannelService) HandleListHTLCs(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	var chanID uint64
+	if value, _ := args["chan_id"].(string); value != "" {
+		id, err := parseChanID("chan_id", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		chanID = id
+	}
+	direction, _ := args["direction"].(string)
+	if direction != "" && direction != "incoming" &&
+		direction != "outgoing" {
+		return invalidArgumentError("direction must be incoming or " +
+			"outgoing"), nil
+	}
+	within, hasWithin := args["expiring_within_blocks"].(float64)
+	if hasWithin && (within < 0 || within > math.MaxUint32 ||
+		within != math.Trunc(within)) {
+		return invalidArgumentError("expiring_within_blocks must be " +
+			"a non-negative integer"), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	height := int64(info.BlockHeight)
+	var incoming, outgoing int
+	var incomingSat, outgoingSat int64
+	htlcs := make([]map[string]any, 0)
+	for _, ch := range list.Channels {
+		if chanID != 0 && ch.ChanId != chanID {
+			continue
+		}
+		for _, htlc := range ch.PendingHtlcs {
+			entry := htlcEntry(ch, htlc, height)
+			if direction != "" && entry["direction"] != direction {
+				continue
+			}
+			left := entry["blocks_until_expiry"].(int64)
+			if hasWithin && left > int64(within) {
+				continue
+			}
+			addContact(s.Contacts, entry, ch.RemotePubkey)
+			htlcs = append(htlcs, entry)
+
+			if htlc.Incoming {
+				incoming++
+				incomingSat += htlc.Amount
+			} else {
+				outgoing++
+				outgoingSat += htlc.Amount
+			}
+		}
+	}
+	sort.SliceStable(htlcs, func(i, j int) bool {
+		return htlcs[i]["expiration_height"].(uint32) <
+			htlcs[j]["expiration_height"].(uint32)
+	})
+
+	return jsonResult("lnc_list_htlcs", map[string]any{
+		"htlcs":               htlcs,
+		"count":               len(htlcs),
+		"incoming_count":      incoming,
+		"outgoing_count":      outgoing,
+		"incoming_amount_sat": incomingSat,
+		"outgoing_amount_sat": outgoingSat,
+		"block_height":        info.BlockHeight,
+	}), nil
+}
+
+// htlcEntry formats an HTLC in flight on a channel. An HTLC the node
+// forwarded names the channel at the other end of the forward.
+func htlcEntry(ch *lnrpc.Channel, htlc *lnrpc.HTLC,
+	height int64) map[string]any {
+	direction := "outgoing"
+	if htlc.Incoming {
+		direction = "incoming"
+	}
+	left := int64(htlc.ExpirationHeight) - height
+
+	entry := map[string]any{
+		"chan_id":             strconv.FormatUint(ch.ChanId, 10),
+		"short_channel_id":    formatShortChanID(ch.ChanId),
+		"channel_point":       ch.ChannelPoint,
+		"remote_pubkey":       ch.RemotePubkey,
+		"direction":           direction,
+		"amount_sat":          htlc.Amount,
+		"payment_hash":        hex.EncodeToString(htlc.HashLock),
+		"htlc_index":          htlc.HtlcIndex,
+		"locked_in":           htlc.LockedIn,
+		"expiration_height":   htlc.ExpirationHeight,
+		"blocks_until_expiry": left,
+		"expired":             left <= 0,
+	}
+	if left > 0 {
+		entry["estimated_hours_until_expiry"] = blockHours(
+			uint32(left))
+		entry["estimated_expiry_at"] = time.Now().Add(
+			time.Duration(left) * averageBlockInterval).UTC().
+			Format(time.RFC3339)
+	}
+	if htlc.ForwardingChannel != 0 {
+		entry["forwarding_chan_id"] = strconv.FormatUint(
+			htlc.ForwardingChannel, 10)
+		entry["forwarding_htlc_index"] = htlc.ForwardingHtlcIndex
+	}
+
+	return entry
+}
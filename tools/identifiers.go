@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// parsePubkey decodes a hex-encoded compressed public key argument. Keys of
+// the right length that are not on the curve are rejected here, so a
+// mistyped key is reported as such instead of as a node or routing error.
+func parsePubkey(name, value string) ([]byte, error) {
+	pubkey, err := hex.DecodeString(value)
+	if err != nil || len(pubkey) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%s must be a 66-character hex "+
+			"public key", name)
+	}
+	if _, err := btcec.ParsePubKey(pubkey); err != nil {
+		return nil, fmt.Errorf("%s is not a valid public key", name)
+	}
+
+	return pubkey, nil
+}
+
+// parseHash decodes a hex-encoded 32-byte hash argument, such as a payment
+// hash.
+func parseHash(name, value string) ([]byte, error) {
+	hash, err := hex.DecodeString(value)
+	if err != nil || len(hash) != chainhash.HashSize {
+		return nil, fmt.Errorf("%s must be a 64-character hex string",
+			name)
+	}
+
+	return hash, nil
+}
+
+// parseChannelPoint splits a txid:output_index channel point argument,
+// returning the txid in lnd's lower-case display form.
+func parseChannelPoint(name, value string) (string, uint32, error) {
+	txid, index, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("%s must be formatted as "+
+			"txid:output_index", name)
+	}
+
+	hash, err := chainhash.NewHashFromStr(txid)
+	if err != nil || len(txid) != 2*chainhash.HashSize {
+		return "", 0, fmt.Errorf("%s has an invalid txid", name)
+	}
+	outputIndex, err := strconv.ParseUint(index, 10, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s has an invalid output index", name)
+	}
+
+	return hash.String(), uint32(outputIndex), nil
+}
+
+// defaultPeerPort is the Lightning peer port assumed when an address has
+// none.
+const defaultPeerPort = "9735"
+
+// parsePeerAddress splits a pubkey@host[:port] peer address argument,
+// returning the public key and the host with its port, 9735 if none was
+// given. IPv6 hosts must be bracketed.
+func parsePeerAddress(name, value string) ([]byte, string, error) {
+	key, hostPort, ok := strings.Cut(value, "@")
+	if !ok {
+		return nil, "", fmt.Errorf("%s must be formatted as "+
+			"pubkey@host:port", name)
+	}
+
+	pubkey, err := parsePubkey(name, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host, port, err = net.SplitHostPort(hostPort + ":" +
+			defaultPeerPort)
+	}
+	if err != nil || host == "" ||
+		strings.ContainsAny(host, " \t\r\n@/[]") {
+		return nil, "", fmt.Errorf("%s has an invalid host", name)
+	}
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || portNumber == 0 {
+		return nil, "", fmt.Errorf("%s has an invalid port", name)
+	}
+
+	return pubkey, net.JoinHostPort(host, strconv.FormatUint(
+		portNumber, 10)), nil
+}
+
+// parseChanID decodes a channel ID argument, given either as lnd's decimal
+// form or as a block x transaction x output short channel ID.
+func parseChanID(name, value string) (uint64, error) {
+	if chanID, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return chanID, nil
+	}
+
+	parts := strings.Split(value, "x")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%s must be a decimal channel ID or "+
+			"a short channel ID such as 800000x1234x0", name)
+	}
+	block, errBlock := strconv.ParseUint(parts[0], 10, 24)
+	tx, errTx := strconv.ParseUint(parts[1], 10, 24)
+	output, errOutput := strconv.ParseUint(parts[2], 10, 16)
+	if errBlock != nil || errTx != nil || errOutput != nil {
+		return 0, fmt.Errorf("%s has an out of range short channel "+
+			"ID component", name)
+	}
+
+	return block<<40 | tx<<16 | output, nil
+}
+
+// formatShortChanID formats a channel ID as block x transaction x output,
+// the form parseChanID accepts besides the decimal one.
+func formatShortChanID(chanID uint64) string {
+	return fmt.Sprintf("%dx%dx%d", chanID>>40, chanID>>16&0xffffff,
+		chanID&0xffff)
+}
@@ -4,20 +4,27 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"time"
 
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/scrub"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // InvoiceService handles read-only Lightning invoice operations.
 type InvoiceService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// MemoScrub is how invoice memos and descriptions are scrubbed from
+	// results: scrub.ModeNone, scrub.ModeHash or scrub.ModeRedact.
+	MemoScrub string
 }
 
 // NewInvoiceService creates a new invoice service for read-only operations.
 func NewInvoiceService(client lnrpc.LightningClient) *InvoiceService {
 	return &InvoiceService{
-		LightningClient: client,
+		Clients: NewClientProvider(client),
 	}
 }
 
@@ -30,9 +37,11 @@ func (s *InvoiceService) DecodeInvoiceTool() mcp.Tool {
 			Type: "object",
 			Properties: map[string]any{
 				"invoice": map[string]any{
-					"type":        "string",
-					"description": "BOLT11 invoice string to decode",
-					"pattern":     "^ln[a-z0-9]+$",
+					"type": "string",
+					"description": "BOLT11 invoice string " +
+						"to decode, in either case and " +
+						"optionally prefixed with " +
+						"lightning:",
 				},
 			},
 			Required: []string{"invoice"},
@@ -43,28 +52,28 @@ func (s *InvoiceService) DecodeInvoiceTool() mcp.Tool {
 // HandleDecodeInvoice handles the decode invoice request.
 func (s *InvoiceService) HandleDecodeInvoice(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	invoice, ok := request.Params.Arguments["invoice"].(string)
 	if !ok {
-		return mcp.NewToolResultError("invoice is required"), nil
+		return invalidArgumentError("invoice is required"), nil
 	}
 
-	// Basic validation
-	if len(invoice) < 3 || invoice[:2] != "ln" {
-		return mcp.NewToolResultError("invalid BOLT11 invoice format"), nil
+	// Validate locally so garbage never reaches the node.
+	invoice = normalizeBolt11(invoice)
+	if _, _, err := decodeBolt11(invoice); err != nil {
+		return toolError(errors.ErrInvalidInvoice(err.Error())), nil
 	}
 
 	// Decode the invoice
-	decoded, err := s.LightningClient.DecodePayReq(ctx, &lnrpc.PayReqString{
+	decoded, err := client.DecodePayReq(ctx, &lnrpc.PayReqString{
 		PayReq: invoice,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf(
-			"Failed to decode invoice: %v", err)), nil
+		return rpcError(err, "failed to decode invoice"), nil
 	}
 
 	// Format route hints if present
@@ -91,35 +100,135 @@ func (s *InvoiceService) HandleDecodeInvoice(ctx context.Context,
 		features[fmt.Sprintf("%d", k)] = v.IsKnown
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"destination": "%s",
-		"payment_hash": "%s",
-		"amount_sats": %d,
-		"amount_msat": %d,
-		"timestamp": %d,
-		"expiry": %d,
-		"description": "%s",
-		"description_hash": "%s",
-		"fallback_address": "%s",
-		"cltv_expiry": %d,
-		"route_hints": %s,
-		"payment_addr": "%s",
-		"features": %s
-	}`,
-		decoded.Destination,
-		decoded.PaymentHash,
-		decoded.NumSatoshis,
-		decoded.NumMsat,
-		decoded.Timestamp,
-		decoded.Expiry,
-		decoded.Description,
-		decoded.DescriptionHash,
-		decoded.FallbackAddr,
-		decoded.CltvExpiry,
-		toJSONString(routeHints),
-		hex.EncodeToString(decoded.PaymentAddr),
-		toJSONString(features),
-	)), nil
+	return jsonResult("lnc_decode_invoice", map[string]any{
+		"destination":      decoded.Destination,
+		"payment_hash":     decoded.PaymentHash,
+		"amount_sats":      decoded.NumSatoshis,
+		"amount_msat":      decoded.NumMsat,
+		"timestamp":        decoded.Timestamp,
+		"expiry":           decoded.Expiry,
+		"description":      scrub.Text(s.MemoScrub, decoded.Description),
+		"description_hash": decoded.DescriptionHash,
+		"fallback_address": decoded.FallbackAddr,
+		"cltv_expiry":      decoded.CltvExpiry,
+		"route_hints":      routeHints,
+		"payment_addr":     hex.EncodeToString(decoded.PaymentAddr),
+		"features":         features,
+	}), nil
+}
+
+// DecodeInvoiceOfflineTool returns the MCP tool definition for decoding
+// invoices locally, without a node connection.
+func (s *InvoiceService) DecodeInvoiceOfflineTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_decode_invoice_offline",
+		Description: "Decode a BOLT11 Lightning invoice locally without " +
+			"a node connection",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"invoice": map[string]any{
+					"type":        "string",
+					"description": "BOLT11 invoice string to decode",
+				},
+			},
+			Required: []string{"invoice"},
+		},
+	}
+}
+
+// HandleDecodeInvoiceOffline handles the offline decode invoice request. It
+// never touches the Lightning client, so it works before lnc_connect.
+func (s *InvoiceService) HandleDecodeInvoiceOffline(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	invoice, ok := request.Params.Arguments["invoice"].(string)
+	if !ok || invoice == "" {
+		return invalidArgumentError("invoice is required"), nil
+	}
+
+	decoded, network, err := decodeBolt11(invoice)
+	if err != nil {
+		return toolError(errors.ErrInvalidInvoice(err.Error())), nil
+	}
+
+	var amountMsat int64
+	if decoded.MilliSat != nil {
+		amountMsat = int64(*decoded.MilliSat)
+	}
+
+	var description, descriptionHash, paymentHash, destination string
+	if decoded.Description != nil {
+		description = *decoded.Description
+	}
+	if decoded.DescriptionHash != nil {
+		descriptionHash = hex.EncodeToString(decoded.DescriptionHash[:])
+	}
+	if decoded.PaymentHash != nil {
+		paymentHash = hex.EncodeToString(decoded.PaymentHash[:])
+	}
+	if decoded.Destination != nil {
+		destination = hex.EncodeToString(
+			decoded.Destination.SerializeCompressed())
+	}
+
+	var fallbackAddr string
+	if decoded.FallbackAddr != nil {
+		fallbackAddr = decoded.FallbackAddr.String()
+	}
+
+	var paymentAddr string
+	decoded.PaymentAddr.WhenSome(func(addr [32]byte) {
+		paymentAddr = hex.EncodeToString(addr[:])
+	})
+
+	// Format route hints if present
+	routeHints := make([]map[string]any, len(decoded.RouteHints))
+	for i, hint := range decoded.RouteHints {
+		hops := make([]map[string]any, len(hint))
+		for j, hop := range hint {
+			hops[j] = map[string]any{
+				"node_id": hex.EncodeToString(
+					hop.NodeID.SerializeCompressed()),
+				"chan_id":    hop.ChannelID,
+				"fee_base":   hop.FeeBaseMSat,
+				"fee_prop":   hop.FeeProportionalMillionths,
+				"cltv_delta": hop.CLTVExpiryDelta,
+			}
+		}
+		routeHints[i] = map[string]any{
+			"hop_hints": hops,
+		}
+	}
+
+	// Format features if present
+	features := make(map[string]bool)
+	if decoded.Features != nil {
+		for bit := range decoded.Features.Features() {
+			features[fmt.Sprintf("%d", bit)] =
+				decoded.Features.IsKnown(bit)
+		}
+	}
+
+	expiresAt := decoded.Timestamp.Add(decoded.Expiry())
+
+	return jsonResult("lnc_decode_invoice_offline", map[string]any{
+		"network":          network.name,
+		"destination":      destination,
+		"payment_hash":     paymentHash,
+		"amount_sats":      amountMsat / 1000,
+		"amount_msat":      amountMsat,
+		"timestamp":        decoded.Timestamp.Unix(),
+		"expiry":           int64(decoded.Expiry().Seconds()),
+		"expires_at":       expiresAt.Unix(),
+		"expired":          time.Now().After(expiresAt),
+		"description":      scrub.Text(s.MemoScrub, description),
+		"description_hash": descriptionHash,
+		"fallback_address": fallbackAddr,
+		"cltv_expiry":      decoded.MinFinalCLTVExpiry(),
+		"route_hints":      routeHints,
+		"payment_addr":     paymentAddr,
+		"features":         features,
+	}), nil
 }
 
 // ListInvoicesTool returns the MCP tool definition for listing invoices.
@@ -157,9 +266,9 @@ func (s *InvoiceService) ListInvoicesTool() mcp.Tool {
 // HandleListInvoices handles the list invoices request.
 func (s *InvoiceService) HandleListInvoices(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	// Parse parameters
@@ -172,23 +281,25 @@ func (s *InvoiceService) HandleListInvoices(ctx context.Context,
 	reversed, _ := request.Params.Arguments["reversed"].(bool)
 
 	// List invoices
-	resp, err := s.LightningClient.ListInvoices(ctx, &lnrpc.ListInvoiceRequest{
+	resp, err := client.ListInvoices(ctx, &lnrpc.ListInvoiceRequest{
 		PendingOnly:    pendingOnly,
 		IndexOffset:    uint64(indexOffset),
 		NumMaxInvoices: uint64(numMaxInvoices),
 		Reversed:       reversed,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to list invoices: %v", err)), nil
+		return rpcError(err, "failed to list invoices"), nil
 	}
 
 	// Format invoice list
 	invoiceList := make([]map[string]any, len(resp.Invoices))
 	for i, invoice := range resp.Invoices {
+		memo := scrub.Text(s.MemoScrub, invoice.Memo)
+		paymentRequest := scrubPaymentRequest(s.MemoScrub,
+			invoice.PaymentRequest)
 		invoiceList[i] = map[string]any{
-			"memo":            invoice.Memo,
-			"payment_request": invoice.PaymentRequest,
+			"memo":            memo,
+			"payment_request": paymentRequest,
 			"r_hash":          hex.EncodeToString(invoice.RHash),
 			"value":           invoice.Value,
 			"value_msat":      invoice.ValueMsat,
@@ -208,13 +319,12 @@ func (s *InvoiceService) HandleListInvoices(ctx context.Context,
 		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"invoices": %s,
-		"first_index_offset": %d,
-		"last_index_offset": %d,
-		"total_invoices": %d
-	}`, toJSONString(invoiceList), resp.FirstIndexOffset,
-		resp.LastIndexOffset, len(invoiceList))), nil
+	return jsonResult("lnc_list_invoices", map[string]any{
+		"invoices":           invoiceList,
+		"first_index_offset": resp.FirstIndexOffset,
+		"last_index_offset":  resp.LastIndexOffset,
+		"total_invoices":     len(invoiceList),
+	}), nil
 }
 
 // LookupInvoiceTool returns the MCP tool definition for looking up a specific invoice.
@@ -239,71 +349,50 @@ func (s *InvoiceService) LookupInvoiceTool() mcp.Tool {
 // HandleLookupInvoice handles the lookup invoice request.
 func (s *InvoiceService) HandleLookupInvoice(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	paymentHash, ok := request.Params.Arguments["payment_hash"].(string)
 	if !ok {
-		return mcp.NewToolResultError("payment_hash is required"), nil
-	}
-
-	// Validate payment hash format
-	if len(paymentHash) != 64 {
-		return mcp.NewToolResultError(
-			"payment_hash must be a 64-character hex string"), nil
+		return invalidArgumentError("payment_hash is required"), nil
 	}
 
-	rhashBytes, err := hex.DecodeString(paymentHash)
+	rhashBytes, err := parseHash("payment_hash", paymentHash)
 	if err != nil {
-		return mcp.NewToolResultError("invalid payment_hash format"), nil
+		return invalidArgumentError(err.Error()), nil
 	}
 
 	// Lookup the invoice
-	invoice, err := s.LightningClient.LookupInvoice(ctx, &lnrpc.PaymentHash{
+	invoice, err := client.LookupInvoice(ctx, &lnrpc.PaymentHash{
 		RHash: rhashBytes,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf(
-			"Failed to lookup invoice: %v", err)), nil
+		return rpcLookupError(err, errors.ErrCodeInvoiceNotFound,
+			"failed to lookup invoice"), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"memo": "%s",
-		"payment_request": "%s",
-		"r_hash": "%s",
-		"value": %d,
-		"value_msat": %d,
-		"settled": %t,
-		"creation_date": %d,
-		"settle_date": %d,
-		"expiry": %d,
-		"cltv_expiry": %d,
-		"private": %t,
-		"add_index": %d,
-		"settle_index": %d,
-		"amt_paid_sat": %d,
-		"amt_paid_msat": %d,
-		"state": "%s",
-		"is_keysend": %t
-	}`,
-		invoice.Memo,
-		invoice.PaymentRequest,
-		hex.EncodeToString(invoice.RHash),
-		invoice.Value,
-		invoice.ValueMsat,
-		invoice.State == lnrpc.Invoice_SETTLED,
-		invoice.CreationDate,
-		invoice.SettleDate,
-		invoice.Expiry,
-		invoice.CltvExpiry,
-		invoice.Private,
-		invoice.AddIndex,
-		invoice.SettleIndex,
-		invoice.AmtPaidSat,
-		invoice.AmtPaidMsat,
-		invoice.State.String(),
-		invoice.IsKeysend,
-	)), nil
+	memo := scrub.Text(s.MemoScrub, invoice.Memo)
+	paymentRequest := scrubPaymentRequest(s.MemoScrub,
+		invoice.PaymentRequest)
+	return jsonResult("lnc_lookup_invoice", map[string]any{
+		"memo":            memo,
+		"payment_request": paymentRequest,
+		"r_hash":          hex.EncodeToString(invoice.RHash),
+		"value":           invoice.Value,
+		"value_msat":      invoice.ValueMsat,
+		"settled":         invoice.State == lnrpc.Invoice_SETTLED,
+		"creation_date":   invoice.CreationDate,
+		"settle_date":     invoice.SettleDate,
+		"expiry":          invoice.Expiry,
+		"cltv_expiry":     invoice.CltvExpiry,
+		"private":         invoice.Private,
+		"add_index":       invoice.AddIndex,
+		"settle_index":    invoice.SettleIndex,
+		"amt_paid_sat":    invoice.AmtPaidSat,
+		"amt_paid_msat":   invoice.AmtPaidMsat,
+		"state":           invoice.State.String(),
+		"is_keysend":      invoice.IsKeysend,
+	}), nil
 }
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxTxLabelLength is the longest label the wallet stores.
+const maxTxLabelLength = 500
+
+// LabelTransactionTool returns the MCP tool definition for labelling an
+// on-chain transaction.
+func (s *OnChainService) LabelTransactionTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_label_transaction",
+		Description: "Label a wallet transaction, as listed by " +
+			"lnc_get_transactions, so it can be recognised later. " +
+			"A transaction that already has a label, including the " +
+			"ones lnd gives channel opens, closes and sweeps, is " +
+			"only relabelled with overwrite",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"txid": map[string]any{
+					"type":        "string",
+					"description": "Transaction ID to label",
+					"pattern":     "^[0-9a-fA-F]{64}$",
+				},
+				"label": map[string]any{
+					"type":        "string",
+					"description": "Label to set",
+					"minLength":   1,
+					"maxLength":   maxTxLabelLength,
+				},
+				"overwrite": map[string]any{
+					"type": "boolean",
+					"description": "Replace an existing " +
+						"label",
+				},
+			},
+			Required: []string{"txid", "label"},
+		},
+	}
+}
+
+// HandleLabelTransaction handles the label transaction request. The
+// transaction is looked up in the wallet first, so an unknown transaction and
+// an existing label are reported before the wallet kit is called.
+func (s *OnChainService) HandleLabelTransaction(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	txid, _ := args["txid"].(string)
+	hash, err := chainhash.NewHashFromStr(txid)
+	if err != nil || len(txid) != 2*chainhash.HashSize {
+		return invalidArgumentError("txid must be 64 hex " +
+			"characters"), nil
+	}
+	label, _ := args["label"].(string)
+	label = strings.TrimSpace(label)
+	if label == "" || len(label) > maxTxLabelLength {
+		return invalidArgumentError(fmt.Sprintf("label must be 1 to "+
+			"%d characters", maxTxLabelLength)), nil
+	}
+	overwrite, _ := args["overwrite"].(bool)
+
+	// The wallet's transactions are listed from the genesis block to the
+	// mempool, as lnc_get_transactions lists them by default.
+	txs, err := client.GetTransactions(ctx, &lnrpc.GetTransactionsRequest{
+		EndHeight: -1,
+	})
+	if err != nil {
+		return rpcError(err, "failed to get transactions"), nil
+	}
+	var tx *lnrpc.Transaction
+	for _, candidate := range txs.Transactions {
+		if candidate.TxHash == hash.String() {
+			tx = candidate
+			break
+		}
+	}
+	if tx == nil {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"the wallet has no transaction with this txid").
+			WithDetails(map[string]any{"txid": hash.String()})), nil
+	}
+	if tx.Label != "" && !overwrite {
+		return toolError(errors.New(errors.ErrCodeInvalidArgument,
+			"the transaction is already labelled; set overwrite "+
+				"to replace the label").WithDetails(
+			txLabelFields(map[string]any{
+				"txid": hash.String(),
+			}, tx.Label))), nil
+	}
+
+	_, err = walletKit.LabelTransaction(ctx,
+		&walletrpc.LabelTransactionRequest{
+			Txid:      hash[:],
+			Label:     label,
+			Overwrite: overwrite,
+		})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to label transaction"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	result := map[string]any{
+		"txid":  hash.String(),
+		"label": label,
+	}
+	if tx.Label != "" {
+		result["previous_label"] = tx.Label
+		if labelType, _ := parseTxLabel(tx.Label); labelType != "" {
+			result["previous_label_type"] = labelType
+		}
+	}
+
+	return jsonResult("lnc_label_transaction", result), nil
+}
+
+// txLabelFields adds a transaction's label to fields, with its type and
+// channel when lnd generated it.
+func txLabelFields(fields map[string]any, label string) map[string]any {
+	fields["label"] = label
+	labelType, chanID := parseTxLabel(label)
+	if labelType != "" {
+		fields["label_type"] = labelType
+	}
+	if chanID != "" {
+		fields["label_chan_id"] = chanID
+	}
+	return fields
+}
+
+// parseTxLabel returns the type of a label lnd gave a transaction it
+// published, such as "openchannel" or "sweep", and the channel it concerns if
+// the label names one. Labels lnd generates read
+// "0:type[:shortchanid-id]"; "external" marks an API send without a label.
+// Labels set by users have no type.
+func parseTxLabel(label string) (string, string) {
+	if label == "external" {
+		return label, ""
+	}
+
+	parts := strings.Split(label, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "0" {
+		return "", ""
+	}
+	switch parts[1] {
+	case "openchannel", "closechannel", "justicetx", "sweep":
+	default:
+		return "", ""
+	}
+	if len(parts) == 2 {
+		return parts[1], ""
+	}
+
+	value, found := strings.CutPrefix(parts[2], "shortchanid-")
+	if !found {
+		return "", ""
+	}
+	if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+		return "", ""
+	}
+	return parts[1], value
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lndInternalLockID is the lock ID lnd leases outputs with itself, such as
+// the inputs of a PSBT it funds without a custom lock ID. It is the SHA256
+// hash of "lnd-internal-lock-id".
+var lndInternalLockID, _ = hex.DecodeString(
+	"ede19a92ed321a4705f8a1cccc1d4f6182545d4bb4fae08bd5937831b7e38f98")
+
+// leasesNote explains how leased outputs show up elsewhere.
+const leasesNote = "Leased outputs are left out of lnc_list_unspent and " +
+	"cannot be spent or used to fund channels until the lease expires " +
+	"or they are released with lnc_release_output. The wallet balance " +
+	"reports them as its locked balance"
+
+// ListLeasesTool returns the MCP tool definition for listing the wallet's
+// leased outputs.
+func (s *OnChainService) ListLeasesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_leases",
+		Description: "List the wallet outputs currently leased, or " +
+			"locked, for example as the inputs of a PSBT funded " +
+			"with lnc_fund_psbt, soonest expiry first, with each " +
+			"lease's ID, amount and expiry. Leased outputs are " +
+			"missing from lnc_list_unspent, which explains a " +
+			"wallet balance larger than the listed UTXOs",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleListLeases handles the list leases request.
+func (s *OnChainService) HandleListLeases(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	resp, err := walletKit.ListLeases(ctx, &walletrpc.ListLeasesRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to list leases"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	balance, err := client.WalletBalance(ctx,
+		&lnrpc.WalletBalanceRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get wallet balance"), nil
+	}
+
+	leases := append([]*walletrpc.UtxoLease(nil), resp.LockedUtxos...)
+	sort.SliceStable(leases, func(i, j int) bool {
+		return leases[i].Expiration < leases[j].Expiration
+	})
+
+	now := time.Now()
+	var total uint64
+	entries := make([]map[string]any, 0, len(leases))
+	for _, lease := range leases {
+		entries = append(entries, leaseEntry(lease, now))
+		total += lease.Value
+	}
+
+	return jsonResult("lnc_list_leases", map[string]any{
+		"leases":             entries,
+		"count":              len(entries),
+		"total_leased_sat":   total,
+		"locked_balance_sat": balance.LockedBalance,
+		"note":               leasesNote,
+	}), nil
+}
+
+// leaseEntry formats a leased output as of now.
+func leaseEntry(lease *walletrpc.UtxoLease, now time.Time) map[string]any {
+	expiresAt := time.Unix(int64(lease.Expiration), 0)
+	left := max(expiresAt.Sub(now), 0)
+
+	return map[string]any{
+		"outpoint":           formatOutPoint(lease.Outpoint),
+		"lock_id":            hex.EncodeToString(lease.Id),
+		"lnd_internal":       bytes.Equal(lease.Id, lndInternalLockID),
+		"amount_sat":         lease.Value,
+		"pk_script":          hex.EncodeToString(lease.PkScript),
+		"expires_at":         expiresAt.UTC().Format(time.RFC3339),
+		"expires_in_seconds": int64(left / time.Second),
+		"expired":            left == 0,
+	}
+}
@@ -0,0 +1,465 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// lspRequestTimeout bounds every HTTP round trip to an LSP.
+	lspRequestTimeout = 30 * time.Second
+
+	// lspMaxResponseBytes caps how much of an LSP response we read.
+	lspMaxResponseBytes = 1 << 20
+
+	// defaultChannelExpiryBlocks is roughly three months of blocks, which
+	// is the lease most LSPs offer by default.
+	defaultChannelExpiryBlocks = 12960
+
+	// defaultFundingConfirmsWithinBlocks is the funding confirmation
+	// target we ask the LSP to meet.
+	defaultFundingConfirmsWithinBlocks = 6
+)
+
+// LSPService integrates with Lightning Service Providers that expose the
+// LSPS1 channel request API over HTTP.
+type LSPService struct {
+	Clients    *ClientProvider
+	Endpoints  map[string]string
+	HTTPClient *http.Client
+}
+
+// NewLSPService creates a new LSP service for the configured endpoints.
+func NewLSPService(client lnrpc.LightningClient,
+	endpoints map[string]string) *LSPService {
+	return &LSPService{
+		Clients:    NewClientProvider(client),
+		Endpoints:  endpoints,
+		HTTPClient: &http.Client{Timeout: lspRequestTimeout},
+	}
+}
+
+// GetInfoTool returns the MCP tool definition for fetching LSP options.
+func (s *LSPService) GetInfoTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_lsp_get_info",
+		Description: "Fetch channel purchase options from a configured LSP " +
+			"and check whether the node has enough inbound capacity",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"lsp": map[string]any{
+					"type": "string",
+					"description": "Name of the configured LSP " +
+						"(optional when only one is configured)",
+				},
+				"required_inbound_sat": map[string]any{
+					"type": "number",
+					"description": "Inbound capacity the node needs; " +
+						"used to suggest a channel size",
+					"minimum": 0,
+				},
+			},
+		},
+	}
+}
+
+// HandleGetInfo handles the LSP get info request.
+func (s *LSPService) HandleGetInfo(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, baseURL, err := s.resolveLSP(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	info, err := s.call(ctx, http.MethodGet, baseURL,
+		"/api/v1/get_info", nil)
+	if err != nil {
+		return lspError(err, "failed to get LSP info from "+name), nil
+	}
+
+	result := map[string]any{
+		"lsp":     name,
+		"url":     baseURL,
+		"options": info["options"],
+	}
+
+	requiredInbound, _ := request.Params.Arguments["required_inbound_sat"].(float64)
+	if requiredInbound > 0 {
+		check, checkErr := s.inboundCheck(ctx, uint64(requiredInbound),
+			info)
+		if checkErr != nil {
+			return toolError(checkErr), nil
+		}
+		result["inbound_check"] = check
+	}
+
+	return jsonResult("lnc_lsp_get_info", result), nil
+}
+
+// CreateOrderTool returns the MCP tool definition for ordering inbound
+// liquidity from an LSP.
+func (s *LSPService) CreateOrderTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_lsp_create_order",
+		Description: "Order an inbound channel from a configured LSP. " +
+			"Returns the quote and the invoice that must be paid to " +
+			"complete the purchase",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"lsp": map[string]any{
+					"type": "string",
+					"description": "Name of the configured LSP " +
+						"(optional when only one is configured)",
+				},
+				"lsp_balance_sat": map[string]any{
+					"type":        "number",
+					"description": "Inbound liquidity to purchase in satoshis",
+					"minimum":     1,
+				},
+				"client_balance_sat": map[string]any{
+					"type":        "number",
+					"description": "Outbound balance pushed to our side (optional)",
+					"minimum":     0,
+				},
+				"channel_expiry_blocks": map[string]any{
+					"type":        "number",
+					"description": "How long the LSP keeps the channel open, in blocks",
+					"minimum":     1,
+				},
+				"zero_conf": map[string]any{
+					"type":        "boolean",
+					"description": "Request a zero-confirmation channel",
+				},
+				"announce_channel": map[string]any{
+					"type":        "boolean",
+					"description": "Announce the channel to the network",
+				},
+				"token": map[string]any{
+					"type":        "string",
+					"description": "Coupon or API token issued by the LSP (optional)",
+				},
+			},
+			Required: []string{"lsp_balance_sat"},
+		},
+	}
+}
+
+// HandleCreateOrder handles the LSP create order request.
+func (s *LSPService) HandleCreateOrder(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	name, baseURL, err := s.resolveLSP(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	lspBalance, ok := request.Params.Arguments["lsp_balance_sat"].(float64)
+	if !ok || lspBalance <= 0 {
+		return invalidArgumentError(
+			"lsp_balance_sat must be a positive number"), nil
+	}
+	clientBalance, _ := request.Params.Arguments["client_balance_sat"].(float64)
+	expiryBlocks, _ := request.Params.Arguments["channel_expiry_blocks"].(float64)
+	if expiryBlocks == 0 {
+		expiryBlocks = defaultChannelExpiryBlocks
+	}
+	zeroConf, _ := request.Params.Arguments["zero_conf"].(bool)
+	announce, _ := request.Params.Arguments["announce_channel"].(bool)
+	token, _ := request.Params.Arguments["token"].(string)
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+
+	requiredConfs := 1
+	if zeroConf {
+		requiredConfs = 0
+	}
+
+	// LSPS1 encodes satoshi amounts as strings to avoid precision loss.
+	order := map[string]any{
+		"public_key":                     info.IdentityPubkey,
+		"lsp_balance_sat":                strconv.FormatUint(uint64(lspBalance), 10),
+		"client_balance_sat":             strconv.FormatUint(uint64(clientBalance), 10),
+		"required_channel_confirmations": requiredConfs,
+		"funding_confirms_within_blocks": defaultFundingConfirmsWithinBlocks,
+		"channel_expiry_blocks":          uint32(expiryBlocks),
+		"announce_channel":               announce,
+	}
+	if token != "" {
+		order["token"] = token
+	}
+
+	resp, err := s.call(ctx, http.MethodPost, baseURL,
+		"/api/v1/create_order", order)
+	if err != nil {
+		return lspError(err, "failed to create order with "+name), nil
+	}
+
+	return jsonResult("lnc_lsp_create_order", map[string]any{
+		"lsp":   name,
+		"order": resp,
+	}), nil
+}
+
+// GetOrderTool returns the MCP tool definition for checking an LSP order.
+func (s *LSPService) GetOrderTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "lnc_lsp_get_order",
+		Description: "Check the payment and channel state of an LSP order",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"lsp": map[string]any{
+					"type": "string",
+					"description": "Name of the configured LSP " +
+						"(optional when only one is configured)",
+				},
+				"order_id": map[string]any{
+					"type":        "string",
+					"description": "Order ID returned by lnc_lsp_create_order",
+				},
+			},
+			Required: []string{"order_id"},
+		},
+	}
+}
+
+// HandleGetOrder handles the LSP get order request.
+func (s *LSPService) HandleGetOrder(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, baseURL, err := s.resolveLSP(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	orderID, ok := request.Params.Arguments["order_id"].(string)
+	if !ok || orderID == "" {
+		return invalidArgumentError("order_id is required"), nil
+	}
+
+	resp, err := s.call(ctx, http.MethodGet, baseURL,
+		"/api/v1/get_order?order_id="+url.QueryEscape(orderID), nil)
+	if err != nil {
+		return lspError(err, "failed to get order from "+name), nil
+	}
+
+	return jsonResult("lnc_lsp_get_order", map[string]any{
+		"lsp":   name,
+		"order": resp,
+	}), nil
+}
+
+// resolveLSP picks the LSP named in the arguments, falling back to the only
+// configured LSP when no name is given.
+func (s *LSPService) resolveLSP(args map[string]any) (string, string, error) {
+	if len(s.Endpoints) == 0 {
+		return "", "", fmt.Errorf("no LSPs configured; set " +
+			"LNC_LSP_ENDPOINTS to name=url pairs")
+	}
+
+	name, _ := args["lsp"].(string)
+	if name == "" {
+		if len(s.Endpoints) > 1 {
+			return "", "", fmt.Errorf("lsp is required when "+
+				"multiple LSPs are configured: %s",
+				strings.Join(s.lspNames(), ", "))
+		}
+		for only := range s.Endpoints {
+			name = only
+		}
+	}
+
+	baseURL, ok := s.Endpoints[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown LSP %q, configured: %s",
+			name, strings.Join(s.lspNames(), ", "))
+	}
+
+	return name, strings.TrimRight(baseURL, "/"), nil
+}
+
+// lspNames returns the configured LSP names in sorted order.
+func (s *LSPService) lspNames() []string {
+	names := make([]string, 0, len(s.Endpoints))
+	for name := range s.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inboundCheck compares the node's current inbound capacity against the
+// required amount and suggests a channel size within the LSP's limits.
+func (s *LSPService) inboundCheck(ctx context.Context, required uint64,
+	info map[string]any) (map[string]any, *errors.Error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return nil, errors.New(errors.ErrCodeNotConnected,
+			"required_inbound_sat needs a node connection. Use "+
+				"lnc_connect first")
+	}
+
+	balance, err := client.ChannelBalance(ctx,
+		&lnrpc.ChannelBalanceRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, classifyRPCError(err,
+			errors.ErrCodeNotFound), "failed to get channel balance")
+	}
+
+	inbound := safeAmount(balance.GetRemoteBalance()).sat
+	check := map[string]any{
+		"required_inbound_sat": required,
+		"current_inbound_sat":  inbound,
+		"sufficient":           inbound >= required,
+	}
+	if inbound >= required {
+		return check, nil
+	}
+
+	shortfall := required - inbound
+	suggested := shortfall
+	options, _ := info["options"].(map[string]any)
+	minBalance, ok := lspAmount(options, "min_initial_lsp_balance_sat")
+	if ok && suggested < minBalance {
+		suggested = minBalance
+	}
+	maxBalance, ok := lspAmount(options, "max_initial_lsp_balance_sat")
+	if ok && suggested > maxBalance {
+		suggested = maxBalance
+		check["exceeds_lsp_maximum"] = true
+	}
+
+	check["shortfall_sat"] = shortfall
+	check["suggested_lsp_balance_sat"] = suggested
+	return check, nil
+}
+
+// call performs a JSON request against an LSPS1 endpoint and decodes the
+// JSON object it returns.
+func (s *LSPService) call(ctx context.Context, method, baseURL, path string,
+	body any) (map[string]any, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, lspMaxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, &lspHTTPError{
+				StatusCode: resp.StatusCode,
+				Message:    strings.TrimSpace(string(data)),
+			}
+		}
+		return nil, fmt.Errorf("unexpected response (HTTP %d): %s",
+			resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := decoded["message"].(string)
+		return nil, &lspHTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    msg,
+		}
+	}
+
+	return decoded, nil
+}
+
+// lspHTTPError is returned when an LSP answers with an HTTP error status.
+type lspHTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *lspHTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// lspError classifies a failed LSP call. Transport failures are retryable
+// connection errors; HTTP errors are classified by status code.
+func lspError(err error, message string) *mcp.CallToolResult {
+	httpErr, ok := err.(*lspHTTPError)
+	if !ok {
+		return toolError(errors.Wrap(err, errors.ErrCodeConnectionFailed,
+			message))
+	}
+
+	code := errors.ErrCodeRPCFailed
+	switch {
+	case httpErr.StatusCode == http.StatusTooManyRequests:
+		code = errors.ErrCodeRateLimited
+	case httpErr.StatusCode == http.StatusNotFound:
+		code = errors.ErrCodeNotFound
+	case httpErr.StatusCode == http.StatusUnauthorized,
+		httpErr.StatusCode == http.StatusForbidden:
+		code = errors.ErrCodePermissionDenied
+	case httpErr.StatusCode < http.StatusInternalServerError:
+		code = errors.ErrCodeInvalidArgument
+	}
+
+	return toolError(errors.Wrap(err, code, message))
+}
+
+// lspAmount reads an LSPS1 satoshi amount, which may be encoded either as a
+// string or as a JSON number.
+func lspAmount(options map[string]any, key string) (uint64, bool) {
+	switch v := options[key].(type) {
+	case string:
+		amount, err := strconv.ParseUint(v, 10, 64)
+		return amount, err == nil
+	case float64:
+		return uint64(v), v >= 0
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,543 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/macaroons"
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/macaroon.v2"
+)
+
+const (
+	// maxMacaroonTimeout bounds the lifetime of a baked macaroon.
+	maxMacaroonTimeout = 365 * 24 * time.Hour
+
+	// maxRootKeyID is the largest root key ID a JSON number carries
+	// exactly.
+	maxRootKeyID = 1<<53 - 1
+)
+
+// Permission entities and actions lnd accepts when baking a macaroon. The
+// "uri" entity takes a full gRPC method as its action instead.
+var (
+	macaroonEntities = []string{
+		"onchain", "offchain", "address", "message", "peers", "info",
+		"invoices", "signer", "macaroon",
+		macaroons.PermissionEntityCustomURI,
+	}
+	macaroonActions = []string{"read", "write", "generate"}
+)
+
+// MacaroonService bakes, lists and revokes delegated macaroons, and lists
+// the permissions RPC methods require.
+type MacaroonService struct {
+	Clients *ClientProvider
+}
+
+// NewMacaroonService creates a new macaroon service.
+func NewMacaroonService(client lnrpc.LightningClient) *MacaroonService {
+	return &MacaroonService{
+		Clients: NewClientProvider(client),
+	}
+}
+
+// BakeMacaroonTool returns the MCP tool definition for baking a macaroon.
+func (s *MacaroonService) BakeMacaroonTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_bake_macaroon",
+		Description: "Bake a macaroon limited to the given permissions, " +
+			"for delegating scoped access to the node. Bake it " +
+			"under its own root key ID so it can be revoked with " +
+			"lnc_delete_macaroon_id, and with a timeout so it " +
+			"expires. The result is a credential: store it as a " +
+			"secret",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"permissions": map[string]any{
+					"type": "array",
+					"description": "Permissions as " +
+						"entity:action, e.g. " +
+						"info:read or offchain:write, " +
+						"or uri:/lnrpc.Lightning/" +
+						"GetInfo for a single method",
+					"items": map[string]any{
+						"type":    "string",
+						"pattern": "^[a-z]+:.+$",
+					},
+					"minItems": 1,
+				},
+				"root_key_id": map[string]any{
+					"type": "number",
+					"description": "Root key to bake under " +
+						"(default 0, the key of the " +
+						"node's own macaroons, which " +
+						"cannot be revoked)",
+					"minimum": 0,
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "Expire the macaroon " +
+						"after this many seconds",
+					"minimum": 1,
+					"maximum": maxMacaroonTimeout.Seconds(),
+				},
+				"ip_address": map[string]any{
+					"type": "string",
+					"description": "Only accept the " +
+						"macaroon from this IP address",
+				},
+				"allow_external_permissions": map[string]any{
+					"type": "boolean",
+					"description": "Pass permissions lnd " +
+						"does not know through " +
+						"unchecked, for services such " +
+						"as Lightning Terminal",
+				},
+			},
+			Required: []string{"permissions"},
+		},
+	}
+}
+
+// HandleBakeMacaroon handles the bake macaroon request. Permissions are
+// checked against the entities and actions lnd knows before the node is
+// called, and the timeout and IP caveats are added here, as lncli does.
+func (s *MacaroonService) HandleBakeMacaroon(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	external, _ := args["allow_external_permissions"].(bool)
+
+	permissions, err := parseMacaroonPermissions(args["permissions"],
+		external)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	rootKeyID, err := macaroonRootKeyID(args, false)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	timeout, hasTimeout := args["timeout_seconds"].(float64)
+	if hasTimeout && (timeout < 1 ||
+		timeout > maxMacaroonTimeout.Seconds()) {
+		return invalidArgumentError(fmt.Sprintf("timeout_seconds "+
+			"must be between 1 and %.0f",
+			maxMacaroonTimeout.Seconds())), nil
+	}
+	ipAddress, _ := args["ip_address"].(string)
+	if ipAddress != "" && net.ParseIP(ipAddress) == nil {
+		return invalidArgumentError("ip_address must be an IPv4 " +
+			"or IPv6 address"), nil
+	}
+
+	resp, err := client.BakeMacaroon(ctx, &lnrpc.BakeMacaroonRequest{
+		Permissions:              permissions,
+		RootKeyId:                rootKeyID,
+		AllowExternalPermissions: external,
+	})
+	if err != nil {
+		return rpcError(err, "failed to bake macaroon"), nil
+	}
+
+	result := map[string]any{
+		"root_key_id": rootKeyID,
+	}
+	var constraints []macaroons.Constraint
+	if hasTimeout {
+		constraints = append(constraints,
+			macaroons.TimeoutConstraint(int64(timeout)))
+		result["expires_at"] = time.Now().Add(time.Duration(timeout) *
+			time.Second).UTC().Format(time.RFC3339)
+	}
+	if ipAddress != "" {
+		constraints = append(constraints,
+			macaroons.IPLockConstraint(ipAddress))
+		result["ip_address"] = ipAddress
+	}
+
+	encoded := resp.Macaroon
+	if len(constraints) > 0 {
+		encoded, err = constrainMacaroon(resp.Macaroon, constraints)
+		if err != nil {
+			return toolError(errors.Wrap(err, errors.ErrCodeRPCFailed,
+				"failed to add caveats to the baked "+
+					"macaroon")), nil
+		}
+	}
+	result["macaroon"] = encoded
+
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.Entity + ":" + permission.Action
+	}
+	result["permissions"] = names
+	result["warnings"] = macaroonWarnings(permissions, rootKeyID,
+		hasTimeout)
+	result["note"] = "The macaroon grants the listed access to anyone " +
+		"who holds it; store it as a secret"
+
+	return jsonResult("lnc_bake_macaroon", result), nil
+}
+
+// ListMacaroonIDsTool returns the MCP tool definition for listing macaroon
+// root key IDs.
+func (s *MacaroonService) ListMacaroonIDsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_macaroon_ids",
+		Description: "List the root key IDs macaroons have been baked " +
+			"under. Deleting an ID with lnc_delete_macaroon_id " +
+			"revokes every macaroon baked under it",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleListMacaroonIDs handles the list macaroon IDs request.
+func (s *MacaroonService) HandleListMacaroonIDs(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	resp, err := client.ListMacaroonIDs(ctx,
+		&lnrpc.ListMacaroonIDsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list macaroon IDs"), nil
+	}
+
+	ids := append([]uint64{}, resp.RootKeyIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return jsonResult("lnc_list_macaroon_ids", map[string]any{
+		"root_key_ids": ids,
+		"count":        len(ids),
+		"note": "Root key ID 0 signs the node's own macaroons and " +
+			"cannot be deleted",
+	}), nil
+}
+
+// ListPermissionsTool returns the MCP tool definition for listing the
+// permissions each RPC method requires.
+func (s *MacaroonService) ListPermissionsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_permissions",
+		Description: "List the macaroon permissions each of the " +
+			"node's RPC methods requires, to explain what a " +
+			"session or a baked macaroon can do. Filter by " +
+			"method name or by a permission such as " +
+			"offchain:write",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"method": map[string]any{
+					"type": "string",
+					"description": "Only methods whose URI " +
+						"contains this text, e.g. " +
+						"SendPayment or routerrpc",
+				},
+				"permission": map[string]any{
+					"type": "string",
+					"description": "Only methods requiring " +
+						"this entity, e.g. offchain, " +
+						"or entity:action, e.g. " +
+						"offchain:write",
+				},
+			},
+		},
+	}
+}
+
+// HandleListPermissions handles the list permissions request.
+func (s *MacaroonService) HandleListPermissions(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	methodFilter, _ := args["method"].(string)
+	methodFilter = strings.ToLower(strings.TrimSpace(methodFilter))
+	permissionFilter, _ := args["permission"].(string)
+	permissionFilter = strings.TrimSpace(permissionFilter)
+
+	resp, err := client.ListPermissions(ctx,
+		&lnrpc.ListPermissionsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list permissions"), nil
+	}
+
+	uris := make([]string, 0, len(resp.MethodPermissions))
+	for uri := range resp.MethodPermissions {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	methods := make([]map[string]any, 0)
+	required := make(map[string]int)
+	for _, uri := range uris {
+		if methodFilter != "" &&
+			!strings.Contains(strings.ToLower(uri), methodFilter) {
+			continue
+		}
+
+		var names []string
+		matched := permissionFilter == ""
+		for _, permission := range resp.MethodPermissions[uri].
+			GetPermissions() {
+			name := permission.Entity + ":" + permission.Action
+			names = append(names, name)
+			if permissionFilter == permission.Entity ||
+				permissionFilter == name {
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			required[name]++
+		}
+		methods = append(methods, map[string]any{
+			"method":      uri,
+			"permissions": append([]string{}, names...),
+		})
+	}
+
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	summary := make([]map[string]any, len(names))
+	for i, name := range names {
+		summary[i] = map[string]any{
+			"permission":   name,
+			"method_count": required[name],
+		}
+	}
+
+	return jsonResult("lnc_list_permissions", map[string]any{
+		"methods":       methods,
+		"count":         len(methods),
+		"total_methods": len(uris),
+		"permissions":   summary,
+		"note": "A session can call a method only if its macaroon " +
+			"holds every permission listed for it. lnd does not " +
+			"report which permissions the current session holds; " +
+			"a call it is not allowed to make fails with a " +
+			"permission error",
+	}), nil
+}
+
+// DeleteMacaroonIDTool returns the MCP tool definition for deleting a
+// macaroon root key ID.
+func (s *MacaroonService) DeleteMacaroonIDTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_delete_macaroon_id",
+		Description: "Delete a macaroon root key ID, revoking every " +
+			"macaroon baked under it. This cannot be undone. If " +
+			"this server's own connection uses a macaroon baked " +
+			"under the ID, the connection stops working",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"root_key_id": map[string]any{
+					"type":        "number",
+					"description": "Root key ID to delete",
+					"minimum":     1,
+				},
+			},
+			Required: []string{"root_key_id"},
+		},
+	}
+}
+
+// HandleDeleteMacaroonID handles the delete macaroon ID request. Deleting an
+// ID that does not exist succeeds with deleted set to false.
+func (s *MacaroonService) HandleDeleteMacaroonID(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	rootKeyID, err := macaroonRootKeyID(request.Params.Arguments, true)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	resp, err := client.DeleteMacaroonID(ctx,
+		&lnrpc.DeleteMacaroonIDRequest{RootKeyId: rootKeyID})
+	if err != nil {
+		return rpcError(err, "failed to delete macaroon ID"), nil
+	}
+
+	result := map[string]any{
+		"root_key_id": rootKeyID,
+		"deleted":     resp.Deleted,
+	}
+	if !resp.Deleted {
+		result["message"] = "No macaroons were baked under this " +
+			"root key ID"
+	}
+
+	return jsonResult("lnc_delete_macaroon_id", result), nil
+}
+
+// parseMacaroonPermissions parses entity:action permissions, dropping
+// duplicates. Unless external permissions are allowed, entities and actions
+// must be ones lnd knows.
+func parseMacaroonPermissions(value any,
+	external bool) ([]*lnrpc.MacaroonPermission, error) {
+	list, ok := value.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("permissions must list at least one " +
+			"entity:action pair")
+	}
+
+	seen := make(map[string]bool, len(list))
+	permissions := make([]*lnrpc.MacaroonPermission, 0, len(list))
+	for _, item := range list {
+		text, _ := item.(string)
+		entity, action, found := strings.Cut(strings.TrimSpace(text),
+			":")
+		if !found || entity == "" || action == "" {
+			return nil, fmt.Errorf("permission %q must be "+
+				"entity:action", text)
+		}
+
+		if !external {
+			switch {
+			case !stringIn(entity, macaroonEntities):
+				return nil, fmt.Errorf("unknown permission "+
+					"entity %q; entities are %s", entity,
+					strings.Join(macaroonEntities, ", "))
+
+			case entity == macaroons.PermissionEntityCustomURI:
+				if !strings.HasPrefix(action, "/") ||
+					!strings.Contains(action[1:], "/") {
+					return nil, fmt.Errorf("uri permission "+
+						"%q must name a method as "+
+						"/package.Service/Method",
+						action)
+				}
+
+			case !stringIn(action, macaroonActions):
+				return nil, fmt.Errorf("unknown permission "+
+					"action %q; actions are %s", action,
+					strings.Join(macaroonActions, ", "))
+			}
+		}
+
+		if seen[entity+":"+action] {
+			continue
+		}
+		seen[entity+":"+action] = true
+		permissions = append(permissions, &lnrpc.MacaroonPermission{
+			Entity: entity,
+			Action: action,
+		})
+	}
+
+	return permissions, nil
+}
+
+// macaroonRootKeyID returns the root_key_id argument. When required, it
+// must be given and must not be the default ID 0.
+func macaroonRootKeyID(args map[string]any, required bool) (uint64, error) {
+	value, ok := args["root_key_id"].(float64)
+	switch {
+	case !ok && required:
+		return 0, fmt.Errorf("root_key_id is required")
+	case !ok:
+		return 0, nil
+	case value < 0 || value > maxRootKeyID || value != math.Trunc(value):
+		return 0, fmt.Errorf("root_key_id must be a non-negative " +
+			"integer")
+	case required && value == 0:
+		return 0, fmt.Errorf("root key ID 0 signs the node's own " +
+			"macaroons and cannot be deleted")
+	}
+
+	return uint64(value), nil
+}
+
+// macaroonWarnings flags what makes a delegated macaroon riskier than it
+// needs to be.
+func macaroonWarnings(permissions []*lnrpc.MacaroonPermission,
+	rootKeyID uint64, hasTimeout bool) []string {
+	warnings := make([]string, 0)
+	for _, permission := range permissions {
+		if permission.Entity == "macaroon" &&
+			permission.Action != "read" {
+			warnings = append(warnings, "the macaroon can bake "+
+				"or revoke other macaroons, so its holder "+
+				"can grant itself any permission")
+			break
+		}
+	}
+	if rootKeyID == 0 {
+		warnings = append(warnings, "baked under the default root "+
+			"key, so it can only be revoked by rotating every "+
+			"macaroon of the node")
+	}
+	if !hasTimeout {
+		warnings = append(warnings, "the macaroon never expires")
+	}
+	return warnings
+}
+
+// constrainMacaroon adds first-party caveats to a hex encoded macaroon.
+func constrainMacaroon(encoded string,
+	constraints []macaroons.Constraint) (string, error) {
+	data, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(data); err != nil {
+		return "", err
+	}
+
+	constrained, err := macaroons.AddConstraints(mac, constraints...)
+	if err != nil {
+		return "", err
+	}
+	data, err = constrained.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// stringIn reports whether value is one of values.
+func stringIn(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// mempoolTimeout bounds each request to the mempool API, which only
+	// enriches a result and must not hold it up for long.
+	mempoolTimeout = 5 * time.Second
+
+	// projectedBlockVsize is the virtual size of a full block, used to
+	// count how many blocks the mempool would fill.
+	projectedBlockVsize = 1_000_000
+
+	// maxFeeBands is how many projected blocks are described.
+	maxFeeBands = 3
+)
+
+// MempoolSource fetches current mempool conditions from an external API
+// with mempool.space's endpoints, so fee-related results can show how a
+// fee rate compares with what the network is paying. Its data does not
+// come from the node and is labeled as such.
+type MempoolSource struct {
+	// URL is the API base, for example https://mempool.space/api.
+	URL string
+
+	HTTPClient *http.Client
+}
+
+// NewMempoolSource creates a source for the API at url.
+func NewMempoolSource(url string) *MempoolSource {
+	return &MempoolSource{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: &http.Client{Timeout: mempoolTimeout},
+	}
+}
+
+// recommendedFees is the /v1/fees/recommended response, in sat/vbyte.
+type recommendedFees struct {
+	FastestFee  float64 `json:"fastestFee"`
+	HalfHourFee float64 `json:"halfHourFee"`
+	HourFee     float64 `json:"hourFee"`
+	EconomyFee  float64 `json:"economyFee"`
+	MinimumFee  float64 `json:"minimumFee"`
+}
+
+// projectedBlock is one entry of the /v1/fees/mempool-blocks response: the
+// transactions the next block, or for the last entry all remaining blocks,
+// would include.
+type projectedBlock struct {
+	BlockVSize float64   `json:"blockVSize"`
+	NTx        int64     `json:"nTx"`
+	MedianFee  float64   `json:"medianFee"`
+	FeeRange   []float64 `json:"feeRange"`
+}
+
+// mempoolSnapshot is the mempool state at one moment.
+type mempoolSnapshot struct {
+	fetchedAt   time.Time
+	recommended recommendedFees
+	blocks      []projectedBlock
+}
+
+// Context describes current mempool conditions for a result. feeRates, in
+// sat/vbyte, are placed in the projected blocks, so a caller can see
+// whether a rate would confirm soon. Failures are reported in the context
+// rather than failing the tool, which works without the data.
+func (m *MempoolSource) Context(ctx context.Context,
+	feeRates map[string]float64) map[string]any {
+	details := map[string]any{
+		"source":   "external",
+		"provider": m.URL,
+		"note": "Mempool data comes from the external provider, " +
+			"not from the node",
+	}
+
+	snapshot, err := m.fetch(ctx)
+	if err != nil {
+		details["error"] = err.Error()
+		return details
+	}
+
+	var vsize float64
+	var txCount int64
+	for _, block := range snapshot.blocks {
+		vsize += block.BlockVSize
+		txCount += block.NTx
+	}
+
+	bands := make([]map[string]any, 0, maxFeeBands)
+	for i, block := range snapshot.blocks {
+		if i == maxFeeBands {
+			break
+		}
+		band := map[string]any{
+			"block":                i + 1,
+			"median_sat_per_vbyte": block.MedianFee,
+		}
+		if len(block.FeeRange) > 0 {
+			band["min_sat_per_vbyte"] = block.FeeRange[0]
+			band["max_sat_per_vbyte"] =
+				block.FeeRange[len(block.FeeRange)-1]
+		}
+		bands = append(bands, band)
+	}
+
+	details["fetched_at"] = snapshot.fetchedAt.UTC().Format(time.RFC3339)
+	details["mempool_vsize"] = int64(vsize)
+	details["mempool_tx_count"] = txCount
+	details["depth_blocks"] = int64((vsize + projectedBlockVsize - 1) /
+		projectedBlockVsize)
+	details["fee_bands"] = bands
+	details["recommended_sat_per_vbyte"] = map[string]any{
+		"fastest":   snapshot.recommended.FastestFee,
+		"half_hour": snapshot.recommended.HalfHourFee,
+		"hour":      snapshot.recommended.HourFee,
+		"economy":   snapshot.recommended.EconomyFee,
+		"minimum":   snapshot.recommended.MinimumFee,
+	}
+
+	if len(feeRates) > 0 {
+		positions := make(map[string]any, len(feeRates))
+		for name, rate := range feeRates {
+			positions[name] = feeRatePosition(rate, snapshot)
+		}
+		details["fee_rate_positions"] = positions
+	}
+
+	return details
+}
+
+// feeRatePosition places a fee rate in the projected blocks: the first
+// block whose cheapest transaction pays no more than the rate. A rate
+// below every block, or below the minimum relay fee, is not projected to
+// confirm until the mempool clears.
+func feeRatePosition(rate float64, snapshot *mempoolSnapshot) map[string]any {
+	position := map[string]any{
+		"sat_per_vbyte": rate,
+	}
+
+	if rate < snapshot.recommended.MinimumFee {
+		position["projected_block"] = nil
+		position["assessment"] = "below the minimum fee the " +
+			"mempool accepts; the transaction may not relay"
+		return position
+	}
+
+	for i, block := range snapshot.blocks {
+		if len(block.FeeRange) == 0 || rate < block.FeeRange[0] {
+			continue
+		}
+
+		position["projected_block"] = i + 1
+		switch {
+		case i == 0:
+			position["assessment"] = "high enough for the next block"
+		case i == len(snapshot.blocks)-1:
+			position["assessment"] = "only in the mempool's " +
+				"backlog; expect a long wait"
+		default:
+			position["assessment"] = fmt.Sprintf("likely to "+
+				"wait about %d blocks", i+1)
+		}
+		return position
+	}
+
+	position["projected_block"] = nil
+	position["assessment"] = "below every projected block; it " +
+		"confirms only once the mempool clears"
+	return position
+}
+
+// fetch reads the recommended fees and projected blocks.
+func (m *MempoolSource) fetch(ctx context.Context) (*mempoolSnapshot, error) {
+	snapshot := &mempoolSnapshot{fetchedAt: time.Now()}
+	if err := m.get(ctx, "/v1/fees/recommended",
+		&snapshot.recommended); err != nil {
+		return nil, err
+	}
+	if err := m.get(ctx, "/v1/fees/mempool-blocks",
+		&snapshot.blocks); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// get decodes the JSON response of an API path into out.
+func (m *MempoolSource) get(ctx context.Context, path string,
+	out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		m.URL+path, nil)
+	if err != nil {
+		return fmt.Errorf("invalid mempool API URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("mempool API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mempool API returned HTTP %d for %s",
+			resp.StatusCode, path)
+	}
+
+	// A projected block list is a few kilobytes.
+	body := io.LimitReader(resp.Body, 1<<20)
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("mempool API returned malformed %s: %w",
+			path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultMissionControlPairs and maxMissionControlPairs bound how
+	// many node pairs one call lists.
+	defaultMissionControlPairs = 100
+	maxMissionControlPairs     = 5_000
+)
+
+// missionControlNote explains how lnd uses the pair history.
+const missionControlNote = "lnd's pathfinding avoids a pair after a " +
+	"failure and trusts amounts up to a pair's last success, until the " +
+	"result fades with the mission control half-life, an hour by " +
+	"default. A pair failing recently at every amount blocks routes " +
+	"through it"
+
+// missionControlPair is a node pair's history with the time of its last
+// result, which pairs are sorted by.
+type missionControlPair struct {
+	entry map[string]any
+	last  int64
+}
+
+// QueryMissionControlTool returns the MCP tool definition for inspecting
+// lnd's mission control.
+func (s *PaymentService) QueryMissionControlTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_query_mission_control",
+		Description: "Inspect lnd's mission control, the memory " +
+			"of past payment attempts its pathfinding uses: for " +
+			"each pair of nodes a payment was routed between, " +
+			"when it last failed or succeeded and at what " +
+			"amount, most recent first. Use it to diagnose why " +
+			"payments keep failing or avoid a route",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"node": map[string]any{
+					"type": "string",
+					"description": "Only list pairs " +
+						"from or to this node " +
+						"(hex encoded public key)",
+					"pattern": "^[0-9a-fA-F]{66}$",
+				},
+				"failures_only": map[string]any{
+					"type": "boolean",
+					"description": "Only list pairs " +
+						"whose last result was a " +
+						"failure",
+				},
+				"max_pairs": map[string]any{
+					"type": "number",
+					"description": "Maximum number of " +
+						"pairs to list (default 100)",
+					"minimum": 1,
+					"maximum": maxMissionControlPairs,
+				},
+			},
+		},
+	}
+}
+
+// HandleQueryMissionControl handles the mission control query.
+func (s *PaymentService) HandleQueryMissionControl(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	var node string
+	if value, _ := args["node"].(string); value != "" {
+		pubkey, err := parsePubkey("node", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		node = hex.EncodeToString(pubkey)
+	}
+	failuresOnly, _ := args["failures_only"].(bool)
+	limit, _ := args["max_pairs"].(float64)
+	switch {
+	case limit < 0 || limit > maxMissionControlPairs ||
+		limit != math.Trunc(limit):
+		return invalidArgumentError(fmt.Sprintf("max_pairs must be "+
+			"a whole number between 1 and %d",
+			maxMissionControlPairs)), nil
+	case limit == 0:
+		limit = defaultMissionControlPairs
+	}
+
+	resp, err := router.QueryMissionControl(ctx,
+		&routerrpc.QueryMissionControlRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to query mission control"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	var failing, succeeding int
+	matched := make([]missionControlPair, 0, len(resp.Pairs))
+	for _, pair := range resp.Pairs {
+		from := hex.EncodeToString(pair.NodeFrom)
+		to := hex.EncodeToString(pair.NodeTo)
+		if node != "" && from != node && to != node {
+			continue
+		}
+		entry := pairHistory(pair.History)
+		entry.entry["from"] = from
+		entry.entry["to"] = to
+		failed := entry.entry["last_result"] == "failure"
+		if failed {
+			failing++
+		} else {
+			succeeding++
+		}
+		if failuresOnly && !failed {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].last > matched[j].last
+	})
+	shown := min(len(matched), int(limit))
+	pairs := make([]map[string]any, 0, shown)
+	for _, pair := range matched[:shown] {
+		pairs = append(pairs, pair.entry)
+	}
+
+	return jsonResult("lnc_query_mission_control", map[string]any{
+		"pairs":            pairs,
+		"count":            len(pairs),
+		"failing_pairs":    failing,
+		"succeeding_pairs": succeeding,
+		"truncated":        shown < len(matched),
+		"note":             missionControlNote,
+	}), nil
+}
+
+// pairHistory formats a node pair's payment history. Its last result is
+// whichever of its last failure and success is more recent.
+func pairHistory(history *routerrpc.PairData) missionControlPair {
+	entry := map[string]any{}
+	if history == nil {
+		history = &routerrpc.PairData{}
+	}
+	if history.FailTime > 0 {
+		entry["failed_at"] = time.Unix(history.FailTime, 0).UTC().
+			Format(time.RFC3339)
+		entry["fail_amount_msat"] = history.FailAmtMsat
+	}
+	if history.SuccessTime > 0 {
+		entry["succeeded_at"] = time.Unix(history.SuccessTime, 0).
+			UTC().Format(time.RFC3339)
+		entry["success_amount_msat"] = history.SuccessAmtMsat
+	}
+
+	if history.FailTime > history.SuccessTime {
+		entry["last_result"] = "failure"
+		return missionControlPair{entry: entry, last: history.FailTime}
+	}
+	entry["last_result"] = "success"
+
+	return missionControlPair{entry: entry, last: history.SuccessTime}
+}
+
+// QueryProbabilityTool returns the MCP tool definition for estimating the
+// chance a payment passes from one node to another.
+func (s *PaymentService) QueryProbabilityTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_query_payment_probability",
+		Description: "Estimate the probability that lnd's " +
+			"pathfinding gives a payment of amount_sat passing " +
+			"from one node to the next, from mission control's " +
+			"history of the pair, without sending anything. It " +
+			"covers one hop; a route's probability is the " +
+			"product of its hops'",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"from_node": map[string]any{
+					"type": "string",
+					"description": "Node the payment " +
+						"leaves, hex encoded " +
+						"(default this node)",
+					"pattern": "^[0-9a-fA-F]{66}$",
+				},
+				"to_node": map[string]any{
+					"type": "string",
+					"description": "Node the payment " +
+						"reaches, hex encoded",
+					"pattern": "^[0-9a-fA-F]{66}$",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to estimate " +
+						"for, in satoshis",
+					"minimum": 1,
+				},
+			},
+			Required: []string{"to_node", "amount_sat"},
+		},
+	}
+}
+
+// HandleQueryProbability handles the payment probability query.
+func (s *PaymentService) HandleQueryProbability(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	router, generation := s.Clients.Router()
+	if client == nil || router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	toArg, _ := args["to_node"].(string)
+	toNode, err := parsePubkey("to_node", toArg)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < 1 || amountSat != math.Trunc(amountSat) {
+		return invalidArgumentError("amount_sat must be a whole " +
+			"number of at least 1"), nil
+	}
+
+	var fromNode []byte
+	if value, _ := args["from_node"].(string); value != "" {
+		fromNode, err = parsePubkey("from_node", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+	} else {
+		info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get node info"), nil
+		}
+		fromNode, err = hex.DecodeString(info.IdentityPubkey)
+		if err != nil {
+			return toolError(errors.New(errors.ErrCodeUnknown,
+				"the node returned an invalid public key")), nil
+		}
+	}
+
+	resp, err := router.QueryProbability(ctx,
+		&routerrpc.QueryProbabilityRequest{
+			FromNode: fromNode,
+			ToNode:   toNode,
+			AmtMsat:  int64(amountSat) * 1000,
+		})
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to query probability"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	history := resp.History
+	hasHistory := history.GetFailTime() > 0 ||
+		history.GetSuccessTime() > 0
+	result := map[string]any{
+		"from_node":   hex.EncodeToString(fromNode),
+		"to_node":     hex.EncodeToString(toNode),
+		"amount_sat":  int64(amountSat),
+		"probability": resp.Probability,
+		"probability_percent": math.Round(
+			resp.Probability*1000) / 10,
+		"has_history": hasHistory,
+	}
+	// Without history, lnd falls back on its a priori probability.
+	if hasHistory {
+		result["history"] = pairHistory(history).entry
+	}
+
+	return jsonResult("lnc_query_payment_probability", result), nil
+}
@@ -0,0 +1,412 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Mission control defaults lnd starts a probability model with, used when
+// switching to a model the node has no parameters for.
+const (
+	defaultHalfLifeSeconds   = 3_600
+	defaultHopProbability    = 0.6
+	defaultAprioriWeight     = 0.5
+	defaultCapacityFraction  = 0.9999
+	defaultBimodalNodeWeight = 0.2
+	defaultBimodalScaleMsat  = 300_000_000
+	defaultBimodalDecayTime  = 7 * 24 * 3_600
+)
+
+// missionControlModelArgs lists the arguments of each probability model.
+var missionControlModelArgs = map[string][]string{
+	"apriori": {
+		"half_life_seconds", "hop_probability", "weight",
+		"capacity_fraction",
+	},
+	"bimodal": {"node_weight", "scale_msat", "decay_time_seconds"},
+}
+
+// GetMissionControlConfigTool returns the MCP tool definition for showing
+// lnd's mission control configuration.
+func (s *PaymentService) GetMissionControlConfigTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_mission_control_config",
+		Description: "Show how lnd's mission control shapes " +
+			"pathfinding: the probability model, apriori or " +
+			"bimodal, with its parameters, such as the penalty " +
+			"half-life after which a failed hop is trusted " +
+			"again, and how many payment results it keeps",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleGetMissionControlConfig handles the mission control config request.
+func (s *PaymentService) HandleGetMissionControlConfig(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	resp, err := router.GetMissionControlConfig(ctx,
+		&routerrpc.GetMissionControlConfigRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to get mission control config"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	return jsonResult("lnc_get_mission_control_config",
+		missionControlConfigMap(resp.Config)), nil
+}
+
+// SetMissionControlConfigTool returns the MCP tool definition for tuning
+// lnd's mission control.
+func (s *PaymentService) SetMissionControlConfigTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_set_mission_control_config",
+		Description: "Tune lnd's mission control. Fields left out " +
+			"keep their current values; switching model starts " +
+			"from lnd's defaults for parameters the node has " +
+			"none for. The change lasts until lnd restarts, " +
+			"which restores the configured values. Use dry_run " +
+			"to preview the resulting configuration",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"model": map[string]any{
+					"type": "string",
+					"description": "Probability model; " +
+						"bimodal is experimental",
+					"enum": []string{"apriori", "bimodal"},
+				},
+				"maximum_payment_results": map[string]any{
+					"type": "number",
+					"description": "Payment results " +
+						"mission control keeps",
+					"minimum": 0,
+				},
+				"min_failure_relax_seconds": map[string]any{
+					"type": "number",
+					"description": "Time after a failure " +
+						"before a larger failure " +
+						"amount is recorded for the " +
+						"pair",
+					"minimum": 0,
+				},
+				"half_life_seconds": map[string]any{
+					"type": "number",
+					"description": "Apriori: time for a " +
+						"failed hop to recover half " +
+						"its probability",
+					"minimum": 0,
+				},
+				"hop_probability": map[string]any{
+					"type": "number",
+					"description": "Apriori: probability " +
+						"of a hop without history",
+					"minimum": 0,
+					"maximum": 1,
+				},
+				"weight": map[string]any{
+					"type": "number",
+					"description": "Apriori: weight of " +
+						"hop_probability against " +
+						"history, 1 ignoring history",
+					"minimum": 0,
+					"maximum": 1,
+				},
+				"capacity_fraction": map[string]any{
+					"type": "number",
+					"description": "Apriori: share of a " +
+						"channel's capacity assumed " +
+						"usable",
+					"minimum": 0.75,
+					"maximum": 1,
+				},
+				"node_weight": map[string]any{
+					"type": "number",
+					"description": "Bimodal: weight of " +
+						"a node's other channels' " +
+						"results",
+					"minimum": 0,
+					"maximum": 1,
+				},
+				"scale_msat": map[string]any{
+					"type": "number",
+					"description": "Bimodal: liquidity " +
+						"scale of channels, in " +
+						"millisatoshis",
+					"minimum": 1,
+				},
+				"decay_time_seconds": map[string]any{
+					"type": "number",
+					"description": "Bimodal: time over " +
+						"which past results are " +
+						"forgotten",
+					"minimum": 1,
+				},
+				"dry_run": map[string]any{
+					"type": "boolean",
+					"description": "Only preview the " +
+						"resulting configuration",
+				},
+			},
+		},
+	}
+}
+
+// HandleSetMissionControlConfig handles the mission control tuning request.
+func (s *PaymentService) HandleSetMissionControlConfig(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	dryRun, _ := args["dry_run"].(bool)
+
+	current, err := router.GetMissionControlConfig(ctx,
+		&routerrpc.GetMissionControlConfigRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to get mission control config"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	updated, err := updatedMissionControlConfig(current.Config, args)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	result := map[string]any{
+		"dry_run":  dryRun,
+		"previous": missionControlConfigMap(current.Config),
+		"config":   missionControlConfigMap(updated),
+	}
+	if dryRun {
+		return jsonResult("lnc_set_mission_control_config", result), nil
+	}
+
+	_, err = router.SetMissionControlConfig(ctx,
+		&routerrpc.SetMissionControlConfigRequest{Config: updated})
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to set mission control config"), nil
+	}
+
+	return jsonResult("lnc_set_mission_control_config", result), nil
+}
+
+// missionControlConfigMap formats a mission control configuration, with
+// the parameters of its model.
+func missionControlConfigMap(
+	config *routerrpc.MissionControlConfig) map[string]any {
+	model := strings.ToLower(config.GetModel().String())
+	relax := config.GetMinimumFailureRelaxInterval()
+	entry := map[string]any{
+		"model":                     model,
+		"maximum_payment_results":   config.GetMaximumPaymentResults(),
+		"min_failure_relax_seconds": relax,
+	}
+	if apriori := config.GetApriori(); apriori != nil {
+		entry["apriori"] = map[string]any{
+			"half_life_seconds": apriori.HalfLifeSeconds,
+			"hop_probability":   apriori.HopProbability,
+			"weight":            apriori.Weight,
+			"capacity_fraction": apriori.CapacityFraction,
+		}
+	}
+	if bimodal := config.GetBimodal(); bimodal != nil {
+		entry["bimodal"] = map[string]any{
+			"node_weight":        bimodal.NodeWeight,
+			"scale_msat":         bimodal.ScaleMsat,
+			"decay_time_seconds": bimodal.DecayTime,
+		}
+	}
+
+	return entry
+}
+
+// updatedMissionControlConfig applies the arguments of a tuning request to
+// the current configuration. Only the parameters of the resulting model may
+// be given.
+func updatedMissionControlConfig(current *routerrpc.MissionControlConfig,
+	args map[string]any) (*routerrpc.MissionControlConfig, error) {
+	model := current.GetModel()
+	if value, _ := args["model"].(string); value != "" {
+		switch value {
+		case "apriori":
+			model = routerrpc.MissionControlConfig_APRIORI
+		case "bimodal":
+			model = routerrpc.MissionControlConfig_BIMODAL
+		default:
+			return nil, fmt.Errorf("model must be apriori or " +
+				"bimodal")
+		}
+	}
+	modelName := strings.ToLower(model.String())
+
+	given := 0
+	for name, params := range missionControlModelArgs {
+		for _, param := range params {
+			if _, ok := args[param]; !ok {
+				continue
+			}
+			if name != modelName {
+				return nil, fmt.Errorf("%s only applies to "+
+					"the %s model", param, name)
+			}
+			given++
+		}
+	}
+	for _, name := range []string{"model", "maximum_payment_results",
+		"min_failure_relax_seconds"} {
+		if _, ok := args[name]; ok {
+			given++
+		}
+	}
+	if given == 0 {
+		return nil, fmt.Errorf("give at least one setting to change")
+	}
+
+	updated := &routerrpc.MissionControlConfig{
+		Model:                 model,
+		MaximumPaymentResults: current.GetMaximumPaymentResults(),
+		MinimumFailureRelaxInterval: current.
+			GetMinimumFailureRelaxInterval(),
+	}
+	value, ok, err := missionControlArg(args, "maximum_payment_results",
+		0, true)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		updated.MaximumPaymentResults = uint32(value)
+	}
+	value, ok, err = missionControlArg(args,
+		"min_failure_relax_seconds", 0, true)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		updated.MinimumFailureRelaxInterval = uint64(value)
+	}
+
+	if model == routerrpc.MissionControlConfig_BIMODAL {
+		params := &routerrpc.BimodalParameters{
+			NodeWeight: defaultBimodalNodeWeight,
+			ScaleMsat:  defaultBimodalScaleMsat,
+			DecayTime:  defaultBimodalDecayTime,
+		}
+		if bimodal := current.GetBimodal(); bimodal != nil {
+			params.NodeWeight = bimodal.NodeWeight
+			params.ScaleMsat = bimodal.ScaleMsat
+			params.DecayTime = bimodal.DecayTime
+		}
+		value, ok, err := missionControlArg(args, "node_weight", 0,
+			false)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			params.NodeWeight = value
+		}
+		value, ok, err = missionControlArg(args, "scale_msat", 1, true)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			params.ScaleMsat = uint64(value)
+		}
+		value, ok, err = missionControlArg(args, "decay_time_seconds",
+			1, true)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			params.DecayTime = uint64(value)
+		}
+		updated.EstimatorConfig =
+			&routerrpc.MissionControlConfig_Bimodal{
+				Bimodal: params,
+			}
+
+		return updated, nil
+	}
+
+	params := &routerrpc.AprioriParameters{
+		HalfLifeSeconds:  defaultHalfLifeSeconds,
+		HopProbability:   defaultHopProbability,
+		Weight:           defaultAprioriWeight,
+		CapacityFraction: defaultCapacityFraction,
+	}
+	if apriori := current.GetApriori(); apriori != nil {
+		params.HalfLifeSeconds = apriori.HalfLifeSeconds
+		params.HopProbability = apriori.HopProbability
+		params.Weight = apriori.Weight
+		params.CapacityFraction = apriori.CapacityFraction
+	}
+	value, ok, err = missionControlArg(args, "half_life_seconds", 0, true)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		params.HalfLifeSeconds = uint64(value)
+	}
+	value, ok, err = missionControlArg(args, "hop_probability", 0, false)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		params.HopProbability = value
+	}
+	value, ok, err = missionControlArg(args, "weight", 0, false)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		params.Weight = value
+	}
+	value, ok, err = missionControlArg(args, "capacity_fraction", 0.75,
+		false)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		params.CapacityFraction = value
+	}
+	updated.EstimatorConfig = &routerrpc.MissionControlConfig_Apriori{
+		Apriori: params,
+	}
+
+	return updated, nil
+}
+
+// missionControlArg validates a numeric mission control argument: a whole
+// number of at least minimum, or a fraction between minimum and 1.
+func missionControlArg(args map[string]any, name string, minimum float64,
+	whole bool) (float64, bool, error) {
+	raw, ok := args[name]
+	if !ok {
+		return 0, false, nil
+	}
+	value, ok := raw.(float64)
+	switch {
+	case whole && (!ok || value < minimum ||
+		value > math.MaxUint32 || value != math.Trunc(value)):
+		return 0, false, fmt.Errorf("%s must be a whole number of "+
+			"at least %g", name, minimum)
+	case !whole && (!ok || value < minimum || value > 1):
+		return 0, false, fmt.Errorf("%s must be between %g and 1",
+			name, minimum)
+	}
+
+	return value, true, nil
+}
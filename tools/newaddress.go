@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultAddressType is the address type used when none is given.
+const defaultAddressType = "p2wkh"
+
+// addressTypes maps the address type argument to lnd's address types.
+var addressTypes = map[string]lnrpc.AddressType{
+	"p2wkh":  lnrpc.AddressType_WITNESS_PUBKEY_HASH,
+	"np2wkh": lnrpc.AddressType_NESTED_PUBKEY_HASH,
+	"p2tr":   lnrpc.AddressType_TAPROOT_PUBKEY,
+}
+
+// NewAddressTool returns the MCP tool definition for generating a receive
+// address.
+func (s *OnChainService) NewAddressTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_new_address",
+		Description: "Generate a new on-chain receive address and a " +
+			"BIP21 payment URI for it",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"type": map[string]any{
+					"type": "string",
+					"description": "Address type: p2wkh (native " +
+						"segwit, default), p2tr (taproot) or " +
+						"np2wkh (nested segwit)",
+					"enum": []string{"p2wkh", "p2tr", "np2wkh"},
+				},
+				"account": map[string]any{
+					"type": "string",
+					"description": "Wallet account to derive the " +
+						"address from (default account if omitted)",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to request in the " +
+						"BIP21 URI, in satoshis",
+					"minimum": 1,
+				},
+				"label": map[string]any{
+					"type":        "string",
+					"description": "Label to include in the BIP21 URI",
+				},
+			},
+		},
+	}
+}
+
+// HandleNewAddress handles the new address request.
+func (s *OnChainService) HandleNewAddress(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	addrType, _ := args["type"].(string)
+	addrType = strings.ToLower(strings.TrimSpace(addrType))
+	if addrType == "" {
+		addrType = defaultAddressType
+	}
+	lndType, ok := addressTypes[addrType]
+	if !ok {
+		return invalidArgumentError(fmt.Sprintf("type must be one of "+
+			"p2wkh, p2tr or np2wkh, not %q", addrType)), nil
+	}
+
+	account, _ := args["account"].(string)
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < 0 {
+		return invalidArgumentError("amount_sat must be positive"), nil
+	}
+	label, _ := args["label"].(string)
+
+	resp, err := client.NewAddress(ctx, &lnrpc.NewAddressRequest{
+		Type:    lndType,
+		Account: account,
+	})
+	if err != nil {
+		return rpcError(err, "failed to generate address"), nil
+	}
+
+	result := map[string]any{
+		"address":   resp.Address,
+		"type":      addrType,
+		"account":   account,
+		"bip21_uri": bip21URI(resp.Address, int64(amountSat), label),
+	}
+	if amountSat > 0 {
+		result["amount_sat"] = int64(amountSat)
+	}
+
+	return jsonResult("lnc_new_address", result), nil
+}
+
+// bip21URI builds a BIP21 payment URI. The amount is given in BTC as the
+// BIP requires and is omitted when zero, as is an empty label.
+func bip21URI(address string, amountSat int64, label string) string {
+	var params []string
+	if amountSat > 0 {
+		btc := btcutil.Amount(amountSat).ToBTC()
+		params = append(params, "amount="+
+			strconv.FormatFloat(btc, 'f', -1, 64))
+	}
+	if label != "" {
+		// BIP21 percent-encodes spaces rather than using '+'.
+		params = append(params, "label="+strings.ReplaceAll(
+			url.QueryEscape(label), "+", "%20"))
+	}
+
+	uri := "bitcoin:" + address
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
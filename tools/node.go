@@ -2,7 +2,7 @@ package tools
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -10,13 +10,26 @@ import (
 
 // NodeService handles Lightning node information operations.
 type NodeService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// TipSource is the URL of an external chain tip height, checked by
+	// lnc_get_sync_status when set.
+	TipSource  string
+	HTTPClient *http.Client
+
+	// DebugUnredacted makes lnc_get_debug_info return what it would
+	// otherwise redact. It is the operator's choice, not the caller's.
+	DebugUnredacted bool
+
+	// recovery holds the progress seen by lnc_get_recovery_info.
+	recovery recoverySnapshots
 }
 
 // NewNodeService creates a new node service.
 func NewNodeService(client lnrpc.LightningClient) *NodeService {
 	return &NodeService{
-		LightningClient: client,
+		Clients:    NewClientProvider(client),
+		HTTPClient: &http.Client{Timeout: tipSourceTimeout},
 	}
 }
 
@@ -36,15 +49,14 @@ func (s *NodeService) GetInfoTool() mcp.Tool {
 // HandleGetInfo handles the node info request.
 func (s *NodeService) HandleGetInfo(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
-	info, err := s.LightningClient.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to get node info: %v", err)), nil
+		return rpcError(err, "failed to get node info"), nil
 	}
 
 	chains := chainNetworks(info.Chains)
@@ -53,35 +65,21 @@ func (s *NodeService) HandleGetInfo(ctx context.Context,
 		primaryNetwork = chains[0]
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"node_id": "%s",
-		"alias": "%s",
-		"version": "%s",
-		"num_peers": %d,
-		"num_active_channels": %d,
-		"num_inactive_channels": %d,
-		"num_pending_channels": %d,
-		"synced_to_chain": %t,
-		"synced_to_graph": %t,
-		"block_height": %d,
-		"block_hash": "%s",
-		"primary_network": "%s",
-		"chains": %v
-	}`,
-		info.IdentityPubkey,
-		info.Alias,
-		info.Version,
-		info.NumPeers,
-		info.NumActiveChannels,
-		info.NumInactiveChannels,
-		info.NumPendingChannels,
-		info.SyncedToChain,
-		info.SyncedToGraph,
-		info.BlockHeight,
-		info.BlockHash,
-		primaryNetwork,
-		chains,
-	)), nil
+	return jsonResult("lnc_get_info", map[string]any{
+		"node_id":               info.IdentityPubkey,
+		"alias":                 info.Alias,
+		"version":               info.Version,
+		"num_peers":             info.NumPeers,
+		"num_active_channels":   info.NumActiveChannels,
+		"num_inactive_channels": info.NumInactiveChannels,
+		"num_pending_channels":  info.NumPendingChannels,
+		"synced_to_chain":       info.SyncedToChain,
+		"synced_to_graph":       info.SyncedToGraph,
+		"block_height":          info.BlockHeight,
+		"block_hash":            info.BlockHash,
+		"primary_network":       primaryNetwork,
+		"chains":                chains,
+	}), nil
 }
 
 // GetBalanceTool returns the MCP tool definition for getting wallet balance.
@@ -99,25 +97,23 @@ func (s *NodeService) GetBalanceTool() mcp.Tool {
 // HandleGetBalance handles the balance request.
 func (s *NodeService) HandleGetBalance(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	// Get on-chain balance
-	walletBalance, err := s.LightningClient.WalletBalance(ctx,
+	walletBalance, err := client.WalletBalance(ctx,
 		&lnrpc.WalletBalanceRequest{})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to get wallet balance: %v", err)), nil
+		return rpcError(err, "failed to get wallet balance"), nil
 	}
 
 	// Get channel balance
-	channelBalance, err := s.LightningClient.ChannelBalance(ctx,
+	channelBalance, err := client.ChannelBalance(ctx,
 		&lnrpc.ChannelBalanceRequest{})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to get channel balance: %v", err)), nil
+		return rpcError(err, "failed to get channel balance"), nil
 	}
 
 	localBalance := safeAmount(channelBalance.GetLocalBalance())
@@ -129,59 +125,23 @@ func (s *NodeService) HandleGetBalance(ctx context.Context,
 	totalChannelBalance := localBalance.sat + remoteBalance.sat
 	totalPendingBalance := pendingLocal.sat + pendingRemote.sat
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"wallet_balance": {
-			"total_balance": %d,
-			"confirmed_balance": %d,
-			"unconfirmed_balance": %d
+	return jsonResult("lnc_get_balance", map[string]any{
+		"wallet_balance": map[string]any{
+			"total_balance":       walletBalance.TotalBalance,
+			"confirmed_balance":   walletBalance.ConfirmedBalance,
+			"unconfirmed_balance": walletBalance.UnconfirmedBalance,
+		},
+		"channel_balance": map[string]any{
+			"total_balance":               totalChannelBalance,
+			"pending_open_balance":        totalPendingBalance,
+			"local_balance":               localBalance.toMap(),
+			"remote_balance":              remoteBalance.toMap(),
+			"unsettled_local_balance":     unsettledLocal.toMap(),
+			"unsettled_remote_balance":    unsettledRemote.toMap(),
+			"pending_open_local_balance":  pendingLocal.toMap(),
+			"pending_open_remote_balance": pendingRemote.toMap(),
 		},
-		"channel_balance": {
-			"total_balance": %d,
-			"pending_open_balance": %d,
-			"local_balance": {
-				"sat": %d,
-				"msat": %d
-			},
-			"remote_balance": {
-				"sat": %d,
-				"msat": %d
-			},
-			"unsettled_local_balance": {
-				"sat": %d,
-				"msat": %d
-			},
-			"unsettled_remote_balance": {
-				"sat": %d,
-				"msat": %d
-			},
-			"pending_open_local_balance": {
-				"sat": %d,
-				"msat": %d
-			},
-			"pending_open_remote_balance": {
-				"sat": %d,
-				"msat": %d
-			}
-		}
-	}`,
-		walletBalance.TotalBalance,
-		walletBalance.ConfirmedBalance,
-		walletBalance.UnconfirmedBalance,
-		totalChannelBalance,
-		totalPendingBalance,
-		localBalance.sat,
-		localBalance.msat,
-		remoteBalance.sat,
-		remoteBalance.msat,
-		unsettledLocal.sat,
-		unsettledLocal.msat,
-		unsettledRemote.sat,
-		unsettledRemote.msat,
-		pendingLocal.sat,
-		pendingLocal.msat,
-		pendingRemote.sat,
-		pendingRemote.msat,
-	)), nil
+	}), nil
 }
 
 type balanceBreakdown struct {
@@ -196,6 +156,13 @@ func safeAmount(amount *lnrpc.Amount) balanceBreakdown {
 	return balanceBreakdown{sat: amount.Sat, msat: amount.Msat}
 }
 
+func (b balanceBreakdown) toMap() map[string]any {
+	return map[string]any{
+		"sat":  b.sat,
+		"msat": b.msat,
+	}
+}
+
 // chainNetworks extracts chain networks from Chain slice.
 func chainNetworks(chains []*lnrpc.Chain) []string {
 	networks := make([]string, len(chains))
@@ -4,19 +4,34 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
-// OnChainService handles read-only on-chain wallet operations.
+// OnChainService handles on-chain wallet operations.
 type OnChainService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// Policy gates withdrawals. Without one, lnc_send_coins refuses to
+	// send.
+	Policy *policy.Engine
+
+	// Mempool adds external mempool conditions to fee estimates and send
+	// previews when set.
+	Mempool *MempoolSource
+
+	confirmations *confirmationStore
+	psbts         *psbtStore
 }
 
-// NewOnChainService creates a new on-chain service for read-only operations.
+// NewOnChainService creates a new on-chain service.
 func NewOnChainService(client lnrpc.LightningClient) *OnChainService {
 	return &OnChainService{
-		LightningClient: client,
+		Clients:       NewClientProvider(client),
+		confirmations: newConfirmationStore(defaultConfirmationTTL),
+		psbts:         newPsbtStore(),
 	}
 }
 
@@ -50,9 +65,9 @@ func (s *OnChainService) ListUnspentTool() mcp.Tool {
 // HandleListUnspent handles the list unspent request.
 func (s *OnChainService) HandleListUnspent(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	minConfs, _ := request.Params.Arguments["min_confs"].(float64)
@@ -62,14 +77,13 @@ func (s *OnChainService) HandleListUnspent(ctx context.Context,
 	}
 	account, _ := request.Params.Arguments["account"].(string)
 
-	resp, err := s.LightningClient.ListUnspent(ctx, &lnrpc.ListUnspentRequest{
+	resp, err := client.ListUnspent(ctx, &lnrpc.ListUnspentRequest{
 		MinConfs: int32(minConfs),
 		MaxConfs: int32(maxConfs),
 		Account:  account,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to list unspent: %v", err)), nil
+		return rpcError(err, "failed to list unspent"), nil
 	}
 
 	utxos := make([]map[string]any, len(resp.Utxos))
@@ -87,11 +101,11 @@ func (s *OnChainService) HandleListUnspent(ctx context.Context,
 		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"utxos": %s,
-		"total_utxos": %d,
-		"total_amount_sat": %d
-	}`, toJSONString(utxos), len(utxos), totalAmount)), nil
+	return jsonResult("lnc_list_unspent", map[string]any{
+		"utxos":            utxos,
+		"total_utxos":      len(utxos),
+		"total_amount_sat": totalAmount,
+	}), nil
 }
 
 // GetTransactionsTool returns the MCP tool definition for listing transactions.
@@ -124,9 +138,9 @@ func (s *OnChainService) GetTransactionsTool() mcp.Tool {
 // HandleGetTransactions handles the get transactions request.
 func (s *OnChainService) HandleGetTransactions(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	startHeight, _ := request.Params.Arguments["start_height"].(float64)
@@ -136,15 +150,14 @@ func (s *OnChainService) HandleGetTransactions(ctx context.Context,
 	}
 	account, _ := request.Params.Arguments["account"].(string)
 
-	resp, err := s.LightningClient.GetTransactions(ctx,
+	resp, err := client.GetTransactions(ctx,
 		&lnrpc.GetTransactionsRequest{
 			StartHeight: int32(startHeight),
 			EndHeight:   int32(endHeight),
 			Account:     account,
 		})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to get transactions: %v", err)), nil
+		return rpcError(err, "failed to get transactions"), nil
 	}
 
 	transactions := make([]map[string]any, len(resp.Transactions))
@@ -158,7 +171,7 @@ func (s *OnChainService) HandleGetTransactions(ctx context.Context,
 			}
 		}
 
-		transactions[i] = map[string]any{
+		transactions[i] = txLabelFields(map[string]any{
 			"tx_hash":            tx.TxHash,
 			"amount":             tx.Amount,
 			"num_confirmations":  tx.NumConfirmations,
@@ -167,15 +180,14 @@ func (s *OnChainService) HandleGetTransactions(ctx context.Context,
 			"time_stamp":         tx.TimeStamp,
 			"total_fees":         tx.TotalFees,
 			"raw_tx_hex":         tx.RawTxHex,
-			"label":              tx.Label,
 			"previous_outpoints": prevOuts,
-		}
+		}, tx.Label)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"transactions": %s,
-		"total_transactions": %d
-	}`, toJSONString(transactions), len(transactions))), nil
+	return jsonResult("lnc_get_transactions", map[string]any{
+		"transactions":       transactions,
+		"total_transactions": len(transactions),
+	}), nil
 }
 
 // EstimateFeesTool returns the MCP tool definition for estimating fees.
@@ -201,9 +213,9 @@ func (s *OnChainService) EstimateFeesTool() mcp.Tool {
 // HandleEstimateFee handles the estimate fee request.
 func (s *OnChainService) HandleEstimateFee(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	targetConf, _ := request.Params.Arguments["target_conf"].(float64)
@@ -213,6 +225,7 @@ func (s *OnChainService) HandleEstimateFee(ctx context.Context,
 
 	// Get estimates for multiple confirmation targets
 	estimates := make(map[string]any)
+	rates := make(map[string]float64)
 
 	targets := []int32{1, 3, 6, 10, 20, 50, 100}
 	for _, target := range targets {
@@ -220,17 +233,19 @@ func (s *OnChainService) HandleEstimateFee(ctx context.Context,
 			continue // Only get estimate for requested target if specified
 		}
 
-		resp, err := s.LightningClient.EstimateFee(ctx, &lnrpc.EstimateFeeRequest{
+		resp, err := client.EstimateFee(ctx, &lnrpc.EstimateFeeRequest{
 			TargetConf: target,
 		})
 		if err != nil {
 			continue // Skip failed estimates
 		}
 
-		estimates[fmt.Sprintf("target_%d_blocks", target)] = map[string]any{
+		name := fmt.Sprintf("target_%d_blocks", target)
+		estimates[name] = map[string]any{
 			"fee_sat":       resp.FeeSat,
 			"sat_per_vbyte": resp.SatPerVbyte,
 		}
+		rates[name] = float64(resp.SatPerVbyte)
 
 		if targetConf > 0 {
 			break // Only one estimate requested
@@ -238,10 +253,16 @@ func (s *OnChainService) HandleEstimateFee(ctx context.Context,
 	}
 
 	if len(estimates) == 0 {
-		return mcp.NewToolResultError("Failed to get fee estimates"), nil
+		return toolError(errors.New(errors.ErrCodeRPCFailed,
+			"failed to get fee estimates")), nil
+	}
+
+	result := map[string]any{
+		"fee_estimates": estimates,
+	}
+	if s.Mempool != nil {
+		result["mempool_context"] = s.Mempool.Context(ctx, rates)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"fee_estimates": %s
-	}`, toJSONString(estimates))), nil
+	return jsonResult("lnc_estimate_fee", result), nil
 }
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// minChannelSizeSat is lnd's default minimum channel size.
+	minChannelSizeSat = 20_000
+
+	// defaultOpenWaitTimeout bounds how long lnc_open_channel waits for
+	// the funding transaction to confirm when asked to.
+	defaultOpenWaitTimeout = 10 * time.Minute
+)
+
+// OpenChannelTool returns the MCP tool definition for opening a channel.
+func (s *ChannelService) OpenChannelTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_open_channel",
+		Description: "Open a channel to a connected peer. Returns once " +
+			"the funding transaction is broadcast, or once it " +
+			"confirms with wait_for_open; funding progress is " +
+			"reported as it happens",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"node_pubkey": map[string]any{
+					"type":        "string",
+					"description": "Public key of the peer (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{66}$",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Channel capacity funded " +
+						"from the on-chain wallet, in satoshis",
+					"minimum": minChannelSizeSat,
+				},
+				"push_sat": map[string]any{
+					"type": "number",
+					"description": "Amount given to the peer " +
+						"on open, in satoshis",
+					"minimum": 0,
+				},
+				"private": map[string]any{
+					"type":        "boolean",
+					"description": "Do not announce the channel",
+				},
+				"sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Funding fee rate; cannot be " +
+						"combined with target_conf",
+					"minimum": 1,
+				},
+				"target_conf": map[string]any{
+					"type": "number",
+					"description": "Confirmation target used to " +
+						"pick the funding fee rate",
+					"minimum": 1,
+					"maximum": 1008,
+				},
+				"min_confs": map[string]any{
+					"type": "number",
+					"description": "Confirmations required of " +
+						"the UTXOs that fund the channel; 0 " +
+						"spends unconfirmed outputs (default 1)",
+					"minimum": 0,
+				},
+				"wait_for_open": map[string]any{
+					"type": "boolean",
+					"description": "Wait for the funding " +
+						"transaction to confirm instead of " +
+						"returning once it is broadcast",
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "With wait_for_open, stop " +
+						"waiting after this many seconds " +
+						"(default 600); the channel still opens",
+					"minimum": 1,
+					"maximum": 86400,
+				},
+			},
+			Required: []string{"node_pubkey", "amount_sat"},
+		},
+	}
+}
+
+// HandleOpenChannel handles the open channel request. It follows lnd's
+// funding stream, reporting each update as progress, until the channel is
+// pending or, with wait_for_open, open.
+func (s *ChannelService) HandleOpenChannel(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, generation := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	nodePubkey, _ := args["node_pubkey"].(string)
+	pubkey, err := parsePubkey("node_pubkey", nodePubkey)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < minChannelSizeSat {
+		return invalidArgumentError(fmt.Sprintf("amount_sat must be "+
+			"at least %d", minChannelSizeSat)), nil
+	}
+	pushSat, _ := args["push_sat"].(float64)
+	if pushSat < 0 || pushSat >= amountSat {
+		return invalidArgumentError("push_sat must be at least 0 " +
+			"and less than amount_sat"), nil
+	}
+
+	satPerVbyte, _ := args["sat_per_vbyte"].(float64)
+	targetConf, _ := args["target_conf"].(float64)
+	if satPerVbyte > 0 && targetConf > 0 {
+		return invalidArgumentError("set either target_conf or " +
+			"sat_per_vbyte, not both"), nil
+	}
+	feeRate, failure := checkFeeRate(ctx, s.Clients, s.Policy,
+		uint64(satPerVbyte), int32(targetConf))
+	if failure != nil {
+		return failure, nil
+	}
+	if feeRate > 0 {
+		targetConf = 0
+	}
+
+	minConfs := float64(1)
+	if value, ok := args["min_confs"].(float64); ok {
+		minConfs = value
+	}
+	if minConfs < 0 {
+		return invalidArgumentError("min_confs must not be negative"), nil
+	}
+
+	private, _ := args["private"].(bool)
+	waitForOpen, _ := args["wait_for_open"].(bool)
+
+	// The stream is cancelled on return so lnd stops sending updates we
+	// no longer read.
+	var (
+		streamCtx context.Context
+		cancel    context.CancelFunc
+	)
+	if waitForOpen {
+		timeout := defaultOpenWaitTimeout
+		if seconds, ok := args["timeout_seconds"].(float64); ok &&
+			seconds > 0 {
+			timeout = time.Duration(seconds) * time.Second
+		}
+		streamCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		streamCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	// What is pushed to the peer is spent; the rest of the funding
+	// amount stays ours, as the channel's local balance.
+	spendID := channelOpenSpendID(pubkey)
+	if pushSat > 0 {
+		err := s.Policy.ReserveSpend(spendID, "lnc_open_channel",
+			int64(pushSat))
+		if err != nil {
+			return policyError(err), nil
+		}
+	}
+
+	stream, err := client.OpenChannel(streamCtx,
+		&lnrpc.OpenChannelRequest{
+			NodePubkey:         pubkey,
+			LocalFundingAmount: int64(amountSat),
+			PushSat:            int64(pushSat),
+			Private:            private,
+			SatPerVbyte:        feeRate,
+			TargetConf:         int32(targetConf),
+			MinConfs:           int32(minConfs),
+			SpendUnconfirmed:   minConfs == 0,
+		})
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return rpcError(err, "failed to open channel"), nil
+	}
+
+	result := map[string]any{
+		"node_pubkey": nodePubkey,
+		"amount_sat":  int64(amountSat),
+		"push_sat":    int64(pushSat),
+		"private":     private,
+	}
+	var updates []map[string]any
+
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Once the funding transaction is out, running out of
+			// time to wait is not a failure: the channel still
+			// opens.
+			if result["status"] == "pending" && streamCtx.Err() != nil {
+				result["message"] = "Stopped waiting before the " +
+					"funding transaction confirmed; follow " +
+					"it with lnc_pending_channels"
+				break
+			}
+
+			// The funding flow cannot be resumed on a new
+			// connection, so report what is known.
+			if s.Clients.Replaced(generation) {
+				if result["status"] == "pending" {
+					result["message"] = "The connection was " +
+						"replaced before the channel " +
+						"opened; follow it with " +
+						"lnc_pending_channels"
+					break
+				}
+				return connectionReplacedError("the connection "+
+					"was replaced before the funding "+
+					"transaction was published; check "+
+					"lnc_pending_channels before retrying",
+					map[string]any{
+						"node_pubkey": nodePubkey,
+					}), nil
+			}
+			if result["status"] == nil {
+				logSpendError(ctx,
+					s.Policy.ReleaseSpend(spendID))
+			}
+			return rpcError(err, "channel funding failed"), nil
+		}
+
+		var status string
+		switch u := update.Update.(type) {
+		case *lnrpc.OpenStatusUpdate_ChanPending:
+			status = "pending"
+			txid, err := chainhash.NewHash(u.ChanPending.Txid)
+			if err != nil {
+				return toolError(errors.Wrap(err,
+					errors.ErrCodeRPCFailed,
+					"invalid funding txid from node")), nil
+			}
+			result["funding_txid"] = txid.String()
+			result["output_index"] = u.ChanPending.OutputIndex
+			result["channel_point"] = fmt.Sprintf("%s:%d", txid,
+				u.ChanPending.OutputIndex)
+
+		case *lnrpc.OpenStatusUpdate_ChanOpen:
+			status = "open"
+			if point := channelPointString(
+				u.ChanOpen.ChannelPoint); point != "" {
+				result["channel_point"] = point
+			}
+
+		default:
+			continue
+		}
+
+		result["status"] = status
+		point, _ := result["channel_point"].(string)
+		if status == "pending" {
+			logJournalError(ctx, s.Journal.Begin(
+				journal.KindChannelOpen, point,
+				"lnc_open_channel", map[string]any{
+					"node_pubkey": nodePubkey,
+					"amount_sat":  int64(amountSat),
+				}))
+		} else {
+			logJournalError(ctx, s.Journal.Update(
+				journal.KindChannelOpen, point,
+				journal.StateSucceeded, nil))
+		}
+		updates = append(updates, map[string]any{
+			"status": status,
+			"at_ms":  time.Now().UnixMilli(),
+		})
+		notifyProgress(ctx, float64(len(updates)), fmt.Sprintf(
+			"channel %s (%v)", status, result["channel_point"]))
+
+		if status == "open" || !waitForOpen {
+			break
+		}
+	}
+
+	if result["status"] == nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return toolError(errors.New(errors.ErrCodeRPCFailed,
+			"funding stream ended without a status")), nil
+	}
+
+	result["status_updates"] = updates
+	return jsonResult("lnc_open_channel", result), nil
+}
+
+// channelPointString formats a channel point as txid:index, or returns ""
+// if it has no txid.
+func channelPointString(point *lnrpc.ChannelPoint) string {
+	if point == nil {
+		return ""
+	}
+
+	switch txid := point.FundingTxid.(type) {
+	case *lnrpc.ChannelPoint_FundingTxidStr:
+		return fmt.Sprintf("%s:%d", txid.FundingTxidStr,
+			point.OutputIndex)
+	case *lnrpc.ChannelPoint_FundingTxidBytes:
+		hash, err := chainhash.NewHash(txid.FundingTxidBytes)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%s:%d", hash, point.OutputIndex)
+	}
+	return ""
+}
@@ -0,0 +1,368 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OperationService reports the operations write tools started, as recorded
+// in the journal, and resolves those still in flight against the node.
+type OperationService struct {
+	Clients *ClientProvider
+	Journal *journal.Journal
+}
+
+// NewOperationService creates a new operation service.
+func NewOperationService(client lnrpc.LightningClient,
+	operations *journal.Journal) *OperationService {
+	return &OperationService{
+		Clients: NewClientProvider(client),
+		Journal: operations,
+	}
+}
+
+// ListOperationsTool returns the MCP tool definition for listing journaled
+// operations.
+func (s *OperationService) ListOperationsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_operations",
+		Description: "List the payments and channel opens started by " +
+			"write tools, including those started before the " +
+			"server last restarted. Operations still in flight " +
+			"are checked against the node first, so their state " +
+			"is current",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"state": map[string]any{
+					"type":        "string",
+					"description": "Only list operations in this state",
+					"enum": []string{
+						journal.StateInFlight,
+						journal.StateSucceeded,
+						journal.StateFailed,
+					},
+				},
+				"kind": map[string]any{
+					"type":        "string",
+					"description": "Only list operations of this kind",
+					"enum": []string{
+						journal.KindPayment,
+						journal.KindChannelOpen,
+					},
+				},
+			},
+		},
+	}
+}
+
+// HandleListOperations handles the list operations request. Without a
+// connection the journal is reported as it stands.
+func (s *OperationService) HandleListOperations(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	state, _ := args["state"].(string)
+	kind, _ := args["kind"].(string)
+
+	result := map[string]any{"reconciled": false}
+	if client, _ := s.Clients.Lightning(); client != nil {
+		result["reconciled"] = true
+		if err := s.reconcile(ctx, false); err != nil {
+			result["reconciled"] = false
+			result["reconcile_error"] = status.Convert(err).Message()
+		}
+	}
+
+	operations := make([]map[string]any, 0)
+	inFlight := 0
+	for _, op := range s.Journal.Operations() {
+		if op.State == journal.StateInFlight {
+			inFlight++
+		}
+		if (state != "" && op.State != state) ||
+			(kind != "" && op.Kind != kind) {
+			continue
+		}
+
+		entry := map[string]any{
+			"kind":       op.Kind,
+			"key":        op.Key,
+			"tool":       op.Tool,
+			"state":      op.State,
+			"started_at": op.StartedAt.Format(time.RFC3339),
+			"updated_at": op.UpdatedAt.Format(time.RFC3339),
+		}
+		if len(op.Detail) > 0 {
+			entry["detail"] = op.Detail
+		}
+		operations = append(operations, entry)
+	}
+
+	result["operations"] = operations
+	result["in_flight"] = inFlight
+
+	return jsonResult("lnc_list_operations", result), nil
+}
+
+// Reconcile checks the journaled operations still in flight against the node
+// once, and returns those it found finished: the ones that completed while
+// the server was not tracking them. An error is returned with whatever was
+// resolved before it.
+func (s *OperationService) Reconcile(
+	ctx context.Context) ([]journal.Operation, error) {
+	pending := make(map[string]bool)
+	for _, op := range s.Journal.InFlight() {
+		pending[op.Kind+":"+op.Key] = true
+	}
+
+	err := s.reconcile(ctx, false)
+
+	var resolved []journal.Operation
+	for _, op := range s.Journal.Operations() {
+		if pending[op.Kind+":"+op.Key] &&
+			op.State != journal.StateInFlight {
+			resolved = append(resolved, op)
+		}
+	}
+
+	return resolved, err
+}
+
+// Resume follows the journaled operations still in flight on the current
+// clients until each finishes or ctx ends. The manager runs it whenever a
+// connection is established, so operations started before a restart or a
+// reconnect are seen through.
+func (s *OperationService) Resume(ctx context.Context) {
+	if err := s.reconcile(ctx, true); err != nil {
+		logging.LogWithContext(ctx).Warn(
+			"Failed to resume journaled operations", zap.Error(err))
+	}
+}
+
+// reconcile resolves the in-flight operations against the node. With
+// follow set, payments are followed until they finish rather than only
+// checked once. The first failure is returned after every operation has
+// been tried.
+func (s *OperationService) reconcile(ctx context.Context, follow bool) error {
+	var (
+		payments []string
+		opens    []string
+	)
+	for _, op := range s.Journal.InFlight() {
+		switch op.Kind {
+		case journal.KindPayment:
+			payments = append(payments, op.Key)
+		case journal.KindChannelOpen:
+			opens = append(opens, op.Key)
+		}
+	}
+
+	firstErr := s.reconcileChannelOpens(ctx, opens)
+
+	errs := make(chan error, len(payments))
+	for _, hash := range payments {
+		go func() {
+			errs <- s.reconcilePayment(ctx, hash, follow)
+		}()
+	}
+	for range payments {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// reconcilePayment records the node's view of a journaled payment. A
+// payment the node has no record of never left this server, so it failed.
+func (s *OperationService) reconcilePayment(ctx context.Context, key string,
+	follow bool) error {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return nil
+	}
+	hash, err := hex.DecodeString(key)
+	if err != nil {
+		return s.Journal.Update(journal.KindPayment, key,
+			journal.StateFailed, map[string]any{
+				"failure_reason": "invalid payment hash",
+			})
+	}
+
+	// The stream is cancelled on return so lnd stops sending updates
+	// once the current state is known.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := router.TrackPaymentV2(streamCtx,
+		&routerrpc.TrackPaymentRequest{PaymentHash: hash})
+	if err != nil {
+		return s.routerFailure(generation, err)
+	}
+	for {
+		update, err := stream.Recv()
+		switch {
+		case err == io.EOF:
+			return nil
+
+		case status.Code(err) == codes.NotFound:
+			return s.Journal.Update(journal.KindPayment, key,
+				journal.StateFailed, map[string]any{
+					"failure_reason": "the node has no " +
+						"record of the payment",
+				})
+
+		case err != nil:
+			return s.routerFailure(generation, err)
+		}
+		s.Clients.recordSubserver(generation, "router", true, "")
+
+		if state, ok := paymentJournalState(update); ok {
+			return s.Journal.Update(journal.KindPayment, key, state,
+				paymentJournalDetail(update))
+		}
+		if !follow {
+			return nil
+		}
+	}
+}
+
+// routerFailure records a missing router subserver and returns err.
+func (s *OperationService) routerFailure(generation uint64, err error) error {
+	if subserverMissing(err) {
+		s.Clients.recordSubserver(generation, "router", false,
+			status.Convert(err).Message())
+	}
+	return err
+}
+
+// reconcileChannelOpens records which journaled channel opens completed.
+// A channel the node closed again still opened; one whose funding was
+// cancelled or abandoned, or that the node no longer knows, did not.
+func (s *OperationService) reconcileChannelOpens(ctx context.Context,
+	points []string) error {
+	client, _ := s.Clients.Lightning()
+	if client == nil || len(points) == 0 {
+		return nil
+	}
+
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return err
+	}
+	pending, err := client.PendingChannels(ctx,
+		&lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return err
+	}
+	closed, err := client.ClosedChannels(ctx,
+		&lnrpc.ClosedChannelsRequest{})
+	if err != nil {
+		return err
+	}
+
+	open := make(map[string]bool, len(list.Channels))
+	for _, ch := range list.Channels {
+		open[ch.ChannelPoint] = true
+	}
+	opening := make(map[string]bool, len(pending.PendingOpenChannels))
+	for _, ch := range pending.PendingOpenChannels {
+		if ch.Channel != nil {
+			opening[ch.Channel.ChannelPoint] = true
+		}
+	}
+	closes := make(map[string]lnrpc.ChannelCloseSummary_ClosureType,
+		len(closed.Channels))
+	for _, ch := range closed.Channels {
+		closes[ch.ChannelPoint] = ch.CloseType
+	}
+
+	for _, point := range points {
+		var (
+			state  string
+			detail map[string]any
+		)
+		closeType, wasClosed := closes[point]
+		switch {
+		case open[point]:
+			state = journal.StateSucceeded
+
+		case opening[point]:
+			continue
+
+		case wasClosed &&
+			closeType != lnrpc.ChannelCloseSummary_FUNDING_CANCELED &&
+			closeType != lnrpc.ChannelCloseSummary_ABANDONED:
+			state = journal.StateSucceeded
+			detail = map[string]any{"closed": closeType.String()}
+
+		case wasClosed:
+			state = journal.StateFailed
+			detail = map[string]any{
+				"failure_reason": closeType.String(),
+			}
+
+		default:
+			state = journal.StateFailed
+			detail = map[string]any{
+				"failure_reason": "the node no longer knows " +
+					"the channel",
+			}
+		}
+
+		if err := s.Journal.Update(journal.KindChannelOpen, point, state,
+			detail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paymentJournalState maps a final payment status to its journal state,
+// and reports false for a payment still in flight.
+func paymentJournalState(payment *lnrpc.Payment) (string, bool) {
+	switch payment.Status {
+	case lnrpc.Payment_SUCCEEDED:
+		return journal.StateSucceeded, true
+	case lnrpc.Payment_FAILED:
+		return journal.StateFailed, true
+	default:
+		return "", false
+	}
+}
+
+// paymentJournalDetail summarizes the outcome of a finished payment. The
+// preimage is left out, as the journal is not a secret store.
+func paymentJournalDetail(payment *lnrpc.Payment) map[string]any {
+	detail := map[string]any{
+		"status":    payment.Status.String(),
+		"value_sat": payment.ValueSat,
+		"fee_sat":   payment.FeeSat,
+	}
+	if payment.Status == lnrpc.Payment_FAILED {
+		detail["failure_reason"] = payment.FailureReason.String()
+	}
+	return detail
+}
+
+// logJournalError logs a failed journal write. The operation itself goes
+// ahead; only its tracking across restarts is lost.
+func logJournalError(ctx context.Context, err error) {
+	if err != nil {
+		logging.LogWithContext(ctx).Warn("Failed to update the "+
+			"operation journal", zap.Error(err))
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+
+	"github.com/jbrill/mcp-lnc-server/internal/classify"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// baseSchemaVersion is the output schema version of every tool whose
+// results have not had a breaking change.
+const baseSchemaVersion = 1
+
+// schemaVersions records tools whose output schema has moved past
+// baseSchemaVersion. Bump a tool's entry only for breaking changes: a field
+// removed or renamed, or its type or meaning changed. Adding fields is not
+// breaking and keeps the version.
+var schemaVersions = map[string]int{}
+
+// deprecatedFields lists, per tool, result fields that will be removed or
+// changed in the tool's next schema version. Fields must be listed here for
+// at least one release before the version is bumped, so clients see the
+// deprecation in results before anything breaks.
+var deprecatedFields = map[string][]string{}
+
+// withheldClasses holds the classify.Class of the fields results must not
+// include. The zero value withholds nothing.
+var withheldClasses atomic.Uint32
+
+// SetResponseClasses sets the classes of fields every tool result may
+// include; fields of other classes are removed as results are encoded. It
+// is meant to be called once, before tools are served.
+func SetResponseClasses(allowed classify.Class) {
+	withheldClasses.Store(uint32(classify.All &^ allowed))
+}
+
+// responseClasses returns the classes of fields results may include.
+func responseClasses() classify.Class {
+	return classify.All &^ classify.Class(withheldClasses.Load())
+}
+
+// schemaVersion returns the output schema version of a tool.
+func schemaVersion(tool string) int {
+	if version, ok := schemaVersions[tool]; ok {
+		return version
+	}
+	return baseSchemaVersion
+}
+
+// jsonResult encodes a tool result as a JSON object tagged with the tool's
+// schema_version. Keys are emitted in sorted order at every level, so the
+// same data always produces the same text. Fields of classes results may
+// not include are removed and named in withheld_fields.
+func jsonResult(tool string, payload map[string]any) *mcp.CallToolResult {
+	data, err := encodeResult(tool, payload)
+	if err != nil {
+		return toolError(err)
+	}
+
+	return mcp.NewToolResultText(string(data))
+}
+
+// encodeResult encodes a result as jsonResult does, for results that are
+// not returned as tool results.
+func encodeResult(tool string, payload map[string]any) ([]byte,
+	*errors.Error) {
+	payload["schema_version"] = schemaVersion(tool)
+	if deprecated := deprecatedFields[tool]; len(deprecated) > 0 {
+		payload["deprecated_fields"] = deprecated
+	}
+
+	data, err := json.Marshal(payload)
+	if err == nil {
+		if allowed := responseClasses(); allowed != classify.All {
+			data, err = withholdFields(data, allowed)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to encode result")
+	}
+
+	return data, nil
+}
+
+// withholdFields removes the fields of an encoded result whose class is not
+// allowed. The result is filtered once encoded, so fields are found however
+// the handler built the payload, whether from maps or structs.
+func withholdFields(data []byte, allowed classify.Class) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var result map[string]any
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	if withheld := classify.Filter(result, allowed); len(withheld) > 0 {
+		// The handler may have withheld fields of its own.
+		previous, _ := result["withheld_fields"].([]any)
+		for _, name := range previous {
+			if name, ok := name.(string); ok {
+				withheld = append(withheld, name)
+			}
+		}
+		sort.Strings(withheld)
+		result["withheld_fields"] = withheld
+	}
+
+	return json.Marshal(result)
+}
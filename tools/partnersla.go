@@ -0,0 +1,361 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultSLADays and maxSLADays bound the forwarding window of a
+	// partner report, in days.
+	defaultSLADays = 30
+	maxSLADays     = 365
+
+	// maxSLAForwardPages caps the forwarding history read for one report,
+	// in pages of maxForwardingEvents.
+	maxSLAForwardPages = 10
+
+	// maxSLASample is the longest HTLC events are sampled for.
+	maxSLASample = 120 * time.Second
+)
+
+// partnerSLA collects the service one channel partner got from the node.
+type partnerSLA struct {
+	channels int
+	active   int
+	capacity int64
+
+	// lifetime and uptime sum lnd's channel monitoring, in seconds.
+	lifetime int64
+	uptime   int64
+
+	forwardsIn    int
+	forwardsOut   int
+	volumeInMsat  uint64
+	volumeOutMsat uint64
+	feesMsat      uint64
+
+	sample *htlcSample
+}
+
+// htlcSample counts the HTLCs a partner sent the node to forward while
+// events were sampled.
+type htlcSample struct {
+	// forwarded HTLCs were passed on to the next channel, though the
+	// next node may still fail them.
+	forwarded int
+
+	// failedByUs HTLCs were refused by the node itself, keyed in reasons
+	// by lnd's failure detail.
+	failedByUs int
+	reasons    map[string]int
+
+	// failedDownstream HTLCs were forwarded and then failed by a node
+	// further along the route.
+	failedDownstream int
+}
+
+// PartnerSLATool returns the MCP tool definition for the service quality
+// report for channel partners.
+func (s *ChannelService) PartnerSLATool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_partner_sla",
+		Description: "Report the service the node gives each channel " +
+			"partner, to share with them: how long its channels " +
+			"were active while lnd monitored them, and the " +
+			"forwards in and out of them over a window of days. " +
+			"With sample_seconds, also watch live HTLCs for that " +
+			"long and report how many the node failed itself",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"peer": map[string]any{
+					"type": "string",
+					"description": "Only report this " +
+						"partner's public key",
+					"pattern": "^[0-9a-fA-F]{66}$",
+				},
+				"days": map[string]any{
+					"type": "number",
+					"description": "Days of forwarding " +
+						"history to count (default 30)",
+					"minimum": 1,
+					"maximum": maxSLADays,
+				},
+				"sample_seconds": map[string]any{
+					"type": "number",
+					"description": "Watch forwarded " +
+						"HTLCs for this many " +
+						"seconds to measure the " +
+						"failure rate (default 0, " +
+						"no sampling)",
+					"minimum": 0,
+					"maximum": maxSLASample.Seconds(),
+				},
+			},
+		},
+	}
+}
+
+// HandlePartnerSLA handles the partner service quality request. Active time
+// comes from lnd's channel monitoring, which restarts with lnd, and the
+// failure rate from HTLC events, which lnd does not keep; only forwards
+// that succeeded are in its history.
+func (s *ChannelService) HandlePartnerSLA(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	peerArg, _ := args["peer"].(string)
+	days, _ := args["days"].(float64)
+	sampleSeconds, _ := args["sample_seconds"].(float64)
+
+	var peer string
+	if peerArg != "" {
+		key, err := parsePubkey("peer", peerArg)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		peer = hex.EncodeToString(key)
+	}
+	switch {
+	case days < 0 || days > maxSLADays || days != math.Trunc(days):
+		return invalidArgumentError(fmt.Sprintf("days must be a whole "+
+			"number between 1 and %d", maxSLADays)), nil
+	case days == 0:
+		days = defaultSLADays
+	}
+	if sampleSeconds < 0 || sampleSeconds > maxSLASample.Seconds() {
+		return invalidArgumentError(fmt.Sprintf("sample_seconds must "+
+			"be between 0 and %.0f", maxSLASample.Seconds())), nil
+	}
+
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	partners := make(map[string]*partnerSLA)
+	chanPeers := make(map[uint64]*partnerSLA)
+	for _, ch := range list.Channels {
+		if peer != "" && ch.RemotePubkey != peer {
+			continue
+		}
+		partner, ok := partners[ch.RemotePubkey]
+		if !ok {
+			partner = &partnerSLA{}
+			partners[ch.RemotePubkey] = partner
+		}
+		partner.add(ch)
+		chanPeers[ch.ChanId] = partner
+	}
+
+	// Forwards over channels since closed are left out, as their
+	// partner is no longer known.
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	var counted int
+	var offset uint32
+	truncated := true
+	for page := 0; page < maxSLAForwardPages; page++ {
+		resp, err := client.ForwardingHistory(ctx,
+			&lnrpc.ForwardingHistoryRequest{
+				StartTime:    uint64(since.Unix()),
+				IndexOffset:  offset,
+				NumMaxEvents: maxForwardingEvents,
+			})
+		if err != nil {
+			return rpcError(err, "failed to fetch forwarding "+
+				"history"), nil
+		}
+		for _, event := range resp.ForwardingEvents {
+			if partner, ok := chanPeers[event.ChanIdIn]; ok {
+				partner.forwardsIn++
+				partner.volumeInMsat += event.AmtInMsat
+				partner.feesMsat += event.FeeMsat
+			}
+			if partner, ok := chanPeers[event.ChanIdOut]; ok {
+				partner.forwardsOut++
+				partner.volumeOutMsat += event.AmtOutMsat
+			}
+		}
+		counted += len(resp.ForwardingEvents)
+		offset = resp.LastOffsetIndex
+		if len(resp.ForwardingEvents) < maxForwardingEvents {
+			truncated = false
+			break
+		}
+	}
+
+	result := map[string]any{
+		"window_days":        int(days),
+		"since":              since.UTC().Format(time.RFC3339),
+		"forwards_counted":   counted,
+		"forwards_truncated": truncated,
+	}
+
+	if sampleSeconds > 0 {
+		sampled, events, errResult := s.sampleHtlcs(ctx,
+			time.Duration(sampleSeconds*float64(time.Second)),
+			chanPeers)
+		if errResult != nil {
+			return errResult, nil
+		}
+		result["sample"] = map[string]any{
+			"seconds": math.Round(sampled.Seconds()*10) / 10,
+			"events":  events,
+		}
+	}
+
+	entries := make([]map[string]any, 0, len(partners))
+	for pubkey, partner := range partners {
+		entry := partner.toMap()
+		entry["remote_pubkey"] = pubkey
+		addContact(s.Contacts, entry, pubkey)
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i]["capacity"].(int64),
+			entries[j]["capacity"].(int64)
+		if a != b {
+			return a > b
+		}
+		return entries[i]["remote_pubkey"].(string) <
+			entries[j]["remote_pubkey"].(string)
+	})
+	result["partners"] = entries
+	result["partner_count"] = len(entries)
+
+	return jsonResult("lnc_partner_sla", result), nil
+}
+
+// sampleHtlcs watches the node's HTLC events for up to duration, counting
+// forwards into each partner's channels. It returns how long it watched
+// and how many forward events it saw.
+func (s *ChannelService) sampleHtlcs(ctx context.Context,
+	duration time.Duration, chanPeers map[uint64]*partnerSLA) (
+	time.Duration, int, *mcp.CallToolResult) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return 0, 0, notConnectedError(s.Clients)
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return 0, 0, missing
+	}
+
+	sampleCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	started := time.Now()
+	stream, err := router.SubscribeHtlcEvents(sampleCtx,
+		&routerrpc.SubscribeHtlcEventsRequest{})
+	if err != nil {
+		return 0, 0, subserverError(s.Clients, generation, "router",
+			err, "failed to subscribe to HTLC events")
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+	notifyProgress(ctx, 0, fmt.Sprintf("Sampling HTLCs for %s",
+		duration))
+
+	for _, partner := range chanPeers {
+		partner.sample = &htlcSample{reasons: make(map[string]int)}
+	}
+
+	var events int
+	for {
+		event, err := stream.Recv()
+		switch {
+		// The sample ends when its time is up, or when the call is
+		// cancelled, with what was seen so far.
+		case err != nil && sampleCtx.Err() != nil:
+			return time.Since(started), events, nil
+		case err != nil:
+			return 0, 0, rpcError(err, "HTLC event stream failed")
+		}
+		if event.EventType != routerrpc.HtlcEvent_FORWARD {
+			continue
+		}
+		partner, ok := chanPeers[event.IncomingChannelId]
+		if !ok {
+			continue
+		}
+
+		sample := partner.sample
+		switch {
+		case event.GetForwardEvent() != nil:
+			sample.forwarded++
+		case event.GetLinkFailEvent() != nil:
+			sample.failedByUs++
+			detail := event.GetLinkFailEvent().FailureDetail
+			sample.reasons[strings.ToLower(detail.String())]++
+		case event.GetForwardFailEvent() != nil:
+			sample.failedDownstream++
+		default:
+			continue
+		}
+		events++
+	}
+}
+
+// add counts a channel with the partner.
+func (p *partnerSLA) add(ch *lnrpc.Channel) {
+	p.channels++
+	if ch.Active {
+		p.active++
+	}
+	p.capacity += ch.Capacity
+	p.lifetime += ch.Lifetime
+	p.uptime += ch.Uptime
+}
+
+// toMap formats the partner's report, with percentages to one decimal
+// place. The active percentage is left out until lnd has monitored a
+// channel.
+func (p *partnerSLA) toMap() map[string]any {
+	entry := map[string]any{
+		"channel_count":        p.channels,
+		"active_channel_count": p.active,
+		"capacity":             p.capacity,
+		"monitored_seconds":    p.lifetime,
+		"active_seconds":       p.uptime,
+		"forwards_in":          p.forwardsIn,
+		"forwards_out":         p.forwardsOut,
+		"volume_in_msat":       p.volumeInMsat,
+		"volume_out_msat":      p.volumeOutMsat,
+		"fees_earned_msat":     p.feesMsat,
+	}
+	if p.lifetime > 0 {
+		entry["active_percent"] = math.Round(float64(p.uptime)/
+			float64(p.lifetime)*1000) / 10
+	}
+
+	if p.sample != nil {
+		attempts := p.sample.forwarded + p.sample.failedByUs
+		sample := map[string]any{
+			"received":          attempts,
+			"forwarded":         p.sample.forwarded,
+			"failed_by_us":      p.sample.failedByUs,
+			"failed_downstream": p.sample.failedDownstream,
+			"failure_reasons":   p.sample.reasons,
+		}
+		if attempts > 0 {
+			sample["failure_rate_percent"] = math.Round(
+				float64(p.sample.failedByUs)/
+					float64(attempts)*1000) / 10
+		}
+		entry["htlcs"] = sample
+	}
+
+	return entry
+}
@@ -0,0 +1,500 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultPaymentTimeout bounds how long lnd keeps trying routes.
+	defaultPaymentTimeout = 60 * time.Second
+
+	// defaultFeeLimitPercent caps routing fees when no limit is given.
+	defaultFeeLimitPercent = 1
+
+	// minFeeLimitSat keeps the default limit usable for small payments.
+	minFeeLimitSat = 10
+
+	// keysendRecordType is the TLV record that carries the preimage of a
+	// keysend payment to the recipient.
+	keysendRecordType uint64 = 5482373484
+
+	// customRecordTypeStart is the lowest TLV type lnd accepts as a
+	// custom record.
+	customRecordTypeStart uint64 = 65536
+
+	// maxStreamRestarts bounds how often a payment is followed again
+	// after the connection is replaced under it.
+	maxStreamRestarts = 3
+)
+
+// PayInvoiceTool returns the MCP tool definition for paying an invoice.
+func (s *PaymentService) PayInvoiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_pay_invoice",
+		Description: "Pay a BOLT11 Lightning invoice. Progress is " +
+			"reported while the payment is in flight",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"invoice": map[string]any{
+					"type":        "string",
+					"description": "BOLT11 invoice to pay",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to pay, only for " +
+						"invoices without an amount",
+					"minimum": 1,
+				},
+				"fee_limit_sat": map[string]any{
+					"type": "number",
+					"description": "Maximum routing fee in " +
+						"satoshis (default 1% of the amount, " +
+						"at least 10 sat)",
+					"minimum": 0,
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "Give up finding a route " +
+						"after this many seconds (default 60)",
+					"minimum": 1,
+					"maximum": 3600,
+				},
+				"amp": map[string]any{
+					"type": "boolean",
+					"description": "Pay with AMP, splitting " +
+						"the payment into shards the " +
+						"recipient settles together; " +
+						"required for AMP invoices",
+				},
+				"include_preimage": includePreimageProperty(),
+			},
+			Required: []string{"invoice"},
+		},
+	}
+}
+
+// HandlePayInvoice handles the pay invoice request. It follows the payment
+// with SendPaymentV2 until it settles or fails, sending a progress
+// notification for every status update.
+func (s *PaymentService) HandlePayInvoice(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if router, _ := s.Clients.Router(); router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	disclose, denied := s.disclosePreimage(request.Params.Arguments)
+	if denied != nil {
+		return denied, nil
+	}
+
+	invoice, ok := request.Params.Arguments["invoice"].(string)
+	if !ok || invoice == "" {
+		return invalidArgumentError("invoice is required"), nil
+	}
+	decoded, _, err := decodeBolt11(invoice)
+	if err != nil {
+		return toolError(errors.ErrInvalidInvoice(err.Error())), nil
+	}
+
+	amountSat, _ := request.Params.Arguments["amount_sat"].(float64)
+	invoiceAmountSat := int64(0)
+	if decoded.MilliSat != nil {
+		invoiceAmountSat = int64(*decoded.MilliSat / 1000)
+	}
+	switch {
+	case invoiceAmountSat > 0 && amountSat > 0:
+		return invalidArgumentError("amount_sat must not be set for " +
+			"an invoice that specifies an amount"), nil
+	case invoiceAmountSat == 0 && amountSat <= 0:
+		return invalidArgumentError("amount_sat is required for an " +
+			"invoice without an amount"), nil
+	}
+
+	// An AMP payment is identified by a set ID lnd picks, not by the
+	// invoice's payment hash.
+	amp, _ := request.Params.Arguments["amp"].(bool)
+	paymentHash := decoded.PaymentHash[:]
+	switch ampInvoice := isAMPInvoice(decoded); {
+	case ampInvoice && !amp:
+		return invalidArgumentError("this is an AMP invoice; set amp " +
+			"to pay it"), nil
+	case amp && !ampInvoice:
+		return invalidArgumentError("the invoice does not accept AMP " +
+			"payments; pay it without amp"), nil
+	case amp:
+		paymentHash = nil
+	}
+
+	paySat := invoiceAmountSat
+	if paySat == 0 {
+		paySat = int64(amountSat)
+	}
+
+	req := &routerrpc.SendPaymentRequest{
+		PaymentRequest: normalizeBolt11(invoice),
+		Amt:            int64(amountSat),
+		FeeLimitSat:    paymentFeeLimit(request.Params.Arguments, paySat),
+		TimeoutSeconds: paymentTimeout(request.Params.Arguments),
+		Amp:            amp,
+	}
+
+	return s.sendPayment(ctx, "lnc_pay_invoice", paymentHash, paySat, req,
+		disclose)
+}
+
+// KeysendTool returns the MCP tool definition for a spontaneous payment.
+func (s *PaymentService) KeysendTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_keysend",
+		Description: "Send a spontaneous keysend payment to a node " +
+			"without an invoice. Progress is reported while the " +
+			"payment is in flight",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"destination": map[string]any{
+					"type":        "string",
+					"description": "Public key of the recipient (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{66}$",
+				},
+				"amount_sat": map[string]any{
+					"type":        "number",
+					"description": "Amount to send in satoshis",
+					"minimum":     1,
+				},
+				"tlv_records": map[string]any{
+					"type": "object",
+					"description": "Extra custom records for the " +
+						"recipient, mapping TLV type (65536 or " +
+						"above) to a hex encoded value",
+					"additionalProperties": map[string]any{
+						"type":    "string",
+						"pattern": "^([0-9a-fA-F]{2})*$",
+					},
+				},
+				"fee_limit_sat": map[string]any{
+					"type": "number",
+					"description": "Maximum routing fee in " +
+						"satoshis (default 1% of the amount, " +
+						"at least 10 sat)",
+					"minimum": 0,
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "Give up finding a route " +
+						"after this many seconds (default 60)",
+					"minimum": 1,
+					"maximum": 3600,
+				},
+				"amp": map[string]any{
+					"type": "boolean",
+					"description": "Send a spontaneous AMP " +
+						"payment instead of keysend; the " +
+						"recipient must accept AMP",
+				},
+				"include_preimage": includePreimageProperty(),
+			},
+			Required: []string{"destination", "amount_sat"},
+		},
+	}
+}
+
+// HandleKeysend handles the keysend request. A fresh preimage is generated
+// and sent to the recipient in the keysend record, so the payment hash can
+// only be settled by the node it was sent to. With amp set, lnd sends a
+// spontaneous AMP payment instead.
+func (s *PaymentService) HandleKeysend(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if router, _ := s.Clients.Router(); router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	disclose, denied := s.disclosePreimage(args)
+	if denied != nil {
+		return denied, nil
+	}
+
+	destination, _ := args["destination"].(string)
+	dest, err := parsePubkey("destination", destination)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < 1 {
+		return invalidArgumentError("amount_sat must be at least 1"), nil
+	}
+
+	records, err := keysendRecords(args["tlv_records"])
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	req := &routerrpc.SendPaymentRequest{
+		Dest:              dest,
+		Amt:               int64(amountSat),
+		DestCustomRecords: records,
+		FeeLimitSat:       paymentFeeLimit(args, int64(amountSat)),
+		TimeoutSeconds:    paymentTimeout(args),
+	}
+
+	// lnd derives the shares, hashes and preimages of an AMP payment
+	// itself.
+	if amp, _ := args["amp"].(bool); amp {
+		req.Amp = true
+		return s.sendPayment(ctx, "lnc_keysend", nil, int64(amountSat),
+			req, disclose)
+	}
+
+	var preimage [32]byte
+	if _, err := rand.Read(preimage[:]); err != nil {
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to generate preimage")), nil
+	}
+	hash := sha256.Sum256(preimage[:])
+	records[keysendRecordType] = preimage[:]
+	req.PaymentHash = hash[:]
+
+	return s.sendPayment(ctx, "lnc_keysend", hash[:], int64(amountSat),
+		req, disclose)
+}
+
+// keysendRecords parses the tlv_records argument into custom records.
+func keysendRecords(arg any) (map[uint64][]byte, error) {
+	records := make(map[uint64][]byte)
+	if arg == nil {
+		return records, nil
+	}
+
+	values, ok := arg.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("tlv_records must be an object")
+	}
+	for key, value := range values {
+		recordType, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || recordType < customRecordTypeStart {
+			return nil, fmt.Errorf("tlv_records type %q must be a "+
+				"number of at least %d", key,
+				customRecordTypeStart)
+		}
+		if recordType == keysendRecordType {
+			return nil, fmt.Errorf("tlv_records must not set the "+
+				"keysend record %d", keysendRecordType)
+		}
+
+		encoded, _ := value.(string)
+		data, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("tlv_records value for %d must "+
+				"be hex encoded", recordType)
+		}
+		records[recordType] = data
+	}
+
+	return records, nil
+}
+
+// sendPayment dispatches a payment and follows its status updates until it
+// reaches a final state. If the connection is replaced while the payment is
+// in flight, the payment is followed again on the new connection, or sent
+// again if the node never recorded it; lnd refuses to pay a hash twice.
+// The amount plus the fee limit counts against the spend caps until the
+// payment settles or fails. The preimage is left out of the result unless
+// disclose is set. An AMP payment has no hash until lnd reports the set ID
+// it identifies the payment by, so paymentHash is nil for one.
+func (s *PaymentService) sendPayment(ctx context.Context, tool string,
+	paymentHash []byte, amountSat int64,
+	req *routerrpc.SendPaymentRequest,
+	disclose bool) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	hash := hex.EncodeToString(paymentHash)
+	spendID := paymentSpendID(paymentHash)
+	err := s.Policy.ReserveSpend(spendID, tool, amountSat+req.FeeLimitSat)
+	if err != nil {
+		return policyError(err), nil
+	}
+
+	stream, err := router.SendPaymentV2(ctx, req)
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to send payment"), nil
+	}
+
+	begin := func() {
+		logJournalError(ctx, s.Journal.Begin(journal.KindPayment, hash,
+			tool, map[string]any{"fee_limit_sat": req.FeeLimitSat}))
+	}
+	if hash != "" {
+		begin()
+	}
+
+	var (
+		payment  *lnrpc.Payment
+		updates  []map[string]any
+		restarts int
+		tracking bool
+	)
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && tracking && status.Code(err) == codes.NotFound {
+			// The payment never reached the old node's database.
+			tracking = false
+			stream, err = router.SendPaymentV2(ctx, req)
+			if err != nil {
+				return subserverError(s.Clients, generation,
+					"router", err, "failed to send payment"), nil
+			}
+			continue
+		}
+		if err != nil && s.Clients.Replaced(generation) &&
+			hash == "" {
+			return connectionReplacedError("the connection was "+
+				"replaced before the node reported the AMP "+
+				"payment; check lnc_list_payments before "+
+				"retrying", map[string]any{}), nil
+		}
+		if err != nil && s.Clients.Replaced(generation) {
+			if restarts == maxStreamRestarts || ctx.Err() != nil {
+				return connectionReplacedError("the connection "+
+					"was replaced while following the "+
+					"payment; check lnc_track_payment "+
+					"before retrying", map[string]any{
+					"payment_hash": hash,
+				}), nil
+			}
+			restarts++
+
+			router, generation = s.Clients.Router()
+			if router == nil {
+				return notConnectedError(s.Clients), nil
+			}
+			stream, err = router.TrackPaymentV2(ctx,
+				&routerrpc.TrackPaymentRequest{
+					PaymentHash: paymentHash,
+				})
+			if err != nil {
+				return subserverError(s.Clients, generation,
+					"router", err, "failed to follow payment "+
+						"after reconnecting"), nil
+			}
+			tracking = true
+			notifyProgress(ctx, float64(len(updates)),
+				"connection replaced; following the payment "+
+					"on the new connection")
+			continue
+		}
+		if err != nil {
+			return subserverError(s.Clients, generation, "router",
+				err, "payment stream failed"), nil
+		}
+
+		s.Clients.recordSubserver(generation, "router", true, "")
+		if hash == "" {
+			hash = update.PaymentHash
+			paymentHash, _ = hex.DecodeString(hash)
+			begin()
+		}
+		payment = update
+		updates = append(updates, map[string]any{
+			"status":   update.Status.String(),
+			"htlcs":    len(update.Htlcs),
+			"at_ms":    time.Now().UnixMilli(),
+			"fee_msat": update.FeeMsat,
+		})
+		notifyProgress(ctx, float64(len(updates)), fmt.Sprintf(
+			"payment %s (%d HTLC attempts)", update.Status,
+			len(update.Htlcs)))
+
+		if update.Status == lnrpc.Payment_SUCCEEDED ||
+			update.Status == lnrpc.Payment_FAILED {
+			break
+		}
+	}
+
+	if payment == nil {
+		return toolError(errors.New(errors.ErrCodeRPCFailed,
+			"payment stream ended without a status")), nil
+	}
+	if state, ok := paymentJournalState(payment); ok {
+		logJournalError(ctx, s.Journal.Update(journal.KindPayment, hash,
+			state, paymentJournalDetail(payment)))
+	}
+	switch payment.Status {
+	case lnrpc.Payment_SUCCEEDED:
+		logSpendError(ctx, s.Policy.SettleSpend(spendID,
+			payment.ValueSat+payment.FeeSat))
+	case lnrpc.Payment_FAILED:
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+	}
+
+	result := map[string]any{
+		"payment_hash":     payment.PaymentHash,
+		"status":           payment.Status.String(),
+		"succeeded":        payment.Status == lnrpc.Payment_SUCCEEDED,
+		"payment_preimage": payment.PaymentPreimage,
+		"value_sat":        payment.ValueSat,
+		"fee_sat":          payment.FeeSat,
+		"fee_msat":         payment.FeeMsat,
+		"failure_reason":   payment.FailureReason.String(),
+		"htlc_attempts":    len(payment.Htlcs),
+		"status_updates":   updates,
+	}
+	addAMPFields(result, payment)
+	withholdPreimage(disclose, result)
+
+	return jsonResult(tool, result), nil
+}
+
+// paymentFeeLimit returns the fee limit argument, or the default limit for
+// a payment of amountSat.
+func paymentFeeLimit(args map[string]any, amountSat int64) int64 {
+	if limit, ok := args["fee_limit_sat"].(float64); ok && limit >= 0 {
+		return int64(limit)
+	}
+
+	limit := amountSat * defaultFeeLimitPercent / 100
+	if limit < minFeeLimitSat {
+		limit = minFeeLimitSat
+	}
+	return limit
+}
+
+// paymentTimeout returns the timeout argument in seconds, or the default.
+func paymentTimeout(args map[string]any) int32 {
+	if timeout, ok := args["timeout_seconds"].(float64); ok &&
+		timeout >= 1 {
+		return int32(timeout)
+	}
+	return int32(defaultPaymentTimeout.Seconds())
+}
@@ -3,28 +3,55 @@ package tools
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
 	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// PaymentService handles read-only Lightning payment operations.
+// PaymentService handles Lightning payment operations. The router client
+// is needed by the tools that send or track payments and those that read
+// lnd's pathfinding state.
 type PaymentService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// Journal records payments in flight, so they are followed again
+	// after a restart. Nil disables journaling.
+	Journal *journal.Journal
+
+	// MemoScrub is how payment descriptions are scrubbed from results:
+	// scrub.ModeNone, scrub.ModeHash or scrub.ModeRedact.
+	MemoScrub string
+
+	// PreimageDisclosure is when results include payment preimages:
+	// PreimageNever, PreimageOnRequest (the default when empty) or
+	// PreimageAlways.
+	PreimageDisclosure string
+
+	// Policy caps what payments spend, recording them in its ledger.
+	// Nil enforces no caps.
+	Policy *policy.Engine
 }
 
 // NewPaymentService creates a new payment service for read-only operations.
 func NewPaymentService(lightningClient lnrpc.LightningClient) *PaymentService {
 	return &PaymentService{
-		LightningClient: lightningClient,
+		Clients: NewClientProvider(lightningClient),
 	}
 }
 
 // ListPaymentsTool returns the MCP tool definition for listing payments.
 func (s *PaymentService) ListPaymentsTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "lnc_list_payments",
-		Description: "List historical Lightning payments made by this node",
+		Name: "lnc_list_payments",
+		Description: "List historical Lightning payments made by this " +
+			"node, optionally only those created in a time range, " +
+			"within an amount range or with a status",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]any{
@@ -47,17 +74,140 @@ func (s *PaymentService) ListPaymentsTool() mcp.Tool {
 					"type":        "boolean",
 					"description": "Return payments in reverse chronological order",
 				},
+				"creation_date_start": map[string]any{
+					"type": "number",
+					"description": "Only payments created at " +
+						"or after this Unix timestamp, in " +
+						"seconds",
+					"minimum": 0,
+				},
+				"creation_date_end": map[string]any{
+					"type": "number",
+					"description": "Only payments created at " +
+						"or before this Unix timestamp, in " +
+						"seconds",
+					"minimum": 0,
+				},
+				"min_amount_sat": map[string]any{
+					"type":        "number",
+					"description": "Only payments of at least this many satoshis",
+					"minimum":     0,
+				},
+				"max_amount_sat": map[string]any{
+					"type":        "number",
+					"description": "Only payments of at most this many satoshis",
+					"minimum":     0,
+				},
+				"status": map[string]any{
+					"type": "string",
+					"description": "Only payments with this " +
+						"status; failed and in_flight imply " +
+						"include_incomplete",
+					"enum": []string{"succeeded", "failed",
+						"in_flight"},
+				},
+				"include_preimage": includePreimageProperty(),
 			},
 		},
 	}
 }
 
+// paymentFilter selects payments by creation time, in Unix seconds, amount
+// and status. Zero fields do not filter.
+type paymentFilter struct {
+	start, end int64
+	minSat     int64
+	maxSat     int64
+	statuses   []lnrpc.Payment_PaymentStatus
+}
+
+// paymentStatuses maps the status filter onto lnd's payment statuses. A
+// payment lnd has only initiated is as good as in flight.
+var paymentStatuses = map[string][]lnrpc.Payment_PaymentStatus{
+	"succeeded": {lnrpc.Payment_SUCCEEDED},
+	"failed":    {lnrpc.Payment_FAILED},
+	"in_flight": {lnrpc.Payment_IN_FLIGHT, lnrpc.Payment_INITIATED},
+}
+
+// parsePaymentFilter reads the filter arguments of lnc_list_payments.
+func parsePaymentFilter(args map[string]any) (paymentFilter, error) {
+	var filter paymentFilter
+	start, _ := args["creation_date_start"].(float64)
+	end, _ := args["creation_date_end"].(float64)
+	minSat, _ := args["min_amount_sat"].(float64)
+	maxSat, _ := args["max_amount_sat"].(float64)
+	status, _ := args["status"].(string)
+
+	switch {
+	case start < 0 || end < 0 || minSat < 0 || maxSat < 0:
+		return filter, fmt.Errorf("dates and amounts must not be " +
+			"negative")
+	case end != 0 && end <= start:
+		return filter, fmt.Errorf("creation_date_end must be after " +
+			"creation_date_start")
+	case maxSat != 0 && maxSat < minSat:
+		return filter, fmt.Errorf("max_amount_sat must not be below " +
+			"min_amount_sat")
+	}
+	if status != "" {
+		statuses, ok := paymentStatuses[status]
+		if !ok {
+			return filter, fmt.Errorf("status must be succeeded, " +
+				"failed or in_flight")
+		}
+		filter.statuses = statuses
+	}
+
+	filter.start = int64(start)
+	filter.end = int64(end)
+	filter.minSat = int64(minSat)
+	filter.maxSat = int64(maxSat)
+	return filter, nil
+}
+
+// local reports whether the filter drops payments after lnd lists them.
+func (f paymentFilter) local() bool {
+	return f.start != 0 || f.end != 0 || f.minSat != 0 ||
+		f.maxSat != 0 || len(f.statuses) > 0
+}
+
+// matches reports whether a payment passes the filter. Dates are checked
+// again here, in whole seconds as lnd does, for nodes that predate lnd's
+// own date filters.
+func (f paymentFilter) matches(payment *lnrpc.Payment) bool {
+	created := payment.CreationTimeNs / int64(time.Second)
+	switch {
+	case created < f.start:
+		return false
+	case f.end != 0 && created > f.end:
+		return false
+	case payment.ValueSat < f.minSat:
+		return false
+	case f.maxSat != 0 && payment.ValueSat > f.maxSat:
+		return false
+	}
+	if len(f.statuses) == 0 {
+		return true
+	}
+	for _, status := range f.statuses {
+		if payment.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleListPayments handles the list payments request.
 func (s *PaymentService) HandleListPayments(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	disclose, denied := s.disclosePreimage(request.Params.Arguments)
+	if denied != nil {
+		return denied, nil
 	}
 
 	// Parse parameters
@@ -69,51 +219,82 @@ func (s *PaymentService) HandleListPayments(ctx context.Context,
 	}
 	reversed, _ := request.Params.Arguments["reversed"].(bool)
 
+	filter, err := parsePaymentFilter(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	for _, status := range filter.statuses {
+		if status != lnrpc.Payment_SUCCEEDED {
+			includeIncomplete = true
+		}
+	}
+
 	// List payments
-	resp, err := s.LightningClient.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
+	resp, err := client.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
 		IncludeIncomplete: includeIncomplete,
 		IndexOffset:       uint64(indexOffset),
 		MaxPayments:       uint64(maxPayments),
 		Reversed:          reversed,
+		CreationDateStart: uint64(filter.start),
+		CreationDateEnd:   uint64(filter.end),
 	})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to list payments: %v", err)), nil
+		return rpcError(err, "failed to list payments"), nil
 	}
 
 	// Format payment list
-	paymentList := make([]map[string]any, len(resp.Payments))
-	for i, payment := range resp.Payments {
-		paymentList[i] = map[string]any{
-			"payment_hash":     payment.PaymentHash,
-			"value_sat":        payment.ValueSat,
-			"value_msat":       payment.ValueMsat,
-			"payment_preimage": payment.PaymentPreimage,
-			"payment_request":  payment.PaymentRequest,
-			"status":           payment.Status.String(),
-			"fee_sat":          payment.FeeSat,
-			"fee_msat":         payment.FeeMsat,
-			"creation_time_ns": payment.CreationTimeNs,
-			"payment_index":    payment.PaymentIndex,
-			"failure_reason":   payment.FailureReason.String(),
-			"htlc_count":       len(payment.Htlcs),
+	paymentList := make([]map[string]any, 0, len(resp.Payments))
+	for _, payment := range resp.Payments {
+		if filter.matches(payment) {
+			paymentList = append(paymentList,
+				s.paymentEntry(payment))
 		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"payments": %s,
-		"first_index_offset": %d,
-		"last_index_offset": %d,
-		"total_payments": %d
-	}`, toJSONString(paymentList), resp.FirstIndexOffset,
-		resp.LastIndexOffset, len(paymentList))), nil
+	// Pages are cut before filtering, so a short page does not mean the
+	// history ended; the offsets still lead to the next page.
+	result := map[string]any{
+		"payments":           paymentList,
+		"first_index_offset": resp.FirstIndexOffset,
+		"last_index_offset":  resp.LastIndexOffset,
+		"total_payments":     len(paymentList),
+	}
+	if filter.local() {
+		result["scanned_payments"] = len(resp.Payments)
+	}
+	withholdPreimage(disclose, result, paymentList...)
+
+	return jsonResult("lnc_list_payments", result), nil
+}
+
+// paymentEntry formats a payment as lnc_list_payments lists it.
+func (s *PaymentService) paymentEntry(payment *lnrpc.Payment) map[string]any {
+	entry := map[string]any{
+		"payment_hash":     payment.PaymentHash,
+		"value_sat":        payment.ValueSat,
+		"value_msat":       payment.ValueMsat,
+		"payment_preimage": payment.PaymentPreimage,
+		"payment_request": scrubPaymentRequest(s.MemoScrub,
+			payment.PaymentRequest),
+		"status":           payment.Status.String(),
+		"fee_sat":          payment.FeeSat,
+		"fee_msat":         payment.FeeMsat,
+		"creation_time_ns": payment.CreationTimeNs,
+		"payment_index":    payment.PaymentIndex,
+		"failure_reason":   payment.FailureReason.String(),
+		"htlc_count":       len(payment.Htlcs),
+	}
+	addAMPFields(entry, payment)
+	return entry
 }
 
 // TrackPaymentTool returns the MCP tool definition for tracking a payment.
 func (s *PaymentService) TrackPaymentTool() mcp.Tool {
 	return mcp.Tool{
-		Name:        "lnc_track_payment",
-		Description: "Track the status of a Lightning payment by its hash",
+		Name: "lnc_track_payment",
+		Description: "Track the status of a Lightning payment by its " +
+			"hash, including one still in flight, as lnd's " +
+			"router currently knows it",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]any{
@@ -122,66 +303,78 @@ func (s *PaymentService) TrackPaymentTool() mcp.Tool {
 					"description": "Payment hash to track (hex encoded)",
 					"pattern":     "^[0-9a-fA-F]{64}$",
 				},
+				"include_preimage": includePreimageProperty(),
 			},
 			Required: []string{"payment_hash"},
 		},
 	}
 }
 
-// HandleTrackPayment handles the track payment request.
+// HandleTrackPayment handles the track payment request. lnd's router looks
+// the payment up by its hash, so in-flight payments report their current
+// state and old ones are found however long the history is.
 func (s *PaymentService) HandleTrackPayment(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	disclose, denied := s.disclosePreimage(request.Params.Arguments)
+	if denied != nil {
+		return denied, nil
 	}
 
 	paymentHash, ok := request.Params.Arguments["payment_hash"].(string)
 	if !ok {
-		return mcp.NewToolResultError("payment_hash is required"), nil
+		return invalidArgumentError("payment_hash is required"), nil
 	}
 
-	// Validate payment hash format
-	if len(paymentHash) != 64 {
-		return mcp.NewToolResultError(
-			"payment_hash must be a 64-character hex string"), nil
+	hash, err := parseHash("payment_hash", paymentHash)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
 	}
 
-	// For read-only operation, we'll just look up the payment in history
-	resp, err := s.LightningClient.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
-		IncludeIncomplete: true,
-	})
+	// lnd sends the payment's current state first, in flight or not.
+	// In-flight updates must not be turned off, as lnd then holds the
+	// first update back until the payment settles or fails. The stream
+	// is cancelled on return so it sends nothing more.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var payment *lnrpc.Payment
+	stream, err := router.TrackPaymentV2(streamCtx,
+		&routerrpc.TrackPaymentRequest{PaymentHash: hash})
+	if err == nil {
+		payment, err = stream.Recv()
+	}
+	if status.Code(err) == codes.NotFound {
+		s.Clients.recordSubserver(generation, "router", true, "")
+		return jsonResult("lnc_track_payment", map[string]any{
+			"found":   false,
+			"message": "Payment not found",
+		}), nil
+	}
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to fetch payment: %v", err)), nil
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to fetch payment"), nil
 	}
+	s.Clients.recordSubserver(generation, "router", true, "")
 
-	// Find the payment with matching hash
-	for _, payment := range resp.Payments {
-		if payment.PaymentHash == paymentHash {
-			return mcp.NewToolResultText(fmt.Sprintf(`{
-				"found": true,
-				"payment_hash": "%s",
-				"status": "%s",
-				"value_sat": %d,
-				"fee_sat": %d,
-				"creation_time_ns": %d,
-				"payment_preimage": "%s",
-				"failure_reason": "%s"
-			}`, payment.PaymentHash, payment.Status.String(),
-				payment.ValueSat, payment.FeeSat,
-				payment.CreationTimeNs, payment.PaymentPreimage,
-				payment.FailureReason.String())), nil
-		}
+	result := map[string]any{
+		"found":            true,
+		"payment_hash":     payment.PaymentHash,
+		"status":           payment.Status.String(),
+		"value_sat":        payment.ValueSat,
+		"fee_sat":          payment.FeeSat,
+		"creation_time_ns": payment.CreationTimeNs,
+		"payment_preimage": payment.PaymentPreimage,
+		"failure_reason":   payment.FailureReason.String(),
 	}
+	withholdPreimage(disclose, result)
 
-	return mcp.NewToolResultText(`{"found": false, "message": "Payment not found"}`), nil
-}
-
-// Helper function to check BOLT11 format
-//
-//nolint:unused // Used in tests
-func isValidBolt11(invoice string) bool {
-	// Basic check: BOLT11 invoices start with "ln"
-	return len(invoice) > 2 && invoice[:2] == "ln"
+	return jsonResult("lnc_track_payment", result), nil
 }
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// peerBalance totals the channels with one peer.
+type peerBalance struct {
+	channels      int
+	active        int
+	private       int
+	capacity      int64
+	localBalance  int64
+	remoteBalance int64
+	unsettled     int64
+}
+
+// ChannelBalanceByPeerTool returns the MCP tool definition for breaking the
+// channel balance down by peer.
+func (s *ChannelService) ChannelBalanceByPeerTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_channel_balance_by_peer",
+		Description: "Break the node's channel balance down by peer: " +
+			"for each remote node, the number of channels, their " +
+			"total capacity, and the local, remote and unsettled " +
+			"balances, largest capacity first",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"active_only": map[string]any{
+					"type":        "boolean",
+					"description": "Only count active channels",
+				},
+			},
+		},
+	}
+}
+
+// HandleChannelBalanceByPeer handles the channel balance by peer request.
+func (s *ChannelService) HandleChannelBalanceByPeer(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	activeOnly, _ := request.Params.Arguments["active_only"].(bool)
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{
+		ActiveOnly: activeOnly,
+	})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	var total peerBalance
+	balances := make(map[string]*peerBalance)
+	for _, ch := range list.Channels {
+		balance, ok := balances[ch.RemotePubkey]
+		if !ok {
+			balance = &peerBalance{}
+			balances[ch.RemotePubkey] = balance
+		}
+		balance.add(ch)
+		total.add(ch)
+	}
+
+	peers := make([]map[string]any, 0, len(balances))
+	for pubkey, balance := range balances {
+		entry := balance.toMap()
+		entry["remote_pubkey"] = pubkey
+		addContact(s.Contacts, entry, pubkey)
+		peers = append(peers, entry)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		a, b := peers[i]["capacity"].(int64), peers[j]["capacity"].(int64)
+		if a != b {
+			return a > b
+		}
+		return peers[i]["remote_pubkey"].(string) <
+			peers[j]["remote_pubkey"].(string)
+	})
+
+	return jsonResult("lnc_channel_balance_by_peer", map[string]any{
+		"peers":      peers,
+		"peer_count": len(peers),
+		"total":      total.toMap(),
+	}), nil
+}
+
+// add counts a channel into the balance.
+func (b *peerBalance) add(ch *lnrpc.Channel) {
+	b.channels++
+	if ch.Active {
+		b.active++
+	}
+	if ch.Private {
+		b.private++
+	}
+	b.capacity += ch.Capacity
+	b.localBalance += ch.LocalBalance
+	b.remoteBalance += ch.RemoteBalance
+	b.unsettled += ch.UnsettledBalance
+}
+
+// toMap formats the balance, with the local share of the capacity as a
+// percentage to one decimal place.
+func (b *peerBalance) toMap() map[string]any {
+	localPercent := 0.0
+	if b.capacity > 0 {
+		localPercent = math.Round(float64(b.localBalance)/
+			float64(b.capacity)*1000) / 10
+	}
+
+	return map[string]any{
+		"channel_count":         b.channels,
+		"active_channel_count":  b.active,
+		"private_channel_count": b.private,
+		"capacity":              b.capacity,
+		"local_balance":         b.localBalance,
+		"remote_balance":        b.remoteBalance,
+		"unsettled_balance":     b.unsettled,
+		"local_balance_percent": localPercent,
+	}
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultConnectPeerTimeout bounds how long lnc_connect_peer waits for the
+// peer to answer.
+const defaultConnectPeerTimeout = 30 * time.Second
+
+// ConnectPeerTool returns the MCP tool definition for connecting to a peer.
+func (s *PeerService) ConnectPeerTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_connect_peer",
+		Description: "Connect to a Lightning Network peer, for " +
+			"example before opening a channel to it",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"address": map[string]any{
+					"type": "string",
+					"description": "Peer address as " +
+						"pubkey@host:port; the port " +
+						"defaults to 9735 and IPv6 " +
+						"hosts must be bracketed",
+				},
+				"perm": map[string]any{
+					"type": "boolean",
+					"description": "Keep reconnecting to the " +
+						"peer in the background instead " +
+						"of connecting once",
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "Stop waiting for the peer " +
+						"after this many seconds (default 30)",
+					"minimum": 1,
+					"maximum": 300,
+				},
+			},
+			Required: []string{"address"},
+		},
+	}
+}
+
+// HandleConnectPeer handles the connect peer request. Connecting to a peer
+// that is already connected succeeds, reporting already_connected.
+func (s *PeerService) HandleConnectPeer(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+
+	address, _ := args["address"].(string)
+	pubkey, host, err := parsePeerAddress("address",
+		strings.TrimSpace(address))
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	nodePubkey := hex.EncodeToString(pubkey)
+
+	perm, _ := args["perm"].(bool)
+
+	timeout := defaultConnectPeerTimeout
+	if seconds, ok := args["timeout_seconds"].(float64); ok {
+		if seconds < 1 {
+			return invalidArgumentError("timeout_seconds must be " +
+				"at least 1"), nil
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	// lnd applies the timeout to the connection attempt; the context
+	// bounds the call itself, for a node that does not answer at all.
+	callCtx, cancel := context.WithTimeout(ctx, timeout+5*time.Second)
+	defer cancel()
+
+	_, err = client.ConnectPeer(callCtx, &lnrpc.ConnectPeerRequest{
+		Addr: &lnrpc.LightningAddress{
+			Pubkey: nodePubkey,
+			Host:   host,
+		},
+		Perm:    perm,
+		Timeout: uint64(timeout / time.Second),
+	})
+	alreadyConnected := false
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "already connected"):
+			alreadyConnected = true
+
+		case callCtx.Err() == context.DeadlineExceeded &&
+			ctx.Err() == nil:
+			return toolError(errors.Wrap(err, errors.ErrCodeTimeout,
+				"timed out connecting to peer")), nil
+
+		default:
+			return rpcError(err, "failed to connect to peer"), nil
+		}
+	}
+
+	return jsonResult("lnc_connect_peer", map[string]any{
+		"node_pubkey":       nodePubkey,
+		"host":              host,
+		"perm":              perm,
+		"already_connected": alreadyConnected,
+	}), nil
+}
+
+// DisconnectPeerTool returns the MCP tool definition for disconnecting from
+// a peer.
+func (s *PeerService) DisconnectPeerTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_disconnect_peer",
+		Description: "Disconnect from a Lightning Network peer. The " +
+			"node refuses while channels with the peer are open",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"node_pubkey": map[string]any{
+					"type":        "string",
+					"description": "Public key of the peer (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{66}$",
+				},
+			},
+			Required: []string{"node_pubkey"},
+		},
+	}
+}
+
+// HandleDisconnectPeer handles the disconnect peer request.
+func (s *PeerService) HandleDisconnectPeer(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	value, _ := request.Params.Arguments["node_pubkey"].(string)
+	pubkey, err := parsePubkey("node_pubkey", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	nodePubkey := hex.EncodeToString(pubkey)
+
+	_, err = client.DisconnectPeer(ctx, &lnrpc.DisconnectPeerRequest{
+		PubKey: nodePubkey,
+	})
+	if err != nil {
+		return rpcError(err, "failed to disconnect from peer"), nil
+	}
+
+	return jsonResult("lnc_disconnect_peer", map[string]any{
+		"node_pubkey":  nodePubkey,
+		"disconnected": true,
+	}), nil
+}
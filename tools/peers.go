@@ -2,21 +2,30 @@ package tools
 
 import (
 	"context"
-	"fmt"
+	"sort"
 
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // PeerService handles read-only Lightning peer operations.
 type PeerService struct {
-	LightningClient lnrpc.LightningClient
+	Clients *ClientProvider
+
+	// GraphSalt keys the pseudonyms of the anonymized graph export. It
+	// must stay secret for the pseudonyms to stay anonymous.
+	GraphSalt []byte
+
+	// Contacts names the peers in results. Nil leaves them unnamed.
+	Contacts *contacts.Book
 }
 
 // NewPeerService creates a new peer service for read-only operations.
 func NewPeerService(client lnrpc.LightningClient) *PeerService {
 	return &PeerService{
-		LightningClient: client,
+		Clients:   NewClientProvider(client),
+		GraphSalt: newGraphSalt(),
 	}
 }
 
@@ -36,22 +45,30 @@ func (s *PeerService) ListPeersTool() mcp.Tool {
 // HandleListPeers handles the list peers request.
 func (s *PeerService) HandleListPeers(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
-	peers, err := s.LightningClient.ListPeers(ctx, &lnrpc.ListPeersRequest{})
+	peers, err := client.ListPeers(ctx, &lnrpc.ListPeersRequest{})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to list peers: %v", err)), nil
+		return rpcError(err, "failed to list peers"), nil
 	}
 
 	peerList := make([]map[string]any, len(peers.Peers))
 	for i, peer := range peers.Peers {
 		// Format peer features
-		features := make([]map[string]any, 0)
-		for featureKey, feature := range peer.Features {
+		featureBits := make([]uint32, 0, len(peer.Features))
+		for featureKey := range peer.Features {
+			featureBits = append(featureBits, featureKey)
+		}
+		sort.Slice(featureBits, func(a, b int) bool {
+			return featureBits[a] < featureBits[b]
+		})
+
+		features := make([]map[string]any, 0, len(featureBits))
+		for _, featureKey := range featureBits {
+			feature := peer.Features[featureKey]
 			features = append(features, map[string]any{
 				"feature":     featureKey,
 				"name":        feature.Name,
@@ -83,12 +100,13 @@ func (s *PeerService) HandleListPeers(ctx context.Context,
 			"flap_count": peer.FlapCount,
 			"last_flap":  lastError,
 		}
+		addContact(s.Contacts, peerList[i], peer.PubKey)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"peers": %s,
-		"total_peers": %d
-	}`, toJSONStringPeers(peerList), len(peerList))), nil
+	return jsonResult("lnc_list_peers", map[string]any{
+		"peers":       peerList,
+		"total_peers": len(peerList),
+	}), nil
 }
 
 // DescribeGraphTool returns the MCP tool definition for getting network graph.
@@ -112,19 +130,18 @@ func (s *PeerService) DescribeGraphTool() mcp.Tool {
 // HandleDescribeGraph handles the describe graph request.
 func (s *PeerService) HandleDescribeGraph(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	includeUnannounced, _ := request.Params.Arguments["include_unannounced"].(bool)
 
-	graph, err := s.LightningClient.DescribeGraph(ctx, &lnrpc.ChannelGraphRequest{
+	graph, err := client.DescribeGraph(ctx, &lnrpc.ChannelGraphRequest{
 		IncludeUnannounced: includeUnannounced,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to describe graph: %v", err)), nil
+		return rpcError(err, "failed to describe graph"), nil
 	}
 
 	// Format the graph data (simplified for readability)
@@ -167,19 +184,13 @@ func (s *PeerService) HandleDescribeGraph(ctx context.Context,
 		})
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf(`{
-		"total_nodes": %d,
-		"total_edges": %d,
-		"include_unannounced": %t,
-		"sample_nodes": %s,
-		"sample_edges": %s
-	}`,
-		nodeCount,
-		edgeCount,
-		includeUnannounced,
-		toJSONStringPeers(sampleNodes),
-		toJSONStringPeers(sampleEdges),
-	)), nil
+	return jsonResult("lnc_describe_graph", map[string]any{
+		"total_nodes":         nodeCount,
+		"total_edges":         edgeCount,
+		"include_unannounced": includeUnannounced,
+		"sample_nodes":        sampleNodes,
+		"sample_edges":        sampleEdges,
+	}), nil
 }
 
 // GetNodeInfoTool returns the MCP tool definition for getting specific node information.
@@ -209,25 +220,24 @@ func (s *PeerService) GetNodeInfoTool() mcp.Tool {
 // HandleGetNodeInfo handles the get node info request.
 func (s *PeerService) HandleGetNodeInfo(ctx context.Context,
 	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if s.LightningClient == nil {
-		return mcp.NewToolResultError(
-			"Not connected to Lightning node. Use lnc_connect first."), nil
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
 	}
 
 	pubKey, ok := request.Params.Arguments["pub_key"].(string)
 	if !ok {
-		return mcp.NewToolResultError("pub_key is required"), nil
+		return invalidArgumentError("pub_key is required"), nil
 	}
 
 	includeChannels, _ := request.Params.Arguments["include_channels"].(bool)
 
-	nodeInfo, err := s.LightningClient.GetNodeInfo(ctx, &lnrpc.NodeInfoRequest{
+	nodeInfo, err := client.GetNodeInfo(ctx, &lnrpc.NodeInfoRequest{
 		PubKey:          pubKey,
 		IncludeChannels: includeChannels,
 	})
 	if err != nil {
-		return mcp.NewToolResultError(
-			fmt.Sprintf("Failed to get node info: %v", err)), nil
+		return rpcError(err, "failed to get node info"), nil
 	}
 
 	// Format node information
@@ -244,6 +254,7 @@ func (s *PeerService) HandleGetNodeInfo(ctx context.Context,
 		"num_channels":   nodeInfo.NumChannels,
 		"total_capacity": nodeInfo.TotalCapacity,
 	}
+	addContact(s.Contacts, nodeData, nodeInfo.Node.PubKey)
 
 	if includeChannels && len(nodeInfo.Channels) > 0 {
 		channels := make([]map[string]any, len(nodeInfo.Channels))
@@ -259,7 +270,7 @@ func (s *PeerService) HandleGetNodeInfo(ctx context.Context,
 		nodeData["channels"] = channels
 	}
 
-	return mcp.NewToolResultText(toJSONStringPeers(nodeData)), nil
+	return jsonResult("lnc_get_node_info", nodeData), nil
 }
 
 // FormatPeerErrors formats peer error information for JSON output.
@@ -274,9 +285,3 @@ func formatPeerErrors(errors []*lnrpc.TimestampedError,
 	}
 	return result
 }
-
-// ToJSONStringPeers converts an interface to JSON string for peer data output.
-func toJSONStringPeers(v any) string {
-	// Simplified JSON conversion - in production use proper JSON marshaling
-	return fmt.Sprintf("%+v", v)
-}
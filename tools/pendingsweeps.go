@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sweepCategories are the kinds of output the sweeper handles, as the
+// category argument names them.
+var sweepCategories = []string{
+	"anchor", "htlc", "commitment", "justice", "wallet", "other",
+}
+
+// PendingSweepsTool returns the MCP tool definition for listing the outputs
+// the node's sweeper is waiting to sweep.
+func (s *OnChainService) PendingSweepsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_pending_sweeps",
+		Description: "List the outputs lnd's sweeper is waiting to " +
+			"sweep into the wallet, such as anchors, HTLCs and " +
+			"the outputs of force closed channels, soonest " +
+			"deadline first, with their fee rate, budget, " +
+			"broadcast attempts, and the height the sweeper " +
+			"next tries to broadcast them at. Raise a sweep's " +
+			"fee with lnc_bump_fee; finished sweeps are listed " +
+			"by lnc_list_sweeps",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"category": map[string]any{
+					"type": "string",
+					"description": "Only list outputs " +
+						"of this kind",
+					"enum": sweepCategories,
+				},
+			},
+		},
+	}
+}
+
+// HandlePendingSweeps handles the pending sweeps request.
+func (s *OnChainService) HandlePendingSweeps(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	category, _ := request.Params.Arguments["category"].(string)
+	if category != "" && !slices.Contains(sweepCategories, category) {
+		return invalidArgumentError("category must be one of " +
+			strings.Join(sweepCategories, ", ")), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	pending, err := walletKit.PendingSweeps(ctx,
+		&walletrpc.PendingSweepsRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to list pending sweeps"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	var totalAmount, totalBudget uint64
+	counts := make(map[string]int)
+	sweeps := make([]map[string]any, 0, len(pending.PendingSweeps))
+	for _, sweep := range pending.PendingSweeps {
+		kind := sweepCategory(sweep.WitnessType)
+		if category != "" && kind != category {
+			continue
+		}
+		entry := pendingSweepEntry(sweep, info.BlockHeight)
+		entry["category"] = kind
+
+		// The sweeper tries every output again with each new block,
+		// once the output's time lock allows spending it.
+		next := max(info.BlockHeight+1, sweep.MaturityHeight)
+		entry["next_broadcast_height"] = next
+		entry["mature"] = sweep.MaturityHeight <= info.BlockHeight
+		if sweep.MaturityHeight > info.BlockHeight {
+			entry["blocks_until_mature"] = sweep.MaturityHeight -
+				info.BlockHeight
+		}
+
+		sweeps = append(sweeps, entry)
+		counts[kind]++
+		totalAmount += uint64(sweep.AmountSat)
+		totalBudget += sweep.Budget
+	}
+
+	// Outputs without a deadline sort last.
+	sort.SliceStable(sweeps, func(i, j int) bool {
+		a, aok := sweeps[i]["deadline_height"].(uint32)
+		b, bok := sweeps[j]["deadline_height"].(uint32)
+		if aok != bok {
+			return aok
+		}
+		return a < b
+	})
+
+	return jsonResult("lnc_pending_sweeps", map[string]any{
+		"block_height":     info.BlockHeight,
+		"pending_sweeps":   sweeps,
+		"count":            len(sweeps),
+		"counts":           counts,
+		"total_amount_sat": totalAmount,
+		"total_budget_sat": totalBudget,
+	}), nil
+}
+
+// pendingSweepEntry formats an output the sweeper is handling, with the
+// blocks left until its deadline at the given height.
+func pendingSweepEntry(sweep *walletrpc.PendingSweep,
+	height uint32) map[string]any {
+	entry := map[string]any{
+		"outpoint":                formatOutPoint(sweep.Outpoint),
+		"witness_type":            sweep.WitnessType.String(),
+		"amount_sat":              sweep.AmountSat,
+		"sat_per_vbyte":           sweep.SatPerVbyte,
+		"requested_sat_per_vbyte": sweep.RequestedSatPerVbyte,
+		"broadcast_attempts":      sweep.BroadcastAttempts,
+		"immediate":               sweep.Immediate,
+		"budget_sat":              sweep.Budget,
+	}
+	if sweep.DeadlineHeight > 0 {
+		entry["deadline_height"] = sweep.DeadlineHeight
+		entry["blocks_to_deadline"] = int64(sweep.DeadlineHeight) -
+			int64(height)
+	}
+	if sweep.MaturityHeight > 0 {
+		entry["maturity_height"] = sweep.MaturityHeight
+	}
+
+	return entry
+}
+
+// sweepCategory returns the kind of output a witness type spends. Revoked
+// outputs are claimed as justice whatever they held.
+func sweepCategory(witness walletrpc.WitnessType) string {
+	name := witness.String()
+	switch {
+	case strings.Contains(name, "REVOKE"):
+		return "justice"
+	case strings.Contains(name, "ANCHOR"):
+		return "anchor"
+	case strings.Contains(name, "HTLC"):
+		return "htlc"
+	case strings.Contains(name, "COMMIT"):
+		return "commitment"
+	case witness == walletrpc.WitnessType_WITNESS_KEY_HASH,
+		witness == walletrpc.WitnessType_NESTED_WITNESS_KEY_HASH,
+		witness == walletrpc.WitnessType_TAPROOT_PUB_KEY_SPEND:
+		return "wallet"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CheckChannelPoliciesTool returns the MCP tool definition for finding
+// routing policies that do not fit their channels.
+func (s *ChannelService) CheckChannelPoliciesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_check_channel_policies",
+		Description: "Compare the routing policy this node " +
+			"advertises on each channel with what the channel " +
+			"can carry and what the peer accepts: a max HTLC " +
+			"above the capacity or the in-flight limit, a min " +
+			"HTLC below the peer's minimum, a disabled policy on " +
+			"an active channel. Such mismatches make forwards " +
+			"fail without any error on this node",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type": "string",
+					"description": "Check only this channel " +
+						"(txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+			},
+		},
+	}
+}
+
+// HandleCheckChannelPolicies handles the channel policy check request.
+func (s *ChannelService) HandleCheckChannelPolicies(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	channelPoint, _ := request.Params.Arguments["channel_point"].(string)
+	if channelPoint != "" {
+		txid, index, err := parseChannelPoint("channel_point",
+			channelPoint)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		channelPoint = fmt.Sprintf("%s:%d", txid, index)
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+
+	checked := 0
+	flagged := make(map[string]bool)
+	findings := make([]map[string]any, 0)
+	for _, ch := range list.Channels {
+		if channelPoint != "" && ch.ChannelPoint != channelPoint {
+			continue
+		}
+		checked++
+
+		edge, err := client.GetChanInfo(ctx, &lnrpc.ChanInfoRequest{
+			ChanId: ch.ChanId,
+		})
+		if err != nil {
+			return rpcError(err, "failed to get channel info"), nil
+		}
+		ours, theirs := edgePolicies(edge, info.IdentityPubkey)
+
+		channelFindings := checkChannelPolicy(ch, ours, theirs)
+		for _, finding := range channelFindings {
+			finding["channel_point"] = ch.ChannelPoint
+			finding["chan_id"] = strconv.FormatUint(ch.ChanId, 10)
+			finding["remote_pubkey"] = ch.RemotePubkey
+			findings = append(findings, finding)
+			flagged[ch.ChannelPoint] = true
+		}
+	}
+	if channelPoint != "" && checked == 0 {
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no open channel with channel point "+channelPoint)), nil
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank[findings[i]["severity"].(string)] <
+			severityRank[findings[j]["severity"].(string)]
+	})
+
+	return jsonResult("lnc_check_channel_policies", map[string]any{
+		"channels_checked":       checked,
+		"channels_with_findings": len(flagged),
+		"findings":               findings,
+		"finding_count":          len(findings),
+	}), nil
+}
+
+// checkChannelPolicy returns the ways the policies on a channel do not fit
+// it. ours governs the HTLCs this node forwards out through the channel,
+// and the local constraints are what the peer accepts of them; theirs, and
+// the remote constraints, govern HTLCs coming in.
+func checkChannelPolicy(ch *lnrpc.Channel, ours,
+	theirs *lnrpc.RoutingPolicy) []map[string]any {
+	findings := make([]map[string]any, 0)
+	add := func(check, severity, message string, values map[string]any) {
+		finding := map[string]any{
+			"check":    check,
+			"severity": severity,
+			"message":  message,
+		}
+		for key, value := range values {
+			finding[key] = value
+		}
+		findings = append(findings, finding)
+	}
+
+	if ours == nil {
+		add("policy_missing", severityHigh, "this node has not "+
+			"announced a policy for the channel, so nothing is "+
+			"routed out through it", nil)
+	} else {
+		checkOwnPolicy(ch, ours, add)
+	}
+
+	if theirs == nil {
+		add("peer_policy_missing", severityLow, "the peer has not "+
+			"announced a policy for the channel, so payments "+
+			"cannot be routed to this node through it", nil)
+	} else if constraints := ch.RemoteConstraints; constraints != nil {
+		peerMax := theirs.MaxHtlcMsat
+		maxInFlight := constraints.MaxPendingAmtMsat
+		if maxInFlight > 0 && peerMax > maxInFlight {
+			add("peer_max_htlc_above_in_flight", severityLow,
+				"the peer advertises a max HTLC above what "+
+					"this node lets it have in flight, so "+
+					"larger payments routed in through it "+
+					"fail",
+				map[string]any{
+					"peer_max_htlc_msat": peerMax,
+					"max_in_flight_msat": maxInFlight,
+				})
+		}
+	}
+
+	return findings
+}
+
+// checkOwnPolicy adds the findings for this node's policy on a channel.
+func checkOwnPolicy(ch *lnrpc.Channel, ours *lnrpc.RoutingPolicy,
+	add func(check, severity, message string, values map[string]any)) {
+	capacityMsat := uint64(ch.Capacity) * 1000
+	minHTLCMsat := uint64(ours.MinHtlc)
+
+	if ours.Disabled && ch.Active {
+		add("policy_disabled", severityHigh, "the channel is active "+
+			"but its policy is disabled, so peers route nothing "+
+			"through it", nil)
+	}
+
+	switch {
+	case ours.MaxHtlcMsat > capacityMsat:
+		add("max_htlc_above_capacity", severityHigh, "max HTLC is "+
+			"above the channel capacity, so pathfinding tries "+
+			"amounts the channel can never carry",
+			map[string]any{
+				"max_htlc_msat": ours.MaxHtlcMsat,
+				"capacity_msat": capacityMsat,
+			})
+
+	case ours.MaxHtlcMsat < minHTLCMsat:
+		add("max_htlc_below_min_htlc", severityHigh, "max HTLC is "+
+			"below min HTLC, so no amount can be forwarded",
+			map[string]any{
+				"max_htlc_msat": ours.MaxHtlcMsat,
+				"min_htlc_msat": minHTLCMsat,
+			})
+	}
+
+	constraints := ch.LocalConstraints
+	if constraints == nil {
+		return
+	}
+
+	maxInFlight := constraints.MaxPendingAmtMsat
+	if maxInFlight > 0 && ours.MaxHtlcMsat > maxInFlight {
+		add("max_htlc_above_in_flight", severityMedium, "max HTLC is "+
+			"above the amount the peer accepts in flight, so "+
+			"forwards between the two fail",
+			map[string]any{
+				"max_htlc_msat":      ours.MaxHtlcMsat,
+				"max_in_flight_msat": maxInFlight,
+			})
+	}
+
+	if minHTLCMsat < constraints.MinHtlcMsat {
+		add("min_htlc_below_peer_minimum", severityMedium, "min HTLC "+
+			"is below the smallest HTLC the peer accepts, so "+
+			"smaller forwards fail",
+			map[string]any{
+				"min_htlc_msat":      minHTLCMsat,
+				"peer_min_htlc_msat": constraints.MinHtlcMsat,
+			})
+	}
+
+	// This node can never send more than the capacity less its reserve
+	// and the commitment fee it pays.
+	sendable := ch.Capacity - int64(constraints.ChanReserveSat)
+	if ch.Initiator {
+		sendable -= ch.CommitFee
+	}
+	sendableMsat := uint64(max(sendable, 0)) * 1000
+	if ours.MaxHtlcMsat <= capacityMsat &&
+		ours.MaxHtlcMsat > sendableMsat {
+		add("max_htlc_above_sendable", severityLow, "max HTLC is "+
+			"above what the channel can ever send after the "+
+			"reserve and commitment fee",
+			map[string]any{
+				"max_htlc_msat": ours.MaxHtlcMsat,
+				"sendable_msat": sendableMsat,
+			})
+	}
+}
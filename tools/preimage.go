@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Preimage disclosure policies. A payment preimage is proof of payment, so
+// by default it is only returned to a caller that asks for it.
+const (
+	// PreimageNever never returns preimages.
+	PreimageNever = "never"
+
+	// PreimageOnRequest returns preimages to calls that set
+	// include_preimage.
+	PreimageOnRequest = "on_request"
+
+	// PreimageAlways returns preimages to every call.
+	PreimageAlways = "always"
+)
+
+// ValidPreimageDisclosure reports whether policy is a known preimage
+// disclosure policy. The empty policy is PreimageOnRequest.
+func ValidPreimageDisclosure(policy string) bool {
+	switch policy {
+	case "", PreimageNever, PreimageOnRequest, PreimageAlways:
+		return true
+	}
+	return false
+}
+
+// includePreimageProperty is the argument a payment tool's caller asks for
+// preimages with.
+func includePreimageProperty() map[string]any {
+	return map[string]any{
+		"type": "boolean",
+		"description": "Include the payment preimage, the proof of " +
+			"payment, in the result (default false; the server's " +
+			"policy may forbid it)",
+	}
+}
+
+// disclosePreimage reports whether a call's result includes payment
+// preimages under s.PreimageDisclosure. A call asking for them when the
+// policy is never gets the error result to reply with instead, before
+// anything is sent.
+func (s *PaymentService) disclosePreimage(
+	args map[string]any) (bool, *mcp.CallToolResult) {
+	requested, _ := args["include_preimage"].(bool)
+
+	switch s.PreimageDisclosure {
+	case PreimageAlways:
+		return true, nil
+	case PreimageNever:
+		if requested {
+			return false, toolError(errors.New(
+				errors.ErrCodePermissionDenied,
+				"payment preimages are never disclosed by this "+
+					"server; call again without include_preimage"))
+		}
+		return false, nil
+	}
+	return requested, nil
+}
+
+// withholdPreimage removes payment_preimage from result and from each of
+// its entries unless disclose is set, and names it in the result's
+// withheld_fields, as fields of withheld classes are.
+func withholdPreimage(disclose bool, result map[string]any,
+	entries ...map[string]any) {
+	if disclose {
+		return
+	}
+
+	withheld := false
+	for _, fields := range append([]map[string]any{result}, entries...) {
+		if _, ok := fields["payment_preimage"]; ok {
+			delete(fields, "payment_preimage")
+			withheld = true
+		}
+	}
+	if withheld {
+		result["withheld_fields"] = []string{"payment_preimage"}
+	}
+}
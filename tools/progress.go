@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// notifyProgress sends an MCP progress notification for the tool call in
+// ctx, so clients can show live status for long-running operations. It does
+// nothing unless the client supplied a progress token.
+func notifyProgress(ctx context.Context, progress float64, message string) {
+	token := lnccontext.GetProgressToken(ctx)
+	if token == nil {
+		return
+	}
+
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return
+	}
+
+	err := mcpServer.SendNotificationToClient(ctx, "notifications/progress",
+		map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		})
+	if err != nil {
+		logging.LogWithContext(ctx).Debug(
+			"Failed to send progress notification", zap.Error(err))
+	}
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// mailboxProxies holds the proxy each mailbox is dialed through, keyed by
+// its host:port. The mailbox library dials its WebSockets with the default
+// HTTP transport, so the proxy is chosen there, per request, by host.
+var mailboxProxies = struct {
+	sync.Mutex
+	byHost map[string]*url.URL
+}{byHost: make(map[string]*url.URL)}
+
+// installProxyOnce routes the default transport's requests through
+// mailboxProxy, the first time a mailbox proxy is set.
+var installProxyOnce sync.Once
+
+// parseProxyURL validates a mailbox proxy URL: socks5 or socks5h, as Tor
+// offers, or http or https for a corporate proxy, with a host and port.
+func parseProxyURL(value string) (*url.URL, error) {
+	proxy, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("proxy is not a valid URL: %w", err)
+	}
+
+	switch proxy.Scheme {
+	case "socks5", "socks5h", "http", "https":
+	default:
+		return nil, fmt.Errorf("proxy scheme must be socks5, socks5h, " +
+			"http or https")
+	}
+	if proxy.Hostname() == "" || proxy.Port() == "" {
+		return nil, fmt.Errorf("proxy must name a host and port, " +
+			"e.g. socks5h://127.0.0.1:9050")
+	}
+
+	return proxy, nil
+}
+
+// setMailboxProxy makes later dials to mailboxServer go through proxy, or
+// through the proxy the environment sets, if any, when proxy is nil. Dials
+// to other hosts are unaffected.
+func setMailboxProxy(mailboxServer string, proxy *url.URL) {
+	if proxy != nil {
+		installProxyOnce.Do(func() {
+			transport := http.DefaultTransport.(*http.Transport)
+			transport.Proxy = mailboxProxy(transport.Proxy)
+		})
+	}
+
+	mailboxProxies.Lock()
+	defer mailboxProxies.Unlock()
+
+	if proxy == nil {
+		delete(mailboxProxies.byHost, mailboxServer)
+		return
+	}
+	mailboxProxies.byHost[mailboxServer] = proxy
+}
+
+// mailboxProxy returns a transport proxy function that picks the proxy set
+// for the request's host, and otherwise defers to fallback.
+func mailboxProxy(fallback func(*http.Request) (*url.URL,
+	error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		mailboxProxies.Lock()
+		proxy := mailboxProxies.byHost[req.URL.Host]
+		mailboxProxies.Unlock()
+
+		if proxy != nil {
+			return proxy, nil
+		}
+		if fallback == nil {
+			return nil, nil
+		}
+		return fallback(req)
+	}
+}
@@ -0,0 +1,641 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultLeaseDuration is how long lnd leases the inputs of a funded PSBT
+// unless told otherwise.
+const defaultLeaseDuration = 10 * time.Minute
+
+// psbtStore remembers the transactions lnc_fund_psbt funded, until their
+// inputs are no longer leased. lnc_finalize_psbt only signs those, so every
+// output a signed transaction pays was checked against the withdrawal policy
+// when it was funded.
+type psbtStore struct {
+	mu     sync.Mutex
+	funded map[chainhash.Hash]fundedPsbt
+}
+
+// fundedPsbt is what the PSBT store keeps of a funded transaction.
+type fundedPsbt struct {
+	expiresAt time.Time
+
+	// spendSat is what the transaction spends: its outputs other than
+	// change, and its fee.
+	spendSat int64
+}
+
+// newPsbtStore creates an empty PSBT store.
+func newPsbtStore() *psbtStore {
+	return &psbtStore{funded: make(map[chainhash.Hash]fundedPsbt)}
+}
+
+// record remembers a funded transaction spending spendSat until expiresAt.
+func (p *psbtStore) record(txid chainhash.Hash, expiresAt time.Time,
+	spendSat int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked()
+	p.funded[txid] = fundedPsbt{expiresAt: expiresAt, spendSat: spendSat}
+}
+
+// lookup returns what txid spends, if it was funded and its lease has not
+// expired.
+func (p *psbtStore) lookup(txid chainhash.Hash) (int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked()
+	funded, ok := p.funded[txid]
+	return funded.spendSat, ok
+}
+
+// forget drops a funded transaction.
+func (p *psbtStore) forget(txid chainhash.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.funded, txid)
+}
+
+// pruneLocked drops expired transactions. The caller must hold the lock.
+func (p *psbtStore) pruneLocked() {
+	now := time.Now()
+	for txid, funded := range p.funded {
+		if !now.Before(funded.expiresAt) {
+			delete(p.funded, txid)
+		}
+	}
+}
+
+// FundPsbtTool returns the MCP tool definition for funding a PSBT.
+func (s *OnChainService) FundPsbtTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_fund_psbt",
+		Description: "Fund a transaction from the on-chain wallet " +
+			"without signing or broadcasting it: the wallet adds " +
+			"inputs and change and leases the inputs so nothing " +
+			"else spends them. Give either outputs, optionally " +
+			"with inputs, or a PSBT template. Every output must " +
+			"be on the withdrawal allowlist. Sign the result " +
+			"externally or with lnc_finalize_psbt, or free its " +
+			"inputs with lnc_release_output",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"outputs": map[string]any{
+					"type":        "array",
+					"description": "Outputs to pay",
+					"minItems":    1,
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"address": map[string]any{
+								"type": "string",
+							},
+							"amount_sat": map[string]any{
+								"type":    "number",
+								"minimum": 1,
+							},
+						},
+						"required": []string{
+							"address", "amount_sat",
+						},
+					},
+				},
+				"inputs": map[string]any{
+					"type": "array",
+					"description": "Wallet outputs to spend " +
+						"(txid:output_index); by default " +
+						"the wallet selects them",
+					"items": map[string]any{
+						"type":    "string",
+						"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+					},
+				},
+				"psbt": map[string]any{
+					"type": "string",
+					"description": "Base64 PSBT template to " +
+						"fund instead of outputs",
+				},
+				"target_conf": map[string]any{
+					"type": "number",
+					"description": "Confirmation target used " +
+						"to pick the fee rate (default 6)",
+					"minimum": 1,
+					"maximum": 1008,
+				},
+				"sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Explicit fee rate; cannot " +
+						"be combined with target_conf",
+					"minimum": 1,
+				},
+				"min_confs": map[string]any{
+					"type": "number",
+					"description": "Confirmations required of " +
+						"the inputs the wallet selects; 0 " +
+						"spends unconfirmed outputs (default 1)",
+					"minimum": 0,
+				},
+			},
+		},
+	}
+}
+
+// HandleFundPsbt handles the fund PSBT request. The outputs are checked
+// against the withdrawal policy before the wallet is asked to fund them.
+func (s *OnChainService) HandleFundPsbt(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+
+	targetConf, _ := args["target_conf"].(float64)
+	satPerVbyte, _ := args["sat_per_vbyte"].(float64)
+	if targetConf > 0 && satPerVbyte > 0 {
+		return invalidArgumentError("set either target_conf or " +
+			"sat_per_vbyte, not both"), nil
+	}
+	feeRate, failure := checkFeeRate(ctx, s.Clients, s.Policy,
+		uint64(satPerVbyte), int32(targetConf))
+	if failure != nil {
+		return failure, nil
+	}
+	minConfs := float64(1)
+	if value, ok := args["min_confs"].(float64); ok {
+		minConfs = value
+	}
+	if minConfs < 0 {
+		return invalidArgumentError("min_confs must not be negative"), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	params, ok := networkParams(nodeNetwork(info))
+	if !ok {
+		return toolError(errors.New(errors.ErrCodeUnsupported,
+			"the node is on an unknown network")), nil
+	}
+
+	req := &walletrpc.FundPsbtRequest{
+		MinConfs:         int32(minConfs),
+		SpendUnconfirmed: minConfs == 0,
+	}
+	switch {
+	case feeRate > 0:
+		req.Fees = &walletrpc.FundPsbtRequest_SatPerVbyte{
+			SatPerVbyte: feeRate,
+		}
+	case targetConf > 0:
+		req.Fees = &walletrpc.FundPsbtRequest_TargetConf{
+			TargetConf: uint32(targetConf),
+		}
+	default:
+		req.Fees = &walletrpc.FundPsbtRequest_TargetConf{
+			TargetConf: defaultSendTargetConf,
+		}
+	}
+
+	var (
+		addresses []string
+		outputSat int64
+	)
+	template, _ := args["psbt"].(string)
+	outputs, hasOutputs := args["outputs"]
+	inputs, hasInputs := args["inputs"]
+	switch {
+	case template != "" && (hasOutputs || hasInputs):
+		return invalidArgumentError("give either psbt or outputs " +
+			"and inputs, not both"), nil
+
+	case template != "":
+		packet, raw, err := decodePsbt(template)
+		if err != nil {
+			return invalidArgumentError("psbt is not a valid " +
+				"base64 PSBT"), nil
+		}
+		addresses, err = psbtOutputAddresses(packet, params)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		for _, out := range packet.UnsignedTx.TxOut {
+			outputSat += out.Value
+		}
+		req.Template = &walletrpc.FundPsbtRequest_Psbt{Psbt: raw}
+
+	case hasOutputs:
+		tx, err := parsePsbtTemplate(outputs, inputs)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		for address, amount := range tx.Outputs {
+			addresses = append(addresses, address)
+			outputSat += int64(amount)
+		}
+		req.Template = &walletrpc.FundPsbtRequest_Raw{Raw: tx}
+
+	default:
+		return invalidArgumentError("outputs or psbt is required"), nil
+	}
+
+	if failure := s.checkPsbtOutputs(addresses); failure != nil {
+		return failure, nil
+	}
+	if err := s.Policy.CheckSpend(outputSat); err != nil {
+		return policyError(err), nil
+	}
+
+	resp, err := walletKit.FundPsbt(ctx, req)
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to fund PSBT"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(resp.FundedPsbt),
+		false)
+	if err != nil {
+		return toolError(errors.Wrap(err, errors.ErrCodeRPCFailed,
+			"invalid funded PSBT from node")), nil
+	}
+	txid := packet.UnsignedTx.TxHash()
+
+	expiresAt := time.Now().Add(defaultLeaseDuration)
+	leases := make([]map[string]any, len(resp.LockedUtxos))
+	for i, lease := range resp.LockedUtxos {
+		leaseExpiry := time.Unix(int64(lease.Expiration), 0)
+		if i == 0 || leaseExpiry.Before(expiresAt) {
+			expiresAt = leaseExpiry
+		}
+		leases[i] = map[string]any{
+			"outpoint":   formatOutPoint(lease.Outpoint),
+			"lock_id":    hex.EncodeToString(lease.Id),
+			"amount_sat": lease.Value,
+			"expires_at": leaseExpiry.UTC().Format(time.RFC3339),
+		}
+	}
+
+	// The transaction spends what it pays other than change, and its
+	// fee. It is recorded against the spend caps once it is signed.
+	fee, feeKnown := psbtFee(packet)
+	spendSat := fee
+	for i, out := range packet.UnsignedTx.TxOut {
+		if int32(i) != resp.ChangeOutputIndex {
+			spendSat += out.Value
+		}
+	}
+	s.psbts.record(txid, expiresAt, spendSat)
+
+	result := map[string]any{
+		"psbt":          base64.StdEncoding.EncodeToString(resp.FundedPsbt),
+		"txid":          txid.String(),
+		"input_count":   len(packet.UnsignedTx.TxIn),
+		"output_count":  len(packet.UnsignedTx.TxOut),
+		"locked_inputs": leases,
+		"expires_at":    expiresAt.UTC().Format(time.RFC3339),
+		"note": "Nothing is signed or broadcast. The inputs stay " +
+			"leased until expires_at unless released with " +
+			"lnc_release_output",
+	}
+	if resp.ChangeOutputIndex >= 0 {
+		result["change_output_index"] = resp.ChangeOutputIndex
+	}
+	if feeKnown {
+		result["fee_sat"] = fee
+	}
+
+	return jsonResult("lnc_fund_psbt", result), nil
+}
+
+// checkPsbtOutputs checks the addresses a PSBT pays against the withdrawal
+// policy. Change the wallet adds while funding is not among them.
+func (s *OnChainService) checkPsbtOutputs(
+	addresses []string) *mcp.CallToolResult {
+	if s.Policy == nil {
+		return toolError(errors.ErrPermissionDenied(
+			"on-chain withdrawals are disabled"))
+	}
+	for _, address := range addresses {
+		if err := s.Policy.CheckWithdrawal(address); err != nil {
+			return policyError(err)
+		}
+	}
+	return nil
+}
+
+// parsePsbtTemplate builds a funding template from the outputs and inputs
+// arguments.
+func parsePsbtTemplate(outputs, inputs any) (*walletrpc.TxTemplate, error) {
+	items, _ := outputs.([]any)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("outputs must list at least one output")
+	}
+
+	tx := &walletrpc.TxTemplate{
+		Outputs: make(map[string]uint64, len(items)),
+	}
+	for i, item := range items {
+		fields, _ := item.(map[string]any)
+		address, _ := fields["address"].(string)
+		address = strings.TrimSpace(address)
+		if _, _, err := decodeAddress(address); err != nil {
+			return nil, fmt.Errorf("outputs[%d].address is not a "+
+				"valid address", i)
+		}
+		if _, ok := tx.Outputs[address]; ok {
+			return nil, fmt.Errorf("outputs[%d] pays %s again; "+
+				"combine the amounts", i, address)
+		}
+		amount, _ := fields["amount_sat"].(float64)
+		if amount < 1 {
+			return nil, fmt.Errorf("outputs[%d].amount_sat must "+
+				"be at least 1", i)
+		}
+		tx.Outputs[address] = uint64(amount)
+	}
+
+	points, _ := inputs.([]any)
+	for i, point := range points {
+		value, _ := point.(string)
+		txid, index, err := parseChannelPoint(
+			fmt.Sprintf("inputs[%d]", i), value)
+		if err != nil {
+			return nil, err
+		}
+		tx.Inputs = append(tx.Inputs, &lnrpc.OutPoint{
+			TxidStr:     txid,
+			OutputIndex: index,
+		})
+	}
+
+	return tx, nil
+}
+
+// FinalizePsbtTool returns the MCP tool definition for signing a funded PSBT.
+func (s *OnChainService) FinalizePsbtTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_finalize_psbt",
+		Description: "Sign the wallet's inputs of a PSBT funded with " +
+			"lnc_fund_psbt, including any external signatures " +
+			"already added, and return the final transaction. " +
+			"The transaction is not broadcast",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"psbt": map[string]any{
+					"type": "string",
+					"description": "Base64 PSBT returned by " +
+						"lnc_fund_psbt, with any " +
+						"external signatures",
+				},
+			},
+			Required: []string{"psbt"},
+		},
+	}
+}
+
+// HandleFinalizePsbt handles the finalize PSBT request. Only transactions
+// funded by lnc_fund_psbt, whose outputs were checked then, are signed. A
+// signed transaction can be broadcast by anyone holding it, so what it
+// spends is recorded against the spend caps before it is signed.
+func (s *OnChainService) HandleFinalizePsbt(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	walletKit, generation := s.Clients.WalletKit()
+	if walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	encoded, _ := request.Params.Arguments["psbt"].(string)
+	packet, raw, err := decodePsbt(encoded)
+	if err != nil {
+		return invalidArgumentError("psbt is not a valid base64 " +
+			"PSBT"), nil
+	}
+	txid := packet.UnsignedTx.TxHash()
+	spendSat, ok := s.psbts.lookup(txid)
+	if !ok {
+		return toolError(errors.New(errors.ErrCodePermissionDenied,
+			"only PSBTs funded with lnc_fund_psbt whose inputs "+
+				"are still leased are signed; fund the "+
+				"transaction again").WithDetails(map[string]any{
+			"txid": txid.String(),
+		})), nil
+	}
+
+	spendID := "psbt:" + txid.String()
+	err = s.Policy.ReserveSpend(spendID, "lnc_finalize_psbt", spendSat)
+	if err != nil {
+		return policyError(err), nil
+	}
+
+	resp, err := walletKit.FinalizePsbt(ctx, &walletrpc.FinalizePsbtRequest{
+		FundedPsbt: raw,
+	})
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to finalize PSBT"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+	s.psbts.forget(txid)
+
+	result := map[string]any{
+		"txid":        txid.String(),
+		"signed_psbt": base64.StdEncoding.EncodeToString(resp.SignedPsbt),
+		"raw_tx":      hex.EncodeToString(resp.RawFinalTx),
+		"note": "The transaction is signed but not broadcast; " +
+			"publish raw_tx to send it",
+	}
+	if fee, ok := psbtFee(packet); ok {
+		result["fee_sat"] = fee
+	}
+
+	return jsonResult("lnc_finalize_psbt", result), nil
+}
+
+// ReleaseOutputTool returns the MCP tool definition for releasing a leased
+// output.
+func (s *OnChainService) ReleaseOutputTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_release_output",
+		Description: "Release a wallet output leased by lnc_fund_psbt " +
+			"or another funding flow, so the wallet may spend it " +
+			"again. Releasing an input of a transaction that is " +
+			"still to be broadcast lets the wallet double spend it",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"outpoint": map[string]any{
+					"type": "string",
+					"description": "Leased output " +
+						"(txid:output_index)",
+					"pattern": "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"lock_id": map[string]any{
+					"type": "string",
+					"description": "Hex ID of the lease; " +
+						"looked up when omitted",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+			},
+			Required: []string{"outpoint"},
+		},
+	}
+}
+
+// HandleReleaseOutput handles the release output request.
+func (s *OnChainService) HandleReleaseOutput(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	walletKit, generation := s.Clients.WalletKit()
+	if walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	value, _ := args["outpoint"].(string)
+	txid, index, err := parseChannelPoint("outpoint", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	outpoint := fmt.Sprintf("%s:%d", txid, index)
+
+	var lockID []byte
+	if value, _ := args["lock_id"].(string); value != "" {
+		lockID, err = parseHash("lock_id", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+	} else {
+		leases, err := walletKit.ListLeases(ctx,
+			&walletrpc.ListLeasesRequest{})
+		if err != nil {
+			return subserverError(s.Clients, generation,
+				"walletkit", err, "failed to list leases"), nil
+		}
+		for _, lease := range leases.LockedUtxos {
+			if formatOutPoint(lease.Outpoint) == outpoint {
+				lockID = lease.Id
+				break
+			}
+		}
+		if lockID == nil {
+			return toolError(errors.New(errors.ErrCodeNotFound,
+				"the output is not leased").WithDetails(
+				map[string]any{"outpoint": outpoint})), nil
+		}
+	}
+
+	resp, err := walletKit.ReleaseOutput(ctx, &walletrpc.ReleaseOutputRequest{
+		Id: lockID,
+		Outpoint: &lnrpc.OutPoint{
+			TxidStr:     txid,
+			OutputIndex: index,
+		},
+	})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to release output"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	return jsonResult("lnc_release_output", map[string]any{
+		"outpoint": outpoint,
+		"lock_id":  hex.EncodeToString(lockID),
+		"released": true,
+		"status":   resp.Status,
+	}), nil
+}
+
+// decodePsbt decodes a base64 PSBT argument, returning the packet and its
+// serialized bytes.
+func decodePsbt(encoded string) (*psbt.Packet, []byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, nil, err
+	}
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return packet, raw, nil
+}
+
+// psbtOutputAddresses returns the address each output of a PSBT pays.
+// Outputs without a standard address cannot be checked against the
+// withdrawal policy, so they are rejected.
+func psbtOutputAddresses(packet *psbt.Packet,
+	params *chaincfg.Params) ([]string, error) {
+	addresses := make([]string, 0, len(packet.UnsignedTx.TxOut))
+	for i, out := range packet.UnsignedTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript,
+			params)
+		if err != nil || len(addrs) != 1 {
+			return nil, fmt.Errorf("output %d of the psbt does "+
+				"not pay a standard address", i)
+		}
+		addresses = append(addresses, addrs[0].EncodeAddress())
+	}
+	return addresses, nil
+}
+
+// psbtFee returns the fee a PSBT pays, if the value of every input is
+// known.
+func psbtFee(packet *psbt.Packet) (int64, bool) {
+	var in int64
+	for i, input := range packet.Inputs {
+		switch {
+		case input.WitnessUtxo != nil:
+			in += input.WitnessUtxo.Value
+
+		case input.NonWitnessUtxo != nil:
+			prev := packet.UnsignedTx.TxIn[i].PreviousOutPoint
+			if int(prev.Index) >= len(input.NonWitnessUtxo.TxOut) {
+				return 0, false
+			}
+			in += input.NonWitnessUtxo.TxOut[prev.Index].Value
+
+		default:
+			return 0, false
+		}
+	}
+
+	var out int64
+	for _, output := range packet.UnsignedTx.TxOut {
+		out += output.Value
+	}
+	return in - out, len(packet.Inputs) > 0
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/status"
+)
+
+// noRouteReasons are the messages lnd's pathfinding fails with when there
+// is no usable route, as opposed to a malformed query or a node error.
+var noRouteReasons = []string{
+	"unable to find a path to destination",
+	"insufficient local balance",
+	"destination hop doesn't understand",
+	"unknown required feature",
+	"missing dependent feature",
+}
+
+// ignoredEdgeSchema is the input schema of one ignored_edges entry.
+var ignoredEdgeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"chan_id":           map[string]any{"type": "string"},
+		"direction_reverse": map[string]any{"type": "boolean"},
+	},
+	"required": []string{"chan_id"},
+}
+
+// QueryRoutesTool returns the MCP tool definition for finding a route
+// without paying.
+func (s *PaymentService) QueryRoutesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_query_routes",
+		Description: "Find a route for a payment to a node without " +
+			"sending anything, to check whether a payment is " +
+			"feasible and what it would cost in fees and time " +
+			"lock. Nodes and channels can be excluded and the " +
+			"first and last hops pinned",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"pub_key": map[string]any{
+					"type":        "string",
+					"description": "Destination node public key (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{66}$",
+				},
+				"amount_sat": map[string]any{
+					"type":        "number",
+					"description": "Amount to deliver, in satoshis",
+					"minimum":     1,
+				},
+				"fee_limit_sat": map[string]any{
+					"type": "number",
+					"description": "Maximum routing fee in " +
+						"satoshis (default 1% of the amount, " +
+						"at least 10 sat)",
+					"minimum": 0,
+				},
+				"final_cltv_delta": map[string]any{
+					"type": "number",
+					"description": "CLTV delta for the final " +
+						"hop (default lnd's, 80 blocks)",
+					"minimum": 1,
+				},
+				"ignored_nodes": map[string]any{
+					"type": "array",
+					"description": "Public keys of nodes the " +
+						"route must not pass through",
+					"items": map[string]any{
+						"type":    "string",
+						"pattern": "^[0-9a-fA-F]{66}$",
+					},
+				},
+				"ignored_edges": map[string]any{
+					"type": "array",
+					"description": "Channels the route must " +
+						"not use, each in one direction: " +
+						"from node1 to node2 of the " +
+						"channel, or the reverse",
+					"items": ignoredEdgeSchema,
+				},
+				"outgoing_chan_id": map[string]any{
+					"type": "string",
+					"description": "Channel of this node the " +
+						"route must leave through",
+				},
+				"last_hop_pubkey": map[string]any{
+					"type": "string",
+					"description": "Node the route must " +
+						"reach the destination through",
+					"pattern": "^[0-9a-fA-F]{66}$",
+				},
+				"use_mission_control": map[string]any{
+					"type": "boolean",
+					"description": "Weigh channels by the " +
+						"outcome of earlier payments, as a " +
+						"payment would (default true)",
+				},
+			},
+			Required: []string{"pub_key", "amount_sat"},
+		},
+	}
+}
+
+// HandleQueryRoutes handles the query routes request. A query that finds
+// no route is a result, not an error: it answers whether the payment is
+// feasible.
+func (s *PaymentService) HandleQueryRoutes(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	req, err := queryRoutesRequest(request.Params.Arguments)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	result := map[string]any{
+		"destination":   req.PubKey,
+		"amount_sat":    req.Amt,
+		"fee_limit_sat": req.FeeLimit.GetFixed(),
+	}
+
+	resp, err := client.QueryRoutes(ctx, req)
+	if err != nil {
+		reason := status.Convert(err).Message()
+		if !isNoRouteReason(reason) {
+			return rpcError(err, "failed to query routes"), nil
+		}
+
+		result["feasible"] = false
+		result["reason"] = reason
+		return jsonResult("lnc_query_routes", result), nil
+	}
+
+	result["feasible"] = len(resp.Routes) > 0
+	result["success_prob"] = resp.SuccessProb
+	if len(resp.Routes) > 0 {
+		result["route"] = queriedRoute(resp.Routes[0])
+	}
+
+	return jsonResult("lnc_query_routes", result), nil
+}
+
+// queryRoutesRequest builds the QueryRoutes request from the tool's
+// arguments.
+func queryRoutesRequest(args map[string]any) (*lnrpc.QueryRoutesRequest,
+	error) {
+	pubKey, _ := args["pub_key"].(string)
+	if _, err := parsePubkey("pub_key", pubKey); err != nil {
+		return nil, err
+	}
+	amountSat, _ := args["amount_sat"].(float64)
+	if amountSat < 1 {
+		return nil, fmt.Errorf("amount_sat must be at least 1")
+	}
+
+	useMissionControl := true
+	if use, ok := args["use_mission_control"].(bool); ok {
+		useMissionControl = use
+	}
+	finalCltvDelta, _ := args["final_cltv_delta"].(float64)
+
+	req := &lnrpc.QueryRoutesRequest{
+		PubKey:         strings.ToLower(pubKey),
+		Amt:            int64(amountSat),
+		FinalCltvDelta: int32(finalCltvDelta),
+		FeeLimit: &lnrpc.FeeLimit{
+			Limit: &lnrpc.FeeLimit_Fixed{
+				Fixed: paymentFeeLimit(args, int64(amountSat)),
+			},
+		},
+		UseMissionControl: useMissionControl,
+	}
+
+	nodes, _ := args["ignored_nodes"].([]any)
+	for i, node := range nodes {
+		value, _ := node.(string)
+		pubkey, err := parsePubkey(fmt.Sprintf("ignored_nodes[%d]", i),
+			value)
+		if err != nil {
+			return nil, err
+		}
+		req.IgnoredNodes = append(req.IgnoredNodes, pubkey)
+	}
+
+	edges, _ := args["ignored_edges"].([]any)
+	for i, edge := range edges {
+		name := fmt.Sprintf("ignored_edges[%d]", i)
+		fields, ok := edge.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object", name)
+		}
+		value, _ := fields["chan_id"].(string)
+		chanID, err := parseChanID(name+".chan_id", value)
+		if err != nil {
+			return nil, err
+		}
+		reverse, _ := fields["direction_reverse"].(bool)
+
+		// lnd still honours ignored edges, and a channel ID is what
+		// a caller holds; ignored pairs would need both node keys.
+		req.IgnoredEdges = append(req.IgnoredEdges,
+			&lnrpc.EdgeLocator{
+				ChannelId:        chanID,
+				DirectionReverse: reverse,
+			})
+	}
+
+	if value, ok := args["outgoing_chan_id"].(string); ok {
+		chanID, err := parseChanID("outgoing_chan_id", value)
+		if err != nil {
+			return nil, err
+		}
+		req.OutgoingChanId = chanID
+	}
+	if value, ok := args["last_hop_pubkey"].(string); ok {
+		pubkey, err := parsePubkey("last_hop_pubkey", value)
+		if err != nil {
+			return nil, err
+		}
+		req.LastHopPubkey = pubkey
+	}
+
+	return req, nil
+}
+
+// isNoRouteReason reports whether an error message is lnd's pathfinding
+// finding no usable route.
+func isNoRouteReason(reason string) bool {
+	for _, noRoute := range noRouteReasons {
+		if strings.Contains(reason, noRoute) {
+			return true
+		}
+	}
+	return false
+}
+
+// queriedRoute formats a route found by QueryRoutes. Channel IDs are
+// strings, as lnc_send_to_route takes them.
+func queriedRoute(route *lnrpc.Route) map[string]any {
+	hops := make([]map[string]any, len(route.Hops))
+	for i, hop := range route.Hops {
+		chanID := strconv.FormatUint(hop.ChanId, 10)
+		hops[i] = map[string]any{
+			"chan_id":             chanID,
+			"pub_key":             hop.PubKey,
+			"amt_to_forward_msat": hop.AmtToForwardMsat,
+			"fee_msat":            hop.FeeMsat,
+			"expiry":              hop.Expiry,
+		}
+	}
+
+	return map[string]any{
+		"total_time_lock": route.TotalTimeLock,
+		"total_fees_msat": route.TotalFeesMsat,
+		"total_amt_msat":  route.TotalAmtMsat,
+		"hop_count":       len(hops),
+		"hops":            hops,
+	}
+}
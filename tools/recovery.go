@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// recoveryPollInterval is how often watch_seconds polls the node.
+	recoveryPollInterval = 10 * time.Second
+
+	// maxRecoveryWatch bounds how long one call watches a scan.
+	maxRecoveryWatch = 10 * time.Minute
+
+	// recoveryRateWindow is how far back the scan rate is measured, and
+	// minRecoveryRateSpan the shortest span it is measured over.
+	recoveryRateWindow  = 6 * time.Hour
+	minRecoveryRateSpan = time.Minute
+
+	// maxRecoverySnapshots caps the snapshots kept.
+	maxRecoverySnapshots = 256
+)
+
+// recoverySnapshot is the scan progress seen at one moment.
+type recoverySnapshot struct {
+	at       time.Time
+	progress float64
+}
+
+// recoverySnapshots keeps the recovery progress seen by earlier calls, so
+// the scan rate, and from it the time remaining, can be estimated. The zero
+// value is ready to use.
+type recoverySnapshots struct {
+	mu        sync.Mutex
+	snapshots []recoverySnapshot
+}
+
+// record adds the progress seen at a moment. Progress going backwards means
+// a new scan started, so the earlier snapshots are dropped.
+func (r *recoverySnapshots) record(at time.Time, progress float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := len(r.snapshots); n > 0 &&
+		progress < r.snapshots[n-1].progress {
+		r.snapshots = nil
+	}
+
+	cutoff := at.Add(-recoveryRateWindow)
+	kept := r.snapshots[:0]
+	for _, snapshot := range r.snapshots {
+		if snapshot.at.After(cutoff) {
+			kept = append(kept, snapshot)
+		}
+	}
+	r.snapshots = append(kept, recoverySnapshot{at, progress})
+	if len(r.snapshots) > maxRecoverySnapshots {
+		r.snapshots = r.snapshots[len(r.snapshots)-
+			maxRecoverySnapshots:]
+	}
+}
+
+// estimate returns the scan rate, as progress per hour, over the snapshots
+// in the window, and the time left at that rate. It reports false until
+// the snapshots span long enough and show the scan advancing.
+func (r *recoverySnapshots) estimate() (float64, time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.snapshots) < 2 {
+		return 0, 0, false
+	}
+	first, last := r.snapshots[0], r.snapshots[len(r.snapshots)-1]
+	span := last.at.Sub(first.at)
+	advanced := last.progress - first.progress
+	if span < minRecoveryRateSpan || advanced <= 0 {
+		return 0, 0, false
+	}
+
+	perHour := advanced / span.Hours()
+	remaining := time.Duration((1 - last.progress) / perHour *
+		float64(time.Hour))
+	return perHour, remaining, true
+}
+
+// GetRecoveryInfoTool returns the MCP tool definition for following a
+// wallet recovery scan.
+func (s *NodeService) GetRecoveryInfoTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_recovery_info",
+		Description: "Report whether the wallet is rescanning the " +
+			"chain to recover funds, how far the scan has got, " +
+			"and an estimate of the time remaining from the scan " +
+			"rate seen across calls. Set watch_seconds to follow " +
+			"the scan, with a progress notification per poll",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"watch_seconds": map[string]any{
+					"type": "number",
+					"description": "Keep polling the scan for " +
+						"up to this many seconds, or until " +
+						"it finishes (default 0)",
+					"minimum": 0,
+					"maximum": maxRecoveryWatch.Seconds(),
+				},
+			},
+		},
+	}
+}
+
+// HandleGetRecoveryInfo handles the recovery info request.
+func (s *NodeService) HandleGetRecoveryInfo(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	watch, _ := request.Params.Arguments["watch_seconds"].(float64)
+	if watch < 0 || watch > maxRecoveryWatch.Seconds() {
+		return invalidArgumentError(fmt.Sprintf("watch_seconds must "+
+			"be between 0 and %.0f",
+			maxRecoveryWatch.Seconds())), nil
+	}
+	deadline := time.Now().Add(time.Duration(watch * float64(time.Second)))
+
+	for {
+		info, err := client.GetRecoveryInfo(ctx,
+			&lnrpc.GetRecoveryInfoRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get recovery info"), nil
+		}
+
+		result := s.recoveryResult(info)
+		scanning := info.RecoveryMode && !info.RecoveryFinished
+		if scanning {
+			percent := result["progress_percent"].(float64)
+			notifyProgress(ctx, percent, recoveryMessage(result))
+		}
+
+		if !scanning || !time.Now().Add(recoveryPollInterval).
+			Before(deadline) {
+			return jsonResult("lnc_get_recovery_info", result), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return jsonResult("lnc_get_recovery_info", result), nil
+		case <-time.After(recoveryPollInterval):
+		}
+	}
+}
+
+// recoveryResult records a snapshot of a scan in progress and reports it
+// with the estimate the snapshots so far allow.
+func (s *NodeService) recoveryResult(
+	info *lnrpc.GetRecoveryInfoResponse) map[string]any {
+	result := map[string]any{
+		"recovery_mode":     info.RecoveryMode,
+		"recovery_finished": info.RecoveryFinished,
+		"progress":          info.Progress,
+		"progress_percent":  math.Round(info.Progress*1000) / 10,
+	}
+
+	switch {
+	case !info.RecoveryMode:
+		result["note"] = "The wallet is not in recovery mode, so no " +
+			"scan is running"
+		return result
+
+	case info.RecoveryFinished:
+		result["note"] = "The recovery scan has finished"
+		return result
+	}
+
+	now := time.Now()
+	s.recovery.record(now, info.Progress)
+	perHour, remaining, ok := s.recovery.estimate()
+	if !ok {
+		result["note"] = "The time remaining is estimated from the " +
+			"scan rate between calls; call again in a few " +
+			"minutes, or set watch_seconds"
+		return result
+	}
+
+	result["scan_rate_percent_per_hour"] = math.Round(perHour*1000) / 10
+	result["estimated_remaining_seconds"] = int64(remaining.Seconds())
+	result["estimated_finish_at"] = now.Add(remaining).UTC().
+		Format(time.RFC3339)
+	return result
+}
+
+// recoveryMessage summarises a recovery result for a progress
+// notification.
+func recoveryMessage(result map[string]any) string {
+	message := fmt.Sprintf("Recovery scan %.1f%% complete",
+		result["progress_percent"])
+	if remaining, ok := result["estimated_remaining_seconds"].(int64); ok {
+		message += fmt.Sprintf(", about %s remaining",
+			(time.Duration(remaining) * time.Second).String())
+	}
+	return message
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"math"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// anchorReservePerChannel and maxAnchorReserve are lnd's wallet
+	// reserve for fee bumping anchor channels: an amount for each public
+	// anchor channel, capped at ten channels' worth.
+	anchorReservePerChannel = 10_000
+	maxAnchorReserve        = 10 * anchorReservePerChannel
+
+	// maxAdditionalChannels bounds the channels a reserve projection
+	// may add.
+	maxAdditionalChannels = 1_000
+)
+
+// RequiredReserveTool returns the MCP tool definition for showing the
+// wallet's anchor channel reserve.
+func (s *OnChainService) RequiredReserveTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_required_reserve",
+		Description: "Show the on-chain balance lnd keeps in " +
+			"reserve to fee bump force closes of anchor " +
+			"channels, and how much of the confirmed balance " +
+			"can be spent above it. lnd reserves 10,000 sat " +
+			"for each public anchor channel, open, pending or " +
+			"waiting to close, up to 100,000 sat, and refuses " +
+			"sends and channel opens that would dip below it. " +
+			"additional_public_channels projects the reserve " +
+			"after opening more channels",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"additional_public_channels": map[string]any{
+					"type": "number",
+					"description": "Public anchor " +
+						"channels about to be opened",
+					"minimum": 0,
+					"maximum": maxAdditionalChannels,
+				},
+			},
+		},
+	}
+}
+
+// HandleRequiredReserve handles the required reserve request.
+func (s *OnChainService) HandleRequiredReserve(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	walletKit, generation := s.Clients.WalletKit()
+	if client == nil || walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	additional, _ := args["additional_public_channels"].(float64)
+	if additional < 0 || additional > maxAdditionalChannels ||
+		additional != math.Trunc(additional) {
+		return invalidArgumentError("additional_public_channels must " +
+			"be a whole number between 0 and 1000"), nil
+	}
+
+	current, err := walletKit.RequiredReserve(ctx,
+		&walletrpc.RequiredReserveRequest{})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to get required reserve"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	balance, err := client.WalletBalance(ctx,
+		&lnrpc.WalletBalanceRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get wallet balance"), nil
+	}
+	channels, err := client.ListChannels(ctx,
+		&lnrpc.ListChannelsRequest{PublicOnly: true})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+	anchorChannels := 0
+	for _, channel := range channels.Channels {
+		if anchorCommitment(channel.CommitmentType) {
+			anchorChannels++
+		}
+	}
+
+	required := current.RequiredReserve
+	confirmed := balance.ConfirmedBalance
+	reserved := balance.ReservedBalanceAnchorChan
+	result := map[string]any{
+		"required_reserve_sat":        required,
+		"reserve_per_channel_sat":     anchorReservePerChannel,
+		"max_reserve_sat":             maxAnchorReserve,
+		"capped":                      required >= maxAnchorReserve,
+		"open_public_anchor_channels": anchorChannels,
+		"confirmed_balance_sat":       confirmed,
+		"anchor_reserve_sat":          reserved,
+		"spendable_sat":               max(confirmed-required, 0),
+		"shortfall_sat":               max(required-confirmed, 0),
+	}
+
+	if additional > 0 {
+		projected, err := walletKit.RequiredReserve(ctx,
+			&walletrpc.RequiredReserveRequest{
+				AdditionalPublicChannels: uint32(additional),
+			})
+		if err != nil {
+			return subserverError(s.Clients, generation,
+				"walletkit", err, "failed to get required "+
+					"reserve"), nil
+		}
+		result["additional_public_channels"] = int(additional)
+		result["projected_reserve_sat"] = projected.RequiredReserve
+		result["projected_spendable_sat"] = max(
+			confirmed-projected.RequiredReserve, 0)
+	}
+
+	return jsonResult("lnc_required_reserve", result), nil
+}
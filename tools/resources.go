@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultPageLimit is the number of entries on a resource page whose
+	// URI sets no limit.
+	defaultPageLimit = 100
+
+	// maxPageLimit is the largest limit a resource page accepts.
+	maxPageLimit = 1000
+)
+
+// PaymentsResourceTemplate returns the MCP resource template addressing
+// pages of the payment history.
+func (s *PaymentService) PaymentsResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate("lnc://payments{?cursor,limit}",
+		"Payment history",
+		mcp.WithTemplateDescription("Completed payments, oldest "+
+			"first, as lnc_list_payments lists them. Each page "+
+			"links the next in next_uri until the history ends; "+
+			"the last page may be empty. Preimages are withheld "+
+			"unless the server always discloses them"),
+		mcp.WithTemplateMIMEType("application/json"))
+}
+
+// HandlePaymentsResource reads a page of the payment history. The cursor
+// is the payment index the page starts after.
+func (s *PaymentService) HandlePaymentsResource(ctx context.Context,
+	request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return nil, errors.ErrNotConnected().WithDetails(
+			s.Clients.State().Details())
+	}
+
+	cursor, limit, pageErr := pageQuery(request.Params.URI)
+	if pageErr != nil {
+		return nil, pageErr
+	}
+	offset := uint64(0)
+	if cursor != "" {
+		var err error
+		offset, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, errors.ErrInvalidArgument("cursor must be " +
+				"a payment index")
+		}
+	}
+
+	resp, err := client.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
+		IndexOffset: offset,
+		MaxPayments: uint64(limit),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err,
+			classifyRPCError(err, errors.ErrCodeNotFound),
+			"failed to list payments")
+	}
+
+	payments := make([]map[string]any, len(resp.Payments))
+	for i, payment := range resp.Payments {
+		payments[i] = s.paymentEntry(payment)
+	}
+	page := map[string]any{
+		"payments": payments,
+		"count":    len(payments),
+	}
+	withholdPreimage(s.PreimageDisclosure == PreimageAlways, page,
+		payments...)
+
+	next := ""
+	if len(payments) == limit {
+		next = strconv.FormatUint(resp.LastIndexOffset, 10)
+	}
+	return pageContents(request.Params.URI, "lnc://payments",
+		"lnc_list_payments", page, next, limit)
+}
+
+// ChannelsResourceTemplate returns the MCP resource template addressing
+// pages of the open channels.
+func (s *ChannelService) ChannelsResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate("lnc://channels{?cursor,limit}",
+		"Open channels",
+		mcp.WithTemplateDescription("Open channels in channel ID "+
+			"order, as lnc_list_channels lists them. Each page "+
+			"links the next in next_uri until the list ends; the "+
+			"last page may be empty"),
+		mcp.WithTemplateMIMEType("application/json"))
+}
+
+// HandleChannelsResource reads a page of the open channels. The cursor is
+// the channel ID the page starts after, so channels opened or closed while
+// paging do not shift the pages.
+func (s *ChannelService) HandleChannelsResource(ctx context.Context,
+	request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return nil, errors.ErrNotConnected().WithDetails(
+			s.Clients.State().Details())
+	}
+
+	cursor, limit, pageErr := pageQuery(request.Params.URI)
+	if pageErr != nil {
+		return nil, pageErr
+	}
+	after := uint64(0)
+	if cursor != "" {
+		var err error
+		after, err = parseChanID("cursor", cursor)
+		if err != nil {
+			return nil, errors.ErrInvalidArgument(err.Error())
+		}
+	}
+
+	// lnd lists every channel at once, so pages are cut from the list.
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err,
+			classifyRPCError(err, errors.ErrCodeNotFound),
+			"failed to list channels")
+	}
+	sort.Slice(list.Channels, func(i, j int) bool {
+		return list.Channels[i].ChanId < list.Channels[j].ChanId
+	})
+
+	splicing := spliceStatus(ctx, s.Clients)
+	channels := make([]map[string]any, 0, limit)
+	next := ""
+	for _, ch := range list.Channels {
+		if ch.ChanId <= after {
+			continue
+		}
+		if len(channels) == limit {
+			next = channels[limit-1]["chan_id"].(string)
+			break
+		}
+		channels = append(channels, channelEntry(ch, splicing))
+	}
+
+	return pageContents(request.Params.URI, "lnc://channels",
+		"lnc_list_channels", map[string]any{
+			"channels": channels,
+			"count":    len(channels),
+			"splicing": splicing.toMap(),
+		}, next, limit)
+}
+
+// pageQuery returns the cursor and limit of a page resource's URI.
+func pageQuery(uri string) (string, int, *errors.Error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", 0, errors.ErrInvalidArgument("malformed resource " +
+			"URI: " + err.Error())
+	}
+
+	query := parsed.Query()
+	limit := defaultPageLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return "", 0, errors.ErrInvalidArgument(fmt.Sprintf(
+				"limit must be between 1 and %d", maxPageLimit))
+		}
+	}
+
+	return query.Get("cursor"), limit, nil
+}
+
+// pageContents encodes a page of a list resource as the tool listing the
+// same entries would, linking the next page when there is one.
+func pageContents(uri, base, tool string, page map[string]any, next string,
+	limit int) ([]mcp.ResourceContents, error) {
+	if next != "" {
+		page["next_cursor"] = next
+		page["next_uri"] = fmt.Sprintf("%s?cursor=%s&limit=%d", base,
+			url.QueryEscape(next), limit)
+	}
+
+	data, err := encodeResult(tool, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
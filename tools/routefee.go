@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultProbeTimeout bounds how long lnd probes an invoice's route
+	// unless timeout_seconds says otherwise, matching lnd's default.
+	defaultProbeTimeout = 60 * time.Second
+
+	// maxProbeTimeout is the longest probe timeout accepted.
+	maxProbeTimeout = 10 * time.Minute
+)
+
+// EstimateRouteFeeTool returns the MCP tool definition for estimating what
+// a payment would cost.
+func (s *PaymentService) EstimateRouteFeeTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_estimate_route_fee",
+		Description: "Estimate the routing fee and time lock of a " +
+			"payment without making it. For an invoice, lnd " +
+			"probes the route with payments the recipient cannot " +
+			"settle, so no funds move; for pub_key and " +
+			"amount_sat, it estimates from the graph alone",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"invoice": map[string]any{
+					"type": "string",
+					"description": "BOLT11 invoice to probe, " +
+						"instead of pub_key and amount_sat",
+				},
+				"pub_key": map[string]any{
+					"type":        "string",
+					"description": "Destination node public key (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{66}$",
+				},
+				"amount_sat": map[string]any{
+					"type":        "number",
+					"description": "Amount to send to pub_key, in satoshis",
+					"minimum":     1,
+				},
+				"timeout_seconds": map[string]any{
+					"type": "number",
+					"description": "Give up probing an invoice " +
+						"after this many seconds (default 60)",
+					"minimum": 1,
+					"maximum": maxProbeTimeout.Seconds(),
+				},
+			},
+		},
+	}
+}
+
+// HandleEstimateRouteFee handles the route fee estimate request.
+func (s *PaymentService) HandleEstimateRouteFee(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	invoice, _ := args["invoice"].(string)
+	pubKey, _ := args["pub_key"].(string)
+	amountSat, _ := args["amount_sat"].(float64)
+	timeout, _ := args["timeout_seconds"].(float64)
+
+	switch {
+	case (invoice == "") == (pubKey == ""):
+		return invalidArgumentError("exactly one of invoice and " +
+			"pub_key is required"), nil
+	case invoice != "" && amountSat != 0:
+		return invalidArgumentError("amount_sat is taken from the " +
+			"invoice and must not be set with it"), nil
+	case timeout < 0 || timeout > maxProbeTimeout.Seconds():
+		return invalidArgumentError(fmt.Sprintf("timeout_seconds must "+
+			"be between 1 and %.0f",
+			maxProbeTimeout.Seconds())), nil
+	case timeout == 0:
+		timeout = defaultProbeTimeout.Seconds()
+	}
+
+	req := &routerrpc.RouteFeeRequest{}
+	result := map[string]any{}
+	if invoice != "" {
+		decoded, _, err := decodeBolt11(invoice)
+		if err != nil {
+			return toolError(errors.ErrInvalidInvoice(
+				err.Error())), nil
+		}
+		if decoded.MilliSat == nil || *decoded.MilliSat == 0 {
+			return invalidArgumentError("the invoice has no " +
+				"amount; estimate with pub_key and " +
+				"amount_sat"), nil
+		}
+
+		req.PaymentRequest = normalizeBolt11(invoice)
+		req.Timeout = uint32(timeout)
+		result["method"] = "probe"
+		result["destination"] = hex.EncodeToString(
+			decoded.Destination.SerializeCompressed())
+		result["amount_sat"] = int64(*decoded.MilliSat / 1000)
+	} else {
+		dest, err := parsePubkey("pub_key", pubKey)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		if amountSat < 1 {
+			return invalidArgumentError("amount_sat is required " +
+				"with pub_key"), nil
+		}
+
+		req.Dest = dest
+		req.AmtSat = int64(amountSat)
+		result["method"] = "graph"
+		result["destination"] = hex.EncodeToString(dest)
+		result["amount_sat"] = req.AmtSat
+	}
+
+	resp, err := router.EstimateRouteFee(ctx, req)
+	if err != nil {
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to estimate route fee"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+
+	// A graph estimate is a lower bound, and the time lock leaves out
+	// the destination's final CLTV delta either way.
+	failed := resp.FailureReason !=
+		lnrpc.PaymentFailureReason_FAILURE_REASON_NONE
+	result["routing_fee_msat"] = resp.RoutingFeeMsat
+	result["time_lock_delay"] = resp.TimeLockDelay
+	result["feasible"] = !failed
+	if failed {
+		result["failure_reason"] = resp.FailureReason.String()
+	}
+
+	return jsonResult("lnc_estimate_route_fee", result), nil
+}
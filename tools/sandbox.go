@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+)
+
+// sandboxNetwork is the only network a sandbox node may run on.
+const sandboxNetwork = "regtest"
+
+// SandboxService manages the regtest node that write tools target in
+// sandbox mode, so operations can be practised with realistic flows and no
+// real funds at risk.
+type SandboxService struct {
+	Clients    *ClientProvider
+	Connection *ConnectionService
+}
+
+// NewSandboxService creates a sandbox service. The callback receives the
+// sandbox connection once lnc_sandbox_connect succeeds.
+func NewSandboxService(defaultMailbox string,
+	callback func(*grpc.ClientConn)) *SandboxService {
+	clients := NewClientProvider(nil)
+	connection := NewConnectionService(callback)
+	connection.DefaultMailbox = defaultMailbox
+	connection.RequiredNetwork = sandboxNetwork
+	connection.State = clients.State()
+
+	return &SandboxService{
+		Clients:    clients,
+		Connection: connection,
+	}
+}
+
+// ConnectTool returns the MCP tool definition for connecting the sandbox
+// node. It takes the same arguments as lnc_connect.
+func (s *SandboxService) ConnectTool() mcp.Tool {
+	tool := s.Connection.ConnectTool()
+	tool.Name = "lnc_sandbox_connect"
+	tool.Description = "Connect the regtest node that write tools use in " +
+		"sandbox mode, using an LNC pairing phrase"
+	return tool
+}
+
+// HandleConnect handles the sandbox connection request. Nodes that are not
+// on regtest are rejected.
+func (s *SandboxService) HandleConnect(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.Connection.HandleConnect(ctx, request)
+}
+
+// StatusTool returns the MCP tool definition for inspecting sandbox mode.
+func (s *SandboxService) StatusTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_sandbox_status",
+		Description: "Show whether sandbox mode is active and which " +
+			"regtest node write tools will use",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleStatus handles the sandbox status request.
+func (s *SandboxService) HandleStatus(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return jsonResult("lnc_sandbox_status", map[string]any{
+			"sandbox_mode": true,
+			"connected":    false,
+			"message": "Write tools are disabled until a regtest " +
+				"node is connected with lnc_sandbox_connect",
+		}), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get sandbox node info"), nil
+	}
+
+	return jsonResult("lnc_sandbox_status", map[string]any{
+		"sandbox_mode":    true,
+		"connected":       true,
+		"node_pubkey":     info.IdentityPubkey,
+		"alias":           info.Alias,
+		"network":         nodeNetwork(info),
+		"block_height":    info.BlockHeight,
+		"synced_to_chain": info.SyncedToChain,
+	}), nil
+}
@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxScheduleAhead is how far ahead an operation can be scheduled.
+const maxScheduleAhead = 366 * 24 * time.Hour
+
+// ScheduleService schedules write tool calls to run later, and lists,
+// cancels and restores them. The manager runs them when they are due.
+type ScheduleService struct {
+	Schedule *schedule.Schedule
+
+	// Schedulable returns why a call of tool with args cannot be
+	// scheduled, or nil if it can. Nil refuses every tool.
+	Schedulable func(tool string, args map[string]any) error
+}
+
+// NewScheduleService creates a new schedule service.
+func NewScheduleService(operations *schedule.Schedule) *ScheduleService {
+	return &ScheduleService{Schedule: operations}
+}
+
+// ScheduleOperationTool returns the MCP tool definition for scheduling a
+// write tool call.
+func (s *ScheduleService) ScheduleOperationTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_schedule_operation",
+		Description: "Schedule a call of a write tool, such as " +
+			"lnc_keysend or lnc_update_channel_policy, to run " +
+			"at run_at. It runs unattended with the same spend " +
+			"limits, audit logging and journaling as a direct " +
+			"call, so tools that need the user's approval, that " +
+			"take a confirmation_id, or whose arguments hold " +
+			"secrets such as preimages cannot be scheduled. " +
+			"Cancelled and failed operations are kept with " +
+			"their history, and lnc_restore_scheduled_operation " +
+			"schedules them again",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"tool": map[string]any{
+					"type":        "string",
+					"description": "The write tool to call, such as lnc_keysend",
+				},
+				"arguments": map[string]any{
+					"type":        "object",
+					"description": "The arguments to call the tool with",
+				},
+				"run_at": map[string]any{
+					"type":        "string",
+					"description": "When to run the call, as an RFC 3339 time such as 2026-01-02T15:04:05Z, at most a year ahead",
+				},
+				"note": map[string]any{
+					"type":        "string",
+					"description": "Why the call is scheduled, kept in its history",
+				},
+			},
+			Required: []string{"tool", "run_at"},
+		},
+	}
+}
+
+// HandleScheduleOperation handles the schedule operation request.
+func (s *ScheduleService) HandleScheduleOperation(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	tool, _ := args["tool"].(string)
+	note, _ := args["note"].(string)
+	callArgs, ok := args["arguments"].(map[string]any)
+	if _, given := args["arguments"]; given && !ok {
+		return invalidArgumentError("arguments must be an " +
+			"object"), nil
+	}
+
+	runAt, errResult := parseRunAt(args)
+	if errResult != nil {
+		return errResult, nil
+	}
+	if !runAt.After(time.Now()) {
+		return invalidArgumentError("run_at must be in the " +
+			"future"), nil
+	}
+
+	switch {
+	case tool == "":
+		return invalidArgumentError("tool is required"), nil
+	case s.Schedulable == nil:
+		return invalidArgumentError(tool + " cannot be " +
+			"scheduled"), nil
+	}
+	if err := s.Schedulable(tool, callArgs); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	op, err := s.Schedule.Add(tool, callArgs, runAt, note)
+	if err != nil {
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to save the schedule")), nil
+	}
+
+	return jsonResult("lnc_schedule_operation", map[string]any{
+		"operation": scheduledOperationResult(op),
+	}), nil
+}
+
+// ListScheduledOperationsTool returns the MCP tool definition for listing
+// scheduled operations.
+func (s *ScheduleService) ListScheduledOperationsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_scheduled_operations",
+		Description: "List the scheduled write tool calls in the " +
+			"order they run, each with its arguments and the " +
+			"history of when it was scheduled, started, " +
+			"finished, cancelled or restored. Cancelled and " +
+			"failed operations are soft-deleted and only listed " +
+			"with include_deleted",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"state": map[string]any{
+					"type":        "string",
+					"description": "Only list operations in this state",
+					"enum":        schedule.States,
+				},
+				"include_deleted": map[string]any{
+					"type":        "boolean",
+					"description": "Also list cancelled and failed operations (default false)",
+				},
+			},
+		},
+	}
+}
+
+// HandleListScheduledOperations handles the list scheduled operations
+// request.
+func (s *ScheduleService) HandleListScheduledOperations(
+	ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	state, _ := args["state"].(string)
+	includeDeleted, _ := args["include_deleted"].(bool)
+
+	operations := make([]map[string]any, 0)
+	scheduled, deleted := 0, 0
+	for _, op := range s.Schedule.Operations() {
+		if op.State == schedule.StateScheduled {
+			scheduled++
+		}
+		if op.Deleted() {
+			deleted++
+		}
+		if (state != "" && op.State != state) ||
+			(op.Deleted() && !includeDeleted) {
+			continue
+		}
+		operations = append(operations, scheduledOperationResult(op))
+	}
+
+	return jsonResult("lnc_list_scheduled_operations", map[string]any{
+		"operations": operations,
+		"scheduled":  scheduled,
+		"deleted":    deleted,
+	}), nil
+}
+
+// CancelScheduledOperationTool returns the MCP tool definition for
+// cancelling a scheduled operation.
+func (s *ScheduleService) CancelScheduledOperationTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_cancel_scheduled_operation",
+		Description: "Cancel a scheduled write tool call that has " +
+			"not run yet. The operation is soft-deleted: it is " +
+			"kept with its history and can be scheduled again " +
+			"with lnc_restore_scheduled_operation",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"id": map[string]any{
+					"type":        "string",
+					"description": "The ID lnc_schedule_operation returned",
+				},
+				"note": map[string]any{
+					"type":        "string",
+					"description": "Why the call is cancelled, kept in its history",
+				},
+			},
+			Required: []string{"id"},
+		},
+	}
+}
+
+// HandleCancelScheduledOperation handles the cancel scheduled operation
+// request.
+func (s *ScheduleService) HandleCancelScheduledOperation(
+	ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	id, _ := args["id"].(string)
+	note, _ := args["note"].(string)
+
+	op, err := s.Schedule.Cancel(id, note)
+	if err != nil {
+		return scheduleError(err, "cancel"), nil
+	}
+
+	return jsonResult("lnc_cancel_scheduled_operation", map[string]any{
+		"operation": scheduledOperationResult(op),
+	}), nil
+}
+
+// RestoreScheduledOperationTool returns the MCP tool definition for
+// restoring a cancelled or failed scheduled operation.
+func (s *ScheduleService) RestoreScheduledOperationTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_restore_scheduled_operation",
+		Description: "Schedule a cancelled or failed write tool call " +
+			"again, with the same tool and arguments, undoing " +
+			"its soft deletion. Its history is kept, with the " +
+			"restore added",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"id": map[string]any{
+					"type":        "string",
+					"description": "The ID of the cancelled or failed operation",
+				},
+				"run_at": map[string]any{
+					"type":        "string",
+					"description": "When to run the call, as an RFC 3339 time, at most a year ahead (default the time it was scheduled for, if still to come)",
+				},
+				"note": map[string]any{
+					"type":        "string",
+					"description": "Why the call is restored, kept in its history",
+				},
+			},
+			Required: []string{"id"},
+		},
+	}
+}
+
+// HandleRestoreScheduledOperation handles the restore scheduled operation
+// request. The call is checked again, as what can be scheduled may have
+// changed since it was first scheduled.
+func (s *ScheduleService) HandleRestoreScheduledOperation(
+	ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	id, _ := args["id"].(string)
+	note, _ := args["note"].(string)
+
+	op, ok := s.Schedule.Get(id)
+	if !ok {
+		return scheduleError(schedule.ErrNotFound, "restore"), nil
+	}
+	if !op.Deleted() {
+		return scheduleError(schedule.ErrNotDeleted, "restore"), nil
+	}
+
+	runAt := op.RunAt
+	if _, given := args["run_at"]; given {
+		var errResult *mcp.CallToolResult
+		if runAt, errResult = parseRunAt(args); errResult != nil {
+			return errResult, nil
+		}
+	}
+	if !runAt.After(time.Now()) {
+		return invalidArgumentError("run_at must be in the future; " +
+			"the operation was due at " +
+			op.RunAt.Format(time.RFC3339)), nil
+	}
+
+	if s.Schedulable == nil {
+		return invalidArgumentError(op.Tool + " cannot be " +
+			"scheduled"), nil
+	}
+	if err := s.Schedulable(op.Tool, op.Arguments); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	op, err := s.Schedule.Restore(id, runAt, note)
+	if err != nil {
+		return scheduleError(err, "restore"), nil
+	}
+
+	return jsonResult("lnc_restore_scheduled_operation", map[string]any{
+		"operation": scheduledOperationResult(op),
+	}), nil
+}
+
+// parseRunAt reads the run_at argument, returning the error result to
+// reply with if it is not a time within the next year.
+func parseRunAt(args map[string]any) (time.Time, *mcp.CallToolResult) {
+	value, _ := args["run_at"].(string)
+	runAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, invalidArgumentError("run_at must be " +
+			"an RFC 3339 time, such as 2026-01-02T15:04:05Z")
+	}
+	if runAt.After(time.Now().Add(maxScheduleAhead)) {
+		return time.Time{}, invalidArgumentError("run_at must be " +
+			"at most a year ahead")
+	}
+	return runAt, nil
+}
+
+// scheduleError reports a failed change to a scheduled operation.
+func scheduleError(err error, action string) *mcp.CallToolResult {
+	switch err {
+	case schedule.ErrNotFound:
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			err.Error()))
+	case schedule.ErrNotScheduled, schedule.ErrNotDeleted:
+		return invalidArgumentError(err.Error())
+	}
+	return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+		"failed to "+action+" the scheduled operation"))
+}
+
+// scheduledOperationResult formats a scheduled operation for a result.
+func scheduledOperationResult(op schedule.Operation) map[string]any {
+	history := make([]map[string]any, 0, len(op.History))
+	for _, event := range op.History {
+		entry := map[string]any{
+			"at":     event.At.Format(time.RFC3339),
+			"action": event.Action,
+		}
+		if event.Note != "" {
+			entry["note"] = event.Note
+		}
+		history = append(history, entry)
+	}
+
+	result := map[string]any{
+		"id":         op.ID,
+		"tool":       op.Tool,
+		"run_at":     op.RunAt.Format(time.RFC3339),
+		"state":      op.State,
+		"deleted":    op.Deleted(),
+		"history":    history,
+		"created_at": op.CreatedAt.Format(time.RFC3339),
+		"updated_at": op.UpdatedAt.Format(time.RFC3339),
+	}
+	if len(op.Arguments) > 0 {
+		result["arguments"] = op.Arguments
+	}
+	if op.DeletedAt != nil {
+		result["deleted_at"] = op.DeletedAt.Format(time.RFC3339)
+	}
+	return result
+}
@@ -0,0 +1,1903 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jbrill/mcp-lnc-server/internal/classify"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// JSON Schema building blocks for output schemas.
+var (
+	stringSchema  = map[string]any{"type": "string"}
+	integerSchema = map[string]any{"type": "integer"}
+	numberSchema  = map[string]any{"type": "number"}
+	booleanSchema = map[string]any{"type": "boolean"}
+	objectSchema  = map[string]any{"type": "object"}
+
+	// nullableObjectSchema is an object that may be null when the node
+	// or service had nothing to report.
+	nullableObjectSchema = map[string]any{
+		"type": []string{"object", "null"},
+	}
+)
+
+// objectOf returns a schema for an object with the given properties, of
+// which required must always be present.
+func objectOf(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// arrayOf returns a schema for an array of items.
+func arrayOf(items map[string]any) map[string]any {
+	return map[string]any{
+		"type":  "array",
+		"items": items,
+	}
+}
+
+// Schemas shared by several tools.
+var (
+	amountSchema = objectOf(map[string]any{
+		"sat":  integerSchema,
+		"msat": integerSchema,
+	}, "sat", "msat")
+
+	routeHintsSchema = arrayOf(objectOf(map[string]any{
+		"hop_hints": arrayOf(objectOf(map[string]any{
+			"node_id":    stringSchema,
+			"chan_id":    integerSchema,
+			"fee_base":   integerSchema,
+			"fee_prop":   integerSchema,
+			"cltv_delta": integerSchema,
+		})),
+	}))
+
+	// scidSchema is a short channel ID in lnd's decimal form and as block
+	// x transaction x output.
+	scidSchema = objectOf(map[string]any{
+		"chan_id":          stringSchema,
+		"short_channel_id": stringSchema,
+	}, "chan_id", "short_channel_id")
+
+	// contactSchema is a contact from the operator's contact book, as
+	// listed by itself or attached to a peer or channel.
+	contactSchema = objectOf(map[string]any{
+		"pubkey":       stringSchema,
+		"label":        stringSchema,
+		"name":         stringSchema,
+		"organization": stringSchema,
+		"notes":        stringSchema,
+		"telegram":     stringSchema,
+		"nostr":        stringSchema,
+		"updated_at":   stringSchema,
+	}, "updated_at")
+
+	// watchedNodeSchema is a node on the watchlist, with what its last
+	// check saw once it has been checked.
+	watchedNodeSchema = objectOf(map[string]any{
+		"pubkey":         stringSchema,
+		"note":           stringSchema,
+		"added_at":       stringSchema,
+		"contact":        contactSchema,
+		"checked_at":     stringSchema,
+		"in_graph":       booleanSchema,
+		"alias":          stringSchema,
+		"num_channels":   integerSchema,
+		"total_capacity": integerSchema,
+		"changes": arrayOf(objectOf(map[string]any{
+			"kind":             stringSchema,
+			"old_alias":        stringSchema,
+			"new_alias":        stringSchema,
+			"chan_id":          stringSchema,
+			"short_channel_id": stringSchema,
+			"peer":             stringSchema,
+			"capacity":         integerSchema,
+			"old_policy":       routingPolicySchema,
+			"new_policy":       routingPolicySchema,
+		}, "kind")),
+	}, "pubkey", "added_at")
+
+	// restoredSectionSchema is what lnc_server_restore did with one
+	// section of a backup.
+	restoredSectionSchema = objectOf(map[string]any{
+		"in_backup": integerSchema,
+		"restored":  integerSchema,
+		"enabled":   booleanSchema,
+	}, "in_backup", "restored", "enabled")
+
+	// scheduledOperationSchema is a scheduled write tool call with its
+	// history, as the schedule tools report it.
+	scheduledOperationSchema = objectOf(map[string]any{
+		"id":         stringSchema,
+		"tool":       stringSchema,
+		"arguments":  objectSchema,
+		"run_at":     stringSchema,
+		"state":      stringSchema,
+		"deleted":    booleanSchema,
+		"deleted_at": stringSchema,
+		"history": arrayOf(objectOf(map[string]any{
+			"at":     stringSchema,
+			"action": stringSchema,
+			"note":   stringSchema,
+		}, "at", "action")),
+		"created_at": stringSchema,
+		"updated_at": stringSchema,
+	}, "id", "tool", "run_at", "state", "deleted", "history",
+		"created_at", "updated_at")
+
+	// peerBalanceProperties are those of a balance totalled by
+	// lnc_channel_balance_by_peer, for one peer or for all of them.
+	peerBalanceProperties = map[string]any{
+		"remote_pubkey":         stringSchema,
+		"channel_count":         integerSchema,
+		"active_channel_count":  integerSchema,
+		"private_channel_count": integerSchema,
+		"capacity":              integerSchema,
+		"local_balance":         integerSchema,
+		"remote_balance":        integerSchema,
+		"unsettled_balance":     integerSchema,
+		"local_balance_percent": numberSchema,
+		"contact":               contactSchema,
+	}
+
+	invoiceSchemaProperties = map[string]any{
+		"memo":            stringSchema,
+		"payment_request": stringSchema,
+		"r_hash":          stringSchema,
+		"value":           integerSchema,
+		"value_msat":      integerSchema,
+		"settled":         booleanSchema,
+		"creation_date":   integerSchema,
+		"settle_date":     integerSchema,
+		"expiry":          integerSchema,
+		"cltv_expiry":     integerSchema,
+		"private":         booleanSchema,
+		"add_index":       integerSchema,
+		"settle_index":    integerSchema,
+		"amt_paid_sat":    integerSchema,
+		"amt_paid_msat":   integerSchema,
+		"state":           stringSchema,
+		"is_keysend":      booleanSchema,
+		"payment_addr":    stringSchema,
+	}
+
+	// featureFlagsSchema maps invoice feature bits to whether lnd knows
+	// the feature.
+	featureFlagsSchema = map[string]any{
+		"type":                 "object",
+		"additionalProperties": booleanSchema,
+	}
+
+	pendingChannelSchema = objectOf(map[string]any{
+		"remote_node_pub": stringSchema,
+		"channel_point":   stringSchema,
+		"capacity":        integerSchema,
+		"local_balance":   integerSchema,
+		"remote_balance":  integerSchema,
+		"initiator":       stringSchema,
+		"dual_funded":     booleanSchema,
+	})
+
+	constraintsSchema = objectOf(map[string]any{
+		"csv_delay":            integerSchema,
+		"chan_reserve_sat":     integerSchema,
+		"dust_limit_sat":       integerSchema,
+		"max_pending_amt_msat": integerSchema,
+		"min_htlc_msat":        integerSchema,
+		"max_accepted_htlcs":   integerSchema,
+	})
+
+	spliceSupportSchema = objectOf(map[string]any{
+		"supported":       booleanSchema,
+		"node_advertises": booleanSchema,
+		"lnd_version":     stringSchema,
+		"reason":          stringSchema,
+	}, "supported")
+
+	graphEdgeSchema = objectOf(map[string]any{
+		"channel_id": integerSchema,
+		"chan_point": stringSchema,
+		"node1_pub":  stringSchema,
+		"node2_pub":  stringSchema,
+		"capacity":   integerSchema,
+	})
+
+	routingPolicySchema = objectOf(map[string]any{
+		"fee_base_msat":         integerSchema,
+		"fee_rate_ppm":          integerSchema,
+		"inbound_fee_base_msat": integerSchema,
+		"inbound_fee_rate_ppm":  integerSchema,
+		"time_lock_delta":       integerSchema,
+		"min_htlc_msat":         integerSchema,
+		"max_htlc_msat":         integerSchema,
+		"disabled":              booleanSchema,
+		"last_update":           integerSchema,
+	}, "fee_base_msat", "fee_rate_ppm", "time_lock_delta", "disabled")
+
+	// anonymizedPolicySchema is a routing policy in an anonymized graph
+	// export: without its update time, and null when never announced.
+	anonymizedPolicySchema = map[string]any{
+		"type": []string{"object", "null"},
+		"properties": map[string]any{
+			"fee_base_msat":         integerSchema,
+			"fee_rate_ppm":          integerSchema,
+			"inbound_fee_base_msat": integerSchema,
+			"inbound_fee_rate_ppm":  integerSchema,
+			"time_lock_delta":       integerSchema,
+			"min_htlc_msat":         integerSchema,
+			"max_htlc_msat":         integerSchema,
+			"disabled":              booleanSchema,
+		},
+	}
+
+	connectSchema = objectOf(map[string]any{
+		"connected":      booleanSchema,
+		"node_pubkey":    stringSchema,
+		"alias":          stringSchema,
+		"num_channels":   integerSchema,
+		"num_peers":      integerSchema,
+		"version":        stringSchema,
+		"mailbox_server": stringSchema,
+		"mailbox_proxy":  stringSchema,
+	}, "connected", "node_pubkey")
+
+	// ampChildPaymentsSchema describes the shards of an AMP payment.
+	ampChildPaymentsSchema = arrayOf(objectOf(map[string]any{
+		"child_index": integerSchema,
+		"attempt_id":  integerSchema,
+		"status":      stringSchema,
+		"amount_msat": integerSchema,
+	}, "child_index", "status"))
+
+	// paymentResultSchema describes a payment sent with SendPaymentV2.
+	paymentResultSchema = objectOf(map[string]any{
+		"payment_hash":     stringSchema,
+		"status":           stringSchema,
+		"succeeded":        booleanSchema,
+		"payment_preimage": stringSchema,
+		"value_sat":        integerSchema,
+		"fee_sat":          integerSchema,
+		"fee_msat":         integerSchema,
+		"failure_reason":   stringSchema,
+		"htlc_attempts":    integerSchema,
+		"amp":              booleanSchema,
+		"set_id":           stringSchema,
+		"child_payments":   ampChildPaymentsSchema,
+		"status_updates": arrayOf(objectOf(map[string]any{
+			"status":   stringSchema,
+			"htlcs":    integerSchema,
+			"at_ms":    integerSchema,
+			"fee_msat": integerSchema,
+		}, "status")),
+	}, "payment_hash", "status", "succeeded")
+
+	lspOrderSchema = objectOf(map[string]any{
+		"lsp":   stringSchema,
+		"order": nullableObjectSchema,
+	}, "lsp", "order")
+
+	// channelPolicySchema describes a channel's routing policy. A global
+	// update's policy only lists the HTLC limits it changes.
+	channelPolicySchema = objectOf(map[string]any{
+		"base_fee_msat":   integerSchema,
+		"fee_rate_ppm":    integerSchema,
+		"time_lock_delta": integerSchema,
+		"min_htlc_msat":   integerSchema,
+		"max_htlc_msat":   integerSchema,
+	}, "base_fee_msat", "fee_rate_ppm", "time_lock_delta")
+
+	// holdInvoiceResultSchema describes an invoice canceled or settled
+	// through the invoices subserver.
+	holdInvoiceResultSchema = objectOf(map[string]any{
+		"payment_hash":   stringSchema,
+		"state":          stringSchema,
+		"previous_state": stringSchema,
+		"changed":        booleanSchema,
+		"value_msat":     integerSchema,
+		"amt_paid_msat":  integerSchema,
+		"accepted_htlcs": integerSchema,
+	}, "payment_hash", "state", "previous_state", "changed")
+
+	// mempoolContextSchema describes mempool conditions reported by the
+	// external mempool API. Only source, provider and note are present
+	// when the API could not be reached.
+	mempoolContextSchema = objectOf(map[string]any{
+		"source":           stringSchema,
+		"provider":         stringSchema,
+		"note":             stringSchema,
+		"error":            stringSchema,
+		"fetched_at":       stringSchema,
+		"mempool_vsize":    integerSchema,
+		"mempool_tx_count": integerSchema,
+		"depth_blocks":     integerSchema,
+		"fee_bands": arrayOf(objectOf(map[string]any{
+			"block":                integerSchema,
+			"min_sat_per_vbyte":    numberSchema,
+			"median_sat_per_vbyte": numberSchema,
+			"max_sat_per_vbyte":    numberSchema,
+		}, "block")),
+		"recommended_sat_per_vbyte": objectOf(map[string]any{
+			"fastest":   numberSchema,
+			"half_hour": numberSchema,
+			"hour":      numberSchema,
+			"economy":   numberSchema,
+			"minimum":   numberSchema,
+		}),
+		"fee_rate_positions": map[string]any{
+			"type": "object",
+			"additionalProperties": objectOf(map[string]any{
+				"sat_per_vbyte": numberSchema,
+				"projected_block": map[string]any{
+					"type": []string{"integer", "null"},
+				},
+				"assessment": stringSchema,
+			}, "sat_per_vbyte", "projected_block", "assessment"),
+		},
+	}, "source", "provider", "note")
+
+	// subserverReportSchema describes what is known about the optional
+	// subservers of one connection.
+	subserverReportSchema = arrayOf(objectOf(map[string]any{
+		"name":       stringSchema,
+		"service":    stringSchema,
+		"status":     stringSchema,
+		"tools":      arrayOf(stringSchema),
+		"checked_at": stringSchema,
+		"reason":     stringSchema,
+		"guidance":   stringSchema,
+	}, "name", "service", "status", "tools"))
+
+	// missionControlConfigSchema is lnd's mission control configuration,
+	// with the parameters of its probability model.
+	missionControlConfigSchema = objectOf(map[string]any{
+		"model":                     stringSchema,
+		"maximum_payment_results":   integerSchema,
+		"min_failure_relax_seconds": integerSchema,
+		"apriori": objectOf(map[string]any{
+			"half_life_seconds": integerSchema,
+			"hop_probability":   numberSchema,
+			"weight":            numberSchema,
+			"capacity_fraction": numberSchema,
+		}),
+		"bimodal": objectOf(map[string]any{
+			"node_weight":        numberSchema,
+			"scale_msat":         integerSchema,
+			"decay_time_seconds": integerSchema,
+		}),
+	}, "model", "maximum_payment_results", "min_failure_relax_seconds")
+)
+
+// outputSchemas declares the JSON Schema of each tool's successful result,
+// excluding the schema_version, deprecated_fields and withheld_fields that
+// results carry. Error results always use the {code, message, retryable,
+// details} payload instead.
+var outputSchemas = map[string]map[string]any{
+	"lnc_connect":         connectSchema,
+	"lnc_sandbox_connect": connectSchema,
+	"lnc_unlock_wallet":   connectSchema,
+	"lnc_disconnect": objectOf(map[string]any{
+		"disconnected": booleanSchema,
+		"message":      stringSchema,
+	}, "disconnected"),
+
+	"lnc_get_info": objectOf(map[string]any{
+		"node_id":               stringSchema,
+		"alias":                 stringSchema,
+		"version":               stringSchema,
+		"num_peers":             integerSchema,
+		"num_active_channels":   integerSchema,
+		"num_inactive_channels": integerSchema,
+		"num_pending_channels":  integerSchema,
+		"synced_to_chain":       booleanSchema,
+		"synced_to_graph":       booleanSchema,
+		"block_height":          integerSchema,
+		"block_hash":            stringSchema,
+		"primary_network":       stringSchema,
+		"chains":                arrayOf(stringSchema),
+	}, "node_id", "synced_to_chain", "block_height"),
+	"lnc_get_balance": objectOf(map[string]any{
+		"wallet_balance": objectOf(map[string]any{
+			"total_balance":       integerSchema,
+			"confirmed_balance":   integerSchema,
+			"unconfirmed_balance": integerSchema,
+		}, "total_balance", "confirmed_balance",
+			"unconfirmed_balance"),
+		"channel_balance": objectOf(map[string]any{
+			"total_balance":               integerSchema,
+			"pending_open_balance":        integerSchema,
+			"local_balance":               amountSchema,
+			"remote_balance":              amountSchema,
+			"unsettled_local_balance":     amountSchema,
+			"unsettled_remote_balance":    amountSchema,
+			"pending_open_local_balance":  amountSchema,
+			"pending_open_remote_balance": amountSchema,
+		}, "total_balance", "local_balance", "remote_balance"),
+	}, "wallet_balance", "channel_balance"),
+
+	"lnc_decode_invoice": objectOf(map[string]any{
+		"destination":      stringSchema,
+		"payment_hash":     stringSchema,
+		"amount_sats":      integerSchema,
+		"amount_msat":      integerSchema,
+		"timestamp":        integerSchema,
+		"expiry":           integerSchema,
+		"description":      stringSchema,
+		"description_hash": stringSchema,
+		"fallback_address": stringSchema,
+		"cltv_expiry":      integerSchema,
+		"route_hints":      routeHintsSchema,
+		"payment_addr":     stringSchema,
+		"features":         featureFlagsSchema,
+	}, "destination", "payment_hash", "amount_msat"),
+	"lnc_decode_invoice_offline": objectOf(map[string]any{
+		"network":          stringSchema,
+		"destination":      stringSchema,
+		"payment_hash":     stringSchema,
+		"amount_sats":      integerSchema,
+		"amount_msat":      integerSchema,
+		"timestamp":        integerSchema,
+		"expiry":           integerSchema,
+		"expires_at":       integerSchema,
+		"expired":          booleanSchema,
+		"description":      stringSchema,
+		"description_hash": stringSchema,
+		"fallback_address": stringSchema,
+		"cltv_expiry":      integerSchema,
+		"route_hints":      routeHintsSchema,
+		"payment_addr":     stringSchema,
+		"features":         featureFlagsSchema,
+	}, "network", "destination", "payment_hash", "amount_msat",
+		"expired"),
+	"lnc_list_invoices": objectOf(map[string]any{
+		"invoices": arrayOf(objectOf(invoiceSchemaProperties,
+			"r_hash", "state")),
+		"first_index_offset": integerSchema,
+		"last_index_offset":  integerSchema,
+		"total_invoices":     integerSchema,
+	}, "invoices", "total_invoices"),
+	"lnc_lookup_invoice": objectOf(invoiceSchemaProperties, "r_hash",
+		"state"),
+
+	"lnc_list_channels": objectOf(map[string]any{
+		"channels": arrayOf(objectOf(map[string]any{
+			"active":                  booleanSchema,
+			"remote_pubkey":           stringSchema,
+			"channel_point":           stringSchema,
+			"chan_id":                 stringSchema,
+			"capacity":                integerSchema,
+			"local_balance":           integerSchema,
+			"remote_balance":          integerSchema,
+			"commit_fee":              integerSchema,
+			"commit_weight":           integerSchema,
+			"fee_per_kw":              integerSchema,
+			"unsettled_balance":       integerSchema,
+			"total_satoshis_sent":     integerSchema,
+			"total_satoshis_received": integerSchema,
+			"num_updates":             integerSchema,
+			"pending_htlcs":           integerSchema,
+			"private":                 booleanSchema,
+			"initiator":               booleanSchema,
+			"chan_status_flags":       stringSchema,
+			"splice_status":           stringSchema,
+			"local_constraints":       constraintsSchema,
+			"remote_constraints":      constraintsSchema,
+			"contact":                 contactSchema,
+		}, "channel_point", "chan_id", "capacity")),
+		"total_channels": integerSchema,
+		"splicing":       spliceSupportSchema,
+	}, "channels", "total_channels"),
+	"lnc_channel_balance_by_peer": objectOf(map[string]any{
+		"peers": arrayOf(objectOf(peerBalanceProperties,
+			"remote_pubkey", "channel_count", "capacity",
+			"local_balance", "remote_balance")),
+		"peer_count": integerSchema,
+		"total": objectOf(peerBalanceProperties, "channel_count",
+			"capacity", "local_balance", "remote_balance"),
+	}, "peers", "peer_count", "total"),
+	"lnc_lookup_htlc_resolution": objectOf(map[string]any{
+		"chan_id":          stringSchema,
+		"short_channel_id": stringSchema,
+		"htlc_index":       integerSchema,
+		"settled":          booleanSchema,
+		"offchain":         booleanSchema,
+		"outcome":          stringSchema,
+		"resolved_on":      stringSchema,
+	}, "chan_id", "short_channel_id", "htlc_index", "settled", "offchain",
+		"outcome", "resolved_on"),
+	"lnc_partner_sla": objectOf(map[string]any{
+		"window_days":        integerSchema,
+		"since":              stringSchema,
+		"forwards_counted":   integerSchema,
+		"forwards_truncated": booleanSchema,
+		"sample": objectOf(map[string]any{
+			"seconds": numberSchema,
+			"events":  integerSchema,
+		}, "seconds", "events"),
+		"partners": arrayOf(objectOf(map[string]any{
+			"remote_pubkey":        stringSchema,
+			"channel_count":        integerSchema,
+			"active_channel_count": integerSchema,
+			"capacity":             integerSchema,
+			"monitored_seconds":    integerSchema,
+			"active_seconds":       integerSchema,
+			"active_percent":       numberSchema,
+			"forwards_in":          integerSchema,
+			"forwards_out":         integerSchema,
+			"volume_in_msat":       integerSchema,
+			"volume_out_msat":      integerSchema,
+			"fees_earned_msat":     integerSchema,
+			"htlcs": objectOf(map[string]any{
+				"received":             integerSchema,
+				"forwarded":            integerSchema,
+				"failed_by_us":         integerSchema,
+				"failed_downstream":    integerSchema,
+				"failure_rate_percent": numberSchema,
+				"failure_reasons": map[string]any{
+					"type":                 "object",
+					"additionalProperties": integerSchema,
+				},
+			}, "received", "forwarded", "failed_by_us",
+				"failed_downstream", "failure_reasons"),
+			"contact": contactSchema,
+		}, "remote_pubkey", "channel_count", "capacity",
+			"monitored_seconds", "active_seconds", "forwards_in",
+			"forwards_out")),
+		"partner_count": integerSchema,
+	}, "window_days", "since", "forwards_counted", "forwards_truncated",
+		"partners", "partner_count"),
+	"lnc_list_htlcs": objectOf(map[string]any{
+		"htlcs": arrayOf(objectOf(map[string]any{
+			"chan_id":                      stringSchema,
+			"short_channel_id":             stringSchema,
+			"channel_point":                stringSchema,
+			"remote_pubkey":                stringSchema,
+			"direction":                    stringSchema,
+			"amount_sat":                   integerSchema,
+			"payment_hash":                 stringSchema,
+			"htlc_index":                   integerSchema,
+			"locked_in":                    booleanSchema,
+			"expiration_height":            integerSchema,
+			"blocks_until_expiry":          integerSchema,
+			"expired":                      booleanSchema,
+			"estimated_hours_until_expiry": numberSchema,
+			"estimated_expiry_at":          stringSchema,
+			"forwarding_chan_id":           stringSchema,
+			"forwarding_htlc_index":        integerSchema,
+			"contact":                      contactSchema,
+		}, "chan_id", "channel_point", "remote_pubkey", "direction",
+			"amount_sat", "payment_hash", "expiration_height",
+			"blocks_until_expiry", "expired")),
+		"count":               integerSchema,
+		"incoming_count":      integerSchema,
+		"outgoing_count":      integerSchema,
+		"incoming_amount_sat": integerSchema,
+		"outgoing_amount_sat": integerSchema,
+		"block_height":        integerSchema,
+	}, "htlcs", "count", "incoming_count", "outgoing_count",
+		"block_height"),
+	"lnc_server_backup": objectOf(map[string]any{
+		"name":          stringSchema,
+		"path":          stringSchema,
+		"created_at":    stringSchema,
+		"contacts":      integerSchema,
+		"watched_nodes": integerSchema,
+		"operations":    integerSchema,
+		"spends":        integerSchema,
+	}, "name", "path", "created_at", "contacts", "watched_nodes",
+		"operations", "spends"),
+	"lnc_server_restore": objectOf(map[string]any{
+		"name":           stringSchema,
+		"created_at":     stringSchema,
+		"server_version": stringSchema,
+		"contacts":       restoredSectionSchema,
+		"watched_nodes":  restoredSectionSchema,
+		"operations":     restoredSectionSchema,
+		"spends":         restoredSectionSchema,
+	}, "name", "created_at", "contacts", "watched_nodes", "operations",
+		"spends"),
+	"lnc_export_records": objectOf(map[string]any{
+		"records":      stringSchema,
+		"format":       stringSchema,
+		"name":         stringSchema,
+		"path":         stringSchema,
+		"count":        integerSchema,
+		"index_offset": integerSchema,
+		"last_index":   integerSchema,
+		"truncated":    booleanSchema,
+		"incremental":  booleanSchema,
+		"exported_at":  stringSchema,
+	}, "records", "format", "name", "path", "count", "index_offset",
+		"last_index", "truncated", "incremental", "exported_at"),
+	"lnc_store_maintenance": objectOf(map[string]any{
+		"action":     stringSchema,
+		"checked_at": stringSchema,
+		"name":       stringSchema,
+		"path":       stringSchema,
+		"records":    integerSchema,
+		"stores": arrayOf(objectOf(map[string]any{
+			"store":              stringSchema,
+			"path":               stringSchema,
+			"format_version":     integerSchema,
+			"entries":            integerSchema,
+			"ok":                 booleanSchema,
+			"problem":            stringSchema,
+			"pruned":             integerSchema,
+			"temp_files_removed": integerSchema,
+		}, "store", "path", "format_version", "entries", "ok")),
+	}, "action", "checked_at", "stores"),
+	"lnc_list_aliases": objectOf(map[string]any{
+		"alias_maps": arrayOf(objectOf(map[string]any{
+			"base_scid":     scidSchema,
+			"aliases":       arrayOf(scidSchema),
+			"alias_count":   integerSchema,
+			"base_is_alias": booleanSchema,
+			"channel": objectOf(map[string]any{
+				"channel_point":   stringSchema,
+				"chan_id":         stringSchema,
+				"remote_pubkey":   stringSchema,
+				"active":          booleanSchema,
+				"private":         booleanSchema,
+				"zero_conf":       booleanSchema,
+				"confirmed_scid":  scidSchema,
+				"peer_scid_alias": scidSchema,
+			}, "channel_point", "chan_id", "remote_pubkey"),
+		}, "base_scid", "aliases", "alias_count", "base_is_alias")),
+		"count": integerSchema,
+	}, "alias_maps", "count"),
+	"lnc_pending_channels": objectOf(map[string]any{
+		"pending_open_channels": arrayOf(objectOf(map[string]any{
+			"channel":          pendingChannelSchema,
+			"commit_fee":       integerSchema,
+			"commit_weight":    integerSchema,
+			"fee_per_kw":       integerSchema,
+			"splice_candidate": booleanSchema,
+		}, "channel")),
+		"pending_force_closing_channels": arrayOf(objectOf(
+			map[string]any{
+				"channel":             pendingChannelSchema,
+				"closing_txid":        stringSchema,
+				"limbo_balance":       integerSchema,
+				"maturity_height":     integerSchema,
+				"blocks_til_maturity": integerSchema,
+				"recovered_balance":   integerSchema,
+			}, "channel")),
+		"waiting_close_channels": arrayOf(objectOf(map[string]any{
+			"channel":       pendingChannelSchema,
+			"limbo_balance": integerSchema,
+		}, "channel")),
+		"total_limbo_balance": integerSchema,
+		"splicing":            spliceSupportSchema,
+		"interactive_funding": objectOf(map[string]any{
+			"sessions": arrayOf(objectOf(map[string]any{
+				"remote_node_pub":         stringSchema,
+				"channel_point":           stringSchema,
+				"capacity":                integerSchema,
+				"local_contribution_sat":  integerSchema,
+				"remote_contribution_sat": integerSchema,
+			})),
+			"contribution_policy": objectOf(map[string]any{
+				"mode":      stringSchema,
+				"fixed_sat": integerSchema,
+				"max_sat":   integerSchema,
+			}, "mode"),
+		}),
+	}, "pending_open_channels", "pending_force_closing_channels",
+		"waiting_close_channels"),
+	"lnc_estimate_force_close": objectOf(map[string]any{
+		"channel_point":     stringSchema,
+		"chan_id":           stringSchema,
+		"remote_pubkey":     stringSchema,
+		"commitment_type":   stringSchema,
+		"initiator":         booleanSchema,
+		"capacity_sat":      integerSchema,
+		"local_balance_sat": integerSchema,
+		"block_height":      integerSchema,
+		"force_close": objectOf(map[string]any{
+			"csv_delay_blocks":          integerSchema,
+			"to_local_sat":              integerSchema,
+			"to_local_available_blocks": integerSchema,
+			"to_local_estimated_hours":  numberSchema,
+			"htlcs": arrayOf(objectOf(map[string]any{
+				"direction":              stringSchema,
+				"amount_sat":             integerSchema,
+				"expiration_height":      integerSchema,
+				"available_after_blocks": integerSchema,
+				"estimated_hours":        numberSchema,
+				"second_level_fee_sat":   integerSchema,
+				"note":                   stringSchema,
+			}, "direction", "amount_sat", "available_after_blocks")),
+			"longest_wait_blocks":          integerSchema,
+			"longest_wait_estimated_hours": numberSchema,
+			"funds_available_height":       integerSchema,
+			"estimated_available_at":       stringSchema,
+		}, "csv_delay_blocks", "htlcs", "longest_wait_blocks"),
+		"remote_force_close": objectOf(map[string]any{
+			"blocks":          integerSchema,
+			"estimated_hours": numberSchema,
+		}, "blocks"),
+		"cooperative_close": objectOf(map[string]any{
+			"blocks":          integerSchema,
+			"estimated_hours": numberSchema,
+			"peer_online":     booleanSchema,
+			"fee_sat":         integerSchema,
+			"thaw_height":     integerSchema,
+			"note":            stringSchema,
+		}, "blocks", "peer_online"),
+		"fees": objectOf(map[string]any{
+			"anchor_channel":           booleanSchema,
+			"commitment_fee_sat":       integerSchema,
+			"commitment_vsize":         integerSchema,
+			"commitment_sat_per_vbyte": numberSchema,
+			"target_sat_per_vbyte":     numberSchema,
+			"target_source":            stringSchema,
+			"target_error":             stringSchema,
+			"cpfp_fee_sat":             integerSchema,
+			"htlc_fee_sat":             integerSchema,
+			"wallet_funds_needed_sat":  integerSchema,
+			"wallet_confirmed_sat":     integerSchema,
+			"anchor_reserve_sat":       integerSchema,
+			"can_fund_fees":            booleanSchema,
+			"warning":                  stringSchema,
+		}, "anchor_channel", "commitment_fee_sat"),
+		"summary": stringSchema,
+		"note":    stringSchema,
+	}, "channel_point", "force_close", "cooperative_close", "fees"),
+	"lnc_close_progress": objectOf(map[string]any{
+		"block_height": integerSchema,
+		"channels": arrayOf(objectOf(map[string]any{
+			"channel_point":          stringSchema,
+			"remote_node_pub":        stringSchema,
+			"stage":                  stringSchema,
+			"close_type":             stringSchema,
+			"closing_txid":           stringSchema,
+			"limbo_balance_sat":      integerSchema,
+			"recovered_balance_sat":  integerSchema,
+			"settled_balance_sat":    integerSchema,
+			"anchor":                 stringSchema,
+			"blocks_remaining":       integerSchema,
+			"funds_available_height": integerSchema,
+			"estimated_available_at": stringSchema,
+			"close_height":           integerSchema,
+			"close_confirmations":    integerSchema,
+			"outputs": arrayOf(objectOf(map[string]any{
+				"kind":                stringSchema,
+				"outpoint":            stringSchema,
+				"amount_sat":          integerSchema,
+				"stage":               integerSchema,
+				"maturity_height":     integerSchema,
+				"blocks_til_maturity": integerSchema,
+				"status":              stringSchema,
+			}, "kind", "status")),
+			"resolutions": arrayOf(objectOf(map[string]any{
+				"type":       stringSchema,
+				"outcome":    stringSchema,
+				"outpoint":   stringSchema,
+				"amount_sat": integerSchema,
+				"sweep_txid": stringSchema,
+			}, "type", "outcome")),
+			"summary": stringSchema,
+		}, "channel_point", "stage", "summary")),
+		"total_limbo_balance_sat": integerSchema,
+		"note":                    stringSchema,
+	}, "block_height", "channels"),
+	"lnc_forwarding_history": objectOf(map[string]any{
+		"forwards": arrayOf(objectOf(map[string]any{
+			"timestamp_ns":   integerSchema,
+			"chan_id_in":     stringSchema,
+			"chan_id_out":    stringSchema,
+			"peer_alias_in":  stringSchema,
+			"peer_alias_out": stringSchema,
+			"htlc_index_in":  integerSchema,
+			"htlc_index_out": integerSchema,
+			"amt_in_msat":    integerSchema,
+			"amt_out_msat":   integerSchema,
+			"fee_msat":       integerSchema,
+		}, "timestamp_ns", "chan_id_in", "chan_id_out", "fee_msat")),
+		"total_forwards":     integerSchema,
+		"total_fee_msat":     integerSchema,
+		"total_amt_out_msat": integerSchema,
+		"last_offset_index":  integerSchema,
+		"has_more":           booleanSchema,
+	}, "forwards", "total_forwards", "total_fee_msat", "last_offset_index"),
+
+	"lnc_check_channel_policies": objectOf(map[string]any{
+		"channels_checked":       integerSchema,
+		"channels_with_findings": integerSchema,
+		"findings": arrayOf(objectOf(map[string]any{
+			"channel_point":      stringSchema,
+			"chan_id":            stringSchema,
+			"remote_pubkey":      stringSchema,
+			"check":              stringSchema,
+			"severity":           stringSchema,
+			"message":            stringSchema,
+			"max_htlc_msat":      integerSchema,
+			"min_htlc_msat":      integerSchema,
+			"capacity_msat":      integerSchema,
+			"sendable_msat":      integerSchema,
+			"max_in_flight_msat": integerSchema,
+			"peer_min_htlc_msat": integerSchema,
+			"peer_max_htlc_msat": integerSchema,
+		}, "channel_point", "chan_id", "check", "severity", "message")),
+		"finding_count": integerSchema,
+	}, "channels_checked", "findings", "finding_count"),
+	"lnc_list_payments": objectOf(map[string]any{
+		"payments": arrayOf(objectOf(map[string]any{
+			"payment_hash":     stringSchema,
+			"value_sat":        integerSchema,
+			"value_msat":       integerSchema,
+			"payment_preimage": stringSchema,
+			"payment_request":  stringSchema,
+			"status":           stringSchema,
+			"fee_sat":          integerSchema,
+			"fee_msat":         integerSchema,
+			"creation_time_ns": integerSchema,
+			"payment_index":    integerSchema,
+			"failure_reason":   stringSchema,
+			"htlc_count":       integerSchema,
+			"amp":              booleanSchema,
+			"set_id":           stringSchema,
+			"child_payments":   ampChildPaymentsSchema,
+		}, "payment_hash", "status")),
+		"first_index_offset": integerSchema,
+		"last_index_offset":  integerSchema,
+		"total_payments":     integerSchema,
+		"scanned_payments":   integerSchema,
+	}, "payments", "total_payments"),
+	"lnc_track_payment": objectOf(map[string]any{
+		"found":            booleanSchema,
+		"message":          stringSchema,
+		"payment_hash":     stringSchema,
+		"status":           stringSchema,
+		"value_sat":        integerSchema,
+		"fee_sat":          integerSchema,
+		"creation_time_ns": integerSchema,
+		"payment_preimage": stringSchema,
+		"failure_reason":   stringSchema,
+	}, "found"),
+	"lnc_query_mission_control": objectOf(map[string]any{
+		"pairs": arrayOf(objectOf(map[string]any{
+			"from":                stringSchema,
+			"to":                  stringSchema,
+			"last_result":         stringSchema,
+			"failed_at":           stringSchema,
+			"fail_amount_msat":    integerSchema,
+			"succeeded_at":        stringSchema,
+			"success_amount_msat": integerSchema,
+		}, "from", "to", "last_result")),
+		"count":            integerSchema,
+		"failing_pairs":    integerSchema,
+		"succeeding_pairs": integerSchema,
+		"truncated":        booleanSchema,
+		"note":             stringSchema,
+	}, "pairs", "count", "failing_pairs", "succeeding_pairs", "truncated"),
+	"lnc_query_payment_probability": objectOf(map[string]any{
+		"from_node":           stringSchema,
+		"to_node":             stringSchema,
+		"amount_sat":          integerSchema,
+		"probability":         numberSchema,
+		"probability_percent": numberSchema,
+		"has_history":         booleanSchema,
+		"history": objectOf(map[string]any{
+			"last_result":         stringSchema,
+			"failed_at":           stringSchema,
+			"fail_amount_msat":    integerSchema,
+			"succeeded_at":        stringSchema,
+			"success_amount_msat": integerSchema,
+		}, "last_result"),
+	}, "from_node", "to_node", "amount_sat", "probability", "has_history"),
+	"lnc_get_mission_control_config": missionControlConfigSchema,
+	"lnc_set_mission_control_config": objectOf(map[string]any{
+		"dry_run":  booleanSchema,
+		"previous": missionControlConfigSchema,
+		"config":   missionControlConfigSchema,
+	}, "dry_run", "previous", "config"),
+	"lnc_estimate_route_fee": objectOf(map[string]any{
+		"method":           stringSchema,
+		"destination":      stringSchema,
+		"amount_sat":       integerSchema,
+		"routing_fee_msat": integerSchema,
+		"time_lock_delay":  integerSchema,
+		"feasible":         booleanSchema,
+		"failure_reason":   stringSchema,
+	}, "method", "destination", "amount_sat", "routing_fee_msat",
+		"time_lock_delay", "feasible"),
+	"lnc_query_routes": objectOf(map[string]any{
+		"feasible":      booleanSchema,
+		"reason":        stringSchema,
+		"destination":   stringSchema,
+		"amount_sat":    integerSchema,
+		"fee_limit_sat": integerSchema,
+		"success_prob":  numberSchema,
+		"route": objectOf(map[string]any{
+			"total_time_lock": integerSchema,
+			"total_fees_msat": integerSchema,
+			"total_amt_msat":  integerSchema,
+			"hop_count":       integerSchema,
+			"hops": arrayOf(objectOf(map[string]any{
+				"chan_id":             stringSchema,
+				"pub_key":             stringSchema,
+				"amt_to_forward_msat": integerSchema,
+				"fee_msat":            integerSchema,
+				"expiry":              integerSchema,
+			}, "chan_id", "pub_key")),
+		}, "total_time_lock", "total_fees_msat", "hops"),
+	}, "feasible", "destination", "amount_sat"),
+	"lnc_build_route": objectOf(map[string]any{
+		"route": objectOf(map[string]any{
+			"total_time_lock": integerSchema,
+			"total_fees_msat": integerSchema,
+			"total_amt_msat":  integerSchema,
+			"hop_count":       integerSchema,
+			"hops": arrayOf(objectOf(map[string]any{
+				"chan_id":             stringSchema,
+				"pub_key":             stringSchema,
+				"amt_to_forward_msat": integerSchema,
+				"fee_msat":            integerSchema,
+				"expiry":              integerSchema,
+				"timelock_delta":      integerSchema,
+				"mpp_record": objectOf(map[string]any{
+					"payment_addr":   stringSchema,
+					"total_amt_msat": integerSchema,
+				}, "payment_addr", "total_amt_msat"),
+			}, "chan_id", "pub_key", "fee_msat", "expiry",
+				"timelock_delta")),
+		}, "total_time_lock", "total_fees_msat", "total_amt_msat",
+			"hops"),
+		"note": stringSchema,
+	}, "route"),
+
+	"lnc_list_unspent": objectOf(map[string]any{
+		"utxos": arrayOf(objectOf(map[string]any{
+			"address":       stringSchema,
+			"amount_sat":    integerSchema,
+			"pk_script":     stringSchema,
+			"outpoint":      stringSchema,
+			"confirmations": integerSchema,
+		}, "outpoint", "amount_sat")),
+		"total_utxos":      integerSchema,
+		"total_amount_sat": integerSchema,
+	}, "utxos", "total_utxos", "total_amount_sat"),
+	"lnc_get_transactions": objectOf(map[string]any{
+		"transactions": arrayOf(objectOf(map[string]any{
+			"tx_hash":           stringSchema,
+			"amount":            integerSchema,
+			"num_confirmations": integerSchema,
+			"block_hash":        stringSchema,
+			"block_height":      integerSchema,
+			"time_stamp":        integerSchema,
+			"total_fees":        integerSchema,
+			"raw_tx_hex":        stringSchema,
+			"label":             stringSchema,
+			"label_type":        stringSchema,
+			"label_chan_id":     stringSchema,
+			"previous_outpoints": arrayOf(objectOf(map[string]any{
+				"outpoint":      stringSchema,
+				"is_our_output": booleanSchema,
+			})),
+		}, "tx_hash", "amount")),
+		"total_transactions": integerSchema,
+	}, "transactions", "total_transactions"),
+	"lnc_estimate_fee": objectOf(map[string]any{
+		"fee_estimates": map[string]any{
+			"type": "object",
+			"additionalProperties": objectOf(map[string]any{
+				"fee_sat":       integerSchema,
+				"sat_per_vbyte": integerSchema,
+			}, "sat_per_vbyte"),
+		},
+		"mempool_context": mempoolContextSchema,
+	}, "fee_estimates"),
+	"lnc_validate_address": objectOf(map[string]any{
+		"address":          stringSchema,
+		"valid":            booleanSchema,
+		"error":            stringSchema,
+		"type":             stringSchema,
+		"networks":         arrayOf(stringSchema),
+		"node_network":     stringSchema,
+		"network_mismatch": booleanSchema,
+		"warning":          stringSchema,
+	}, "address", "valid"),
+
+	"lnc_list_peers": objectOf(map[string]any{
+		"peers": arrayOf(objectOf(map[string]any{
+			"pub_key":    stringSchema,
+			"address":    stringSchema,
+			"bytes_sent": integerSchema,
+			"bytes_recv": integerSchema,
+			"sat_sent":   integerSchema,
+			"sat_recv":   integerSchema,
+			"inbound":    booleanSchema,
+			"ping_time":  integerSchema,
+			"sync_type":  stringSchema,
+			"features": arrayOf(objectOf(map[string]any{
+				"feature":     integerSchema,
+				"name":        stringSchema,
+				"is_required": booleanSchema,
+				"is_known":    booleanSchema,
+			}, "feature")),
+			"errors": arrayOf(objectOf(map[string]any{
+				"error":     stringSchema,
+				"timestamp": integerSchema,
+			})),
+			"flap_count": integerSchema,
+			"last_flap":  nullableObjectSchema,
+			"contact":    contactSchema,
+		}, "pub_key")),
+		"total_peers": integerSchema,
+	}, "peers", "total_peers"),
+	"lnc_describe_graph": objectOf(map[string]any{
+		"total_nodes":         integerSchema,
+		"total_edges":         integerSchema,
+		"include_unannounced": booleanSchema,
+		"sample_nodes": arrayOf(objectOf(map[string]any{
+			"pub_key":   stringSchema,
+			"alias":     stringSchema,
+			"addresses": arrayOf(stringSchema),
+			"color":     stringSchema,
+		})),
+		"sample_edges": arrayOf(graphEdgeSchema),
+	}, "total_nodes", "total_edges"),
+	"lnc_export_anonymized_graph": objectOf(map[string]any{
+		"nodes": arrayOf(objectOf(map[string]any{
+			"id":            stringSchema,
+			"channel_count": integerSchema,
+		}, "id", "channel_count")),
+		"edges": arrayOf(objectOf(map[string]any{
+			"id":           stringSchema,
+			"node1":        stringSchema,
+			"node2":        stringSchema,
+			"capacity":     integerSchema,
+			"node1_policy": anonymizedPolicySchema,
+			"node2_policy": anonymizedPolicySchema,
+		}, "id", "node1", "node2", "capacity")),
+		"node_count": integerSchema,
+		"edge_count": integerSchema,
+		"anonymization": objectOf(map[string]any{
+			"method":   stringSchema,
+			"id_bytes": integerSchema,
+			"stripped": arrayOf(stringSchema),
+		}, "method", "id_bytes", "stripped"),
+		"own_node_id": stringSchema,
+		"note":        stringSchema,
+	}, "nodes", "edges", "node_count", "edge_count", "anonymization"),
+	"lnc_get_node_info": objectOf(map[string]any{
+		"pub_key":        stringSchema,
+		"alias":          stringSchema,
+		"addresses":      arrayOf(stringSchema),
+		"color":          stringSchema,
+		"num_channels":   integerSchema,
+		"total_capacity": integerSchema,
+		"channels":       arrayOf(graphEdgeSchema),
+		"contact":        contactSchema,
+	}, "pub_key"),
+	"lnc_get_chan_info": objectOf(map[string]any{
+		"chan_id":       stringSchema,
+		"channel_point": stringSchema,
+		"capacity_sat":  integerSchema,
+		"last_update":   integerSchema,
+		"node1_pub":     stringSchema,
+		"node2_pub":     stringSchema,
+		"node1_policy":  routingPolicySchema,
+		"node2_policy":  routingPolicySchema,
+	}, "chan_id", "channel_point", "capacity_sat", "node1_pub",
+		"node2_pub"),
+	"lnc_list_contacts": objectOf(map[string]any{
+		"contacts":       arrayOf(contactSchema),
+		"count":          integerSchema,
+		"total_contacts": integerSchema,
+	}, "contacts", "count", "total_contacts"),
+	"lnc_set_contact": objectOf(map[string]any{
+		"contact": contactSchema,
+		"created": booleanSchema,
+	}, "contact", "created"),
+	"lnc_delete_contact": objectOf(map[string]any{
+		"pubkey":  stringSchema,
+		"deleted": booleanSchema,
+	}, "pubkey", "deleted"),
+	"lnc_watch_node": objectOf(map[string]any{
+		"node":    watchedNodeSchema,
+		"created": booleanSchema,
+	}, "node", "created"),
+	"lnc_unwatch_node": objectOf(map[string]any{
+		"pubkey":  stringSchema,
+		"removed": booleanSchema,
+	}, "pubkey", "removed"),
+	"lnc_watched_nodes": objectOf(map[string]any{
+		"nodes":        arrayOf(watchedNodeSchema),
+		"count":        integerSchema,
+		"refreshed":    booleanSchema,
+		"change_count": integerSchema,
+	}, "nodes", "count", "refreshed", "change_count"),
+
+	"lnc_lsp_get_info": objectOf(map[string]any{
+		"lsp":     stringSchema,
+		"url":     stringSchema,
+		"options": nullableObjectSchema,
+		"inbound_check": objectOf(map[string]any{
+			"required_inbound_sat":      integerSchema,
+			"current_inbound_sat":       integerSchema,
+			"sufficient":                booleanSchema,
+			"exceeds_lsp_maximum":       booleanSchema,
+			"shortfall_sat":             integerSchema,
+			"suggested_lsp_balance_sat": integerSchema,
+		}, "required_inbound_sat", "current_inbound_sat", "sufficient"),
+	}, "lsp", "url"),
+	"lnc_lsp_get_order":    lspOrderSchema,
+	"lnc_lsp_create_order": lspOrderSchema,
+
+	"lnc_sandbox_status": objectOf(map[string]any{
+		"sandbox_mode":    booleanSchema,
+		"connected":       booleanSchema,
+		"message":         stringSchema,
+		"node_pubkey":     stringSchema,
+		"alias":           stringSchema,
+		"network":         stringSchema,
+		"block_height":    integerSchema,
+		"synced_to_chain": booleanSchema,
+	}, "sandbox_mode", "connected"),
+	"lnc_get_sync_status": objectOf(map[string]any{
+		"network":               stringSchema,
+		"block_height":          integerSchema,
+		"best_header_timestamp": stringSchema,
+		"header_age_seconds":    integerSchema,
+		"synced_to_chain":       booleanSchema,
+		"synced_to_graph":       booleanSchema,
+		"tip_source":            stringSchema,
+		"tip_error":             stringSchema,
+		"external_tip_height":   integerSchema,
+		"blocks_behind":         integerSchema,
+		"status":                stringSchema,
+		"warnings": arrayOf(objectOf(map[string]any{
+			"check":   stringSchema,
+			"message": stringSchema,
+		}, "check", "message")),
+	}, "block_height", "best_header_timestamp", "header_age_seconds",
+		"synced_to_chain", "status", "warnings"),
+	"lnc_get_recovery_info": objectOf(map[string]any{
+		"recovery_mode":               booleanSchema,
+		"recovery_finished":           booleanSchema,
+		"progress":                    numberSchema,
+		"progress_percent":            numberSchema,
+		"scan_rate_percent_per_hour":  numberSchema,
+		"estimated_remaining_seconds": integerSchema,
+		"estimated_finish_at":         stringSchema,
+		"note":                        stringSchema,
+	}, "recovery_mode", "recovery_finished", "progress",
+		"progress_percent"),
+	"lnc_config_check": objectOf(map[string]any{
+		"settings_reported": integerSchema,
+		"findings": arrayOf(objectOf(map[string]any{
+			"check":          stringSchema,
+			"severity":       stringSchema,
+			"message":        stringSchema,
+			"recommendation": stringSchema,
+			"settings":       objectSchema,
+		}, "check", "severity", "message", "recommendation")),
+		"finding_count":   integerSchema,
+		"recommendations": arrayOf(stringSchema),
+	}, "findings", "finding_count", "recommendations"),
+	"lnc_get_debug_info": objectOf(map[string]any{
+		"redacted":           booleanSchema,
+		"config":             objectSchema,
+		"config_count":       integerSchema,
+		"config_total":       integerSchema,
+		"redacted_keys":      arrayOf(stringSchema),
+		"log":                arrayOf(stringSchema),
+		"log_lines_returned": integerSchema,
+		"log_lines_matched":  integerSchema,
+		"truncated_lines":    integerSchema,
+	}, "redacted"),
+	"lnc_security_report": objectOf(map[string]any{
+		"block_height": integerSchema,
+		"channel_backup_status": objectOf(map[string]any{
+			"channels_expected":        integerSchema,
+			"channels_in_backup":       integerSchema,
+			"missing_from_backup":      arrayOf(stringSchema),
+			"size_bytes":               integerSchema,
+			"sha256":                   stringSchema,
+			"last_channel_open_height": integerSchema,
+			"blocks_since_last_open":   integerSchema,
+			"error":                    stringSchema,
+		}, "channels_expected"),
+		"towers": objectOf(map[string]any{
+			"status":   stringSchema,
+			"guidance": stringSchema,
+			"error":    stringSchema,
+			"towers": arrayOf(objectOf(map[string]any{
+				"pubkey":    stringSchema,
+				"addresses": arrayOf(stringSchema),
+				"sessions": arrayOf(objectOf(map[string]any{
+					"policy_type":              stringSchema,
+					"active_session_candidate": booleanSchema,
+					"num_sessions":             integerSchema,
+				}, "policy_type", "active_session_candidate")),
+			}, "pubkey", "sessions")),
+			"stats": objectOf(map[string]any{
+				"num_backups":            integerSchema,
+				"num_pending_backups":    integerSchema,
+				"num_failed_backups":     integerSchema,
+				"num_sessions_acquired":  integerSchema,
+				"num_sessions_exhausted": integerSchema,
+			}),
+		}, "status", "towers"),
+		"channels": arrayOf(objectOf(map[string]any{
+			"channel_point":   stringSchema,
+			"chan_id":         stringSchema,
+			"remote_pubkey":   stringSchema,
+			"commitment_type": stringSchema,
+			"tower_policy":    stringSchema,
+			"in_backup":       booleanSchema,
+			"tower_covered":   booleanSchema,
+		}, "channel_point", "in_backup", "tower_covered")),
+		"breaches": arrayOf(objectOf(map[string]any{
+			"channel_point":       stringSchema,
+			"chan_id":             stringSchema,
+			"remote_pubkey":       stringSchema,
+			"close_height":        integerSchema,
+			"closing_tx_hash":     stringSchema,
+			"capacity_sat":        integerSchema,
+			"settled_balance_sat": integerSchema,
+		}, "channel_point", "close_height")),
+		"note":   stringSchema,
+		"status": stringSchema,
+		"warnings": arrayOf(objectOf(map[string]any{
+			"check":   stringSchema,
+			"message": stringSchema,
+		}, "check", "message")),
+	}, "block_height", "channel_backup_status", "towers", "channels",
+		"breaches", "status", "warnings"),
+	"lnc_list_towers": objectOf(map[string]any{
+		"towers": arrayOf(objectOf(map[string]any{
+			"pubkey":    stringSchema,
+			"addresses": arrayOf(stringSchema),
+			"active":    booleanSchema,
+			"sessions": arrayOf(objectOf(map[string]any{
+				"policy_type":              stringSchema,
+				"active_session_candidate": booleanSchema,
+				"num_sessions":             integerSchema,
+				"sessions": arrayOf(objectOf(map[string]any{
+					"id":                  stringSchema,
+					"num_backups":         integerSchema,
+					"num_pending_backups": integerSchema,
+					"max_backups":         integerSchema,
+					"sweep_sat_per_vbyte": integerSchema,
+				}, "id", "num_backups", "max_backups")),
+			}, "policy_type", "active_session_candidate")),
+		}, "pubkey", "active", "sessions")),
+		"count":            integerSchema,
+		"active_towers":    integerSchema,
+		"covered_policies": arrayOf(stringSchema),
+		"warning":          stringSchema,
+	}, "towers", "count", "active_towers", "covered_policies"),
+	"lnc_tower_stats": objectOf(map[string]any{
+		"num_backups":            integerSchema,
+		"num_pending_backups":    integerSchema,
+		"num_failed_backups":     integerSchema,
+		"num_sessions_acquired":  integerSchema,
+		"num_sessions_exhausted": integerSchema,
+		"healthy":                booleanSchema,
+		"warning":                stringSchema,
+		"note":                   stringSchema,
+	}, "num_backups", "num_pending_backups", "num_failed_backups",
+		"healthy"),
+	"lnc_tower_policy": objectOf(map[string]any{
+		"policies": arrayOf(objectOf(map[string]any{
+			"policy_type":         stringSchema,
+			"enabled":             booleanSchema,
+			"max_updates":         integerSchema,
+			"sweep_sat_per_vbyte": integerSchema,
+		}, "policy_type", "enabled")),
+		"note": stringSchema,
+	}, "policies"),
+	"lnc_export_channel_backups": objectOf(map[string]any{
+		"kind":          stringSchema,
+		"channel_point": stringSchema,
+		"backup":        stringSchema,
+		"size_bytes":    integerSchema,
+		"sha256":        stringSchema,
+		"multi_chan_backup": objectOf(map[string]any{
+			"backup":         stringSchema,
+			"size_bytes":     integerSchema,
+			"sha256":         stringSchema,
+			"channel_points": arrayOf(stringSchema),
+			"channel_count":  integerSchema,
+		}, "backup", "size_bytes", "sha256", "channel_points"),
+		"single_chan_backups": arrayOf(objectOf(map[string]any{
+			"channel_point": stringSchema,
+			"backup":        stringSchema,
+			"size_bytes":    integerSchema,
+			"sha256":        stringSchema,
+		}, "channel_point", "backup")),
+		"note": stringSchema,
+	}, "kind", "note"),
+	"lnc_verify_channel_backup": objectOf(map[string]any{
+		"source":              stringSchema,
+		"kind":                stringSchema,
+		"valid":               booleanSchema,
+		"reason":              stringSchema,
+		"channel_points":      arrayOf(stringSchema),
+		"channel_count":       integerSchema,
+		"missing_channels":    arrayOf(stringSchema),
+		"covers_all_channels": booleanSchema,
+	}, "source", "kind", "valid"),
+	"lnc_server_stats": objectOf(map[string]any{
+		"connected":          booleanSchema,
+		"client_generation":  integerSchema,
+		"subservers":         subserverReportSchema,
+		"sandbox_connected":  booleanSchema,
+		"sandbox_subservers": subserverReportSchema,
+		"degraded_tools":     arrayOf(stringSchema),
+	}, "connected", "client_generation", "subservers", "degraded_tools"),
+	"lnc_verify_response": objectOf(map[string]any{
+		"valid":               booleanSchema,
+		"algorithm":           stringSchema,
+		"pubkey":              stringSchema,
+		"signed_by_this_node": booleanSchema,
+	}, "valid", "algorithm"),
+	"lnc_list_operations": objectOf(map[string]any{
+		"reconciled":      booleanSchema,
+		"reconcile_error": stringSchema,
+		"in_flight":       integerSchema,
+		"operations": arrayOf(objectOf(map[string]any{
+			"kind":       stringSchema,
+			"key":        stringSchema,
+			"tool":       stringSchema,
+			"state":      stringSchema,
+			"detail":     objectSchema,
+			"started_at": stringSchema,
+			"updated_at": stringSchema,
+		}, "kind", "key", "tool", "state", "started_at", "updated_at")),
+	}, "reconciled", "in_flight", "operations"),
+	"lnc_schedule_operation": objectOf(map[string]any{
+		"operation": scheduledOperationSchema,
+	}, "operation"),
+	"lnc_list_scheduled_operations": objectOf(map[string]any{
+		"operations": arrayOf(scheduledOperationSchema),
+		"scheduled":  integerSchema,
+		"deleted":    integerSchema,
+	}, "operations", "scheduled", "deleted"),
+	"lnc_cancel_scheduled_operation": objectOf(map[string]any{
+		"operation": scheduledOperationSchema,
+	}, "operation"),
+	"lnc_restore_scheduled_operation": objectOf(map[string]any{
+		"operation": scheduledOperationSchema,
+	}, "operation"),
+	"lnc_get_output_schema": objectOf(map[string]any{
+		"schemas": objectSchema,
+	}, "schemas"),
+
+	// lnc_splice_channel reports an Unsupported error until lnd exposes
+	// a splice RPC; a successful result will carry the splice status.
+	"lnc_splice_channel": objectOf(map[string]any{
+		"channel_point": stringSchema,
+		"status":        stringSchema,
+	}),
+	"lnc_open_channel": objectOf(map[string]any{
+		"node_pubkey":   stringSchema,
+		"amount_sat":    integerSchema,
+		"push_sat":      integerSchema,
+		"private":       booleanSchema,
+		"status":        stringSchema,
+		"funding_txid":  stringSchema,
+		"output_index":  integerSchema,
+		"channel_point": stringSchema,
+		"message":       stringSchema,
+		"status_updates": arrayOf(objectOf(map[string]any{
+			"status": stringSchema,
+			"at_ms":  integerSchema,
+		}, "status")),
+	}, "node_pubkey", "amount_sat", "status", "channel_point"),
+	"lnc_batch_open_channels": objectOf(map[string]any{
+		"status":            stringSchema,
+		"funding_txid":      stringSchema,
+		"channel_count":     integerSchema,
+		"total_amount_sat":  integerSchema,
+		"estimated_fee_sat": integerSchema,
+		"sat_per_vbyte":     integerSchema,
+		"label":             stringSchema,
+		"message":           stringSchema,
+		"channels": arrayOf(objectOf(map[string]any{
+			"node_pubkey":   stringSchema,
+			"amount_sat":    integerSchema,
+			"push_sat":      integerSchema,
+			"private":       booleanSchema,
+			"output_index":  integerSchema,
+			"channel_point": stringSchema,
+		}, "node_pubkey", "amount_sat", "channel_point")),
+	}, "status", "funding_txid", "channel_count", "total_amount_sat",
+		"estimated_fee_sat", "channels"),
+	"lnc_add_invoice": objectOf(map[string]any{
+		"payment_request":  stringSchema,
+		"payment_hash":     stringSchema,
+		"payment_addr":     stringSchema,
+		"add_index":        integerSchema,
+		"state":            stringSchema,
+		"value_msat":       integerSchema,
+		"expiry_seconds":   integerSchema,
+		"private":          booleanSchema,
+		"route_hint_count": integerSchema,
+		"amp":              booleanSchema,
+		"note":             stringSchema,
+	}, "payment_request", "payment_hash", "state"),
+	"lnc_add_hold_invoice": objectOf(map[string]any{
+		"payment_request":  stringSchema,
+		"payment_hash":     stringSchema,
+		"payment_addr":     stringSchema,
+		"add_index":        integerSchema,
+		"state":            stringSchema,
+		"value_msat":       integerSchema,
+		"expiry_seconds":   integerSchema,
+		"private":          booleanSchema,
+		"route_hint_count": integerSchema,
+		"note":             stringSchema,
+	}, "payment_request", "payment_hash", "state"),
+	"lnc_list_accounts": objectOf(map[string]any{
+		"accounts": arrayOf(objectOf(map[string]any{
+			"name":                   stringSchema,
+			"address_type":           stringSchema,
+			"extended_public_key":    stringSchema,
+			"master_key_fingerprint": stringSchema,
+			"derivation_path":        stringSchema,
+			"key_scope":              stringSchema,
+			"purpose":                integerSchema,
+			"coin_type":              integerSchema,
+			"account_index":          integerSchema,
+			"external_key_count":     integerSchema,
+			"internal_key_count":     integerSchema,
+			"watch_only":             booleanSchema,
+			"imported":               booleanSchema,
+		}, "name", "address_type", "watch_only", "imported")),
+		"count":            integerSchema,
+		"watch_only_count": integerSchema,
+	}, "accounts", "count", "watch_only_count"),
+	"lnc_list_addresses": objectOf(map[string]any{
+		"accounts": arrayOf(objectOf(map[string]any{
+			"name":            stringSchema,
+			"address_type":    stringSchema,
+			"derivation_path": stringSchema,
+			"address_count":   integerSchema,
+			"balance_sat":     integerSchema,
+			"addresses": arrayOf(objectOf(map[string]any{
+				"address":         stringSchema,
+				"change":          booleanSchema,
+				"balance_sat":     integerSchema,
+				"derivation_path": stringSchema,
+				"index":           integerSchema,
+				"public_key":      stringSchema,
+			}, "address", "change", "balance_sat")),
+		}, "name", "address_type", "address_count", "balance_sat",
+			"addresses")),
+		"listed_addresses":  integerSchema,
+		"total_addresses":   integerSchema,
+		"funded_addresses":  integerSchema,
+		"total_balance_sat": integerSchema,
+		"truncated":         booleanSchema,
+	}, "accounts", "listed_addresses", "total_addresses", "truncated"),
+	"lnc_list_sweeps": objectOf(map[string]any{
+		"sweeps": arrayOf(objectOf(map[string]any{
+			"txid":             stringSchema,
+			"confirmations":    integerSchema,
+			"confirmed":        booleanSchema,
+			"block_height":     integerSchema,
+			"time_stamp":       integerSchema,
+			"received_sat":     integerSchema,
+			"fee_sat":          integerSchema,
+			"swept_outpoints":  arrayOf(stringSchema),
+			"wallet_addresses": arrayOf(stringSchema),
+			"label":            stringSchema,
+			"label_type":       stringSchema,
+			"label_chan_id":    stringSchema,
+		}, "txid", "confirmed", "received_sat", "fee_sat",
+			"swept_outpoints")),
+		"count":              integerSchema,
+		"unconfirmed_count":  integerSchema,
+		"total_received_sat": integerSchema,
+		"total_fees_sat":     integerSchema,
+	}, "sweeps", "count", "total_received_sat", "total_fees_sat"),
+	"lnc_list_bumpable": objectOf(map[string]any{
+		"block_height": integerSchema,
+		"pending_sweeps": arrayOf(objectOf(map[string]any{
+			"outpoint":                stringSchema,
+			"witness_type":            stringSchema,
+			"amount_sat":              integerSchema,
+			"sat_per_vbyte":           integerSchema,
+			"requested_sat_per_vbyte": integerSchema,
+			"broadcast_attempts":      integerSchema,
+			"immediate":               booleanSchema,
+			"budget_sat":              integerSchema,
+			"deadline_height":         integerSchema,
+			"blocks_to_deadline":      integerSchema,
+			"maturity_height":         integerSchema,
+			"bump":                    stringSchema,
+		}, "outpoint", "bump")),
+		"unconfirmed_outputs": arrayOf(objectOf(map[string]any{
+			"outpoint":   stringSchema,
+			"address":    stringSchema,
+			"amount_sat": integerSchema,
+			"bump":       stringSchema,
+		}, "outpoint", "bump")),
+		"total_bumpable": integerSchema,
+	}, "pending_sweeps", "unconfirmed_outputs", "total_bumpable"),
+	"lnc_pending_sweeps": objectOf(map[string]any{
+		"block_height": integerSchema,
+		"pending_sweeps": arrayOf(objectOf(map[string]any{
+			"outpoint":                stringSchema,
+			"witness_type":            stringSchema,
+			"category":                stringSchema,
+			"amount_sat":              integerSchema,
+			"sat_per_vbyte":           integerSchema,
+			"requested_sat_per_vbyte": integerSchema,
+			"broadcast_attempts":      integerSchema,
+			"immediate":               booleanSchema,
+			"budget_sat":              integerSchema,
+			"deadline_height":         integerSchema,
+			"blocks_to_deadline":      integerSchema,
+			"maturity_height":         integerSchema,
+			"mature":                  booleanSchema,
+			"blocks_until_mature":     integerSchema,
+			"next_broadcast_height":   integerSchema,
+		}, "outpoint", "category", "next_broadcast_height")),
+		"count": integerSchema,
+		"counts": map[string]any{
+			"type":                 "object",
+			"additionalProperties": integerSchema,
+		},
+		"total_amount_sat": integerSchema,
+		"total_budget_sat": integerSchema,
+	}, "block_height", "pending_sweeps", "count", "counts"),
+	"lnc_required_reserve": objectOf(map[string]any{
+		"required_reserve_sat":        integerSchema,
+		"reserve_per_channel_sat":     integerSchema,
+		"max_reserve_sat":             integerSchema,
+		"capped":                      booleanSchema,
+		"open_public_anchor_channels": integerSchema,
+		"confirmed_balance_sat":       integerSchema,
+		"anchor_reserve_sat":          integerSchema,
+		"spendable_sat":               integerSchema,
+		"shortfall_sat":               integerSchema,
+		"additional_public_channels":  integerSchema,
+		"projected_reserve_sat":       integerSchema,
+		"projected_spendable_sat":     integerSchema,
+	}, "required_reserve_sat", "confirmed_balance_sat", "spendable_sat",
+		"shortfall_sat"),
+	"lnc_bump_fee": objectOf(map[string]any{
+		"outpoint":               stringSchema,
+		"bump":                   stringSchema,
+		"sat_per_vbyte":          integerSchema,
+		"previous_sat_per_vbyte": integerSchema,
+		"budget_sat":             integerSchema,
+		"immediate":              booleanSchema,
+		"status":                 stringSchema,
+	}, "outpoint", "bump", "sat_per_vbyte", "status"),
+	"lnc_bake_macaroon": objectOf(map[string]any{
+		"macaroon":    stringSchema,
+		"root_key_id": integerSchema,
+		"permissions": arrayOf(stringSchema),
+		"expires_at":  stringSchema,
+		"ip_address":  stringSchema,
+		"warnings":    arrayOf(stringSchema),
+		"note":        stringSchema,
+	}, "macaroon", "root_key_id", "permissions", "warnings"),
+	"lnc_list_macaroon_ids": objectOf(map[string]any{
+		"root_key_ids": arrayOf(integerSchema),
+		"count":        integerSchema,
+		"note":         stringSchema,
+	}, "root_key_ids", "count"),
+	"lnc_list_permissions": objectOf(map[string]any{
+		"methods": arrayOf(objectOf(map[string]any{
+			"method":      stringSchema,
+			"permissions": arrayOf(stringSchema),
+		}, "method", "permissions")),
+		"count":         integerSchema,
+		"total_methods": integerSchema,
+		"permissions": arrayOf(objectOf(map[string]any{
+			"permission":   stringSchema,
+			"method_count": integerSchema,
+		}, "permission", "method_count")),
+		"note": stringSchema,
+	}, "methods", "count", "total_methods", "permissions"),
+	"lnc_delete_macaroon_id": objectOf(map[string]any{
+		"root_key_id": integerSchema,
+		"deleted":     booleanSchema,
+		"message":     stringSchema,
+	}, "root_key_id", "deleted"),
+	"lnc_fund_psbt": objectOf(map[string]any{
+		"psbt":                stringSchema,
+		"txid":                stringSchema,
+		"input_count":         integerSchema,
+		"output_count":        integerSchema,
+		"change_output_index": integerSchema,
+		"fee_sat":             integerSchema,
+		"locked_inputs": arrayOf(objectOf(map[string]any{
+			"outpoint":   stringSchema,
+			"lock_id":    stringSchema,
+			"amount_sat": integerSchema,
+			"expires_at": stringSchema,
+		}, "outpoint", "lock_id", "amount_sat", "expires_at")),
+		"expires_at": stringSchema,
+		"note":       stringSchema,
+	}, "psbt", "txid", "input_count", "output_count", "locked_inputs",
+		"expires_at"),
+	"lnc_finalize_psbt": objectOf(map[string]any{
+		"txid":        stringSchema,
+		"signed_psbt": stringSchema,
+		"raw_tx":      stringSchema,
+		"fee_sat":     integerSchema,
+		"note":        stringSchema,
+	}, "txid", "signed_psbt", "raw_tx"),
+	"lnc_list_leases": objectOf(map[string]any{
+		"leases": arrayOf(objectOf(map[string]any{
+			"outpoint":           stringSchema,
+			"lock_id":            stringSchema,
+			"lnd_internal":       booleanSchema,
+			"amount_sat":         integerSchema,
+			"pk_script":          stringSchema,
+			"expires_at":         stringSchema,
+			"expires_in_seconds": integerSchema,
+			"expired":            booleanSchema,
+		}, "outpoint", "lock_id", "amount_sat", "expires_at")),
+		"count":              integerSchema,
+		"total_leased_sat":   integerSchema,
+		"locked_balance_sat": integerSchema,
+		"note":               stringSchema,
+	}, "leases", "count", "total_leased_sat"),
+	"lnc_release_output": objectOf(map[string]any{
+		"outpoint": stringSchema,
+		"lock_id":  stringSchema,
+		"released": booleanSchema,
+		"status":   stringSchema,
+	}, "outpoint", "lock_id", "released"),
+	"lnc_label_transaction": objectOf(map[string]any{
+		"txid":                stringSchema,
+		"label":               stringSchema,
+		"previous_label":      stringSchema,
+		"previous_label_type": stringSchema,
+	}, "txid", "label"),
+	"lnc_abandon_channel": objectOf(map[string]any{
+		"channel_point":             stringSchema,
+		"abandoned":                 booleanSchema,
+		"pending_funding_shim_only": booleanSchema,
+		"previous_state":            stringSchema,
+		"network":                   stringSchema,
+	}, "channel_point", "abandoned", "pending_funding_shim_only",
+		"network"),
+	"lnc_cancel_invoice": holdInvoiceResultSchema,
+	"lnc_settle_invoice": holdInvoiceResultSchema,
+	"lnc_pay_invoice":    paymentResultSchema,
+	"lnc_keysend":        paymentResultSchema,
+	"lnc_send_to_route": objectOf(map[string]any{
+		"payment_hash":     stringSchema,
+		"attempt_id":       integerSchema,
+		"status":           stringSchema,
+		"succeeded":        booleanSchema,
+		"payment_preimage": stringSchema,
+		"total_amt_msat":   integerSchema,
+		"total_fees_msat":  integerSchema,
+		"total_time_lock":  integerSchema,
+		"hops": arrayOf(objectOf(map[string]any{
+			"index":               integerSchema,
+			"chan_id":             integerSchema,
+			"pub_key":             stringSchema,
+			"amt_to_forward_msat": integerSchema,
+			"fee_msat":            integerSchema,
+			"expiry":              integerSchema,
+			"outcome":             stringSchema,
+		}, "index", "chan_id", "pub_key", "outcome")),
+		"failure": objectOf(map[string]any{
+			"code":                 stringSchema,
+			"failure_source_index": integerSchema,
+			"origin":               stringSchema,
+			"failing_node":         stringSchema,
+			"failing_channel":      integerSchema,
+			"htlc_msat":            integerSchema,
+			"cltv_expiry":          integerSchema,
+			"height":               integerSchema,
+			"channel_update": objectOf(map[string]any{
+				"chan_id":           integerSchema,
+				"timestamp":         integerSchema,
+				"base_fee_msat":     integerSchema,
+				"fee_rate_ppm":      integerSchema,
+				"time_lock_delta":   integerSchema,
+				"htlc_minimum_msat": integerSchema,
+				"htlc_maximum_msat": integerSchema,
+				"disabled":          booleanSchema,
+			}),
+		}, "code", "failure_source_index", "origin"),
+	}, "payment_hash", "status", "succeeded", "hops"),
+
+	// lnc_send_coins returns a preview with a confirmation_id on the
+	// first call and the broadcast transaction on the second.
+	"lnc_send_coins": objectOf(map[string]any{
+		"confirmed":          booleanSchema,
+		"address":            stringSchema,
+		"amount_sat":         integerSchema,
+		"send_all":           booleanSchema,
+		"label":              stringSchema,
+		"target_conf":        integerSchema,
+		"sat_per_vbyte":      integerSchema,
+		"estimated_fee_sat":  integerSchema,
+		"wallet_balance_sat": integerSchema,
+		"confirmation_id":    stringSchema,
+		"expires_at":         stringSchema,
+		"message":            stringSchema,
+		"txid":               stringSchema,
+		"mempool_context":    mempoolContextSchema,
+	}, "confirmed", "address", "send_all"),
+	"lnc_new_address": objectOf(map[string]any{
+		"address":    stringSchema,
+		"type":       stringSchema,
+		"account":    stringSchema,
+		"amount_sat": integerSchema,
+		"bip21_uri":  stringSchema,
+	}, "address", "type", "bip21_uri"),
+	"lnc_update_channel_policy": objectOf(map[string]any{
+		"scope":          stringSchema,
+		"channel_point":  stringSchema,
+		"dry_run":        booleanSchema,
+		"policy":         channelPolicySchema,
+		"affected_count": integerSchema,
+		"channels": arrayOf(objectOf(map[string]any{
+			"channel_point": stringSchema,
+			"chan_id":       integerSchema,
+			"current":       channelPolicySchema,
+			"updated":       channelPolicySchema,
+		}, "channel_point", "chan_id", "current", "updated")),
+		"failed_updates": arrayOf(objectOf(map[string]any{
+			"channel_point": stringSchema,
+			"reason":        stringSchema,
+			"error":         stringSchema,
+		}, "reason", "error")),
+	}, "scope", "dry_run", "policy", "affected_count", "channels"),
+	"lnc_connect_peer": objectOf(map[string]any{
+		"node_pubkey":       stringSchema,
+		"host":              stringSchema,
+		"perm":              booleanSchema,
+		"already_connected": booleanSchema,
+	}, "node_pubkey", "host", "perm", "already_connected"),
+	"lnc_disconnect_peer": objectOf(map[string]any{
+		"node_pubkey":  stringSchema,
+		"disconnected": booleanSchema,
+	}, "node_pubkey", "disconnected"),
+}
+
+// OutputSchema returns the JSON Schema of a tool's successful result,
+// including the schema_version every result carries.
+func OutputSchema(tool string) (map[string]any, bool) {
+	declared, ok := outputSchemas[tool]
+	if !ok {
+		return nil, false
+	}
+
+	properties := map[string]any{
+		"schema_version": map[string]any{
+			"type":  "integer",
+			"const": schemaVersion(tool),
+		},
+		"deprecated_fields": arrayOf(stringSchema),
+		"withheld_fields":   arrayOf(stringSchema),
+	}
+	declaredProperties, _ := declared["properties"].(map[string]any)
+	for name, property := range declaredProperties {
+		properties[name] = property
+	}
+
+	required := []string{"schema_version"}
+	if declaredRequired, ok := declared["required"].([]string); ok {
+		required = append(required, declaredRequired...)
+	}
+
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	if allowed := responseClasses(); allowed != classify.All {
+		schema = withheldSchema(schema, allowed).(map[string]any)
+	}
+
+	return schema, true
+}
+
+// withheldSchema returns a copy of schema in which no field of a class
+// results may not include is required, at any depth, since such fields are
+// withheld from every result.
+func withheldSchema(schema any, allowed classify.Class) any {
+	switch schema := schema.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(schema))
+		for key, value := range schema {
+			copied[key] = withheldSchema(value, allowed)
+		}
+		if required, ok := schema["required"].([]string); ok {
+			kept := make([]string, 0, len(required))
+			for _, name := range required {
+				if classify.Of(name)&allowed != 0 {
+					kept = append(kept, name)
+				}
+			}
+			copied["required"] = kept
+		}
+		return copied
+
+	case []any:
+		copied := make([]any, len(schema))
+		for i, value := range schema {
+			copied[i] = withheldSchema(value, allowed)
+		}
+		return copied
+
+	default:
+		return schema
+	}
+}
+
+// SchemaService exposes the output schemas of the registered tools.
+type SchemaService struct {
+	// Tools lists the registered tool names, so only schemas for tools
+	// this server actually offers are returned.
+	Tools []string
+}
+
+// NewSchemaService creates a schema service for the given tools.
+func NewSchemaService(tools []string) *SchemaService {
+	return &SchemaService{
+		Tools: tools,
+	}
+}
+
+// OutputSchemaTool returns the MCP tool definition for fetching output
+// schemas.
+func (s *SchemaService) OutputSchemaTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_output_schema",
+		Description: "Get the JSON Schema of tool results, for one tool " +
+			"or all registered tools",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"tool": map[string]any{
+					"type": "string",
+					"description": "Tool name; omit for all " +
+						"registered tools",
+				},
+			},
+		},
+	}
+}
+
+// HandleOutputSchema handles the output schema request.
+func (s *SchemaService) HandleOutputSchema(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tool, _ := request.Params.Arguments["tool"].(string)
+
+	schemas := make(map[string]any)
+	for _, name := range s.Tools {
+		if tool != "" && name != tool {
+			continue
+		}
+		schema, ok := OutputSchema(name)
+		if !ok {
+			continue
+		}
+		schemas[name] = schema
+	}
+
+	if len(schemas) == 0 {
+		return invalidArgumentError(fmt.Sprintf(
+			"%q is not a registered tool", tool)), nil
+	}
+
+	return jsonResult("lnc_get_output_schema", map[string]any{
+		"schemas": schemas,
+	}), nil
+}
@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/status"
+)
+
+// SecurityReportTool returns the MCP tool definition for the breach
+// protection report.
+func (s *NodeService) SecurityReportTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_security_report",
+		Description: "Report how well the node's channel funds are " +
+			"protected: whether every channel is in the static " +
+			"channel backup, which watchtowers back up channel " +
+			"states and which channels they cover, and any past " +
+			"breach closes where a peer broadcast a revoked state",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleSecurityReport handles the security report request. The backup and
+// tower sections report their own failures, so a node without the watchtower
+// client still gets a report.
+func (s *NodeService) HandleSecurityReport(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+	list, err := client.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list channels"), nil
+	}
+	pending, err := client.PendingChannels(ctx,
+		&lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return rpcError(err, "failed to list pending channels"), nil
+	}
+	closed, err := client.ClosedChannels(ctx, &lnrpc.ClosedChannelsRequest{
+		Breach: true,
+	})
+	if err != nil {
+		return rpcError(err, "failed to list closed channels"), nil
+	}
+
+	warnings := make([]map[string]any, 0)
+
+	channelPoints := backupChannelPoints(list, pending)
+
+	backedUp := make(map[string]bool)
+	backup := map[string]any{
+		"channels_expected": len(channelPoints),
+	}
+	export, err := client.ExportAllChannelBackups(ctx,
+		&lnrpc.ChanBackupExportRequest{})
+	switch {
+	case err != nil:
+		backup["error"] = status.Convert(err).Message()
+		warnings = append(warnings, syncWarning("backup",
+			"the static channel backup could not be exported"))
+
+	case export.MultiChanBackup != nil:
+		multi := export.MultiChanBackup
+		for _, point := range multi.ChanPoints {
+			backedUp[channelPointString(point)] = true
+		}
+		sum := sha256.Sum256(multi.MultiChanBackup)
+		backup["channels_in_backup"] = len(multi.ChanPoints)
+		backup["size_bytes"] = len(multi.MultiChanBackup)
+		backup["sha256"] = hex.EncodeToString(sum[:])
+	}
+	if err == nil {
+		missing := make([]string, 0)
+		for _, point := range channelPoints {
+			if !backedUp[point] {
+				missing = append(missing, point)
+			}
+		}
+		backup["missing_from_backup"] = missing
+		if len(missing) > 0 {
+			warnings = append(warnings, syncWarning("backup",
+				fmt.Sprintf("the static channel backup is "+
+					"missing %d of %d channels", len(missing),
+					len(channelPoints))))
+		}
+	}
+
+	// A saved backup must be newer than the last channel opened, or
+	// that channel cannot be recovered from it.
+	lastOpen := uint32(0)
+	for _, ch := range list.Channels {
+		lastOpen = max(lastOpen, uint32(ch.ChanId>>40))
+	}
+	if lastOpen > 0 {
+		backup["last_channel_open_height"] = lastOpen
+		backup["blocks_since_last_open"] = info.BlockHeight - lastOpen
+	}
+
+	towers, covered := s.towerReport(ctx, len(list.Channels) > 0)
+	if warning, ok := towers["warning"].(string); ok {
+		warnings = append(warnings, syncWarning("towers", warning))
+		delete(towers, "warning")
+	}
+
+	channels := make([]map[string]any, 0, len(list.Channels))
+	uncovered := 0
+	for _, ch := range list.Channels {
+		policy := towerPolicy(ch.CommitmentType)
+		entry := map[string]any{
+			"channel_point":   ch.ChannelPoint,
+			"chan_id":         strconv.FormatUint(ch.ChanId, 10),
+			"remote_pubkey":   ch.RemotePubkey,
+			"commitment_type": ch.CommitmentType.String(),
+			"tower_policy":    policy.String(),
+			"in_backup":       backedUp[ch.ChannelPoint],
+			"tower_covered":   covered[policy],
+		}
+		if !covered[policy] {
+			uncovered++
+		}
+		channels = append(channels, entry)
+	}
+	if uncovered > 0 && towers["status"] == "active" {
+		warnings = append(warnings, syncWarning("towers",
+			fmt.Sprintf("%d of %d channels have no active tower "+
+				"session for their commitment type", uncovered,
+				len(channels))))
+	}
+
+	breaches := make([]map[string]any, 0)
+	for _, ch := range closed.Channels {
+		if ch.CloseType != lnrpc.ChannelCloseSummary_BREACH_CLOSE {
+			continue
+		}
+		breaches = append(breaches, map[string]any{
+			"channel_point":       ch.ChannelPoint,
+			"chan_id":             strconv.FormatUint(ch.ChanId, 10),
+			"remote_pubkey":       ch.RemotePubkey,
+			"close_height":        ch.CloseHeight,
+			"closing_tx_hash":     ch.ClosingTxHash,
+			"capacity_sat":        ch.Capacity,
+			"settled_balance_sat": ch.SettledBalance,
+		})
+	}
+	if len(breaches) > 0 {
+		warnings = append(warnings, syncWarning("breaches",
+			fmt.Sprintf("a peer broadcast a revoked state in %d "+
+				"of the node's closed channels", len(breaches))))
+	}
+
+	result := map[string]any{
+		"block_height":          info.BlockHeight,
+		"channel_backup_status": backup,
+		"towers":                towers,
+		"channels":              channels,
+		"breaches":              breaches,
+		"note": "Tower coverage is by commitment type: a channel " +
+			"counts as covered when an active tower accepts " +
+			"sessions of its type",
+		"status":   "ok",
+		"warnings": warnings,
+	}
+	if len(warnings) > 0 {
+		result["status"] = "warning"
+	}
+
+	return jsonResult("lnc_security_report", result), nil
+}
+
+// backupChannelPoints returns the channel points a complete static channel
+// backup holds. Channels still being opened are in the backup too, as a
+// restore must be able to recover their funds once they confirm.
+func backupChannelPoints(list *lnrpc.ListChannelsResponse,
+	pending *lnrpc.PendingChannelsResponse) []string {
+	var channelPoints []string
+	seen := make(map[string]bool)
+	for _, ch := range list.Channels {
+		if !seen[ch.ChannelPoint] {
+			seen[ch.ChannelPoint] = true
+			channelPoints = append(channelPoints, ch.ChannelPoint)
+		}
+	}
+	for _, ch := range pending.PendingOpenChannels {
+		if ch.Channel != nil && !seen[ch.Channel.ChannelPoint] {
+			seen[ch.Channel.ChannelPoint] = true
+			channelPoints = append(channelPoints,
+				ch.Channel.ChannelPoint)
+		}
+	}
+
+	return channelPoints
+}
+
+// towerReport describes the watchtowers the node's tower client uses, and
+// which session policies they actively cover. A warning is set on the
+// section when the node has channels that no tower can be protecting.
+func (s *NodeService) towerReport(ctx context.Context,
+	hasChannels bool) (map[string]any, map[wtclientrpc.PolicyType]bool) {
+	covered := make(map[wtclientrpc.PolicyType]bool)
+	section := map[string]any{
+		"towers": make([]map[string]any, 0),
+	}
+
+	wtclient, generation := s.Clients.Towers()
+	failure := func(err error) (map[string]any,
+		map[wtclientrpc.PolicyType]bool) {
+		message := status.Convert(err).Message()
+		switch {
+		case subserverMissing(err):
+			s.Clients.recordSubserver(generation, "wtclient",
+				false, message)
+			section["status"] = "unavailable"
+			section["guidance"] = subservers["wtclient"].guidance
+
+		// lnd answers this way when the subserver is compiled in but
+		// the client is disabled in its configuration.
+		case towerClientInactive(err):
+			s.Clients.recordSubserver(generation, "wtclient",
+				true, "")
+			section["status"] = "disabled"
+			section["guidance"] = towerClientGuidance
+
+		default:
+			section["status"] = "error"
+			section["error"] = message
+		}
+		if hasChannels {
+			section["warning"] = "no watchtower is protecting " +
+				"the node's channels"
+		}
+		return section, covered
+	}
+
+	state, known := s.Clients.subserver("wtclient")
+	if wtclient == nil || (known && !state.available) {
+		section["status"] = "unavailable"
+		section["guidance"] = subservers["wtclient"].guidance
+		if hasChannels {
+			section["warning"] = "no watchtower is protecting " +
+				"the node's channels"
+		}
+		return section, covered
+	}
+
+	list, err := wtclient.ListTowers(ctx, &wtclientrpc.ListTowersRequest{
+		ExcludeExhaustedSessions: true,
+	})
+	if err != nil {
+		return failure(err)
+	}
+	stats, err := wtclient.Stats(ctx, &wtclientrpc.StatsRequest{})
+	if err != nil {
+		return failure(err)
+	}
+	s.Clients.recordSubserver(generation, "wtclient", true, "")
+
+	towers := make([]map[string]any, 0, len(list.Towers))
+	for _, tower := range list.Towers {
+		sessions := make([]map[string]any, 0, len(tower.SessionInfo))
+		for _, info := range tower.SessionInfo {
+			sessions = append(sessions, towerSessionEntry(info))
+			if info.ActiveSessionCandidate {
+				covered[info.PolicyType] = true
+			}
+		}
+		towers = append(towers, map[string]any{
+			"pubkey":    hex.EncodeToString(tower.Pubkey),
+			"addresses": tower.Addresses,
+			"sessions":  sessions,
+		})
+	}
+
+	section["status"] = "active"
+	section["towers"] = towers
+	section["stats"] = map[string]any{
+		"num_backups":            stats.NumBackups,
+		"num_pending_backups":    stats.NumPendingBackups,
+		"num_failed_backups":     stats.NumFailedBackups,
+		"num_sessions_acquired":  stats.NumSessionsAcquired,
+		"num_sessions_exhausted": stats.NumSessionsExhausted,
+	}
+
+	switch {
+	case hasChannels && len(covered) == 0:
+		section["warning"] = "the tower client has no active tower " +
+			"session; add a tower with lncli wtclient add"
+	case stats.NumFailedBackups > 0:
+		section["warning"] = fmt.Sprintf("%d channel states failed "+
+			"to be backed up to a tower", stats.NumFailedBackups)
+	}
+
+	return section, covered
+}
+
+// towerPolicy returns the tower session policy that backs up channels of a
+// commitment type.
+func towerPolicy(commitment lnrpc.CommitmentType) wtclientrpc.PolicyType {
+	switch commitment {
+	case lnrpc.CommitmentType_ANCHORS,
+		lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE:
+		return wtclientrpc.PolicyType_ANCHOR
+	case lnrpc.CommitmentType_SIMPLE_TAPROOT,
+		lnrpc.CommitmentType_SIMPLE_TAPROOT_OVERLAY:
+		return wtclientrpc.PolicyType_TAPROOT
+	default:
+		return wtclientrpc.PolicyType_LEGACY
+	}
+}
@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultSendTargetConf is the confirmation target used when neither a
+// target nor a fee rate is given.
+const defaultSendTargetConf = 6
+
+// SendCoinsTool returns the MCP tool definition for sending funds on-chain.
+func (s *OnChainService) SendCoinsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_send_coins",
+		Description: "Send on-chain funds to an allowlisted address. " +
+			"The first call only previews the send and returns a " +
+			"confirmation_id; call again with the same arguments " +
+			"and that confirmation_id to broadcast",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"address": map[string]any{
+					"type":        "string",
+					"description": "Destination Bitcoin address",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to send in satoshis " +
+						"(omit when send_all is set)",
+					"minimum": 1,
+				},
+				"send_all": map[string]any{
+					"type": "boolean",
+					"description": "Sweep all confirmed wallet " +
+						"funds to the address",
+				},
+				"target_conf": map[string]any{
+					"type": "number",
+					"description": "Confirmation target used " +
+						"to pick the fee rate (default 6)",
+					"minimum": 1,
+					"maximum": 1008,
+				},
+				"sat_per_vbyte": map[string]any{
+					"type": "number",
+					"description": "Explicit fee rate; cannot " +
+						"be combined with target_conf",
+					"minimum": 1,
+				},
+				"label": map[string]any{
+					"type":        "string",
+					"description": "Wallet label for the transaction",
+				},
+				"confirmation_id": map[string]any{
+					"type": "string",
+					"description": "ID from a preview of the same " +
+						"send, to broadcast it",
+				},
+			},
+			Required: []string{"address"},
+		},
+	}
+}
+
+// sendCoinsRequest holds the validated lnc_send_coins arguments.
+type sendCoinsRequest struct {
+	address     string
+	amountSat   int64
+	sendAll     bool
+	targetConf  int32
+	satPerVbyte uint64
+	label       string
+}
+
+// parseSendCoins validates the lnc_send_coins arguments.
+func parseSendCoins(args map[string]any) (*sendCoinsRequest, *errors.Error) {
+	address, _ := args["address"].(string)
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, errors.ErrInvalidArgument("address is required")
+	}
+	if _, _, err := decodeAddress(address); err != nil {
+		return nil, errors.ErrInvalidAddress(address)
+	}
+
+	amountSat, _ := args["amount_sat"].(float64)
+	sendAll, _ := args["send_all"].(bool)
+	switch {
+	case sendAll && amountSat > 0:
+		return nil, errors.ErrInvalidArgument(
+			"set either amount_sat or send_all, not both")
+	case !sendAll && amountSat < 1:
+		return nil, errors.ErrInvalidArgument(
+			"amount_sat is required unless send_all is set")
+	}
+
+	targetConf, _ := args["target_conf"].(float64)
+	satPerVbyte, _ := args["sat_per_vbyte"].(float64)
+	switch {
+	case targetConf > 0 && satPerVbyte > 0:
+		return nil, errors.ErrInvalidArgument(
+			"set either target_conf or sat_per_vbyte, not both")
+	case targetConf == 0 && satPerVbyte == 0:
+		targetConf = defaultSendTargetConf
+	}
+
+	label, _ := args["label"].(string)
+
+	return &sendCoinsRequest{
+		address:     address,
+		amountSat:   int64(amountSat),
+		sendAll:     sendAll,
+		targetConf:  int32(targetConf),
+		satPerVbyte: uint64(satPerVbyte),
+		label:       label,
+	}, nil
+}
+
+// toMap formats the send for JSON output.
+func (r *sendCoinsRequest) toMap() map[string]any {
+	result := map[string]any{
+		"address":  r.address,
+		"send_all": r.sendAll,
+		"label":    r.label,
+	}
+	if !r.sendAll {
+		result["amount_sat"] = r.amountSat
+	}
+	if r.satPerVbyte > 0 {
+		result["sat_per_vbyte"] = r.satPerVbyte
+	} else {
+		result["target_conf"] = r.targetConf
+	}
+	return result
+}
+
+// HandleSendCoins handles the send coins request. Without a confirmation_id
+// it returns a preview; with a valid one it broadcasts the transaction.
+// The withdrawal policy, fee rate cap and spend caps are checked on both
+// calls, and the send is recorded against the spend caps on the second.
+func (s *OnChainService) HandleSendCoins(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	args := request.Params.Arguments
+	send, argErr := parseSendCoins(args)
+	if argErr != nil {
+		return toolError(argErr), nil
+	}
+
+	if s.Policy == nil {
+		return toolError(errors.ErrPermissionDenied(
+			"on-chain withdrawals are disabled")), nil
+	}
+	if err := s.Policy.CheckWithdrawal(send.address); err != nil {
+		return policyError(err), nil
+	}
+	satPerVbyte, failure := checkFeeRate(ctx, s.Clients, s.Policy,
+		send.satPerVbyte, send.targetConf)
+	if failure != nil {
+		return failure, nil
+	}
+
+	// A send spends its amount and fee and a sweep the confirmed
+	// balance, which only matter when spending is capped.
+	spendSat := send.amountSat
+	switch {
+	case send.sendAll && s.Policy.SpendLimited():
+		balance, err := client.WalletBalance(ctx,
+			&lnrpc.WalletBalanceRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get wallet balance"), nil
+		}
+		spendSat = balance.ConfirmedBalance
+
+	case s.Policy.SpendLimited():
+		fee, _, err := estimateSendFee(ctx, client, send, satPerVbyte)
+		if err != nil {
+			return rpcError(err, "failed to estimate fee"), nil
+		}
+		spendSat += fee
+	}
+
+	token, _ := args[confirmationArgument].(string)
+	if token == "" {
+		if err := s.Policy.CheckSpend(spendSat); err != nil {
+			return policyError(err), nil
+		}
+		return s.previewSendCoins(ctx, client, send, satPerVbyte,
+			args)
+	}
+
+	if !s.confirmations.redeem("lnc_send_coins", token, args) {
+		return invalidArgumentError("confirmation_id is unknown, " +
+			"expired or was issued for different arguments; call " +
+			"lnc_send_coins without it for a new preview"), nil
+	}
+
+	spendID := "send_coins:" + token
+	err := s.Policy.ReserveSpend(spendID, "lnc_send_coins", spendSat)
+	if err != nil {
+		return policyError(err), nil
+	}
+
+	targetConf := send.targetConf
+	if satPerVbyte > 0 {
+		targetConf = 0
+	}
+	resp, err := client.SendCoins(ctx, &lnrpc.SendCoinsRequest{
+		Addr:        send.address,
+		Amount:      send.amountSat,
+		TargetConf:  targetConf,
+		SatPerVbyte: satPerVbyte,
+		SendAll:     send.sendAll,
+		Label:       send.label,
+	})
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return rpcError(err, "failed to send coins"), nil
+	}
+
+	result := send.toMap()
+	result["confirmed"] = true
+	result["txid"] = resp.Txid
+	return jsonResult("lnc_send_coins", result), nil
+}
+
+// previewSendCoins describes a send at satPerVbyte, or at the rate lnd
+// picks for the target when it is 0, without broadcasting it, and issues the
+// confirmation_id that authorises it.
+func (s *OnChainService) previewSendCoins(ctx context.Context,
+	client lnrpc.LightningClient, send *sendCoinsRequest,
+	satPerVbyte uint64, args map[string]any) (*mcp.CallToolResult,
+	error) {
+	result := send.toMap()
+
+	// The fee rates to place in the mempool, unknown for a sweep.
+	var rates map[string]float64
+	if send.sendAll {
+		balance, err := client.WalletBalance(ctx,
+			&lnrpc.WalletBalanceRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get wallet balance"), nil
+		}
+		result["wallet_balance_sat"] = balance.ConfirmedBalance
+	} else {
+		fee, rate, err := estimateSendFee(ctx, client, send,
+			satPerVbyte)
+		if err != nil {
+			return rpcError(err, "failed to estimate fee"), nil
+		}
+		result["estimated_fee_sat"] = fee
+		rates = map[string]float64{"send": float64(rate)}
+	}
+
+	if s.Mempool != nil {
+		result["mempool_context"] = s.Mempool.Context(ctx, rates)
+	}
+
+	token, expiresAt, err := s.confirmations.issue("lnc_send_coins", args)
+	if err != nil {
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to issue confirmation")), nil
+	}
+
+	result["confirmed"] = false
+	result["confirmation_id"] = token
+	result["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	result["message"] = "Nothing has been sent. Call lnc_send_coins " +
+		"again with the same arguments and this confirmation_id " +
+		"to broadcast."
+	return jsonResult("lnc_send_coins", result), nil
+}
+
+// estimateSendFee estimates the fee of a send that is not a sweep, and the
+// rate it pays, at satPerVbyte or, when that is 0, at the rate lnd picks
+// for the target.
+func estimateSendFee(ctx context.Context, client lnrpc.LightningClient,
+	send *sendCoinsRequest, satPerVbyte uint64) (int64, uint64, error) {
+	targetConf := send.targetConf
+	if targetConf == 0 {
+		targetConf = defaultSendTargetConf
+	}
+	estimate, err := client.EstimateFee(ctx, &lnrpc.EstimateFeeRequest{
+		AddrToAmount: map[string]int64{send.address: send.amountSat},
+		TargetConf:   targetConf,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if satPerVbyte == 0 {
+		return estimate.FeeSat, estimate.SatPerVbyte, nil
+	}
+
+	// With a fixed fee rate, scale the estimate to it.
+	fee := estimate.FeeSat
+	if estimate.SatPerVbyte > 0 {
+		rate := int64(estimate.SatPerVbyte)
+		fee = (fee*int64(satPerVbyte) + rate - 1) / rate
+	}
+	return fee, satPerVbyte, nil
+}
@@ -0,0 +1,433 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxExactJSONInteger is the largest integer a JSON number carries without
+// losing precision in clients that decode numbers as doubles.
+const maxExactJSONInteger = 1 << 53
+
+// SendToRouteTool returns the MCP tool definition for paying along a
+// given route.
+func (s *PaymentService) SendToRouteTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_send_to_route",
+		Description: "Send a payment along a route built beforehand, " +
+			"for example with lncli buildroute or queryroutes, " +
+			"without lnd's pathfinding. The result lists each " +
+			"hop's outcome and, when the attempt fails, which " +
+			"node and channel failed it and why",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"payment_hash": map[string]any{
+					"type":        "string",
+					"description": "Payment hash to pay (hex encoded)",
+					"pattern":     "^[0-9a-fA-F]{64}$",
+				},
+				"route": map[string]any{
+					"type": []string{"object", "string"},
+					"description": "Route in lnd's JSON form, " +
+						"as an object or a JSON string: " +
+						"total_time_lock and hops, each " +
+						"with chan_id, pub_key, " +
+						"amt_to_forward_msat, fee_msat, " +
+						"expiry and an optional " +
+						"mpp_record. Output wrapped in " +
+						"{\"route\": ...} is accepted. " +
+						"Send chan_id as a string, as " +
+						"lncli prints it, to keep its " +
+						"precision",
+				},
+				"payment_addr": map[string]any{
+					"type": "string",
+					"description": "Payment address from the " +
+						"invoice (hex encoded), added to " +
+						"the final hop when the route has " +
+						"no mpp_record",
+					"pattern": "^[0-9a-fA-F]{64}$",
+				},
+				"skip_temp_err": map[string]any{
+					"type": "boolean",
+					"description": "Keep the payment open after " +
+						"a temporary failure so it can be " +
+						"retried along another route",
+				},
+				"include_preimage": includePreimageProperty(),
+			},
+			Required: []string{"payment_hash", "route"},
+		},
+	}
+}
+
+// HandleSendToRoute handles the send to route request. The attempt is a
+// single HTLC, so unlike lnc_pay_invoice the call returns once the HTLC
+// settles or fails, without progress updates.
+func (s *PaymentService) HandleSendToRoute(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	router, generation := s.Clients.Router()
+	if router == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "router"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	disclose, denied := s.disclosePreimage(args)
+	if denied != nil {
+		return denied, nil
+	}
+
+	value, _ := args["payment_hash"].(string)
+	paymentHash, err := parseHash("payment_hash", value)
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	route, err := parseRoute(args["route"])
+	if err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	if value, ok := args["payment_addr"].(string); ok {
+		paymentAddr, err := parseHash("payment_addr", value)
+		if err != nil {
+			return invalidArgumentError(err.Error()), nil
+		}
+		final := route.Hops[len(route.Hops)-1]
+		if final.MppRecord == nil {
+			final.MppRecord = &lnrpc.MPPRecord{
+				PaymentAddr:  paymentAddr,
+				TotalAmtMsat: final.AmtToForwardMsat,
+			}
+		}
+	}
+
+	skipTempErr, _ := args["skip_temp_err"].(bool)
+
+	// The route's total includes its fees.
+	spendID := paymentSpendID(paymentHash)
+	err = s.Policy.ReserveSpend(spendID, "lnc_send_to_route",
+		(route.TotalAmtMsat+999)/1000)
+	if err != nil {
+		return policyError(err), nil
+	}
+
+	attempt, err := router.SendToRouteV2(ctx, &routerrpc.SendToRouteRequest{
+		PaymentHash: paymentHash,
+		Route:       route,
+		SkipTempErr: skipTempErr,
+	})
+	if err != nil && s.Clients.Replaced(generation) {
+		return connectionReplacedError("the connection was replaced "+
+			"while sending to the route; check lnc_track_payment "+
+			"before retrying", map[string]any{
+			"payment_hash": hex.EncodeToString(paymentHash),
+		}), nil
+	}
+	if err != nil {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+		return subserverError(s.Clients, generation, "router", err,
+			"failed to send to route"), nil
+	}
+	s.Clients.recordSubserver(generation, "router", true, "")
+	if attempt.Status == lnrpc.HTLCAttempt_FAILED {
+		logSpendError(ctx, s.Policy.ReleaseSpend(spendID))
+	}
+
+	// lnd returns the route it used, which is the one sent.
+	if attempt.Route != nil && len(attempt.Route.Hops) > 0 {
+		route = attempt.Route
+	}
+
+	result := map[string]any{
+		"payment_hash":     hex.EncodeToString(paymentHash),
+		"attempt_id":       attempt.AttemptId,
+		"status":           attempt.Status.String(),
+		"succeeded":        attempt.Status == lnrpc.HTLCAttempt_SUCCEEDED,
+		"payment_preimage": hex.EncodeToString(attempt.Preimage),
+		"total_amt_msat":   route.TotalAmtMsat,
+		"total_fees_msat":  route.TotalFeesMsat,
+		"total_time_lock":  route.TotalTimeLock,
+		"hops":             routeHopOutcomes(route, attempt),
+	}
+	if attempt.Failure != nil {
+		result["failure"] = routeFailure(route, attempt.Failure)
+	}
+	withholdPreimage(disclose, result)
+
+	return jsonResult("lnc_send_to_route", result), nil
+}
+
+// parseRoute reads the route argument into an lnd route, filling in the
+// totals when they are left out and checking them when they are not.
+func parseRoute(value any) (*lnrpc.Route, error) {
+	fields, ok := value.(map[string]any)
+	if text, isString := value.(string); isString {
+		// Decode numbers exactly, so channel IDs survive.
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		ok = decoder.Decode(&fields) == nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("route must be a route object")
+	}
+	if inner, ok := fields["route"].(map[string]any); ok {
+		fields = inner
+	}
+
+	rawHops, _ := fields["hops"].([]any)
+	if len(rawHops) == 0 {
+		return nil, fmt.Errorf("route must have at least one hop")
+	}
+
+	route := &lnrpc.Route{}
+	for i, rawHop := range rawHops {
+		hop, err := parseRouteHop(i, rawHop)
+		if err != nil {
+			return nil, err
+		}
+		route.Hops = append(route.Hops, hop)
+		route.TotalFeesMsat += hop.FeeMsat
+	}
+	final := route.Hops[len(route.Hops)-1]
+	totalAmt := final.AmtToForwardMsat + route.TotalFeesMsat
+
+	timeLock, err := routeInteger(fields, "total_time_lock", "route", 32)
+	if err != nil {
+		return nil, err
+	}
+	if timeLock == 0 {
+		return nil, fmt.Errorf("route.total_time_lock is required")
+	}
+	route.TotalTimeLock = uint32(timeLock)
+
+	for _, total := range []struct {
+		key  string
+		want int64
+	}{
+		{"total_amt_msat", totalAmt},
+		{"total_fees_msat", route.TotalFeesMsat},
+	} {
+		if _, ok := fields[total.key]; !ok {
+			continue
+		}
+		got, err := routeInteger(fields, total.key, "route", 63)
+		if err != nil {
+			return nil, err
+		}
+		if int64(got) != total.want {
+			return nil, fmt.Errorf("route.%s is %d but the hops "+
+				"add up to %d", total.key, got, total.want)
+		}
+	}
+	route.TotalAmtMsat = totalAmt
+
+	return route, nil
+}
+
+// parseRouteHop reads hop i of the route argument.
+func parseRouteHop(i int, value any) (*lnrpc.Hop, error) {
+	name := fmt.Sprintf("route.hops[%d]", i)
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", name)
+	}
+
+	chanID, err := routeInteger(fields, "chan_id", name, 64)
+	if err != nil {
+		return nil, err
+	}
+	if chanID == 0 {
+		return nil, fmt.Errorf("%s.chan_id is required", name)
+	}
+
+	pubkey, _ := fields["pub_key"].(string)
+	if _, err := parsePubkey(name+".pub_key", pubkey); err != nil {
+		return nil, err
+	}
+
+	amount, err := routeInteger(fields, "amt_to_forward_msat", name, 63)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("%s.amt_to_forward_msat is required",
+			name)
+	}
+	fee, err := routeInteger(fields, "fee_msat", name, 63)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := routeInteger(fields, "expiry", name, 32)
+	if err != nil {
+		return nil, err
+	}
+	if expiry == 0 {
+		return nil, fmt.Errorf("%s.expiry is required", name)
+	}
+
+	hop := &lnrpc.Hop{
+		ChanId:           chanID,
+		PubKey:           strings.ToLower(pubkey),
+		AmtToForwardMsat: int64(amount),
+		FeeMsat:          int64(fee),
+		Expiry:           uint32(expiry),
+	}
+
+	if rawMPP, ok := fields["mpp_record"]; ok && rawMPP != nil {
+		mpp, ok := rawMPP.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.mpp_record must be an "+
+				"object", name)
+		}
+		addr, _ := mpp["payment_addr"].(string)
+		paymentAddr, err := parseHash(name+".mpp_record.payment_addr",
+			addr)
+		if err != nil {
+			return nil, err
+		}
+		total, err := routeInteger(mpp, "total_amt_msat",
+			name+".mpp_record", 63)
+		if err != nil {
+			return nil, err
+		}
+		hop.MppRecord = &lnrpc.MPPRecord{
+			PaymentAddr:  paymentAddr,
+			TotalAmtMsat: int64(total),
+		}
+	}
+
+	return hop, nil
+}
+
+// routeInteger reads a non-negative integer field of at most bits bits,
+// which lncli prints as a decimal string and clients may send as a number.
+// A missing field reads as zero.
+func routeInteger(fields map[string]any, key, name string,
+	bits int) (uint64, error) {
+	var text string
+	switch v := fields[key].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	case float64:
+		if v > maxExactJSONInteger {
+			return 0, fmt.Errorf("%s.%s is too large for a JSON "+
+				"number; send it as a string", name, key)
+		}
+		if v < 0 || v != math.Trunc(v) {
+			return 0, fmt.Errorf("%s.%s must be a non-negative "+
+				"integer", name, key)
+		}
+		text = strconv.FormatUint(uint64(v), 10)
+	default:
+		return 0, fmt.Errorf("%s.%s must be an integer", name, key)
+	}
+
+	value, err := strconv.ParseUint(text, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("%s.%s must be a non-negative integer "+
+			"of at most %d bits", name, key, bits)
+	}
+
+	return value, nil
+}
+
+// routeHopOutcomes lists the route's hops with how far the attempt got.
+// A failure at source index i means the node before hop i, this node for
+// i = 0, did not forward across hop i's channel: earlier hops forwarded,
+// hop i failed and later hops were never reached. An index past the last
+// hop means the recipient rejected the payment.
+func routeHopOutcomes(route *lnrpc.Route,
+	attempt *lnrpc.HTLCAttempt) []map[string]any {
+	hops := make([]map[string]any, len(route.Hops))
+	for i, hop := range route.Hops {
+		var outcome string
+		switch {
+		case attempt.Status == lnrpc.HTLCAttempt_SUCCEEDED:
+			outcome = "settled"
+		case attempt.Status == lnrpc.HTLCAttempt_IN_FLIGHT:
+			outcome = "in_flight"
+		case attempt.Failure == nil:
+			outcome = "unknown"
+		case i < int(attempt.Failure.FailureSourceIndex):
+			outcome = "forwarded"
+		case i == int(attempt.Failure.FailureSourceIndex):
+			outcome = "failed"
+		default:
+			outcome = "not_reached"
+		}
+
+		hops[i] = map[string]any{
+			"index":               i,
+			"chan_id":             hop.ChanId,
+			"pub_key":             hop.PubKey,
+			"amt_to_forward_msat": hop.AmtToForwardMsat,
+			"fee_msat":            hop.FeeMsat,
+			"expiry":              hop.Expiry,
+			"outcome":             outcome,
+		}
+	}
+
+	return hops
+}
+
+// routeFailure describes where along the route an attempt failed and why,
+// including the channel update a failing node sent back, which carries
+// the policy it expects for a fee or expiry failure.
+func routeFailure(route *lnrpc.Route, failure *lnrpc.Failure) map[string]any {
+	index := int(failure.FailureSourceIndex)
+	details := map[string]any{
+		"code":                 failure.Code.String(),
+		"failure_source_index": index,
+		"htlc_msat":            failure.HtlcMsat,
+		"cltv_expiry":          failure.CltvExpiry,
+		"height":               failure.Height,
+	}
+
+	switch {
+	case index == 0:
+		details["origin"] = "local"
+	case index >= len(route.Hops):
+		details["origin"] = "recipient"
+	default:
+		details["origin"] = "intermediate"
+	}
+	if index > 0 && index <= len(route.Hops) {
+		details["failing_node"] = route.Hops[index-1].PubKey
+	}
+	if index < len(route.Hops) {
+		details["failing_channel"] = route.Hops[index].ChanId
+	}
+
+	if update := failure.ChannelUpdate; update != nil {
+		details["channel_update"] = map[string]any{
+			"chan_id":           update.ChanId,
+			"timestamp":         update.Timestamp,
+			"base_fee_msat":     update.BaseFee,
+			"fee_rate_ppm":      update.FeeRate,
+			"time_lock_delta":   update.TimeLockDelta,
+			"htlc_minimum_msat": update.HtlcMinimumMsat,
+			"htlc_maximum_msat": update.HtlcMaximumMsat,
+			"disabled":          update.ChannelFlags&2 != 0,
+		}
+	}
+
+	return details
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/backup"
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/export"
+	"github.com/jbrill/mcp-lnc-server/internal/journal"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/jbrill/mcp-lnc-server/internal/schedule"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// backupNamePattern matches the file names backups may be given: a plain
+// name in the backup directory, never a path.
+var backupNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+// BackupService backs up and restores the server's own state to files in
+// the backup directory. Each store is nil when it is not enabled.
+type BackupService struct {
+	Dir           string
+	ServerVersion string
+
+	Contacts  *contacts.Book
+	Watchlist *watchlist.List
+	Journal   *journal.Journal
+	Ledger    *policy.Ledger
+
+	// Checkpoints are not backed up, as they belong with the exports
+	// in the export directory, but they are maintained with the other
+	// local stores.
+	Checkpoints *export.Checkpoints
+
+	// Schedule is not backed up either, as a restored host would run
+	// its calls a second time, but it is maintained like the others.
+	Schedule *schedule.Schedule
+}
+
+// NewBackupService creates a backup service writing to dir.
+func NewBackupService(dir, serverVersion string) *BackupService {
+	return &BackupService{Dir: dir, ServerVersion: serverVersion}
+}
+
+// ServerBackupTool returns the MCP tool definition for backing up the
+// server's state.
+func (s *BackupService) ServerBackupTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_server_backup",
+		Description: "Back up this MCP server's own state into one " +
+			"file in its backup directory: the contact book, the " +
+			"watched nodes with their last snapshots, the " +
+			"operation journal and the spend ledger. Restore it " +
+			"with lnc_server_restore after moving the server to " +
+			"a new host. The node itself is not backed up, and " +
+			"the server stores no pairing phrases or session " +
+			"keys to include",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type": "string",
+					"description": "File name for " +
+						"the backup (default " +
+						"mcp-lnc-backup-<time>" +
+						".json). Existing backups " +
+						"are never overwritten",
+					"pattern": backupNamePattern.String(),
+				},
+			},
+		},
+	}
+}
+
+// HandleServerBackup handles the server backup request.
+func (s *BackupService) HandleServerBackup(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	now := time.Now().UTC()
+	name, _ := request.Params.Arguments["name"].(string)
+	if name == "" {
+		name = "mcp-lnc-backup-" + now.Format("20060102T150405Z") +
+			".json"
+	}
+	if !backupNamePattern.MatchString(name) {
+		return invalidArgumentError("name must be a file name of " +
+			"letters, digits, dots, dashes and underscores"), nil
+	}
+
+	archive := backup.Archive{
+		CreatedAt:     now,
+		ServerVersion: s.ServerVersion,
+		Contacts:      s.Contacts.All(),
+		WatchedNodes:  s.Watchlist.All(),
+		Operations:    s.Journal.Operations(),
+	}
+	if s.Ledger != nil {
+		archive.Spends = s.Ledger.Spends()
+	}
+
+	path := filepath.Join(s.Dir, name)
+	err := backup.Write(path, archive)
+	switch {
+	case err == backup.ErrExists:
+		return invalidArgumentError("a backup named " + name +
+			" already exists; choose another name"), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to write backup")), nil
+	}
+
+	return jsonResult("lnc_server_backup", map[string]any{
+		"name":          name,
+		"path":          path,
+		"created_at":    now.Format(time.RFC3339),
+		"contacts":      len(archive.Contacts),
+		"watched_nodes": len(archive.WatchedNodes),
+		"operations":    len(archive.Operations),
+		"spends":        len(archive.Spends),
+	}), nil
+}
+
+// ServerRestoreTool returns the MCP tool definition for restoring the
+// server's state from a backup.
+func (s *BackupService) ServerRestoreTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_server_restore",
+		Description: "Restore this MCP server's state from a backup " +
+			"made by lnc_server_backup, merging it into the " +
+			"current state: contacts replace older edits of the " +
+			"same pubkey, and watched nodes, operations and " +
+			"spends are added where missing. Nothing is removed, " +
+			"so restoring twice is harmless. Sections whose " +
+			"store is not enabled on this server are skipped. " +
+			"Restored in-flight operations are resumed when the " +
+			"server next starts",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"name": map[string]any{
+					"type": "string",
+					"description": "File name of " +
+						"the backup in the backup " +
+						"directory",
+					"pattern": backupNamePattern.String(),
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+// HandleServerRestore handles the server restore request. Sections are
+// restored in turn, so one that fails leaves the earlier ones restored; as
+// restoring only merges, the restore can simply be retried.
+func (s *BackupService) HandleServerRestore(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, _ := request.Params.Arguments["name"].(string)
+	if !backupNamePattern.MatchString(name) {
+		return invalidArgumentError("name must be the file name of " +
+			"a backup"), nil
+	}
+
+	archive, err := backup.Read(filepath.Join(s.Dir, name))
+	switch {
+	case os.IsNotExist(err):
+		return toolError(errors.New(errors.ErrCodeNotFound,
+			"no backup named "+name)), nil
+	case err != nil:
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	sections := []struct {
+		name    string
+		count   int
+		enabled bool
+		restore func() (int, error)
+	}{{
+		"contacts", len(archive.Contacts), s.Contacts != nil,
+		func() (int, error) {
+			return s.Contacts.Import(archive.Contacts)
+		},
+	}, {
+		"watched_nodes", len(archive.WatchedNodes), s.Watchlist != nil,
+		func() (int, error) {
+			return s.Watchlist.Import(archive.WatchedNodes)
+		},
+	}, {
+		"operations", len(archive.Operations), s.Journal != nil,
+		func() (int, error) {
+			return s.Journal.Import(archive.Operations)
+		},
+	}, {
+		"spends", len(archive.Spends), s.Ledger != nil,
+		func() (int, error) {
+			return s.Ledger.Import(archive.Spends)
+		},
+	}}
+
+	result := map[string]any{
+		"name":       name,
+		"created_at": archive.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if archive.ServerVersion != "" {
+		result["server_version"] = archive.ServerVersion
+	}
+	for _, section := range sections {
+		restored := 0
+		if section.enabled && section.count > 0 {
+			restored, err = section.restore()
+			if err != nil {
+				return toolError(errors.Wrap(err,
+					errors.ErrCodeUnknown, "failed to "+
+						"restore "+section.name)), nil
+			}
+		}
+		result[section.name] = map[string]any{
+			"in_backup": section.count,
+			"restored":  restored,
+			"enabled":   section.enabled,
+		}
+	}
+
+	return jsonResult("lnc_server_restore", result), nil
+}
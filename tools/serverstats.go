@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ServerStatsService reports the server's view of its node connections,
+// including tools that are degraded because the node lacks a subserver.
+type ServerStatsService struct {
+	Clients *ClientProvider
+
+	// SandboxClients are the sandbox node's clients, nil outside sandbox
+	// mode.
+	SandboxClients *ClientProvider
+}
+
+// NewServerStatsService creates a server stats service reporting on the
+// given clients.
+func NewServerStatsService(clients *ClientProvider) *ServerStatsService {
+	return &ServerStatsService{
+		Clients: clients,
+	}
+}
+
+// ServerStatsTool returns the MCP tool definition for server stats.
+func (s *ServerStatsService) ServerStatsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_server_stats",
+		Description: "Show the state of the node connection and which " +
+			"optional lnd subservers are available, listing tools " +
+			"that are degraded because the node does not run a " +
+			"subserver they need",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleServerStats handles the server stats request. Subservers are
+// reported as unknown until a tool has called them on the current
+// connection.
+func (s *ServerStatsService) HandleServerStats(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, generation := s.Clients.Lightning()
+	report, degraded := subserverReport(s.Clients)
+
+	result := map[string]any{
+		"connected":         client != nil,
+		"client_generation": generation,
+		"subservers":        report,
+	}
+
+	if s.SandboxClients != nil {
+		sandbox, _ := s.SandboxClients.Lightning()
+		sandboxReport, sandboxDegraded := subserverReport(
+			s.SandboxClients)
+		result["sandbox_connected"] = sandbox != nil
+		result["sandbox_subservers"] = sandboxReport
+		degraded = append(degraded, sandboxDegraded...)
+	}
+
+	result["degraded_tools"] = uniqueSorted(degraded)
+	return jsonResult("lnc_server_stats", result), nil
+}
+
+// uniqueSorted returns values sorted with duplicates removed, and an empty
+// slice rather than nil so the result always lists the field.
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+
+	return unique
+}
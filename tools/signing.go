@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/signing"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// SigningService signs tool results so downstream systems can verify they
+// came from this server, and verifies signatures on request.
+type SigningService struct {
+	Clients *ClientProvider
+
+	// Mode is one of signing.ModeHMAC or signing.ModeNode.
+	Mode string
+
+	// Key is the shared HMAC key in HMAC mode.
+	Key []byte
+}
+
+// NewSigningService creates a signing service for the given mode.
+func NewSigningService(client lnrpc.LightningClient, mode string,
+	key []byte) *SigningService {
+	return &SigningService{
+		Clients: NewClientProvider(client),
+		Mode:    mode,
+		Key:     key,
+	}
+}
+
+// Sign signs a tool result's text.
+func (s *SigningService) Sign(ctx context.Context, tool,
+	content string) (*signing.Signature, error) {
+	signedAt := time.Now().UTC().Format(time.RFC3339)
+	message := signing.Message(tool, signedAt, content)
+
+	sig := &signing.Signature{
+		Tool:     tool,
+		SignedAt: signedAt,
+	}
+
+	switch s.Mode {
+	case signing.ModeHMAC:
+		sig.Algorithm = signing.AlgorithmHMAC
+		sig.Value = signing.HMAC(s.Key, message)
+
+	case signing.ModeNode:
+		client, _ := s.Clients.Lightning()
+		if client == nil {
+			return nil, errors.ErrNotConnected()
+		}
+		resp, err := client.SignMessage(ctx,
+			&lnrpc.SignMessageRequest{Msg: message})
+		if err != nil {
+			return nil, errors.Wrap(err, classifyRPCError(err,
+				errors.ErrCodeRPCFailed), "failed to sign result")
+		}
+		sig.Algorithm = signing.AlgorithmNode
+		sig.Value = resp.Signature
+
+	default:
+		return nil, errors.New(errors.ErrCodeUnsupported,
+			fmt.Sprintf("unknown signing mode %q", s.Mode))
+	}
+
+	return sig, nil
+}
+
+// SignResult appends a signature over the result's first text content as
+// an extra {"signature": ...} text item, leaving the result text intact.
+func (s *SigningService) SignResult(ctx context.Context, tool string,
+	result *mcp.CallToolResult) error {
+	if result == nil {
+		return nil
+	}
+
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+
+		sig, err := s.Sign(ctx, tool, text.Text)
+		if err != nil {
+			return err
+		}
+		result.Content = append(result.Content,
+			mcp.NewTextContent(sig.JSON()))
+		return nil
+	}
+
+	return nil
+}
+
+// VerifyResponseTool returns the MCP tool definition for verifying a signed
+// tool result.
+func (s *SigningService) VerifyResponseTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_verify_response",
+		Description: "Verify that a tool result was signed by this " +
+			"server and has not been altered",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"tool": map[string]any{
+					"type":        "string",
+					"description": "Tool name from the signature",
+				},
+				"signed_at": map[string]any{
+					"type":        "string",
+					"description": "signed_at from the signature",
+				},
+				"content": map[string]any{
+					"type": "string",
+					"description": "Exact text of the tool " +
+						"result that was signed",
+				},
+				"signature": map[string]any{
+					"type":        "string",
+					"description": "Signature value",
+				},
+			},
+			Required: []string{"tool", "signed_at", "content",
+				"signature"},
+		},
+	}
+}
+
+// HandleVerifyResponse handles the verify response request.
+func (s *SigningService) HandleVerifyResponse(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	tool, _ := args["tool"].(string)
+	signedAt, _ := args["signed_at"].(string)
+	content, _ := args["content"].(string)
+	signature, _ := args["signature"].(string)
+	if tool == "" || signedAt == "" || signature == "" {
+		return invalidArgumentError(
+			"tool, signed_at and signature are required"), nil
+	}
+
+	message := signing.Message(tool, signedAt, content)
+
+	switch s.Mode {
+	case signing.ModeHMAC:
+		return jsonResult("lnc_verify_response", map[string]any{
+			"valid": signing.VerifyHMAC(s.Key, message,
+				signature),
+			"algorithm": signing.AlgorithmHMAC,
+		}), nil
+
+	case signing.ModeNode:
+		client, _ := s.Clients.Lightning()
+		if client == nil {
+			return notConnectedError(s.Clients), nil
+		}
+
+		info, err := client.GetInfo(ctx,
+			&lnrpc.GetInfoRequest{})
+		if err != nil {
+			return rpcError(err, "failed to get node info"), nil
+		}
+		resp, err := client.VerifyMessage(ctx,
+			&lnrpc.VerifyMessageRequest{
+				Msg:       message,
+				Signature: signature,
+			})
+		if err != nil {
+			return rpcError(err, "failed to verify signature"), nil
+		}
+
+		// A valid signature from another node is not ours.
+		ownKey := resp.Pubkey == info.IdentityPubkey
+		return jsonResult("lnc_verify_response", map[string]any{
+			"valid":               resp.Valid && ownKey,
+			"algorithm":           signing.AlgorithmNode,
+			"pubkey":              resp.Pubkey,
+			"signed_by_this_node": ownKey,
+		}), nil
+
+	default:
+		return toolError(errors.New(errors.ErrCodeUnsupported,
+			fmt.Sprintf("unknown signing mode %q", s.Mode))), nil
+	}
+}
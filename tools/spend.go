@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/jbrill/mcp-lnc-server/internal/policy"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// paymentSpendID identifies an attempt to pay paymentHash in the spend
+// ledger. Each attempt gets its own ID, so releasing an attempt lnd refuses,
+// such as a second payment of a settled hash, leaves the first one counted.
+func paymentSpendID(paymentHash []byte) string {
+	return fmt.Sprintf("payment:%x:%d", paymentHash, time.Now().UnixNano())
+}
+
+// channelOpenSpendID identifies what an attempt to open a channel to pubkey
+// pushes to the peer in the spend ledger.
+func channelOpenSpendID(pubkey []byte) string {
+	return fmt.Sprintf("channel_open:%x:%d", pubkey, time.Now().UnixNano())
+}
+
+// logSpendError logs a failure to update the spend ledger after a spend
+// settled or failed. The caller's result stands either way; a spend left
+// reserved only counts against the caps for longer than it should.
+func logSpendError(ctx context.Context, err error) {
+	if err != nil {
+		logging.LogWithContext(ctx).Warn("Failed to update the spend "+
+			"ledger", zap.Error(err))
+	}
+}
+
+// checkFeeRate holds an on-chain send to the fee rate cap. An explicit
+// satPerVbyte is checked as given. With a confirmation target and a capped
+// fee rate, the rate the wallet estimates for targetConf is checked
+// instead and returned, so the transaction is sent at the rate that passed
+// rather than one lnd estimates again later. Otherwise it returns
+// satPerVbyte, 0 for a target lnd resolves itself.
+func checkFeeRate(ctx context.Context, clients *ClientProvider,
+	engine *policy.Engine, satPerVbyte uint64,
+	targetConf int32) (uint64, *mcp.CallToolResult) {
+	if satPerVbyte > 0 || !engine.FeeRateLimited() {
+		if err := engine.CheckFeeRate(satPerVbyte); err != nil {
+			return 0, policyError(err)
+		}
+		return satPerVbyte, nil
+	}
+
+	walletKit, generation := clients.WalletKit()
+	if walletKit == nil {
+		return 0, notConnectedError(clients)
+	}
+	if missing := knownMissingSubserver(clients, "walletkit"); missing != nil {
+		return 0, missing
+	}
+	if targetConf == 0 {
+		targetConf = defaultSendTargetConf
+	}
+	estimate, err := walletKit.EstimateFee(ctx,
+		&walletrpc.EstimateFeeRequest{ConfTarget: targetConf})
+	if err != nil {
+		return 0, subserverError(clients, generation, "walletkit", err,
+			"failed to estimate the fee rate")
+	}
+	clients.recordSubserver(generation, "walletkit", true, "")
+
+	// A kiloweight is 250 vbytes; round up so the target is still met.
+	rate := max(uint64(estimate.SatPerKw+249)/250, 1)
+	if err := engine.CheckFeeRate(rate); err != nil {
+		return 0, policyError(err)
+	}
+	return rate, nil
+}
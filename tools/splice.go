@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// featureBitSpliceRequired and featureBitSpliceOptional are the
+	// option_splice feature bits from the BOLT specification.
+	featureBitSpliceRequired = 62
+	featureBitSpliceOptional = 63
+)
+
+// spliceMinLNDVersion is the first lnd release we expect to expose splicing
+// over RPC. No release up to 0.19 does; bump this once the RPC lands.
+var spliceMinLNDVersion = [3]int{0, 20, 0}
+
+// spliceSupport describes whether the connected node can splice.
+type spliceSupport struct {
+	Supported      bool
+	NodeAdvertises bool
+	LNDVersion     string
+	Reason         string
+}
+
+// toMap formats the splice support status for JSON output.
+func (s spliceSupport) toMap() map[string]any {
+	return map[string]any{
+		"supported":       s.Supported,
+		"node_advertises": s.NodeAdvertises,
+		"lnd_version":     s.LNDVersion,
+		"reason":          s.Reason,
+	}
+}
+
+// parseLNDVersion extracts the major, minor and patch numbers from an lnd
+// version string such as "0.19.3-beta commit=v0.19.3-beta".
+func parseLNDVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return parsed, false
+	}
+
+	core := strings.TrimPrefix(fields[0], "v")
+	if idx := strings.IndexAny(core, "-+"); idx >= 0 {
+		core = core[:idx]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+
+	return parsed, true
+}
+
+// versionAtLeast reports whether version is greater than or equal to min.
+func versionAtLeast(version, minVersion [3]int) bool {
+	for i := range version {
+		if version[i] != minVersion[i] {
+			return version[i] > minVersion[i]
+		}
+	}
+	return true
+}
+
+// hasSpliceFeature reports whether a feature map advertises option_splice.
+func hasSpliceFeature(features map[uint32]*lnrpc.Feature) bool {
+	_, required := features[featureBitSpliceRequired]
+	_, optional := features[featureBitSpliceOptional]
+	return required || optional
+}
+
+// detectSpliceSupport decides whether splicing can be used based on the
+// node's version and advertised features. Both must agree.
+func detectSpliceSupport(info *lnrpc.GetInfoResponse) spliceSupport {
+	status := spliceSupport{
+		NodeAdvertises: hasSpliceFeature(info.Features),
+		LNDVersion:     info.Version,
+	}
+
+	version, ok := parseLNDVersion(info.Version)
+	switch {
+	case !ok:
+		status.Reason = "unrecognized lnd version"
+	case !versionAtLeast(version, spliceMinLNDVersion):
+		status.Reason = fmt.Sprintf("lnd %d.%d.%d or newer is "+
+			"required", spliceMinLNDVersion[0],
+			spliceMinLNDVersion[1], spliceMinLNDVersion[2])
+	case !status.NodeAdvertises:
+		status.Reason = "node does not advertise option_splice"
+	default:
+		status.Supported = true
+	}
+
+	return status
+}
+
+// spliceStatus reports the splice support of the node behind the current
+// clients. GetInfo is called once per connection and its answer kept.
+// Errors are folded into the reason so listing tools never fail because of
+// it.
+func spliceStatus(ctx context.Context, clients *ClientProvider) spliceSupport {
+	if support, ok := clients.splicingSupport(); ok {
+		return support
+	}
+
+	client, generation := clients.Lightning()
+	if client == nil {
+		return spliceSupport{Reason: "not connected"}
+	}
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return spliceSupport{
+			Reason: fmt.Sprintf("failed to get node info: %v", err),
+		}
+	}
+
+	support := detectSpliceSupport(info)
+	clients.recordSplicing(generation, support)
+	return support
+}
+
+// channelSpliceStatus labels a channel's splice state. lnd does not yet
+// report splices per channel, so supported nodes get "unknown".
+func channelSpliceStatus(support spliceSupport) string {
+	if !support.Supported {
+		return "unsupported"
+	}
+	return "unknown"
+}
+
+// SpliceChannelTool returns the MCP tool definition for splicing funds into
+// or out of an existing channel.
+func (s *ChannelService) SpliceChannelTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_splice_channel",
+		Description: "Splice funds into or out of an existing channel " +
+			"(requires splicing support on the node)",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"channel_point": map[string]any{
+					"type":        "string",
+					"description": "Channel point (txid:index) to splice",
+					"pattern":     "^[0-9a-fA-F]{64}:[0-9]+$",
+				},
+				"amount_sat": map[string]any{
+					"type": "number",
+					"description": "Amount to splice in (positive) " +
+						"or out (negative)",
+				},
+			},
+			Required: []string{"channel_point", "amount_sat"},
+		},
+	}
+}
+
+// HandleSpliceChannel handles the splice channel request. The request is
+// gated on the node's splice support, read once per connection, and until
+// lnd exposes a splice RPC it reports what is missing instead of
+// attempting the operation.
+func (s *ChannelService) HandleSpliceChannel(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	channelPoint, ok := request.Params.Arguments["channel_point"].(string)
+	if !ok || channelPoint == "" {
+		return invalidArgumentError("channel_point is required"), nil
+	}
+	if _, _, err := parseChannelPoint("channel_point",
+		channelPoint); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	amount, ok := request.Params.Arguments["amount_sat"].(float64)
+	if !ok || amount == 0 {
+		return invalidArgumentError(
+			"amount_sat must be a non-zero number"), nil
+	}
+
+	support := spliceStatus(ctx, s.Clients)
+	if !support.Supported {
+		return toolError(errors.New(errors.ErrCodeUnsupported,
+			"splicing is not available: "+support.Reason).
+			WithDetails(map[string]any{
+				"splicing": support.toMap(),
+			})), nil
+	}
+
+	return toolError(errors.New(errors.ErrCodeUnsupported, fmt.Sprintf(
+		"node supports splicing but this server's lnd %s bindings do "+
+			"not include a splice RPC yet", support.LNDVersion)).
+		WithDetails(map[string]any{
+			"splicing": support.toMap(),
+		})), nil
+}
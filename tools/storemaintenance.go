@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxPruneDays is the longest age lnc_store_maintenance prunes by.
+const maxPruneDays = 3650
+
+// storeActions are what lnc_store_maintenance can do to the local stores.
+var storeActions = []string{"check", "vacuum", "prune", "export"}
+
+// localStore is one of the server's local stores as the maintenance tool
+// sees it.
+type localStore struct {
+	name string
+	path string
+
+	// check verifies the stored file and returns its format version.
+	check func() (int, error)
+
+	// vacuum removes leftover temporary files and rewrites the store,
+	// returning how many files it removed.
+	vacuum func() (int, error)
+
+	// prune drops entries older than olderThan, or than the store's own
+	// retention when it is zero, returning how many it dropped.
+	prune func(olderThan time.Duration) (int, error)
+
+	// records returns the store's entries for export.
+	records func() []any
+}
+
+// localStores returns the enabled local stores, in the order they are
+// reported.
+func (s *BackupService) localStores() []localStore {
+	var stores []localStore
+	if s.Journal != nil {
+		stores = append(stores, localStore{
+			name:   "journal",
+			path:   s.Journal.Path(),
+			check:  s.Journal.Check,
+			vacuum: s.Journal.Vacuum,
+			prune:  s.Journal.Prune,
+			records: func() []any {
+				var records []any
+				for _, op := range s.Journal.Operations() {
+					records = append(records, op)
+				}
+				return records
+			},
+		})
+	}
+	if s.Ledger != nil {
+		stores = append(stores, localStore{
+			name:   "spend_ledger",
+			path:   s.Ledger.Path(),
+			check:  s.Ledger.Check,
+			vacuum: s.Ledger.Vacuum,
+			// Spends younger than a day still count towards the
+			// limits, so the ledger keeps its own retention.
+			prune: func(time.Duration) (int, error) {
+				return s.Ledger.Prune()
+			},
+			records: func() []any {
+				var records []any
+				for _, spend := range s.Ledger.Spends() {
+					records = append(records, spend)
+				}
+				return records
+			},
+		})
+	}
+	if s.Checkpoints != nil {
+		stores = append(stores, localStore{
+			name:   "export_checkpoints",
+			path:   s.Checkpoints.Path(),
+			check:  s.Checkpoints.Check,
+			vacuum: s.Checkpoints.Vacuum,
+			// A checkpoint is replaced by the next export, never
+			// outdated, so there is nothing to prune.
+			prune: func(time.Duration) (int, error) {
+				return 0, nil
+			},
+			records: func() []any {
+				var records []any
+				for _, c := range s.Checkpoints.All() {
+					records = append(records, c)
+				}
+				return records
+			},
+		})
+	}
+	if s.Schedule != nil {
+		stores = append(stores, localStore{
+			name:   "schedule",
+			path:   s.Schedule.Path(),
+			check:  s.Schedule.Check,
+			vacuum: s.Schedule.Vacuum,
+			prune:  s.Schedule.Prune,
+			records: func() []any {
+				var records []any
+				for _, op := range s.Schedule.Operations() {
+					records = append(records, op)
+				}
+				return records
+			},
+		})
+	}
+	return stores
+}
+
+// StoreMaintenanceTool returns the MCP tool definition for maintaining the
+// server's local stores.
+func (s *BackupService) StoreMaintenanceTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_store_maintenance",
+		Description: "Maintain this MCP server's local stores: the " +
+			"operation journal, the spend ledger, the export " +
+			"checkpoints and the schedule. check " +
+			"verifies each file's format version and integrity " +
+			"checksum; vacuum removes temporary files left by " +
+			"interrupted writes and rewrites each store in the " +
+			"current format, which also repairs a file damaged " +
+			"since the server started; prune drops journal " +
+			"operations and scheduled operations finished more " +
+			"than older_than_days ago, including cancelled ones, " +
+			"and spends older than a day, which no limit counts " +
+			"any more; export writes every entry to a JSON " +
+			"lines file in the backup directory. Every action " +
+			"reports each store's state afterwards",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"description": "What to do: check, vacuum, prune or export",
+					"enum":        storeActions,
+				},
+				"older_than_days": map[string]any{
+					"type": "number",
+					"description": "For prune, the " +
+						"age of the journal " +
+						"and scheduled " +
+						"operations to drop " +
+						"(default the journal's " +
+						"retention period, and " +
+						"30 days for the " +
+						"schedule)",
+					"minimum": 1,
+					"maximum": maxPruneDays,
+				},
+				"name": map[string]any{
+					"type": "string",
+					"description": "For export, the " +
+						"file name in the backup " +
+						"directory (default " +
+						"mcp-lnc-stores-<time>" +
+						".jsonl). Existing files " +
+						"are never overwritten",
+					"pattern": backupNamePattern.String(),
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// HandleStoreMaintenance handles the store maintenance request.
+func (s *BackupService) HandleStoreMaintenance(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, _ := args["action"].(string)
+	days, _ := args["older_than_days"].(float64)
+	name, _ := args["name"].(string)
+
+	switch {
+	case action != "check" && action != "vacuum" && action != "prune" &&
+		action != "export":
+		return invalidArgumentError("action must be check, vacuum, " +
+			"prune or export"), nil
+	case days < 0 || days > maxPruneDays || days != math.Trunc(days):
+		return invalidArgumentError(fmt.Sprintf("older_than_days "+
+			"must be a whole number between 1 and %d",
+			maxPruneDays)), nil
+	case days != 0 && action != "prune":
+		return invalidArgumentError("older_than_days only applies " +
+			"to prune"), nil
+	case name != "" && action != "export":
+		return invalidArgumentError("name only applies to export"), nil
+	case action == "export" && s.Dir == "":
+		return invalidArgumentError("export needs a backup " +
+			"directory; set LNC_BACKUP_DIR"), nil
+	}
+
+	now := time.Now().UTC()
+	stores := s.localStores()
+	result := map[string]any{"action": action}
+	switch action {
+	case "export":
+		if name == "" {
+			name = "mcp-lnc-stores-" +
+				now.Format("20060102T150405Z") + ".jsonl"
+		}
+		if !backupNamePattern.MatchString(name) {
+			return invalidArgumentError("name must be a file " +
+				"name of letters, digits, dots, dashes " +
+				"and underscores"), nil
+		}
+		path := filepath.Join(s.Dir, name)
+		records, err := exportStores(path, stores)
+		switch {
+		case os.IsExist(err):
+			return invalidArgumentError("a file named " + name +
+				" already exists; choose another name"), nil
+		case err != nil:
+			return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+				"failed to export the local stores")), nil
+		}
+		result["name"] = name
+		result["path"] = path
+		result["records"] = records
+	}
+
+	reports := make([]map[string]any, 0, len(stores))
+	for _, store := range stores {
+		report := map[string]any{"store": store.name}
+		var err error
+		switch action {
+		case "vacuum":
+			var removed int
+			removed, err = store.vacuum()
+			report["temp_files_removed"] = removed
+		case "prune":
+			var pruned int
+			pruned, err = store.prune(
+				time.Duration(days) * 24 * time.Hour)
+			report["pruned"] = pruned
+		}
+		if err != nil {
+			return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+				"failed to "+action+" the "+store.name)), nil
+		}
+
+		// The state afterwards is reported whatever the action, so
+		// a damaged file shows up before it is next read.
+		version, err := store.check()
+		report["path"] = store.path
+		report["format_version"] = version
+		report["entries"] = len(store.records())
+		report["ok"] = err == nil
+		if err != nil {
+			report["problem"] = err.Error()
+		}
+		reports = append(reports, report)
+	}
+	result["stores"] = reports
+	result["checked_at"] = now.Format(time.RFC3339)
+
+	return jsonResult("lnc_store_maintenance", result), nil
+}
+
+// exportStores writes every entry of stores to a new JSON lines file at
+// path, one {"store", "record"} object per line, and returns how many it
+// wrote. An existing file is left alone, so the error satisfies
+// os.IsExist.
+func exportStores(path string, stores []localStore) (int, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		0o600)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	out := bufio.NewWriter(file)
+	encoder := json.NewEncoder(out)
+	for _, store := range stores {
+		for _, record := range store.records() {
+			err := encoder.Encode(map[string]any{
+				"store":  store.name,
+				"record": record,
+			})
+			if err != nil {
+				file.Close()
+				os.Remove(path)
+				return 0, err
+			}
+			written++
+		}
+	}
+	if err := out.Flush(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return written, nil
+}
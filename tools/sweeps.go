@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ListSweepsTool returns the MCP tool definition for listing the node's past
+// sweeps.
+func (s *OnChainService) ListSweepsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_sweeps",
+		Description: "List the sweep transactions the node has " +
+			"published to move funds back into its wallet, such " +
+			"as the outputs of force closed channels and claimed " +
+			"HTLCs, newest first, with the outputs each spent, " +
+			"the amount it returned to the wallet and its fee. " +
+			"Sweeps replaced by a fee bump are not listed. Use " +
+			"lnc_list_bumpable for sweeps still pending",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"start_height": map[string]any{
+					"type": "number",
+					"description": "Only list sweeps " +
+						"confirmed from this block " +
+						"height on, and unconfirmed " +
+						"ones",
+					"minimum": 0,
+				},
+				"unconfirmed_only": map[string]any{
+					"type": "boolean",
+					"description": "Only list sweeps " +
+						"not yet confirmed",
+				},
+			},
+		},
+	}
+}
+
+// HandleListSweeps handles the list sweeps request.
+func (s *OnChainService) HandleListSweeps(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	walletKit, generation := s.Clients.WalletKit()
+	if walletKit == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "walletkit"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	startHeight, _ := args["start_height"].(float64)
+	unconfirmedOnly, _ := args["unconfirmed_only"].(bool)
+	if startHeight < 0 || startHeight > math.MaxInt32 ||
+		startHeight != math.Trunc(startHeight) {
+		return invalidArgumentError("start_height must be a " +
+			"non-negative integer"), nil
+	}
+	// lnd takes a start height of -1 to mean unconfirmed sweeps only.
+	height := int32(startHeight)
+	if unconfirmedOnly {
+		height = -1
+	}
+
+	resp, err := walletKit.ListSweeps(ctx, &walletrpc.ListSweepsRequest{
+		Verbose:     true,
+		StartHeight: height,
+	})
+	if err != nil {
+		return subserverError(s.Clients, generation, "walletkit", err,
+			"failed to list sweeps"), nil
+	}
+	s.Clients.recordSubserver(generation, "walletkit", true, "")
+
+	var txs []*lnrpc.Transaction
+	if details := resp.GetTransactionDetails(); details != nil {
+		txs = details.Transactions
+	}
+
+	var totalReceived, totalFees int64
+	unconfirmed := 0
+	sweeps := make([]map[string]any, 0, len(txs))
+	for _, tx := range txs {
+		entry := sweepEntry(tx)
+		totalReceived += entry["received_sat"].(int64)
+		totalFees += tx.TotalFees
+		if tx.NumConfirmations == 0 {
+			unconfirmed++
+		}
+		sweeps = append(sweeps, entry)
+	}
+
+	// Unconfirmed sweeps, at height 0, are the newest.
+	sort.SliceStable(sweeps, func(i, j int) bool {
+		a, b := sweeps[i]["block_height"].(int32),
+			sweeps[j]["block_height"].(int32)
+		if a == 0 || b == 0 {
+			return a == 0 && b != 0
+		}
+		return a > b
+	})
+
+	return jsonResult("lnc_list_sweeps", map[string]any{
+		"sweeps":             sweeps,
+		"count":              len(sweeps),
+		"unconfirmed_count":  unconfirmed,
+		"total_received_sat": totalReceived,
+		"total_fees_sat":     totalFees,
+	}), nil
+}
+
+// sweepEntry formats a sweep transaction. The amount it returned to the
+// wallet is the sum of its outputs paying the wallet.
+func sweepEntry(tx *lnrpc.Transaction) map[string]any {
+	var received int64
+	addresses := make([]string, 0)
+	for _, output := range tx.OutputDetails {
+		if output.IsOurAddress {
+			received += output.Amount
+			addresses = append(addresses, output.Address)
+		}
+	}
+	inputs := make([]string, 0, len(tx.PreviousOutpoints))
+	for _, input := range tx.PreviousOutpoints {
+		inputs = append(inputs, input.Outpoint)
+	}
+
+	return txLabelFields(map[string]any{
+		"txid":             tx.TxHash,
+		"confirmations":    tx.NumConfirmations,
+		"confirmed":        tx.NumConfirmations > 0,
+		"block_height":     tx.BlockHeight,
+		"time_stamp":       tx.TimeStamp,
+		"received_sat":     received,
+		"fee_sat":          tx.TotalFees,
+		"swept_outpoints":  inputs,
+		"wallet_addresses": addresses,
+	}, tx.Label)
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// staleHeaderAge is how old the best block header may be before the
+	// node is reported as stalled. Blocks arrive every ten minutes on
+	// average; a 90 minute gap happens about once in ten thousand.
+	staleHeaderAge = 90 * time.Minute
+
+	// maxHeaderClockLead is how far a block timestamp may be ahead of the
+	// wall clock. Consensus allows two hours, so a header further ahead
+	// means this server's or the node's clock is wrong.
+	maxHeaderClockLead = 2 * time.Hour
+
+	// maxBlockLag is how many blocks the node may trail the external tip
+	// source by, allowing for propagation between the two.
+	maxBlockLag = 2
+
+	// tipSourceTimeout bounds the request to the external tip source.
+	tipSourceTimeout = 10 * time.Second
+)
+
+// GetSyncStatusTool returns the MCP tool definition for checking chain
+// sync.
+func (s *NodeService) GetSyncStatusTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_get_sync_status",
+		Description: "Check whether the node is keeping up with the " +
+			"chain: compares its best block header with the wall " +
+			"clock and, when configured, an external chain tip, " +
+			"and warns when the node appears stalled or a clock " +
+			"is skewed. A stalled node is a common cause of " +
+			"failing payments",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// syncWarning builds a warning entry for the sync status result.
+func syncWarning(check, message string) map[string]any {
+	return map[string]any{
+		"check":   check,
+		"message": message,
+	}
+}
+
+// HandleGetSyncStatus handles the sync status request.
+func (s *NodeService) HandleGetSyncStatus(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return notConnectedError(s.Clients), nil
+	}
+
+	info, err := client.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return rpcError(err, "failed to get node info"), nil
+	}
+
+	network := ""
+	if chains := chainNetworks(info.Chains); len(chains) > 0 {
+		network = chains[0]
+	}
+
+	now := time.Now()
+	headerTime := time.Unix(info.BestHeaderTimestamp, 0)
+	headerAge := now.Sub(headerTime)
+
+	result := map[string]any{
+		"network":               network,
+		"block_height":          info.BlockHeight,
+		"best_header_timestamp": headerTime.UTC().Format(time.RFC3339),
+		"header_age_seconds":    int64(headerAge / time.Second),
+		"synced_to_chain":       info.SyncedToChain,
+		"synced_to_graph":       info.SyncedToGraph,
+	}
+	warnings := make([]map[string]any, 0)
+
+	if !info.SyncedToChain {
+		warnings = append(warnings, syncWarning("synced_to_chain",
+			"the node reports it is not synced to the chain; "+
+				"payments and channel operations may fail "+
+				"until it catches up"))
+	}
+
+	// Blocks are only mined on demand on regtest and simnet, so an old
+	// header says nothing there.
+	if network != "regtest" && network != "simnet" {
+		switch {
+		case info.BestHeaderTimestamp == 0:
+			warnings = append(warnings, syncWarning("header_age",
+				"the node did not report a best header "+
+					"timestamp"))
+
+		case -headerAge > maxHeaderClockLead:
+			warnings = append(warnings, syncWarning("clock_skew",
+				"the node's best block header is more than "+
+					"two hours ahead of this server's clock; "+
+					"check the clocks of this server and the "+
+					"node"))
+
+		case headerAge > staleHeaderAge:
+			warnings = append(warnings, syncWarning("header_age",
+				"the node has not seen a new block header "+
+					"for more than 90 minutes; its chain "+
+					"backend may be stalled or offline, "+
+					"or this server's clock is ahead"))
+		}
+	}
+
+	if s.TipSource != "" {
+		result["tip_source"] = s.TipSource
+		tip, err := s.fetchTipHeight(ctx)
+		if err != nil {
+			result["tip_error"] = err.Error()
+		} else {
+			behind := int64(tip) - int64(info.BlockHeight)
+			result["external_tip_height"] = tip
+			result["blocks_behind"] = behind
+
+			switch {
+			case behind > maxBlockLag:
+				warnings = append(warnings, syncWarning(
+					"block_lag", fmt.Sprintf("the node is %d "+
+						"blocks behind the external "+
+						"tip", behind)))
+
+			case -behind > maxBlockLag:
+				warnings = append(warnings, syncWarning(
+					"tip_source", "the node is ahead of "+
+						"the external tip; the tip "+
+						"source may serve a different "+
+						"network"))
+			}
+		}
+	}
+
+	result["status"] = "ok"
+	if len(warnings) > 0 {
+		result["status"] = "warning"
+	}
+	result["warnings"] = warnings
+
+	return jsonResult("lnc_get_sync_status", result), nil
+}
+
+// fetchTipHeight asks the external tip source for the current chain height.
+// The source must answer a GET with the height as plain text, as Esplora's
+// /blocks/tip/height endpoint does.
+func (s *NodeService) fetchTipHeight(ctx context.Context) (uint32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.TipSource, nil)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tip source: %w", err)
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("tip source unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("tip source returned HTTP %d",
+			resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return 0, fmt.Errorf("reading tip source: %w", err)
+	}
+	height, err := strconv.ParseUint(strings.TrimSpace(string(body)),
+		10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("tip source did not return a block " +
+			"height")
+	}
+
+	return uint32(height), nil
+}
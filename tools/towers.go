@@ -0,0 +1,314 @@
+package tools
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/status"
+)
+
+// towerClientGuidance tells how to turn on a tower client that lnd was
+// built with but runs without.
+const towerClientGuidance = "enable the tower client with " +
+	"wtclient.active=true in lnd.conf"
+
+// towerPolicyTypes are the tower session policies, as the policy_type
+// argument names them.
+var towerPolicyTypes = []string{"legacy", "anchor", "taproot"}
+
+// towerStatsNote explains what the tower client statistics count.
+const towerStatsNote = "Counts are kept in memory and start again when " +
+	"lnd restarts. A backup is one channel state sent to a tower; " +
+	"pending backups are waiting for the tower to acknowledge them"
+
+// towerPolicyNote explains the tower client policy settings.
+const towerPolicyNote = "Each session with a tower backs up at most " +
+	"max_updates channel states before the client negotiates a new one. " +
+	"sweep_sat_per_vbyte is the fee rate of the justice transaction the " +
+	"tower broadcasts after a breach. A policy type is disabled when " +
+	"the node runs no client for it, as for taproot without taproot " +
+	"channels"
+
+// ListTowersTool returns the MCP tool definition for listing the
+// watchtowers the node's tower client uses.
+func (s *NodeService) ListTowersTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_list_towers",
+		Description: "List the watchtowers the node's tower client " +
+			"backs channel states up to, with their addresses " +
+			"and, for each session policy (legacy, anchor or " +
+			"taproot channels), whether the tower is used for " +
+			"new backups and how many sessions it holds. Use it " +
+			"to verify that watchtower protection is active",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"include_sessions": map[string]any{
+					"type": "boolean",
+					"description": "List each session " +
+						"with its backups and fee rate",
+				},
+				"include_exhausted": map[string]any{
+					"type": "boolean",
+					"description": "Count sessions with " +
+						"no backups left too",
+				},
+			},
+		},
+	}
+}
+
+// HandleListTowers handles the list towers request.
+func (s *NodeService) HandleListTowers(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	wtclient, generation := s.Clients.Towers()
+	if wtclient == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "wtclient"); missing != nil {
+		return missing, nil
+	}
+
+	args := request.Params.Arguments
+	includeSessions, _ := args["include_sessions"].(bool)
+	includeExhausted, _ := args["include_exhausted"].(bool)
+
+	list, err := wtclient.ListTowers(ctx, &wtclientrpc.ListTowersRequest{
+		IncludeSessions:          includeSessions,
+		ExcludeExhaustedSessions: !includeExhausted,
+	})
+	if err != nil {
+		return towerClientError(s.Clients, generation, err,
+			"failed to list towers"), nil
+	}
+	s.Clients.recordSubserver(generation, "wtclient", true, "")
+
+	activeTowers := 0
+	covered := make([]string, 0, len(towerPolicyTypes))
+	towers := make([]map[string]any, 0, len(list.Towers))
+	for _, tower := range list.Towers {
+		active := false
+		policies := make([]map[string]any, 0, len(tower.SessionInfo))
+		for _, info := range tower.SessionInfo {
+			policies = append(policies, towerSessionEntry(info))
+			if !info.ActiveSessionCandidate {
+				continue
+			}
+			active = true
+			policy := info.PolicyType.String()
+			if !slices.Contains(covered, policy) {
+				covered = append(covered, policy)
+			}
+		}
+		if active {
+			activeTowers++
+		}
+		towers = append(towers, map[string]any{
+			"pubkey":    hex.EncodeToString(tower.Pubkey),
+			"addresses": tower.Addresses,
+			"active":    active,
+			"sessions":  policies,
+		})
+	}
+	slices.Sort(covered)
+
+	result := map[string]any{
+		"towers":           towers,
+		"count":            len(towers),
+		"active_towers":    activeTowers,
+		"covered_policies": covered,
+	}
+	if activeTowers == 0 {
+		result["warning"] = "no tower is used for new backups; add " +
+			"one with lncli wtclient add"
+	}
+
+	return jsonResult("lnc_list_towers", result), nil
+}
+
+// TowerStatsTool returns the MCP tool definition for the tower client's
+// backup statistics.
+func (s *NodeService) TowerStatsTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_tower_stats",
+		Description: "Show how the node's watchtower client has " +
+			"fared since lnd started: channel states backed up, " +
+			"waiting for a tower's acknowledgement and failed, " +
+			"and the sessions negotiated and used up. Failed " +
+			"backups mean some channel states are not protected",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]any{},
+		},
+	}
+}
+
+// HandleTowerStats handles the tower stats request.
+func (s *NodeService) HandleTowerStats(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	wtclient, generation := s.Clients.Towers()
+	if wtclient == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "wtclient"); missing != nil {
+		return missing, nil
+	}
+
+	stats, err := wtclient.Stats(ctx, &wtclientrpc.StatsRequest{})
+	if err != nil {
+		return towerClientError(s.Clients, generation, err,
+			"failed to get tower stats"), nil
+	}
+	s.Clients.recordSubserver(generation, "wtclient", true, "")
+
+	result := map[string]any{
+		"num_backups":            stats.NumBackups,
+		"num_pending_backups":    stats.NumPendingBackups,
+		"num_failed_backups":     stats.NumFailedBackups,
+		"num_sessions_acquired":  stats.NumSessionsAcquired,
+		"num_sessions_exhausted": stats.NumSessionsExhausted,
+		"healthy":                stats.NumFailedBackups == 0,
+		"note":                   towerStatsNote,
+	}
+	if stats.NumFailedBackups > 0 {
+		result["warning"] = fmt.Sprintf("%d channel states failed "+
+			"to be backed up to a tower", stats.NumFailedBackups)
+	}
+
+	return jsonResult("lnc_tower_stats", result), nil
+}
+
+// TowerPolicyTool returns the MCP tool definition for the tower client's
+// session policies.
+func (s *NodeService) TowerPolicyTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_tower_policy",
+		Description: "Show the policy the node's watchtower client " +
+			"negotiates sessions with: how many channel states " +
+			"a session backs up and the fee rate of the justice " +
+			"transaction a tower broadcasts after a breach. " +
+			"Without policy_type every policy is listed",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"policy_type": map[string]any{
+					"type": "string",
+					"description": "Policy to show, " +
+						"by channel type",
+					"enum": towerPolicyTypes,
+				},
+			},
+		},
+	}
+}
+
+// HandleTowerPolicy handles the tower policy request.
+func (s *NodeService) HandleTowerPolicy(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	wtclient, generation := s.Clients.Towers()
+	if wtclient == nil {
+		return notConnectedError(s.Clients), nil
+	}
+	if missing := knownMissingSubserver(s.Clients, "wtclient"); missing != nil {
+		return missing, nil
+	}
+
+	types := towerPolicyTypes
+	value, _ := request.Params.Arguments["policy_type"].(string)
+	if value != "" && !slices.Contains(towerPolicyTypes, value) {
+		return invalidArgumentError("policy_type must be one of " +
+			strings.Join(towerPolicyTypes, ", ")), nil
+	}
+	if value != "" {
+		types = []string{value}
+	}
+
+	policies := make([]map[string]any, 0, len(types))
+	for _, name := range types {
+		policyType := wtclientrpc.PolicyType(
+			wtclientrpc.PolicyType_value[strings.ToUpper(name)])
+		policy, err := wtclient.Policy(ctx, &wtclientrpc.PolicyRequest{
+			PolicyType: policyType,
+		})
+
+		// lnd runs a client only for the channel types it supports.
+		if err != nil && strings.Contains(
+			status.Convert(err).Message(), "no client") {
+			policies = append(policies, map[string]any{
+				"policy_type": policyType.String(),
+				"enabled":     false,
+			})
+			continue
+		}
+		if err != nil {
+			return towerClientError(s.Clients, generation, err,
+				"failed to get tower policy"), nil
+		}
+		s.Clients.recordSubserver(generation, "wtclient", true, "")
+
+		policies = append(policies, map[string]any{
+			"policy_type":         policyType.String(),
+			"enabled":             true,
+			"max_updates":         policy.MaxUpdates,
+			"sweep_sat_per_vbyte": policy.SweepSatPerVbyte,
+		})
+	}
+
+	return jsonResult("lnc_tower_policy", map[string]any{
+		"policies": policies,
+		"note":     towerPolicyNote,
+	}), nil
+}
+
+// towerSessionEntry formats a tower's sessions under one policy, with each
+// session when they were asked for.
+func towerSessionEntry(info *wtclientrpc.TowerSessionInfo) map[string]any {
+	entry := map[string]any{
+		"policy_type":              info.PolicyType.String(),
+		"active_session_candidate": info.ActiveSessionCandidate,
+		"num_sessions":             info.NumSessions,
+	}
+	if len(info.Sessions) == 0 {
+		return entry
+	}
+
+	sessions := make([]map[string]any, 0, len(info.Sessions))
+	for _, session := range info.Sessions {
+		sessions = append(sessions, map[string]any{
+			"id":                  hex.EncodeToString(session.Id),
+			"num_backups":         session.NumBackups,
+			"num_pending_backups": session.NumPendingBackups,
+			"max_backups":         session.MaxBackups,
+			"sweep_sat_per_vbyte": session.SweepSatPerVbyte,
+		})
+	}
+	entry["sessions"] = sessions
+
+	return entry
+}
+
+// towerClientInactive reports whether err is lnd's answer from a tower
+// client subserver it runs with the client turned off.
+func towerClientInactive(err error) bool {
+	return strings.Contains(status.Convert(err).Message(), "not active")
+}
+
+// towerClientError is subserverError for the tower client, which lnd can
+// also include but leave turned off.
+func towerClientError(clients *ClientProvider, generation uint64, err error,
+	message string) *mcp.CallToolResult {
+	if !towerClientInactive(err) {
+		return subserverError(clients, generation, "wtclient", err,
+			message)
+	}
+
+	clients.recordSubserver(generation, "wtclient", true, "")
+	return toolError(errors.New(errors.ErrCodeUnsupported,
+		"the watchtower client is not active; "+towerClientGuidance))
+}
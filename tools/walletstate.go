@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	lnccontext "github.com/jbrill/mcp-lnc-server/internal/context"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/logging"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// walletStateMessages are the errors lnd answers calls with while its wallet
+// is not ready, and the state each stands for.
+var walletStateMessages = []struct {
+	text  string
+	state lnrpc.WalletState
+}{
+	{"wallet locked", lnrpc.WalletState_LOCKED},
+	{"wallet not created", lnrpc.WalletState_NON_EXISTING},
+	{"waiting to start", lnrpc.WalletState_WAITING_TO_START},
+	{"in the process of starting up", lnrpc.WalletState_UNLOCKED},
+}
+
+// walletNotReadyError reports a node whose wallet cannot serve calls yet.
+// The connection is kept when the wallet may be unlocked over it.
+type walletNotReadyError struct {
+	state lnrpc.WalletState
+	conn  *grpc.ClientConn
+}
+
+// Error implements the error interface.
+func (e *walletNotReadyError) Error() string {
+	return "the node's wallet is " + walletStateName(e.state)
+}
+
+// walletStateOf returns the wallet state of the node behind conn, which
+// failed a call with err. The state service is asked first; if the node does
+// not answer, the state is read from err.
+func walletStateOf(ctx context.Context, conn *grpc.ClientConn,
+	err error) (lnrpc.WalletState, bool) {
+	resp, stateErr := lnrpc.NewStateClient(conn).GetState(ctx,
+		&lnrpc.GetStateRequest{})
+	if stateErr == nil {
+		return resp.State, true
+	}
+	return walletStateFromError(err)
+}
+
+// walletStateFromError returns the wallet state an RPC error from lnd
+// stands for, if it is one of the errors of a wallet that is not ready.
+func walletStateFromError(err error) (lnrpc.WalletState, bool) {
+	if err == nil {
+		return 0, false
+	}
+	message := status.Convert(err).Message()
+	for _, known := range walletStateMessages {
+		if strings.Contains(message, known.text) {
+			return known.state, true
+		}
+	}
+	return 0, false
+}
+
+// walletReady reports whether a node in state serves the Lightning RPCs.
+func walletReady(state lnrpc.WalletState) bool {
+	return state == lnrpc.WalletState_RPC_ACTIVE ||
+		state == lnrpc.WalletState_SERVER_ACTIVE
+}
+
+// walletStateName describes a wallet state for messages.
+func walletStateName(state lnrpc.WalletState) string {
+	switch state {
+	case lnrpc.WalletState_LOCKED:
+		return "locked"
+	case lnrpc.WalletState_NON_EXISTING:
+		return "not created"
+	default:
+		return "starting up"
+	}
+}
+
+// walletStateError explains a wallet that is not ready and how to get it
+// ready. With unlockable set, the steps include lnc_unlock_wallet.
+func walletStateError(state lnrpc.WalletState, unlockable bool) *errors.Error {
+	code := errors.ErrCodeWalletLocked
+	var steps []string
+	switch state {
+	case lnrpc.WalletState_LOCKED:
+		steps = []string{
+			"unlock the wallet on the node, with lncli unlock or " +
+				"from Lightning Terminal or the node's dashboard",
+			"to have lnd unlock it on every start, set " +
+				"wallet-unlock-password-file in lnd.conf",
+			"then call lnc_connect again",
+		}
+		if unlockable {
+			steps = append(steps, "on a development node, "+
+				"lnc_unlock_wallet can unlock it over this "+
+				"connection instead")
+		}
+
+	case lnrpc.WalletState_NON_EXISTING:
+		steps = []string{
+			"create the wallet on the node, with lncli create",
+			"then call lnc_connect again",
+		}
+
+	default:
+		// The node is unlocked and only has to finish starting.
+		code = errors.ErrCodeConnectionFailed
+		steps = []string{"call lnc_connect again in a minute"}
+	}
+
+	return errors.New(code, fmt.Sprintf("the node's wallet is %s, so it "+
+		"cannot serve requests", walletStateName(state))).WithDetails(
+		map[string]any{
+			"wallet_state": state.String(),
+			"steps":        steps,
+		})
+}
+
+// walletNotReady reports a connection attempt that reached a node whose
+// wallet is not ready, keeping a connection lnc_unlock_wallet may use.
+func (s *ConnectionService) walletNotReady(
+	notReady *walletNotReadyError) *mcp.CallToolResult {
+	s.State.SetFailed(notReady.Error())
+
+	s.lockedMu.Lock()
+	previous := s.lockedConn
+	s.lockedConn = notReady.conn
+	s.lockedMu.Unlock()
+	if previous != nil && previous != notReady.conn {
+		previous.Close()
+	}
+
+	return toolError(walletStateError(notReady.state, notReady.conn != nil))
+}
+
+// UnlockWalletTool returns the MCP tool definition for unlocking the wallet
+// of a node lnc_connect found locked.
+func (s *ConnectionService) UnlockWalletTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_unlock_wallet",
+		Description: "Unlock the wallet of a development node that " +
+			"lnc_connect found locked, and finish connecting. The " +
+			"wallet password passes through this conversation, so " +
+			"never use this with a node holding real funds",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"wallet_password": map[string]any{
+					"type":        "string",
+					"description": "The node's wallet password",
+				},
+			},
+			Required: []string{"wallet_password"},
+		},
+	}
+}
+
+// HandleUnlockWallet handles the unlock wallet request. Once the wallet is
+// unlocked, the connection is kept as lnc_connect would have kept it.
+func (s *ConnectionService) HandleUnlockWallet(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	reqCtx := lnccontext.New(ctx, "lnc_unlock_wallet", 90*time.Second)
+	defer reqCtx.Cancel()
+	logger := logging.LogWithContext(reqCtx)
+
+	password, _ := request.Params.Arguments["wallet_password"].(string)
+	if password == "" {
+		return invalidArgumentError("wallet_password is required"), nil
+	}
+
+	s.lockedMu.Lock()
+	conn := s.lockedConn
+	s.lockedConn = nil
+	s.lockedMu.Unlock()
+	if conn == nil {
+		return toolError(errors.New(errors.ErrCodeNotConnected,
+			"no connection is waiting for its wallet to be "+
+				"unlocked; call lnc_connect first")), nil
+	}
+
+	_, err := lnrpc.NewWalletUnlockerClient(conn).UnlockWallet(reqCtx,
+		&lnrpc.UnlockWalletRequest{WalletPassword: []byte(password)})
+	if err != nil {
+		// The connection is kept so a mistyped password can be
+		// retried.
+		s.lockedMu.Lock()
+		if s.lockedConn == nil {
+			s.lockedConn = conn
+		} else {
+			conn.Close()
+		}
+		s.lockedMu.Unlock()
+		return rpcError(err, "failed to unlock wallet"), nil
+	}
+	logger.Info("Wallet unlocked, waiting for the node to start")
+
+	if err := waitWalletReady(reqCtx, conn); err != nil {
+		conn.Close()
+		s.State.SetFailed("wallet unlocked but the node did not start")
+		return toolError(errors.Wrap(err, errors.ErrCodeTimeout,
+			"the wallet was unlocked but the node did not finish "+
+				"starting; call lnc_connect again once it has")), nil
+	}
+
+	info, err := lnrpc.NewLightningClient(conn).GetInfo(reqCtx,
+		&lnrpc.GetInfoRequest{})
+	if err != nil {
+		conn.Close()
+		s.State.SetFailed(err.Error())
+		return rpcError(err, "failed to get node info"), nil
+	}
+
+	summary, failure := s.establish(reqCtx, conn, info)
+	if failure != nil {
+		return failure, nil
+	}
+
+	return jsonResult("lnc_unlock_wallet", summary), nil
+}
+
+// waitWalletReady waits until the node behind conn serves the Lightning
+// RPCs.
+func waitWalletReady(ctx context.Context, conn *grpc.ClientConn) error {
+	stream, err := lnrpc.NewStateClient(conn).SubscribeState(ctx,
+		&lnrpc.SubscribeStateRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if walletReady(update.State) {
+			return nil
+		}
+	}
+}
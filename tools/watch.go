@@ -0,0 +1,415 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jbrill/mcp-lnc-server/internal/contacts"
+	"github.com/jbrill/mcp-lnc-server/internal/errors"
+	"github.com/jbrill/mcp-lnc-server/internal/watchlist"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchService watches external nodes of interest, such as LSPs and large
+// routing partners, through the node's channel graph.
+type WatchService struct {
+	Clients *ClientProvider
+	List    *watchlist.List
+
+	// Contacts names the watched nodes in results. Nil leaves them
+	// unnamed.
+	Contacts *contacts.Book
+
+	// OnChanges is called with the changes each check finds, formatted
+	// as in results with the pubkey of the node that changed, so they
+	// can be raised as events. Nil drops them.
+	OnChanges func(ctx context.Context, changes []map[string]any)
+
+	// checkMu keeps checks from interleaving, so each change is found by
+	// one of them.
+	checkMu sync.Mutex
+}
+
+// NewWatchService creates a new watch service.
+func NewWatchService(client lnrpc.LightningClient,
+	list *watchlist.List) *WatchService {
+	return &WatchService{
+		Clients: NewClientProvider(client),
+		List:    list,
+	}
+}
+
+// WatchNodeTool returns the MCP tool definition for watching a node.
+func (s *WatchService) WatchNodeTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_watch_node",
+		Description: "Watch an external node, such as an LSP or a " +
+			"large routing partner, for policy changes, new and " +
+			"closed channels, and disappearing from the graph. " +
+			"Its current state in the node's graph is recorded " +
+			"as the baseline; lnc_watched_nodes reports what " +
+			"changed since. Watching the node again updates its " +
+			"note. This edits the server's local watchlist, not " +
+			"the node",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"pubkey": map[string]any{
+					"type":        "string",
+					"description": "Public key of the node, in hex",
+				},
+				"note": map[string]any{
+					"type":        "string",
+					"description": "Why the node is watched, such as \"our LSP\"",
+				},
+			},
+			Required: []string{"pubkey"},
+		},
+	}
+}
+
+// HandleWatchNode handles the watch node request.
+func (s *WatchService) HandleWatchNode(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pubkey, _ := request.Params.Arguments["pubkey"].(string)
+	if _, err := parsePubkey("pubkey", pubkey); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+	note, _ := request.Params.Arguments["note"].(string)
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > watchlist.MaxNoteLength {
+		return invalidArgumentError(fmt.Sprintf("note must be at most "+
+			"%d characters", watchlist.MaxNoteLength)), nil
+	}
+
+	node, created, err := s.List.Add(pubkey, note)
+	switch {
+	case err == watchlist.ErrDisabled:
+		return watchlistDisabledError(), nil
+	case err == watchlist.ErrFull:
+		return invalidArgumentError(err.Error()), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to save watchlist")), nil
+	}
+
+	// A new node is baselined at once when the node is connected, so
+	// changes from now on are reported. If the lookup fails, the next
+	// check takes the baseline instead.
+	if client, _ := s.Clients.Lightning(); client != nil && created {
+		s.checkMu.Lock()
+		_, err := s.checkNode(ctx, client, node.Pubkey)
+		s.checkMu.Unlock()
+		if err == nil {
+			node, _ = s.List.Get(node.Pubkey)
+		}
+	}
+
+	return jsonResult("lnc_watch_node", map[string]any{
+		"node":    s.watchedNodeMap(node),
+		"created": created,
+	}), nil
+}
+
+// UnwatchNodeTool returns the MCP tool definition for no longer watching
+// a node.
+func (s *WatchService) UnwatchNodeTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_unwatch_node",
+		Description: "Stop watching a node, removing it from the " +
+			"server's local watchlist",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"pubkey": map[string]any{
+					"type":        "string",
+					"description": "Public key of the node, in hex",
+				},
+			},
+			Required: []string{"pubkey"},
+		},
+	}
+}
+
+// HandleUnwatchNode handles the unwatch node request.
+func (s *WatchService) HandleUnwatchNode(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pubkey, _ := request.Params.Arguments["pubkey"].(string)
+	if _, err := parsePubkey("pubkey", pubkey); err != nil {
+		return invalidArgumentError(err.Error()), nil
+	}
+
+	removed, err := s.List.Remove(pubkey)
+	switch {
+	case err == watchlist.ErrDisabled:
+		return watchlistDisabledError(), nil
+	case err != nil:
+		return toolError(errors.Wrap(err, errors.ErrCodeUnknown,
+			"failed to save watchlist")), nil
+	}
+
+	return jsonResult("lnc_unwatch_node", map[string]any{
+		"pubkey":  strings.ToLower(pubkey),
+		"removed": removed,
+	}), nil
+}
+
+// WatchedNodesTool returns the MCP tool definition for the watched nodes
+// report.
+func (s *WatchService) WatchedNodesTool() mcp.Tool {
+	return mcp.Tool{
+		Name: "lnc_watched_nodes",
+		Description: "Report on the watched nodes: whether each is " +
+			"in the graph, its alias, channel count and " +
+			"capacity, and what changed since it was last " +
+			"checked: policy changes, opened and closed " +
+			"channels, alias changes, and leaving or returning " +
+			"to the graph. Each change is also recorded as an " +
+			"event. Set refresh to false to report the last " +
+			"check without looking the nodes up",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]any{
+				"refresh": map[string]any{
+					"type":        "boolean",
+					"description": "Look the nodes up in the graph now (default true)",
+				},
+			},
+		},
+	}
+}
+
+// HandleWatchedNodes handles the watched nodes request.
+func (s *WatchService) HandleWatchedNodes(ctx context.Context,
+	request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	refresh, ok := request.Params.Arguments["refresh"].(bool)
+	if !ok {
+		refresh = true
+	}
+
+	changes := make(map[string][]map[string]any)
+	total := 0
+	if refresh {
+		client, _ := s.Clients.Lightning()
+		if client == nil {
+			return notConnectedError(s.Clients), nil
+		}
+
+		found, err := s.check(ctx, client)
+		if err != nil {
+			return rpcError(err, "failed to look up watched "+
+				"nodes"), nil
+		}
+		for _, change := range found {
+			pubkey := change["pubkey"].(string)
+			changes[pubkey] = append(changes[pubkey], change)
+		}
+		total = len(found)
+	}
+
+	watched := s.List.All()
+	nodes := make([]map[string]any, len(watched))
+	for i, node := range watched {
+		entry := s.watchedNodeMap(node)
+		if refresh {
+			list := make([]map[string]any, 0,
+				len(changes[node.Pubkey]))
+			for _, change := range changes[node.Pubkey] {
+				change = maps.Clone(change)
+				delete(change, "pubkey")
+				list = append(list, change)
+			}
+			entry["changes"] = list
+		}
+		nodes[i] = entry
+	}
+
+	return jsonResult("lnc_watched_nodes", map[string]any{
+		"nodes":        nodes,
+		"count":        len(nodes),
+		"refreshed":    refresh,
+		"change_count": total,
+	}), nil
+}
+
+// Check looks every watched node up in the node's graph, records what it
+// saw and raises the changes since the last check through OnChanges. It
+// returns how many changes it found.
+func (s *WatchService) Check(ctx context.Context) (int, error) {
+	client, _ := s.Clients.Lightning()
+	if client == nil {
+		return 0, errors.ErrNotConnected()
+	}
+
+	changes, err := s.check(ctx, client)
+	return len(changes), err
+}
+
+// check looks every watched node up and returns the changes found, each
+// with the pubkey of its node. Changes found before a lookup fails are
+// still recorded and raised.
+func (s *WatchService) check(ctx context.Context,
+	client lnrpc.LightningClient) ([]map[string]any, error) {
+	s.checkMu.Lock()
+	defer s.checkMu.Unlock()
+
+	var changes []map[string]any
+	var err error
+	for _, node := range s.List.All() {
+		var found []map[string]any
+		found, err = s.checkNode(ctx, client, node.Pubkey)
+		changes = append(changes, found...)
+		if err != nil {
+			break
+		}
+	}
+
+	if len(changes) > 0 && s.OnChanges != nil {
+		s.OnChanges(ctx, changes)
+	}
+	return changes, err
+}
+
+// checkNode looks one watched node up and records what it saw, returning
+// the changes since the last check. The caller holds checkMu.
+func (s *WatchService) checkNode(ctx context.Context,
+	client lnrpc.LightningClient, pubkey string) ([]map[string]any,
+	error) {
+	snapshot := watchlist.Snapshot{CheckedAt: time.Now().UTC()}
+	info, err := client.GetNodeInfo(ctx, &lnrpc.NodeInfoRequest{
+		PubKey:          pubkey,
+		IncludeChannels: true,
+	})
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		return nil, err
+	default:
+		snapshot = watchSnapshot(pubkey, info)
+	}
+
+	changes, err := s.List.Record(pubkey, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]map[string]any, len(changes))
+	for i, change := range changes {
+		found[i] = watchChangeMap(change)
+		found[i]["pubkey"] = change.Pubkey
+	}
+	return found, nil
+}
+
+// watchSnapshot records what the graph shows of a watched node.
+func watchSnapshot(pubkey string, info *lnrpc.NodeInfo) watchlist.Snapshot {
+	snapshot := watchlist.Snapshot{
+		CheckedAt: time.Now().UTC(),
+		InGraph:   true,
+		Channels: make(map[uint64]watchlist.Channel,
+			len(info.Channels)),
+	}
+	if info.Node != nil {
+		snapshot.Alias = info.Node.Alias
+	}
+
+	for _, edge := range info.Channels {
+		peer, policy := edge.Node2Pub, edge.Node1Policy
+		if !strings.EqualFold(edge.Node1Pub, pubkey) {
+			peer, policy = edge.Node1Pub, edge.Node2Policy
+		}
+
+		channel := watchlist.Channel{
+			Peer:     peer,
+			Capacity: edge.Capacity,
+		}
+		if policy != nil {
+			channel.Policy = &watchlist.Policy{
+				FeeBaseMsat:   policy.FeeBaseMsat,
+				FeeRatePPM:    policy.FeeRateMilliMsat,
+				TimeLockDelta: policy.TimeLockDelta,
+				MinHTLCMsat:   policy.MinHtlc,
+				MaxHTLCMsat:   policy.MaxHtlcMsat,
+				Disabled:      policy.Disabled,
+			}
+		}
+		snapshot.Channels[edge.ChannelId] = channel
+	}
+
+	return snapshot
+}
+
+// watchedNodeMap formats a watched node with what its last check saw.
+func (s *WatchService) watchedNodeMap(node watchlist.Node) map[string]any {
+	entry := map[string]any{
+		"pubkey":   node.Pubkey,
+		"added_at": node.AddedAt.Format(time.RFC3339),
+	}
+	if node.Note != "" {
+		entry["note"] = node.Note
+	}
+	addContact(s.Contacts, entry, node.Pubkey)
+
+	if snapshot := node.Snapshot; snapshot != nil {
+		entry["checked_at"] = snapshot.CheckedAt.Format(time.RFC3339)
+		entry["in_graph"] = snapshot.InGraph
+		if snapshot.InGraph {
+			entry["alias"] = snapshot.Alias
+			entry["num_channels"] = len(snapshot.Channels)
+			entry["total_capacity"] = snapshot.Capacity()
+		}
+	}
+	return entry
+}
+
+// watchChangeMap formats a change to a watched node.
+func watchChangeMap(change watchlist.Change) map[string]any {
+	entry := map[string]any{"kind": change.Kind}
+	switch change.Kind {
+	case watchlist.ChangeAlias:
+		entry["old_alias"] = change.OldAlias
+		entry["new_alias"] = change.NewAlias
+
+	case watchlist.ChangeChannelOpened, watchlist.ChangeChannelClosed,
+		watchlist.ChangePolicy:
+		entry["chan_id"] = strconv.FormatUint(change.ChanID, 10)
+		entry["short_channel_id"] = formatShortChanID(change.ChanID)
+		entry["peer"] = change.Peer
+		entry["capacity"] = change.Capacity
+		if change.OldPolicy != nil {
+			entry["old_policy"] = watchPolicyMap(change.OldPolicy)
+		}
+		if change.NewPolicy != nil {
+			entry["new_policy"] = watchPolicyMap(change.NewPolicy)
+		}
+	}
+	return entry
+}
+
+// watchPolicyMap formats a watched node's routing policy.
+func watchPolicyMap(policy *watchlist.Policy) map[string]any {
+	return map[string]any{
+		"fee_base_msat":   policy.FeeBaseMsat,
+		"fee_rate_ppm":    policy.FeeRatePPM,
+		"time_lock_delta": policy.TimeLockDelta,
+		"min_htlc_msat":   policy.MinHTLCMsat,
+		"max_htlc_msat":   policy.MaxHTLCMsat,
+		"disabled":        policy.Disabled,
+	}
+}
+
+// watchlistDisabledError reports that no watchlist is configured.
+func watchlistDisabledError() *mcp.CallToolResult {
+	return toolError(errors.New(errors.ErrCodeUnsupported, "the "+
+		"watchlist is not enabled; set LNC_WATCHLIST_PATH to enable "+
+		"it"))
+}